@@ -22,6 +22,7 @@ import (
 	"syscall"
 
 	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/fileutil/downloadcleanup"
 	"github.com/aws/amazon-ssm-agent/agent/framework/coremanager"
 	logger "github.com/aws/amazon-ssm-agent/agent/log"
 	"github.com/aws/amazon-ssm-agent/agent/version"
@@ -62,6 +63,7 @@ func start(log logger.T, instanceIDPtr *string, regionPtr *string) (cpm *coreman
 		return
 	}
 	cpm.Start()
+	downloadcleanup.Start(log)
 	return
 }
 
@@ -85,6 +87,7 @@ func blockUntilSignaled(log logger.T) {
 func stop(log logger.T, cpm *coremanager.CoreManager) {
 	log.Info("Stopping agent")
 	log.Flush()
+	downloadcleanup.Stop()
 	cpm.Stop()
 	log.Info("Bye.")
 	log.Flush()
@@ -118,14 +118,26 @@ func DefaultConfig() SsmagentConfig {
 		RunCommandLogsRetentionDurationHours:  DefaultRunCommandLogsRetentionDurationHours,
 	}
 	var agent = AgentInfo{
-		Name:                 "amazon-ssm-agent",
-		OrchestrationRootDir: defaultOrchestrationRootDirName,
+		Name:                    "amazon-ssm-agent",
+		OrchestrationRootDir:    defaultOrchestrationRootDirName,
+		FileWriteRetryLimit:     DefaultFileWriteRetryLimit,
+		DownloadBufferSizeKB:    DefaultDownloadBufferSizeKB,
+		MinTLSVersion:           DefaultMinTLSVersion,
+		DownloadMaxFileSizeMB:   DefaultDownloadMaxFileSizeMB,
+		DownloadMaxRedirects:    DefaultDownloadMaxRedirects,
+		DownloadRootMaxAgeHours: DefaultDownloadRootMaxAgeHours,
 	}
 	var os = OsInfo{
 		Lang:    "en-US",
 		Version: "1",
 	}
 	var birdwatcher BirdwatcherCfg
+	var github = GitHubCfg{
+		FetchMaxAttempts:        DefaultGitHubFetchMaxAttempts,
+		RateLimitMaxWaitSeconds: DefaultGitHubRateLimitMaxWaitSeconds,
+		DownloadConcurrency:     DefaultGitHubDownloadConcurrency,
+	}
+	var remoteResource RemoteResourceCfg
 
 	var ssmagentCfg = SsmagentConfig{
 		Profile:     credsProfile,
@@ -135,6 +147,9 @@ func DefaultConfig() SsmagentConfig {
 		Os:          os,
 		S3:          s3,
 		Birdwatcher: birdwatcher,
+		GitHub:      github,
+
+		RemoteResource: remoteResource,
 	}
 
 	return ssmagentCfg
@@ -28,6 +28,30 @@ func parser(config *SsmagentConfig) {
 	config.Agent.Name = getStringValue(config.Agent.Name, DefaultAgentName)
 	config.Agent.OrchestrationRootDir = getStringValue(config.Agent.OrchestrationRootDir, defaultOrchestrationRootDirName)
 	config.Agent.Region = getStringValue(config.Agent.Region, "")
+	config.Agent.FileWriteRetryLimit = getNumericValue(
+		config.Agent.FileWriteRetryLimit,
+		DefaultFileWriteRetryLimitMin,
+		DefaultFileWriteRetryLimitMax,
+		DefaultFileWriteRetryLimit)
+	config.Agent.DownloadBufferSizeKB = getNumericValue(
+		config.Agent.DownloadBufferSizeKB,
+		DefaultDownloadBufferSizeKBMin,
+		DefaultDownloadBufferSizeKBMax,
+		DefaultDownloadBufferSizeKB)
+	config.Agent.MinTLSVersion = getStringValue(config.Agent.MinTLSVersion, DefaultMinTLSVersion)
+	config.Agent.DownloadMaxFileSizeMB = getNumericValueAboveMin(
+		config.Agent.DownloadMaxFileSizeMB,
+		DefaultDownloadMaxFileSizeMBMin,
+		DefaultDownloadMaxFileSizeMB)
+	config.Agent.DownloadMaxRedirects = getNumericValue(
+		config.Agent.DownloadMaxRedirects,
+		DefaultDownloadMaxRedirectsMin,
+		DefaultDownloadMaxRedirectsMax,
+		DefaultDownloadMaxRedirects)
+	config.Agent.DownloadRootMaxAgeHours = getNumericValueAboveMin(
+		config.Agent.DownloadRootMaxAgeHours,
+		DefaultDownloadRootMaxAgeHoursMin,
+		DefaultDownloadRootMaxAgeHours)
 
 	// MDS config
 	config.Mds.CommandWorkersLimit = getNumericValue(
@@ -68,6 +92,22 @@ func parser(config *SsmagentConfig) {
 		DefaultStateOrchestrationLogsRetentionDurationHoursMin,
 		DefaultRunCommandLogsRetentionDurationHours)
 
+	// GitHub config
+	config.GitHub.FetchMaxAttempts = getNumericValue(
+		config.GitHub.FetchMaxAttempts,
+		DefaultGitHubFetchMaxAttemptsMin,
+		DefaultGitHubFetchMaxAttemptsMax,
+		DefaultGitHubFetchMaxAttempts)
+	config.GitHub.RateLimitMaxWaitSeconds = getNumericValue(
+		config.GitHub.RateLimitMaxWaitSeconds,
+		DefaultGitHubRateLimitMaxWaitSecondsMin,
+		DefaultGitHubRateLimitMaxWaitSecondsMax,
+		DefaultGitHubRateLimitMaxWaitSeconds)
+	config.GitHub.DownloadConcurrency = getNumericValue(
+		config.GitHub.DownloadConcurrency,
+		DefaultGitHubDownloadConcurrencyMin,
+		DefaultGitHubDownloadConcurrencyMax,
+		DefaultGitHubDownloadConcurrency)
 }
 
 // TODO https://sim.amazon.com/issues/SSM-3439
@@ -31,6 +31,39 @@ const (
 	DefaultStopTimeoutMillisMin = 10000
 	DefaultStopTimeoutMillisMax = 1000000
 
+	DefaultFileWriteRetryLimit    = 2
+	DefaultFileWriteRetryLimitMin = 0
+	DefaultFileWriteRetryLimitMax = 10
+
+	DefaultDownloadBufferSizeKB    = 256
+	DefaultDownloadBufferSizeKBMin = 4
+	DefaultDownloadBufferSizeKBMax = 16384
+
+	DefaultMinTLSVersion = "1.2"
+
+	DefaultDownloadMaxFileSizeMB    = 10240 // 10 GB
+	DefaultDownloadMaxFileSizeMBMin = 1
+
+	DefaultDownloadMaxRedirects    = 5
+	DefaultDownloadMaxRedirectsMin = 0
+	DefaultDownloadMaxRedirectsMax = 20
+
+	DefaultDownloadRootMaxAgeHours    = 168 // 7 days
+	DefaultDownloadRootMaxAgeHoursMin = 1
+
+	// GitHub defaults
+	DefaultGitHubFetchMaxAttempts    = 3
+	DefaultGitHubFetchMaxAttemptsMin = 1
+	DefaultGitHubFetchMaxAttemptsMax = 10
+
+	DefaultGitHubRateLimitMaxWaitSeconds    = 300
+	DefaultGitHubRateLimitMaxWaitSecondsMin = 1
+	DefaultGitHubRateLimitMaxWaitSecondsMax = 3600
+
+	DefaultGitHubDownloadConcurrency    = 5
+	DefaultGitHubDownloadConcurrencyMin = 1
+	DefaultGitHubDownloadConcurrencyMax = 50
+
 	// SSM defaults
 	DefaultSsmHealthFrequencyMinutes    = 5
 	DefaultSsmHealthFrequencyMinutesMin = 5
@@ -51,6 +51,35 @@ type AgentInfo struct {
 	Region               string
 	OrchestrationRootDir string
 	DownloadRootDir      string
+	// IPFamily restricts download connections to "ipv4" or "ipv6", both are used when empty
+	IPFamily string
+	// ProxyUsername and ProxyPassword authenticate download connections to a proxy whose URL has no credentials
+	ProxyUsername string
+	ProxyPassword string
+	// ClientCertificate and ClientKey are the PEM certificate and private key download connections present to servers
+	// requiring mutual TLS, each as a file path or a {{ssm:name}} or {{ssm-secure:name}} parameter reference
+	ClientCertificate string
+	ClientKey         string
+	// DownloadConcurrencyLimit bounds the downloads in flight across the agent, they are unlimited when it is not positive
+	DownloadConcurrencyLimit int
+	// DownloadRetryBudget is the number of retries each host can take before further retries to it are refused,
+	// every successful call earns back a tenth of a retry. Retries are not budgeted when it is not positive.
+	DownloadRetryBudget int
+	// FileWriteRetryLimit is the number of times a download write is retried after a transient file system error
+	FileWriteRetryLimit int
+	// DownloadBufferSizeKB is the size of the buffer downloaded content is copied through
+	DownloadBufferSizeKB int
+	// MinTLSVersion is the lowest TLS version, "1.0" to "1.3", download connections accept
+	MinTLSVersion string
+	// DownloadMaxFileSizeMB is the size a single downloaded file may not exceed, larger files fail the download
+	DownloadMaxFileSizeMB int
+	// DownloadMaxRedirects is the number of redirects an http/https download follows, 0 refuses any redirect
+	DownloadMaxRedirects int
+	// DownloadSameHostRedirects refuses redirects of http/https downloads to another host or scheme
+	DownloadSameHostRedirects bool
+	// DownloadRootCleanup periodically removes artifacts older than DownloadRootMaxAgeHours from the download directory
+	DownloadRootCleanup     bool
+	DownloadRootMaxAgeHours int
 }
 
 // MfsCfg represents configuration for HummingBird service (MFS)
@@ -78,6 +107,71 @@ type S3Cfg struct {
 // BirdwatcherCfg represents configuration related to ConfigurePackage Birdwatcher integration
 type BirdwatcherCfg struct {
 	ForceEnable bool
+	// EnableFileOverrides applies FileOverrides over package manifests, it is meant for debugging only
+	EnableFileOverrides bool
+	// FileOverrides replaces the source of manifest files by file name
+	FileOverrides map[string]BirdwatcherFileOverride
+	// ManifestOverlays lists, by package name, the packages whose manifests are deep-merged in order over its manifest
+	ManifestOverlays map[string][]string
+	// PlatformDetectionTimeoutSeconds bounds the platform detection, a default is used when it is not positive
+	PlatformDetectionTimeoutSeconds int
+	// ManifestChecksums are the known checksums of package manifests, by package name, version and checksum algorithm,
+	// e.g. taken from a signed index. Manifests with a known checksum are verified before they are parsed.
+	ManifestChecksums map[string]map[string]map[string]string
+	// PlatformSelectionPolicy selects the manifest entries of the exact platform ("exact"), of its family ("family"),
+	// or of the platform and else its family ("exact-then-family", the default)
+	PlatformSelectionPolicy string
+	// MirrorURLs are base URLs package files are downloaded from, in order, when the download from their manifest location fails.
+	// The path of the manifest location is appended to each of them.
+	MirrorURLs []string
+}
+
+// BirdwatcherFileOverride replaces the download location and optionally the checksums of a manifest file
+type BirdwatcherFileOverride struct {
+	DownloadLocation string
+	Checksums        map[string]string
+}
+
+// GitHubCfg represents configuration related to downloading content from GitHub
+type GitHubCfg struct {
+	// DefaultRef is the branch used when a document doesn't specify getOptions
+	DefaultRef string
+	// DeployKeyParameterPrefix is the parameter store path holding deploy keys as <prefix>/<owner>/<repository>
+	DeployKeyParameterPrefix string
+	// DefaultDeployKeyParameter is the deploy key parameter used for repositories without a key of their own
+	DefaultDeployKeyParameter string
+	// MirrorURL is the base URL of a caching mirror of the GitHub API preferred for content fetches
+	MirrorURL string
+	// AllowedRepositories restricts downloads to repositories matching one of these owner/repository globs, all are allowed when empty
+	AllowedRepositories []string
+	// TrustedSigningKeys are the GPG key fingerprints or long key IDs accepted for commits downloaded with requireSignature
+	TrustedSigningKeys []string
+	// SignaturePublicKey is the PEM encoded RSA, ECDSA or Ed25519 public key verifying detached .sig signatures of files
+	// downloaded with verifySignature
+	SignaturePublicKey string
+	// TokenKMSKeyID is the KMS key, as a key ID, alias or ARN, token and deploy key parameters must be encrypted with.
+	// Parameters encrypted with any key are accepted when it is empty.
+	TokenKMSKeyID string
+	// FetchMaxAttempts is how many times a fetch from GitHub is attempted before the download fails,
+	// fetches are only attempted again after a 5xx or 429 answer or a network error
+	FetchMaxAttempts int
+	// RateLimitMaxWaitSeconds bounds the total time a request waits for the GitHub rate limit it exceeded to reset
+	// before it is retried, the request fails when the limit resets later than that
+	RateLimitMaxWaitSeconds int
+	// DownloadConcurrency is the number of files of a directory download fetched at once
+	DownloadConcurrency int
+}
+
+// RemoteResourceCfg represents configuration related to downloaded remote resources
+type RemoteResourceCfg struct {
+	// ResourceTypes maps file extensions (e.g. ".template") to the resource type, Script or Document, of files with that extension
+	ResourceTypes map[string]string
+	// FileOwner and FileGroup, user and group names or numeric IDs, own the downloaded files on Unix, the agent's are kept when empty
+	FileOwner string
+	FileGroup string
+	// AllowedDestinationRoots are the directories, besides the download directory of the command, content may be downloaded to.
+	// Only DownloadRoot is allowed when it is empty.
+	AllowedDestinationRoots []string
 }
 
 // SsmagentConfig stores agent configuration values.
@@ -90,4 +184,7 @@ type SsmagentConfig struct {
 	Os          OsInfo
 	S3          S3Cfg
 	Birdwatcher BirdwatcherCfg
+	GitHub      GitHubCfg
+	// RemoteResource is the configuration of resources fetched by the downloadContent plugin
+	RemoteResource RemoteResourceCfg
 }
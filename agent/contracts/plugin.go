@@ -78,6 +78,8 @@ type Configuration struct {
 	Preconditions           map[string][]string
 	IsPreconditionEnabled   bool
 	CurrentAssociations     []string
+	// Parameters are the parameter values of the document the plugin is part of, once validated and defaulted
+	Parameters map[string]interface{}
 }
 
 // Plugin wraps the plugin configuration and plugin result.
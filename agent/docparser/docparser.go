@@ -78,11 +78,12 @@ func ParseDocument(log log.T,
 	if err = validateSchema(docContent.SchemaVersion); err != nil {
 		return
 	}
-	if err = getValidatedParameters(log, params, docContent); err != nil {
+	validParameters, err := getValidatedParameters(log, params, docContent)
+	if err != nil {
 		return
 	}
 
-	return parseDocumentContent(*docContent, parserInfo)
+	return parseDocumentContent(*docContent, parserInfo, validParameters)
 }
 
 // ParseParameters is a method to parse the ssm parameters into a string map interface
@@ -113,15 +114,15 @@ func ParseParameters(log log.T, params map[string][]*string, paramsDef map[strin
 }
 
 // parseDocumentContent parses an SSM Document and returns the plugin information
-func parseDocumentContent(docContent contracts.DocumentContent, parserInfo DocumentParserInfo) (pluginsInfo []contracts.PluginState, err error) {
+func parseDocumentContent(docContent contracts.DocumentContent, parserInfo DocumentParserInfo, params map[string]interface{}) (pluginsInfo []contracts.PluginState, err error) {
 
 	switch docContent.SchemaVersion {
 	case "1.0", "1.2":
-		return parsePluginStateForV10Schema(docContent, parserInfo.OrchestrationDir, parserInfo.S3Bucket, parserInfo.S3Prefix, parserInfo.MessageId, parserInfo.DocumentId, parserInfo.DefaultWorkingDir)
+		return parsePluginStateForV10Schema(docContent, parserInfo.OrchestrationDir, parserInfo.S3Bucket, parserInfo.S3Prefix, parserInfo.MessageId, parserInfo.DocumentId, parserInfo.DefaultWorkingDir, params)
 
 	case "2.0", "2.0.1", "2.0.2", "2.0.3", "2.2":
 
-		return parsePluginStateForV20Schema(docContent, parserInfo.OrchestrationDir, parserInfo.S3Bucket, parserInfo.S3Prefix, parserInfo.MessageId, parserInfo.DocumentId, parserInfo.DefaultWorkingDir)
+		return parsePluginStateForV20Schema(docContent, parserInfo.OrchestrationDir, parserInfo.S3Bucket, parserInfo.S3Prefix, parserInfo.MessageId, parserInfo.DocumentId, parserInfo.DefaultWorkingDir, params)
 
 	default:
 		return pluginsInfo, fmt.Errorf("Unsupported document")
@@ -131,7 +132,8 @@ func parseDocumentContent(docContent contracts.DocumentContent, parserInfo Docum
 // parsePluginStateForV10Schema initializes pluginsInfo for the docState. Used for document v1.0 and 1.2
 func parsePluginStateForV10Schema(
 	docContent contracts.DocumentContent,
-	orchestrationDir, s3Bucket, s3Prefix, messageID, documentID, defaultWorkingDir string) (pluginsInfo []contracts.PluginState, err error) {
+	orchestrationDir, s3Bucket, s3Prefix, messageID, documentID, defaultWorkingDir string,
+	params map[string]interface{}) (pluginsInfo []contracts.PluginState, err error) {
 
 	if len(docContent.RuntimeConfig) == 0 {
 		return pluginsInfo, fmt.Errorf("Unsupported schema format")
@@ -152,6 +154,7 @@ func parsePluginStateForV10Schema(
 			PluginName:              pluginName,
 			PluginID:                pluginName,
 			DefaultWorkingDirectory: defaultWorkingDir,
+			Parameters:              params,
 		}
 		pluginConfigurations = append(pluginConfigurations, &config)
 	}
@@ -169,7 +172,8 @@ func parsePluginStateForV10Schema(
 // parsePluginStateForV20Schema initializes instancePluginsInfo for the docState. Used by document v2.0.
 func parsePluginStateForV20Schema(
 	docContent contracts.DocumentContent,
-	orchestrationDir, s3Bucket, s3Prefix, messageID, documentID, defaultWorkingDir string) (pluginsInfo []contracts.PluginState, err error) {
+	orchestrationDir, s3Bucket, s3Prefix, messageID, documentID, defaultWorkingDir string,
+	params map[string]interface{}) (pluginsInfo []contracts.PluginState, err error) {
 
 	if len(docContent.MainSteps) == 0 {
 		return pluginsInfo, fmt.Errorf("Unsupported schema format")
@@ -196,6 +200,7 @@ func parsePluginStateForV20Schema(
 			Preconditions:           instancePluginConfig.Preconditions,
 			IsPreconditionEnabled:   isPreconditionEnabled,
 			DefaultWorkingDirectory: defaultWorkingDir,
+			Parameters:              params,
 		}
 
 		var plugin contracts.PluginState
@@ -220,7 +225,8 @@ func validateSchema(documentSchemaVersion string) error {
 }
 
 // getValidatedParameters validates the parameters and modifies the document content by replacing all ssm parameters with their actual values.
-func getValidatedParameters(log log.T, params map[string]interface{}, docContent *contracts.DocumentContent) error {
+// It returns the validated parameters with the defaults of the missing ones.
+func getValidatedParameters(log log.T, params map[string]interface{}, docContent *contracts.DocumentContent) (map[string]interface{}, error) {
 
 	//ValidateParameterNames
 	validParameters := parameters.ValidParameters(log, params)
@@ -235,11 +241,11 @@ func getValidatedParameters(log log.T, params map[string]interface{}, docContent
 	log.Info("Validating SSM parameters")
 	// Validates SSM parameters
 	if err := parameterstore.ValidateSSMParameters(log, docContent.Parameters, validParameters); err != nil {
-		return err
+		return nil, err
 	}
 
 	err := replaceValidatedPluginParameters(docContent, validParameters, log)
-	return err
+	return validParameters, err
 }
 
 // replaceValidatedPluginParameters replaces parameters with their values, within the plugin Properties.
@@ -78,6 +78,19 @@ func TestParseDocument_ValidRuntimeConfig(t *testing.T) {
 	assert.Equal(t, testWorkingDir, pluginInfoTest.Configuration.DefaultWorkingDirectory)
 }
 
+func TestParseDocument_PluginParameters(t *testing.T) {
+	mockLog := log.NewMockLog()
+
+	var testDocContent contracts.DocumentContent
+	err := json.Unmarshal(loadFile(t, "../runcommand/mds/testdata/validcommand20.json"), &testDocContent)
+	assert.NoError(t, err)
+	pluginsInfo, err := ParseDocument(mockLog, &testDocContent, DocumentParserInfo{OrchestrationDir: testOrchDir}, map[string]interface{}{"workDir": "/var/lib/app"})
+
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(pluginsInfo))
+	assert.Equal(t, "/var/lib/app", pluginsInfo[0].Configuration.Parameters["workDir"])
+}
+
 func TestParseDocument_ValidMainSteps(t *testing.T) {
 	mockLog := log.NewMockLog()
 
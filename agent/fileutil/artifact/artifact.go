@@ -15,11 +15,14 @@
 package artifact
 
 import (
+	"compress/gzip"
 	"crypto/md5"
 	"crypto/sha1"
 	"crypto/sha256"
+	"crypto/sha512"
 	"encoding/hex"
 	"fmt"
+	"hash"
 	"io"
 	"net/http"
 	"net/url"
@@ -30,6 +33,7 @@ import (
 	"github.com/aws/amazon-ssm-agent/agent/appconfig"
 	"github.com/aws/amazon-ssm-agent/agent/fileutil"
 	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/aws/amazon-ssm-agent/agent/network"
 	"github.com/aws/amazon-ssm-agent/agent/platform"
 	"github.com/aws/amazon-ssm-agent/agent/s3util"
 	"github.com/aws/amazon-ssm-agent/agent/sdkutil"
@@ -50,10 +54,42 @@ type DownloadInput struct {
 	SourceURL            string
 	DestinationDirectory string
 	SourceChecksums      map[string]string
+	// Headers are added to http/https download requests, a Host header overrides the host sent to the server.
+	// Values may reference parameters as {{ssm:name}} or {{ssm-secure:name}}.
+	Headers map[string]string
+	// BasicAuth authenticates http/https download requests, it can't be combined with an Authorization header
+	BasicAuth *BasicAuth
+}
+
+// redirectLimits returns the number of redirects http/https downloads follow and whether they must stay on the same host and scheme
+var redirectLimits = func() (maxRedirects int, sameHostOnly bool) {
+	if appCfg, err := appconfig.Config(false); err == nil {
+		return appCfg.Agent.DownloadMaxRedirects, appCfg.Agent.DownloadSameHostRedirects
+	}
+	return appconfig.DefaultDownloadMaxRedirects, false
+}
+
+// checkRedirect returns the redirect policy of http/https downloads, which follows up to maxRedirects redirects.
+// When sameHostOnly is set, redirects to another host or scheme than the one originally requested are refused.
+func checkRedirect(maxRedirects int, sameHostOnly bool) func(r *http.Request, via []*http.Request) error {
+	return func(r *http.Request, via []*http.Request) error {
+		if len(via) > maxRedirects {
+			return fmt.Errorf("too many redirects, downloads follow at most %v", maxRedirects)
+		}
+		if sameHostOnly {
+			original := via[0].URL
+			if !strings.EqualFold(r.URL.Scheme, original.Scheme) || !strings.EqualFold(r.URL.Host, original.Host) {
+				return fmt.Errorf("refused redirect from %v://%v to another host or scheme %v://%v",
+					original.Scheme, original.Host, r.URL.Scheme, r.URL.Host)
+			}
+		}
+		r.URL.Opaque = r.URL.Path
+		return nil
+	}
 }
 
 // httpDownload attempts to download a file via http/s call
-func httpDownload(log log.T, fileURL string, destFile string) (output DownloadOutput, err error) {
+func httpDownload(log log.T, fileURL string, headers map[string]string, destFile string) (output DownloadOutput, err error) {
 	log.Debugf("attempting to download as http/https download %v", destFile)
 	eTagFile := destFile + ".etag"
 	var check http.Client
@@ -62,17 +98,20 @@ func httpDownload(log log.T, fileURL string, destFile string) (output DownloadOu
 	if err != nil {
 		return
 	}
+	if len(headers) > 0 {
+		log.Debugf("adding request headers %v", redactHeaders(headers))
+		setHeaders(request, headers)
+	}
 	if fileutil.Exists(destFile) == true && fileutil.Exists(eTagFile) == true {
 		var existingETag string
 		existingETag, err = fileutil.ReadAllText(eTagFile)
 		request.Header.Add("If-None-Match", existingETag)
 	}
 
+	maxRedirects, sameHostOnly := redirectLimits()
 	check = http.Client{
-		CheckRedirect: func(r *http.Request, via []*http.Request) error {
-			r.URL.Opaque = r.URL.Path
-			return nil
-		},
+		Transport:     network.DefaultTransport(),
+		CheckRedirect: checkRedirect(maxRedirects, sameHostOnly),
 	}
 
 	var resp *http.Response
@@ -81,6 +120,7 @@ func httpDownload(log log.T, fileURL string, destFile string) (output DownloadOu
 		log.Debug("failed to download from http/https, ", err)
 		fileutil.DeleteFile(destFile)
 		fileutil.DeleteFile(eTagFile)
+		err = network.ClassifyError(err)
 		return
 	}
 
@@ -106,16 +146,44 @@ func httpDownload(log log.T, fileURL string, destFile string) (output DownloadOu
 			return
 		}
 	}
-	_, err = FileCopy(log, destFile, resp.Body)
+	if err = CheckDeclaredSize(fileURL, resp.ContentLength, maxFileSize()); err != nil {
+		fileutil.DeleteFile(eTagFile)
+		return
+	}
+	var body io.Reader
+	if body, err = responseBody(log, resp); err != nil {
+		log.Errorf("failed to decompress response for %v, %v", destFile, err)
+		return
+	}
+	_, err = FileCopy(log, destFile, LimitReader(body, fileURL, maxFileSize()))
 	if err == nil {
 		output.LocalFilePath = destFile
 		output.IsUpdated = true
 	} else {
 		log.Errorf("failed to write destFile %v, %v ", destFile, err)
+		deleteTooLargeFile(err, destFile, eTagFile)
 	}
 	return
 }
 
+// deleteTooLargeFile deletes the partial download of a file, and its etag, if it failed for exceeding the download size limit
+func deleteTooLargeFile(err error, destFile string, eTagFile string) {
+	if _, tooLarge := err.(*FileTooLargeError); tooLarge {
+		fileutil.DeleteFile(destFile)
+		fileutil.DeleteFile(eTagFile)
+	}
+}
+
+// responseBody returns the body of a http response, decompressing it when it is gzip encoded.
+// The transport only decompresses responses transparently when it added the Accept-Encoding header itself.
+func responseBody(log log.T, resp *http.Response) (io.Reader, error) {
+	if resp.Uncompressed || !strings.EqualFold(resp.Header.Get("Content-Encoding"), "gzip") {
+		return resp.Body, nil
+	}
+	log.Debug("response is gzip encoded, decompressing it")
+	return gzip.NewReader(resp.Body)
+}
+
 // awsConfig creates a config and sets region and credential information given an S3 URL
 func awsConfig(log log.T, amazonS3URL s3util.AmazonS3URL) (config *aws.Config, err error) {
 	config = sdkutil.AwsConfig()
@@ -260,6 +328,7 @@ func s3Download(log log.T, amazonS3URL s3util.AmazonS3URL, destFile string) (out
 			log.Debug("failed to download from s3, ", err)
 			fileutil.DeleteFile(destFile)
 			fileutil.DeleteFile(eTagFile)
+			err = network.ClassifyError(err)
 			return
 		}
 
@@ -269,6 +338,11 @@ func s3Download(log log.T, amazonS3URL s3util.AmazonS3URL, destFile string) (out
 		return output, nil
 	}
 
+	if err = CheckDeclaredSize(amazonS3URL.String(), aws.Int64Value(resp.ContentLength), maxFileSize()); err != nil {
+		resp.Body.Close()
+		return
+	}
+
 	if *resp.ETag != "" {
 		log.Debug("files etag is ", *resp.ETag)
 		err = fileutil.WriteAllText(eTagFile, *resp.ETag)
@@ -279,12 +353,13 @@ func s3Download(log log.T, amazonS3URL s3util.AmazonS3URL, destFile string) (out
 	}
 
 	defer resp.Body.Close()
-	_, err = FileCopy(log, destFile, resp.Body)
+	_, err = FileCopy(log, destFile, LimitReader(resp.Body, amazonS3URL.String(), maxFileSize()))
 	if err == nil {
 		output.LocalFilePath = destFile
 		output.IsUpdated = true
 	} else {
 		log.Errorf("failed to write destFile %v, %v ", destFile, err)
+		deleteTooLargeFile(err, destFile, eTagFile)
 	}
 	return
 }
@@ -300,13 +375,17 @@ func FileCopy(log log.T, destinationPath string, src io.Reader) (written int64,
 	}
 	defer file.Close()
 	var size int64
-	size, err = io.Copy(file, src)
+	size, err = CopyBuffered(file, src)
 	log.Infof("%s with %v bytes downloaded", destinationPath, size)
 	return
 }
 
 // Download is a generic utility which attempts to download smartly.
 func Download(log log.T, input DownloadInput) (output DownloadOutput, err error) {
+	if err = ValidateAuthorization(input); err != nil {
+		return
+	}
+
 	// parse the url
 	var fileURL *url.URL
 	fileURL, err = url.Parse(input.SourceURL)
@@ -343,12 +422,25 @@ func Download(log log.T, input DownloadInput) (output DownloadOutput, err error)
 		output.IsHashMatched, err = VerifyHash(log, input, output)
 	} else {
 		err = fmt.Errorf("source file wasn't found locally, will attempt as web download. %v", input.SourceURL)
+		limiter := network.SharedDownloadLimiter()
+		limiter.Acquire()
+		defer limiter.Release()
 		// compute the local filename which is hash of url_filename
 		// Generating a hash_filename will also help against attackers
 		// from specifying a directory and filename to overwrite any ami/built-in files.
 		urlHash := sha1.Sum([]byte(fileURL.String()))
 		output.LocalFilePath = filepath.Join(destinationDir, fmt.Sprintf("%x", urlHash))
 
+		var headers map[string]string
+		if headers, err = ResolveHeaders(log, input.Headers); err != nil {
+			return
+		}
+		if input.BasicAuth != nil {
+			if headers, err = withBasicAuthorization(log, headers, input.BasicAuth); err != nil {
+				return
+			}
+		}
+
 		amazonS3URL := s3util.ParseAmazonS3URL(log, fileURL)
 		if amazonS3URL.IsBucketAndKeyPresent() {
 			// source is s3
@@ -356,12 +448,12 @@ func Download(log log.T, input DownloadInput) (output DownloadOutput, err error)
 			tempOutput, err = s3Download(log, amazonS3URL, output.LocalFilePath)
 			// if s3 download fails, attempt http/https download as fallback
 			if err != nil {
-				tempOutput, err = httpDownload(log, input.SourceURL, output.LocalFilePath)
+				tempOutput, err = httpDownload(log, input.SourceURL, headers, output.LocalFilePath)
 			}
 			output = tempOutput
 		} else {
 			// simple http/https download
-			output, err = httpDownload(log, input.SourceURL, output.LocalFilePath)
+			output, err = httpDownload(log, input.SourceURL, headers, output.LocalFilePath)
 		}
 
 		if err != nil {
@@ -399,17 +491,10 @@ func VerifyHash(log log.T, input DownloadInput, output DownloadOutput) (bool, er
 	}
 
 	for hashAlgorithm, hashValue := range checksums {
-		var computedHashValue string
-		var err error
-		// check the sha256 algorithm by default
-		if hashAlgorithm == "" || strings.EqualFold(hashAlgorithm, "sha256") {
-			computedHashValue, err = Sha256HashValue(log, output.LocalFilePath)
-		} else if strings.EqualFold(hashAlgorithm, "md5") {
-			computedHashValue, err = Md5HashValue(log, output.LocalFilePath)
-		} else {
-			continue
+		if _, err := NewHash(hashAlgorithm); err != nil {
+			return false, err
 		}
-
+		computedHashValue, err := HashValue(log, hashAlgorithm, output.LocalFilePath)
 		if err != nil {
 			return false, fmt.Errorf("the algorithm returned an error when trying to compute the checksum %v", input)
 		}
@@ -429,31 +514,59 @@ func VerifyHash(log log.T, input DownloadInput, output DownloadOutput) (bool, er
 	return true, nil
 }
 
-// Sha256HashValue gets the sha256 hash value
-func Sha256HashValue(log log.T, filePath string) (hash string, err error) {
-	var exists = false
-	exists, err = fileutil.LocalFileExist(filePath)
-	if err != nil || exists == false {
-		return
+// checksumAlgorithms are the supported checksum algorithms, strongest first
+var checksumAlgorithms = []string{"sha512", "sha256", "sha1", "md5"}
+
+// NewHash returns a hash computing checksums of the given algorithm, sha256 when no algorithm is given
+func NewHash(algorithm string) (hash.Hash, error) {
+	switch strings.ToLower(algorithm) {
+	case "", "sha256":
+		return sha256.New(), nil
+	case "sha1":
+		return sha1.New(), nil
+	case "sha512":
+		return sha512.New(), nil
+	case "md5":
+		return md5.New(), nil
+	default:
+		return nil, fmt.Errorf("unsupported checksum algorithm %v", algorithm)
 	}
+}
 
-	var f *os.File
-	f, err = os.Open(filePath)
+// Checksum computes the hex checksum of data with the given algorithm
+func Checksum(algorithm string, data []byte) (string, error) {
+	hasher, err := NewHash(algorithm)
 	if err != nil {
-		log.Error(err)
+		return "", err
 	}
-	defer f.Close()
-	hasher := sha256.New()
-	if _, err = io.Copy(hasher, f); err != nil {
-		log.Error(err)
+	hasher.Write(data)
+	return hex.EncodeToString(hasher.Sum(nil)), nil
+}
+
+// PreferredChecksum picks the strongest supported algorithm among checksums and its value.
+// When none of the algorithms is supported one of them is returned, so that using it fails.
+func PreferredChecksum(checksums map[string]string) (algorithm string, value string) {
+	for _, supported := range checksumAlgorithms {
+		for candidate, candidateValue := range checksums {
+			if strings.EqualFold(candidate, supported) && candidateValue != "" {
+				return candidate, candidateValue
+			}
+		}
 	}
-	hash = hex.EncodeToString(hasher.Sum(nil))
-	log.Debugf("Hash=%v, FilePath=%v", hash, filePath)
-	return
+	for candidate, candidateValue := range checksums {
+		if candidateValue != "" {
+			return candidate, candidateValue
+		}
+	}
+	return "", ""
 }
 
-// Md5HashValue gets the md5 hash value
-func Md5HashValue(log log.T, filePath string) (hash string, err error) {
+// HashValue gets the hash value of a file with the given algorithm
+func HashValue(log log.T, algorithm string, filePath string) (hash string, err error) {
+	hasher, err := NewHash(algorithm)
+	if err != nil {
+		return
+	}
 	var exists = false
 	exists, err = fileutil.LocalFileExist(filePath)
 	if err != nil || exists == false {
@@ -466,7 +579,6 @@ func Md5HashValue(log log.T, filePath string) (hash string, err error) {
 		log.Error(err)
 	}
 	defer f.Close()
-	hasher := md5.New()
 	if _, err = io.Copy(hasher, f); err != nil {
 		log.Error(err)
 	}
@@ -474,3 +586,13 @@ func Md5HashValue(log log.T, filePath string) (hash string, err error) {
 	log.Debugf("Hash=%v, FilePath=%v", hash, filePath)
 	return
 }
+
+// Sha256HashValue gets the sha256 hash value
+func Sha256HashValue(log log.T, filePath string) (hash string, err error) {
+	return HashValue(log, "sha256", filePath)
+}
+
+// Md5HashValue gets the md5 hash value
+func Md5HashValue(log log.T, filePath string) (hash string, err error) {
+	return HashValue(log, "md5", filePath)
+}
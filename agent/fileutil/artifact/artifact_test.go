@@ -0,0 +1,456 @@
+// Copyright 2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package artifact
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/aws/amazon-ssm-agent/agent/network"
+	"github.com/stretchr/testify/assert"
+)
+
+var logger = log.NewMockLog()
+
+func gzipContent(content string) []byte {
+	var buf bytes.Buffer
+	writer := gzip.NewWriter(&buf)
+	writer.Write([]byte(content))
+	writer.Close()
+	return buf.Bytes()
+}
+
+func TestResponseBody_Gzip(t *testing.T) {
+	resp := &http.Response{
+		Header: http.Header{"Content-Encoding": []string{"gzip"}},
+		Body:   ioutil.NopCloser(bytes.NewReader(gzipContent("script content"))),
+	}
+
+	body, err := responseBody(logger, resp)
+	assert.NoError(t, err)
+	content, err := ioutil.ReadAll(body)
+	assert.NoError(t, err)
+	assert.Equal(t, "script content", string(content))
+}
+
+func TestResponseBody_AlreadyDecompressed(t *testing.T) {
+	resp := &http.Response{
+		Header:       http.Header{},
+		Body:         ioutil.NopCloser(bytes.NewReader([]byte("script content"))),
+		Uncompressed: true,
+	}
+
+	body, err := responseBody(logger, resp)
+	assert.NoError(t, err)
+	content, _ := ioutil.ReadAll(body)
+	assert.Equal(t, "script content", string(content))
+}
+
+func TestResponseBody_Identity(t *testing.T) {
+	resp := &http.Response{
+		Header: http.Header{},
+		Body:   ioutil.NopCloser(bytes.NewReader([]byte("plain content"))),
+	}
+
+	body, err := responseBody(logger, resp)
+	assert.NoError(t, err)
+	content, _ := ioutil.ReadAll(body)
+	assert.Equal(t, "plain content", string(content))
+}
+
+func TestResponseBody_InvalidGzip(t *testing.T) {
+	resp := &http.Response{
+		Header: http.Header{"Content-Encoding": []string{"gzip"}},
+		Body:   ioutil.NopCloser(bytes.NewReader([]byte("not gzip"))),
+	}
+
+	_, err := responseBody(logger, resp)
+	assert.Error(t, err)
+}
+
+func TestHttpDownload_Gzip(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Encoding", "gzip")
+		w.Write(gzipContent("script content"))
+	}))
+	defer server.Close()
+
+	dir, _ := ioutil.TempDir("", "artifact")
+	defer os.RemoveAll(dir)
+	destFile := filepath.Join(dir, "file")
+
+	output, err := httpDownload(logger, server.URL, nil, destFile)
+
+	assert.NoError(t, err)
+	assert.Equal(t, destFile, output.LocalFilePath)
+	content, _ := ioutil.ReadFile(destFile)
+	assert.Equal(t, "script content", string(content))
+}
+
+func TestHttpDownload_Headers(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "secret-key", r.Header.Get("X-Api-Key"))
+		assert.Equal(t, "artifacts.internal", r.Host)
+		w.Write([]byte("content"))
+	}))
+	defer server.Close()
+
+	dir, _ := ioutil.TempDir("", "artifact")
+	defer os.RemoveAll(dir)
+	destFile := filepath.Join(dir, "file")
+
+	_, err := httpDownload(logger, server.URL, map[string]string{"X-Api-Key": "secret-key", "Host": "artifacts.internal"}, destFile)
+
+	assert.NoError(t, err)
+	content, _ := ioutil.ReadFile(destFile)
+	assert.Equal(t, "content", string(content))
+}
+
+func TestHttpDownload_Redirects(t *testing.T) {
+	otherHost := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("other host content"))
+	}))
+	defer otherHost.Close()
+	// /hops/N redirects N more times before serving the content, /elsewhere redirects to another host
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/elsewhere":
+			http.Redirect(w, r, otherHost.URL+"/file", http.StatusFound)
+		case r.URL.Path == "/hops/0":
+			w.Write([]byte("content"))
+		default:
+			var hops int
+			fmt.Sscanf(r.URL.Path, "/hops/%d", &hops)
+			http.Redirect(w, r, fmt.Sprintf("/hops/%d", hops-1), http.StatusFound)
+		}
+	}))
+	defer server.Close()
+
+	data := []struct {
+		name            string
+		path            string
+		maxRedirects    int
+		sameHostOnly    bool
+		expectedContent string
+		expectedErr     string
+	}{
+		{"redirect chain within the limit", "/hops/3", 3, false, "content", ""},
+		{"redirect chain over the limit", "/hops/4", 3, false, "", "too many redirects, downloads follow at most 3"},
+		{"no redirect allowed", "/hops/1", 0, false, "", "too many redirects, downloads follow at most 0"},
+		{"cross-host redirect allowed", "/elsewhere", 3, false, "other host content", ""},
+		{"cross-host redirect refused", "/elsewhere", 3, true, "", "refused redirect"},
+		{"same-host redirect with the restriction", "/hops/2", 3, true, "content", ""},
+	}
+	defer func(original func() (int, bool)) { redirectLimits = original }(redirectLimits)
+
+	for _, testdata := range data {
+		t.Run(testdata.name, func(t *testing.T) {
+			redirectLimits = func() (int, bool) { return testdata.maxRedirects, testdata.sameHostOnly }
+			dir, _ := ioutil.TempDir("", "artifact")
+			defer os.RemoveAll(dir)
+			destFile := filepath.Join(dir, "file")
+
+			_, err := httpDownload(logger, server.URL+testdata.path, nil, destFile)
+
+			if testdata.expectedErr != "" {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), testdata.expectedErr)
+				return
+			}
+			assert.NoError(t, err)
+			content, _ := ioutil.ReadFile(destFile)
+			assert.Equal(t, testdata.expectedContent, string(content))
+		})
+	}
+}
+
+func TestHttpDownload_DeclaredSizeOverLimit(t *testing.T) {
+	defer func(original func() int64) { maxFileSize = original }(maxFileSize)
+	maxFileSize = func() int64 { return 1024 }
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(bytes.Repeat([]byte("x"), 2048))
+	}))
+	defer server.Close()
+
+	dir, _ := ioutil.TempDir("", "artifact")
+	defer os.RemoveAll(dir)
+	destFile := filepath.Join(dir, "file")
+
+	_, err := httpDownload(logger, server.URL, nil, destFile)
+
+	assert.EqualError(t, err, server.URL+" is 2048 bytes, larger than the download limit of 1024 bytes")
+	_, statErr := os.Stat(destFile)
+	assert.True(t, os.IsNotExist(statErr))
+}
+
+func TestHttpDownload_StreamedOverLimit(t *testing.T) {
+	defer func(original func() int64) { maxFileSize = original }(maxFileSize)
+	maxFileSize = func() int64 { return 1024 }
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// flushing before the whole body is written sends it chunked, without a Content-Length
+		w.Write(bytes.Repeat([]byte("x"), 1000))
+		w.(http.Flusher).Flush()
+		w.Write(bytes.Repeat([]byte("x"), 1000))
+	}))
+	defer server.Close()
+
+	dir, _ := ioutil.TempDir("", "artifact")
+	defer os.RemoveAll(dir)
+	destFile := filepath.Join(dir, "file")
+
+	_, err := httpDownload(logger, server.URL, nil, destFile)
+
+	assert.EqualError(t, err, server.URL+" exceeded the download limit of 1024 bytes")
+	_, statErr := os.Stat(destFile)
+	assert.True(t, os.IsNotExist(statErr), "the partial download is deleted")
+}
+
+func TestHttpDownload_ClassifiesNetworkFailure(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	closedURL := "http://" + listener.Addr().String() + "/file"
+	listener.Close()
+	dir, _ := ioutil.TempDir("", "artifact")
+	defer os.RemoveAll(dir)
+
+	_, err = httpDownload(logger, closedURL, nil, filepath.Join(dir, "file"))
+
+	assert.Error(t, err)
+	assert.IsType(t, &network.Error{}, err)
+	assert.Contains(t, err.Error(), network.FailureConnectionRefused+": ")
+}
+
+func TestLimitReader(t *testing.T) {
+	content, err := ioutil.ReadAll(LimitReader(strings.NewReader("exactly"), "file", 7))
+	assert.NoError(t, err)
+	assert.Equal(t, "exactly", string(content))
+
+	content, err = ioutil.ReadAll(LimitReader(strings.NewReader("too long"), "file", 7))
+	assert.EqualError(t, err, "file exceeded the download limit of 7 bytes")
+	assert.Equal(t, "too lon", string(content))
+
+	assert.NoError(t, CheckDeclaredSize("file", -1, 7))
+	assert.NoError(t, CheckDeclaredSize("file", 8, 0))
+}
+
+func TestResolveHeaders(t *testing.T) {
+	defer func(original func(log.T, string) (string, error)) { resolveParameters = original }(resolveParameters)
+	var resolvedTexts []string
+	resolveParameters = func(log log.T, text string) (string, error) {
+		resolvedTexts = append(resolvedTexts, text)
+		return strings.Replace(text, "{{ssm-secure:/artifacts/apikey}}", "secret-key", -1), nil
+	}
+
+	headers, err := ResolveHeaders(logger, map[string]string{
+		"Host":      "artifacts.internal",
+		"X-Api-Key": "{{ssm-secure:/artifacts/apikey}}",
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"Host": "artifacts.internal", "X-Api-Key": "secret-key"}, headers)
+	assert.Equal(t, []string{"{{ssm-secure:/artifacts/apikey}}"}, resolvedTexts)
+}
+
+func TestResolveHeadersFails(t *testing.T) {
+	defer func(original func(log.T, string) (string, error)) { resolveParameters = original }(resolveParameters)
+	resolveParameters = func(log log.T, text string) (string, error) {
+		return text, errors.New("parameter not found")
+	}
+
+	_, err := ResolveHeaders(logger, map[string]string{"X-Api-Key": "{{ssm:/artifacts/missing}}"})
+
+	assert.EqualError(t, err, "failed to resolve the value of header X-Api-Key. parameter not found")
+}
+
+func TestWithBasicAuthorization(t *testing.T) {
+	defer func(original func(log.T, string) (string, error)) { resolveParameters = original }(resolveParameters)
+	var resolvedTexts []string
+	resolveParameters = func(log log.T, text string) (string, error) {
+		resolvedTexts = append(resolvedTexts, text)
+		return strings.Replace(text, "{{ssm-secure:/artifacts/password}}", "p@ss:word", -1), nil
+	}
+	headers := map[string]string{"Host": "artifacts.internal"}
+
+	authorized, err := withBasicAuthorization(logger, headers, &BasicAuth{Username: "deploy", Password: "{{ssm-secure:/artifacts/password}}"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"Host":          "artifacts.internal",
+		"Authorization": "Basic " + base64.StdEncoding.EncodeToString([]byte("deploy:p@ss:word")),
+	}, authorized)
+	assert.Equal(t, map[string]string{"Host": "artifacts.internal"}, headers)
+	assert.Equal(t, []string{"{{ssm-secure:/artifacts/password}}"}, resolvedTexts)
+	assert.NotContains(t, redactHeaders(authorized), base64.StdEncoding.EncodeToString([]byte("deploy:p@ss:word")))
+
+	resolveParameters = func(log log.T, text string) (string, error) {
+		return text, errors.New("parameter not found")
+	}
+	_, err = withBasicAuthorization(logger, nil, &BasicAuth{Username: "deploy", Password: "{{ssm-secure:/artifacts/missing}}"})
+	assert.EqualError(t, err, "failed to resolve the basic auth password. parameter not found")
+}
+
+func TestValidateAuthorization(t *testing.T) {
+	data := []struct {
+		name        string
+		input       DownloadInput
+		expectedErr string
+	}{
+		{"no authentication", DownloadInput{}, ""},
+		{"bearer token", DownloadInput{Headers: map[string]string{"Authorization": "Bearer token"}}, ""},
+		{"basic auth", DownloadInput{Headers: map[string]string{"X-Api-Key": "key"}, BasicAuth: &BasicAuth{Username: "deploy"}}, ""},
+		{"basic auth and bearer token", DownloadInput{Headers: map[string]string{"authorization": "Bearer token"}, BasicAuth: &BasicAuth{Username: "deploy"}},
+			"basic auth can't be combined with an Authorization header"},
+		{"basic auth without username", DownloadInput{BasicAuth: &BasicAuth{Password: "secret"}}, "basic auth requires a username"},
+	}
+	for _, testdata := range data {
+		t.Run(testdata.name, func(t *testing.T) {
+			err := ValidateAuthorization(testdata.input)
+			if testdata.expectedErr == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, testdata.expectedErr)
+			}
+		})
+	}
+}
+
+func TestDownload_BasicAuth(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		username, password, ok := r.BasicAuth()
+		if !ok || username != "deploy" || password != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte("content"))
+	}))
+	defer server.Close()
+	dir, _ := ioutil.TempDir("", "artifact")
+	defer os.RemoveAll(dir)
+
+	output, err := Download(logger, DownloadInput{
+		SourceURL:            server.URL + "/file",
+		DestinationDirectory: dir,
+		BasicAuth:            &BasicAuth{Username: "deploy", Password: "secret"},
+	})
+	assert.NoError(t, err)
+	content, _ := ioutil.ReadFile(output.LocalFilePath)
+	assert.Equal(t, "content", string(content))
+
+	_, err = Download(logger, DownloadInput{
+		SourceURL:            server.URL + "/file",
+		DestinationDirectory: dir,
+		Headers:              map[string]string{"Authorization": "Bearer token"},
+		BasicAuth:            &BasicAuth{Username: "deploy", Password: "secret"},
+	})
+	assert.EqualError(t, err, "basic auth can't be combined with an Authorization header")
+}
+
+func TestRedactHeaders(t *testing.T) {
+	redacted := redactHeaders(map[string]string{
+		"Host":          "artifacts.internal",
+		"X-Api-Key":     "secret-key",
+		"Authorization": "Bearer token",
+	})
+
+	assert.Equal(t, "[Authorization: <redacted>, Host: artifacts.internal, X-Api-Key: <redacted>]", redacted)
+	assert.NotContains(t, redacted, "secret-key")
+}
+
+func TestChecksum(t *testing.T) {
+	data := []struct {
+		algorithm string
+		expected  string
+	}{
+		{"sha1", "040f06fd774092478d450774f5ba30c5da78acc8"},
+		{"sha256", "ed7002b439e9ac845f22357d822bac1444730fbdb6016d3ec9432297b9ec9f73"},
+		{"SHA256", "ed7002b439e9ac845f22357d822bac1444730fbdb6016d3ec9432297b9ec9f73"},
+		{"", "ed7002b439e9ac845f22357d822bac1444730fbdb6016d3ec9432297b9ec9f73"},
+		{"sha512", "b2d1d285b5199c85f988d03649c37e44fd3dde01e5d69c50fef90651962f48110e9340b60d49a479c4c0b53f5f07d690686dd87d2481937a512e8b85ee7c617f"},
+	}
+	for _, testdata := range data {
+		t.Run(testdata.algorithm, func(t *testing.T) {
+			checksum, err := Checksum(testdata.algorithm, []byte("content"))
+
+			assert.NoError(t, err)
+			assert.Equal(t, testdata.expected, checksum)
+		})
+	}
+
+	_, err := Checksum("sha384", []byte("content"))
+	assert.EqualError(t, err, "unsupported checksum algorithm sha384")
+}
+
+func TestPreferredChecksum(t *testing.T) {
+	data := []struct {
+		name              string
+		checksums         map[string]string
+		expectedAlgorithm string
+		expectedValue     string
+	}{
+		{"none", map[string]string{}, "", ""},
+		{"sha256 only", map[string]string{"sha256": "a"}, "sha256", "a"},
+		{"strongest first", map[string]string{"sha1": "a", "SHA512": "b", "sha256": "c"}, "SHA512", "b"},
+		{"empty value skipped", map[string]string{"sha512": "", "sha1": "a"}, "sha1", "a"},
+		{"unsupported only", map[string]string{"sha384": "a"}, "sha384", "a"},
+	}
+	for _, testdata := range data {
+		t.Run(testdata.name, func(t *testing.T) {
+			algorithm, value := PreferredChecksum(testdata.checksums)
+
+			assert.Equal(t, testdata.expectedAlgorithm, algorithm)
+			assert.Equal(t, testdata.expectedValue, value)
+		})
+	}
+}
+
+func TestVerifyHash(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "artifact")
+	defer os.RemoveAll(dir)
+	localFile := filepath.Join(dir, "file")
+	ioutil.WriteFile(localFile, []byte("content"), 0600)
+	output := DownloadOutput{LocalFilePath: localFile}
+
+	data := []struct {
+		name      string
+		checksums map[string]string
+		matched   bool
+	}{
+		{"sha1", map[string]string{"sha1": "040F06FD774092478D450774F5BA30C5DA78ACC8"}, true},
+		{"sha256", map[string]string{"sha256": "ed7002b439e9ac845f22357d822bac1444730fbdb6016d3ec9432297b9ec9f73"}, true},
+		{"sha512", map[string]string{"sha512": "b2d1d285b5199c85f988d03649c37e44fd3dde01e5d69c50fef90651962f48110e9340b60d49a479c4c0b53f5f07d690686dd87d2481937a512e8b85ee7c617f"}, true},
+		{"mismatch", map[string]string{"sha256": "0000"}, false},
+		{"unsupported algorithm", map[string]string{"sha256": "ed7002b439e9ac845f22357d822bac1444730fbdb6016d3ec9432297b9ec9f73", "crc32": "0000"}, false},
+	}
+	for _, testdata := range data {
+		t.Run(testdata.name, func(t *testing.T) {
+			matched, err := VerifyHash(logger, DownloadInput{SourceChecksums: testdata.checksums}, output)
+
+			assert.Equal(t, testdata.matched, matched)
+			assert.Equal(t, !testdata.matched, err != nil)
+		})
+	}
+}
@@ -0,0 +1,49 @@
+// Copyright 2016 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package artifact
+
+import (
+	"io"
+	"sync"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+)
+
+var (
+	copyBufferSizeOnce sync.Once
+	copyBufferSize     int
+)
+
+// CopyBufferSize returns the size in bytes of the buffer downloads are copied through, as configured in appconfig
+func CopyBufferSize() int {
+	copyBufferSizeOnce.Do(func() {
+		sizeKB := appconfig.DefaultDownloadBufferSizeKB
+		if appCfg, err := appconfig.Config(false); err == nil {
+			sizeKB = appCfg.Agent.DownloadBufferSizeKB
+		}
+		copyBufferSize = sizeKB * 1024
+	})
+	return copyBufferSize
+}
+
+// CopyBuffered copies src to dst through a buffer of the configured download buffer size
+func CopyBuffered(dst io.Writer, src io.Reader) (int64, error) {
+	return copyWithBuffer(dst, src, CopyBufferSize())
+}
+
+// copyWithBuffer copies src to dst through a buffer of the given size.
+// ReaderFrom and WriterTo are hidden from io.CopyBuffer, they would otherwise copy through their own 32KB buffer.
+func copyWithBuffer(dst io.Writer, src io.Reader, size int) (int64, error) {
+	return io.CopyBuffer(struct{ io.Writer }{dst}, struct{ io.Reader }{src}, make([]byte, size))
+}
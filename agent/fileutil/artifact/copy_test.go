@@ -0,0 +1,55 @@
+// Copyright 2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package artifact
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCopyBuffered(t *testing.T) {
+	content := bytes.Repeat([]byte("0123456789"), 100000)
+	var dst bytes.Buffer
+
+	written, err := CopyBuffered(&dst, bytes.NewReader(content))
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(len(content)), written)
+	assert.Equal(t, content, dst.Bytes())
+}
+
+func TestCopyBufferSizeDefault(t *testing.T) {
+	assert.True(t, CopyBufferSize() >= 4*1024)
+}
+
+// BenchmarkCopyWithBuffer copies a large in-memory source through exponentially growing buffer sizes,
+// showing the throughput gained over the 32KB buffer of io.Copy
+func BenchmarkCopyWithBuffer(b *testing.B) {
+	content := make([]byte, 256*1024*1024)
+	for size := 32 * 1024; size <= 4*1024*1024; size *= 2 {
+		b.Run(fmt.Sprintf("%vKB", size/1024), func(b *testing.B) {
+			b.SetBytes(int64(len(content)))
+			for i := 0; i < b.N; i++ {
+				if _, err := copyWithBuffer(ioutil.Discard, bytes.NewReader(content), size); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,136 @@
+// Copyright 2016 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package artifact
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/aws/amazon-ssm-agent/agent/ssmparameterresolver"
+)
+
+// nonSensitiveHeaders are the lower case names of headers whose values are logged, the values of all others are redacted
+var nonSensitiveHeaders = map[string]bool{
+	"accept":          true,
+	"accept-encoding": true,
+	"accept-language": true,
+	"cache-control":   true,
+	"content-type":    true,
+	"host":            true,
+	"user-agent":      true,
+}
+
+// resolveParameters replaces the parameter references in text with their values, it is a seam for tests
+var resolveParameters = func(log log.T, text string) (string, error) {
+	service := ssmparameterresolver.NewService()
+	return ssmparameterresolver.ResolveParametersInText(&service, log, text, ssmparameterresolver.ResolveOptions{IgnoreSecureParameters: false})
+}
+
+// ResolveHeaders returns the headers with the {{ssm:name}} and {{ssm-secure:name}} references in their values
+// replaced by the values of the parameters
+func ResolveHeaders(log log.T, headers map[string]string) (map[string]string, error) {
+	if len(headers) == 0 {
+		return headers, nil
+	}
+	resolved := make(map[string]string, len(headers))
+	for name, value := range headers {
+		// NOTE: Do not log the resolved value
+		resolvedValue, err := resolveReferences(log, value)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve the value of header %v. %v", name, err)
+		}
+		resolved[name] = resolvedValue
+	}
+	return resolved, nil
+}
+
+// resolveReferences returns the text with its parameter references replaced, text without references is returned as is
+func resolveReferences(log log.T, text string) (string, error) {
+	if !strings.Contains(text, "{{") {
+		return text, nil
+	}
+	return resolveParameters(log, text)
+}
+
+// BasicAuth are the credentials of http/https downloads from servers requiring basic authentication.
+// Username and Password may reference parameters as {{ssm:name}} or {{ssm-secure:name}}.
+type BasicAuth struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+// ValidateAuthorization ensures the download authenticates one way only, with basic auth or an Authorization header such as a bearer token
+func ValidateAuthorization(input DownloadInput) error {
+	if input.BasicAuth == nil {
+		return nil
+	}
+	if input.BasicAuth.Username == "" {
+		return errors.New("basic auth requires a username")
+	}
+	for name := range input.Headers {
+		if strings.EqualFold(name, "Authorization") {
+			return errors.New("basic auth can't be combined with an Authorization header")
+		}
+	}
+	return nil
+}
+
+// withBasicAuthorization returns a copy of the headers with the Authorization header of the basic auth credentials
+func withBasicAuthorization(log log.T, headers map[string]string, auth *BasicAuth) (map[string]string, error) {
+	// NOTE: Do not log the resolved credentials
+	username, err := resolveReferences(log, auth.Username)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve the basic auth username. %v", err)
+	}
+	password, err := resolveReferences(log, auth.Password)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve the basic auth password. %v", err)
+	}
+
+	authorized := make(map[string]string, len(headers)+1)
+	for name, value := range headers {
+		authorized[name] = value
+	}
+	authorized["Authorization"] = "Basic " + base64.StdEncoding.EncodeToString([]byte(username+":"+password))
+	return authorized, nil
+}
+
+// setHeaders adds the headers to the request, Host replaces the host of the request URL
+func setHeaders(request *http.Request, headers map[string]string) {
+	for name, value := range headers {
+		if strings.EqualFold(name, "Host") {
+			request.Host = value
+		} else {
+			request.Header.Set(name, value)
+		}
+	}
+}
+
+// redactHeaders formats the headers for logging, hiding the values of headers that may hold secrets
+func redactHeaders(headers map[string]string) string {
+	formatted := make([]string, 0, len(headers))
+	for name, value := range headers {
+		if !nonSensitiveHeaders[strings.ToLower(name)] {
+			value = "<redacted>"
+		}
+		formatted = append(formatted, name+": "+value)
+	}
+	sort.Strings(formatted)
+	return "[" + strings.Join(formatted, ", ") + "]"
+}
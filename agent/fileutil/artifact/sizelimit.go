@@ -0,0 +1,87 @@
+// Copyright 2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package artifact
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+)
+
+// MaxFileSize returns the size in bytes a single downloaded file may not exceed, as configured in appconfig
+func MaxFileSize() int64 {
+	sizeMB := appconfig.DefaultDownloadMaxFileSizeMB
+	if appCfg, err := appconfig.Config(false); err == nil {
+		sizeMB = appCfg.Agent.DownloadMaxFileSizeMB
+	}
+	return int64(sizeMB) << 20
+}
+
+// maxFileSize is a seam for the size limit of the files downloaded by this package
+var maxFileSize = MaxFileSize
+
+// FileTooLargeError is returned for a downloaded file larger than the download size limit
+type FileTooLargeError struct {
+	Name string
+	// Size is the size declared for the file, 0 when it was unknown and the limit was exceeded while the file was read
+	Size    int64
+	MaxSize int64
+}
+
+func (e *FileTooLargeError) Error() string {
+	if e.Size > 0 {
+		return fmt.Sprintf("%v is %v bytes, larger than the download limit of %v bytes", e.Name, e.Size, e.MaxSize)
+	}
+	return fmt.Sprintf("%v exceeded the download limit of %v bytes", e.Name, e.MaxSize)
+}
+
+// CheckDeclaredSize fails with a FileTooLargeError if the size declared for a file exceeds maxSize.
+// Unknown (negative) sizes and a maxSize that isn't positive are not checked.
+func CheckDeclaredSize(name string, size int64, maxSize int64) error {
+	if maxSize > 0 && size > maxSize {
+		return &FileTooLargeError{Name: name, Size: size, MaxSize: maxSize}
+	}
+	return nil
+}
+
+// LimitReader returns a reader of src that fails with a FileTooLargeError once more than maxSize bytes are read from it.
+// src is returned as it is when maxSize isn't positive.
+func LimitReader(src io.Reader, name string, maxSize int64) io.Reader {
+	if maxSize <= 0 {
+		return src
+	}
+	return &sizeLimitedReader{src: src, name: name, maxSize: maxSize, remaining: maxSize}
+}
+
+// sizeLimitedReader reads one byte past the limit to tell a file of exactly maxSize bytes from a larger one
+type sizeLimitedReader struct {
+	src       io.Reader
+	name      string
+	maxSize   int64
+	remaining int64
+}
+
+func (r *sizeLimitedReader) Read(p []byte) (n int, err error) {
+	if int64(len(p)) > r.remaining+1 {
+		p = p[:r.remaining+1]
+	}
+	n, err = r.src.Read(p)
+	if int64(n) > r.remaining {
+		return int(r.remaining), &FileTooLargeError{Name: r.name, MaxSize: r.maxSize}
+	}
+	r.remaining -= int64(n)
+	return n, err
+}
@@ -33,14 +33,17 @@ type fileSystem interface {
 }
 
 // osFS implements fileSystem using the local disk.
+// Paths are passed through LongPath so that paths over the windows length limit can be used.
 type osFS struct{}
 
 func (osFS) IsNotExist(err error) bool                    { return os.IsNotExist(err) }
-func (osFS) MkdirAll(path string, perm os.FileMode) error { return os.MkdirAll(path, perm) }
-func (osFS) Open(name string) (ioFile, error)             { return os.Open(name) }
-func (osFS) Stat(name string) (os.FileInfo, error)        { return os.Stat(name) }
-func (osFS) Remove(name string) error                     { return os.Remove(name) }
-func (osFS) Rename(oldpath string, newpath string) error  { return os.Rename(oldpath, newpath) }
+func (osFS) MkdirAll(path string, perm os.FileMode) error { return os.MkdirAll(LongPath(path), perm) }
+func (osFS) Open(name string) (ioFile, error)             { return os.Open(LongPath(name)) }
+func (osFS) Stat(name string) (os.FileInfo, error)        { return os.Stat(LongPath(name)) }
+func (osFS) Remove(name string) error                     { return os.Remove(LongPath(name)) }
+func (osFS) Rename(oldpath string, newpath string) error {
+	return os.Rename(LongPath(oldpath), LongPath(newpath))
+}
 
 type ioFile interface {
 	io.Closer
@@ -57,5 +60,5 @@ type ioUtility interface {
 type ioU struct{}
 
 func (ioU) WriteFile(filename string, data []byte, perm os.FileMode) error {
-	return ioutil.WriteFile(filename, data, perm)
+	return ioutil.WriteFile(LongPath(filename), data, perm)
 }
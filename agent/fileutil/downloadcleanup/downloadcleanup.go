@@ -0,0 +1,198 @@
+// Copyright 2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package downloadcleanup removes stale artifacts left behind in the download directory.
+package downloadcleanup
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+)
+
+const (
+	// minCleanupInterval is the wait before the first background cleanup and after any cleanup that removed artifacts
+	minCleanupInterval = time.Hour
+	// maxCleanupInterval bounds the wait between background cleanups, which doubles after every cleanup that removed nothing
+	maxCleanupInterval = 24 * time.Hour
+)
+
+var (
+	inUseLock sync.Mutex
+	// inUse counts, by cleaned path, the commands currently using a path under the download directory
+	inUse = map[string]int{}
+)
+
+// MarkInUse keeps path, and the artifacts holding it, from being cleaned up until the returned release is called
+func MarkInUse(path string) (release func()) {
+	path = filepath.Clean(path)
+	inUseLock.Lock()
+	defer inUseLock.Unlock()
+	inUse[path]++
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			inUseLock.Lock()
+			defer inUseLock.Unlock()
+			if inUse[path]--; inUse[path] <= 0 {
+				delete(inUse, path)
+			}
+		})
+	}
+}
+
+// isInUse returns true if path is, holds or is held by a path marked in use
+func isInUse(path string) bool {
+	inUseLock.Lock()
+	defer inUseLock.Unlock()
+	for inUsePath := range inUse {
+		if isUnderDir(inUsePath, path) || isUnderDir(path, inUsePath) {
+			return true
+		}
+	}
+	return false
+}
+
+// isUnderDir determines if childPath is parentDirPath or a path under it
+func isUnderDir(childPath, parentDirPath string) bool {
+	return strings.HasPrefix(filepath.Clean(childPath)+string(filepath.Separator), filepath.Clean(parentDirPath)+string(filepath.Separator))
+}
+
+// Cleanup removes the artifacts directly under root that are older than maxAge and returns how many were removed.
+// A directory is only old when nothing in it was modified within maxAge. Artifacts in use are kept whatever their age.
+func Cleanup(log log.T, root string, maxAge time.Duration) (removed int, err error) {
+	entries, err := filesysdep.ReadDir(root)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, nil
+		}
+		return 0, err
+	}
+
+	cutoff := time.Now().Add(-maxAge)
+	for _, entry := range entries {
+		entryPath := filepath.Join(root, entry.Name())
+		if isInUse(entryPath) {
+			log.Debugf("Keeping %v, it is in use", entryPath)
+			continue
+		}
+		if !isOlderThan(log, entryPath, entry, cutoff) {
+			continue
+		}
+		log.Debugf("Removing stale download artifact %v", entryPath)
+		if err := filesysdep.RemoveAll(entryPath); err != nil {
+			log.Warnf("Could not remove stale download artifact %v - %v", entryPath, err)
+			continue
+		}
+		removed++
+	}
+	return removed, nil
+}
+
+// isOlderThan returns true if entry, and everything under it when it is a directory, was last modified before cutoff
+func isOlderThan(log log.T, entryPath string, entry os.FileInfo, cutoff time.Time) bool {
+	if !entry.ModTime().Before(cutoff) {
+		return false
+	}
+	if !entry.IsDir() {
+		return true
+	}
+	children, err := filesysdep.ReadDir(entryPath)
+	if err != nil {
+		log.Debugf("Keeping %v, its content could not be read - %v", entryPath, err)
+		return false
+	}
+	for _, child := range children {
+		if !isOlderThan(log, filepath.Join(entryPath, child.Name()), child, cutoff) {
+			return false
+		}
+	}
+	return true
+}
+
+var (
+	backgroundLock sync.Mutex
+	// backgroundStop stops the background cleanup, it is nil when none is running
+	backgroundStop chan struct{}
+)
+
+// Start cleans up the download directory in the background when the agent configuration enables it
+func Start(log log.T) {
+	appCfg, err := appconfig.Config(false)
+	if err != nil || !appCfg.Agent.DownloadRootCleanup {
+		return
+	}
+	maxAge := time.Duration(appCfg.Agent.DownloadRootMaxAgeHours) * time.Hour
+
+	backgroundLock.Lock()
+	defer backgroundLock.Unlock()
+	if backgroundStop != nil {
+		return
+	}
+	backgroundStop = make(chan struct{})
+	log.Infof("Cleaning up download artifacts older than %v from %v", maxAge, appconfig.DownloadRoot)
+	go runBackground(log, appconfig.DownloadRoot, maxAge, backgroundStop)
+}
+
+// Stop stops the background cleanup started by Start
+func Stop() {
+	backgroundLock.Lock()
+	defer backgroundLock.Unlock()
+	if backgroundStop != nil {
+		close(backgroundStop)
+		backgroundStop = nil
+	}
+}
+
+// runBackground cleans up root until stop is closed.
+// The wait between cleanups doubles while they find nothing to remove and resets once they do.
+func runBackground(log log.T, root string, maxAge time.Duration, stop chan struct{}) {
+	defer func() {
+		// recover in case the cleanup panics
+		if msg := recover(); msg != nil {
+			log.Errorf("Download directory cleanup failed with message %v", msg)
+		}
+	}()
+
+	interval := minCleanupInterval
+	for {
+		select {
+		case <-stop:
+			return
+		case <-time.After(interval):
+		}
+		removed, err := Cleanup(log, root, maxAge)
+		if err != nil {
+			log.Warnf("Could not clean up download directory %v - %v", root, err)
+		}
+		interval = nextInterval(interval, removed)
+	}
+}
+
+// nextInterval returns the wait before the cleanup following one that removed the given number of artifacts
+func nextInterval(interval time.Duration, removed int) time.Duration {
+	if removed > 0 {
+		return minCleanupInterval
+	}
+	if interval *= 2; interval > maxCleanupInterval {
+		return maxCleanupInterval
+	}
+	return interval
+}
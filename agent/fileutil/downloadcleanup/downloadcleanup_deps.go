@@ -0,0 +1,39 @@
+// Copyright 2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package downloadcleanup
+
+import (
+	"os"
+
+	"github.com/aws/amazon-ssm-agent/agent/fileutil"
+)
+
+// dependency on filesystem and os utility functions
+type fileSysDep interface {
+	ReadDir(location string) ([]os.FileInfo, error)
+	RemoveAll(path string) error
+}
+
+var filesysdep fileSysDep = &fileSysDepImp{}
+
+type fileSysDepImp struct{}
+
+func (fileSysDepImp) ReadDir(location string) ([]os.FileInfo, error) {
+	return fileutil.ReadDir(location)
+}
+
+func (fileSysDepImp) RemoveAll(path string) error {
+	return os.RemoveAll(fileutil.LongPath(path))
+}
@@ -0,0 +1,164 @@
+// Copyright 2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package downloadcleanup
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/stretchr/testify/assert"
+)
+
+// fileInfoStub describes a file or directory of fileSysStub
+type fileInfoStub struct {
+	name    string
+	modTime time.Time
+	isDir   bool
+}
+
+func (info fileInfoStub) Name() string       { return info.name }
+func (info fileInfoStub) Size() int64        { return 0 }
+func (info fileInfoStub) Mode() os.FileMode  { return 0 }
+func (info fileInfoStub) ModTime() time.Time { return info.modTime }
+func (info fileInfoStub) IsDir() bool        { return info.isDir }
+func (info fileInfoStub) Sys() interface{}   { return nil }
+
+// fileSysStub lists directories by path and records the removed paths
+type fileSysStub struct {
+	dirs      map[string][]os.FileInfo
+	removeErr map[string]error
+	removed   []string
+}
+
+func (f *fileSysStub) ReadDir(location string) ([]os.FileInfo, error) {
+	entries, found := f.dirs[location]
+	if !found {
+		return nil, os.ErrNotExist
+	}
+	return entries, nil
+}
+
+func (f *fileSysStub) RemoveAll(path string) error {
+	if err := f.removeErr[path]; err != nil {
+		return err
+	}
+	f.removed = append(f.removed, path)
+	return nil
+}
+
+func stubFileSys(stub *fileSysStub) func() {
+	previous := filesysdep
+	filesysdep = stub
+	return func() { filesysdep = previous }
+}
+
+func TestCleanup(t *testing.T) {
+	old := time.Now().Add(-48 * time.Hour)
+	recent := time.Now().Add(-time.Hour)
+	root := filepath.Join("download", "root")
+	stub := &fileSysStub{
+		dirs: map[string][]os.FileInfo{
+			root: {
+				fileInfoStub{name: "old.zip", modTime: old},
+				fileInfoStub{name: "recent.zip", modTime: recent},
+				fileInfoStub{name: "olddir", modTime: old, isDir: true},
+				fileInfoStub{name: "touchedDir", modTime: old, isDir: true},
+			},
+			filepath.Join(root, "olddir"): {
+				fileInfoStub{name: "script.sh", modTime: old},
+			},
+			filepath.Join(root, "touchedDir"): {
+				fileInfoStub{name: "script.sh", modTime: recent},
+			},
+		},
+	}
+	defer stubFileSys(stub)()
+
+	removed, err := Cleanup(log.NewMockLog(), root, 24*time.Hour)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 2, removed)
+	sort.Strings(stub.removed)
+	assert.Equal(t, []string{filepath.Join(root, "old.zip"), filepath.Join(root, "olddir")}, stub.removed)
+}
+
+func TestCleanup_KeepsInUse(t *testing.T) {
+	old := time.Now().Add(-48 * time.Hour)
+	root := filepath.Join("download", "root")
+	stub := &fileSysStub{
+		dirs: map[string][]os.FileInfo{
+			root: {
+				fileInfoStub{name: "command", modTime: old, isDir: true},
+			},
+			filepath.Join(root, "command"): {
+				fileInfoStub{name: "script.sh", modTime: old},
+			},
+		},
+	}
+	defer stubFileSys(stub)()
+
+	release := MarkInUse(filepath.Join(root, "command", "script.sh"))
+	removed, err := Cleanup(log.NewMockLog(), root, 24*time.Hour)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, removed)
+
+	release()
+	release()
+	removed, err = Cleanup(log.NewMockLog(), root, 24*time.Hour)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, removed)
+	assert.Equal(t, []string{filepath.Join(root, "command")}, stub.removed)
+}
+
+func TestCleanup_RemoveFailureContinues(t *testing.T) {
+	old := time.Now().Add(-48 * time.Hour)
+	root := filepath.Join("download", "root")
+	stub := &fileSysStub{
+		dirs: map[string][]os.FileInfo{
+			root: {
+				fileInfoStub{name: "locked.zip", modTime: old},
+				fileInfoStub{name: "old.zip", modTime: old},
+			},
+		},
+		removeErr: map[string]error{filepath.Join(root, "locked.zip"): errors.New("access denied")},
+	}
+	defer stubFileSys(stub)()
+
+	removed, err := Cleanup(log.NewMockLog(), root, 24*time.Hour)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, removed)
+	assert.Equal(t, []string{filepath.Join(root, "old.zip")}, stub.removed)
+}
+
+func TestCleanup_MissingRoot(t *testing.T) {
+	defer stubFileSys(&fileSysStub{})()
+
+	removed, err := Cleanup(log.NewMockLog(), "missing", time.Hour)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 0, removed)
+}
+
+func TestNextInterval(t *testing.T) {
+	assert.Equal(t, 2*minCleanupInterval, nextInterval(minCleanupInterval, 0))
+	assert.Equal(t, maxCleanupInterval, nextInterval(maxCleanupInterval, 0))
+	assert.Equal(t, minCleanupInterval, nextInterval(maxCleanupInterval, 3))
+}
@@ -0,0 +1,230 @@
+// Copyright 2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package filemanager
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+)
+
+// archiveHeaderSize is how much of an archive is read to detect its format, the tar magic ends at byte 263
+const archiveHeaderSize = 512
+
+// Archive extracts the downloaded archives of one format
+type Archive interface {
+	// Name is the name of the format, e.g. zip
+	Name() string
+	// Matches returns true if the archive is in this format, from its name or the first bytes of its content
+	Matches(name string, header []byte) bool
+	// Extract extracts the archive into the destination directory, keeping the modes of its files.
+	// Entries that would be placed outside the destination fail the extraction.
+	Extract(source string, destination string) error
+}
+
+var archives = struct {
+	lock     sync.RWMutex
+	registry []Archive
+}{registry: []Archive{zipArchive{}, tarGzArchive{}, tarArchive{}}}
+
+// RegisterArchive adds an archive format, e.g. tar.xz, ArchiveFor selects it when no format registered before matches
+func RegisterArchive(archive Archive) {
+	archives.lock.Lock()
+	defer archives.lock.Unlock()
+	archives.registry = append(archives.registry, archive)
+}
+
+// ArchiveFor returns the format of the archive file, detected from its content or else from its extension
+func ArchiveFor(source string) (Archive, error) {
+	file, err := os.Open(source)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	header := make([]byte, archiveHeaderSize)
+	n, err := io.ReadFull(file, header)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return nil, err
+	}
+	header = header[:n]
+
+	archives.lock.RLock()
+	defer archives.lock.RUnlock()
+	for _, archive := range archives.registry {
+		if archive.Matches("", header) {
+			return archive, nil
+		}
+	}
+	for _, archive := range archives.registry {
+		if archive.Matches(filepath.Base(source), nil) {
+			return archive, nil
+		}
+	}
+	return nil, fmt.Errorf("%v is not an archive of a supported format", filepath.Base(source))
+}
+
+// ExtractArchive extracts the archive file into the destination directory with the format detected by ArchiveFor
+func ExtractArchive(source string, destination string) error {
+	archive, err := ArchiveFor(source)
+	if err != nil {
+		return err
+	}
+	return archive.Extract(source, destination)
+}
+
+// hasExtension returns true if the file name ends with one of the extensions, ignoring case
+func hasExtension(name string, extensions ...string) bool {
+	name = strings.ToLower(name)
+	for _, extension := range extensions {
+		if strings.HasSuffix(name, extension) {
+			return true
+		}
+	}
+	return false
+}
+
+// extractEntry writes one entry of an archive under the destination directory with the mode it has in the archive
+func extractEntry(destination string, name string, mode os.FileMode, content io.Reader) error {
+	path := filepath.Join(destination, filepath.FromSlash(name))
+	if !isWithin(path, filepath.Clean(destination)) {
+		return fmt.Errorf("%v attempts to place files outside %v subtree", name, destination)
+	}
+	if mode.IsDir() {
+		if err := os.MkdirAll(path, appconfig.ReadWriteExecuteAccess); err != nil {
+			return err
+		}
+		return os.Chmod(path, mode.Perm())
+	}
+	if !mode.IsRegular() {
+		return fmt.Errorf("%v is not a file or a directory, it can't be extracted", name)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), appconfig.ReadWriteExecuteAccess); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(path, appconfig.FileFlagsCreateOrTruncate, mode.Perm())
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	if _, err = io.Copy(file, content); err != nil {
+		return err
+	}
+	// the mode given to OpenFile is reduced by the umask and not applied to existing files
+	return file.Chmod(mode.Perm())
+}
+
+// zipArchive extracts zip files
+type zipArchive struct{}
+
+func (zipArchive) Name() string {
+	return "zip"
+}
+
+func (zipArchive) Matches(name string, header []byte) bool {
+	return bytes.HasPrefix(header, []byte("PK\x03\x04")) || bytes.HasPrefix(header, []byte("PK\x05\x06")) || hasExtension(name, ".zip")
+}
+
+func (zipArchive) Extract(source string, destination string) error {
+	reader, err := zip.OpenReader(source)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+	for _, entry := range reader.File {
+		if err = extractZipEntry(destination, entry); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// extractZipEntry extracts one entry of a zip file, closing it before the next one is opened
+func extractZipEntry(destination string, entry *zip.File) error {
+	content, err := entry.Open()
+	if err != nil {
+		return err
+	}
+	defer content.Close()
+	return extractEntry(destination, entry.Name, entry.Mode(), content)
+}
+
+// tarArchive extracts uncompressed tar files
+type tarArchive struct{}
+
+func (tarArchive) Name() string {
+	return "tar"
+}
+
+func (tarArchive) Matches(name string, header []byte) bool {
+	return (len(header) >= 262 && string(header[257:262]) == "ustar") || hasExtension(name, ".tar")
+}
+
+func (tarArchive) Extract(source string, destination string) error {
+	file, err := os.Open(source)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return extractTar(tar.NewReader(file), destination)
+}
+
+// tarGzArchive extracts gzip compressed tar files
+type tarGzArchive struct{}
+
+func (tarGzArchive) Name() string {
+	return "tar.gz"
+}
+
+func (tarGzArchive) Matches(name string, header []byte) bool {
+	return bytes.HasPrefix(header, []byte{0x1f, 0x8b}) || hasExtension(name, ".tar.gz", ".tgz")
+}
+
+func (tarGzArchive) Extract(source string, destination string) error {
+	file, err := os.Open(source)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	uncompressed, err := gzip.NewReader(file)
+	if err != nil {
+		return err
+	}
+	defer uncompressed.Close()
+	return extractTar(tar.NewReader(uncompressed), destination)
+}
+
+// extractTar extracts the entries of a tar stream into the destination directory
+func extractTar(reader *tar.Reader, destination string) error {
+	for {
+		header, err := reader.Next()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		if err = extractEntry(destination, header.Name, header.FileInfo().Mode(), reader); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,208 @@
+// Copyright 2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package filemanager
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// archiveEntry is a file or a directory of a test archive
+type archiveEntry struct {
+	name    string
+	mode    os.FileMode
+	content string
+}
+
+var validArchiveEntries = []archiveEntry{
+	{"scripts/", os.ModeDir | 0755, ""},
+	{"scripts/install.sh", 0755, "#!/bin/sh\necho install"},
+	{"config.json", 0600, `{"enabled": true}`},
+}
+
+func zipContent(t *testing.T, entries []archiveEntry) []byte {
+	var buffer bytes.Buffer
+	writer := zip.NewWriter(&buffer)
+	for _, entry := range entries {
+		header := &zip.FileHeader{Name: entry.name, Method: zip.Deflate}
+		header.SetMode(entry.mode)
+		file, err := writer.CreateHeader(header)
+		assert.NoError(t, err)
+		_, err = file.Write([]byte(entry.content))
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, writer.Close())
+	return buffer.Bytes()
+}
+
+func tarContent(t *testing.T, entries []archiveEntry) []byte {
+	var buffer bytes.Buffer
+	writer := tar.NewWriter(&buffer)
+	for _, entry := range entries {
+		header := &tar.Header{Name: entry.name, Mode: int64(entry.mode.Perm()), Size: int64(len(entry.content)), Typeflag: tar.TypeReg, Format: tar.FormatUSTAR}
+		if entry.mode.IsDir() {
+			header.Typeflag = tar.TypeDir
+		}
+		assert.NoError(t, writer.WriteHeader(header))
+		_, err := writer.Write([]byte(entry.content))
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, writer.Close())
+	return buffer.Bytes()
+}
+
+func tarGzContent(t *testing.T, entries []archiveEntry) []byte {
+	var buffer bytes.Buffer
+	writer := gzip.NewWriter(&buffer)
+	_, err := writer.Write(tarContent(t, entries))
+	assert.NoError(t, err)
+	assert.NoError(t, writer.Close())
+	return buffer.Bytes()
+}
+
+var archiveFormats = []struct {
+	name     string
+	fileName string
+	content  func(t *testing.T, entries []archiveEntry) []byte
+}{
+	{"zip", "package.zip", zipContent},
+	{"tar", "package.tar", tarContent},
+	{"tar.gz", "package.tar.gz", tarGzContent},
+}
+
+func TestArchive_Extract(t *testing.T) {
+	for _, format := range archiveFormats {
+		t.Run(format.name, func(t *testing.T) {
+			root, err := ioutil.TempDir("", "archive")
+			assert.NoError(t, err)
+			defer os.RemoveAll(root)
+			source := filepath.Join(root, format.fileName)
+			assert.NoError(t, ioutil.WriteFile(source, format.content(t, validArchiveEntries), 0600))
+			destination := filepath.Join(root, "extracted")
+
+			archive, err := ArchiveFor(source)
+			assert.NoError(t, err)
+			assert.Equal(t, format.name, archive.Name())
+			assert.NoError(t, archive.Extract(source, destination))
+
+			for _, entry := range validArchiveEntries {
+				path := filepath.Join(destination, filepath.FromSlash(entry.name))
+				info, err := os.Stat(path)
+				assert.NoError(t, err)
+				if entry.mode.IsDir() {
+					assert.True(t, info.IsDir())
+					continue
+				}
+				content, err := ioutil.ReadFile(path)
+				assert.NoError(t, err)
+				assert.Equal(t, entry.content, string(content))
+				if runtime.GOOS != "windows" {
+					assert.Equal(t, entry.mode.Perm(), info.Mode().Perm(), entry.name)
+				}
+			}
+		})
+	}
+}
+
+func TestArchive_ExtractTraversal(t *testing.T) {
+	for _, format := range archiveFormats {
+		t.Run(format.name, func(t *testing.T) {
+			root, err := ioutil.TempDir("", "archive")
+			assert.NoError(t, err)
+			defer os.RemoveAll(root)
+			source := filepath.Join(root, format.fileName)
+			entries := []archiveEntry{{"readme.txt", 0644, "readme"}, {"../../evil.sh", 0755, "rm -rf /"}}
+			assert.NoError(t, ioutil.WriteFile(source, format.content(t, entries), 0600))
+			destination := filepath.Join(root, "a", "extracted")
+
+			err = ExtractArchive(source, destination)
+
+			assert.Error(t, err)
+			assert.Contains(t, err.Error(), "outside")
+			_, err = os.Stat(filepath.Join(root, "evil.sh"))
+			assert.True(t, os.IsNotExist(err))
+		})
+	}
+}
+
+func TestArchiveFor_DetectsContentBeforeExtension(t *testing.T) {
+	root, err := ioutil.TempDir("", "archive")
+	assert.NoError(t, err)
+	defer os.RemoveAll(root)
+
+	// a tar.gz downloaded under a misleading name
+	misnamed := filepath.Join(root, "package.zip")
+	assert.NoError(t, ioutil.WriteFile(misnamed, tarGzContent(t, validArchiveEntries), 0600))
+	archive, err := ArchiveFor(misnamed)
+	assert.NoError(t, err)
+	assert.Equal(t, "tar.gz", archive.Name())
+
+	// content that isn't recognized falls back to the extension
+	unknown := filepath.Join(root, "package.tgz")
+	assert.NoError(t, ioutil.WriteFile(unknown, []byte("not an archive"), 0600))
+	archive, err = ArchiveFor(unknown)
+	assert.NoError(t, err)
+	assert.Equal(t, "tar.gz", archive.Name())
+
+	text := filepath.Join(root, "readme.txt")
+	assert.NoError(t, ioutil.WriteFile(text, []byte("not an archive"), 0600))
+	_, err = ArchiveFor(text)
+	assert.Error(t, err)
+}
+
+// xzArchiveMock is a format registered by a test
+type xzArchiveMock struct {
+	extracted []string
+}
+
+func (a *xzArchiveMock) Name() string {
+	return "tar.xz"
+}
+
+func (a *xzArchiveMock) Matches(name string, header []byte) bool {
+	return bytes.HasPrefix(header, []byte("\xfd7zXZ\x00")) || hasExtension(name, ".tar.xz")
+}
+
+func (a *xzArchiveMock) Extract(source string, destination string) error {
+	a.extracted = append(a.extracted, source)
+	return nil
+}
+
+func TestRegisterArchive(t *testing.T) {
+	originalRegistry := archives.registry
+	defer func() { archives.registry = originalRegistry }()
+	root, err := ioutil.TempDir("", "archive")
+	assert.NoError(t, err)
+	defer os.RemoveAll(root)
+	source := filepath.Join(root, "package.tar.xz")
+	assert.NoError(t, ioutil.WriteFile(source, []byte("\xfd7zXZ\x00\x00\x04"), 0600))
+
+	_, err = ArchiveFor(source)
+	assert.Error(t, err)
+
+	xz := &xzArchiveMock{}
+	RegisterArchive(xz)
+	assert.NoError(t, ExtractArchive(source, filepath.Join(root, "extracted")))
+	assert.Equal(t, []string{source}, xz.extracted)
+}
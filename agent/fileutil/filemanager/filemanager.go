@@ -18,6 +18,9 @@
 package filemanager
 
 import (
+	"io"
+	"os"
+
 	"github.com/aws/amazon-ssm-agent/agent/fileutil"
 )
 
@@ -25,12 +28,14 @@ import (
 type FileSystem interface {
 	MakeDirs(destinationDir string) (err error)
 	WriteFile(filename string, content string) error
+	WriteStream(filename string, content io.Reader) (written int64, err error)
 	ReadFile(filename string) (string, error)
 	MoveAndRenameFile(sourcePath, sourceName, destPath, destName string) (result bool, err error)
 	DeleteFile(filename string) (err error)
 	DeleteDirectory(filename string) (err error)
 	Exists(filename string) bool
 	IsDirectory(srcPath string) bool
+	Chmod(filename string, mode os.FileMode) error
 }
 
 type FileSystemImpl struct{}
@@ -60,6 +65,11 @@ func (f FileSystemImpl) WriteFile(filename string, content string) error {
 	return fileutil.WriteAllText(filename, content)
 }
 
+// WriteStream writes the content read from the reader in the file path provided
+func (f FileSystemImpl) WriteStream(filename string, content io.Reader) (written int64, err error) {
+	return fileutil.WriteStream(filename, content)
+}
+
 // ReadFile reads the contents of file in path provided
 func (f FileSystemImpl) ReadFile(filename string) (string, error) {
 	return fileutil.ReadAllText(filename)
@@ -72,3 +82,8 @@ func (f FileSystemImpl) Exists(root string) bool {
 func (f FileSystemImpl) IsDirectory(srcPath string) bool {
 	return fileutil.IsDirectory(srcPath)
 }
+
+// Chmod changes the permissions of the file
+func (f FileSystemImpl) Chmod(filename string, mode os.FileMode) error {
+	return os.Chmod(filename, mode)
+}
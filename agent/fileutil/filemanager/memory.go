@@ -0,0 +1,248 @@
+// Copyright 2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package filemanager
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// MemoryFileSystem is a FileSystem keeping its files in memory, for content that must never be written to disk.
+// Release zeroes the content once it is no longer needed.
+type MemoryFileSystem struct {
+	lock  sync.Mutex
+	files map[string][]byte
+	dirs  map[string]bool
+	// modes are the permissions files were given with Chmod
+	modes map[string]os.FileMode
+}
+
+// NewMemoryFileSystem returns an empty in-memory file system
+func NewMemoryFileSystem() *MemoryFileSystem {
+	return &MemoryFileSystem{
+		files: make(map[string][]byte),
+		dirs:  make(map[string]bool),
+		modes: make(map[string]os.FileMode),
+	}
+}
+
+// MakeDirs creates the directory and its parents
+func (f *MemoryFileSystem) MakeDirs(destinationDir string) (err error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	return f.makeDirs(filepath.Clean(destinationDir))
+}
+
+// WriteFile writes the content in the file path provided, creating its parent directories
+func (f *MemoryFileSystem) WriteFile(filename string, content string) error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	return f.write(filepath.Clean(filename), []byte(content))
+}
+
+// WriteStream writes the content read from the reader in the file path provided, creating its parent directories
+func (f *MemoryFileSystem) WriteStream(filename string, content io.Reader) (written int64, err error) {
+	data, err := ioutil.ReadAll(content)
+	if err != nil {
+		zero(data)
+		return 0, err
+	}
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if err = f.write(filepath.Clean(filename), data); err != nil {
+		zero(data)
+		return 0, err
+	}
+	return int64(len(data)), nil
+}
+
+// ReadFile reads the contents of file in path provided
+func (f *MemoryFileSystem) ReadFile(filename string) (string, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	content, found := f.files[filepath.Clean(filename)]
+	if !found {
+		return "", fmt.Errorf("%v does not exist in memory", filename)
+	}
+	return string(content), nil
+}
+
+// MoveAndRenameFile moves and renames the file
+func (f *MemoryFileSystem) MoveAndRenameFile(sourcePath, sourceName, destPath, destName string) (result bool, err error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	source := filepath.Join(sourcePath, sourceName)
+	content, found := f.files[source]
+	if !found {
+		return false, fmt.Errorf("%v does not exist in memory", source)
+	}
+	if err = f.makeDirs(filepath.Clean(destPath)); err != nil {
+		return false, err
+	}
+	destination := filepath.Join(destPath, destName)
+	if destination != source {
+		zero(f.files[destination])
+		f.files[destination] = content
+		delete(f.files, source)
+		f.modes[destination] = f.modes[source]
+		delete(f.modes, source)
+	}
+	return true, nil
+}
+
+// DeleteFile zeroes and deletes the file
+func (f *MemoryFileSystem) DeleteFile(filename string) (err error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	filename = filepath.Clean(filename)
+	zero(f.files[filename])
+	delete(f.files, filename)
+	delete(f.modes, filename)
+	return nil
+}
+
+// DeleteDirectory zeroes and deletes the directory along with everything in it
+func (f *MemoryFileSystem) DeleteDirectory(filename string) (err error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	dir := filepath.Clean(filename)
+	for path, content := range f.files {
+		if isWithin(path, dir) {
+			zero(content)
+			delete(f.files, path)
+			delete(f.modes, path)
+		}
+	}
+	for path := range f.dirs {
+		if isWithin(path, dir) {
+			delete(f.dirs, path)
+		}
+	}
+	return nil
+}
+
+// Exists returns true if a file or directory exists at the path
+func (f *MemoryFileSystem) Exists(filename string) bool {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	filename = filepath.Clean(filename)
+	_, isFile := f.files[filename]
+	return isFile || f.dirs[filename]
+}
+
+// IsDirectory returns true if a directory exists at the path
+func (f *MemoryFileSystem) IsDirectory(srcPath string) bool {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	return f.dirs[filepath.Clean(srcPath)]
+}
+
+// Chmod records the permissions of the file
+func (f *MemoryFileSystem) Chmod(filename string, mode os.FileMode) error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	filename = filepath.Clean(filename)
+	if _, found := f.files[filename]; !found {
+		return fmt.Errorf("%v does not exist in memory", filename)
+	}
+	f.modes[filename] = mode
+	return nil
+}
+
+// Mode returns the permissions the file was given with Chmod, 0 when it wasn't
+func (f *MemoryFileSystem) Mode(filename string) os.FileMode {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	return f.modes[filepath.Clean(filename)]
+}
+
+// Files returns the paths of all the files held in memory
+func (f *MemoryFileSystem) Files() []string {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	paths := make([]string, 0, len(f.files))
+	for path := range f.files {
+		paths = append(paths, path)
+	}
+	return paths
+}
+
+// FilesUnder returns the sorted paths, relative to dir and with / separators, of the files held in memory under dir
+func (f *MemoryFileSystem) FilesUnder(dir string) []string {
+	var paths []string
+	for _, path := range f.Files() {
+		if relative, err := filepath.Rel(dir, path); err == nil && relative != ".." && !strings.HasPrefix(relative, ".."+string(filepath.Separator)) {
+			paths = append(paths, filepath.ToSlash(relative))
+		}
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// Release zeroes the content of every file and empties the file system
+func (f *MemoryFileSystem) Release() {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	for path, content := range f.files {
+		zero(content)
+		delete(f.files, path)
+	}
+	f.dirs = make(map[string]bool)
+	f.modes = make(map[string]os.FileMode)
+}
+
+// makeDirs marks the directory and its parents as existing, the caller holds the lock
+func (f *MemoryFileSystem) makeDirs(dir string) error {
+	for ; ; dir = filepath.Dir(dir) {
+		if _, isFile := f.files[dir]; isFile {
+			return fmt.Errorf("%v is a file", dir)
+		}
+		f.dirs[dir] = true
+		if filepath.Dir(dir) == dir {
+			return nil
+		}
+	}
+}
+
+// write replaces the content of the file, the caller holds the lock
+func (f *MemoryFileSystem) write(filename string, content []byte) error {
+	if f.dirs[filename] {
+		return fmt.Errorf("%v is a directory", filename)
+	}
+	if err := f.makeDirs(filepath.Dir(filename)); err != nil {
+		return err
+	}
+	zero(f.files[filename])
+	f.files[filename] = content
+	return nil
+}
+
+// isWithin returns true if path is dir or is inside it
+func isWithin(path string, dir string) bool {
+	return path == dir || strings.HasPrefix(path, strings.TrimSuffix(dir, string(filepath.Separator))+string(filepath.Separator))
+}
+
+// zero overwrites the content so it doesn't linger in memory
+func zero(content []byte) {
+	for i := range content {
+		content[i] = 0
+	}
+}
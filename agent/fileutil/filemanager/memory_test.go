@@ -0,0 +1,116 @@
+// Copyright 2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package filemanager
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryFileSystem_WriteAndRead(t *testing.T) {
+	root, err := ioutil.TempDir("", "memoryfs")
+	assert.NoError(t, err)
+	defer os.RemoveAll(root)
+
+	filesys := NewMemoryFileSystem()
+	script := filepath.Join(root, "scripts", "secret.sh")
+	assert.NoError(t, filesys.WriteFile(script, "echo secret"))
+	written, err := filesys.WriteStream(filepath.Join(root, "scripts", "other.sh"), strings.NewReader("echo other"))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(len("echo other")), written)
+
+	content, err := filesys.ReadFile(script)
+	assert.NoError(t, err)
+	assert.Equal(t, "echo secret", content)
+	assert.True(t, filesys.Exists(script))
+	assert.False(t, filesys.IsDirectory(script))
+	assert.True(t, filesys.IsDirectory(filepath.Join(root, "scripts")))
+
+	// nothing reaches the real file system
+	entries, err := ioutil.ReadDir(root)
+	assert.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestMemoryFileSystem_MoveAndDelete(t *testing.T) {
+	filesys := NewMemoryFileSystem()
+	assert.NoError(t, filesys.WriteFile("/tmp/download/file.tmp", "content"))
+
+	moved, err := filesys.MoveAndRenameFile("/tmp/download", "file.tmp", "/tmp/final", "file.sh")
+	assert.NoError(t, err)
+	assert.True(t, moved)
+	assert.False(t, filesys.Exists("/tmp/download/file.tmp"))
+	content, err := filesys.ReadFile("/tmp/final/file.sh")
+	assert.NoError(t, err)
+	assert.Equal(t, "content", content)
+
+	_, err = filesys.MoveAndRenameFile("/tmp/download", "missing", "/tmp/final", "missing")
+	assert.Error(t, err)
+
+	assert.NoError(t, filesys.DeleteDirectory("/tmp/final"))
+	assert.False(t, filesys.Exists("/tmp/final/file.sh"))
+	assert.False(t, filesys.Exists("/tmp/final"))
+	assert.True(t, filesys.Exists("/tmp/download"))
+}
+
+func TestMemoryFileSystem_FileAndDirectoryConflict(t *testing.T) {
+	filesys := NewMemoryFileSystem()
+	assert.NoError(t, filesys.WriteFile("/tmp/file", "content"))
+	assert.Error(t, filesys.MakeDirs("/tmp/file/dir"))
+	assert.Error(t, filesys.WriteFile("/tmp", "content"))
+}
+
+func TestMemoryFileSystem_Chmod(t *testing.T) {
+	filesys := NewMemoryFileSystem()
+	assert.NoError(t, filesys.WriteFile("/tmp/download/run.sh", "echo run"))
+	assert.Equal(t, os.FileMode(0), filesys.Mode("/tmp/download/run.sh"))
+
+	assert.NoError(t, filesys.Chmod("/tmp/download/run.sh", 0755))
+	assert.Equal(t, os.FileMode(0755), filesys.Mode("/tmp/download/run.sh"))
+	assert.Error(t, filesys.Chmod("/tmp/download/missing.sh", 0755))
+
+	_, err := filesys.MoveAndRenameFile("/tmp/download", "run.sh", "/tmp/final", "run.sh")
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0755), filesys.Mode("/tmp/final/run.sh"))
+	assert.NoError(t, filesys.DeleteFile("/tmp/final/run.sh"))
+	assert.Equal(t, os.FileMode(0), filesys.Mode("/tmp/final/run.sh"))
+}
+
+func TestMemoryFileSystem_ReleaseZeroesContent(t *testing.T) {
+	filesys := NewMemoryFileSystem()
+	_, err := filesys.WriteStream("/tmp/secret", strings.NewReader("password"))
+	assert.NoError(t, err)
+	held := filesys.files["/tmp/secret"]
+
+	filesys.Release()
+
+	assert.Equal(t, make([]byte, len("password")), held)
+	assert.False(t, filesys.Exists("/tmp/secret"))
+	assert.Empty(t, filesys.Files())
+}
+
+func TestMemoryFileSystem_FilesUnder(t *testing.T) {
+	filesys := NewMemoryFileSystem()
+	for _, file := range []string{filepath.Join("dest", "b", "run.sh"), filepath.Join("dest", "a.txt"), filepath.Join("other", "c.txt")} {
+		assert.NoError(t, filesys.WriteFile(file, "content"))
+	}
+
+	assert.Equal(t, []string{"a.txt", "b/run.sh"}, filesys.FilesUnder("dest"))
+}
@@ -16,6 +16,9 @@
 package fileutil_mock
 
 import (
+	"io"
+	"os"
+
 	"github.com/stretchr/testify/mock"
 )
 
@@ -33,6 +36,11 @@ func (fileMock FileSystemMock) WriteFile(filename string, content string) error
 	return args.Error(0)
 }
 
+func (fileMock FileSystemMock) WriteStream(filename string, content io.Reader) (written int64, err error) {
+	args := fileMock.Called(filename, content)
+	return args.Get(0).(int64), args.Error(1)
+}
+
 func (fileMock FileSystemMock) ReadFile(filename string) (string, error) {
 	args := fileMock.Called(filename)
 	return args.Get(0).(string), args.Error(1)
@@ -62,3 +70,8 @@ func (fileMock FileSystemMock) IsDirectory(root string) bool {
 	args := fileMock.Called(root)
 	return args.Bool(0)
 }
+
+func (fileMock FileSystemMock) Chmod(filename string, mode os.FileMode) error {
+	args := fileMock.Called(filename, mode)
+	return args.Error(0)
+}
@@ -0,0 +1,74 @@
+// Copyright 2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package filemanager
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// SafeJoin joins the relative path, with / or the OS separator between its elements, to baseDir
+// and returns an error if it is absolute or resolves outside of baseDir, e.g. through .. elements or symbolic links.
+// The parts of both paths that exist on disk have their symbolic links resolved before the check, the rest,
+// like paths of in-memory file systems, is checked lexically.
+// Paths of untrusted sources like repositories and archives are expected to go through it.
+func SafeJoin(baseDir string, relativePath string) (string, error) {
+	relative := filepath.FromSlash(relativePath)
+	if filepath.IsAbs(relative) || filepath.VolumeName(relative) != "" || strings.HasPrefix(relative, string(filepath.Separator)) {
+		return "", fmt.Errorf("%v is an absolute path, it can't be placed in %v", relativePath, baseDir)
+	}
+	base := filepath.Clean(baseDir)
+	joined := filepath.Join(base, relative)
+	// Rel also handles a relative baseDir, like ., whose joined paths don't start with it
+	if rel, err := filepath.Rel(base, joined); err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("%v attempts to place files outside %v subtree", relativePath, baseDir)
+	}
+	resolvedBase, err := resolvePath(base)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve %v: %v", baseDir, err)
+	}
+	resolvedJoined, err := resolvePath(joined)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve %v: %v", relativePath, err)
+	}
+	if !isWithin(resolvedJoined, resolvedBase) {
+		return "", fmt.Errorf("%v attempts to place files outside %v subtree through a symbolic link", relativePath, baseDir)
+	}
+	return joined, nil
+}
+
+// resolvePath returns the absolute path with the symbolic links of its longest existing prefix resolved
+func resolvePath(path string) (string, error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	existing, rest := absPath, ""
+	for {
+		if resolved, err := filepath.EvalSymlinks(existing); err == nil {
+			return filepath.Join(resolved, rest), nil
+		} else if !os.IsNotExist(err) {
+			return "", err
+		}
+		parent := filepath.Dir(existing)
+		if parent == existing {
+			return absPath, nil
+		}
+		rest = filepath.Join(filepath.Base(existing), rest)
+		existing = parent
+	}
+}
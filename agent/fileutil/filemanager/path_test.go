@@ -0,0 +1,118 @@
+// Copyright 2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package filemanager
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSafeJoin(t *testing.T) {
+	base := filepath.Join("downloads", "orchestration")
+	data := []struct {
+		name         string
+		baseDir      string
+		relativePath string
+		expected     string
+	}{
+		{"file", base, "run.sh", filepath.Join(base, "run.sh")},
+		{"nested file", base, "scripts/lib/common.sh", filepath.Join(base, "scripts", "lib", "common.sh")},
+		{"current directory elements", base, "./scripts/./run.sh", filepath.Join(base, "scripts", "run.sh")},
+		{"parent elements staying inside", base, "scripts/lib/../run.sh", filepath.Join(base, "scripts", "run.sh")},
+		{"dots in names", base, "..hidden/run..sh", filepath.Join(base, "..hidden", "run..sh")},
+		{"empty path", base, "", base},
+		{"base with trailing separator", base + string(filepath.Separator), "run.sh", filepath.Join(base, "run.sh")},
+		{"relative base", ".", "scripts/run.sh", filepath.Join("scripts", "run.sh")},
+	}
+	for _, testdata := range data {
+		t.Run(testdata.name, func(t *testing.T) {
+			joined, err := SafeJoin(testdata.baseDir, testdata.relativePath)
+
+			assert.NoError(t, err)
+			assert.Equal(t, testdata.expected, joined)
+		})
+	}
+}
+
+func TestSafeJoin_Rejects(t *testing.T) {
+	base := filepath.Join("downloads", "orchestration")
+	data := []struct {
+		name          string
+		baseDir       string
+		relativePath  string
+		expectedError string
+	}{
+		{"parent", base, "..", "outside"},
+		{"traversal", base, "../../etc/passwd", "outside"},
+		{"traversal through a subdirectory", base, "scripts/../../../etc/passwd", "outside"},
+		{"traversal to a sibling with the same prefix", base, "../orchestration-other/run.sh", "outside"},
+		{"traversal from a relative base", ".", "../run.sh", "outside"},
+		{"absolute path", base, "/etc/passwd", "absolute"},
+		{"absolute path with the OS separator", base, string(filepath.Separator) + "run.sh", "absolute"},
+	}
+	for _, testdata := range data {
+		t.Run(testdata.name, func(t *testing.T) {
+			joined, err := SafeJoin(testdata.baseDir, testdata.relativePath)
+
+			assert.Error(t, err)
+			assert.Contains(t, err.Error(), testdata.expectedError)
+			assert.Empty(t, joined)
+		})
+	}
+}
+
+func TestSafeJoin_SymbolicLinks(t *testing.T) {
+	root := t.TempDir()
+	base := filepath.Join(root, "orchestration")
+	outside := filepath.Join(root, "outside")
+	assert.NoError(t, os.MkdirAll(filepath.Join(base, "scripts"), 0700))
+	assert.NoError(t, os.MkdirAll(outside, 0700))
+	if err := os.Symlink(outside, filepath.Join(base, "escape")); err != nil {
+		t.Skipf("symbolic links aren't supported: %v", err)
+	}
+	assert.NoError(t, os.Symlink(filepath.Join(base, "scripts"), filepath.Join(base, "inside")))
+	assert.NoError(t, os.Symlink(base, filepath.Join(root, "linked-orchestration")))
+
+	data := []struct {
+		name          string
+		baseDir       string
+		relativePath  string
+		expected      string
+		expectedError string
+	}{
+		{"link to a directory outside", base, "escape/run.sh", "", "symbolic link"},
+		{"link to a directory outside itself", base, "escape", "", "symbolic link"},
+		{"link to a directory inside", base, "inside/run.sh", filepath.Join(base, "inside", "run.sh"), ""},
+		{"base reached through a link", filepath.Join(root, "linked-orchestration"), "scripts/run.sh", filepath.Join(root, "linked-orchestration", "scripts", "run.sh"), ""},
+		{"paths that don't exist yet", base, "new/dir/run.sh", filepath.Join(base, "new", "dir", "run.sh"), ""},
+	}
+	for _, testdata := range data {
+		t.Run(testdata.name, func(t *testing.T) {
+			joined, err := SafeJoin(testdata.baseDir, testdata.relativePath)
+
+			if testdata.expectedError == "" {
+				assert.NoError(t, err)
+				assert.Equal(t, testdata.expected, joined)
+			} else {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), testdata.expectedError)
+				assert.Empty(t, joined)
+			}
+		})
+	}
+}
@@ -55,7 +55,7 @@ func DeleteFile(filepath string) (err error) {
 // DeleteDirectory deletes a directory and all its content.
 func DeleteDirectory(dirName string) (err error) {
 
-	return os.RemoveAll(dirName)
+	return os.RemoveAll(LongPath(dirName))
 }
 
 // ReadAllText reads all content from the specified file
@@ -67,7 +67,7 @@ func ReadAllText(filePath string) (text string, err error) {
 	}
 
 	buf := bytes.NewBuffer(nil)
-	f, _ := os.Open(filePath)
+	f, _ := os.Open(LongPath(filePath))
 	defer f.Close()
 	_, err = io.Copy(buf, f)
 	if err != nil {
@@ -79,12 +79,26 @@ func ReadAllText(filePath string) (text string, err error) {
 
 // WriteAllText writes all text content to the specified file
 func WriteAllText(filePath string, text string) (err error) {
-	f, _ := os.Create(filePath)
+	f, _ := os.Create(LongPath(filePath))
 	defer f.Close()
 	_, err = f.WriteString(text)
 	return
 }
 
+// WriteStream writes everything read from content to the file, without holding it all in memory
+func WriteStream(filePath string, content io.Reader) (written int64, err error) {
+	f, err := os.Create(LongPath(filePath))
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+	}()
+	return io.Copy(f, content)
+}
+
 // Exists returns true if the given file exists, false otherwise, ignoring any underlying error
 func Exists(filePath string) bool {
 	exist, _ := LocalFileExist(filePath)
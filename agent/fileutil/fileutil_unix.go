@@ -27,6 +27,11 @@ import (
 	"github.com/aws/amazon-ssm-agent/agent/appconfig"
 )
 
+// LongPath returns path unchanged, there is no path length limit to work around
+func LongPath(path string) string {
+	return path
+}
+
 // Uncompress untar the installation package
 func Uncompress(src, dest string) error {
 	file, err := os.Open(src)
@@ -18,12 +18,38 @@ package fileutil
 
 import (
 	"os"
+	"path/filepath"
+	"strings"
 	"syscall"
 	"unsafe"
 
 	"github.com/aws/amazon-ssm-agent/agent/appconfig"
 )
 
+const (
+	// longPathPrefix makes windows api calls accept paths over maxPath characters
+	longPathPrefix = `\\?\`
+	// maxPath is the length from which paths need longPathPrefix, directories are limited to 248 characters
+	maxPath = 248
+)
+
+// LongPath returns path with the extended-length prefix when it is too long for the windows api
+func LongPath(path string) string {
+	if len(path) < maxPath || strings.HasPrefix(path, longPathPrefix) {
+		return path
+	}
+	// the prefix disables path normalization, so the path must be absolute and clean
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return path
+	}
+	if strings.HasPrefix(absPath, `\\`) {
+		// UNC paths \\server\share become \\?\UNC\server\share
+		return longPathPrefix + `UNC\` + absPath[2:]
+	}
+	return longPathPrefix + absPath
+}
+
 // Uncompress unzips the installation package
 func Uncompress(src, dest string) error {
 	return Unzip(src, dest)
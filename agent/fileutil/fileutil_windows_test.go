@@ -0,0 +1,71 @@
+// Copyright 2016 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// +build windows
+
+// Package fileutil contains utilities for working with the file system.
+package fileutil
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLongPath(t *testing.T) {
+	longDir := strings.Repeat("a", maxPath)
+	data := []struct {
+		name     string
+		path     string
+		expected string
+	}{
+		{"short path", `C:\short\path`, `C:\short\path`},
+		{"long path", `C:\` + longDir, `\\?\C:\` + longDir},
+		{"long path is cleaned", `C:\dir\..\` + longDir, `\\?\C:\` + longDir},
+		{"long UNC path", `\\server\share\` + longDir, `\\?\UNC\server\share\` + longDir},
+		{"already prefixed", `\\?\C:\` + longDir, `\\?\C:\` + longDir},
+	}
+	for _, testdata := range data {
+		t.Run(testdata.name, func(t *testing.T) {
+			assert.Equal(t, testdata.expected, LongPath(testdata.path))
+		})
+	}
+}
+
+func TestWriteAllTextLongPath(t *testing.T) {
+	root, err := ioutil.TempDir("", "longpath")
+	assert.NoError(t, err)
+	defer DeleteDirectory(root)
+
+	// nest directories until the file path is well over the 260 character limit
+	dir := root
+	for len(dir) < 300 {
+		dir = filepath.Join(dir, strings.Repeat("d", 50))
+	}
+	filePath := filepath.Join(dir, "file.txt")
+
+	assert.NoError(t, MakeDirs(dir))
+	assert.NoError(t, WriteAllText(filePath, "content"))
+	assert.True(t, Exists(filePath))
+
+	content, err := ReadAllText(filePath)
+	assert.NoError(t, err)
+	assert.Equal(t, "content", content)
+
+	_, err = os.Stat(LongPath(filePath))
+	assert.NoError(t, err)
+}
@@ -16,13 +16,23 @@
 package githubclient
 
 import (
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
 	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/aws/amazon-ssm-agent/agent/network"
 	"github.com/go-github/github"
 	gitcontext "golang.org/x/net/context"
 
+	"encoding/base64"
 	"fmt"
+	"io"
+	"net"
 	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"errors"
 )
@@ -34,19 +44,159 @@ const (
 const (
 	contentTypeFile      = "file"
 	contentTypeDirectory = "dir"
+
+	// mediaTypeRaw makes the contents API answer with the file itself instead of its base64 encoded metadata
+	mediaTypeRaw = "application/vnd.github.v3.raw"
+
+	// listPageSize is the number of tags or branches requested per page, the most GitHub returns
+	listPageSize = 100
+)
+
+const (
+	// maxAbuseRateLimitRetries is the number of times a request is retried after hitting the secondary rate limit
+	maxAbuseRateLimitRetries = 3
+	// defaultAbuseRateLimitWait is used when GitHub doesn't send a Retry-After header
+	defaultAbuseRateLimitWait = 10 * time.Second
+	// maxAbuseRateLimitWait bounds the wait requested by GitHub
+	maxAbuseRateLimitWait = 60 * time.Second
+	// rateLimitResetMargin is waited past the reset of the rate limit, which GitHub reports in whole seconds
+	rateLimitResetMargin = time.Second
 )
 
-// NewClient is a constructor for GitClient
+// sleep is a seam for waiting between retries
+var sleep = time.Sleep
+
+// commitIDPattern matches full commit SHAs, SHA-1 or SHA-256, and their abbreviations of at least 4 characters as git allows
+var commitIDPattern = regexp.MustCompile(`^([0-9a-fA-F]{4,40}|[0-9a-fA-F]{64})$`)
+
+// NewClient is a constructor for GitClient. A nil httpClient makes anonymous requests
 func NewClient(httpClient *http.Client) IGitClient {
+	authenticated := httpClient != nil
+	if httpClient == nil {
+		httpClient = &http.Client{Transport: network.DefaultTransport()}
+	}
 
 	return &GitClient{
-		github.NewClient(httpClient),
+		Client:           github.NewClient(httpClient),
+		httpClient:       httpClient,
+		authenticated:    authenticated,
+		rateLimitMaxWait: configuredRateLimitMaxWait(),
+	}
+}
+
+// configuredRateLimitMaxWait returns the total time a request may wait for the rate limit to reset, from appconfig
+func configuredRateLimitMaxWait() time.Duration {
+	maxWaitSeconds := appconfig.DefaultGitHubRateLimitMaxWaitSeconds
+	if appCfg, err := appconfig.Config(false); err == nil {
+		maxWaitSeconds = appCfg.GitHub.RateLimitMaxWaitSeconds
+	}
+	return time.Duration(maxWaitSeconds) * time.Second
+}
+
+// NewClientWithBaseURL is a constructor for GitClient sending every request to the GitHub API at apiURL,
+// e.g. https://github.example.com/api/v3/ for GitHub Enterprise. A nil httpClient makes anonymous requests
+func NewClientWithBaseURL(httpClient *http.Client, apiURL string) (IGitClient, error) {
+	baseURL, err := parseAPIURL(apiURL)
+	if err != nil {
+		return nil, err
+	}
+	client := NewClient(httpClient).(*GitClient)
+	client.BaseURL = baseURL
+	return client, nil
+}
+
+// parseAPIURL validates the base URL of a GitHub API, which must end with a slash
+func parseAPIURL(apiURL string) (*url.URL, error) {
+	baseURL, err := url.Parse(apiURL)
+	if err != nil || baseURL.Scheme == "" || baseURL.Host == "" {
+		return nil, fmt.Errorf("GitHub API URL %v is not valid", apiURL)
+	}
+	if !strings.HasSuffix(baseURL.Path, "/") {
+		baseURL.Path += "/"
+	}
+	return baseURL, nil
+}
+
+// APIHost returns the host of the GitHub API at apiURL, the one of github.com when apiURL is empty or not valid.
+// Retries of the requests to an API spend the retry budget of its host.
+func APIHost(apiURL string) string {
+	if apiURL == "" {
+		return githubAPIHost
+	}
+	baseURL, err := parseAPIURL(apiURL)
+	if err != nil {
+		return githubAPIHost
+	}
+	return baseURL.Host
+}
+
+// NewEnterpriseClient is a constructor for GitClient sending every request to the API of the GitHub Enterprise Server at endpoint,
+// e.g. https://github.example.com, like go-github's NewEnterpriseClient. A nil httpClient makes anonymous requests
+func NewEnterpriseClient(httpClient *http.Client, endpoint string) (IGitClient, error) {
+	apiURL, err := EnterpriseAPIURL(endpoint)
+	if err != nil {
+		return nil, err
+	}
+	return NewClientWithBaseURL(httpClient, apiURL)
+}
+
+// EnterpriseAPIURL returns the API URL of the GitHub Enterprise Server at endpoint, appending api/v3/ unless endpoint already ends with it
+func EnterpriseAPIURL(endpoint string) (string, error) {
+	baseURL, err := parseAPIURL(endpoint)
+	if err != nil || (baseURL.Scheme != "https" && baseURL.Scheme != "http") || baseURL.RawQuery != "" || baseURL.Fragment != "" {
+		return "", fmt.Errorf("GitHub Enterprise endpoint %v is not valid", endpoint)
+	}
+	if !strings.HasSuffix(baseURL.Path, "/api/v3/") {
+		baseURL.Path += "api/v3/"
 	}
+	return baseURL.String(), nil
+}
+
+// NewMirroredClient is a constructor for GitClient that prefers a caching mirror of the GitHub API
+// for content fetches and falls back to GitHub when the mirror errors or misses
+func NewMirroredClient(httpClient *http.Client, mirrorURL string) (IGitClient, error) {
+	baseURL, err := parseAPIURL(mirrorURL)
+	if err != nil {
+		return nil, err
+	}
+
+	authenticated := httpClient != nil
+	if httpClient == nil {
+		httpClient = &http.Client{Transport: network.DefaultTransport()}
+	}
+
+	// the mirror shares the http client so that authorization headers are sent to it as well
+	mirror := github.NewClient(httpClient)
+	mirror.BaseURL = baseURL
+
+	return &GitClient{
+		Client:           github.NewClient(httpClient),
+		mirror:           mirror,
+		httpClient:       httpClient,
+		authenticated:    authenticated,
+		rateLimitMaxWait: configuredRateLimitMaxWait(),
+	}, nil
+}
+
+// WithTimeout bounds each request of client, reading its response included, to timeout, e.g. the content of a file.
+// It sets the timeout of the http client client was created with, which must not be shared. Other clients, e.g. mocks, are left as they are.
+func WithTimeout(client IGitClient, timeout time.Duration) IGitClient {
+	if gitClient, ok := client.(*GitClient); ok {
+		gitClient.httpClient.Timeout = timeout
+	}
+	return client
 }
 
 // GitClient is a wrapper around github.Client. This is done for mocking
 type GitClient struct {
 	*github.Client
+	// mirror is an optional caching mirror of the GitHub API tried before GitHub
+	mirror *github.Client
+	// httpClient sends the requests whose response is read as a stream
+	httpClient    *http.Client
+	authenticated bool
+	// rateLimitMaxWait bounds the total time a request waits for an exceeded rate limit to reset, it fails right away when 0
+	rateLimitMaxWait time.Duration
 }
 
 // IGitClient is an interface for type IGitClient
@@ -54,18 +204,87 @@ type IGitClient interface {
 	GetRepositoryContents(log log.T, owner, repo, path string, opt *github.RepositoryContentGetOptions) (fileContent *github.RepositoryContent, directoryContent []*github.RepositoryContent, err error)
 	ParseGetOptions(log log.T, getOptions string) (*github.RepositoryContentGetOptions, error)
 	IsFileContentType(file *github.RepositoryContent) bool
+	GetLatestCommitDate(log log.T, owner, repo, path string, opt *github.RepositoryContentGetOptions) (time.Time, error)
+	GetCommitTreeSha(log log.T, owner, repo, commitID string) (string, error)
+	GetCommitSignature(log log.T, owner, repo, ref string) (*github.SignatureVerification, error)
+	GetDefaultBranch(log log.T, owner, repo string) (string, error)
+	GetRawContent(log log.T, owner, repo, path string, opt *github.RepositoryContentGetOptions) (io.ReadCloser, error)
+	GetBlob(log log.T, owner, repo, sha string) (string, error)
+	GetLFSObject(log log.T, owner, repo, oid string, size int64) (string, error)
+	GetTreeModes(log log.T, owner, repo, tree string) (map[string]string, error)
+	ListTags(log log.T, owner, repo string) ([]string, error)
+	ListBranches(log log.T, owner, repo string) ([]string, error)
 }
 
 // GetRepositoryContents is a wrapper around GetContents method in gitub SDK
 func (git *GitClient) GetRepositoryContents(log log.T, owner, repo, path string, opt *github.RepositoryContentGetOptions) (fileContent *github.RepositoryContent, directoryContent []*github.RepositoryContent, err error) {
+	if git.mirror != nil {
+		// a rate limited mirror falls back to GitHub right away instead of waiting for its limit to reset
+		if fileContent, directoryContent, err = git.getRepositoryContents(log, git.mirror, false, owner, repo, path, opt); err == nil {
+			return fileContent, directoryContent, nil
+		}
+		log.Warnf("Could not retrieve %v/%v/%v from GitHub mirror %v, falling back to GitHub. Error - %v", owner, repo, path, git.mirror.BaseURL, err)
+	}
+	return git.getRepositoryContents(log, git.Client, true, owner, repo, path, opt)
+}
+
+// getRepositoryContents retrieves the repository contents using the given github client,
+// waiting for exceeded rate limits only when waitForRateLimits is set
+func (git *GitClient) getRepositoryContents(log log.T, client *github.Client, waitForRateLimits bool, owner, repo, path string, opt *github.RepositoryContentGetOptions) (fileContent *github.RepositoryContent, directoryContent []*github.RepositoryContent, err error) {
 	var resp *github.Response
 
-	fileContent, directoryContent, resp, err = git.Repositories.GetContents(gitcontext.Background(), owner, repo, path, opt)
+	budget := network.SharedRetryBudget()
+	rateLimitMaxWait := git.rateLimitMaxWait
+	if !waitForRateLimits {
+		rateLimitMaxWait = 0
+	}
+	var rateLimitWaited time.Duration
+	for attempt := 0; ; attempt++ {
+		limiter := network.SharedDownloadLimiter()
+		limiter.Acquire()
+		fileContent, directoryContent, resp, err = client.Repositories.GetContents(gitcontext.Background(), owner, repo, path, opt)
+		limiter.Release()
+		if reset, isRateLimit := rateLimitReset(err); isRateLimit {
+			wait := reset.Sub(time.Now()) + rateLimitResetMargin
+			if wait < rateLimitResetMargin {
+				wait = rateLimitResetMargin
+			}
+			if rateLimitWaited+wait > rateLimitMaxWait {
+				return nil, nil, fmt.Errorf("GitHub rate limit exceeded until %v, later than the agent waits for it. Error - %v", reset, err)
+			}
+			log.Warnf("GitHub rate limit exceeded, retrying when it resets in %v. Error - %v", wait, err)
+			rateLimitWaited += wait
+			sleep(wait)
+			continue
+		}
+		wait, isAbuseRateLimit := abuseRateLimitWait(err)
+		if !isAbuseRateLimit {
+			if err == nil {
+				budget.RecordSuccess(client.BaseURL.Host)
+			}
+			break
+		}
+		if !waitForRateLimits {
+			return nil, nil, fmt.Errorf("GitHub secondary rate limit exceeded. Error - %v", err)
+		}
+		if attempt >= maxAbuseRateLimitRetries {
+			return nil, nil, fmt.Errorf("GitHub secondary rate limit still exceeded after %v retries. Error - %v", maxAbuseRateLimitRetries, err)
+		}
+		if !budget.AllowRetry(client.BaseURL.Host) {
+			return nil, nil, fmt.Errorf("GitHub secondary rate limit exceeded and the retry budget of %v is spent. Error - %v", client.BaseURL.Host, err)
+		}
+		log.Warnf("GitHub secondary rate limit exceeded, retrying in %v. Error - %v", wait, err)
+		sleep(wait)
+	}
 
 	if fileContent != nil {
 		log.Info("URL downloaded from - ", fileContent.GetURL())
 	}
 
+	if resp == nil {
+		log.Errorf("Error retreiving information from github repository. Error - %v", err)
+		return nil, nil, network.ClassifyError(err)
+	}
 	defer resp.Body.Close()
 	log.Info("Status code - ", resp.StatusCode)
 	if err != nil {
@@ -73,6 +292,13 @@ func (git *GitClient) GetRepositoryContents(log log.T, owner, repo, path string,
 			log.Error("Unauthorized access attempted. Please specify tokenInfo with correct access information ")
 		}
 		log.Errorf("Error retreiving information from github repository. Error - %v and response - %v", err, resp)
+		if resp.StatusCode == http.StatusUnavailableForLegalReasons {
+			return nil, nil, &LegalUnavailableError{Owner: owner, Repository: repo, Err: err}
+		}
+		if resp.StatusCode == http.StatusNotFound && git.authenticated {
+			// GitHub answers 404 instead of 403 for private repositories the token can't access
+			return nil, nil, fmt.Errorf("Repository %v/%v not found or token lacks access to it. Error - %v", owner, repo, err)
+		}
 		return nil, nil, err
 	} else if resp.StatusCode == http.StatusForbidden && resp.Rate.Limit == 0 {
 		return nil, nil, errors.New("Rate limit exceeded")
@@ -85,6 +311,112 @@ func (git *GitClient) GetRepositoryContents(log log.T, owner, repo, path string,
 	return fileContent, directoryContent, err
 }
 
+// rateLimitReset returns when the primary rate limit resets if err is GitHub answering that the rate limit is exceeded,
+// from the X-RateLimit-Remaining and X-RateLimit-Reset headers of the 403 or 429 response
+func rateLimitReset(err error) (reset time.Time, isRateLimit bool) {
+	switch e := err.(type) {
+	case *github.RateLimitError:
+		// also returned by the github SDK without sending the request until the reset it learnt from an earlier response
+		reset = e.Rate.Reset.Time
+	case *github.ErrorResponse:
+		if e.Response == nil || (e.Response.StatusCode != http.StatusForbidden && e.Response.StatusCode != http.StatusTooManyRequests) ||
+			e.Response.Header.Get("X-RateLimit-Remaining") != "0" {
+			return reset, false
+		}
+		if seconds, parseErr := strconv.ParseInt(e.Response.Header.Get("X-RateLimit-Reset"), 10, 64); parseErr == nil {
+			reset = time.Unix(seconds, 0)
+		}
+	}
+	return reset, !reset.IsZero()
+}
+
+// abuseRateLimitWait returns how long to wait before retrying when err is GitHub's abuse/secondary rate limit response
+func abuseRateLimitWait(err error) (wait time.Duration, isAbuseRateLimit bool) {
+	var retryAfter *time.Duration
+	switch e := err.(type) {
+	case *github.AbuseRateLimitError:
+		retryAfter = e.RetryAfter
+	case *github.ErrorResponse:
+		// newer responses aren't recognized by the github SDK, detect them from the body and headers
+		if e.Response == nil || e.Response.StatusCode != http.StatusForbidden {
+			return 0, false
+		}
+		message := strings.ToLower(e.Message)
+		if !strings.Contains(e.DocumentationURL, "secondary-rate-limits") &&
+			!strings.Contains(e.DocumentationURL, "abuse-rate-limits") &&
+			!strings.Contains(message, "secondary rate limit") &&
+			!strings.Contains(message, "abuse detection") {
+			return 0, false
+		}
+		if seconds, parseErr := strconv.Atoi(e.Response.Header.Get("Retry-After")); parseErr == nil {
+			duration := time.Duration(seconds) * time.Second
+			retryAfter = &duration
+		}
+	default:
+		return 0, false
+	}
+
+	wait = defaultAbuseRateLimitWait
+	if retryAfter != nil && *retryAfter > 0 {
+		wait = *retryAfter
+	}
+	if wait > maxAbuseRateLimitWait {
+		wait = maxAbuseRateLimitWait
+	}
+	return wait, true
+}
+
+// LegalUnavailableError is returned when GitHub blocks access to a repository for legal reasons (451), e.g. a DMCA takedown
+type LegalUnavailableError struct {
+	Owner      string
+	Repository string
+	Err        error
+}
+
+func (e *LegalUnavailableError) Error() string {
+	return fmt.Sprintf("Repository %v/%v is unavailable for legal reasons, retrying won't help. Error - %v", e.Owner, e.Repository, e.Err)
+}
+
+// IsUnavailableForLegalReasons returns true if err is GitHub blocking access to a repository for legal reasons, such requests are not retried
+func IsUnavailableForLegalReasons(err error) bool {
+	_, ok := err.(*LegalUnavailableError)
+	return ok
+}
+
+// checkLegalUnavailable returns a LegalUnavailableError for a 451 response of GitHub,
+// and err with its network failure classified otherwise
+func checkLegalUnavailable(owner, repo string, err error) error {
+	if errorResponse, ok := err.(*github.ErrorResponse); ok && errorResponse.Response != nil &&
+		errorResponse.Response.StatusCode == http.StatusUnavailableForLegalReasons {
+		return &LegalUnavailableError{Owner: owner, Repository: repo, Err: err}
+	}
+	return network.ClassifyError(err)
+}
+
+// IsUnauthorized returns true if err is GitHub refusing the credentials of a request, e.g. because its token expired
+func IsUnauthorized(err error) bool {
+	errorResponse, ok := err.(*github.ErrorResponse)
+	return ok && errorResponse.Response != nil && errorResponse.Response.StatusCode == http.StatusUnauthorized
+}
+
+// IsTransient returns true if err is a failure a later attempt of the request may not have: GitHub answering with 5xx or 429,
+// or the request failing to reach it. TLS handshake failures, e.g. an untrusted certificate, are not transient.
+func IsTransient(err error) bool {
+	switch e := err.(type) {
+	case *github.ErrorResponse:
+		return e.Response != nil && (e.Response.StatusCode >= http.StatusInternalServerError || e.Response.StatusCode == http.StatusTooManyRequests)
+	case *network.Error:
+		return e.Failure != network.FailureTLSHandshake
+	case net.Error:
+		// e.g. the *url.Error of a request that got no response
+		if classified, ok := network.ClassifyError(err).(*network.Error); ok {
+			return classified.Failure != network.FailureTLSHandshake
+		}
+		return true
+	}
+	return false
+}
+
 // ParseGetOptions manipulates the getOptions parameter and returns
 func (git *GitClient) ParseGetOptions(log log.T, getOptions string) (*github.RepositoryContentGetOptions, error) {
 	//If no option is specified, use master branch
@@ -94,30 +426,56 @@ func (git *GitClient) ParseGetOptions(log log.T, getOptions string) (*github.Rep
 		}, nil
 	}
 
+	log.Debug("Splitting getOptions to get the actual option - ", getOptions)
+	ref, err := getOptionsRef(getOptions)
+	if err != nil {
+		return nil, err
+	}
+	log.Info("GetOptions value - ", ref)
+
+	return &github.RepositoryContentGetOptions{
+		Ref: ref,
+	}, nil
+}
+
+// ValidateGetOptions returns the error ParseGetOptions would return for getOptions, without logging
+func ValidateGetOptions(getOptions string) error {
+	if getOptions == "" {
+		return nil
+	}
+	_, err := getOptionsRef(getOptions)
+	return err
+}
+
+// getOptionsRef returns the branch or commit SHA of getOptions
+func getOptionsRef(getOptions string) (string, error) {
 	// Checking for format of extra option specified (if it has been)
 	// Ideal input pattern will either be "branch: <name of branch>" or "commitID: <SHA of commit>"
 	// Only one among the above patterns is valid.
-	log.Debug("Splitting getOptions to get the actual option - ", getOptions)
+	if strings.Contains(getOptions, "branch:") && strings.Contains(getOptions, "commitID:") {
+		return "", errors.New("Specify either a branch or a commitID in getOptions, not both")
+	}
 	branchOrSHA := strings.Split(getOptions, ":")
 	if len(branchOrSHA) == 2 {
 		if strings.Compare(branchOrSHA[0], "branch") != 0 && strings.Compare(branchOrSHA[0], "commitID") != 0 {
-			return nil, errors.New("Type of option is unknown. Please use either 'branch' or 'commitID'.")
+			return "", errors.New("Type of option is unknown. Please use either 'branch' or 'commitID'.")
 		}
 		//Error if extra option has been specified but is empty
 		// Length must be 2 (key and value)
 		if branchOrSHA[1] == "" {
-			return nil, errors.New("Option for retreiving git content is empty")
+			return "", errors.New("Option for retreiving git content is empty")
 		}
 	} else if len(branchOrSHA) > 2 {
-		return nil, errors.New("Only specify one required option")
+		return "", errors.New("Only specify one required option")
 	} else {
-		return nil, errors.New("getOptions is not specified in the right format")
+		return "", errors.New("getOptions is not specified in the right format")
 	}
-	log.Info("GetOptions value - ", branchOrSHA[1])
 
-	return &github.RepositoryContentGetOptions{
-		Ref: strings.TrimSpace(branchOrSHA[1]),
-	}, nil
+	ref := strings.TrimSpace(branchOrSHA[1])
+	if branchOrSHA[0] == "commitID" && !commitIDPattern.MatchString(ref) {
+		return "", fmt.Errorf("commitID %v in getOptions must be a full or abbreviated hexadecimal commit SHA", ref)
+	}
+	return ref, nil
 }
 
 // IsFileContentType returns true if the repository content points to a file
@@ -130,3 +488,184 @@ func (git *GitClient) IsFileContentType(file *github.RepositoryContent) bool {
 	}
 	return false
 }
+
+// GetLatestCommitDate returns the committer date of the latest commit touching path at the ref in opt
+func (git *GitClient) GetLatestCommitDate(log log.T, owner, repo, path string, opt *github.RepositoryContentGetOptions) (time.Time, error) {
+	listOptions := &github.CommitsListOptions{
+		Path:        path,
+		ListOptions: github.ListOptions{PerPage: 1},
+	}
+	if opt != nil {
+		listOptions.SHA = opt.Ref
+	}
+
+	commits, _, err := git.Repositories.ListCommits(gitcontext.Background(), owner, repo, listOptions)
+	if err != nil {
+		log.Errorf("Error listing commits of %v in github repository. Error - %v", path, err)
+		return time.Time{}, err
+	}
+	if len(commits) == 0 || commits[0].Commit == nil || commits[0].Commit.Committer == nil || commits[0].Commit.Committer.Date == nil {
+		return time.Time{}, fmt.Errorf("No commit found for %v", path)
+	}
+
+	return commits[0].Commit.Committer.GetDate(), nil
+}
+
+// GetCommitTreeSha returns the SHA of the tree the given commit points to
+func (git *GitClient) GetCommitTreeSha(log log.T, owner, repo, commitID string) (string, error) {
+	commit, _, err := git.Git.GetCommit(gitcontext.Background(), owner, repo, commitID)
+	if err != nil {
+		log.Errorf("Error retrieving commit %v from github repository. Error - %v", commitID, err)
+		return "", err
+	}
+	if commit.Tree == nil || commit.Tree.GetSHA() == "" {
+		return "", fmt.Errorf("Commit %v has no tree", commitID)
+	}
+	return commit.Tree.GetSHA(), nil
+}
+
+// GetCommitSignature returns the signature verification GitHub reports for the commit the given ref resolves to
+func (git *GitClient) GetCommitSignature(log log.T, owner, repo, ref string) (*github.SignatureVerification, error) {
+	commit, _, err := git.Repositories.GetCommit(gitcontext.Background(), owner, repo, ref)
+	if err != nil {
+		log.Errorf("Error retrieving commit %v from github repository. Error - %v", ref, err)
+		return nil, err
+	}
+	if commit.Commit == nil || commit.Commit.Verification == nil {
+		return nil, fmt.Errorf("No signature verification reported for commit %v", ref)
+	}
+	return commit.Commit.Verification, nil
+}
+
+// GetDefaultBranch returns the name of the default branch of the repository
+func (git *GitClient) GetDefaultBranch(log log.T, owner, repo string) (string, error) {
+	repository, _, err := git.Repositories.Get(gitcontext.Background(), owner, repo)
+	if err != nil {
+		log.Errorf("Error retrieving repository %v/%v from github. Error - %v", owner, repo, err)
+		return "", err
+	}
+	if repository.GetDefaultBranch() == "" {
+		return "", fmt.Errorf("No default branch reported for repository %v/%v", owner, repo)
+	}
+	return repository.GetDefaultBranch(), nil
+}
+
+// GetBlob returns the decoded content of the blob with the given SHA, whatever path or ref it is reachable from
+func (git *GitClient) GetBlob(log log.T, owner, repo, sha string) (string, error) {
+	blob, _, err := git.Git.GetBlob(gitcontext.Background(), owner, repo, sha)
+	if err != nil {
+		log.Errorf("Error retrieving blob %v from github repository. Error - %v", sha, err)
+		return "", checkLegalUnavailable(owner, repo, err)
+	}
+	switch blob.GetEncoding() {
+	case "base64":
+		content, err := base64.StdEncoding.DecodeString(blob.GetContent())
+		if err != nil {
+			return "", fmt.Errorf("Could not decode blob %v - %v", sha, err)
+		}
+		return string(content), nil
+	case "utf-8", "":
+		return blob.GetContent(), nil
+	default:
+		return "", fmt.Errorf("Unsupported encoding %v of blob %v", blob.GetEncoding(), sha)
+	}
+}
+
+// GetTreeModes returns the git file modes, e.g. 100755 for an executable, of the entries of a tree by name.
+// tree is the SHA of the tree or <ref>:<path> for the directory at path.
+func (git *GitClient) GetTreeModes(log log.T, owner, repo, tree string) (map[string]string, error) {
+	result, _, err := git.Git.GetTree(gitcontext.Background(), owner, repo, tree, false)
+	if err != nil {
+		log.Errorf("Error retrieving tree %v from github repository. Error - %v", tree, err)
+		return nil, checkLegalUnavailable(owner, repo, err)
+	}
+	modes := make(map[string]string, len(result.Entries))
+	for _, entry := range result.Entries {
+		modes[entry.GetPath()] = entry.GetMode()
+	}
+	return modes, nil
+}
+
+// ListTags returns the names of all the tags of the repository
+func (git *GitClient) ListTags(log log.T, owner, repo string) ([]string, error) {
+	var names []string
+	opt := &github.ListOptions{PerPage: listPageSize}
+	for {
+		tags, resp, err := git.Repositories.ListTags(gitcontext.Background(), owner, repo, opt)
+		if err != nil {
+			log.Errorf("Error listing tags of %v/%v from github. Error - %v", owner, repo, err)
+			return nil, err
+		}
+		for _, tag := range tags {
+			names = append(names, tag.GetName())
+		}
+		if resp.NextPage == 0 {
+			return names, nil
+		}
+		opt.Page = resp.NextPage
+	}
+}
+
+// ListBranches returns the names of all the branches of the repository
+func (git *GitClient) ListBranches(log log.T, owner, repo string) ([]string, error) {
+	var names []string
+	opt := &github.ListOptions{PerPage: listPageSize}
+	for {
+		branches, resp, err := git.Repositories.ListBranches(gitcontext.Background(), owner, repo, opt)
+		if err != nil {
+			log.Errorf("Error listing branches of %v/%v from github. Error - %v", owner, repo, err)
+			return nil, err
+		}
+		for _, branch := range branches {
+			names = append(names, branch.GetName())
+		}
+		if resp.NextPage == 0 {
+			return names, nil
+		}
+		opt.Page = resp.NextPage
+	}
+}
+
+// GetRawContent returns the content of the file at path as it is read from GitHub, without holding it in memory.
+// The download counts against the shared download limit until the returned reader is closed.
+func (git *GitClient) GetRawContent(log log.T, owner, repo, path string, opt *github.RepositoryContentGetOptions) (io.ReadCloser, error) {
+	u := fmt.Sprintf("repos/%s/%s/contents/%s", owner, repo, (&url.URL{Path: path}).String())
+	if opt != nil && opt.Ref != "" {
+		u += "?ref=" + url.QueryEscape(opt.Ref)
+	}
+	req, err := git.NewRequest("GET", u, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", mediaTypeRaw)
+
+	limiter := network.SharedDownloadLimiter()
+	limiter.Acquire()
+	resp, err := git.httpClient.Do(req)
+	if err != nil {
+		limiter.Release()
+		log.Errorf("Error retrieving %v/%v/%v from github repository. Error - %v", owner, repo, path, err)
+		return nil, network.ClassifyError(err)
+	}
+	if err = github.CheckResponse(resp); err != nil {
+		resp.Body.Close()
+		limiter.Release()
+		log.Errorf("Error retrieving %v/%v/%v from github repository. Error - %v", owner, repo, path, err)
+		return nil, checkLegalUnavailable(owner, repo, err)
+	}
+	return &limitedBody{ReadCloser: resp.Body, release: limiter.Release}, nil
+}
+
+// limitedBody releases its slot of the download limiter once closed
+type limitedBody struct {
+	io.ReadCloser
+	release func()
+	once    sync.Once
+}
+
+// Close closes the response body and releases the download slot
+func (body *limitedBody) Close() error {
+	err := body.ReadCloser.Close()
+	body.once.Do(body.release)
+	return err
+}
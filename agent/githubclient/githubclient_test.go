@@ -17,15 +17,543 @@ package githubclient
 
 import (
 	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/aws/amazon-ssm-agent/agent/network"
 	"github.com/go-github/github"
 	"github.com/stretchr/testify/assert"
 
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
 )
 
 var logMock = log.NewMockLog()
 
-//TODO: Add tests for GetRepositoryContent
+// newTestClient returns a GitClient that sends its requests to a local test server
+func newTestClient(handler http.HandlerFunc, authenticated bool) (*GitClient, *httptest.Server) {
+	server := httptest.NewServer(handler)
+	client := &GitClient{
+		Client:        github.NewClient(nil),
+		httpClient:    http.DefaultClient,
+		authenticated: authenticated,
+	}
+	client.BaseURL, _ = url.Parse(server.URL + "/")
+	return client, server
+}
+
+func notFoundHandler(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusNotFound)
+	w.Write([]byte(`{"message": "Not Found"}`))
+}
+
+func TestGitClient_GetRepositoryContentsNotFoundAuthenticated(t *testing.T) {
+	client, server := newTestClient(notFoundHandler, true)
+	defer server.Close()
+
+	_, _, err := client.GetRepositoryContents(logMock, "owner", "repo", "path", nil)
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "Repository owner/repo not found or token lacks access to it")
+}
+
+func TestGitClient_GetRepositoryContentsNotFoundAnonymous(t *testing.T) {
+	client, server := newTestClient(notFoundHandler, false)
+	defer server.Close()
+
+	_, _, err := client.GetRepositoryContents(logMock, "owner", "repo", "path", nil)
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "404")
+	assert.NotContains(t, err.Error(), "token lacks access")
+}
+
+func TestGitClient_GetRepositoryContentsFile(t *testing.T) {
+	client, server := newTestClient(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/repos/owner/repo/contents/path/file.sh", r.URL.Path)
+		w.Write([]byte(`{"type": "file", "path": "path/file.sh", "content": "Y29udGVudA==", "encoding": "base64"}`))
+	}, false)
+	defer server.Close()
+
+	file, dir, err := client.GetRepositoryContents(logMock, "owner", "repo", "path/file.sh", nil)
+
+	assert.NoError(t, err)
+	assert.Nil(t, dir)
+	content, _ := file.GetContent()
+	assert.Equal(t, "content", content)
+}
+
+// newMirroredTestClient returns a GitClient whose mirror and GitHub API are local test servers
+func newMirroredTestClient(t *testing.T, mirrorHandler, githubHandler http.HandlerFunc) (*GitClient, func()) {
+	mirrorServer := httptest.NewServer(mirrorHandler)
+	githubServer := httptest.NewServer(githubHandler)
+
+	httpClient := &http.Client{Transport: &tokenTransport{token: "token"}}
+	client, err := NewMirroredClient(httpClient, mirrorServer.URL)
+	assert.NoError(t, err)
+	gitClient := client.(*GitClient)
+	gitClient.BaseURL, _ = url.Parse(githubServer.URL + "/")
+
+	return gitClient, func() {
+		mirrorServer.Close()
+		githubServer.Close()
+	}
+}
+
+// tokenTransport adds an authorization header to every request
+type tokenTransport struct {
+	token string
+}
+
+func (t *tokenTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req.Header.Set("Authorization", "token "+t.token)
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func fileHandler(content string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"type": "file", "path": "path/file.sh", "content": "` + content + `", "encoding": "base64"}`))
+	}
+}
+
+func TestGitClient_GetRepositoryContentsFromMirror(t *testing.T) {
+	githubCalled := false
+	client, closeServers := newMirroredTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/repos/owner/repo/contents/path/file.sh", r.URL.Path)
+		assert.Equal(t, "token token", r.Header.Get("Authorization"))
+		fileHandler("bWlycm9y")(w, r)
+	}, func(w http.ResponseWriter, r *http.Request) {
+		githubCalled = true
+		fileHandler("Z2l0aHVi")(w, r)
+	})
+	defer closeServers()
+
+	file, _, err := client.GetRepositoryContents(logMock, "owner", "repo", "path/file.sh", nil)
+
+	assert.NoError(t, err)
+	content, _ := file.GetContent()
+	assert.Equal(t, "mirror", content)
+	assert.False(t, githubCalled)
+}
+
+func TestGitClient_GetRepositoryContentsMirrorFallback(t *testing.T) {
+	data := []struct {
+		name          string
+		mirrorHandler http.HandlerFunc
+	}{
+		{
+			"mirror miss",
+			notFoundHandler,
+		},
+		{
+			"mirror error",
+			func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusBadGateway)
+			},
+		},
+	}
+
+	for _, testdata := range data {
+		t.Run(testdata.name, func(t *testing.T) {
+			client, closeServers := newMirroredTestClient(t, testdata.mirrorHandler, fileHandler("Z2l0aHVi"))
+			defer closeServers()
+
+			file, _, err := client.GetRepositoryContents(logMock, "owner", "repo", "path/file.sh", nil)
+
+			assert.NoError(t, err)
+			content, _ := file.GetContent()
+			assert.Equal(t, "github", content)
+		})
+	}
+}
+
+func TestGitClient_GetRepositoryContentsRateLimitedMirrorFallsBackRightAway(t *testing.T) {
+	data := []struct {
+		name          string
+		mirrorHandler http.HandlerFunc
+	}{
+		{
+			"rate limit",
+			rateLimitHandler(time.Minute),
+		},
+		{
+			"secondary rate limit",
+			abuseRateLimitHandler(1, "https://developer.github.com/v3#abuse-rate-limits", "5"),
+		},
+	}
+
+	sleep = func(d time.Duration) { assert.Fail(t, "unexpected wait") }
+	defer func() { sleep = time.Sleep }()
+
+	for _, testdata := range data {
+		t.Run(testdata.name, func(t *testing.T) {
+			client, closeServers := newMirroredTestClient(t, testdata.mirrorHandler, fileHandler("Z2l0aHVi"))
+			defer closeServers()
+			client.rateLimitMaxWait = 5 * time.Minute
+
+			file, _, err := client.GetRepositoryContents(logMock, "owner", "repo", "path/file.sh", nil)
+
+			assert.NoError(t, err)
+			content, _ := file.GetContent()
+			assert.Equal(t, "github", content)
+		})
+	}
+}
+
+func TestNewClientWithBaseURL(t *testing.T) {
+	server := httptest.NewServer(fileHandler("Y29udGVudA=="))
+	defer server.Close()
+
+	client, err := NewClientWithBaseURL(nil, server.URL+"/api/v3")
+	assert.NoError(t, err)
+	assert.Equal(t, server.URL+"/api/v3/", client.(*GitClient).BaseURL.String())
+
+	file, _, err := client.GetRepositoryContents(logMock, "owner", "repo", "path/file.sh", &github.RepositoryContentGetOptions{})
+	assert.NoError(t, err)
+	content, _ := file.GetContent()
+	assert.Equal(t, "content", content)
+}
+
+func TestNewClientWithBaseURL_InvalidURL(t *testing.T) {
+	_, err := NewClientWithBaseURL(nil, "not a url")
+
+	assert.Error(t, err)
+}
+
+func TestNewEnterpriseClient(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/v3/repos/owner/repo/contents/path/file.sh", r.URL.Path)
+		fileHandler("Y29udGVudA==")(w, r)
+	}))
+	defer server.Close()
+
+	client, err := NewEnterpriseClient(nil, server.URL)
+	assert.NoError(t, err)
+
+	file, _, err := client.GetRepositoryContents(logMock, "owner", "repo", "path/file.sh", &github.RepositoryContentGetOptions{})
+	assert.NoError(t, err)
+	content, _ := file.GetContent()
+	assert.Equal(t, "content", content)
+}
+
+func TestEnterpriseAPIURL(t *testing.T) {
+	data := []struct {
+		endpoint    string
+		expectedURL string
+	}{
+		{"https://github.example.com", "https://github.example.com/api/v3/"},
+		{"https://github.example.com/", "https://github.example.com/api/v3/"},
+		{"https://github.example.com/api/v3", "https://github.example.com/api/v3/"},
+		{"https://example.com/github/", "https://example.com/github/api/v3/"},
+		{"not a url", ""},
+		{"ftp://github.example.com", ""},
+		{"https://github.example.com/?token=secret", ""},
+	}
+	for _, testdata := range data {
+		t.Run(testdata.endpoint, func(t *testing.T) {
+			apiURL, err := EnterpriseAPIURL(testdata.endpoint)
+
+			if testdata.expectedURL == "" {
+				assert.EqualError(t, err, "GitHub Enterprise endpoint "+testdata.endpoint+" is not valid")
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, testdata.expectedURL, apiURL)
+			}
+		})
+	}
+}
+
+func TestAPIHost(t *testing.T) {
+	assert.Equal(t, "api.github.com", APIHost(""))
+	assert.Equal(t, "github.example.com", APIHost("https://github.example.com/api/v3/"))
+	assert.Equal(t, "mirror.example.com:8443", APIHost("https://mirror.example.com:8443/github"))
+	assert.Equal(t, "api.github.com", APIHost("not a url"))
+}
+
+func TestNewMirroredClient_InvalidURL(t *testing.T) {
+	_, err := NewMirroredClient(nil, "not a url")
+
+	assert.Error(t, err)
+}
+
+func TestWithTimeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// the response starts right away, its content is late
+		w.WriteHeader(http.StatusOK)
+		w.(http.Flusher).Flush()
+		time.Sleep(500 * time.Millisecond)
+		w.Write([]byte(`{"type": "file", "encoding": "base64", "content": "Y29udGVudA=="}`))
+	}))
+	defer server.Close()
+
+	client, err := NewClientWithBaseURL(nil, server.URL+"/")
+	assert.NoError(t, err)
+	client = WithTimeout(client, 50*time.Millisecond)
+
+	_, _, err = client.GetRepositoryContents(logMock, "owner", "repo", "path/file.sh", &github.RepositoryContentGetOptions{})
+	assert.Error(t, err)
+	assert.Equal(t, 50*time.Millisecond, client.(*GitClient).httpClient.Timeout)
+	// an anonymous client stays anonymous
+	assert.False(t, client.(*GitClient).authenticated)
+}
+
+// abuseRateLimitHandler answers with the secondary rate limit response for the first failures requests
+func abuseRateLimitHandler(failures int, documentationURL string, retryAfter string) http.HandlerFunc {
+	requests := 0
+	return func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests <= failures {
+			if retryAfter != "" {
+				w.Header().Set("Retry-After", retryAfter)
+			}
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"message": "You have exceeded a secondary rate limit.", "documentation_url": "` + documentationURL + `"}`))
+			return
+		}
+		fileHandler("Y29udGVudA==")(w, r)
+	}
+}
+
+func TestGitClient_GetRepositoryContentsAbuseRateLimit(t *testing.T) {
+	data := []struct {
+		name          string
+		handler       http.HandlerFunc
+		expectedWaits []time.Duration
+		expectedErr   bool
+	}{
+		{
+			"abuse rate limit with retry-after",
+			abuseRateLimitHandler(1, "https://developer.github.com/v3#abuse-rate-limits", "5"),
+			[]time.Duration{5 * time.Second},
+			false,
+		},
+		{
+			"secondary rate limit without retry-after",
+			abuseRateLimitHandler(2, "https://docs.github.com/rest/overview/resources-in-the-rest-api#secondary-rate-limits", ""),
+			[]time.Duration{defaultAbuseRateLimitWait, defaultAbuseRateLimitWait},
+			false,
+		},
+		{
+			"retry-after is bounded",
+			abuseRateLimitHandler(1, "https://developer.github.com/v3#abuse-rate-limits", "3600"),
+			[]time.Duration{maxAbuseRateLimitWait},
+			false,
+		},
+		{
+			"retries are bounded",
+			abuseRateLimitHandler(maxAbuseRateLimitRetries+1, "https://developer.github.com/v3#abuse-rate-limits", "1"),
+			[]time.Duration{time.Second, time.Second, time.Second},
+			true,
+		},
+	}
+
+	defer func() { sleep = time.Sleep }()
+	for _, testdata := range data {
+		t.Run(testdata.name, func(t *testing.T) {
+			var waits []time.Duration
+			sleep = func(d time.Duration) { waits = append(waits, d) }
+
+			client, server := newTestClient(testdata.handler, false)
+			defer server.Close()
+
+			file, _, err := client.GetRepositoryContents(logMock, "owner", "repo", "path/file.sh", nil)
+
+			assert.Equal(t, testdata.expectedWaits, waits)
+			if testdata.expectedErr {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), "secondary rate limit")
+			} else {
+				assert.NoError(t, err)
+				content, _ := file.GetContent()
+				assert.Equal(t, "content", content)
+			}
+		})
+	}
+}
+
+// rateLimitHandler answers with the exceeded rate limit response, resetting after resetIn, until it resets
+func rateLimitHandler(resetIn time.Duration) http.HandlerFunc {
+	reset := time.Now().Add(resetIn)
+	return func(w http.ResponseWriter, r *http.Request) {
+		if time.Now().Before(reset) {
+			w.Header().Set("X-RateLimit-Limit", "60")
+			w.Header().Set("X-RateLimit-Remaining", "0")
+			w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(reset.Unix()+1, 10))
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"message": "API rate limit exceeded for 127.0.0.1."}`))
+			return
+		}
+		fileHandler("Y29udGVudA==")(w, r)
+	}
+}
+
+func TestGitClient_GetRepositoryContentsRateLimit(t *testing.T) {
+	var waits []time.Duration
+	sleep = func(d time.Duration) {
+		waits = append(waits, d)
+		time.Sleep(d)
+	}
+	defer func() { sleep = time.Sleep }()
+
+	client, server := newTestClient(rateLimitHandler(2*time.Second), false)
+	defer server.Close()
+	client.rateLimitMaxWait = time.Minute
+
+	file, _, err := client.GetRepositoryContents(logMock, "owner", "repo", "path/file.sh", nil)
+
+	assert.NoError(t, err)
+	content, _ := file.GetContent()
+	assert.Equal(t, "content", content)
+	assert.NotEmpty(t, waits)
+	var waited time.Duration
+	for _, wait := range waits {
+		waited += wait
+	}
+	assert.True(t, waited >= 2*time.Second && waited <= 5*time.Second, "waited %v", waited)
+}
+
+func TestGitClient_GetRepositoryContentsRateLimitBeyondMaxWait(t *testing.T) {
+	sleep = func(d time.Duration) { assert.Fail(t, "unexpected wait") }
+	defer func() { sleep = time.Sleep }()
+
+	client, server := newTestClient(rateLimitHandler(time.Hour), false)
+	defer server.Close()
+	client.rateLimitMaxWait = time.Minute
+
+	_, _, err := client.GetRepositoryContents(logMock, "owner", "repo", "path/file.sh", nil)
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "later than the agent waits for it")
+}
+
+func TestGitClient_GetRepositoryContentsRetryBudget(t *testing.T) {
+	network.SetSharedRetryBudget(network.NewRetryBudget(1))
+	defer network.SetSharedRetryBudget(nil)
+	var waits []time.Duration
+	sleep = func(d time.Duration) { waits = append(waits, d) }
+	defer func() { sleep = time.Sleep }()
+
+	client, server := newTestClient(abuseRateLimitHandler(maxAbuseRateLimitRetries, "https://developer.github.com/v3#abuse-rate-limits", "1"), false)
+	defer server.Close()
+
+	_, _, err := client.GetRepositoryContents(logMock, "owner", "repo", "path/file.sh", nil)
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "retry budget")
+	assert.Len(t, waits, 1)
+}
+
+func TestGitClient_UnavailableForLegalReasons(t *testing.T) {
+	sleep = func(d time.Duration) { assert.Fail(t, "unexpected retry") }
+	defer func() { sleep = time.Sleep }()
+	requests := 0
+	client, server := newTestClient(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusUnavailableForLegalReasons)
+		w.Write([]byte(`{"message": "Repository access blocked", "block": {"reason": "dmca"}}`))
+	}, false)
+	defer server.Close()
+
+	_, _, err := client.GetRepositoryContents(logMock, "owner", "repo", "path/file.sh", nil)
+	assert.True(t, IsUnavailableForLegalReasons(err))
+	assert.Contains(t, err.Error(), "Repository owner/repo is unavailable for legal reasons")
+	assert.Equal(t, 1, requests)
+
+	_, err = client.GetRawContent(logMock, "owner", "repo", "path/file.sh", nil)
+	assert.True(t, IsUnavailableForLegalReasons(err))
+	assert.Equal(t, 2, requests)
+
+	assert.False(t, IsUnavailableForLegalReasons(errors.New("connection reset by peer")))
+}
+
+func TestGitClient_GetRepositoryContentsForbiddenIsNotRetried(t *testing.T) {
+	sleep = func(d time.Duration) { assert.Fail(t, "unexpected retry") }
+	defer func() { sleep = time.Sleep }()
+
+	client, server := newTestClient(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		w.Write([]byte(`{"message": "Resource not accessible by integration"}`))
+	}, true)
+	defer server.Close()
+
+	_, _, err := client.GetRepositoryContents(logMock, "owner", "repo", "path/file.sh", nil)
+
+	assert.Error(t, err)
+}
+
+func TestGitClient_GetCommitTreeSha(t *testing.T) {
+	client, server := newTestClient(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/repos/owner/repo/git/commits/abc123", r.URL.Path)
+		w.Write([]byte(`{"sha": "abc123", "tree": {"sha": "def456"}}`))
+	}, false)
+	defer server.Close()
+
+	treeSha, err := client.GetCommitTreeSha(logMock, "owner", "repo", "abc123")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "def456", treeSha)
+}
+
+func TestGitClient_GetCommitTreeShaNotFound(t *testing.T) {
+	client, server := newTestClient(notFoundHandler, false)
+	defer server.Close()
+
+	_, err := client.GetCommitTreeSha(logMock, "owner", "repo", "abc123")
+
+	assert.Error(t, err)
+}
+
+func TestGitClient_GetCommitSignature(t *testing.T) {
+	client, server := newTestClient(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/repos/owner/repo/commits/v1.0", r.URL.Path)
+		w.Write([]byte(`{"sha": "abc123", "commit": {"verification": {"verified": true, "reason": "valid", "signature": "sig"}}}`))
+	}, false)
+	defer server.Close()
+
+	verification, err := client.GetCommitSignature(logMock, "owner", "repo", "v1.0")
+
+	assert.NoError(t, err)
+	assert.True(t, verification.GetVerified())
+	assert.Equal(t, "valid", verification.GetReason())
+	assert.Equal(t, "sig", verification.GetSignature())
+}
+
+func TestGitClient_GetCommitSignatureMissing(t *testing.T) {
+	client, server := newTestClient(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"sha": "abc123", "commit": {}}`))
+	}, false)
+	defer server.Close()
+
+	_, err := client.GetCommitSignature(logMock, "owner", "repo", "abc123")
+
+	assert.Error(t, err)
+}
+
+func TestGitClient_GetDefaultBranch(t *testing.T) {
+	client, server := newTestClient(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/repos/owner/repo", r.URL.Path)
+		w.Write([]byte(`{"id": 1, "name": "repo", "full_name": "owner/repo", "default_branch": "main"}`))
+	}, false)
+	defer server.Close()
+
+	branch, err := client.GetDefaultBranch(logMock, "owner", "repo")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "main", branch)
+}
+
+func TestGitClient_GetDefaultBranchFails(t *testing.T) {
+	client, server := newTestClient(notFoundHandler, false)
+	defer server.Close()
+
+	_, err := client.GetDefaultBranch(logMock, "owner", "repo")
+
+	assert.Error(t, err)
+}
 
 func TestGitClient_ParseGetOptions(t *testing.T) {
 	client := NewClient(nil)
@@ -65,6 +593,57 @@ func TestGitClient_ParseGetOptionsInvalidFormat(t *testing.T) {
 
 }
 
+func TestGitClient_ParseGetOptionsCommitID(t *testing.T) {
+	client := NewClient(nil)
+	data := []struct {
+		name          string
+		getOptions    string
+		expectedRef   string
+		expectedError string
+	}{
+		{"full sha", "commitID:3b18e512dba79e4c8300dd08aeb37f8e728b8dad", "3b18e512dba79e4c8300dd08aeb37f8e728b8dad", ""},
+		{"short sha", "commitID: 3b18e51", "3b18e51", ""},
+		{"sha-256", "commitID:" + strings.Repeat("ab", 32), strings.Repeat("ab", 32), ""},
+		{"branch name", "commitID:main", "", "must be a full or abbreviated hexadecimal commit SHA"},
+		{"too short", "commitID:3b1", "", "must be a full or abbreviated hexadecimal commit SHA"},
+		{"too long", "commitID:3b18e512dba79e4c8300dd08aeb37f8e728b8dad0", "", "must be a full or abbreviated hexadecimal commit SHA"},
+		{"branch and commit", "branch:main,commitID:3b18e51", "", "not both"},
+	}
+	for _, testdata := range data {
+		t.Run(testdata.name, func(t *testing.T) {
+			opt, err := client.ParseGetOptions(logMock, testdata.getOptions)
+			validationErr := ValidateGetOptions(testdata.getOptions)
+
+			if testdata.expectedError == "" {
+				assert.NoError(t, err)
+				assert.NoError(t, validationErr)
+				assert.Equal(t, testdata.expectedRef, opt.Ref)
+			} else {
+				assert.Nil(t, opt)
+				assert.Contains(t, err.Error(), testdata.expectedError)
+				assert.Equal(t, err, validationErr)
+			}
+		})
+	}
+	assert.NoError(t, ValidateGetOptions(""))
+}
+
+func TestGitClient_GetRepositoryContentsAtCommit(t *testing.T) {
+	client, server := newTestClient(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "3b18e512dba79e4c8300dd08aeb37f8e728b8dad", r.URL.Query().Get("ref"))
+		fileHandler("Y29udGVudA==")(w, r)
+	}, false)
+	defer server.Close()
+
+	opt, err := client.ParseGetOptions(logMock, "commitID:3b18e512dba79e4c8300dd08aeb37f8e728b8dad")
+	assert.NoError(t, err)
+	file, _, err := client.GetRepositoryContents(logMock, "owner", "repo", "path/file.sh", opt)
+
+	assert.NoError(t, err)
+	content, _ := file.GetContent()
+	assert.Equal(t, "content", content)
+}
+
 func TestGitClient_ParseGetOptionsTooManyOptions(t *testing.T) {
 	client := NewClient(nil)
 	var expected *github.RepositoryContentGetOptions
@@ -120,3 +699,154 @@ func Test_isFileContentTypeNil(t *testing.T) {
 
 	assert.False(t, isFile)
 }
+
+func TestGitClient_GetRepositoryContentsEmptyFile(t *testing.T) {
+	client, server := newTestClient(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"type": "file", "encoding": "base64", "size": 0, "path": "empty.txt", "content": ""}`))
+	}, false)
+	defer server.Close()
+
+	fileContent, directoryContent, err := client.GetRepositoryContents(logMock, "owner", "repo", "empty.txt", &github.RepositoryContentGetOptions{})
+	assert.NoError(t, err)
+	assert.Nil(t, directoryContent)
+	assert.True(t, client.IsFileContentType(fileContent))
+	content, err := fileContent.GetContent()
+	assert.NoError(t, err)
+	assert.Empty(t, content)
+}
+
+func TestGitClient_GetBlob(t *testing.T) {
+	client, server := newTestClient(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/repos/owner/repo/git/blobs/3b18e512dba79e4c8300dd08aeb37f8e728b8dad" {
+			notFoundHandler(w, r)
+			return
+		}
+		w.Write([]byte(`{"sha": "3b18e512dba79e4c8300dd08aeb37f8e728b8dad", "size": 12, "encoding": "base64", "content": "aGVsbG8g\nd29ybGQK\n"}`))
+	}, false)
+	defer server.Close()
+
+	content, err := client.GetBlob(logMock, "owner", "repo", "3b18e512dba79e4c8300dd08aeb37f8e728b8dad")
+	assert.NoError(t, err)
+	assert.Equal(t, "hello world\n", content)
+
+	_, err = client.GetBlob(logMock, "owner", "repo", "0000000000000000000000000000000000000000")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "404")
+}
+
+func TestGitClient_GetTreeModes(t *testing.T) {
+	client, server := newTestClient(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/repos/owner/repo/git/trees/master:scripts", r.URL.Path)
+		w.Write([]byte(`{"sha": "tree", "tree": [
+			{"path": "run.sh", "mode": "100755", "type": "blob"},
+			{"path": "README.md", "mode": "100644", "type": "blob"},
+			{"path": "lib", "mode": "040000", "type": "tree"}
+		]}`))
+	}, false)
+	defer server.Close()
+
+	modes, err := client.GetTreeModes(logMock, "owner", "repo", "master:scripts")
+
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"run.sh": "100755", "README.md": "100644", "lib": "040000"}, modes)
+}
+
+func TestGitClient_GetRawContent(t *testing.T) {
+	client, server := newTestClient(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/repos/owner/repo/contents/path/file.sh", r.URL.Path)
+		assert.Equal(t, "v1.0", r.URL.Query().Get("ref"))
+		assert.Equal(t, mediaTypeRaw, r.Header.Get("Accept"))
+		w.Write([]byte("echo hello"))
+	}, false)
+	defer server.Close()
+
+	body, err := client.GetRawContent(logMock, "owner", "repo", "path/file.sh", &github.RepositoryContentGetOptions{Ref: "v1.0"})
+	assert.NoError(t, err)
+	content, err := ioutil.ReadAll(body)
+	assert.NoError(t, err)
+	assert.NoError(t, body.Close())
+	assert.Equal(t, "echo hello", string(content))
+}
+
+func TestGitClient_GetRawContentNotFound(t *testing.T) {
+	client, server := newTestClient(notFoundHandler, false)
+	defer server.Close()
+
+	_, err := client.GetRawContent(logMock, "owner", "repo", "path/file.sh", nil)
+	assert.Error(t, err)
+}
+
+func TestIsUnauthorized(t *testing.T) {
+	unauthorized := &github.ErrorResponse{Response: &http.Response{StatusCode: http.StatusUnauthorized}}
+	notFound := &github.ErrorResponse{Response: &http.Response{StatusCode: http.StatusNotFound}}
+
+	assert.True(t, IsUnauthorized(unauthorized))
+	assert.False(t, IsUnauthorized(notFound))
+	assert.False(t, IsUnauthorized(&github.ErrorResponse{}))
+	assert.False(t, IsUnauthorized(nil))
+}
+
+func TestIsTransient(t *testing.T) {
+	data := []struct {
+		name      string
+		err       error
+		transient bool
+	}{
+		{"server error", &github.ErrorResponse{Response: &http.Response{StatusCode: http.StatusBadGateway}}, true},
+		{"too many requests", &github.ErrorResponse{Response: &http.Response{StatusCode: http.StatusTooManyRequests}}, true},
+		{"not found", &github.ErrorResponse{Response: &http.Response{StatusCode: http.StatusNotFound}}, false},
+		{"unauthorized", &github.ErrorResponse{Response: &http.Response{StatusCode: http.StatusUnauthorized}}, false},
+		{"connection reset", &url.Error{Op: "Get", URL: "https://api.github.com", Err: errors.New("connection reset by peer")}, true},
+		{"classified timeout", &network.Error{Failure: network.FailureReadTimeout, Err: errors.New("i/o timeout")}, true},
+		{"tls handshake", &network.Error{Failure: network.FailureTLSHandshake, Err: errors.New("bad certificate")}, false},
+		{"legal reasons", &LegalUnavailableError{Owner: "owner", Repository: "repo", Err: errors.New("451")}, false},
+		{"other", errors.New("Option for retreiving git content is empty"), false},
+		{"nil", nil, false},
+	}
+	for _, testdata := range data {
+		t.Run(testdata.name, func(t *testing.T) {
+			assert.Equal(t, testdata.transient, IsTransient(testdata.err))
+		})
+	}
+}
+
+func TestGitClient_ListTags(t *testing.T) {
+	var server *httptest.Server
+	client, server := newTestClient(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/repos/owner/repo/tags", r.URL.Path)
+		if r.URL.Query().Get("page") == "2" {
+			w.Write([]byte(`[{"name": "v1.0.0"}]`))
+			return
+		}
+		w.Header().Set("Link", `<`+server.URL+`/repos/owner/repo/tags?page=2>; rel="next"`)
+		w.Write([]byte(`[{"name": "v2.0.0"}, {"name": "v1.1.0"}]`))
+	}, false)
+	defer server.Close()
+
+	tags, err := client.ListTags(logMock, "owner", "repo")
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"v2.0.0", "v1.1.0", "v1.0.0"}, tags)
+}
+
+func TestGitClient_ListBranches(t *testing.T) {
+	client, server := newTestClient(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/repos/owner/repo/branches", r.URL.Path)
+		w.Write([]byte(`[{"name": "main"}, {"name": "release-1"}]`))
+	}, false)
+	defer server.Close()
+
+	branches, err := client.ListBranches(logMock, "owner", "repo")
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"main", "release-1"}, branches)
+}
+
+func TestGitClient_ListTagsFails(t *testing.T) {
+	client, server := newTestClient(notFoundHandler, false)
+	defer server.Close()
+
+	_, err := client.ListTags(logMock, "owner", "repo")
+
+	assert.Error(t, err)
+}
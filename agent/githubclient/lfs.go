@@ -0,0 +1,174 @@
+// Copyright 2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package githubclient
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/aws/amazon-ssm-agent/agent/network"
+	"github.com/go-github/github"
+)
+
+const (
+	// mediaTypeLFS is the media type of the requests and responses of the Git LFS batch API
+	mediaTypeLFS = "application/vnd.git-lfs+json"
+	// githubAPIHost is the host of the API of github.com
+	githubAPIHost = "api.github.com"
+	// githubHost serves the Git LFS API of the repositories whose API is api.github.com
+	githubHost = "github.com"
+)
+
+// lfsBatchRequest asks the Git LFS batch API where to download objects from
+type lfsBatchRequest struct {
+	Operation string      `json:"operation"`
+	Transfers []string    `json:"transfers"`
+	Objects   []lfsObject `json:"objects"`
+}
+
+// lfsBatchResponse lists the objects of an lfsBatchRequest with the actions to download them, or why they can't be
+type lfsBatchResponse struct {
+	Objects []lfsObject `json:"objects"`
+}
+
+// lfsObject is a Git LFS object, named by the SHA-256 of its content
+type lfsObject struct {
+	Oid     string               `json:"oid"`
+	Size    int64                `json:"size"`
+	Actions map[string]lfsAction `json:"actions,omitempty"`
+	Error   *lfsObjectError      `json:"error,omitempty"`
+}
+
+// lfsAction is the request to make to transfer an object, its headers authorize it
+type lfsAction struct {
+	Href   string            `json:"href"`
+	Header map[string]string `json:"header"`
+}
+
+// lfsObjectError tells why an object of a batch can't be transferred
+type lfsObjectError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// GetLFSObject returns the content of the Git LFS object of the repository with the given SHA-256 oid and size.
+// The batch API is asked for the object with the authorization of the client, the object is then downloaded
+// with the authorization the batch API returns for it and verified against its oid and size.
+func (git *GitClient) GetLFSObject(log log.T, owner, repo, oid string, size int64) (string, error) {
+	download, err := git.lfsDownloadAction(log, owner, repo, oid, size)
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequest("GET", download.Href, nil)
+	if err != nil {
+		return "", err
+	}
+	for name, value := range download.Header {
+		req.Header.Set(name, value)
+	}
+	limiter := network.SharedDownloadLimiter()
+	limiter.Acquire()
+	defer limiter.Release()
+	// the object may be served by another host, which must not be sent the token of the client, only its timeout applies
+	resp, err := (&http.Client{Transport: network.DefaultTransport(), Timeout: git.httpClient.Timeout}).Do(req)
+	if err != nil {
+		log.Errorf("Error downloading Git LFS object %v of %v/%v. Error - %v", oid, owner, repo, err)
+		return "", network.ClassifyError(err)
+	}
+	defer resp.Body.Close()
+	if err = github.CheckResponse(resp); err != nil {
+		log.Errorf("Error downloading Git LFS object %v of %v/%v. Error - %v", oid, owner, repo, err)
+		return "", err
+	}
+	content, err := ioutil.ReadAll(io.LimitReader(resp.Body, size+1))
+	if err != nil {
+		return "", network.ClassifyError(err)
+	}
+	if int64(len(content)) != size || !strings.EqualFold(fmt.Sprintf("%x", sha256.Sum256(content)), oid) {
+		return "", fmt.Errorf("Content of Git LFS object %v of %v/%v does not match its oid and size %v", oid, owner, repo, size)
+	}
+	return string(content), nil
+}
+
+// lfsDownloadAction asks the Git LFS batch API of the repository how to download the object with the given oid and size
+func (git *GitClient) lfsDownloadAction(log log.T, owner, repo, oid string, size int64) (*lfsAction, error) {
+	body, err := json.Marshal(lfsBatchRequest{
+		Operation: "download",
+		Transfers: []string{"basic"},
+		Objects:   []lfsObject{{Oid: oid, Size: size}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest("POST", git.lfsBatchURL(owner, repo), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", mediaTypeLFS)
+	req.Header.Set("Content-Type", mediaTypeLFS)
+
+	log.Debugf("Requesting Git LFS object %v of %v/%v", oid, owner, repo)
+	resp, err := git.httpClient.Do(req)
+	if err != nil {
+		log.Errorf("Error requesting Git LFS object %v of %v/%v. Error - %v", oid, owner, repo, err)
+		return nil, network.ClassifyError(err)
+	}
+	defer resp.Body.Close()
+	if err = github.CheckResponse(resp); err != nil {
+		log.Errorf("Error requesting Git LFS object %v of %v/%v. Error - %v", oid, owner, repo, err)
+		return nil, checkLegalUnavailable(owner, repo, err)
+	}
+	var batch lfsBatchResponse
+	if err = json.NewDecoder(resp.Body).Decode(&batch); err != nil {
+		return nil, fmt.Errorf("Could not parse the Git LFS batch response for object %v of %v/%v - %v", oid, owner, repo, err)
+	}
+	for _, object := range batch.Objects {
+		if !strings.EqualFold(object.Oid, oid) {
+			continue
+		}
+		if object.Error != nil {
+			return nil, fmt.Errorf("Git LFS object %v of %v/%v can't be downloaded - %v %v", oid, owner, repo, object.Error.Code, object.Error.Message)
+		}
+		if download, found := object.Actions["download"]; found && download.Href != "" {
+			return &download, nil
+		}
+	}
+	return nil, fmt.Errorf("Git LFS batch response has no download for object %v of %v/%v", oid, owner, repo)
+}
+
+// lfsBatchURL returns the Git LFS batch API of the repository, served by github.com for api.github.com
+// and by the server itself for a GitHub Enterprise API under api/v3/
+func (git *GitClient) lfsBatchURL(owner, repo string) string {
+	server := *git.BaseURL
+	if server.Host == githubAPIHost {
+		server.Host = githubHost
+		server.Path = "/"
+	} else {
+		server.Path = strings.TrimSuffix(server.Path, "api/v3/")
+	}
+	if !strings.HasSuffix(server.Path, "/") {
+		server.Path += "/"
+	}
+	server.RawQuery = ""
+	return server.String() + fmt.Sprintf("%s/%s.git/info/lfs/objects/batch", owner, repo)
+}
@@ -0,0 +1,113 @@
+// Copyright 2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package githubclient
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// the SHA-256 oid of "binary asset"
+const testLfsOid = "9a6006e812cbdb4d8e7a5188a7c767a14f5bdac313753a844ecb4a6aa3ac8cf5"
+
+// lfsHandler serves the batch API of owner/repo, answering with objectJSON for the object, and the object itself with content.
+// {server} in objectJSON is replaced with the URL of the test server.
+func lfsHandler(t *testing.T, objectJSON string, content string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/owner/repo.git/info/lfs/objects/batch":
+			assert.Equal(t, "POST", r.Method)
+			assert.Equal(t, mediaTypeLFS, r.Header.Get("Accept"))
+			var batch lfsBatchRequest
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&batch))
+			assert.Equal(t, "download", batch.Operation)
+			w.Header().Set("Content-Type", mediaTypeLFS)
+			w.Write([]byte(`{"objects": [` + strings.Replace(objectJSON, "{server}", "http://"+r.Host, 1) + `]}`))
+		case "/objects/" + testLfsOid:
+			assert.Equal(t, "RemoteAuth secret", r.Header.Get("Authorization"))
+			w.Write([]byte(content))
+		default:
+			notFoundHandler(w, r)
+		}
+	}
+}
+
+func TestGitClient_GetLFSObject(t *testing.T) {
+	download := `{"oid": "` + testLfsOid + `", "size": 12, "actions": {"download": {"href": "{server}/objects/` + testLfsOid + `", "header": {"Authorization": "RemoteAuth secret"}}}}`
+	tests := []struct {
+		name       string
+		objectJSON string
+		content    string
+		err        string
+	}{
+		{"downloaded", download, "binary asset", ""},
+		{"corrupted", download, "binary assex", "Content of Git LFS object " + testLfsOid + " of owner/repo does not match its oid and size 12"},
+		{"truncated", download, "binary", "Content of Git LFS object " + testLfsOid + " of owner/repo does not match its oid and size 12"},
+		{"object error", `{"oid": "` + testLfsOid + `", "size": 12, "error": {"code": 404, "message": "Object does not exist"}}`, "", "Git LFS object " + testLfsOid + " of owner/repo can't be downloaded - 404 Object does not exist"},
+		{"no download", `{"oid": "` + testLfsOid + `", "size": 12}`, "", "Git LFS batch response has no download for object " + testLfsOid + " of owner/repo"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			client, server := newTestClient(lfsHandler(t, test.objectJSON, test.content), true)
+			defer server.Close()
+
+			content, err := client.GetLFSObject(logMock, "owner", "repo", testLfsOid, 12)
+
+			if test.err == "" {
+				assert.NoError(t, err)
+				assert.Equal(t, test.content, content)
+			} else {
+				assert.EqualError(t, err, test.err)
+			}
+		})
+	}
+}
+
+func TestGitClient_GetLFSObjectUnauthorized(t *testing.T) {
+	client, server := newTestClient(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(`{"message": "Bad credentials"}`))
+	}, true)
+	defer server.Close()
+
+	_, err := client.GetLFSObject(logMock, "owner", "repo", testLfsOid, 12)
+
+	assert.True(t, IsUnauthorized(err))
+}
+
+func TestGitClient_LfsBatchURL(t *testing.T) {
+	tests := []struct {
+		apiURL   string
+		expected string
+	}{
+		{"https://api.github.com/", "https://github.com/owner/repo.git/info/lfs/objects/batch"},
+		{"https://github.example.com/api/v3/", "https://github.example.com/owner/repo.git/info/lfs/objects/batch"},
+		{"https://example.com/github/api/v3/", "https://example.com/github/owner/repo.git/info/lfs/objects/batch"},
+	}
+	for _, test := range tests {
+		t.Run(test.apiURL, func(t *testing.T) {
+			baseURL, _ := url.Parse(test.apiURL)
+			client := NewClient(nil).(*GitClient)
+			client.BaseURL = baseURL
+
+			assert.Equal(t, test.expected, client.lfsBatchURL("owner", "repo"))
+		})
+	}
+}
@@ -20,7 +20,9 @@ import (
 	"github.com/go-github/github"
 	"github.com/stretchr/testify/mock"
 
+	"io"
 	"net/http"
+	"time"
 )
 
 type ClientMock struct {
@@ -42,6 +44,60 @@ func (git_mock *ClientMock) IsFileContentType(content *github.RepositoryContent)
 	return args.Bool(0)
 }
 
+func (git_mock *ClientMock) GetLatestCommitDate(log log.T, owner, repo, path string, opt *github.RepositoryContentGetOptions) (time.Time, error) {
+	args := git_mock.Called(log, owner, repo, path, opt)
+	return args.Get(0).(time.Time), args.Error(1)
+}
+
+func (git_mock *ClientMock) GetCommitTreeSha(log log.T, owner, repo, commitID string) (string, error) {
+	args := git_mock.Called(log, owner, repo, commitID)
+	return args.String(0), args.Error(1)
+}
+
+func (git_mock *ClientMock) GetCommitSignature(log log.T, owner, repo, ref string) (*github.SignatureVerification, error) {
+	args := git_mock.Called(log, owner, repo, ref)
+	return args.Get(0).(*github.SignatureVerification), args.Error(1)
+}
+
+func (git_mock *ClientMock) GetDefaultBranch(log log.T, owner, repo string) (string, error) {
+	args := git_mock.Called(log, owner, repo)
+	return args.String(0), args.Error(1)
+}
+
+func (git_mock *ClientMock) GetRawContent(log log.T, owner, repo, path string, opt *github.RepositoryContentGetOptions) (io.ReadCloser, error) {
+	args := git_mock.Called(log, owner, repo, path, opt)
+	body, _ := args.Get(0).(io.ReadCloser)
+	return body, args.Error(1)
+}
+
+func (git_mock *ClientMock) GetBlob(log log.T, owner, repo, sha string) (string, error) {
+	args := git_mock.Called(log, owner, repo, sha)
+	return args.String(0), args.Error(1)
+}
+
+func (git_mock *ClientMock) GetLFSObject(log log.T, owner, repo, oid string, size int64) (string, error) {
+	args := git_mock.Called(log, owner, repo, oid, size)
+	return args.String(0), args.Error(1)
+}
+
+func (git_mock *ClientMock) GetTreeModes(log log.T, owner, repo, tree string) (map[string]string, error) {
+	args := git_mock.Called(log, owner, repo, tree)
+	modes, _ := args.Get(0).(map[string]string)
+	return modes, args.Error(1)
+}
+
+func (git_mock *ClientMock) ListTags(log log.T, owner, repo string) ([]string, error) {
+	args := git_mock.Called(log, owner, repo)
+	tags, _ := args.Get(0).([]string)
+	return tags, args.Error(1)
+}
+
+func (git_mock *ClientMock) ListBranches(log log.T, owner, repo string) ([]string, error) {
+	args := git_mock.Called(log, owner, repo)
+	branches, _ := args.Get(0).([]string)
+	return branches, args.Error(1)
+}
+
 type OAuthClientMock struct {
 	mock.Mock
 }
@@ -16,6 +16,7 @@
 package githubclient
 
 import (
+	"github.com/aws/amazon-ssm-agent/agent/network"
 	gitcontext "golang.org/x/net/context"
 
 	"net/http"
@@ -34,7 +35,8 @@ type IOAuthClient interface {
 // GetGithubOauthClient returns the http client using oauth access tokens
 // implementation of this has been taken from https://github.com/google/go-github#authentication
 func (git OAuthClient) GetGithubOauthClient(token string) *http.Client {
-	ctx := gitcontext.Background()
+	// the oauth client wraps the http client found in the context
+	ctx := gitcontext.WithValue(gitcontext.Background(), oauth2.HTTPClient, &http.Client{Transport: network.DefaultTransport()})
 	ts := oauth2.StaticTokenSource(
 		&oauth2.Token{AccessToken: token},
 	)
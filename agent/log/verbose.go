@@ -0,0 +1,53 @@
+// Copyright 2016 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+
+package log
+
+// VerboseLogger writes Trace and Debug messages at Info level so that a single operation
+// can be troubleshot without lowering the log level of the whole agent.
+type VerboseLogger struct {
+	T
+}
+
+// Verbose returns a VerboseLogger around logger when verbose is set, and logger itself otherwise.
+func Verbose(logger T, verbose bool) T {
+	if !verbose {
+		return logger
+	}
+	return &VerboseLogger{T: logger}
+}
+
+// Tracef formats message according to format specifier
+// and writes to log with level = Info.
+func (v *VerboseLogger) Tracef(format string, params ...interface{}) {
+	v.T.Infof(format, params...)
+}
+
+// Debugf formats message according to format specifier
+// and writes to log with level = Info.
+func (v *VerboseLogger) Debugf(format string, params ...interface{}) {
+	v.T.Infof(format, params...)
+}
+
+// Trace formats message using the default formats for its operands
+// and writes to log with level = Info.
+func (v *VerboseLogger) Trace(params ...interface{}) {
+	v.T.Info(params...)
+}
+
+// Debug formats message using the default formats for its operands
+// and writes to log with level = Info.
+func (v *VerboseLogger) Debug(params ...interface{}) {
+	v.T.Info(params...)
+}
@@ -0,0 +1,36 @@
+// Copyright 2016 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package log
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVerbose(t *testing.T) {
+	logger := NewMockLog()
+
+	assert.Equal(t, logger, Verbose(logger, false))
+
+	verbose := Verbose(logger, true)
+	verbose.Debugf("debug %v", 1)
+	verbose.Trace("trace")
+	verbose.Errorf("error %v", 2)
+
+	logger.AssertCalled(t, "Infof", "debug %v", []interface{}{1})
+	logger.AssertCalled(t, "Info", []interface{}{"trace"})
+	logger.AssertCalled(t, "Errorf", "error %v", []interface{}{2})
+	logger.AssertNotCalled(t, "Debugf", "debug %v", []interface{}{1})
+}
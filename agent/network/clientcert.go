@@ -0,0 +1,59 @@
+// Copyright 2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package network
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/aws/amazon-ssm-agent/agent/ssmparameterresolver"
+)
+
+// resolveParameters replaces the parameter references in text with their values, it is a seam for tests
+var resolveParameters = func(log log.T, text string) (string, error) {
+	service := ssmparameterresolver.NewService()
+	return ssmparameterresolver.ResolveParametersInText(&service, log, text, ssmparameterresolver.ResolveOptions{IgnoreSecureParameters: false})
+}
+
+// ClientCertificate returns the client certificate of mutual TLS from its PEM certificate and private key,
+// each given as a file path or a {{ssm:name}} or {{ssm-secure:name}} reference to a parameter holding the PEM
+func ClientCertificate(log log.T, certificate string, key string) (tls.Certificate, error) {
+	if certificate == "" || key == "" {
+		return tls.Certificate{}, errors.New("mutual TLS requires both a client certificate and a client key")
+	}
+	certificatePEM, err := loadPEM(log, certificate)
+	if err != nil {
+		return tls.Certificate{}, fmt.Errorf("failed to load the client certificate. %v", err)
+	}
+	// NOTE: Do not log the private key
+	keyPEM, err := loadPEM(log, key)
+	if err != nil {
+		return tls.Certificate{}, fmt.Errorf("failed to load the client key. %v", err)
+	}
+	return tls.X509KeyPair(certificatePEM, keyPEM)
+}
+
+// loadPEM returns the content of the PEM file at source, or of the parameter it references
+func loadPEM(log log.T, source string) ([]byte, error) {
+	if strings.Contains(source, "{{") {
+		content, err := resolveParameters(log, source)
+		return []byte(content), err
+	}
+	return ioutil.ReadFile(source)
+}
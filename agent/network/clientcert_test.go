@@ -0,0 +1,126 @@
+// Copyright 2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package network
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/stretchr/testify/assert"
+)
+
+// clientCertificatePEM returns a self-signed client certificate and its private key as PEM
+func clientCertificatePEM(t *testing.T) (string, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "amazon-ssm-agent"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	certificate, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	assert.NoError(t, err)
+	keyBytes, err := x509.MarshalECPrivateKey(key)
+	assert.NoError(t, err)
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certificate})),
+		string(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyBytes}))
+}
+
+func TestConfiguredTransportClientCertificate(t *testing.T) {
+	certificatePEM, keyPEM := clientCertificatePEM(t)
+	dir, err := ioutil.TempDir("", "clientcert")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	certificatePath, keyPath := filepath.Join(dir, "client.crt"), filepath.Join(dir, "client.key")
+	assert.NoError(t, ioutil.WriteFile(certificatePath, []byte(certificatePEM), 0600))
+	assert.NoError(t, ioutil.WriteFile(keyPath, []byte(keyPEM), 0600))
+
+	defer func(original func(log.T, string) (string, error)) { resolveParameters = original }(resolveParameters)
+	resolveParameters = func(log log.T, text string) (string, error) {
+		return map[string]string{
+			"{{ssm:/agent/client.crt}}":        certificatePEM,
+			"{{ssm-secure:/agent/client.key}}": keyPEM,
+		}[text], nil
+	}
+
+	data := []struct {
+		name         string
+		certificate  string
+		key          string
+		certificates int
+	}{
+		{"files", certificatePath, keyPath, 1},
+		{"parameters", "{{ssm:/agent/client.crt}}", "{{ssm-secure:/agent/client.key}}", 1},
+		{"not configured", "", "", 0},
+		{"key missing", certificatePath, "", 0},
+		{"unreadable key", certificatePath, filepath.Join(dir, "missing.key"), 0},
+	}
+	for _, testdata := range data {
+		t.Run(testdata.name, func(t *testing.T) {
+			transport := configuredTransport(log.NewMockLog(), appconfig.AgentInfo{ClientCertificate: testdata.certificate, ClientKey: testdata.key})
+
+			assert.Len(t, transport.TLSClientConfig.Certificates, testdata.certificates)
+			assert.Equal(t, uint16(defaultMinTLSVersion), transport.TLSClientConfig.MinVersion)
+		})
+	}
+}
+
+func TestConfiguredTransportPresentsClientCertificate(t *testing.T) {
+	certificatePEM, keyPEM := clientCertificatePEM(t)
+	var presented []*x509.Certificate
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		presented = r.TLS.PeerCertificates
+	}))
+	server.TLS = &tls.Config{ClientAuth: tls.RequireAnyClientCert}
+	server.StartTLS()
+	defer server.Close()
+
+	defer func(original func(log.T, string) (string, error)) { resolveParameters = original }(resolveParameters)
+	resolveParameters = func(log log.T, text string) (string, error) {
+		if text == "{{ssm:/agent/client.crt}}" {
+			return certificatePEM, nil
+		}
+		return keyPEM, nil
+	}
+	transport := configuredTransport(log.NewMockLog(), appconfig.AgentInfo{ClientCertificate: "{{ssm:/agent/client.crt}}", ClientKey: "{{ssm-secure:/agent/client.key}}"})
+	transport.TLSClientConfig.RootCAs = server.Client().Transport.(*http.Transport).TLSClientConfig.RootCAs
+
+	resp, err := (&http.Client{Transport: transport}).Get(server.URL)
+
+	assert.NoError(t, err)
+	if resp != nil {
+		resp.Body.Close()
+	}
+	if assert.Len(t, presented, 1) {
+		assert.Equal(t, "amazon-ssm-agent", presented[0].Subject.CommonName)
+	}
+}
@@ -0,0 +1,123 @@
+// Copyright 2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package network
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"strings"
+	"syscall"
+)
+
+// Failures of the transport of a download, named in its error to tell them apart
+const (
+	FailureDNS               = "DNS resolution failure"
+	FailureConnectionRefused = "connection refused"
+	FailureConnectTimeout    = "connection timeout"
+	FailureTLSHandshake      = "TLS handshake failure"
+	FailureReadTimeout       = "read timeout"
+)
+
+// Error is a failed download request whose transport failure was classified
+type Error struct {
+	Failure string
+	Err     error
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%v: %v", e.Failure, e.Err)
+}
+
+// Unwrap returns the error of the transport
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// ClassifyError returns err as an Error naming its failure when the transport failed with a DNS resolution failure,
+// a refused connection, a TLS handshake failure or a timeout, and err as is otherwise
+func ClassifyError(err error) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := err.(*Error); ok {
+		return err
+	}
+	if failure := classify(err); failure != "" {
+		return &Error{Failure: failure, Err: err}
+	}
+	return err
+}
+
+// classify returns the failure of err or of the errors it wraps, it is empty when none is a classified failure
+func classify(err error) string {
+	timedOut := false
+	for ; err != nil; err = unwrap(err) {
+		switch e := err.(type) {
+		case *Error:
+			return e.Failure
+		case *net.DNSError:
+			return FailureDNS
+		case x509.UnknownAuthorityError, x509.HostnameError, x509.CertificateInvalidError, *tls.CertificateVerificationError,
+			tls.RecordHeaderError, tls.AlertError:
+			return FailureTLSHandshake
+		case *net.OpError:
+			if e.Op == "dial" && e.Timeout() {
+				return FailureConnectTimeout
+			}
+			if e.Op == "dial" && isConnectionRefused(e.Err) {
+				return FailureConnectionRefused
+			}
+			if e.Op == "remote error" {
+				// alerts the server sends during the handshake, e.g. tls: handshake failure
+				return FailureTLSHandshake
+			}
+		}
+		// net/http reports a server answering in plain http without a TLS error of its own
+		if message := err.Error(); strings.Contains(message, "TLS handshake") || strings.Contains(message, "HTTP response to HTTPS client") {
+			return FailureTLSHandshake
+		}
+		if timeout, ok := err.(interface{ Timeout() bool }); ok && timeout.Timeout() {
+			timedOut = true
+		}
+	}
+	if timedOut {
+		return FailureReadTimeout
+	}
+	return ""
+}
+
+// isConnectionRefused returns true if the dial error is the host refusing the connection
+func isConnectionRefused(err error) bool {
+	for cause := err; cause != nil; cause = unwrap(cause) {
+		if errno, ok := cause.(syscall.Errno); ok && errno == syscall.ECONNREFUSED {
+			return true
+		}
+	}
+	// Windows reports WSAECONNREFUSED, which isn't syscall.ECONNREFUSED
+	return err != nil && strings.Contains(err.Error(), "refused")
+}
+
+// unwrap returns the error err wraps, the original error of an AWS SDK error
+func unwrap(err error) error {
+	switch e := err.(type) {
+	case interface{ OrigErr() error }:
+		return e.OrigErr()
+	case interface{ Unwrap() error }:
+		return e.Unwrap()
+	}
+	return nil
+}
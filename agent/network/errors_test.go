@@ -0,0 +1,126 @@
+// Copyright 2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package network
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/stretchr/testify/assert"
+)
+
+// timeoutError is a net.Error that timed out
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+// requestError wraps err as http.Client returns the errors of the transport
+func requestError(err error) error {
+	return &url.Error{Op: "Get", URL: "https://example.com/file.txt", Err: err}
+}
+
+func TestClassifyError(t *testing.T) {
+	dnsError := &net.OpError{Op: "dial", Net: "tcp", Err: &net.DNSError{Err: "no such host", Name: "example.invalid"}}
+	data := []struct {
+		name     string
+		err      error
+		expected string
+	}{
+		{"dns", requestError(dnsError), FailureDNS},
+		{"dns through the aws sdk", awserr.New("RequestError", "send request failed", requestError(dnsError)), FailureDNS},
+		{"connection refused", requestError(&net.OpError{Op: "dial", Net: "tcp", Err: &os.SyscallError{Syscall: "connect", Err: syscall.ECONNREFUSED}}), FailureConnectionRefused},
+		{"connection timeout", requestError(&net.OpError{Op: "dial", Net: "tcp", Err: timeoutError{}}), FailureConnectTimeout},
+		{"read timeout", requestError(&net.OpError{Op: "read", Net: "tcp", Err: timeoutError{}}), FailureReadTimeout},
+		{"client timeout", requestError(timeoutError{}), FailureReadTimeout},
+		{"unknown authority", requestError(x509.UnknownAuthorityError{}), FailureTLSHandshake},
+		{"not tls", requestError(tls.RecordHeaderError{Msg: "first record does not look like a TLS handshake"}), FailureTLSHandshake},
+		{"tls handshake timeout", requestError(errors.New("net/http: TLS handshake timeout")), FailureTLSHandshake},
+		{"tls alert", requestError(&net.OpError{Op: "remote error", Err: errors.New("tls: handshake failure")}), FailureTLSHandshake},
+	}
+	for _, testdata := range data {
+		t.Run(testdata.name, func(t *testing.T) {
+			err := ClassifyError(testdata.err)
+
+			classified, ok := err.(*Error)
+			assert.True(t, ok)
+			if ok {
+				assert.Equal(t, testdata.expected, classified.Failure)
+				assert.Equal(t, testdata.err, classified.Err)
+				assert.Contains(t, err.Error(), testdata.expected+": ")
+			}
+			// classifying again keeps the classification
+			assert.Equal(t, err, ClassifyError(err))
+		})
+	}
+}
+
+func TestClassifyErrorLeavesOtherErrors(t *testing.T) {
+	other := errors.New("http request failed. status:404 Not Found statuscode:404")
+	assert.Equal(t, other, ClassifyError(other))
+	notFound := awserr.New("NoSuchKey", "key does not exist", other)
+	assert.Equal(t, notFound, ClassifyError(notFound))
+	assert.Nil(t, ClassifyError(nil))
+}
+
+func TestClassifyErrorOfTransport(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	closedAddress := listener.Addr().String()
+	listener.Close()
+	plain := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer plain.Close()
+	untrusted := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer untrusted.Close()
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(200 * time.Millisecond)
+	}))
+	defer slow.Close()
+
+	data := []struct {
+		name     string
+		url      string
+		timeout  time.Duration
+		expected string
+	}{
+		{"connection refused", "http://" + closedAddress, 0, FailureConnectionRefused},
+		{"https to a plain http server", "https" + plain.URL[len("http"):], 0, FailureTLSHandshake},
+		{"untrusted certificate", untrusted.URL, 0, FailureTLSHandshake},
+		{"slow response", slow.URL, 50 * time.Millisecond, FailureReadTimeout},
+	}
+	for _, testdata := range data {
+		t.Run(testdata.name, func(t *testing.T) {
+			client := &http.Client{Transport: NewTransport(IPFamilyAny, defaultMinTLSVersion), Timeout: testdata.timeout}
+			_, err := client.Get(testdata.url)
+
+			classified, ok := ClassifyError(err).(*Error)
+			assert.True(t, ok, "%v", err)
+			if ok {
+				assert.Equal(t, testdata.expected, classified.Failure)
+			}
+		})
+	}
+}
@@ -0,0 +1,81 @@
+// Copyright 2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package network
+
+import (
+	"sync"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+)
+
+// DownloadLimiter bounds the number of downloads in flight.
+// Every download acquires the limiter before it starts and releases it once it is done.
+type DownloadLimiter interface {
+	Acquire()
+	Release()
+}
+
+// semaphoreLimiter allows as many downloads at a time as its capacity
+type semaphoreLimiter chan struct{}
+
+func (l semaphoreLimiter) Acquire() {
+	l <- struct{}{}
+}
+
+func (l semaphoreLimiter) Release() {
+	<-l
+}
+
+// unlimitedLimiter never blocks downloads
+type unlimitedLimiter struct{}
+
+func (unlimitedLimiter) Acquire() {}
+
+func (unlimitedLimiter) Release() {}
+
+// NewDownloadLimiter returns a limiter allowing limit downloads at a time, or any number when limit is not positive
+func NewDownloadLimiter(limit int) DownloadLimiter {
+	if limit <= 0 {
+		return unlimitedLimiter{}
+	}
+	return make(semaphoreLimiter, limit)
+}
+
+var (
+	sharedLimiterLock sync.Mutex
+	sharedLimiter     DownloadLimiter
+)
+
+// SharedDownloadLimiter returns the process-wide limiter shared by all download paths,
+// created on first use with the limit configured in appconfig
+func SharedDownloadLimiter() DownloadLimiter {
+	sharedLimiterLock.Lock()
+	defer sharedLimiterLock.Unlock()
+	if sharedLimiter == nil {
+		limit := 0
+		if appCfg, err := appconfig.Config(false); err == nil {
+			limit = appCfg.Agent.DownloadConcurrencyLimit
+		}
+		sharedLimiter = NewDownloadLimiter(limit)
+	}
+	return sharedLimiter
+}
+
+// SetSharedDownloadLimiter replaces the process-wide download limiter, nil restores the configured one
+func SetSharedDownloadLimiter(limiter DownloadLimiter) {
+	sharedLimiterLock.Lock()
+	defer sharedLimiterLock.Unlock()
+	sharedLimiter = limiter
+}
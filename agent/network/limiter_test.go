@@ -0,0 +1,74 @@
+// Copyright 2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package network
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSharedDownloadLimiterAcrossSubsystems(t *testing.T) {
+	SetSharedDownloadLimiter(NewDownloadLimiter(3))
+	defer SetSharedDownloadLimiter(nil)
+
+	var inFlight, maxInFlight int32
+	download := func() {
+		limiter := SharedDownloadLimiter()
+		limiter.Acquire()
+		defer limiter.Release()
+
+		current := atomic.AddInt32(&inFlight, 1)
+		for {
+			max := atomic.LoadInt32(&maxInFlight)
+			if current <= max || atomic.CompareAndSwapInt32(&maxInFlight, max, current) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		atomic.AddInt32(&inFlight, -1)
+	}
+
+	// two subsystems, e.g. package and git downloads, each running their own downloads concurrently
+	var wg sync.WaitGroup
+	for _, subsystem := range []string{"birdwatcher", "git"} {
+		for i := 0; i < 5; i++ {
+			wg.Add(1)
+			go func(subsystem string) {
+				defer wg.Done()
+				download()
+			}(subsystem)
+		}
+	}
+	wg.Wait()
+
+	assert.True(t, maxInFlight <= 3)
+	assert.True(t, maxInFlight > 1)
+}
+
+func TestNewDownloadLimiterUnlimited(t *testing.T) {
+	limiter := NewDownloadLimiter(0)
+
+	// acquiring never blocks
+	for i := 0; i < 100; i++ {
+		limiter.Acquire()
+	}
+	for i := 0; i < 100; i++ {
+		limiter.Release()
+	}
+}
@@ -0,0 +1,109 @@
+// Copyright 2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package network
+
+import (
+	"sync"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+)
+
+// retryCost is the number of tokens spent by a retry, each successful call earns back a single token
+const retryCost = 10
+
+// RetryBudget throttles the retries sent to each host across all download operations,
+// so that a failing host isn't retried by every call independently.
+type RetryBudget interface {
+	// AllowRetry spends a retry from the budget of host, it returns false when none is left
+	AllowRetry(host string) bool
+	// RecordSuccess earns back part of a retry for host
+	RecordSuccess(host string)
+}
+
+// tokenBucketBudget holds up to capacity tokens per host
+type tokenBucketBudget struct {
+	capacity int
+	lock     sync.Mutex
+	tokens   map[string]int
+}
+
+func (b *tokenBucketBudget) AllowRetry(host string) bool {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	tokens, found := b.tokens[host]
+	if !found {
+		tokens = b.capacity
+	}
+	if tokens < retryCost {
+		return false
+	}
+	b.tokens[host] = tokens - retryCost
+	return true
+}
+
+func (b *tokenBucketBudget) RecordSuccess(host string) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	if tokens, found := b.tokens[host]; found {
+		if tokens++; tokens >= b.capacity {
+			// a full bucket is the same as one never spent from
+			delete(b.tokens, host)
+		} else {
+			b.tokens[host] = tokens
+		}
+	}
+}
+
+// unlimitedBudget allows every retry
+type unlimitedBudget struct{}
+
+func (unlimitedBudget) AllowRetry(host string) bool { return true }
+
+func (unlimitedBudget) RecordSuccess(host string) {}
+
+// NewRetryBudget returns a budget allowing each host capacity retries, or any number when capacity is not positive
+func NewRetryBudget(capacity int) RetryBudget {
+	if capacity <= 0 {
+		return unlimitedBudget{}
+	}
+	return &tokenBucketBudget{capacity: capacity * retryCost, tokens: map[string]int{}}
+}
+
+var (
+	sharedBudgetLock sync.Mutex
+	sharedBudget     RetryBudget
+)
+
+// SharedRetryBudget returns the process-wide retry budget shared by all download paths,
+// created on first use with the budget configured in appconfig
+func SharedRetryBudget() RetryBudget {
+	sharedBudgetLock.Lock()
+	defer sharedBudgetLock.Unlock()
+	if sharedBudget == nil {
+		capacity := 0
+		if appCfg, err := appconfig.Config(false); err == nil {
+			capacity = appCfg.Agent.DownloadRetryBudget
+		}
+		sharedBudget = NewRetryBudget(capacity)
+	}
+	return sharedBudget
+}
+
+// SetSharedRetryBudget replaces the process-wide retry budget, nil restores the configured one
+func SetSharedRetryBudget(budget RetryBudget) {
+	sharedBudgetLock.Lock()
+	defer sharedBudgetLock.Unlock()
+	sharedBudget = budget
+}
@@ -0,0 +1,83 @@
+// Copyright 2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package network
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSharedRetryBudgetThrottlesFailingHost(t *testing.T) {
+	SetSharedRetryBudget(NewRetryBudget(5))
+	defer SetSharedRetryBudget(nil)
+
+	// many concurrent calls to a failing host, each willing to retry up to 3 times
+	var retries int32
+	failingCall := func() {
+		for attempt := 0; attempt < 3; attempt++ {
+			if !SharedRetryBudget().AllowRetry("failing.example.com") {
+				return
+			}
+			atomic.AddInt32(&retries, 1)
+		}
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			failingCall()
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, int32(5), retries)
+	assert.False(t, SharedRetryBudget().AllowRetry("failing.example.com"))
+	// other hosts keep their own budget
+	assert.True(t, SharedRetryBudget().AllowRetry("healthy.example.com"))
+}
+
+func TestRetryBudgetRefillsOnSuccess(t *testing.T) {
+	budget := NewRetryBudget(2)
+	assert.True(t, budget.AllowRetry("host"))
+	assert.True(t, budget.AllowRetry("host"))
+	assert.False(t, budget.AllowRetry("host"))
+
+	// ten successes earn back one retry
+	for i := 0; i < 10; i++ {
+		budget.RecordSuccess("host")
+	}
+	assert.True(t, budget.AllowRetry("host"))
+	assert.False(t, budget.AllowRetry("host"))
+
+	// the budget never grows past its capacity
+	for i := 0; i < 100; i++ {
+		budget.RecordSuccess("host")
+	}
+	assert.True(t, budget.AllowRetry("host"))
+	assert.True(t, budget.AllowRetry("host"))
+	assert.False(t, budget.AllowRetry("host"))
+}
+
+func TestNewRetryBudgetUnlimited(t *testing.T) {
+	budget := NewRetryBudget(0)
+
+	for i := 0; i < 100; i++ {
+		assert.True(t, budget.AllowRetry("host"))
+	}
+}
@@ -20,10 +20,13 @@ import (
 	"errors"
 	"fmt"
 	"reflect"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/aws/amazon-ssm-agent/agent/appconfig"
 	"github.com/aws/amazon-ssm-agent/agent/fileutil/artifact"
+	"github.com/aws/amazon-ssm-agent/agent/log"
 	"github.com/aws/amazon-ssm-agent/agent/platform"
 	"github.com/aws/amazon-ssm-agent/agent/plugins/configurepackage/birdwatcher/facade"
 	"github.com/aws/amazon-ssm-agent/agent/plugins/configurepackage/envdetect"
@@ -54,16 +57,52 @@ type PackageService struct {
 	manifestCache packageservice.ManifestCache
 	collector     envdetect.Collector
 	timeProvider  NanoTime
+	// forceDownload downloads the artifacts even when the artifact of an earlier successful install can be reused
+	forceDownload bool
+	// downloads are the artifacts downloaded by this run by version that no install recorded yet
+	downloads map[string]*DownloadState
+	// requestedVersions are the versions the manifests of this run were requested with by package arn, e.g. latest
+	requestedVersions map[string]string
+	// fileOverrides replace the source of manifest files when enabled in appconfig
+	fileOverrides map[string]appconfig.BirdwatcherFileOverride
+	// manifestOverlays are the packages whose manifests are merged, in order, over the manifest of a package
+	manifestOverlays map[string][]string
+	// platformDetectionTimeout bounds how long collecting the platform of the instance may take
+	platformDetectionTimeout time.Duration
+	// manifestChecksums are the known checksums of manifests by package name, version and algorithm
+	manifestChecksums map[string]map[string]map[string]string
+	// platformSelectionPolicy tells whether the manifest entries of the exact platform, of its family or both, in that order, are selected
+	platformSelectionPolicy string
+	// parsedManifests keeps recently downloaded manifests in memory, manifests are always downloaded when it is nil
+	parsedManifests *parsedManifestCache
+	// mirrorURLs are the base URLs files are downloaded from, in order, when their manifest location fails
+	mirrorURLs []string
 }
 
 // New constructor for PackageService
-func New(endpoint string, manifestCache packageservice.ManifestCache) packageservice.PackageService {
+func New(endpoint string, manifestCache packageservice.ManifestCache, forceDownload bool) packageservice.PackageService {
 	// TODO: endpoint vs appconfig
 	// TODO: pass in log var to log errs
 	cfg := sdkutil.AwsConfig()
+	var fileOverrides map[string]appconfig.BirdwatcherFileOverride
+	var manifestOverlays map[string][]string
+	var manifestChecksums map[string]map[string]map[string]string
+	var platformSelectionPolicy string
+	var mirrorURLs []string
+	platformDetectionTimeout := defaultPlatformDetectionTimeout
 
 	// overrides ssm client config from appconfig if applicable
 	if appCfg, err := appconfig.Config(false); err == nil {
+		manifestOverlays = appCfg.Birdwatcher.ManifestOverlays
+		manifestChecksums = appCfg.Birdwatcher.ManifestChecksums
+		platformSelectionPolicy = appCfg.Birdwatcher.PlatformSelectionPolicy
+		mirrorURLs = appCfg.Birdwatcher.MirrorURLs
+		if appCfg.Birdwatcher.PlatformDetectionTimeoutSeconds > 0 {
+			platformDetectionTimeout = time.Duration(appCfg.Birdwatcher.PlatformDetectionTimeoutSeconds) * time.Second
+		}
+		if appCfg.Birdwatcher.EnableFileOverrides {
+			fileOverrides = appCfg.Birdwatcher.FileOverrides
+		}
 		if appCfg.Ssm.Endpoint != "" {
 			cfg.Endpoint = &appCfg.Ssm.Endpoint
 		} else {
@@ -90,10 +129,19 @@ func New(endpoint string, manifestCache packageservice.ManifestCache) packageser
 	facadeClientSession.Handlers.Build.PushBackNamed(SSMAgentVersionUserAgentHandler)
 
 	return &PackageService{
-		facadeClient:  ssm.New(facadeClientSession),
-		manifestCache: manifestCache,
-		collector:     &envdetect.CollectorImp{},
-		timeProvider:  &TimeImpl{},
+		facadeClient:     ssm.New(facadeClientSession),
+		manifestCache:    manifestCache,
+		collector:        &envdetect.CollectorImp{},
+		timeProvider:     &TimeImpl{},
+		forceDownload:    forceDownload,
+		fileOverrides:    fileOverrides,
+		manifestOverlays: manifestOverlays,
+
+		platformDetectionTimeout: platformDetectionTimeout,
+		manifestChecksums:        manifestChecksums,
+		platformSelectionPolicy:  platformSelectionPolicy,
+		parsedManifests:          sharedParsedManifests,
+		mirrorURLs:               mirrorURLs,
 	}
 }
 
@@ -103,10 +151,14 @@ func (ds *PackageService) PackageServiceName() string {
 
 // DownloadManifest downloads the manifest for a given version (or latest) and returns the agent version specified in manifest
 func (ds *PackageService) DownloadManifest(tracer trace.Tracer, packageName string, version string) (string, string, bool, error) {
-	manifest, isSameAsCache, err := downloadManifest(ds, packageName, version)
+	manifest, isSameAsCache, err := downloadManifest(tracer, ds, packageName, version)
 	if err != nil {
 		return "", "", isSameAsCache, err
 	}
+	if ds.requestedVersions == nil {
+		ds.requestedVersions = make(map[string]string)
+	}
+	ds.requestedVersions[manifest.PackageArn] = version
 	return manifest.PackageArn, manifest.Version, isSameAsCache, nil
 }
 
@@ -116,27 +168,95 @@ func (ds *PackageService) DownloadArtifact(tracer trace.Tracer, packageName stri
 	manifest, err := readManifestFromCache(ds.manifestCache, packageName, version)
 	if err != nil {
 		trace.AppendInfof("error when reading the manifest from cache %v", err).End()
-		manifest, _, err = downloadManifest(ds, packageName, version)
+		manifest, _, err = downloadManifest(tracer, ds, packageName, version)
 		if err != nil {
 			trace.WithError(err).End()
 			return "", fmt.Errorf("failed to download the manifest: %v", err)
 		}
 	}
 
-	file, err := ds.findFileFromManifest(tracer, manifest)
+	ds.applyFileOverrides(tracer, manifest)
+
+	file, resolution, err := ds.findFileFromManifest(tracer, manifest)
+	if resolution != nil {
+		resolution.RequestedVersion = version
+		if requested, ok := ds.requestedVersions[packageName]; ok {
+			resolution.RequestedVersion = requested
+		}
+		if data, err := json.Marshal(resolution); err == nil {
+			trace.AppendInfof("package resolution: %s", data)
+		}
+	}
 	if err != nil {
 		trace.WithError(err).End()
 		return "", err
 	}
 
+	if !ds.forceDownload {
+		if localFilePath, ok := findDownloadedFile(tracer, packageName, version, file); ok {
+			trace.AppendInfof("%v %v is already downloaded to %v", packageName, version, localFilePath).End()
+			return localFilePath, nil
+		}
+	}
+
 	trace.End()
-	return downloadFile(tracer, file)
+	localFilePath, err := downloadFile(tracer, file, ds.mirrorURLs)
+	if err != nil {
+		return "", err
+	}
+
+	_, checksum := artifact.PreferredChecksum(file.Checksums)
+	if ds.downloads == nil {
+		ds.downloads = make(map[string]*DownloadState)
+	}
+	ds.downloads[version] = &DownloadState{
+		PackageName:   packageName,
+		Version:       version,
+		LocalFilePath: localFilePath,
+		Checksum:      checksum,
+	}
+	return localFilePath, nil
+}
+
+// RecordInstall records the artifact this run downloaded for a version that was installed successfully, later installs of the version reuse it.
+// The artifact recorded for the version installed before is deleted.
+func (ds *PackageService) RecordInstall(tracer trace.Tracer, packageName string, version string) error {
+	state, ok := ds.downloads[version]
+	if !ok || state.PackageName != packageName || state.Checksum == "" {
+		// nothing was downloaded for the version or it can't be validated later, the recorded state stays as it is
+		return nil
+	}
+	previous, _ := readPackageState(packageName)
+	if err := writeDownloadState(state); err != nil {
+		return err
+	}
+	delete(ds.downloads, version)
+	tracer.CurrentTrace().AppendDebugf("recorded %v %v as installed from %v", packageName, version, state.LocalFilePath)
+
+	if previous != nil && previous.LocalFilePath != state.LocalFilePath && filesysdep.Exists(previous.LocalFilePath) {
+		if err := filesysdep.Remove(previous.LocalFilePath); err != nil {
+			tracer.CurrentTrace().AppendDebugf("failed to delete the artifact of %v %v: %v", packageName, previous.Version, err)
+		}
+	}
+	return nil
+}
+
+// UnrecordedArtifacts returns the artifacts this run downloaded that no install recorded
+func (ds *PackageService) UnrecordedArtifacts() []string {
+	var filePaths []string
+	for _, state := range ds.downloads {
+		filePaths = append(filePaths, state.LocalFilePath)
+	}
+	sort.Strings(filePaths)
+	return filePaths
 }
 
 // ReportResult sents back the result of the install/upgrade/uninstall run back to Birdwatcher
 func (ds *PackageService) ReportResult(tracer trace.Tracer, result packageservice.PackageResult) error {
-	log := tracer.CurrentTrace().Logger
-	env, _ := ds.collector.CollectData(log)
+	env, err := ds.collectEnvironment(tracer)
+	if env == nil {
+		return fmt.Errorf("failed to report results: %v", err)
+	}
 
 	var previousPackageVersion *string
 	if result.PreviousPackageVersion != "" {
@@ -155,7 +275,7 @@ func (ds *PackageService) ReportResult(tracer trace.Tracer, result packageservic
 	}
 
 	overallTiming := (ds.timeProvider.NowUnixNano() - result.Timing) / 1000000
-	_, err := ds.facadeClient.PutConfigurePackageResult(
+	_, err = ds.facadeClient.PutConfigurePackageResult(
 		&ssm.PutConfigurePackageResultInput{
 			PackageName:            &result.PackageName,
 			PackageVersion:         &result.Version,
@@ -193,21 +313,17 @@ func readManifestFromCache(cache packageservice.ManifestCache, packageName strin
 	return parseManifest(&data)
 }
 
-func downloadManifest(ds *PackageService, packageName string, version string) (*Manifest, bool, error) {
-	isSameAsCache := false
-	resp, err := ds.facadeClient.GetManifest(
-		&ssm.GetManifestInput{
-			PackageName:    &packageName,
-			PackageVersion: &version,
-		},
-	)
-	if err != nil {
-		return nil, isSameAsCache, fmt.Errorf("failed to retrieve manifest: %v", err)
-	}
-
-	byteManifest := []byte(*resp.Manifest)
+// downloadManifest returns the manifest of a package version, downloading it unless it was downloaded recently
+func downloadManifest(tracer trace.Tracer, ds *PackageService, packageName string, version string) (*Manifest, bool, error) {
+	return ds.parsedManifests.get(newParsedManifestKey(packageName, version), func() (*Manifest, bool, error) {
+		return fetchAndCacheManifest(tracer, ds, packageName, version)
+	})
+}
 
-	manifest, err := parseManifest(&byteManifest)
+// fetchAndCacheManifest downloads the manifest of a package version and writes it to the manifest cache
+func fetchAndCacheManifest(tracer trace.Tracer, ds *PackageService, packageName string, version string) (*Manifest, bool, error) {
+	isSameAsCache := false
+	manifest, byteManifest, err := fetchParsedManifest(tracer, ds, packageName, version)
 	if err != nil {
 		return nil, isSameAsCache, err
 	}
@@ -226,6 +342,26 @@ func downloadManifest(ds *PackageService, packageName string, version string) (*
 	return manifest, isSameAsCache, nil
 }
 
+// fetchParsedManifest downloads the manifest of a package version, merges its overlays and parses it, without caching it
+func fetchParsedManifest(tracer trace.Tracer, ds *PackageService, packageName string, version string) (*Manifest, []byte, error) {
+	byteManifest, err := fetchManifest(tracer, ds, packageName, version)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if overlays := ds.manifestOverlays[packageName]; len(overlays) > 0 {
+		if byteManifest, err = mergeManifestOverlays(tracer, ds, byteManifest, overlays, version); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	manifest, err := parseManifest(&byteManifest)
+	if err != nil {
+		return nil, nil, err
+	}
+	return manifest, byteManifest, nil
+}
+
 func parseManifest(data *[]byte) (*Manifest, error) {
 	var manifest Manifest
 
@@ -237,36 +373,81 @@ func parseManifest(data *[]byte) (*Manifest, error) {
 	return &manifest, nil
 }
 
-func (ds *PackageService) findFileFromManifest(tracer trace.Tracer, manifest *Manifest) (*File, error) {
+// applyFileOverrides replaces the source of the manifest files configured to be overridden
+func (ds *PackageService) applyFileOverrides(tracer trace.Tracer, manifest *Manifest) {
+	for name, override := range ds.fileOverrides {
+		file, ok := manifest.Files[name]
+		if !ok || file == nil {
+			tracer.CurrentTrace().AppendInfof("file override for %v ignored, the manifest has no such file", name)
+			continue
+		}
+
+		overridden := &File{
+			DownloadLocation: override.DownloadLocation,
+			Checksums:        file.Checksums,
+			Size:             file.Size,
+		}
+		if len(override.Checksums) > 0 {
+			overridden.Checksums = override.Checksums
+		}
+		manifest.Files[name] = overridden
+		tracer.CurrentTrace().AppendInfof("manifest file %v overridden, downloading from %v instead of %v", name, overridden.DownloadLocation, file.DownloadLocation)
+	}
+}
+
+// findFileFromManifest returns the manifest file to install on the current instance and how it was resolved.
+// The resolution is returned, as far as it got, even when no file matches.
+func (ds *PackageService) findFileFromManifest(tracer trace.Tracer, manifest *Manifest) (*File, *Resolution, error) {
 	var file *File
 
-	pkginfo, err := ds.extractPackageInfo(tracer, manifest)
+	resolution, err := ds.resolvePackage(tracer, manifest)
 	if err != nil {
-		return nil, fmt.Errorf("failed to find platform: %v", err)
+		return nil, resolution, fmt.Errorf("failed to find platform: %v", err)
 	}
+	pkginfo := resolution.packageInfo
 
 	for name, f := range manifest.Files {
-		if name == pkginfo.File {
+		if pkginfo != nil && name == pkginfo.File {
 			file = f
 			break
 		}
 	}
 
 	if file == nil {
-		return nil, fmt.Errorf("failed to find file for %+v", pkginfo)
+		return nil, resolution, fmt.Errorf("failed to find file for %+v", pkginfo)
 	}
 
-	return file, nil
+	return file, resolution, nil
+}
+
+// downloadFile downloads the file from its manifest location and, when that fails, from each mirror in turn.
+// The first download matching the manifest checksum wins.
+func downloadFile(tracer trace.Tracer, file *File, mirrorURLs []string) (string, error) {
+	log := tracer.CurrentTrace().Logger
+	var errMessages []string
+	for i, source := range downloadSources(log, file.DownloadLocation, mirrorURLs) {
+		localFilePath, err := downloadFrom(log, source, file)
+		if err != nil {
+			log.Warnf("%v", err)
+			errMessages = append(errMessages, err.Error())
+			continue
+		}
+		if i > 0 {
+			tracer.CurrentTrace().AppendInfof("downloaded %v from mirror %v", file.DownloadLocation, source)
+		}
+		return localFilePath, nil
+	}
+	return "", errors.New(strings.Join(errMessages, "; "))
 }
 
-func downloadFile(tracer trace.Tracer, file *File) (string, error) {
+// downloadFrom downloads the file from source and verifies it against the manifest checksum
+func downloadFrom(log log.T, source string, file *File) (string, error) {
 	downloadInput := artifact.DownloadInput{
-		SourceURL: file.DownloadLocation,
-		// TODO don't hardcode sha256 - use multiple checksums
+		SourceURL:       source,
 		SourceChecksums: file.Checksums,
 	}
 
-	log := tracer.CurrentTrace().Logger
+	log.Debugf("downloading %v (%v bytes) with checksums %v", downloadInput.SourceURL, file.Size, downloadInput.SourceChecksums)
 	downloadOutput, downloadErr := networkdep.Download(log, downloadInput)
 	if downloadErr != nil || downloadOutput.LocalFilePath == "" {
 		errMessage := fmt.Sprintf("failed to download installation package reliably, %v", downloadInput.SourceURL)
@@ -279,33 +460,97 @@ func downloadFile(tracer trace.Tracer, file *File) (string, error) {
 		return "", errors.New(errMessage)
 	}
 
+	if err := verifyDownloadedFile(file, downloadOutput.LocalFilePath); err != nil {
+		// a file that doesn't match its manifest may have been tampered with, it must neither be installed nor reused
+		if removeErr := filesysdep.Remove(downloadOutput.LocalFilePath); removeErr != nil {
+			log.Warnf("failed to delete %v: %v", downloadOutput.LocalFilePath, removeErr)
+		}
+		return "", fmt.Errorf("downloaded installation package %v does not match the manifest, %v", downloadInput.SourceURL, err)
+	}
+
+	log.Debugf("downloaded %v to %v", downloadInput.SourceURL, downloadOutput.LocalFilePath)
 	return downloadOutput.LocalFilePath, nil
 }
 
 // ExtractPackageInfo returns the correct PackageInfo for the current instances platform/version/arch
 func (ds *PackageService) extractPackageInfo(tracer trace.Tracer, manifest *Manifest) (*PackageInfo, error) {
-	log := tracer.CurrentTrace().Logger
-	env, err := ds.collector.CollectData(log)
+	resolution, err := ds.resolvePackage(tracer, manifest)
+	if err != nil {
+		return nil, err
+	}
+	return resolution.packageInfo, nil
+}
+
+// resolvePackage matches the platform/version/arch of the current instance against the manifest packages.
+// The resolution is returned, as far as it got, even when no package matches.
+func (ds *PackageService) resolvePackage(tracer trace.Tracer, manifest *Manifest) (*Resolution, error) {
+	env, err := ds.collectEnvironment(tracer)
 	if err != nil {
 		return nil, fmt.Errorf("failed to collect data: %v", err)
 	}
 
-	if keyplatform, ok := matchPackageSelectorPlatform(env.OperatingSystem.Platform, manifest.Packages); ok {
+	resolution := &Resolution{
+		PackageArn:      manifest.PackageArn,
+		SelectedVersion: manifest.Version,
+		Platform:        env.OperatingSystem.Platform,
+		PlatformVersion: env.OperatingSystem.PlatformVersion,
+		PlatformFamily:  env.OperatingSystem.PlatformFamily,
+		Architecture:    env.OperatingSystem.Architecture,
+	}
+
+	platformKeys, err := platformSelectorKeys(ds.platformSelectionPolicy, env.OperatingSystem.Platform, env.OperatingSystem.PlatformFamily)
+	if err != nil {
+		return resolution, err
+	}
+	if keyplatform, ok := matchPackageSelectorPlatform(platformKeys, manifest.Packages); ok {
+		resolution.MatchedPlatform = keyplatform
+		for platformVersion := range manifest.Packages[keyplatform] {
+			resolution.ConsideredPlatformVersions = append(resolution.ConsideredPlatformVersions, platformVersion)
+		}
+		sort.Strings(resolution.ConsideredPlatformVersions)
+
 		if keyversion, ok := matchPackageSelectorVersion(env.OperatingSystem.PlatformVersion, manifest.Packages[keyplatform]); ok {
+			resolution.MatchedPlatformVersion = keyversion
 			if keyarch, ok := matchPackageSelectorArch(env.OperatingSystem.Architecture, manifest.Packages[keyplatform][keyversion]); ok {
-				return manifest.Packages[keyplatform][keyversion][keyarch], nil
+				resolution.MatchedArchitecture = keyarch
+				resolution.packageInfo = manifest.Packages[keyplatform][keyversion][keyarch]
+				if resolution.packageInfo != nil {
+					resolution.File = resolution.packageInfo.File
+				}
+				return resolution, nil
 			}
 		}
 	}
 
-	return nil, fmt.Errorf("no manifest found for platform: %s, version %s, architecture %s",
+	return resolution, fmt.Errorf("no manifest found for platform: %s, version %s, architecture %s",
 		env.OperatingSystem.Platform, env.OperatingSystem.PlatformVersion, env.OperatingSystem.Architecture)
 }
 
-func matchPackageSelectorPlatform(key string, dict map[string]map[string]map[string]*PackageInfo) (string, bool) {
-	if _, ok := dict[key]; ok {
-		return key, true
-	} else if _, ok := dict["_any"]; ok {
+// platformSelectorKeys returns the manifest platform keys to try, in order, for the platform and family of the instance
+func platformSelectorKeys(policy string, platform string, family string) ([]string, error) {
+	switch policy {
+	case PlatformSelectionExact:
+		return []string{platform}, nil
+	case PlatformSelectionFamily:
+		return []string{family}, nil
+	case "", PlatformSelectionExactThenFamily:
+		return []string{platform, family}, nil
+	default:
+		return nil, fmt.Errorf("unknown platform selection policy %v, expected %v, %v or %v",
+			policy, PlatformSelectionExact, PlatformSelectionFamily, PlatformSelectionExactThenFamily)
+	}
+}
+
+func matchPackageSelectorPlatform(keys []string, dict map[string]map[string]map[string]*PackageInfo) (string, bool) {
+	for _, key := range keys {
+		if key == "" {
+			continue
+		}
+		if _, ok := dict[key]; ok {
+			return key, true
+		}
+	}
+	if _, ok := dict["_any"]; ok {
 		return "_any", true
 	}
 
@@ -322,10 +567,14 @@ func matchPackageSelectorVersion(key string, dict map[string]map[string]*Package
 	return "", false
 }
 
+// matchPackageSelectorArch matches the architecture, or else one of its aliases, before falling back to _any
 func matchPackageSelectorArch(key string, dict map[string]*PackageInfo) (string, bool) {
-	if _, ok := dict[key]; ok {
-		return key, true
-	} else if _, ok := dict["_any"]; ok {
+	for _, arch := range architectureCandidates(key) {
+		if _, ok := dict[arch]; ok {
+			return arch, true
+		}
+	}
+	if _, ok := dict["_any"]; ok {
 		return "_any", true
 	}
 
@@ -0,0 +1,72 @@
+// Copyright 2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package birdwatcher
+
+import (
+	"strings"
+	"sync"
+)
+
+// architectureAliases maps each architecture name to all the names, itself included, manifests may list the same architecture under
+var architectureAliases = struct {
+	sync.RWMutex
+	names map[string][]string
+}{names: make(map[string][]string)}
+
+func init() {
+	RegisterArchitectureAliases("x86_64", "amd64")
+	RegisterArchitectureAliases("arm64", "aarch64")
+	RegisterArchitectureAliases("i386", "386", "i686")
+}
+
+// RegisterArchitectureAliases makes the architecture names aliases of each other when manifest entries are matched.
+// Names already registered with other aliases join them, so all of them become aliases of each other.
+func RegisterArchitectureAliases(names ...string) {
+	architectureAliases.Lock()
+	defer architectureAliases.Unlock()
+
+	var group []string
+	seen := make(map[string]bool)
+	add := func(name string) {
+		if name = strings.ToLower(name); name != "" && !seen[name] {
+			seen[name] = true
+			group = append(group, name)
+		}
+	}
+	for _, name := range names {
+		for _, alias := range architectureAliases.names[strings.ToLower(name)] {
+			add(alias)
+		}
+		add(name)
+	}
+	for _, name := range group {
+		architectureAliases.names[name] = group
+	}
+}
+
+// architectureCandidates returns the manifest architecture keys to try, in order, for the architecture of the instance:
+// the architecture itself followed by its aliases
+func architectureCandidates(arch string) []string {
+	architectureAliases.RLock()
+	defer architectureAliases.RUnlock()
+
+	candidates := []string{arch}
+	for _, alias := range architectureAliases.names[strings.ToLower(arch)] {
+		if alias != arch {
+			candidates = append(candidates, alias)
+		}
+	}
+	return candidates
+}
@@ -0,0 +1,92 @@
+// Copyright 2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package birdwatcher
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatchPackageSelectorArch(t *testing.T) {
+	data := []struct {
+		name         string
+		arch         string
+		manifestArch []string
+		expected     string
+		expectMatch  bool
+	}{
+		{"arm64 instance, aarch64 manifest", "arm64", []string{"aarch64"}, "aarch64", true},
+		{"aarch64 instance, arm64 manifest", "aarch64", []string{"arm64"}, "arm64", true},
+		{"x86_64 instance, amd64 manifest", "x86_64", []string{"amd64"}, "amd64", true},
+		{"amd64 instance, x86_64 manifest", "amd64", []string{"x86_64"}, "x86_64", true},
+		{"386 instance, i386 manifest", "386", []string{"i386"}, "i386", true},
+		{"386 instance, i686 manifest", "386", []string{"i686"}, "i686", true},
+		{"exact entry preferred over alias", "arm64", []string{"aarch64", "arm64"}, "arm64", true},
+		{"alias preferred over _any", "arm64", []string{"_any", "aarch64"}, "aarch64", true},
+		{"unrelated architecture falls back to _any", "arm64", []string{"_any", "x86_64"}, "_any", true},
+		{"unrelated architecture", "arm64", []string{"x86_64", "amd64"}, "", false},
+		{"unknown architecture matches itself", "riscv64", []string{"riscv64"}, "riscv64", true},
+	}
+	for _, testdata := range data {
+		t.Run(testdata.name, func(t *testing.T) {
+			dict := make(map[string]*PackageInfo)
+			for _, arch := range testdata.manifestArch {
+				dict[arch] = &PackageInfo{File: arch + ".zip"}
+			}
+
+			matched, ok := matchPackageSelectorArch(testdata.arch, dict)
+
+			assert.Equal(t, testdata.expectMatch, ok)
+			assert.Equal(t, testdata.expected, matched)
+		})
+	}
+}
+
+func TestRegisterArchitectureAliases(t *testing.T) {
+	defer restoreArchitectureAliases()()
+
+	RegisterArchitectureAliases("armv7l", "arm", "armhf")
+
+	assert.Equal(t, []string{"arm", "armv7l", "armhf"}, architectureCandidates("arm"))
+	matched, ok := matchPackageSelectorArch("arm", map[string]*PackageInfo{"armhf": {}})
+	assert.True(t, ok)
+	assert.Equal(t, "armhf", matched)
+}
+
+func TestRegisterArchitectureAliases_JoinsExistingAliases(t *testing.T) {
+	defer restoreArchitectureAliases()()
+
+	RegisterArchitectureAliases("ARM64", "armv8")
+
+	assert.Equal(t, []string{"arm64", "aarch64", "armv8"}, architectureCandidates("arm64"))
+	assert.Equal(t, []string{"armv8", "arm64", "aarch64"}, architectureCandidates("armv8"))
+	assert.Equal(t, []string{"aarch64", "arm64", "armv8"}, architectureCandidates("aarch64"))
+}
+
+// restoreArchitectureAliases saves the registered architecture aliases and returns the function restoring them
+func restoreArchitectureAliases() (restore func()) {
+	architectureAliases.Lock()
+	saved := make(map[string][]string, len(architectureAliases.names))
+	for name, group := range architectureAliases.names {
+		saved[name] = group
+	}
+	architectureAliases.Unlock()
+	return func() {
+		architectureAliases.Lock()
+		architectureAliases.names = saved
+		architectureAliases.Unlock()
+	}
+}
@@ -0,0 +1,134 @@
+// Copyright 2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package birdwatcher
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/aws/amazon-ssm-agent/agent/fileutil/artifact"
+)
+
+// maxConcurrentChecksums is how many files are read and hashed at the same time when verifying checksums
+const maxConcurrentChecksums = 4
+
+// fileChecksumCheck is a local file to compare with its checksum from the manifest
+type fileChecksumCheck struct {
+	Name      string
+	Path      string
+	Algorithm string
+	Expected  string
+}
+
+// checksumMismatchError lists every file that failed checksum verification
+type checksumMismatchError struct {
+	// Files maps the name of each bad file to why it failed
+	Files map[string]string
+	// Actual maps the name of each bad file that could be read to its checksum
+	Actual map[string]string
+}
+
+func (e *checksumMismatchError) Error() string {
+	names := make([]string, 0, len(e.Files))
+	for name := range e.Files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	failures := make([]string, 0, len(names))
+	for _, name := range names {
+		failures = append(failures, fmt.Sprintf("%v (%v)", name, e.Files[name]))
+	}
+	return fmt.Sprintf("checksum verification failed for %v files: %v", len(failures), strings.Join(failures, ", "))
+}
+
+// checkFileChecksum hashes a file and returns its checksum and why it doesn't match, empty if it does
+func checkFileChecksum(check fileChecksumCheck) (actual string, failure string) {
+	actual, err := fileChecksum(check.Path, check.Algorithm)
+	if err != nil {
+		return "", fmt.Sprintf("failed to compute %v checksum: %v", check.Algorithm, err)
+	}
+	if !strings.EqualFold(actual, check.Expected) {
+		return actual, fmt.Sprintf("%v checksum %v does not match the expected %v", check.Algorithm, actual, check.Expected)
+	}
+	return actual, ""
+}
+
+// verifyFileChecksum compares a single file with its expected checksum and returns a checksumMismatchError if it doesn't match
+func verifyFileChecksum(check fileChecksumCheck) error {
+	actual, failure := checkFileChecksum(check)
+	if failure == "" {
+		return nil
+	}
+	mismatch := &checksumMismatchError{Files: map[string]string{check.Name: failure}, Actual: map[string]string{}}
+	if actual != "" {
+		mismatch.Actual[check.Name] = actual
+	}
+	return mismatch
+}
+
+// verifyFileChecksums hashes all the files of a package concurrently, at most maxConcurrentChecksums at a time,
+// and returns a checksumMismatchError listing all the files that don't match or can't be read.
+func verifyFileChecksums(checks []fileChecksumCheck) error {
+	var lock sync.Mutex
+	mismatch := &checksumMismatchError{Files: map[string]string{}, Actual: map[string]string{}}
+	pending := make(chan fileChecksumCheck)
+
+	var wg sync.WaitGroup
+	workers := maxConcurrentChecksums
+	if len(checks) < workers {
+		workers = len(checks)
+	}
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for check := range pending {
+				actual, failure := checkFileChecksum(check)
+				if failure == "" {
+					continue
+				}
+				lock.Lock()
+				mismatch.Files[check.Name] = failure
+				if actual != "" {
+					mismatch.Actual[check.Name] = actual
+				}
+				lock.Unlock()
+			}
+		}()
+	}
+	for _, check := range checks {
+		pending <- check
+	}
+	close(pending)
+	wg.Wait()
+
+	if len(mismatch.Files) > 0 {
+		return mismatch
+	}
+	return nil
+}
+
+// verifyDownloadedFile compares a downloaded file with the strongest checksum the manifest declares for it, sha256 in most manifests.
+// Files the manifest declares no checksum for aren't verified.
+func verifyDownloadedFile(file *File, localFilePath string) error {
+	algorithm, expected := artifact.PreferredChecksum(file.Checksums)
+	if expected == "" {
+		return nil
+	}
+	return verifyFileChecksum(fileChecksumCheck{Name: filepath.Base(localFilePath), Path: localFilePath, Algorithm: algorithm, Expected: expected})
+}
@@ -0,0 +1,121 @@
+// Copyright 2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package birdwatcher
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/aws/amazon-ssm-agent/agent/fileutil/artifact"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/configurepackage/trace"
+	"github.com/stretchr/testify/assert"
+)
+
+// the sha256 checksums of "content", "original" and "debug"
+const (
+	contentSha256  = "ed7002b439e9ac845f22357d822bac1444730fbdb6016d3ec9432297b9ec9f73"
+	originalSha256 = "0682c5f2076f099c34cfdd15a9e063849ed437a49677e6fcc5b4198c76575be5"
+	debugSha256    = "0b8e9e995d8d77f1e4770f0f79665aee6f3f70247b3735422daba73df4c3096f"
+)
+
+func TestVerifyFileChecksums(t *testing.T) {
+	fileSys := newFileSysMock()
+	filesysdep = fileSys
+
+	var checks []fileChecksumCheck
+	for i := 0; i < 10; i++ {
+		name := fmt.Sprintf("file%v.zip", i)
+		content := []byte(fmt.Sprintf("content of %v", name))
+		checksum, err := artifact.Checksum("sha256", content)
+		assert.NoError(t, err)
+		fileSys.files["local/"+name] = content
+		checks = append(checks, fileChecksumCheck{Name: name, Path: "local/" + name, Algorithm: "sha256", Expected: checksum})
+	}
+	assert.NoError(t, verifyFileChecksums(checks))
+
+	// tamper with two of the files
+	fileSys.files["local/file3.zip"] = []byte("tampered")
+	fileSys.files["local/file7.zip"] = []byte("tampered")
+
+	err := verifyFileChecksums(checks)
+	assert.Error(t, err)
+	mismatch, ok := err.(*checksumMismatchError)
+	assert.True(t, ok)
+	assert.Len(t, mismatch.Files, 2)
+	assert.Contains(t, mismatch.Files, "file3.zip")
+	assert.Contains(t, mismatch.Files, "file7.zip")
+	tampered, _ := artifact.Checksum("sha256", []byte("tampered"))
+	assert.Equal(t, map[string]string{"file3.zip": tampered, "file7.zip": tampered}, mismatch.Actual)
+	assert.Contains(t, err.Error(), "checksum verification failed for 2 files: file3.zip (sha256 checksum")
+	assert.Contains(t, err.Error(), "file7.zip (sha256 checksum")
+}
+
+func TestVerifyFileChecksums_UnreadableFile(t *testing.T) {
+	filesysdep = newFileSysMock()
+
+	err := verifyFileChecksums([]fileChecksumCheck{{Name: "missing.zip", Path: "local/missing.zip", Algorithm: "sha256", Expected: "0000"}})
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "missing.zip (failed to compute sha256 checksum: file not found)")
+	assert.Empty(t, err.(*checksumMismatchError).Actual)
+}
+
+func TestVerifyFileChecksums_NoFiles(t *testing.T) {
+	assert.NoError(t, verifyFileChecksums(nil))
+}
+
+func TestDownloadFile_VerifiesChecksum(t *testing.T) {
+	tracer := trace.NewTracer(log.NewMockLog())
+	tracer.BeginSection("test segment root")
+
+	data := []struct {
+		name        string
+		content     string
+		checksums   map[string]string
+		expectedErr string
+	}{
+		{"matching sha256", "content", map[string]string{"sha256": contentSha256}, ""},
+		{"matching upper case sha256", "content", map[string]string{"SHA256": "ED7002B439E9AC845F22357D822BAC1444730FBDB6016D3EC9432297B9EC9F73"}, ""},
+		{"no checksum declared", "content", nil, ""},
+		{"tampered file", "tampered", map[string]string{"sha256": contentSha256}, "agent.zip (sha256 checksum"},
+		{"unsupported algorithm", "content", map[string]string{"crc32": "0000"}, "agent.zip (failed to compute crc32 checksum"},
+	}
+	for _, testdata := range data {
+		t.Run(testdata.name, func(t *testing.T) {
+			fileSys := newFileSysMock()
+			filesysdep = fileSys
+			networkdep = &networkMock{
+				downloadOutput:  artifact.DownloadOutput{LocalFilePath: "agent.zip"},
+				downloadContent: map[string]string{"https://example.com/agent": testdata.content},
+			}
+
+			result, err := downloadFile(tracer, &File{DownloadLocation: "https://example.com/agent", Checksums: testdata.checksums}, nil)
+
+			if testdata.expectedErr == "" {
+				assert.NoError(t, err)
+				assert.Equal(t, "agent.zip", result)
+				assert.True(t, fileSys.Exists("agent.zip"))
+			} else {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), "downloaded installation package https://example.com/agent does not match the manifest")
+				assert.Contains(t, err.Error(), testdata.expectedErr)
+				assert.Empty(t, result)
+				// the mismatching file is deleted
+				assert.False(t, fileSys.Exists("agent.zip"))
+			}
+		})
+	}
+}
@@ -15,6 +15,10 @@
 package birdwatcher
 
 import (
+	"io/ioutil"
+	"os"
+
+	"github.com/aws/amazon-ssm-agent/agent/fileutil"
 	"github.com/aws/amazon-ssm-agent/agent/fileutil/artifact"
 	"github.com/aws/amazon-ssm-agent/agent/log"
 )
@@ -31,3 +35,41 @@ type networkDepImp struct{}
 func (networkDepImp) Download(log log.T, input artifact.DownloadInput) (artifact.DownloadOutput, error) {
 	return artifact.Download(log, input)
 }
+
+// dependency on filesystem and os utility functions, ReadFile must be safe to call concurrently
+type fileSysDep interface {
+	MakeDirs(destinationDir string) error
+	Exists(filePath string) bool
+	ReadFile(filename string) ([]byte, error)
+	WriteFile(filename string, content string) error
+	Rename(oldPath string, newPath string) error
+	Remove(filePath string) error
+}
+
+var filesysdep fileSysDep = &fileSysDepImp{}
+
+type fileSysDepImp struct{}
+
+func (fileSysDepImp) MakeDirs(destinationDir string) error {
+	return fileutil.MakeDirs(destinationDir)
+}
+
+func (fileSysDepImp) Exists(filePath string) bool {
+	return fileutil.Exists(filePath)
+}
+
+func (fileSysDepImp) ReadFile(filename string) ([]byte, error) {
+	return ioutil.ReadFile(filename)
+}
+
+func (fileSysDepImp) WriteFile(filename string, content string) error {
+	return fileutil.WriteAllText(filename, content)
+}
+
+func (fileSysDepImp) Rename(oldPath string, newPath string) error {
+	return os.Rename(oldPath, newPath)
+}
+
+func (fileSysDepImp) Remove(filePath string) error {
+	return os.Remove(filePath)
+}
@@ -0,0 +1,73 @@
+// Copyright 2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+package birdwatcher
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/plugins/configurepackage/envdetect"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/configurepackage/trace"
+)
+
+// defaultPlatformDetectionTimeout is used when no platform detection timeout is configured
+const defaultPlatformDetectionTimeout = 2 * time.Minute
+
+// lastEnvironment is the environment of the last platform detection that completed successfully
+var (
+	lastEnvironmentLock sync.Mutex
+	lastEnvironment     *envdetect.Environment
+)
+
+// collectEnvironment detects the platform of the instance, giving up once the platform detection timeout expires.
+// On timeout the environment of the last successful detection is used when there is one.
+// A detection that times out is abandoned and its result is discarded once it completes.
+func (ds *PackageService) collectEnvironment(tracer trace.Tracer) (*envdetect.Environment, error) {
+	log := tracer.CurrentTrace().Logger
+	timeout := ds.platformDetectionTimeout
+	if timeout <= 0 {
+		timeout = defaultPlatformDetectionTimeout
+	}
+
+	type collectResult struct {
+		env *envdetect.Environment
+		err error
+	}
+	done := make(chan collectResult, 1)
+	go func() {
+		env, err := ds.collector.CollectData(log)
+		done <- collectResult{env: env, err: err}
+	}()
+
+	select {
+	case result := <-done:
+		if result.err == nil && result.env != nil {
+			lastEnvironmentLock.Lock()
+			lastEnvironment = result.env
+			lastEnvironmentLock.Unlock()
+		}
+		return result.env, result.err
+	case <-time.After(timeout):
+	}
+
+	lastEnvironmentLock.Lock()
+	cached := lastEnvironment
+	lastEnvironmentLock.Unlock()
+	if cached == nil {
+		return nil, fmt.Errorf("platform detection did not complete within %v", timeout)
+	}
+	tracer.CurrentTrace().AppendInfof("platform detection did not complete within %v, using the previously detected platform", timeout)
+	return cached, nil
+}
@@ -0,0 +1,95 @@
+// Copyright 2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+package birdwatcher
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/configurepackage/envdetect"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/configurepackage/envdetect/osdetect"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/configurepackage/trace"
+	"github.com/stretchr/testify/assert"
+)
+
+// blockingCollector is a platform detection stub that does not return until released
+type blockingCollector struct {
+	release chan struct{}
+	env     *envdetect.Environment
+}
+
+func (c *blockingCollector) CollectData(log log.T) (*envdetect.Environment, error) {
+	<-c.release
+	return c.env, nil
+}
+
+func TestCollectEnvironmentTimeout(t *testing.T) {
+	cachedEnv := &envdetect.Environment{OperatingSystem: &osdetect.OperatingSystem{Platform: "cached"}}
+
+	data := []struct {
+		name        string
+		cached      *envdetect.Environment
+		expectedEnv *envdetect.Environment
+		expectedErr string
+	}{
+		{
+			"timeout without previous detection",
+			nil,
+			nil,
+			"platform detection did not complete within 10ms",
+		},
+		{
+			"timeout with previous detection",
+			cachedEnv,
+			cachedEnv,
+			"",
+		},
+	}
+	for _, testdata := range data {
+		t.Run(testdata.name, func(t *testing.T) {
+			lastEnvironment = testdata.cached
+			defer func() { lastEnvironment = nil }()
+
+			collector := &blockingCollector{release: make(chan struct{})}
+			defer close(collector.release)
+			ds := &PackageService{collector: collector, platformDetectionTimeout: 10 * time.Millisecond}
+			tracer := trace.NewTracer(log.NewMockLog())
+			tracer.BeginSection("test collect environment")
+
+			env, err := ds.collectEnvironment(tracer)
+			if testdata.expectedErr != "" {
+				assert.EqualError(t, err, testdata.expectedErr)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, testdata.expectedEnv, env)
+		})
+	}
+}
+
+func TestCollectEnvironmentCachesDetection(t *testing.T) {
+	defer func() { lastEnvironment = nil }()
+	env := &envdetect.Environment{OperatingSystem: &osdetect.OperatingSystem{Platform: "detected"}}
+	collector := &blockingCollector{release: make(chan struct{}), env: env}
+	close(collector.release)
+	ds := &PackageService{collector: collector, platformDetectionTimeout: time.Minute}
+	tracer := trace.NewTracer(log.NewMockLog())
+	tracer.BeginSection("test collect environment")
+
+	result, err := ds.collectEnvironment(tracer)
+	assert.NoError(t, err)
+	assert.Equal(t, env, result)
+	assert.Equal(t, env, lastEnvironment)
+}
@@ -0,0 +1,122 @@
+// Copyright 2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package birdwatcher
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/fileutil/artifact"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/configurepackage/trace"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/ssm"
+)
+
+const (
+	// manifestFetchAttempts is how many times a manifest is fetched before giving up on a transient failure
+	manifestFetchAttempts = 3
+	// manifestFetchBackoff is the wait before the first retry of a manifest fetch, it doubles with each retry
+	manifestFetchBackoff = 500 * time.Millisecond
+)
+
+// sleep is a seam for waiting between manifest fetch attempts
+var sleep = time.Sleep
+
+// manifestChecksumError is returned when a fetched manifest doesn't match its known checksum
+type manifestChecksumError struct {
+	algorithm string
+	expected  string
+	actual    string
+}
+
+func (e *manifestChecksumError) Error() string {
+	return fmt.Sprintf("%v checksum %v does not match the expected %v", e.algorithm, e.actual, e.expected)
+}
+
+// fetchManifest gets the manifest of a package version, retrying with backoff when the fetch fails transiently
+// or the manifest doesn't match its known checksum, which points at a corrupted transfer.
+func fetchManifest(tracer trace.Tracer, ds *PackageService, packageName string, version string) ([]byte, error) {
+	var lastErr error
+	backoff := manifestFetchBackoff
+	for attempt := 1; attempt <= manifestFetchAttempts; attempt++ {
+		if attempt > 1 {
+			if current := tracer.CurrentTrace(); current != nil {
+				current.AppendInfof("retrying manifest fetch of %v in %v after: %v", packageName, backoff, lastErr)
+			}
+			sleep(backoff)
+			backoff *= 2
+		}
+
+		resp, err := ds.facadeClient.GetManifest(
+			&ssm.GetManifestInput{
+				PackageName:    &packageName,
+				PackageVersion: &version,
+			},
+		)
+		if err != nil {
+			if !isTransientManifestError(err) {
+				return nil, fmt.Errorf("failed to retrieve manifest: %v", err)
+			}
+			lastErr = err
+			continue
+		}
+
+		byteManifest := []byte(*resp.Manifest)
+		if err := ds.verifyManifestChecksum(packageName, version, byteManifest); err != nil {
+			if _, ok := err.(*manifestChecksumError); !ok {
+				return nil, fmt.Errorf("failed to verify manifest: %v", err)
+			}
+			lastErr = err
+			continue
+		}
+		return byteManifest, nil
+	}
+
+	if _, ok := lastErr.(*manifestChecksumError); ok {
+		return nil, fmt.Errorf("manifest failed the integrity check after %v attempts: %v", manifestFetchAttempts, lastErr)
+	}
+	return nil, fmt.Errorf("failed to retrieve manifest after %v attempts: %v", manifestFetchAttempts, lastErr)
+}
+
+// verifyManifestChecksum compares the manifest with its known checksum, manifests without one are not verified
+func (ds *PackageService) verifyManifestChecksum(packageName string, version string, manifest []byte) error {
+	algorithm, expected := artifact.PreferredChecksum(ds.manifestChecksums[packageName][version])
+	if expected == "" {
+		return nil
+	}
+	actual, err := artifact.Checksum(algorithm, manifest)
+	if err != nil {
+		return err
+	}
+	if !strings.EqualFold(actual, expected) {
+		return &manifestChecksumError{algorithm: algorithm, expected: expected, actual: actual}
+	}
+	return nil
+}
+
+// isTransientManifestError returns true if a failed manifest fetch may succeed when retried.
+// Errors returned by the service are only transient when the SDK considers them retryable,
+// errors that never reached it, such as connection failures, always are.
+func isTransientManifestError(err error) bool {
+	if requestFailure, ok := err.(awserr.RequestFailure); ok && requestFailure.StatusCode() >= 500 {
+		return true
+	}
+	if _, ok := err.(awserr.Error); ok {
+		return request.IsErrorRetryable(err) || request.IsErrorThrottle(err)
+	}
+	return true
+}
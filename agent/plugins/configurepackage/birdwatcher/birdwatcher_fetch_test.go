@@ -0,0 +1,124 @@
+// Copyright 2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package birdwatcher
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/fileutil/artifact"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/configurepackage/packageservice"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/configurepackage/trace"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDownloadManifestRetries(t *testing.T) {
+	manifestStr := `{"version": "1234", "packageArn": "packagearn"}`
+	corruptStr := `{"version": "1234", "packageArn": "packagearm"}`
+	checksum, _ := artifact.Checksum("sha256", []byte(manifestStr))
+
+	data := []struct {
+		name          string
+		responses     []getManifestResponse
+		checksums     map[string]string
+		expectedCalls int
+		expectedErr   string
+	}{
+		{
+			"transient failure recovers",
+			[]getManifestResponse{{err: errors.New("connection reset")}, {manifest: manifestStr}},
+			map[string]string{"sha256": checksum},
+			2,
+			"",
+		},
+		{
+			"corrupt transfer recovers",
+			[]getManifestResponse{{manifest: corruptStr}, {manifest: manifestStr}},
+			map[string]string{"sha256": checksum},
+			2,
+			"",
+		},
+		{
+			"corrupt manifest",
+			[]getManifestResponse{{manifest: corruptStr}},
+			map[string]string{"sha256": checksum},
+			manifestFetchAttempts,
+			"manifest failed the integrity check after 3 attempts",
+		},
+		{
+			"unverified manifest is not checked",
+			[]getManifestResponse{{manifest: corruptStr}},
+			nil,
+			1,
+			"",
+		},
+		{
+			"persistent failure",
+			[]getManifestResponse{{err: errors.New("connection reset")}},
+			nil,
+			manifestFetchAttempts,
+			"failed to retrieve manifest after 3 attempts: connection reset",
+		},
+		{
+			"non transient failure is not retried",
+			[]getManifestResponse{{err: awserr.NewRequestFailure(awserr.New("ValidationException", "unknown package", nil), 400, "id")}},
+			nil,
+			1,
+			"failed to retrieve manifest: ValidationException",
+		},
+		{
+			"parse error",
+			[]getManifestResponse{{manifest: "{not json"}},
+			nil,
+			1,
+			"failed to decode manifest",
+		},
+	}
+
+	for _, testdata := range data {
+		t.Run(testdata.name, func(t *testing.T) {
+			var waits []time.Duration
+			sleep = func(d time.Duration) { waits = append(waits, d) }
+			defer func() { sleep = time.Sleep }()
+
+			facade := &facadeMock{getManifestSequence: testdata.responses}
+			ds := &PackageService{
+				facadeClient:      facade,
+				manifestCache:     packageservice.ManifestCacheMemNew(),
+				manifestChecksums: map[string]map[string]map[string]string{"packagearn": {"1234": testdata.checksums}},
+			}
+
+			tracer := trace.NewTracer(log.NewMockLog())
+			tracer.BeginSection("download manifest")
+			_, version, _, err := ds.DownloadManifest(tracer, "packagearn", "1234")
+
+			assert.Equal(t, testdata.expectedCalls, facade.getManifestCalls)
+			assert.Len(t, waits, testdata.expectedCalls-1)
+			for i := 1; i < len(waits); i++ {
+				assert.Equal(t, 2*waits[i-1], waits[i])
+			}
+			if testdata.expectedErr != "" {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), testdata.expectedErr)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, "1234", version)
+			}
+		})
+	}
+}
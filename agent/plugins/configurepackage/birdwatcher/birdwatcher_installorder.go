@@ -0,0 +1,98 @@
+// Copyright 2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package birdwatcher
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ParseInstallManifest decodes an install manifest and returns the order its packages must be installed in
+func ParseInstallManifest(data []byte) (*InstallManifest, []string, error) {
+	var manifest InstallManifest
+	if err := json.NewDecoder(bytes.NewReader(data)).Decode(&manifest); err != nil {
+		return nil, nil, fmt.Errorf("failed to decode install manifest: %v", err)
+	}
+	order, err := InstallOrder(&manifest)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &manifest, order, nil
+}
+
+// InstallOrder returns the names of the packages of the manifest so that each package comes after its dependencies.
+// Each package is then installed on its own through the usual install flow. Packages that don't depend on each
+// other are ordered by name, so the order is the same on every instance.
+func InstallOrder(manifest *InstallManifest) ([]string, error) {
+	names := make([]string, 0, len(manifest.Packages))
+	for name, pkg := range manifest.Packages {
+		if pkg == nil {
+			return nil, fmt.Errorf("package %v of the install manifest is empty", name)
+		}
+		for _, dependency := range pkg.Dependencies {
+			if _, found := manifest.Packages[dependency]; !found {
+				return nil, fmt.Errorf("package %v depends on %v, which is not in the install manifest", name, dependency)
+			}
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := make(map[string]int, len(names))
+	order := make([]string, 0, len(names))
+	// path is the chain of packages being visited, to report the packages of a cycle
+	var path []string
+	var visit func(name string) error
+	visit = func(name string) error {
+		switch state[name] {
+		case visited:
+			return nil
+		case visiting:
+			start := 0
+			for path[start] != name {
+				start++
+			}
+			cycle := append(append([]string{}, path[start:]...), name)
+			return fmt.Errorf("install manifest has a dependency cycle: %v", strings.Join(cycle, " -> "))
+		}
+		state[name] = visiting
+		path = append(path, name)
+		dependencies := append([]string{}, manifest.Packages[name].Dependencies...)
+		sort.Strings(dependencies)
+		for _, dependency := range dependencies {
+			if err := visit(dependency); err != nil {
+				return err
+			}
+		}
+		path = path[:len(path)-1]
+		state[name] = visited
+		order = append(order, name)
+		return nil
+	}
+	for _, name := range names {
+		if err := visit(name); err != nil {
+			return nil, err
+		}
+	}
+	return order, nil
+}
@@ -0,0 +1,106 @@
+// Copyright 2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package birdwatcher
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInstallOrder(t *testing.T) {
+	data := []struct {
+		name          string
+		dependencies  map[string][]string
+		expectedOrder []string
+		expectedError string
+	}{
+		{
+			"linear chain",
+			map[string][]string{"app": {"runtime"}, "runtime": {"libs"}, "libs": nil},
+			[]string{"libs", "runtime", "app"},
+			"",
+		},
+		{
+			"diamond",
+			map[string][]string{"app": {"logging", "metrics"}, "logging": {"base"}, "metrics": {"base"}, "base": nil},
+			[]string{"base", "logging", "metrics", "app"},
+			"",
+		},
+		{
+			"independent packages",
+			map[string][]string{"b": nil, "c": nil, "a": nil},
+			[]string{"a", "b", "c"},
+			"",
+		},
+		{
+			"cycle",
+			map[string][]string{"app": {"runtime"}, "runtime": {"libs"}, "libs": {"app"}},
+			nil,
+			"dependency cycle: app -> runtime -> libs -> app",
+		},
+		{
+			"self dependency",
+			map[string][]string{"app": {"app"}},
+			nil,
+			"dependency cycle: app -> app",
+		},
+		{
+			"unknown dependency",
+			map[string][]string{"app": {"runtime"}},
+			nil,
+			"app depends on runtime, which is not in the install manifest",
+		},
+	}
+	for _, testdata := range data {
+		t.Run(testdata.name, func(t *testing.T) {
+			manifest := &InstallManifest{Packages: make(map[string]*InstallPackage)}
+			for name, dependencies := range testdata.dependencies {
+				manifest.Packages[name] = &InstallPackage{Version: "1.0", Dependencies: dependencies}
+			}
+
+			order, err := InstallOrder(manifest)
+
+			if testdata.expectedError == "" {
+				assert.NoError(t, err)
+				assert.Equal(t, testdata.expectedOrder, order)
+			} else {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), testdata.expectedError)
+			}
+		})
+	}
+}
+
+func TestParseInstallManifest(t *testing.T) {
+	manifest, order, err := ParseInstallManifest([]byte(`{
+		"schemaVersion": "1.0",
+		"packages": {
+			"AWSAgent": {"version": "2.0", "dependencies": ["AWSRuntime"]},
+			"AWSRuntime": {"version": "1.5"}
+		}
+	}`))
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"AWSRuntime", "AWSAgent"}, order)
+	assert.Equal(t, "2.0", manifest.Packages["AWSAgent"].Version)
+
+	_, _, err = ParseInstallManifest([]byte(`{"packages": {"a": {"dependencies": ["b"]}, "b": {"dependencies": ["a"]}}}`))
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "dependency cycle: a -> b -> a")
+
+	_, _, err = ParseInstallManifest([]byte(`{"packages": `))
+	assert.Error(t, err)
+}
@@ -0,0 +1,132 @@
+// Copyright 2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package birdwatcher
+
+import (
+	"runtime"
+	"sync"
+	"time"
+)
+
+// parsedManifestTTL is how long a downloaded manifest is reused by the operations of the agent process
+const parsedManifestTTL = time.Minute
+
+// sharedParsedManifests is the manifest cache of the package services created by New
+var sharedParsedManifests = newParsedManifestCache(parsedManifestTTL)
+
+// parsedManifestKey identifies a manifest in the parsed manifest cache
+type parsedManifestKey struct {
+	packageName string
+	version     string
+	// platform is the one the agent runs on, the manifest is resolved for it
+	platform string
+}
+
+// newParsedManifestKey returns the key of the manifest of a package version for the platform of the agent
+func newParsedManifestKey(packageName string, version string) parsedManifestKey {
+	return parsedManifestKey{packageName: packageName, version: version, platform: runtime.GOOS + "/" + runtime.GOARCH}
+}
+
+// parsedManifest is a downloaded manifest and whether it was the same as the one in the manifest cache
+type parsedManifest struct {
+	manifest      *Manifest
+	isSameAsCache bool
+	expires       time.Time
+}
+
+// manifestFetch is a download of a manifest that the concurrent requests for the same manifest wait for
+type manifestFetch struct {
+	done   chan struct{}
+	result parsedManifest
+	err    error
+}
+
+// parsedManifestCache keeps downloaded manifests in memory for a while, so near-simultaneous operations on the
+// same package don't download and parse its manifest again. Concurrent misses share a single download.
+// Failed downloads are not cached.
+type parsedManifestCache struct {
+	ttl time.Duration
+	// now is a seam for the current time
+	now func() time.Time
+
+	lock     sync.Mutex
+	entries  map[parsedManifestKey]parsedManifest
+	inflight map[parsedManifestKey]*manifestFetch
+}
+
+func newParsedManifestCache(ttl time.Duration) *parsedManifestCache {
+	return &parsedManifestCache{
+		ttl:      ttl,
+		now:      time.Now,
+		entries:  make(map[parsedManifestKey]parsedManifest),
+		inflight: make(map[parsedManifestKey]*manifestFetch),
+	}
+}
+
+// get returns the cached manifest for the key, or calls download once for all the concurrent requests missing it.
+// A cached manifest is the same as the one in the manifest cache, download wrote it there.
+// Each caller gets its own copy of the manifest since file overrides modify it.
+func (c *parsedManifestCache) get(key parsedManifestKey, download func() (*Manifest, bool, error)) (*Manifest, bool, error) {
+	if c == nil {
+		return download()
+	}
+
+	c.lock.Lock()
+	if entry, ok := c.entries[key]; ok {
+		if c.now().Before(entry.expires) {
+			c.lock.Unlock()
+			return entry.manifest.clone(), true, nil
+		}
+		delete(c.entries, key)
+	}
+	if fetch, ok := c.inflight[key]; ok {
+		c.lock.Unlock()
+		<-fetch.done
+		if fetch.err != nil {
+			return nil, false, fetch.err
+		}
+		return fetch.result.manifest.clone(), fetch.result.isSameAsCache, nil
+	}
+	fetch := &manifestFetch{done: make(chan struct{})}
+	c.inflight[key] = fetch
+	c.lock.Unlock()
+
+	manifest, isSameAsCache, err := download()
+
+	c.lock.Lock()
+	delete(c.inflight, key)
+	if err == nil {
+		fetch.result = parsedManifest{manifest: manifest.clone(), isSameAsCache: isSameAsCache, expires: c.now().Add(c.ttl)}
+		c.entries[key] = fetch.result
+	} else {
+		fetch.err = err
+	}
+	c.lock.Unlock()
+	close(fetch.done)
+
+	return manifest, isSameAsCache, err
+}
+
+// clone returns a copy of the manifest whose files can be replaced without affecting the original
+func (m *Manifest) clone() *Manifest {
+	copied := *m
+	if m.Files != nil {
+		copied.Files = make(map[string]*File, len(m.Files))
+		for name, file := range m.Files {
+			copied.Files[name] = file
+		}
+	}
+	return &copied
+}
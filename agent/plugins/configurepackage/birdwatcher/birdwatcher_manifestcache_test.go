@@ -0,0 +1,128 @@
+// Copyright 2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package birdwatcher
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/configurepackage/packageservice"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/configurepackage/trace"
+	"github.com/aws/aws-sdk-go/service/ssm"
+	"github.com/stretchr/testify/assert"
+)
+
+// blockingFacadeMock holds every GetManifest until release is closed and counts the calls
+type blockingFacadeMock struct {
+	facadeMock
+	manifest string
+	release  chan struct{}
+	calls    int32
+}
+
+func (m *blockingFacadeMock) GetManifest(input *ssm.GetManifestInput) (*ssm.GetManifestOutput, error) {
+	atomic.AddInt32(&m.calls, 1)
+	<-m.release
+	return &ssm.GetManifestOutput{Manifest: &m.manifest}, nil
+}
+
+func TestDownloadManifestConcurrentRequestsFetchOnce(t *testing.T) {
+	const requests = 10
+	facade := &blockingFacadeMock{
+		manifest: `{"version": "1234", "packageArn": "packagearn", "files": {"package.zip": {"downloadLocation": "https://example.com/package.zip"}}}`,
+		release:  make(chan struct{}),
+	}
+	ds := &PackageService{
+		facadeClient:    facade,
+		manifestCache:   packageservice.ManifestCacheMemNew(),
+		parsedManifests: newParsedManifestCache(time.Minute),
+	}
+
+	var started, finished sync.WaitGroup
+	manifests := make([]*Manifest, requests)
+	errs := make([]error, requests)
+	for i := 0; i < requests; i++ {
+		started.Add(1)
+		finished.Add(1)
+		go func(i int) {
+			defer finished.Done()
+			tracer := trace.NewTracer(log.NewMockLog())
+			tracer.BeginSection("download manifest")
+			started.Done()
+			manifests[i], _, errs[i] = downloadManifest(tracer, ds, "packagearn", "1234")
+		}(i)
+	}
+	started.Wait()
+	// let the requests reach the cache before the fetch completes, any that don't are served from the cache
+	time.Sleep(50 * time.Millisecond)
+	close(facade.release)
+	finished.Wait()
+
+	assert.Equal(t, int32(1), atomic.LoadInt32(&facade.calls))
+	for i := 0; i < requests; i++ {
+		assert.NoError(t, errs[i])
+		assert.Equal(t, "1234", manifests[i].Version)
+	}
+	// each request has its own copy of the manifest to override files in
+	manifests[0].Files["package.zip"] = &File{DownloadLocation: "https://mirror.example.com/package.zip"}
+	assert.Equal(t, "https://example.com/package.zip", manifests[1].Files["package.zip"].DownloadLocation)
+}
+
+func TestParsedManifestCache(t *testing.T) {
+	now := time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)
+	cache := newParsedManifestCache(time.Minute)
+	cache.now = func() time.Time { return now }
+	key := newParsedManifestKey("packagearn", "1234")
+
+	downloads := 0
+	failure := errors.New("connection reset")
+	download := func() (*Manifest, bool, error) {
+		downloads++
+		if downloads == 1 {
+			return nil, false, failure
+		}
+		return &Manifest{Version: "1234"}, false, nil
+	}
+
+	// failures are not cached
+	_, _, err := cache.get(key, download)
+	assert.Equal(t, failure, err)
+	manifest, isSameAsCache, err := cache.get(key, download)
+	assert.NoError(t, err)
+	assert.False(t, isSameAsCache)
+	assert.Equal(t, "1234", manifest.Version)
+	assert.Equal(t, 2, downloads)
+
+	// a cached manifest is the one written to the manifest cache
+	now = now.Add(59 * time.Second)
+	manifest, isSameAsCache, err = cache.get(key, download)
+	assert.NoError(t, err)
+	assert.True(t, isSameAsCache)
+	assert.Equal(t, "1234", manifest.Version)
+	assert.Equal(t, 2, downloads)
+
+	// other versions and expired manifests are downloaded
+	_, _, err = cache.get(newParsedManifestKey("packagearn", "5678"), download)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, downloads)
+	now = now.Add(time.Second)
+	_, _, err = cache.get(key, download)
+	assert.NoError(t, err)
+	assert.Equal(t, 4, downloads)
+}
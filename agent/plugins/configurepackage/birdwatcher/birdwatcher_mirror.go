@@ -0,0 +1,43 @@
+// Copyright 2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package birdwatcher
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/aws/amazon-ssm-agent/agent/log"
+)
+
+// downloadSources returns the URLs a file is downloaded from, in order: its manifest location followed by the
+// location on each mirror, which is the path of the manifest location under the mirror base URL
+func downloadSources(log log.T, location string, mirrorURLs []string) []string {
+	sources := []string{location}
+	if len(mirrorURLs) == 0 {
+		return sources
+	}
+	parsed, err := url.Parse(location)
+	if err != nil || parsed.Host == "" {
+		log.Warnf("mirrors are not used for %v, it is not an absolute URL", location)
+		return sources
+	}
+	for _, mirrorURL := range mirrorURLs {
+		if mirrorURL == "" {
+			continue
+		}
+		sources = append(sources, strings.TrimSuffix(mirrorURL, "/")+"/"+strings.TrimPrefix(parsed.EscapedPath(), "/"))
+	}
+	return sources
+}
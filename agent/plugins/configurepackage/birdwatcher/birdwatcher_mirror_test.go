@@ -0,0 +1,143 @@
+// Copyright 2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package birdwatcher
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/aws/amazon-ssm-agent/agent/fileutil/artifact"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/configurepackage/trace"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	primaryLocation = "https://s3.amazonaws.com/packages/agent/1.0/agent.zip"
+	firstMirror     = "https://mirror-1.example.com/birdwatcher/packages/agent/1.0/agent.zip"
+	secondMirror    = "https://mirror-2.example.com/packages/agent/1.0/agent.zip"
+)
+
+var mirrorURLs = []string{"https://mirror-1.example.com/birdwatcher/", "https://mirror-2.example.com"}
+
+func TestDownloadSources(t *testing.T) {
+	data := []struct {
+		name       string
+		location   string
+		mirrorURLs []string
+		expected   []string
+	}{
+		{"no mirrors", primaryLocation, nil, []string{primaryLocation}},
+		{"mirrors in order", primaryLocation, mirrorURLs, []string{primaryLocation, firstMirror, secondMirror}},
+		{"empty mirror skipped", primaryLocation, []string{"", "https://mirror-2.example.com"}, []string{primaryLocation, secondMirror}},
+		{"query left out on mirrors", primaryLocation + "?versionId=3", []string{"https://mirror-2.example.com"}, []string{primaryLocation + "?versionId=3", secondMirror}},
+		{"escaped path kept", "https://s3.amazonaws.com/packages/my%20agent.zip", []string{"https://mirror-2.example.com"},
+			[]string{"https://s3.amazonaws.com/packages/my%20agent.zip", "https://mirror-2.example.com/packages/my%20agent.zip"}},
+		{"relative location", "agent.zip", mirrorURLs, []string{"agent.zip"}},
+	}
+	for _, testdata := range data {
+		t.Run(testdata.name, func(t *testing.T) {
+			assert.Equal(t, testdata.expected, downloadSources(log.NewMockLog(), testdata.location, testdata.mirrorURLs))
+		})
+	}
+}
+
+func TestDownloadFile_Mirrors(t *testing.T) {
+	tracer := trace.NewTracer(log.NewMockLog())
+	tracer.BeginSection("test segment root")
+
+	data := []struct {
+		name            string
+		downloadErrors  map[string]error
+		downloadContent map[string]string
+		expectedSources []string
+		expectedErr     []string
+	}{
+		{
+			"primary location succeeds",
+			nil,
+			map[string]string{primaryLocation: "content"},
+			[]string{primaryLocation},
+			nil,
+		},
+		{
+			"primary location not found, first mirror succeeds",
+			map[string]error{primaryLocation: errors.New("404 Not Found")},
+			map[string]string{firstMirror: "content"},
+			[]string{primaryLocation, firstMirror},
+			nil,
+		},
+		{
+			"primary location and first mirror fail, second mirror succeeds",
+			map[string]error{primaryLocation: errors.New("connection refused"), firstMirror: errors.New("404 Not Found")},
+			map[string]string{secondMirror: "content"},
+			[]string{primaryLocation, firstMirror, secondMirror},
+			nil,
+		},
+		{
+			"tampered file on the first mirror, second mirror succeeds",
+			map[string]error{primaryLocation: errors.New("connection refused")},
+			map[string]string{firstMirror: "tampered", secondMirror: "content"},
+			[]string{primaryLocation, firstMirror, secondMirror},
+			nil,
+		},
+		{
+			"all sources fail",
+			map[string]error{primaryLocation: errors.New("connection refused"), firstMirror: errors.New("404 Not Found"), secondMirror: errors.New("503 Slow Down")},
+			nil,
+			[]string{primaryLocation, firstMirror, secondMirror},
+			[]string{
+				"failed to download installation package reliably, " + primaryLocation + ", connection refused",
+				"failed to download installation package reliably, " + firstMirror + ", 404 Not Found",
+				"failed to download installation package reliably, " + secondMirror + ", 503 Slow Down",
+			},
+		},
+	}
+	for _, testdata := range data {
+		t.Run(testdata.name, func(t *testing.T) {
+			fileSys := newFileSysMock()
+			filesysdep = fileSys
+			network := &networkMock{
+				downloadOutput:  artifact.DownloadOutput{LocalFilePath: "agent.zip"},
+				downloadContent: testdata.downloadContent,
+				downloadErrors:  testdata.downloadErrors,
+			}
+			networkdep = network
+			file := &File{DownloadLocation: primaryLocation, Checksums: map[string]string{"sha256": contentSha256}}
+
+			result, err := downloadFile(tracer, file, mirrorURLs)
+
+			var sources []string
+			for _, input := range network.downloadInputs {
+				sources = append(sources, input.SourceURL)
+				assert.Equal(t, file.Checksums, input.SourceChecksums)
+			}
+			assert.Equal(t, testdata.expectedSources, sources)
+			if testdata.expectedErr == nil {
+				assert.NoError(t, err)
+				assert.Equal(t, "agent.zip", result)
+				content, err := fileSys.ReadFile("agent.zip")
+				assert.NoError(t, err)
+				assert.Equal(t, "content", string(content))
+			} else {
+				assert.Error(t, err)
+				for _, expected := range testdata.expectedErr {
+					assert.Contains(t, err.Error(), expected)
+				}
+				assert.Empty(t, result)
+			}
+		})
+	}
+}
@@ -15,6 +15,9 @@
 package birdwatcher
 
 import (
+	"errors"
+	"sync"
+
 	"github.com/aws/amazon-ssm-agent/agent/fileutil/artifact"
 	"github.com/aws/amazon-ssm-agent/agent/log"
 	"github.com/aws/aws-sdk-go/aws/request"
@@ -26,6 +29,11 @@ type facadeMock struct {
 	getManifestInput  *ssm.GetManifestInput
 	getManifestOutput *ssm.GetManifestOutput
 	getManifestError  error
+	// manifestsByName, when set, returns the manifest of the requested package
+	manifestsByName map[string]string
+	// getManifestSequence, when set, is returned by successive calls, the last response repeating
+	getManifestSequence []getManifestResponse
+	getManifestCalls    int
 
 	putConfigurePackageResultInput  *ssm.PutConfigurePackageResultInput
 	putConfigurePackageResultOutput *ssm.PutConfigurePackageResultOutput
@@ -36,8 +44,32 @@ func (*facadeMock) GetManifestRequest(*ssm.GetManifestInput) (*request.Request,
 	panic("not implemented")
 }
 
+// getManifestResponse is one response of facadeMock.GetManifest
+type getManifestResponse struct {
+	manifest string
+	err      error
+}
+
 func (m *facadeMock) GetManifest(input *ssm.GetManifestInput) (*ssm.GetManifestOutput, error) {
 	m.getManifestInput = input
+	m.getManifestCalls++
+	if len(m.getManifestSequence) > 0 {
+		response := m.getManifestSequence[len(m.getManifestSequence)-1]
+		if m.getManifestCalls <= len(m.getManifestSequence) {
+			response = m.getManifestSequence[m.getManifestCalls-1]
+		}
+		if response.err != nil {
+			return nil, response.err
+		}
+		return &ssm.GetManifestOutput{Manifest: &response.manifest}, nil
+	}
+	if m.manifestsByName != nil {
+		manifest, ok := m.manifestsByName[*input.PackageName]
+		if !ok {
+			return nil, errors.New("manifest not found")
+		}
+		return &ssm.GetManifestOutput{Manifest: &manifest}, nil
+	}
 	return m.getManifestOutput, m.getManifestError
 }
 
@@ -55,9 +87,79 @@ type networkMock struct {
 	downloadInput  artifact.DownloadInput
 	downloadOutput artifact.DownloadOutput
 	downloadError  error
+	// downloadContent, by source URL, is written to the local file of a successful download
+	downloadContent map[string]string
+	// downloadErrors, by source URL, fail the downloads from it
+	downloadErrors map[string]error
+	// downloadInputs are the inputs of all the downloads, in order, downloadInput is the last of them
+	downloadInputs []artifact.DownloadInput
 }
 
 func (p *networkMock) Download(log log.T, input artifact.DownloadInput) (artifact.DownloadOutput, error) {
 	p.downloadInput = input
+	p.downloadInputs = append(p.downloadInputs, input)
+	if err, ok := p.downloadErrors[input.SourceURL]; ok {
+		return artifact.DownloadOutput{}, err
+	}
+	if content, ok := p.downloadContent[input.SourceURL]; ok && p.downloadError == nil && p.downloadOutput.LocalFilePath != "" {
+		filesysdep.WriteFile(p.downloadOutput.LocalFilePath, content)
+	}
 	return p.downloadOutput, p.downloadError
 }
+
+// fileSysMock is an in-memory file system, files may be read concurrently
+type fileSysMock struct {
+	files map[string][]byte
+	lock  sync.RWMutex
+}
+
+func newFileSysMock() *fileSysMock {
+	return &fileSysMock{files: map[string][]byte{}}
+}
+
+func (f *fileSysMock) MakeDirs(destinationDir string) error {
+	return nil
+}
+
+func (f *fileSysMock) Exists(filePath string) bool {
+	_, ok := f.files[filePath]
+	return ok
+}
+
+func (f *fileSysMock) ReadFile(filename string) ([]byte, error) {
+	f.lock.RLock()
+	defer f.lock.RUnlock()
+	if data, ok := f.files[filename]; ok {
+		return data, nil
+	}
+	return nil, errors.New("file not found")
+}
+
+func (f *fileSysMock) WriteFile(filename string, content string) error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.files[filename] = []byte(content)
+	return nil
+}
+
+func (f *fileSysMock) Rename(oldPath string, newPath string) error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	data, ok := f.files[oldPath]
+	if !ok {
+		return errors.New("file not found")
+	}
+	delete(f.files, oldPath)
+	f.files[newPath] = data
+	return nil
+}
+
+func (f *fileSysMock) Remove(filePath string) error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if _, ok := f.files[filePath]; !ok {
+		return errors.New("file not found")
+	}
+	delete(f.files, filePath)
+	return nil
+}
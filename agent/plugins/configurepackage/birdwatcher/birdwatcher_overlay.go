@@ -0,0 +1,86 @@
+// Copyright 2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+package birdwatcher
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	"github.com/aws/amazon-ssm-agent/agent/plugins/configurepackage/trace"
+	"github.com/aws/aws-sdk-go/service/ssm"
+)
+
+// manifestIdentityKeys identify the package a manifest belongs to and are never taken from an overlay
+var manifestIdentityKeys = map[string]bool{
+	"packageArn": true,
+	"version":    true,
+}
+
+// mergeManifestOverlays downloads the overlay manifests and deep-merges them in order over the base manifest.
+// Objects are merged key by key, any other value of a later manifest replaces the earlier one.
+func mergeManifestOverlays(tracer trace.Tracer, ds *PackageService, base []byte, overlays []string, version string) ([]byte, error) {
+	var merged map[string]interface{}
+	if err := json.Unmarshal(base, &merged); err != nil {
+		return nil, fmt.Errorf("failed to decode manifest: %v", err)
+	}
+
+	for _, overlayName := range overlays {
+		overlayName := overlayName
+		resp, err := ds.facadeClient.GetManifest(
+			&ssm.GetManifestInput{
+				PackageName:    &overlayName,
+				PackageVersion: &version,
+			},
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to retrieve manifest overlay %v: %v", overlayName, err)
+		}
+
+		var overlay map[string]interface{}
+		if err := json.Unmarshal([]byte(*resp.Manifest), &overlay); err != nil {
+			return nil, fmt.Errorf("failed to decode manifest overlay %v: %v", overlayName, err)
+		}
+		for key := range manifestIdentityKeys {
+			delete(overlay, key)
+		}
+
+		tracer.CurrentTrace().AppendDebugf("merging manifest overlay %v", overlayName)
+		mergeManifestObjects(tracer, overlayName, "", merged, overlay)
+	}
+
+	return json.Marshal(merged)
+}
+
+// mergeManifestObjects merges overlay into base, logging the values the overlay replaces
+func mergeManifestObjects(tracer trace.Tracer, overlayName string, path string, base map[string]interface{}, overlay map[string]interface{}) {
+	for key, value := range overlay {
+		keyPath := key
+		if path != "" {
+			keyPath = path + "." + key
+		}
+
+		baseObject, baseIsObject := base[key].(map[string]interface{})
+		overlayObject, overlayIsObject := value.(map[string]interface{})
+		if baseIsObject && overlayIsObject {
+			mergeManifestObjects(tracer, overlayName, keyPath, baseObject, overlayObject)
+			continue
+		}
+
+		if existing, ok := base[key]; ok && !reflect.DeepEqual(existing, value) {
+			tracer.CurrentTrace().AppendInfof("manifest overlay %v overrides %v", overlayName, keyPath)
+		}
+		base[key] = value
+	}
+}
@@ -0,0 +1,145 @@
+// Copyright 2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+package birdwatcher
+
+import (
+	"testing"
+
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/configurepackage/packageservice"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/configurepackage/trace"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDownloadManifestWithOverlays(t *testing.T) {
+	base := `{
+		"schemaVersion": "2.0",
+		"packageArn": "packagearn",
+		"version": "1.0.0",
+		"packages": {
+			"windows": {"_any": {"x86_64": {"file": "windows.zip"}}},
+			"linux": {"_any": {"x86_64": {"file": "linux.zip"}}}
+		},
+		"files": {
+			"windows.zip": {"downloadLocation": "https://example.com/windows.zip", "checksums": {"sha256": "windows"}},
+			"linux.zip": {"downloadLocation": "https://example.com/linux.zip", "checksums": {"sha256": "linux"}}
+		}
+	}`
+	data := []struct {
+		name             string
+		overlays         map[string]string
+		overlayOrder     []string
+		expectedPackages map[string]map[string]map[string]*PackageInfo
+		expectedFiles    map[string]*File
+	}{
+		{
+			"no overlays",
+			nil,
+			nil,
+			map[string]map[string]map[string]*PackageInfo{
+				"windows": {"_any": {"x86_64": {File: "windows.zip"}}},
+				"linux":   {"_any": {"x86_64": {File: "linux.zip"}}},
+			},
+			map[string]*File{
+				"windows.zip": {DownloadLocation: "https://example.com/windows.zip", Checksums: map[string]string{"sha256": "windows"}},
+				"linux.zip":   {DownloadLocation: "https://example.com/linux.zip", Checksums: map[string]string{"sha256": "linux"}},
+			},
+		},
+		{
+			"file union and scalar override",
+			map[string]string{
+				"overlay": `{
+					"packageArn": "overlayarn",
+					"packages": {"linux": {"_any": {"arm64": {"file": "linux-arm.zip"}}}},
+					"files": {
+						"linux-arm.zip": {"downloadLocation": "https://example.com/linux-arm.zip", "checksums": {"sha256": "linuxarm"}},
+						"linux.zip": {"downloadLocation": "https://mirror.example.com/linux.zip"}
+					}
+				}`,
+			},
+			[]string{"overlay"},
+			map[string]map[string]map[string]*PackageInfo{
+				"windows": {"_any": {"x86_64": {File: "windows.zip"}}},
+				"linux":   {"_any": {"x86_64": {File: "linux.zip"}, "arm64": {File: "linux-arm.zip"}}},
+			},
+			map[string]*File{
+				"windows.zip":   {DownloadLocation: "https://example.com/windows.zip", Checksums: map[string]string{"sha256": "windows"}},
+				"linux.zip":     {DownloadLocation: "https://mirror.example.com/linux.zip", Checksums: map[string]string{"sha256": "linux"}},
+				"linux-arm.zip": {DownloadLocation: "https://example.com/linux-arm.zip", Checksums: map[string]string{"sha256": "linuxarm"}},
+			},
+		},
+		{
+			"later overlays win",
+			map[string]string{
+				"first":  `{"files": {"linux.zip": {"checksums": {"sha256": "first"}}}}`,
+				"second": `{"files": {"linux.zip": {"checksums": {"sha256": "second"}}}}`,
+			},
+			[]string{"first", "second"},
+			map[string]map[string]map[string]*PackageInfo{
+				"windows": {"_any": {"x86_64": {File: "windows.zip"}}},
+				"linux":   {"_any": {"x86_64": {File: "linux.zip"}}},
+			},
+			map[string]*File{
+				"windows.zip": {DownloadLocation: "https://example.com/windows.zip", Checksums: map[string]string{"sha256": "windows"}},
+				"linux.zip":   {DownloadLocation: "https://example.com/linux.zip", Checksums: map[string]string{"sha256": "second"}},
+			},
+		},
+	}
+
+	for _, testdata := range data {
+		t.Run(testdata.name, func(t *testing.T) {
+			tracer := trace.NewTracer(log.NewMockLog())
+			tracer.BeginSection("test segment root")
+
+			manifests := map[string]string{"packagearn": base}
+			for name, overlay := range testdata.overlays {
+				manifests[name] = overlay
+			}
+			ds := &PackageService{
+				facadeClient:     &facadeMock{manifestsByName: manifests},
+				manifestCache:    packageservice.ManifestCacheMemNew(),
+				manifestOverlays: map[string][]string{"packagearn": testdata.overlayOrder},
+			}
+
+			manifest, _, err := downloadManifest(tracer, ds, "packagearn", "1.0.0")
+
+			assert.NoError(t, err)
+			assert.Equal(t, "packagearn", manifest.PackageArn)
+			assert.Equal(t, "1.0.0", manifest.Version)
+			assert.Equal(t, testdata.expectedPackages, manifest.Packages)
+			assert.Equal(t, testdata.expectedFiles, manifest.Files)
+
+			// the merged manifest is what gets cached for the install
+			cached, err := readManifestFromCache(ds.manifestCache, "packagearn", "1.0.0")
+			assert.NoError(t, err)
+			assert.Equal(t, manifest, cached)
+		})
+	}
+}
+
+func TestDownloadManifestWithMissingOverlay(t *testing.T) {
+	tracer := trace.NewTracer(log.NewMockLog())
+	tracer.BeginSection("test segment root")
+
+	ds := &PackageService{
+		facadeClient:     &facadeMock{manifestsByName: map[string]string{"packagearn": `{"packageArn": "packagearn", "version": "1.0.0"}`}},
+		manifestCache:    packageservice.ManifestCacheMemNew(),
+		manifestOverlays: map[string][]string{"packagearn": {"missing"}},
+	}
+
+	_, _, err := downloadManifest(tracer, ds, "packagearn", "1.0.0")
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "missing")
+}
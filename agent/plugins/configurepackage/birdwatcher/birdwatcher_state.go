@@ -0,0 +1,125 @@
+// Copyright 2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package birdwatcher
+
+import (
+	"crypto/sha1"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/fileutil/artifact"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/configurepackage/trace"
+)
+
+const (
+	stateDirName        = "birdwatcher"
+	stateFileExtension  = ".state"
+	stateTempFileSuffix = ".tmp"
+)
+
+// stateFilePath returns the location of the download state record of a package, which holds the last version recorded
+func stateFilePath(packageName string) string {
+	// package names are arns, hash them so the record name is always a valid file name
+	nameHash := sha1.Sum([]byte(packageName))
+	return filepath.Join(appconfig.DownloadRoot, stateDirName, fmt.Sprintf("%x%s", nameHash, stateFileExtension))
+}
+
+// readPackageState reads the download state record of a package
+func readPackageState(packageName string) (*DownloadState, error) {
+	statePath := stateFilePath(packageName)
+	if !filesysdep.Exists(statePath) {
+		return nil, fmt.Errorf("no download state recorded for %v", packageName)
+	}
+
+	data, err := filesysdep.ReadFile(statePath)
+	if err != nil {
+		return nil, err
+	}
+
+	var state DownloadState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("download state for %v is invalid: %v", packageName, err)
+	}
+	return &state, nil
+}
+
+// readDownloadState reads the download state record of a package version
+func readDownloadState(packageName string, version string) (*DownloadState, error) {
+	state, err := readPackageState(packageName)
+	if err != nil {
+		return nil, err
+	}
+	if state.Version != version {
+		return nil, fmt.Errorf("no download state recorded for %v %v, %v is recorded", packageName, version, state.Version)
+	}
+	return state, nil
+}
+
+// writeDownloadState writes the record to a temporary file and renames it in place so a partial record is never read
+func writeDownloadState(state *DownloadState) error {
+	statePath := stateFilePath(state.PackageName)
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+
+	if err := filesysdep.MakeDirs(filepath.Dir(statePath)); err != nil {
+		return fmt.Errorf("failed to create directory for download state: %v", err)
+	}
+	tempPath := statePath + stateTempFileSuffix
+	if err := filesysdep.WriteFile(tempPath, string(data)); err != nil {
+		return fmt.Errorf("failed to write download state: %v", err)
+	}
+	return filesysdep.Rename(tempPath, statePath)
+}
+
+// fileChecksum computes the checksum of a local file with the given algorithm
+func fileChecksum(path string, algorithm string) (string, error) {
+	data, err := filesysdep.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return artifact.Checksum(algorithm, data)
+}
+
+// findDownloadedFile returns the previously downloaded artifact of a package version if it still matches the manifest
+func findDownloadedFile(tracer trace.Tracer, packageName string, version string, file *File) (string, bool) {
+	algorithm, expected := artifact.PreferredChecksum(file.Checksums)
+	if expected == "" {
+		// without a checksum the local copy can't be validated
+		return "", false
+	}
+
+	state, err := readDownloadState(packageName, version)
+	if err != nil {
+		tracer.CurrentTrace().AppendDebugf("no previous download found: %v", err)
+		return "", false
+	}
+
+	if !strings.EqualFold(state.Checksum, expected) || !filesysdep.Exists(state.LocalFilePath) {
+		return "", false
+	}
+
+	check := fileChecksumCheck{Name: filepath.Base(state.LocalFilePath), Path: state.LocalFilePath, Algorithm: algorithm, Expected: expected}
+	if err := verifyFileChecksum(check); err != nil {
+		tracer.CurrentTrace().AppendDebugf("previous download of %v %v does not match the manifest: %v", packageName, version, err)
+		return "", false
+	}
+
+	return state.LocalFilePath, true
+}
@@ -0,0 +1,116 @@
+// Copyright 2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package birdwatcher
+
+import (
+	"testing"
+
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/configurepackage/trace"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteAndReadDownloadState(t *testing.T) {
+	fileSys := newFileSysMock()
+	filesysdep = fileSys
+
+	state := &DownloadState{
+		PackageName:   "arn:aws:ssm:::package/name",
+		Version:       "1.0.0",
+		LocalFilePath: "local/file.zip",
+		Checksum:      "abc",
+	}
+	err := writeDownloadState(state)
+	assert.NoError(t, err)
+
+	// only the final record is left behind
+	statePath := stateFilePath(state.PackageName)
+	assert.True(t, fileSys.Exists(statePath))
+	assert.False(t, fileSys.Exists(statePath+stateTempFileSuffix))
+
+	result, err := readDownloadState(state.PackageName, state.Version)
+	assert.NoError(t, err)
+	assert.Equal(t, state, result)
+}
+
+func TestReadDownloadStateMissing(t *testing.T) {
+	filesysdep = newFileSysMock()
+
+	_, err := readDownloadState("name", "1.0.0")
+	assert.Error(t, err)
+}
+
+func TestReadDownloadStateInvalid(t *testing.T) {
+	fileSys := newFileSysMock()
+	fileSys.files[stateFilePath("name")] = []byte("not json")
+	filesysdep = fileSys
+
+	_, err := readDownloadState("name", "1.0.0")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid")
+}
+
+func TestRecordInstallWithoutDownload(t *testing.T) {
+	filesysdep = newFileSysMock()
+	tracer := trace.NewTracer(log.NewMockLog())
+	tracer.BeginSection("test segment root")
+	ds := &PackageService{}
+
+	err := ds.RecordInstall(tracer, "name", "1.0.0")
+
+	assert.NoError(t, err)
+	_, err = readDownloadState("name", "1.0.0")
+	assert.Error(t, err)
+}
+
+func TestRecordInstallDeletesPreviousArtifact(t *testing.T) {
+	fileSys := newFileSysMock()
+	fileSys.files["local/1.0.0.zip"] = []byte("1.0.0")
+	fileSys.files["local/2.0.0.zip"] = []byte("2.0.0")
+	filesysdep = fileSys
+	tracer := trace.NewTracer(log.NewMockLog())
+	tracer.BeginSection("test segment root")
+	assert.NoError(t, writeDownloadState(&DownloadState{PackageName: "name", Version: "1.0.0", LocalFilePath: "local/1.0.0.zip", Checksum: "abc"}))
+	ds := &PackageService{downloads: map[string]*DownloadState{
+		"2.0.0": {PackageName: "name", Version: "2.0.0", LocalFilePath: "local/2.0.0.zip", Checksum: "def"},
+	}}
+
+	err := ds.RecordInstall(tracer, "name", "2.0.0")
+
+	assert.NoError(t, err)
+	assert.False(t, fileSys.Exists("local/1.0.0.zip"))
+	assert.True(t, fileSys.Exists("local/2.0.0.zip"))
+	_, err = readDownloadState("name", "1.0.0")
+	assert.Error(t, err)
+	state, err := readDownloadState("name", "2.0.0")
+	assert.NoError(t, err)
+	assert.Equal(t, "local/2.0.0.zip", state.LocalFilePath)
+	assert.Empty(t, ds.UnrecordedArtifacts())
+}
+
+func TestUnrecordedArtifacts(t *testing.T) {
+	filesysdep = newFileSysMock()
+	tracer := trace.NewTracer(log.NewMockLog())
+	tracer.BeginSection("test segment root")
+	ds := &PackageService{downloads: map[string]*DownloadState{
+		"1.0.0": {PackageName: "name", Version: "1.0.0", LocalFilePath: "local/1.0.0.zip", Checksum: "abc"},
+		"2.0.0": {PackageName: "name", Version: "2.0.0", LocalFilePath: "local/2.0.0.zip", Checksum: "def"},
+		"3.0.0": {PackageName: "name", Version: "3.0.0", LocalFilePath: "local/3.0.0.zip", Checksum: "ghi"},
+	}}
+
+	assert.NoError(t, ds.RecordInstall(tracer, "name", "2.0.0"))
+
+	assert.Equal(t, []string{"local/1.0.0.zip", "local/3.0.0.zip"}, ds.UnrecordedArtifacts())
+}
@@ -18,6 +18,7 @@ import (
 	"errors"
 	"testing"
 
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
 	"github.com/aws/amazon-ssm-agent/agent/fileutil/artifact"
 	"github.com/aws/amazon-ssm-agent/agent/log"
 	"github.com/aws/amazon-ssm-agent/agent/plugins/configurepackage/envdetect"
@@ -222,6 +223,163 @@ func TestExtractPackageInfo(t *testing.T) {
 	}
 }
 
+func TestResolvePackage(t *testing.T) {
+	tracer := trace.NewTracer(log.NewMockLog())
+	tracer.BeginSection("test segment root")
+
+	data := []struct {
+		name        string
+		manifest    *Manifest
+		expected    *Resolution
+		expectedErr bool
+	}{
+		{
+			"concrete entries preferred over `_any`",
+			&Manifest{
+				PackageArn: "packagearn",
+				Version:    "1.0.0",
+				Packages: manifestPackageGen(&[]pkgselector{
+					{platformName, "_any", architecture, &PackageInfo{File: "wrongfilename"}},
+					{platformName, platformVersion, "_any", &PackageInfo{File: "wrongfilename"}},
+					{platformName, platformVersion, architecture, &PackageInfo{File: "filename"}},
+				}),
+			},
+			&Resolution{
+				PackageArn:                 "packagearn",
+				SelectedVersion:            "1.0.0",
+				Platform:                   platformName,
+				PlatformVersion:            platformVersion,
+				Architecture:               architecture,
+				ConsideredPlatformVersions: []string{"_any", platformVersion},
+				MatchedPlatform:            platformName,
+				MatchedPlatformVersion:     platformVersion,
+				MatchedArchitecture:        architecture,
+				File:                       "filename",
+			},
+			false,
+		},
+		{
+			"`_any` fallback entries",
+			&Manifest{
+				PackageArn: "packagearn",
+				Version:    "1.0.0",
+				Packages: manifestPackageGen(&[]pkgselector{
+					{"_any", "_any", "_any", &PackageInfo{File: "filename"}},
+				}),
+			},
+			&Resolution{
+				PackageArn:                 "packagearn",
+				SelectedVersion:            "1.0.0",
+				Platform:                   platformName,
+				PlatformVersion:            platformVersion,
+				Architecture:               architecture,
+				ConsideredPlatformVersions: []string{"_any"},
+				MatchedPlatform:            "_any",
+				MatchedPlatformVersion:     "_any",
+				MatchedArchitecture:        "_any",
+				File:                       "filename",
+			},
+			false,
+		},
+		{
+			"non-matching arch reports how far the resolution got",
+			&Manifest{
+				PackageArn: "packagearn",
+				Version:    "1.0.0",
+				Packages: manifestPackageGen(&[]pkgselector{
+					{platformName, platformVersion, "nonexistarch", &PackageInfo{File: "filename"}},
+				}),
+			},
+			&Resolution{
+				PackageArn:                 "packagearn",
+				SelectedVersion:            "1.0.0",
+				Platform:                   platformName,
+				PlatformVersion:            platformVersion,
+				Architecture:               architecture,
+				ConsideredPlatformVersions: []string{platformVersion},
+				MatchedPlatform:            platformName,
+				MatchedPlatformVersion:     platformVersion,
+			},
+			true,
+		},
+	}
+
+	for _, testdata := range data {
+		t.Run(testdata.name, func(t *testing.T) {
+			mockedCollector := envdetect.CollectorMock{}
+
+			mockedCollector.On("CollectData", mock.Anything).Return(&envdetect.Environment{
+				&osdetect.OperatingSystem{platformName, platformVersion, "", architecture, "", ""},
+				nil,
+			}, nil).Once()
+
+			testdata.manifest.Files = map[string]*File{"filename": {}}
+			ds := &PackageService{manifestCache: packageservice.ManifestCacheMemNew(), collector: &mockedCollector}
+
+			_, result, err := ds.findFileFromManifest(tracer, testdata.manifest)
+			if testdata.expectedErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.NotNil(t, result)
+			result.packageInfo = nil
+			assert.Equal(t, testdata.expected, result)
+		})
+	}
+}
+
+func TestResolvePackagePlatformSelectionPolicy(t *testing.T) {
+	tracer := trace.NewTracer(log.NewMockLog())
+	tracer.BeginSection("test segment root")
+
+	manifest := &Manifest{
+		PackageArn: "packagearn",
+		Version:    "1.0.0",
+		Packages: manifestPackageGen(&[]pkgselector{
+			{"amazon", "_any", architecture, &PackageInfo{File: "exactfile"}},
+			{"rhel", "_any", architecture, &PackageInfo{File: "familyfile"}},
+		}),
+	}
+
+	data := []struct {
+		name            string
+		policy          string
+		family          string
+		expectedFile    string
+		expectedMatched string
+		expectedErr     bool
+	}{
+		{"default prefers exact", "", "rhel", "exactfile", "amazon", false},
+		{"exact", PlatformSelectionExact, "rhel", "exactfile", "amazon", false},
+		{"family", PlatformSelectionFamily, "rhel", "familyfile", "rhel", false},
+		{"exact-then-family", PlatformSelectionExactThenFamily, "rhel", "exactfile", "amazon", false},
+		{"family without family entries", PlatformSelectionFamily, "debian", "", "", true},
+		{"unknown policy", "closest", "rhel", "", "", true},
+	}
+
+	for _, testdata := range data {
+		t.Run(testdata.name, func(t *testing.T) {
+			mockedCollector := envdetect.CollectorMock{}
+			mockedCollector.On("CollectData", mock.Anything).Return(&envdetect.Environment{
+				&osdetect.OperatingSystem{"amazon", platformVersion, testdata.family, architecture, "", ""},
+				nil,
+			}, nil).Once()
+			ds := &PackageService{collector: &mockedCollector, platformSelectionPolicy: testdata.policy}
+
+			result, err := ds.resolvePackage(tracer, manifest)
+			if testdata.expectedErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, testdata.expectedMatched, result.MatchedPlatform)
+			assert.Equal(t, testdata.expectedFile, result.File)
+			assert.Equal(t, testdata.family, result.PlatformFamily)
+		})
+	}
+}
+
 func TestReportResult(t *testing.T) {
 	now := 420000
 	timemock := &TimeMock{}
@@ -528,7 +686,7 @@ func TestFindFileFromManifest(t *testing.T) {
 			}
 			ds := &PackageService{facadeClient: &facadeClientMock, manifestCache: packageservice.ManifestCacheMemNew(), collector: &mockedCollector}
 
-			result, err := ds.findFileFromManifest(tracer, testdata.manifest)
+			result, _, err := ds.findFileFromManifest(tracer, testdata.manifest)
 
 			if testdata.expectedErr {
 				assert.Error(t, err)
@@ -556,11 +714,12 @@ func TestDownloadFile(t *testing.T) {
 				downloadOutput: artifact.DownloadOutput{
 					LocalFilePath: "agent.zip",
 				},
+				downloadContent: map[string]string{"https://example.com/agent": "content"},
 			},
 			&File{
 				DownloadLocation: "https://example.com/agent",
 				Checksums: map[string]string{
-					"sha256": "asdf",
+					"sha256": contentSha256,
 				},
 			},
 			false,
@@ -597,8 +756,9 @@ func TestDownloadFile(t *testing.T) {
 	for _, testdata := range data {
 		t.Run(testdata.name, func(t *testing.T) {
 			networkdep = &testdata.network
+			filesysdep = newFileSysMock()
 
-			result, err := downloadFile(tracer, testdata.file)
+			result, err := downloadFile(tracer, testdata.file, nil)
 			if testdata.expectedErr {
 				assert.Error(t, err)
 			} else {
@@ -607,7 +767,7 @@ func TestDownloadFile(t *testing.T) {
 				// verify download input
 				input := artifact.DownloadInput{
 					SourceURL:       testdata.file.DownloadLocation,
-					SourceChecksums: map[string]string{"sha256": "asdf"},
+					SourceChecksums: map[string]string{"sha256": contentSha256},
 				}
 				assert.Equal(t, input, testdata.network.downloadInput)
 			}
@@ -678,6 +838,7 @@ func TestDownloadArtifact(t *testing.T) {
 
 			ds := &PackageService{manifestCache: cache, collector: &mockedCollector}
 			networkdep = &testdata.network
+			filesysdep = newFileSysMock()
 
 			result, err := ds.DownloadArtifact(tracer, testdata.packageName, testdata.packageVersion)
 
@@ -690,3 +851,252 @@ func TestDownloadArtifact(t *testing.T) {
 		})
 	}
 }
+
+func TestDownloadArtifactTracesResolution(t *testing.T) {
+	manifestStr := `
+	{
+		"packageArn": "packageArn",
+		"version": "1234",
+		"packages": {
+			"platformName": {
+				"platformVersion": {
+					"architecture": {
+						"file": "test.zip"
+					}
+				}
+			}
+		},
+		"files": {
+			"test.zip": {
+				"downloadLocation": "https://example.com/agent"
+			}
+		}
+	}
+	`
+	tracer := trace.NewTracer(log.NewMockLog())
+	tracer.BeginSection("test segment root")
+	mockedCollector := envdetect.CollectorMock{}
+	mockedCollector.On("CollectData", mock.Anything).Return(&envdetect.Environment{
+		&osdetect.OperatingSystem{"platformName", "platformVersion", "", "architecture", "", ""},
+		&ec2infradetect.Ec2Infrastructure{"instanceID", "region", "", "availabilityZone", "instanceType"},
+	}, nil).Once()
+	facadeClient := facadeMock{getManifestOutput: &ssm.GetManifestOutput{Manifest: &manifestStr}}
+	networkdep = &networkMock{downloadOutput: artifact.DownloadOutput{LocalFilePath: "agent.zip"}}
+	filesysdep = newFileSysMock()
+
+	ds := &PackageService{facadeClient: &facadeClient, manifestCache: packageservice.ManifestCacheMemNew(), collector: &mockedCollector}
+	packageArn, version, _, err := ds.DownloadManifest(tracer, "packageName", "latest")
+	assert.NoError(t, err)
+	_, err = ds.DownloadArtifact(tracer, packageArn, version)
+
+	assert.NoError(t, err)
+	output := tracer.ToPluginOutput().GetStdout()
+	assert.Contains(t, output, `"requestedVersion":"latest","selectedVersion":"1234"`)
+	assert.Contains(t, output, `"matchedPlatform":"platformName","matchedPlatformVersion":"platformVersion","matchedArchitecture":"architecture","file":"test.zip"`)
+}
+
+func TestDownloadArtifactAlreadyDownloaded(t *testing.T) {
+	// the manifest checksum is the sha256 of "content"
+	manifestStr := `
+	{
+		"packages": {
+			"platformName": {
+				"platformVersion": {
+					"architecture": {
+						"file": "test.zip"
+					}
+				}
+			}
+		},
+		"files": {
+			"test.zip": {
+				"downloadLocation": "https://example.com/agent",
+				"checksums": {
+					"sha256": "ED7002B439E9AC845F22357D822BAC1444730FBDB6016D3EC9432297B9EC9F73"
+				}
+			}
+		}
+	}
+	`
+	tracer := trace.NewTracer(log.NewMockLog())
+	tracer.BeginSection("test segment root")
+
+	data := []struct {
+		name             string
+		localContent     string
+		forceDownload    bool
+		expectedDownload bool
+	}{
+		{
+			"valid local copy",
+			"content",
+			false,
+			false,
+		},
+		{
+			"valid local copy with force download",
+			"content",
+			true,
+			true,
+		},
+		{
+			"modified local copy",
+			"modified content",
+			false,
+			true,
+		},
+	}
+
+	for _, testdata := range data {
+		t.Run(testdata.name, func(t *testing.T) {
+			cache := packageservice.ManifestCacheMemNew()
+			cache.WriteManifest("packageName", "1234", []byte(manifestStr))
+
+			mockedCollector := envdetect.CollectorMock{}
+			mockedCollector.On("CollectData", mock.Anything).Return(&envdetect.Environment{
+				&osdetect.OperatingSystem{"platformName", "platformVersion", "", "architecture", "", ""},
+				&ec2infradetect.Ec2Infrastructure{"instanceID", "region", "", "availabilityZone", "instanceType"},
+			}, nil).Once()
+
+			fileSys := newFileSysMock()
+			fileSys.files["local/agent.zip"] = []byte(testdata.localContent)
+			filesysdep = fileSys
+			err := writeDownloadState(&DownloadState{
+				PackageName:   "packageName",
+				Version:       "1234",
+				LocalFilePath: "local/agent.zip",
+				Checksum:      "ed7002b439e9ac845f22357d822bac1444730fbdb6016d3ec9432297b9ec9f73",
+			})
+			assert.NoError(t, err)
+
+			network := networkMock{
+				downloadOutput: artifact.DownloadOutput{
+					LocalFilePath: "agent.zip",
+				},
+				downloadContent: map[string]string{"https://example.com/agent": "content"},
+			}
+			networkdep = &network
+
+			ds := &PackageService{manifestCache: cache, collector: &mockedCollector, forceDownload: testdata.forceDownload}
+			result, err := ds.DownloadArtifact(tracer, "packageName", "1234")
+
+			assert.NoError(t, err)
+			if testdata.expectedDownload {
+				assert.Equal(t, "agent.zip", result)
+				assert.Equal(t, "https://example.com/agent", network.downloadInput.SourceURL)
+				// the download is only recorded once its install succeeds
+				state, err := readDownloadState("packageName", "1234")
+				assert.NoError(t, err)
+				assert.Equal(t, "local/agent.zip", state.LocalFilePath)
+
+				assert.NoError(t, ds.RecordInstall(tracer, "packageName", "1234"))
+				state, err = readDownloadState("packageName", "1234")
+				assert.NoError(t, err)
+				assert.Equal(t, "agent.zip", state.LocalFilePath)
+				assert.False(t, fileSys.Exists("local/agent.zip"))
+			} else {
+				assert.Equal(t, "local/agent.zip", result)
+				assert.Empty(t, network.downloadInput.SourceURL)
+			}
+		})
+	}
+}
+
+func TestDownloadArtifactFileOverrides(t *testing.T) {
+	manifestStr := `
+	{
+		"packages": {
+			"platformName": {
+				"platformVersion": {
+					"architecture": {
+						"file": "test.zip"
+					}
+				}
+			}
+		},
+		"files": {
+			"test.zip": {
+				"downloadLocation": "https://example.com/agent",
+				"checksums": {
+					"sha256": "0682c5f2076f099c34cfdd15a9e063849ed437a49677e6fcc5b4198c76575be5"
+				}
+			}
+		}
+	}
+	`
+	tracer := trace.NewTracer(log.NewMockLog())
+	tracer.BeginSection("test segment root")
+
+	data := []struct {
+		name              string
+		overrides         map[string]appconfig.BirdwatcherFileOverride
+		downloadContent   string
+		expectedURL       string
+		expectedChecksums map[string]string
+	}{
+		{
+			"no overrides",
+			nil,
+			"original",
+			"https://example.com/agent",
+			map[string]string{"sha256": originalSha256},
+		},
+		{
+			"override with checksum",
+			map[string]appconfig.BirdwatcherFileOverride{
+				"test.zip": {DownloadLocation: "https://example.com/debug", Checksums: map[string]string{"sha256": debugSha256}},
+			},
+			"debug",
+			"https://example.com/debug",
+			map[string]string{"sha256": debugSha256},
+		},
+		{
+			"override keeps the manifest checksum",
+			map[string]appconfig.BirdwatcherFileOverride{
+				"test.zip": {DownloadLocation: "https://example.com/debug"},
+			},
+			"original",
+			"https://example.com/debug",
+			map[string]string{"sha256": originalSha256},
+		},
+		{
+			"override of a file not in the manifest",
+			map[string]appconfig.BirdwatcherFileOverride{
+				"other.zip": {DownloadLocation: "https://example.com/debug"},
+			},
+			"original",
+			"https://example.com/agent",
+			map[string]string{"sha256": originalSha256},
+		},
+	}
+
+	for _, testdata := range data {
+		t.Run(testdata.name, func(t *testing.T) {
+			cache := packageservice.ManifestCacheMemNew()
+			cache.WriteManifest("packageName", "1234", []byte(manifestStr))
+
+			mockedCollector := envdetect.CollectorMock{}
+			mockedCollector.On("CollectData", mock.Anything).Return(&envdetect.Environment{
+				&osdetect.OperatingSystem{"platformName", "platformVersion", "", "architecture", "", ""},
+				nil,
+			}, nil).Once()
+
+			filesysdep = newFileSysMock()
+			network := networkMock{
+				downloadOutput: artifact.DownloadOutput{
+					LocalFilePath: "agent.zip",
+				},
+				downloadContent: map[string]string{testdata.expectedURL: testdata.downloadContent},
+			}
+			networkdep = &network
+
+			ds := &PackageService{manifestCache: cache, collector: &mockedCollector, fileOverrides: testdata.overrides}
+			result, err := ds.DownloadArtifact(tracer, "packageName", "1234")
+
+			assert.NoError(t, err)
+			assert.Equal(t, "agent.zip", result)
+			assert.Equal(t, testdata.expectedURL, network.downloadInput.SourceURL)
+			assert.Equal(t, testdata.expectedChecksums, network.downloadInput.SourceChecksums)
+		})
+	}
+}
@@ -0,0 +1,213 @@
+// Copyright 2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+package birdwatcher
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/aws/amazon-ssm-agent/agent/fileutil/artifact"
+	"github.com/aws/amazon-ssm-agent/agent/fileutil/filemanager"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/configurepackage/trace"
+)
+
+const (
+	// FileVerified means the local file matches the manifest checksum
+	FileVerified = "Verified"
+	// FileMissing means there is no local file recorded for the package version or it was removed
+	FileMissing = "Missing"
+	// FileModified means the local file doesn't match the manifest checksum
+	FileModified = "Modified"
+	// FileUnverifiable means the manifest has no checksum of a supported algorithm to compare the local file with
+	FileUnverifiable = "Unverifiable"
+)
+
+// Verify checks that the files of a package version on the instance still match its manifest.
+// The artifact recorded for the version is checked against the manifest checksum, then each file it holds is checked
+// against the file it was extracted to in installDirectory. Missing and modified files are listed in the result.
+// Nothing is downloaded other than the manifest, which isn't cached, and nothing on disk is modified.
+func (ds *PackageService) Verify(tracer trace.Tracer, packageName string, version string, installDirectory string) (*VerificationResult, error) {
+	trace := tracer.BeginSection("verify package files")
+	manifest, err := readManifestFromCache(ds.manifestCache, packageName, version)
+	if err != nil {
+		trace.AppendInfof("error when reading the manifest from cache %v", err)
+		if manifest, _, err = fetchParsedManifest(tracer, ds, packageName, version); err != nil {
+			trace.WithError(err).End()
+			return nil, fmt.Errorf("failed to download the manifest: %v", err)
+		}
+	}
+
+	pkginfo, err := ds.extractPackageInfo(tracer, manifest)
+	if err != nil {
+		trace.WithError(err).End()
+		return nil, fmt.Errorf("failed to find platform: %v", err)
+	}
+	file, ok := manifest.Files[pkginfo.File]
+	if !ok || file == nil {
+		err = fmt.Errorf("failed to find file for %+v", pkginfo)
+		trace.WithError(err).End()
+		return nil, err
+	}
+
+	verification := verifyFile(packageName, version, pkginfo.File, file)
+	files := []FileVerification{verification}
+	if verification.Status == FileVerified {
+		installed, err := verifyInstalledFiles(verification.LocalFilePath, verification.ChecksumAlgorithm, installDirectory)
+		if err != nil {
+			trace.WithError(err).End()
+			return nil, err
+		}
+		files = append(files, installed...)
+	}
+
+	result := &VerificationResult{
+		PackageArn: manifest.PackageArn,
+		Version:    manifest.Version,
+		Compliant:  true,
+		Files:      files,
+	}
+	for _, fileVerification := range files {
+		switch fileVerification.Status {
+		case FileMissing:
+			result.MissingFiles = append(result.MissingFiles, fileVerification.Name)
+		case FileModified:
+			result.ModifiedFiles = append(result.ModifiedFiles, fileVerification.Name)
+		}
+		if fileVerification.Status != FileVerified {
+			result.Compliant = false
+		}
+	}
+	trace.AppendInfof("%v %v file %v is %v, %v files checked, missing: %v, modified: %v",
+		packageName, version, verification.Name, verification.Status, len(files), result.MissingFiles, result.ModifiedFiles).End()
+	return result, nil
+}
+
+// verifyInstalledFiles checks the files of the verified artifact against the files they were extracted to in installDirectory.
+// The checksums of all the installed files are computed together once the expected checksums are read from the artifact.
+func verifyInstalledFiles(artifactPath string, algorithm string, installDirectory string) ([]FileVerification, error) {
+	data, err := filesysdep.ReadFile(artifactPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %v: %v", artifactPath, err)
+	}
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the files of %v: %v", artifactPath, err)
+	}
+
+	var verifications []FileVerification
+	var checks []fileChecksumCheck
+	for _, entry := range archive.File {
+		if entry.FileInfo().IsDir() {
+			continue
+		}
+		verification := FileVerification{Name: entry.Name, ChecksumAlgorithm: algorithm}
+		if verification.LocalFilePath, err = filemanager.SafeJoin(installDirectory, entry.Name); err != nil {
+			return nil, err
+		}
+		if verification.ExpectedChecksum, err = zipEntryChecksum(entry, algorithm); err != nil {
+			return nil, fmt.Errorf("failed to read %v from %v: %v", entry.Name, artifactPath, err)
+		}
+		if filesysdep.Exists(verification.LocalFilePath) {
+			checks = append(checks, fileChecksumCheck{Name: entry.Name, Path: verification.LocalFilePath, Algorithm: algorithm, Expected: verification.ExpectedChecksum})
+		} else {
+			verification.Status = FileMissing
+		}
+		verifications = append(verifications, verification)
+	}
+
+	mismatch := &checksumMismatchError{}
+	if err = verifyFileChecksums(checks); err != nil {
+		mismatch = err.(*checksumMismatchError)
+	}
+	for i := range verifications {
+		verification := &verifications[i]
+		if verification.Status == FileMissing {
+			continue
+		}
+		if _, failed := mismatch.Files[verification.Name]; !failed {
+			verification.ActualChecksum = verification.ExpectedChecksum
+			verification.Status = FileVerified
+		} else if actual, ok := mismatch.Actual[verification.Name]; ok {
+			verification.ActualChecksum = actual
+			verification.Status = FileModified
+		} else {
+			verification.Status = FileMissing
+		}
+	}
+	return verifications, nil
+}
+
+// zipEntryChecksum returns the checksum of the content of an artifact entry
+func zipEntryChecksum(entry *zip.File, algorithm string) (string, error) {
+	reader, err := entry.Open()
+	if err != nil {
+		return "", err
+	}
+	defer reader.Close()
+	content, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return "", err
+	}
+	return artifact.Checksum(algorithm, content)
+}
+
+// compareFileChecksum computes the checksum of the local file of a verification and returns its status
+func compareFileChecksum(verification *FileVerification) string {
+	if !filesysdep.Exists(verification.LocalFilePath) {
+		return FileMissing
+	}
+	actual, err := fileChecksum(verification.LocalFilePath, verification.ChecksumAlgorithm)
+	if err != nil {
+		return FileMissing
+	}
+	verification.ActualChecksum = actual
+	if strings.EqualFold(verification.ActualChecksum, verification.ExpectedChecksum) {
+		return FileVerified
+	}
+	return FileModified
+}
+
+func verifyFile(packageName string, version string, name string, file *File) FileVerification {
+	algorithm, expected := artifact.PreferredChecksum(file.Checksums)
+	verification := FileVerification{
+		Name:              name,
+		ChecksumAlgorithm: algorithm,
+		ExpectedChecksum:  expected,
+	}
+
+	state, err := readDownloadState(packageName, version)
+	if err != nil {
+		verification.Status = FileMissing
+		return verification
+	}
+	verification.LocalFilePath = state.LocalFilePath
+	if !filesysdep.Exists(state.LocalFilePath) {
+		verification.Status = FileMissing
+		return verification
+	}
+
+	if verification.ExpectedChecksum == "" {
+		verification.Status = FileUnverifiable
+		return verification
+	}
+	if _, err = artifact.NewHash(algorithm); err != nil {
+		verification.Status = FileUnverifiable
+		return verification
+	}
+	verification.Status = compareFileChecksum(&verification)
+	return verification
+}
@@ -0,0 +1,250 @@
+// Copyright 2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+package birdwatcher
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/aws/amazon-ssm-agent/agent/fileutil/artifact"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/configurepackage/envdetect"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/configurepackage/envdetect/osdetect"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/configurepackage/packageservice"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/configurepackage/trace"
+	"github.com/aws/aws-sdk-go/service/ssm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// verifyManifest returns a manifest whose test.zip file is the artifact
+func verifyManifest(artifactContent []byte) string {
+	checksum, _ := artifact.Checksum("sha256", artifactContent)
+	return fmt.Sprintf(`
+	{
+		"packageArn": "packageName",
+		"version": "1234",
+		"packages": {
+			"platformName": {
+				"platformVersion": {
+					"architecture": {
+						"file": "test.zip"
+					}
+				}
+			}
+		},
+		"files": {
+			"test.zip": {
+				"downloadLocation": "https://example.com/agent",
+				"checksums": {
+					"sha256": "%v"
+				}
+			}
+		}
+	}
+	`, strings.ToUpper(checksum))
+}
+
+// zipArtifact returns a zip archive of files
+func zipArtifact(t *testing.T, files map[string]string) []byte {
+	var buffer bytes.Buffer
+	writer := zip.NewWriter(&buffer)
+	for _, name := range []string{"install.sh", "bin/", "bin/tool"} {
+		entry, err := writer.Create(name)
+		assert.NoError(t, err)
+		if content, ok := files[name]; ok {
+			_, err = entry.Write([]byte(content))
+			assert.NoError(t, err)
+		}
+	}
+	assert.NoError(t, writer.Close())
+	return buffer.Bytes()
+}
+
+func TestVerify(t *testing.T) {
+	artifactContent := zipArtifact(t, map[string]string{"install.sh": "install", "bin/tool": "tool"})
+	installDirectory := filepath.Join("packages", "packageName", "1234")
+	tracer := trace.NewTracer(log.NewMockLog())
+	tracer.BeginSection("test segment root")
+
+	data := []struct {
+		name              string
+		recordState       bool
+		localContent      []byte
+		installedFiles    map[string]string
+		expectedStatuses  []string
+		expectedMissing   []string
+		expectedModified  []string
+		expectedCompliant bool
+	}{
+		{
+			"unmodified files",
+			true,
+			artifactContent,
+			map[string]string{"install.sh": "install", "bin/tool": "tool"},
+			[]string{FileVerified, FileVerified, FileVerified},
+			nil,
+			nil,
+			true,
+		},
+		{
+			"modified and removed installed files",
+			true,
+			artifactContent,
+			map[string]string{"bin/tool": "modified tool"},
+			[]string{FileVerified, FileMissing, FileModified},
+			[]string{"install.sh"},
+			[]string{"bin/tool"},
+			false,
+		},
+		{
+			"modified artifact",
+			true,
+			[]byte("modified content"),
+			nil,
+			[]string{FileModified},
+			nil,
+			[]string{"test.zip"},
+			false,
+		},
+		{
+			"removed artifact",
+			true,
+			nil,
+			nil,
+			[]string{FileMissing},
+			[]string{"test.zip"},
+			nil,
+			false,
+		},
+		{
+			"never downloaded",
+			false,
+			nil,
+			nil,
+			[]string{FileMissing},
+			[]string{"test.zip"},
+			nil,
+			false,
+		},
+	}
+
+	for _, testdata := range data {
+		t.Run(testdata.name, func(t *testing.T) {
+			cache := packageservice.ManifestCacheMemNew()
+			cache.WriteManifest("packageName", "1234", []byte(verifyManifest(artifactContent)))
+
+			mockedCollector := envdetect.CollectorMock{}
+			mockedCollector.On("CollectData", mock.Anything).Return(&envdetect.Environment{
+				&osdetect.OperatingSystem{"platformName", "platformVersion", "", "architecture", "", ""},
+				nil,
+			}, nil).Once()
+
+			fileSys := newFileSysMock()
+			filesysdep = fileSys
+			if testdata.recordState {
+				writeDownloadState(&DownloadState{
+					PackageName:   "packageName",
+					Version:       "1234",
+					LocalFilePath: "local/agent.zip",
+				})
+			}
+			if testdata.localContent != nil {
+				fileSys.files["local/agent.zip"] = testdata.localContent
+			}
+			for name, content := range testdata.installedFiles {
+				fileSys.files[filepath.Join(installDirectory, filepath.FromSlash(name))] = []byte(content)
+			}
+			filesBefore := len(fileSys.files)
+
+			ds := &PackageService{manifestCache: cache, collector: &mockedCollector}
+			result, err := ds.Verify(tracer, "packageName", "1234", installDirectory)
+
+			assert.NoError(t, err)
+			assert.Equal(t, "packageName", result.PackageArn)
+			assert.Equal(t, "1234", result.Version)
+			assert.Equal(t, testdata.expectedCompliant, result.Compliant)
+			assert.Equal(t, "test.zip", result.Files[0].Name)
+			var statuses []string
+			for _, file := range result.Files {
+				statuses = append(statuses, file.Status)
+			}
+			assert.Equal(t, testdata.expectedStatuses, statuses)
+			assert.Equal(t, testdata.expectedMissing, result.MissingFiles)
+			assert.Equal(t, testdata.expectedModified, result.ModifiedFiles)
+			// verification never writes to disk
+			assert.Equal(t, filesBefore, len(fileSys.files))
+		})
+	}
+}
+
+func TestVerifyDoesNotCacheManifest(t *testing.T) {
+	manifestStr := verifyManifest([]byte("content"))
+	tracer := trace.NewTracer(log.NewMockLog())
+	tracer.BeginSection("test segment root")
+	mockedCollector := envdetect.CollectorMock{}
+	mockedCollector.On("CollectData", mock.Anything).Return(&envdetect.Environment{
+		&osdetect.OperatingSystem{"platformName", "platformVersion", "", "architecture", "", ""},
+		nil,
+	}, nil).Once()
+	filesysdep = newFileSysMock()
+	facadeClient := facadeMock{getManifestOutput: &ssm.GetManifestOutput{Manifest: &manifestStr}}
+	cache := packageservice.ManifestCacheMemNew()
+
+	ds := &PackageService{facadeClient: &facadeClient, manifestCache: cache, collector: &mockedCollector}
+	result, err := ds.Verify(tracer, "packageName", "1234", "packages")
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"test.zip"}, result.MissingFiles)
+	assert.Equal(t, "packageName", *facadeClient.getManifestInput.PackageName)
+	cachedManifest, err := cache.ReadManifest("packageName", "1234")
+	assert.NoError(t, err)
+	assert.Empty(t, cachedManifest)
+}
+
+func TestVerifyFileChecksumAlgorithms(t *testing.T) {
+	data := []struct {
+		name              string
+		checksums         map[string]string
+		expectedAlgorithm string
+		expectedStatus    string
+	}{
+		{"sha1", map[string]string{"sha1": "040F06FD774092478D450774F5BA30C5DA78ACC8"}, "sha1", FileVerified},
+		{"sha256", map[string]string{"sha256": "ed7002b439e9ac845f22357d822bac1444730fbdb6016d3ec9432297b9ec9f73"}, "sha256", FileVerified},
+		{"sha512", map[string]string{"sha512": "b2d1d285b5199c85f988d03649c37e44fd3dde01e5d69c50fef90651962f48110e9340b60d49a479c4c0b53f5f07d690686dd87d2481937a512e8b85ee7c617f"}, "sha512", FileVerified},
+		{"strongest algorithm wins", map[string]string{"sha1": "040f06fd774092478d450774f5ba30c5da78acc8", "sha256": "0000"}, "sha256", FileModified},
+		{"unsupported algorithm", map[string]string{"sha384": "0000"}, "sha384", FileUnverifiable},
+	}
+	for _, testdata := range data {
+		t.Run(testdata.name, func(t *testing.T) {
+			fileSys := newFileSysMock()
+			filesysdep = fileSys
+			writeDownloadState(&DownloadState{
+				PackageName:   "packageName",
+				Version:       "1234",
+				LocalFilePath: "local/agent.zip",
+			})
+			fileSys.files["local/agent.zip"] = []byte("content")
+
+			verification := verifyFile("packageName", "1234", "test.zip", &File{Checksums: testdata.checksums})
+
+			assert.Equal(t, testdata.expectedAlgorithm, verification.ChecksumAlgorithm)
+			assert.Equal(t, testdata.expectedStatus, verification.Status)
+		})
+	}
+}
@@ -14,6 +14,16 @@
 
 package birdwatcher
 
+// Platform selection policies, telling which manifest platform entries match the platform of the instance
+const (
+	// PlatformSelectionExact only matches the entries of the platform itself, e.g. amazon
+	PlatformSelectionExact = "exact"
+	// PlatformSelectionFamily only matches the entries of the platform family, e.g. rhel
+	PlatformSelectionFamily = "family"
+	// PlatformSelectionExactThenFamily matches the entries of the platform, or of its family when it has none
+	PlatformSelectionExactThenFamily = "exact-then-family"
+)
+
 // File contains data for one SSM package
 type File struct {
 	Checksums        map[string]string `json:"checksums"`
@@ -36,3 +46,70 @@ type Manifest struct {
 	Packages map[string]map[string]map[string]*PackageInfo `json:"packages"`
 	Files    map[string]*File                              `json:"files"`
 }
+
+// InstallManifest lists packages that are installed together and the packages each of them depends on
+type InstallManifest struct {
+	SchemaVersion string `json:"schemaVersion"`
+
+	// package name -> package to install
+	Packages map[string]*InstallPackage `json:"packages"`
+}
+
+// InstallPackage is a package of an install manifest
+type InstallPackage struct {
+	Version string `json:"version"`
+	// Dependencies are the names of the packages of the manifest that must be installed before this one
+	Dependencies []string `json:"dependencies"`
+}
+
+// DownloadState records the artifact downloaded for a package version so repeated installs can reuse it
+type DownloadState struct {
+	PackageName   string `json:"packageName"`
+	Version       string `json:"version"`
+	LocalFilePath string `json:"localFilePath"`
+	Checksum      string `json:"checksum"`
+}
+
+// FileVerification is the outcome of checking one package file on the instance against the manifest
+type FileVerification struct {
+	Name          string `json:"name"`
+	LocalFilePath string `json:"localFilePath,omitempty"`
+	Status        string `json:"status"`
+	// ChecksumAlgorithm is the strongest supported algorithm among the manifest checksums
+	ChecksumAlgorithm string `json:"checksumAlgorithm,omitempty"`
+	ExpectedChecksum  string `json:"expectedChecksum,omitempty"`
+	ActualChecksum    string `json:"actualChecksum,omitempty"`
+}
+
+// VerificationResult lists the verified files of a package version for compliance reporting
+type VerificationResult struct {
+	PackageArn string             `json:"packageArn"`
+	Version    string             `json:"version"`
+	Compliant  bool               `json:"compliant"`
+	Files      []FileVerification `json:"files"`
+	// MissingFiles and ModifiedFiles are the names of the files that failed verification
+	MissingFiles  []string `json:"missingFiles,omitempty"`
+	ModifiedFiles []string `json:"modifiedFiles,omitempty"`
+}
+
+// Resolution describes how the package file matching the current platform was selected from a manifest
+type Resolution struct {
+	PackageArn       string `json:"packageArn"`
+	RequestedVersion string `json:"requestedVersion"`
+	SelectedVersion  string `json:"selectedVersion"`
+
+	// platform detected on the instance
+	Platform        string `json:"platform"`
+	PlatformVersion string `json:"platformVersion"`
+	PlatformFamily  string `json:"platformFamily,omitempty"`
+	Architecture    string `json:"architecture"`
+
+	// manifest entries considered and matched for the detected platform
+	ConsideredPlatformVersions []string `json:"consideredPlatformVersions"`
+	MatchedPlatform            string   `json:"matchedPlatform"`
+	MatchedPlatformVersion     string   `json:"matchedPlatformVersion"`
+	MatchedArchitecture        string   `json:"matchedArchitecture"`
+	File                       string   `json:"file"`
+
+	packageInfo *PackageInfo
+}
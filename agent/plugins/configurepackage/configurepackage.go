@@ -26,6 +26,7 @@ import (
 	"github.com/aws/amazon-ssm-agent/agent/contracts"
 	"github.com/aws/amazon-ssm-agent/agent/framework/processor/executer/iohandler"
 	"github.com/aws/amazon-ssm-agent/agent/jsonutil"
+	"github.com/aws/amazon-ssm-agent/agent/log"
 	"github.com/aws/amazon-ssm-agent/agent/platform"
 	"github.com/aws/amazon-ssm-agent/agent/plugins/configurepackage/birdwatcher"
 	"github.com/aws/amazon-ssm-agent/agent/plugins/configurepackage/installer"
@@ -45,7 +46,7 @@ const (
 
 // Plugin is the type for the configurepackage plugin.
 type Plugin struct {
-	packageServiceSelector func(tracer trace.Tracer, serviceEndpoint string, localrepo localpackages.Repository) packageservice.PackageService
+	packageServiceSelector func(tracer trace.Tracer, serviceEndpoint string, localrepo localpackages.Repository, forceDownload bool) packageservice.PackageService
 	localRepository        localpackages.Repository
 }
 
@@ -57,6 +58,10 @@ type ConfigurePackagePluginInput struct {
 	Action     string `json:"action"`
 	Source     string `json:"source"`
 	Repository string `json:"repository"`
+	// Verbose logs the details of this run at Info level
+	Verbose bool `json:"verbose"`
+	// Force downloads the package even when a copy of an earlier successful install can be reused
+	Force bool `json:"force"`
 }
 
 // NewPlugin returns a new instance of the plugin.
@@ -205,6 +210,12 @@ func buildDownloadDelegate(tracer trace.Tracer, packageService packageservice.Pa
 			return fmt.Errorf("failed to extract package installer package %v from %v, %v", filePath, targetDirectory, uncompressErr.Error())
 		}
 
+		// services recording installs keep the artifact until the install is recorded, removeUnrecordedArtifacts deletes it otherwise
+		if _, recorded := packageService.(packageservice.InstallRecorder); recorded {
+			trace.End()
+			return nil
+		}
+
 		// NOTE: this could be considered a warning - it likely points to a real problem, but if uncompress succeeded, we could continue
 		// delete compressed package after using
 		if cleanupErr := filesysdep.RemoveAll(filePath); cleanupErr != nil {
@@ -249,6 +260,11 @@ func getVersionToUninstall(
 	return installedVersion, currentState
 }
 
+// verboseLogger returns a logger writing the debug messages of a run at Info level when verbose is set
+func verboseLogger(logger log.T, verbose bool) log.T {
+	return log.Verbose(logger, verbose)
+}
+
 // parseAndValidateInput marshals raw JSON and returns the result of input validation or an error
 func parseAndValidateInput(rawPluginInput interface{}) (*ConfigurePackagePluginInput, error) {
 	var input ConfigurePackagePluginInput
@@ -355,13 +371,13 @@ func checkAlreadyInstalled(
 }
 
 // selectService chooses the implementation of PackageService to use for a given execution of the plugin
-func selectService(tracer trace.Tracer, serviceEndpoint string, localrepo localpackages.Repository) packageservice.PackageService {
+func selectService(tracer trace.Tracer, serviceEndpoint string, localrepo localpackages.Repository, forceDownload bool) packageservice.PackageService {
 	region, _ := platform.Region()
 	appCfg, err := appconfig.Config(false)
 
 	if (err == nil && appCfg.Birdwatcher.ForceEnable) || !ssms3.UseSSMS3Service(tracer, serviceEndpoint, region) {
 		tracer.CurrentTrace().AppendInfof("S3 repository is not marked active in %v %v", region, serviceEndpoint)
-		return birdwatcher.New(serviceEndpoint, localrepo)
+		return birdwatcher.New(serviceEndpoint, localrepo, forceDownload)
 	}
 
 	tracer.CurrentTrace().AppendInfof("S3 repository is marked active")
@@ -378,6 +394,10 @@ func (p *Plugin) execute(context context.T, config contracts.Configuration, canc
 	log := context.Log()
 	log.Info("RunCommand started with configuration ", config)
 	startTime := time.Now().UnixNano()
+	input, inputErr := parseAndValidateInput(config.Properties)
+	if inputErr == nil {
+		log = verboseLogger(log, input.Verbose)
+	}
 	tracer := trace.NewTracer(log)
 	defer tracer.BeginSection("configurePackage").End()
 
@@ -387,11 +407,12 @@ func (p *Plugin) execute(context context.T, config contracts.Configuration, canc
 		out.MarkAsShutdown()
 	} else if cancelFlag.Canceled() {
 		out.MarkAsCancelled()
-	} else if input, err := parseAndValidateInput(config.Properties); err != nil {
-		tracer.CurrentTrace().WithError(err).End()
+	} else if inputErr != nil {
+		tracer.CurrentTrace().WithError(inputErr).End()
 		out.MarkAsFailed(nil, nil)
 	} else {
-		packageService := p.packageServiceSelector(tracer, input.Repository, p.localRepository)
+		packageService := p.packageServiceSelector(tracer, input.Repository, p.localRepository, input.Force)
+		defer removeUnrecordedArtifacts(tracer, packageService)
 		//Return failure if the manifest cannot be accessed
 		//Return failure if the package version is installed, but the manifest is no longer available
 		packageArn, manifestVersion, isSameAsCache, err := getPackageArnAndVersion(tracer, packageService, input)
@@ -435,6 +456,9 @@ func (p *Plugin) execute(context context.T, config contracts.Configuration, canc
 						uninst,
 						installState,
 						&out)
+					if input.Action == InstallAction && out.GetStatus() == contracts.ResultStatusSuccess {
+						recordInstall(tracer, packageService, packageArn, inst.Version())
+					}
 					if !out.GetStatus().IsReboot() {
 						version := manifestVersion
 						if input.Action == InstallAction {
@@ -471,6 +495,31 @@ func (p *Plugin) execute(context context.T, config contracts.Configuration, canc
 	return
 }
 
+// recordInstall lets the package service record a successful install when it reuses the artifacts of successful installs
+func recordInstall(tracer trace.Tracer, packageService packageservice.PackageService, packageArn string, version string) {
+	recorder, ok := packageService.(packageservice.InstallRecorder)
+	if !ok {
+		return
+	}
+	if err := recorder.RecordInstall(tracer, packageArn, version); err != nil {
+		// the install itself succeeded, the next install just downloads the package again
+		tracer.CurrentTrace().AppendInfof("failed to record install of %v %v: %v", packageArn, version, err)
+	}
+}
+
+// removeUnrecordedArtifacts deletes the artifacts a service recording installs kept for installs it did not record
+func removeUnrecordedArtifacts(tracer trace.Tracer, packageService packageservice.PackageService) {
+	recorder, ok := packageService.(packageservice.InstallRecorder)
+	if !ok {
+		return
+	}
+	for _, filePath := range recorder.UnrecordedArtifacts() {
+		if err := filesysdep.RemoveAll(filePath); err != nil {
+			tracer.CurrentTrace().AppendInfof("failed to delete compressed package %v: %v", filePath, err)
+		}
+	}
+}
+
 // Name returns the name of the plugin.
 func Name() string {
 	return appconfig.PluginNameAwsConfigurePackage
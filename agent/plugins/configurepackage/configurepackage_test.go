@@ -22,6 +22,8 @@ import (
 	"github.com/aws/amazon-ssm-agent/agent/contracts"
 	"github.com/aws/amazon-ssm-agent/agent/log"
 	"github.com/aws/amazon-ssm-agent/agent/plugins/configurepackage/localpackages"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/configurepackage/packageservice"
+	serviceMock "github.com/aws/amazon-ssm-agent/agent/plugins/configurepackage/packageservice/mock"
 	"github.com/aws/amazon-ssm-agent/agent/plugins/configurepackage/trace"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -456,6 +458,36 @@ func TestExecute(t *testing.T) {
 	serviceMock.AssertExpectations(t)
 }
 
+func TestExecuteRecordsInstall(t *testing.T) {
+	stubs := setSuccessStubs()
+	defer stubs.Clear()
+
+	pluginInformation := createStubPluginInputInstall()
+	pluginInformation.Force = true
+	installerMock := installerSuccessMock(pluginInformation.Name, pluginInformation.Version)
+	repoMock := repoInstallMock(pluginInformation, installerMock)
+	recorderMock := &serviceMock.RecorderMock{}
+	recorderMock.On("DownloadManifest", mock.Anything, mock.Anything, mock.Anything).Return("packageArn", "0.0.1", false, nil)
+	recorderMock.On("ReportResult", mock.Anything, mock.Anything).Return(nil)
+	recorderMock.On("RecordInstall", mock.Anything, "packageArn", pluginInformation.Version).Return(nil).Once()
+	recorderMock.On("UnrecordedArtifacts").Return([]string{"unrecorded.zip"}).Once()
+
+	var forceDownload bool
+	plugin := &Plugin{
+		localRepository: repoMock,
+		packageServiceSelector: func(tracer trace.Tracer, repository string, localrepo localpackages.Repository, force bool) packageservice.PackageService {
+			forceDownload = force
+			return recorderMock
+		},
+	}
+	plugin.execute(contextMock, buildConfigSimple(pluginInformation), createMockCancelFlag(), createMockIOHandler())
+
+	assert.True(t, forceDownload)
+	// the artifacts no install recorded are deleted
+	assert.Equal(t, []string{"unrecorded.zip"}, stubs.fileSysDepStub.(*FileSysDepStub).removed)
+	recorderMock.AssertExpectations(t)
+}
+
 func TestExecuteArrayInput(t *testing.T) {
 	pluginInformation := createStubPluginInputInstall()
 	installerMock := installerSuccessMock(pluginInformation.Name, pluginInformation.Version)
@@ -189,8 +189,8 @@ func installerNotCalledMock() *installerMock.Mock {
 	return &installerMock.Mock{}
 }
 
-func selectMockService(service packageservice.PackageService) func(tracer trace.Tracer, repository string, localrepo localpackages.Repository) packageservice.PackageService {
-	return func(tracer trace.Tracer, repository string, localrepo localpackages.Repository) packageservice.PackageService {
+func selectMockService(service packageservice.PackageService) func(tracer trace.Tracer, repository string, localrepo localpackages.Repository, forceDownload bool) packageservice.PackageService {
+	return func(tracer trace.Tracer, repository string, localrepo localpackages.Repository, forceDownload bool) packageservice.PackageService {
 		return service
 	}
 }
@@ -284,6 +284,7 @@ type FileSysDepStub struct {
 	uncompressError error
 	removeError     error
 	writeError      error
+	removed         []string
 }
 
 func (m *FileSysDepStub) MakeDirExecute(destinationDir string) (err error) {
@@ -295,6 +296,7 @@ func (m *FileSysDepStub) Uncompress(src, dest string) error {
 }
 
 func (m *FileSysDepStub) RemoveAll(path string) error {
+	m.removed = append(m.removed, path)
 	return m.removeError
 }
 
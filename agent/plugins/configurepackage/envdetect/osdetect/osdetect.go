@@ -3,6 +3,7 @@ package osdetect
 import (
 	"fmt"
 	"runtime"
+	"sync"
 
 	"github.com/aws/amazon-ssm-agent/agent/log"
 
@@ -28,21 +29,39 @@ type OperatingSystem struct {
 	PackageManager  string
 }
 
+// detectedPlatform is the platform, its version and family and the architecture detected by the OS detector
+type detectedPlatform struct {
+	platform        string
+	platformVersion string
+	platformFamily  string
+	architecture    string
+}
+
+// platformCache memoizes the first successful platform detection, which shells out on some distributions,
+// the platform doesn't change while the agent runs
+var platformCache struct {
+	sync.Mutex
+	detected *detectedPlatform
+}
+
+// newDetector is a seam returning the OS detector of the operating system the agent runs on
+var newDetector = detectorForOS
+
+// ResetPlatformCache discards the memoized platform so the next CollectOSData detects it again, tests use it to start afresh
+func ResetPlatformCache() {
+	platformCache.Lock()
+	defer platformCache.Unlock()
+	platformCache.detected = nil
+}
+
 // CollectOSData quires the operating system for type and capabilities
 func CollectOSData(log log.T) (*OperatingSystem, error) {
-	var d OsDetector
-	switch runtime.GOOS {
-	case "darwin":
-		d = &darwin.Detector{}
-	case "linux":
-		d = &linux.Detector{}
-	case "windows":
-		d = &windows.Detector{}
-	default:
-		return nil, fmt.Errorf("unknown platform: %s", runtime.GOOS)
+	d, err := newDetector()
+	if err != nil {
+		return nil, err
 	}
 
-	platform, platformVersion, platformFamily, err := d.DetectPlatform(log)
+	detected, err := detectPlatform(log, d)
 	if err != nil {
 		return nil, err
 	}
@@ -52,23 +71,59 @@ func CollectOSData(log log.T) (*OperatingSystem, error) {
 		return nil, err
 	}
 
-	pkg, err := d.DetectPkgManager(platform, platformVersion, platformFamily)
+	pkg, err := d.DetectPkgManager(detected.platform, detected.platformVersion, detected.platformFamily)
 	if err != nil {
 		return nil, err
 	}
 
+	e := &OperatingSystem{
+		Platform:        detected.platform,
+		PlatformVersion: detected.platformVersion,
+		PlatformFamily:  detected.platformFamily,
+		Architecture:    detected.architecture,
+		InitSystem:      init,
+		PackageManager:  pkg,
+	}
+	return e, err
+}
+
+// detectorForOS returns the OS detector of runtime.GOOS
+func detectorForOS() (OsDetector, error) {
+	switch runtime.GOOS {
+	case "darwin":
+		return &darwin.Detector{}, nil
+	case "linux":
+		return &linux.Detector{}, nil
+	case "windows":
+		return &windows.Detector{}, nil
+	default:
+		return nil, fmt.Errorf("unknown platform: %s", runtime.GOOS)
+	}
+}
+
+// detectPlatform returns the memoized platform, detecting it with d when there is none yet. Failed detections are not memoized.
+func detectPlatform(log log.T, d OsDetector) (detectedPlatform, error) {
+	platformCache.Lock()
+	defer platformCache.Unlock()
+	if platformCache.detected != nil {
+		return *platformCache.detected, nil
+	}
+
+	platform, platformVersion, platformFamily, err := d.DetectPlatform(log)
+	if err != nil {
+		return detectedPlatform{}, err
+	}
+
 	arch := runtime.GOARCH
 	if arch == "amd64" {
 		arch = "x86_64"
 	}
 
-	e := &OperatingSystem{
-		Platform:        platform,
-		PlatformVersion: platformVersion,
-		PlatformFamily:  platformFamily,
-		Architecture:    arch,
-		InitSystem:      init,
-		PackageManager:  pkg,
+	platformCache.detected = &detectedPlatform{
+		platform:        platform,
+		platformVersion: platformVersion,
+		platformFamily:  platformFamily,
+		architecture:    arch,
 	}
-	return e, err
+	return *platformCache.detected, nil
 }
@@ -0,0 +1,99 @@
+package osdetect
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type detectorMock struct {
+	mock.Mock
+}
+
+func (d *detectorMock) DetectPlatform(log log.T) (string, string, string, error) {
+	args := d.Called(log)
+	return args.String(0), args.String(1), args.String(2), args.Error(3)
+}
+
+func (d *detectorMock) DetectInitSystem() (string, error) {
+	args := d.Called()
+	return args.String(0), args.Error(1)
+}
+
+func (d *detectorMock) DetectPkgManager(platform string, version string, family string) (string, error) {
+	args := d.Called(platform, version, family)
+	return args.String(0), args.Error(1)
+}
+
+// useDetector makes CollectOSData detect with d from an empty platform cache, until the returned function restores the OS detector
+func useDetector(d OsDetector) (restore func()) {
+	ResetPlatformCache()
+	newDetector = func() (OsDetector, error) { return d, nil }
+	return func() {
+		newDetector = detectorForOS
+		ResetPlatformCache()
+	}
+}
+
+func TestCollectOSData_DetectsPlatformOnce(t *testing.T) {
+	logger := log.NewMockLog()
+	d := &detectorMock{}
+	d.On("DetectPlatform", logger).Return("ubuntu", "18.04", "debian", nil).Once()
+	d.On("DetectInitSystem").Return("systemd", nil)
+	d.On("DetectPkgManager", "ubuntu", "18.04", "debian").Return("apt", nil)
+	defer useDetector(d)()
+
+	for i := 0; i < 3; i++ {
+		os, err := CollectOSData(logger)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "ubuntu", os.Platform)
+		assert.Equal(t, "18.04", os.PlatformVersion)
+		assert.Equal(t, "debian", os.PlatformFamily)
+		assert.NotEmpty(t, os.Architecture)
+		assert.Equal(t, "apt", os.PackageManager)
+	}
+	d.AssertNumberOfCalls(t, "DetectPlatform", 1)
+}
+
+func TestCollectOSData_FailedDetectionIsNotCached(t *testing.T) {
+	logger := log.NewMockLog()
+	d := &detectorMock{}
+	d.On("DetectPlatform", logger).Return("", "", "", errors.New("lsb_release not found")).Once()
+	d.On("DetectPlatform", logger).Return("amazon", "2", "rhel", nil).Once()
+	d.On("DetectInitSystem").Return("systemd", nil)
+	d.On("DetectPkgManager", "amazon", "2", "rhel").Return("yum", nil)
+	defer useDetector(d)()
+
+	_, err := CollectOSData(logger)
+	assert.EqualError(t, err, "lsb_release not found")
+
+	os, err := CollectOSData(logger)
+	assert.NoError(t, err)
+	assert.Equal(t, "amazon", os.Platform)
+	d.AssertNumberOfCalls(t, "DetectPlatform", 2)
+}
+
+func TestResetPlatformCache(t *testing.T) {
+	logger := log.NewMockLog()
+	d := &detectorMock{}
+	d.On("DetectPlatform", logger).Return("ubuntu", "18.04", "debian", nil).Once()
+	d.On("DetectPlatform", logger).Return("ubuntu", "20.04", "debian", nil).Once()
+	d.On("DetectInitSystem").Return("systemd", nil)
+	d.On("DetectPkgManager", "ubuntu", mock.Anything, "debian").Return("apt", nil)
+	defer useDetector(d)()
+
+	os, err := CollectOSData(logger)
+	assert.NoError(t, err)
+	assert.Equal(t, "18.04", os.PlatformVersion)
+
+	ResetPlatformCache()
+	os, err = CollectOSData(logger)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "20.04", os.PlatformVersion)
+	d.AssertNumberOfCalls(t, "DetectPlatform", 2)
+}
@@ -15,7 +15,7 @@
 package localpackages
 
 import (
-	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/aws/amazon-ssm-agent/agent/fileutil/filelock"
@@ -27,42 +27,49 @@ var fileLocker = filelock.NewFileLocker()
 // var fileLocker = &filelock.FileLockerNoop{}
 
 func TestPackageLock(t *testing.T) {
-	os.Remove("lockpath")
+	// lock files are written in a directory of the test, so none is left behind to hold a package of a later run
+	lockDir := t.TempDir()
+	fooLock := filepath.Join(lockDir, "lockpath-Foo")
+	barLock := filepath.Join(lockDir, "lockpath-Bar")
+	foobarLock := filepath.Join(lockDir, "lockpath-Foobar")
 
 	// lock Foo for Install
-	err := lockPackage(fileLocker, "lockpath-Foo", "Foo", "Install")
+	err := lockPackage(fileLocker, fooLock, "Foo", "Install")
 	assert.Nil(t, err)
-	defer unlockPackage(fileLocker, "lockpath-Foo", "Foo")
+	defer unlockPackage(fileLocker, fooLock, "Foo")
 
 	// shouldn't be able to lock Foo, even for a different action
-	err = lockPackage(fileLocker, "lockpath-Foo", "Foo", "Uninstall")
+	err = lockPackage(fileLocker, fooLock, "Foo", "Uninstall")
 	assert.NotNil(t, err)
 
 	// lock and unlock Bar (with defer)
-	err = lockAndUnlock("lockpath-Bar", "Bar")
+	err = lockAndUnlock(barLock, "Bar")
 	assert.Nil(t, err)
 
 	// should be able to lock and then unlock Bar
-	err = lockPackage(fileLocker, "lockpath-Bar", "Bar", "Uninstall")
+	err = lockPackage(fileLocker, barLock, "Bar", "Uninstall")
 	assert.Nil(t, err)
-	unlockPackage(fileLocker, "lockpath-Bar", "Bar")
+	unlockPackage(fileLocker, barLock, "Bar")
 
 	// should be able to lock Bar
-	err = lockPackage(fileLocker, "lockpath-Bar", "Bar", "Uninstall")
+	err = lockPackage(fileLocker, barLock, "Bar", "Uninstall")
 	assert.Nil(t, err)
-	defer unlockPackage(fileLocker, "lockpath-Bar", "Bar")
+	defer unlockPackage(fileLocker, barLock, "Bar")
 
-	// lock in a goroutine with a 10ms sleep
+	// lock in a goroutine
 	errorChan := make(chan error)
-	go lockAndUnlockGo("lockpath-Foobar", "Foobar", errorChan)
+	done := make(chan struct{})
+	go lockAndUnlockGo(foobarLock, "Foobar", errorChan, done)
 	err = <-errorChan // wait until the goroutine has acquired the lock
 	assert.Nil(t, err)
-	err = lockPackage(fileLocker, "lockpath-Foobar", "Foobar", "Install")
+	err = lockPackage(fileLocker, foobarLock, "Foobar", "Install")
 	errorChan <- err // signal the goroutine to exit
 	assert.NotNil(t, err)
+	<-done // wait until the goroutine has released the lock
 }
 
-func lockAndUnlockGo(lockpath string, packageName string, channel chan error) {
+func lockAndUnlockGo(lockpath string, packageName string, channel chan error, done chan struct{}) {
+	defer close(done)
 	err := lockPackage(fileLocker, lockpath, packageName, "Install")
 	channel <- err
 	_ = <-channel
@@ -43,3 +43,18 @@ func (ds *Mock) ReportResult(tracer trace.Tracer, result packageservice.PackageR
 	args := ds.Called(tracer, result)
 	return args.Error(0)
 }
+
+// RecorderMock is a package service mock that also records successful installs
+type RecorderMock struct {
+	Mock
+}
+
+func (ds *RecorderMock) RecordInstall(tracer trace.Tracer, packageName string, version string) error {
+	args := ds.Called(tracer, packageName, version)
+	return args.Error(0)
+}
+
+func (ds *RecorderMock) UnrecordedArtifacts() []string {
+	args := ds.Called()
+	return args.Get(0).([]string)
+}
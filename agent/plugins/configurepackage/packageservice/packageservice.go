@@ -48,6 +48,13 @@ type PackageService interface {
 	ReportResult(tracer trace.Tracer, result PackageResult) error
 }
 
+// InstallRecorder is implemented by the package services that reuse the artifacts they downloaded for installs that succeeded.
+// The artifacts they return are kept after extraction, the ones no install recorded are deleted once the run is over.
+type InstallRecorder interface {
+	RecordInstall(tracer trace.Tracer, packageName string, version string) error
+	UnrecordedArtifacts() []string
+}
+
 const (
 	PackageServiceName_ssms3       = "ssms3"
 	PackageServiceName_birdwatcher = "birdwatcher"
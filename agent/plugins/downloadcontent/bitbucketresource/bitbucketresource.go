@@ -0,0 +1,210 @@
+// Copyright 2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package bitbucketresource implements the methods to access resources from Bitbucket Cloud
+package bitbucketresource
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/fileutil/artifact"
+	"github.com/aws/amazon-ssm-agent/agent/fileutil/filemanager"
+	"github.com/aws/amazon-ssm-agent/agent/jsonutil"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/downloadcontent/remoteresource"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/downloadcontent/system"
+)
+
+// requestTimeout bounds each request to Bitbucket, reading its response included
+const requestTimeout = 30 * time.Second
+
+// BitbucketResource is a struct for the remote resource of type Bitbucket
+type BitbucketResource struct {
+	client bitbucketClient
+	Info   BitbucketInfo
+	// fileOwnership is given to downloaded files, as configured in appconfig
+	fileOwnership system.FileOwnership
+	// maxFileSize is the configured size in bytes a single downloaded file may not exceed, files are not limited when it is 0
+	maxFileSize int64
+}
+
+// BitbucketInfo represents the sourceInfo type sent by runcommand
+type BitbucketInfo struct {
+	Workspace string `json:"workspace"`
+	// Repository is the slug of the repository in the workspace
+	Repository string `json:"repository"`
+	// Path is the file or directory of the repository to download, a directory is downloaded with all its subdirectories.
+	// The whole repository is downloaded when it is empty.
+	Path string `json:"path"`
+	// Ref is the branch, tag or commit to download, the main branch of the repository when empty
+	Ref string `json:"ref"`
+	// Username and the app password in TokenInfo authenticate the requests, stored like the tokens of GitHub sources:
+	// a secure string parameter, {{ ssm-secure:parameter-name }}, or a Secrets Manager secret, secretsmanager:secret-name
+	Username  string `json:"username"`
+	TokenInfo string `json:"tokenInfo"`
+}
+
+// NewBitbucketResource is a constructor of type BitbucketResource
+func NewBitbucketResource(log log.T, info string, token remoteresource.TokenAccess) (bitbucket *BitbucketResource, err error) {
+	var bitbucketInfo BitbucketInfo
+	if bitbucketInfo, err = parseSourceInfo(info); err != nil {
+		return nil, err
+	}
+	var appPassword string
+	if bitbucketInfo.TokenInfo != "" {
+		// NOTE: Do not log the app password
+		if appPassword, err = token.GetToken(log, bitbucketInfo.TokenInfo); err != nil {
+			return nil, err
+		}
+	}
+	return &BitbucketResource{
+		client:        newBitbucketAPI(bitbucketInfo.Username, appPassword),
+		Info:          bitbucketInfo,
+		fileOwnership: system.ConfiguredFileOwnership(),
+		maxFileSize:   artifact.MaxFileSize(),
+	}, nil
+}
+
+// parseSourceInfo unmarshals the information in sourceInfo of type BitbucketInfo and returns it
+func parseSourceInfo(sourceInfo string) (bitbucketInfo BitbucketInfo, err error) {
+	if err = jsonutil.Unmarshal(sourceInfo, &bitbucketInfo); err != nil {
+		return bitbucketInfo, fmt.Errorf("Source Info could not be unmarshalled for source type Bitbucket. Please check JSON format of sourceInfo - %v", err.Error())
+	}
+	return bitbucketInfo, nil
+}
+
+// Download pulls down the file or directory at Path of the repository, like a GitHub download: a file is saved to destPath,
+// or in it when it is a directory or ends with a path separator, and the content of a directory is saved in destPath
+func (bitbucket *BitbucketResource) Download(log log.T, filesys filemanager.FileSystem, destPath string) (err error) {
+	// if destination directory is not specified, specify the directory
+	if destPath == "" {
+		destPath = appconfig.DownloadRoot
+	}
+	info := bitbucket.Info
+	ref := info.Ref
+	if ref == "" {
+		if ref, err = bitbucket.client.GetMainBranch(log, info.Workspace, info.Repository); err != nil {
+			return err
+		}
+		log.Debugf("ref not specified, using main branch %v of %v/%v", ref, info.Workspace, info.Repository)
+	}
+	repositoryPath := strings.Trim(path.Clean("/"+info.Path), "/")
+	log.Infof("Downloading %v from Bitbucket repository %v/%v at ref %v", repositoryPath, info.Workspace, info.Repository, ref)
+
+	if repositoryPath == "" {
+		return bitbucket.downloadDirectory(log, filesys, ref, repositoryPath, destPath)
+	}
+	entry, found, err := bitbucket.client.GetEntry(log, info.Workspace, info.Repository, ref, repositoryPath)
+	if err != nil {
+		log.Error("Error occurred when trying to get repository contents - ", err)
+		return err
+	}
+	if !found {
+		return fmt.Errorf("Path %v was not found in Bitbucket repository %v/%v at ref %v", repositoryPath, info.Workspace, info.Repository, ref)
+	}
+	switch entry.Type {
+	case entryFile:
+		return bitbucket.downloadFile(log, filesys, ref, entry, remoteresource.FileDestination(filesys, destPath, repositoryPath))
+	case entryDir:
+		return bitbucket.downloadDirectory(log, filesys, ref, repositoryPath, destPath)
+	default:
+		return fmt.Errorf("Could not download %v of type %v from Bitbucket repository", repositoryPath, entry.Type)
+	}
+}
+
+// downloadDirectory pulls down the files of the directory at dirPath and of its subdirectories into destination
+func (bitbucket *BitbucketResource) downloadDirectory(log log.T, filesys filemanager.FileSystem, ref string, dirPath string, destination string) error {
+	entries, err := bitbucket.client.ListDirectory(log, bitbucket.Info.Workspace, bitbucket.Info.Repository, ref, dirPath)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		// the path comes from the repository, it may not leave the destination
+		entryDestination, err := filemanager.SafeJoin(destination, path.Base(entry.Path))
+		if err != nil {
+			return err
+		}
+		switch entry.Type {
+		case entryFile:
+			err = bitbucket.downloadFile(log, filesys, ref, entry, entryDestination)
+		case entryDir:
+			err = bitbucket.downloadDirectory(log, filesys, ref, entry.Path, entryDestination)
+		default:
+			log.Debugf("Skipping %v of type %v", entry.Path, entry.Type)
+		}
+		if err != nil {
+			log.Error("Error retrieving file from directory", destination)
+			return err
+		}
+	}
+	return nil
+}
+
+// downloadFile saves the content of the file entry to destination
+func (bitbucket *BitbucketResource) downloadFile(log log.T, filesys filemanager.FileSystem, ref string, entry sourceEntry, destination string) error {
+	if err := artifact.CheckDeclaredSize(entry.Path, entry.Size, bitbucket.maxFileSize); err != nil {
+		return err
+	}
+	content, err := bitbucket.client.GetFileContent(log, bitbucket.Info.Workspace, bitbucket.Info.Repository, ref, entry.Path)
+	if err != nil {
+		log.Error("File content could not be retrieved - ", err)
+		return err
+	}
+	if err = artifact.CheckDeclaredSize(entry.Path, int64(len(content)), bitbucket.maxFileSize); err != nil {
+		return err
+	}
+	if err = system.SaveFileContentWithOwnership(log, filesys, destination, content, bitbucket.fileOwnership); err != nil {
+		log.Errorf("Error saving file - %v", err)
+		return err
+	}
+	return nil
+}
+
+// AuditLocation describes the repository, path and ref downloaded for the audit log, leaving out tokenInfo
+func (bitbucket *BitbucketResource) AuditLocation() remoteresource.AuditLocation {
+	return remoteresource.AuditLocation{
+		Source: bitbucket.Info.Workspace + "/" + bitbucket.Info.Repository,
+		Path:   bitbucket.Info.Path,
+		Ref:    bitbucket.Info.Ref,
+	}
+}
+
+// LocationKey describes the content this resource downloads, normalizing the parts of BitbucketInfo that don't change it
+func (bitbucket *BitbucketResource) LocationKey() string {
+	info := bitbucket.Info
+	info.Workspace = strings.ToLower(info.Workspace)
+	info.Repository = strings.ToLower(info.Repository)
+	info.Path = strings.Trim(path.Clean("/"+info.Path), "/")
+	key, _ := jsonutil.Marshal(info)
+	return "Bitbucket:" + key
+}
+
+// ValidateLocationInfo ensures that the required parameters of SourceInfo are specified
+func (bitbucket *BitbucketResource) ValidateLocationInfo() (valid bool, err error) {
+	if bitbucket.Info.Workspace == "" {
+		return false, errors.New("Workspace for Bitbucket SourceType must be specified")
+	}
+	if bitbucket.Info.Repository == "" {
+		return false, errors.New("Repository for Bitbucket SourceType must be specified")
+	}
+	if (bitbucket.Info.Username == "") != (bitbucket.Info.TokenInfo == "") {
+		return false, errors.New("Username and TokenInfo for Bitbucket SourceType must be specified together")
+	}
+	return true, nil
+}
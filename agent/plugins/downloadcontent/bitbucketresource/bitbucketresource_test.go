@@ -0,0 +1,249 @@
+// Copyright 2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package bitbucketresource
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/aws/amazon-ssm-agent/agent/fileutil/filemanager"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	resourcemock "github.com/aws/amazon-ssm-agent/agent/plugins/downloadcontent/remoteresource/mock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+var logMock = log.NewMockLog()
+
+// clientMock mocks the Bitbucket API
+type clientMock struct {
+	mock.Mock
+}
+
+func (m *clientMock) GetEntry(log log.T, workspace, repository, ref, entryPath string) (sourceEntry, bool, error) {
+	args := m.Called(log, workspace, repository, ref, entryPath)
+	return args.Get(0).(sourceEntry), args.Bool(1), args.Error(2)
+}
+
+func (m *clientMock) ListDirectory(log log.T, workspace, repository, ref, dirPath string) ([]sourceEntry, error) {
+	args := m.Called(log, workspace, repository, ref, dirPath)
+	return args.Get(0).([]sourceEntry), args.Error(1)
+}
+
+func (m *clientMock) GetFileContent(log log.T, workspace, repository, ref, filePath string) (string, error) {
+	args := m.Called(log, workspace, repository, ref, filePath)
+	return args.String(0), args.Error(1)
+}
+
+func (m *clientMock) GetMainBranch(log log.T, workspace, repository string) (string, error) {
+	args := m.Called(log, workspace, repository)
+	return args.String(0), args.Error(1)
+}
+
+// fileEntry returns the entry of the file at filePath, with content "content"
+func fileEntry(filePath string) sourceEntry {
+	return sourceEntry{Type: entryFile, Path: filePath, Size: 7}
+}
+
+// mockContent expects the fetch of the content of the file at filePath
+func mockContent(client *clientMock, filePath string) {
+	client.On("GetFileContent", logMock, "team", "repo", "main", filePath).Return("content", nil).Once()
+}
+
+// newResourceWithMockedClient returns a resource downloading Path of team/repo at main with the mocked client
+func newResourceWithMockedClient(client *clientMock, path string) *BitbucketResource {
+	return &BitbucketResource{
+		client: client,
+		Info:   BitbucketInfo{Workspace: "team", Repository: "repo", Path: path, Ref: "main"},
+	}
+}
+
+func TestNewBitbucketResource(t *testing.T) {
+	token := &resourcemock.TokenAccessMock{}
+	token.On("GetToken", logMock, "{{ ssm-secure:bitbucket-password }}").Return("app-password", nil).Once()
+
+	bitbucket, err := NewBitbucketResource(logMock, `{"workspace": "team", "repository": "repo", "username": "user", "tokenInfo": "{{ ssm-secure:bitbucket-password }}"}`, token)
+
+	assert.NoError(t, err)
+	assert.Equal(t, BitbucketInfo{Workspace: "team", Repository: "repo", Username: "user", TokenInfo: "{{ ssm-secure:bitbucket-password }}"}, bitbucket.Info)
+	assert.Equal(t, "https://api.bitbucket.org/2.0/", bitbucket.client.(*bitbucketAPI).baseURL)
+	assert.Equal(t, "user", bitbucket.client.(*bitbucketAPI).username)
+	assert.Equal(t, "app-password", bitbucket.client.(*bitbucketAPI).appPassword)
+	token.AssertExpectations(t)
+}
+
+func TestNewBitbucketResource_TokenFail(t *testing.T) {
+	token := &resourcemock.TokenAccessMock{}
+	token.On("GetToken", logMock, "{{ ssm-secure:bitbucket-password }}").Return("", errors.New("Token not found")).Once()
+
+	_, err := NewBitbucketResource(logMock, `{"workspace": "team", "repository": "repo", "username": "user", "tokenInfo": "{{ ssm-secure:bitbucket-password }}"}`, token)
+
+	assert.EqualError(t, err, "Token not found")
+}
+
+func TestNewBitbucketResource_InvalidJSON(t *testing.T) {
+	_, err := NewBitbucketResource(logMock, `{"workspace": `, &resourcemock.TokenAccessMock{})
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "Source Info could not be unmarshalled for source type Bitbucket")
+}
+
+func TestBitbucketResource_DownloadFile(t *testing.T) {
+	data := []struct {
+		name                string
+		destination         string
+		existingDirectory   bool
+		expectedDestination string
+	}{
+		{"to a file", "run-me.sh", false, "run-me.sh"},
+		{"to a path ending with a separator", "destination" + string(filepath.Separator), false, filepath.Join("destination", "run.sh")},
+		{"to an existing directory", "destination", true, filepath.Join("destination", "run.sh")},
+	}
+	for _, testdata := range data {
+		t.Run(testdata.name, func(t *testing.T) {
+			client := &clientMock{}
+			client.On("GetEntry", logMock, "team", "repo", "main", "scripts/run.sh").Return(fileEntry("scripts/run.sh"), true, nil).Once()
+			mockContent(client, "scripts/run.sh")
+			filesys := filemanager.NewMemoryFileSystem()
+			if testdata.existingDirectory {
+				filesys.MakeDirs(testdata.destination)
+			}
+
+			err := newResourceWithMockedClient(client, "scripts/run.sh").Download(logMock, filesys, testdata.destination)
+
+			assert.NoError(t, err)
+			content, err := filesys.ReadFile(testdata.expectedDestination)
+			assert.NoError(t, err)
+			assert.Equal(t, "content", content)
+			client.AssertExpectations(t)
+		})
+	}
+}
+
+func TestBitbucketResource_DownloadDirectory(t *testing.T) {
+	client := &clientMock{}
+	client.On("GetEntry", logMock, "team", "repo", "main", "scripts").Return(sourceEntry{Type: entryDir, Path: "scripts"}, true, nil).Once()
+	client.On("ListDirectory", logMock, "team", "repo", "main", "scripts").Return([]sourceEntry{
+		{Type: entryDir, Path: "scripts/lib"},
+		{Type: "commit_link", Path: "scripts/module"},
+		fileEntry("scripts/run.sh"),
+	}, nil).Once()
+	client.On("ListDirectory", logMock, "team", "repo", "main", "scripts/lib").Return([]sourceEntry{fileEntry("scripts/lib/common.sh")}, nil).Once()
+	mockContent(client, "scripts/lib/common.sh")
+	mockContent(client, "scripts/run.sh")
+	filesys := filemanager.NewMemoryFileSystem()
+
+	err := newResourceWithMockedClient(client, "/scripts/").Download(logMock, filesys, "destination")
+
+	assert.NoError(t, err)
+	// the link is skipped, the content of the directory lands in the destination itself
+	assert.Equal(t, []string{"lib/common.sh", "run.sh"}, filesys.FilesUnder("destination"))
+	client.AssertExpectations(t)
+}
+
+func TestBitbucketResource_DownloadDirectoryEntryOutsideDestination(t *testing.T) {
+	client := &clientMock{}
+	client.On("GetEntry", logMock, "team", "repo", "main", "scripts").Return(sourceEntry{Type: entryDir, Path: "scripts"}, true, nil).Once()
+	client.On("ListDirectory", logMock, "team", "repo", "main", "scripts").Return([]sourceEntry{fileEntry("scripts/..")}, nil).Once()
+	filesys := filemanager.NewMemoryFileSystem()
+
+	err := newResourceWithMockedClient(client, "scripts").Download(logMock, filesys, "destination")
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "outside")
+	assert.Empty(t, filesys.Files())
+	client.AssertNotCalled(t, "GetFileContent", logMock, "team", "repo", "main", "scripts/..")
+}
+
+func TestBitbucketResource_DownloadRepositoryAtMainBranch(t *testing.T) {
+	client := &clientMock{}
+	client.On("GetMainBranch", logMock, "team", "repo").Return("main", nil).Once()
+	client.On("ListDirectory", logMock, "team", "repo", "main", "").Return([]sourceEntry{fileEntry("README.md")}, nil).Once()
+	mockContent(client, "README.md")
+	bitbucket := newResourceWithMockedClient(client, "")
+	bitbucket.Info.Ref = ""
+	filesys := filemanager.NewMemoryFileSystem()
+
+	err := bitbucket.Download(logMock, filesys, "destination")
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"README.md"}, filesys.FilesUnder("destination"))
+	client.AssertExpectations(t)
+}
+
+func TestBitbucketResource_DownloadPathNotFound(t *testing.T) {
+	client := &clientMock{}
+	client.On("GetEntry", logMock, "team", "repo", "main", "missing").Return(sourceEntry{}, false, nil).Once()
+	filesys := filemanager.NewMemoryFileSystem()
+
+	err := newResourceWithMockedClient(client, "missing").Download(logMock, filesys, "destination")
+
+	assert.EqualError(t, err, "Path missing was not found in Bitbucket repository team/repo at ref main")
+	assert.Empty(t, filesys.Files())
+}
+
+func TestBitbucketResource_DownloadFileTooLarge(t *testing.T) {
+	client := &clientMock{}
+	client.On("GetEntry", logMock, "team", "repo", "main", "scripts/run.sh").Return(fileEntry("scripts/run.sh"), true, nil).Once()
+	bitbucket := newResourceWithMockedClient(client, "scripts/run.sh")
+	bitbucket.maxFileSize = 3
+	filesys := filemanager.NewMemoryFileSystem()
+
+	err := bitbucket.Download(logMock, filesys, "run.sh")
+
+	assert.Error(t, err)
+	assert.Empty(t, filesys.Files())
+	client.AssertNotCalled(t, "GetFileContent", logMock, "team", "repo", "main", "scripts/run.sh")
+}
+
+func TestBitbucketResource_ValidateLocationInfo(t *testing.T) {
+	data := []struct {
+		name          string
+		info          BitbucketInfo
+		expectedError string
+	}{
+		{"valid", BitbucketInfo{Workspace: "team", Repository: "repo"}, ""},
+		{"valid with app password", BitbucketInfo{Workspace: "team", Repository: "repo", Username: "user", TokenInfo: "{{ ssm-secure:bitbucket-password }}"}, ""},
+		{"no workspace", BitbucketInfo{Repository: "repo"}, "Workspace for Bitbucket SourceType must be specified"},
+		{"no repository", BitbucketInfo{Workspace: "team"}, "Repository for Bitbucket SourceType must be specified"},
+		{"app password without username", BitbucketInfo{Workspace: "team", Repository: "repo", TokenInfo: "{{ ssm-secure:bitbucket-password }}"}, "Username and TokenInfo for Bitbucket SourceType must be specified together"},
+		{"username without app password", BitbucketInfo{Workspace: "team", Repository: "repo", Username: "user"}, "Username and TokenInfo for Bitbucket SourceType must be specified together"},
+	}
+	for _, testdata := range data {
+		t.Run(testdata.name, func(t *testing.T) {
+			bitbucket := &BitbucketResource{Info: testdata.info}
+
+			valid, err := bitbucket.ValidateLocationInfo()
+
+			if testdata.expectedError == "" {
+				assert.True(t, valid)
+				assert.NoError(t, err)
+			} else {
+				assert.False(t, valid)
+				assert.EqualError(t, err, testdata.expectedError)
+			}
+		})
+	}
+}
+
+func TestBitbucketResource_LocationKey(t *testing.T) {
+	first := &BitbucketResource{Info: BitbucketInfo{Workspace: "Team", Repository: "Repo", Path: "/scripts/", Ref: "main"}}
+	second := &BitbucketResource{Info: BitbucketInfo{Workspace: "team", Repository: "repo", Path: "scripts", Ref: "main"}}
+	other := &BitbucketResource{Info: BitbucketInfo{Workspace: "team", Repository: "repo", Path: "scripts", Ref: "release"}}
+
+	assert.Equal(t, first.LocationKey(), second.LocationKey())
+	assert.NotEqual(t, first.LocationKey(), other.LocationKey())
+}
@@ -0,0 +1,203 @@
+// Copyright 2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package bitbucketresource
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/aws/amazon-ssm-agent/agent/network"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/downloadcontent/remoteresource"
+)
+
+const (
+	// apiURL is the Bitbucket Cloud 2.0 API
+	apiURL = "https://api.bitbucket.org/2.0/"
+	// directoryPageSize is the number of entries of a directory listed per request, the most Bitbucket allows
+	directoryPageSize = 100
+
+	entryFile = "commit_file"
+	entryDir  = "commit_directory"
+)
+
+// sourceEntry is a file or directory of a repository as returned by the src API, Type is "commit_file" or "commit_directory"
+type sourceEntry struct {
+	Type string `json:"type"`
+	Path string `json:"path"`
+	Size int64  `json:"size"`
+}
+
+// bitbucketClient reads the repository of a Bitbucket Cloud workspace
+type bitbucketClient interface {
+	// GetEntry returns the file or directory at entryPath, found is false when there is none
+	GetEntry(log log.T, workspace, repository, ref, entryPath string) (entry sourceEntry, found bool, err error)
+	// ListDirectory returns the entries of the directory at dirPath, the root of the repository when empty
+	ListDirectory(log log.T, workspace, repository, ref, dirPath string) ([]sourceEntry, error)
+	// GetFileContent returns the content of the file at filePath
+	GetFileContent(log log.T, workspace, repository, ref, filePath string) (string, error)
+	// GetMainBranch returns the main branch of the repository
+	GetMainBranch(log log.T, workspace, repository string) (string, error)
+}
+
+// bitbucketAPI is a client of the Bitbucket Cloud API at baseURL authenticating with username and its app password, anonymous when empty
+type bitbucketAPI struct {
+	baseURL     string
+	username    string
+	appPassword string
+	httpClient  *http.Client
+}
+
+// newBitbucketAPI creates a client of the Bitbucket Cloud API, whose requests time out after the request timeout
+func newBitbucketAPI(username string, appPassword string) *bitbucketAPI {
+	return &bitbucketAPI{
+		baseURL:     apiURL,
+		username:    username,
+		appPassword: appPassword,
+		httpClient:  &http.Client{Transport: network.DefaultTransport(), Timeout: requestTimeout},
+	}
+}
+
+// GetEntry gets the metadata of the entry from the src API
+func (api *bitbucketAPI) GetEntry(log log.T, workspace, repository, ref, entryPath string) (entry sourceEntry, found bool, err error) {
+	resp, err := api.get(log, api.sourceURL(workspace, repository, ref, entryPath)+"?format=meta")
+	if err != nil {
+		return entry, false, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return entry, false, nil
+	}
+	if err = remoteresource.CheckResponse(resp, errorMessage); err != nil {
+		return entry, false, err
+	}
+	if err = json.NewDecoder(resp.Body).Decode(&entry); err != nil {
+		return entry, false, fmt.Errorf("%v of Bitbucket repository %v/%v could not be read - %v", entryPath, workspace, repository, err)
+	}
+	return entry, true, nil
+}
+
+// ListDirectory lists the directory with the src API, following its pages
+func (api *bitbucketAPI) ListDirectory(log log.T, workspace, repository, ref, dirPath string) (entries []sourceEntry, err error) {
+	pageURL := fmt.Sprintf("%v/?pagelen=%v", strings.TrimSuffix(api.sourceURL(workspace, repository, ref, dirPath), "/"), directoryPageSize)
+	for pageURL != "" {
+		resp, err := api.get(log, pageURL)
+		if err != nil {
+			return nil, err
+		}
+		var page struct {
+			Values []sourceEntry `json:"values"`
+			Next   string        `json:"next"`
+		}
+		if err = remoteresource.CheckResponse(resp, errorMessage); err == nil {
+			err = json.NewDecoder(resp.Body).Decode(&page)
+		}
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("Directory %v of Bitbucket repository %v/%v could not be listed - %v", dirPath, workspace, repository, err)
+		}
+		entries = append(entries, page.Values...)
+		pageURL = page.Next
+	}
+	return entries, nil
+}
+
+// GetFileContent gets the raw content of the file from the src API
+func (api *bitbucketAPI) GetFileContent(log log.T, workspace, repository, ref, filePath string) (string, error) {
+	resp, err := api.get(log, api.sourceURL(workspace, repository, ref, filePath))
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if err = remoteresource.CheckResponse(resp, errorMessage); err != nil {
+		return "", fmt.Errorf("File %v of Bitbucket repository %v/%v could not be read - %v", filePath, workspace, repository, err)
+	}
+	content, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", network.ClassifyError(err)
+	}
+	return string(content), nil
+}
+
+// GetMainBranch reads the main branch from the repositories API
+func (api *bitbucketAPI) GetMainBranch(log log.T, workspace, repository string) (string, error) {
+	resp, err := api.get(log, api.baseURL+"repositories/"+url.PathEscape(workspace)+"/"+url.PathEscape(repository))
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if err = remoteresource.CheckResponse(resp, errorMessage); err != nil {
+		return "", fmt.Errorf("Bitbucket repository %v/%v could not be read - %v", workspace, repository, err)
+	}
+	var repositoryInfo struct {
+		MainBranch struct {
+			Name string `json:"name"`
+		} `json:"mainbranch"`
+	}
+	if err = json.NewDecoder(resp.Body).Decode(&repositoryInfo); err != nil {
+		return "", fmt.Errorf("Bitbucket repository %v/%v could not be read - %v", workspace, repository, err)
+	}
+	if repositoryInfo.MainBranch.Name == "" {
+		return "", fmt.Errorf("Bitbucket repository %v/%v has no main branch, specify a ref", workspace, repository)
+	}
+	return repositoryInfo.MainBranch.Name, nil
+}
+
+// sourceURL returns the URL of the src API for the entry at entryPath, whose segments are escaped one by one
+func (api *bitbucketAPI) sourceURL(workspace, repository, ref, entryPath string) string {
+	var segments []string
+	for _, segment := range strings.Split(entryPath, "/") {
+		if segment != "" {
+			segments = append(segments, url.PathEscape(segment))
+		}
+	}
+	return api.baseURL + "repositories/" + url.PathEscape(workspace) + "/" + url.PathEscape(repository) +
+		"/src/" + url.PathEscape(ref) + "/" + strings.Join(segments, "/")
+}
+
+// get sends a GET request to requestURL
+func (api *bitbucketAPI) get(log log.T, requestURL string) (*http.Response, error) {
+	req, err := http.NewRequest("GET", requestURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	if api.username != "" {
+		// NOTE: Do not log the app password
+		req.SetBasicAuth(api.username, api.appPassword)
+	}
+	log.Debugf("Requesting %v from Bitbucket", req.URL.Path)
+	resp, err := api.httpClient.Do(req)
+	if err != nil {
+		return nil, network.ClassifyError(err)
+	}
+	return resp, nil
+}
+
+// errorMessage returns the message of the error Bitbucket answered with
+func errorMessage(body []byte) string {
+	var answer struct {
+		Error struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if json.Unmarshal(body, &answer) != nil {
+		return ""
+	}
+	return answer.Error.Message
+}
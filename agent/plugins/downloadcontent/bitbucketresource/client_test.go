@@ -0,0 +1,139 @@
+// Copyright 2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package bitbucketresource
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestAPI returns a client of a local test server of the Bitbucket API
+func newTestAPI(handler http.HandlerFunc, username string, appPassword string) (*bitbucketAPI, *httptest.Server) {
+	server := httptest.NewServer(handler)
+	api := newBitbucketAPI(username, appPassword)
+	api.baseURL = server.URL + "/2.0/"
+	api.httpClient = server.Client()
+	return api, server
+}
+
+func TestBitbucketAPI_GetEntry(t *testing.T) {
+	api, server := newTestAPI(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/2.0/repositories/team/repo/src/release%2F1.0/scripts/run%20me.sh", r.URL.EscapedPath())
+		assert.Equal(t, "meta", r.URL.Query().Get("format"))
+		username, password, ok := r.BasicAuth()
+		assert.True(t, ok)
+		assert.Equal(t, "user", username)
+		assert.Equal(t, "app-password", password)
+		w.Write([]byte(`{"type": "commit_file", "path": "scripts/run me.sh", "size": 7}`))
+	}, "user", "app-password")
+	defer server.Close()
+
+	entry, found, err := api.GetEntry(logMock, "team", "repo", "release/1.0", "scripts/run me.sh")
+
+	assert.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, sourceEntry{Type: entryFile, Path: "scripts/run me.sh", Size: 7}, entry)
+}
+
+func TestBitbucketAPI_GetEntryNotFound(t *testing.T) {
+	api, server := newTestAPI(func(w http.ResponseWriter, r *http.Request) {
+		_, _, ok := r.BasicAuth()
+		assert.False(t, ok)
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"type": "error", "error": {"message": "No such file or directory: missing"}}`))
+	}, "", "")
+	defer server.Close()
+
+	_, found, err := api.GetEntry(logMock, "team", "repo", "main", "missing")
+
+	assert.NoError(t, err)
+	assert.False(t, found)
+}
+
+func TestBitbucketAPI_GetEntryUnauthorized(t *testing.T) {
+	api, server := newTestAPI(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		w.Write([]byte(`{"type": "error", "error": {"message": "Access denied"}}`))
+	}, "user", "revoked-password")
+	defer server.Close()
+
+	_, found, err := api.GetEntry(logMock, "team", "repo", "main", "scripts/run.sh")
+
+	assert.EqualError(t, err, "403 Forbidden Access denied")
+	assert.False(t, found)
+}
+
+func TestBitbucketAPI_ListDirectoryFollowsPages(t *testing.T) {
+	var server *httptest.Server
+	api, server := newTestAPI(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/2.0/repositories/team/repo/src/main/scripts/", r.URL.Path)
+		if r.URL.Query().Get("page") == "" {
+			assert.Equal(t, "100", r.URL.Query().Get("pagelen"))
+			w.Write([]byte(`{"values": [{"type": "commit_directory", "path": "scripts/lib"}], "next": "` + server.URL + `/2.0/repositories/team/repo/src/main/scripts/?pagelen=100&page=2"}`))
+			return
+		}
+		assert.Equal(t, "2", r.URL.Query().Get("page"))
+		w.Write([]byte(`{"values": [{"type": "commit_file", "path": "scripts/run.sh", "size": 7}]}`))
+	}, "", "")
+	defer server.Close()
+
+	entries, err := api.ListDirectory(logMock, "team", "repo", "main", "scripts")
+
+	assert.NoError(t, err)
+	assert.Equal(t, []sourceEntry{{entryDir, "scripts/lib", 0}, {entryFile, "scripts/run.sh", 7}}, entries)
+}
+
+func TestBitbucketAPI_ListRepositoryRoot(t *testing.T) {
+	api, server := newTestAPI(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/2.0/repositories/team/repo/src/main/", r.URL.Path)
+		w.Write([]byte(`{"values": [{"type": "commit_file", "path": "README.md", "size": 7}]}`))
+	}, "", "")
+	defer server.Close()
+
+	entries, err := api.ListDirectory(logMock, "team", "repo", "main", "")
+
+	assert.NoError(t, err)
+	assert.Equal(t, []sourceEntry{{entryFile, "README.md", 7}}, entries)
+}
+
+func TestBitbucketAPI_GetFileContent(t *testing.T) {
+	api, server := newTestAPI(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/2.0/repositories/team/repo/src/main/scripts/run.sh", r.URL.Path)
+		assert.Empty(t, r.URL.Query().Get("format"))
+		w.Write([]byte("content"))
+	}, "", "")
+	defer server.Close()
+
+	content, err := api.GetFileContent(logMock, "team", "repo", "main", "scripts/run.sh")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "content", content)
+}
+
+func TestBitbucketAPI_GetMainBranch(t *testing.T) {
+	api, server := newTestAPI(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/2.0/repositories/team/repo", r.URL.Path)
+		w.Write([]byte(`{"slug": "repo", "mainbranch": {"type": "branch", "name": "master"}}`))
+	}, "", "")
+	defer server.Close()
+
+	branch, err := api.GetMainBranch(logMock, "team", "repo")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "master", branch)
+}
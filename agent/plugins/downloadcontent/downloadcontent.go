@@ -19,9 +19,13 @@ import (
 	"github.com/aws/amazon-ssm-agent/agent/appconfig"
 	"github.com/aws/amazon-ssm-agent/agent/context"
 	"github.com/aws/amazon-ssm-agent/agent/contracts"
+	"github.com/aws/amazon-ssm-agent/agent/executers"
+	"github.com/aws/amazon-ssm-agent/agent/fileutil/downloadcleanup"
 	"github.com/aws/amazon-ssm-agent/agent/fileutil/filemanager"
 	"github.com/aws/amazon-ssm-agent/agent/jsonutil"
 	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/downloadcontent/bitbucketresource"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/downloadcontent/gitlabresource"
 	"github.com/aws/amazon-ssm-agent/agent/plugins/downloadcontent/gitresource"
 	"github.com/aws/amazon-ssm-agent/agent/plugins/downloadcontent/gitresource/privategithub"
 	"github.com/aws/amazon-ssm-agent/agent/plugins/downloadcontent/remoteresource"
@@ -39,7 +43,9 @@ import (
 )
 
 const (
+	Bitbucket   = "Bitbucket"   //Bitbucket represents the source type "Bitbucket" from where the resource can be downloaded
 	GitHub      = "GitHub"      //Github represents the source type "GitHub" from where the resource can be downloaded
+	GitLab      = "GitLab"      //GitLab represents the source type "GitLab" from where the resource can be downloaded
 	S3          = "S3"          //S3 represents the source type "S3" from where the resource is being downloaded
 	SSMDocument = "SSMDocument" //SSMDocument represents the source type as SSM Document
 
@@ -51,10 +57,26 @@ const (
 
 var SetPermission = SetFilePermissions
 
+func init() {
+	remoteresource.RegisterCommonOptions(sourceOptions{})
+	remoteresource.RegisterSourceType(Bitbucket, bitbucketresource.BitbucketInfo{})
+	remoteresource.RegisterSourceType(GitHub, gitresource.GitInfo{}, gitresource.Features...)
+	remoteresource.RegisterSourceType(GitLab, gitlabresource.GitLabInfo{})
+	remoteresource.RegisterSourceType(S3, s3resource.S3Info{})
+	remoteresource.RegisterSourceType(SSMDocument, ssmdocresource.SSMDocInfo{})
+}
+
 // NewPlugin returns a new instance of the plugin.
 func NewPlugin() (*Plugin, error) {
 	var plugin Plugin
 	plugin.remoteResourceCreator = newRemoteResource
+	plugin.CommandExecuter = executers.ShellCommandExecuter{}
+	plugin.auditSink = remoteresource.SharedAuditSink()
+	plugin.executionStore = remoteresource.SharedExecutionStore()
+	plugin.allowedDestinationRoots = []string{appconfig.DownloadRoot}
+	if appCfg, err := appconfig.Config(false); err == nil && len(appCfg.RemoteResource.AllowedDestinationRoots) > 0 {
+		plugin.allowedDestinationRoots = appCfg.RemoteResource.AllowedDestinationRoots
+	}
 	return &plugin, nil
 }
 
@@ -62,6 +84,15 @@ func NewPlugin() (*Plugin, error) {
 type Plugin struct {
 	remoteResourceCreator func(log log.T, sourceType string, SourceInfo string) (remoteresource.RemoteResource, error)
 	filesys               filemanager.FileSystem
+	// allowedDestinationRoots are the directories, besides the command download directory, content may be saved to.
+	// Destinations are not restricted when it is nil.
+	allowedDestinationRoots []string
+	// CommandExecuter runs the post download command of the source
+	CommandExecuter executers.T
+	// auditSink records every download, downloads aren't audited when it is nil
+	auditSink remoteresource.AuditSink
+	// executionStore remembers the content of the last successful run of downloads with skipIfUnchanged
+	executionStore *remoteresource.ExecutionStore
 }
 
 // ExecutePluginInput is a struct that holds the parameters sent through send command
@@ -70,18 +101,73 @@ type DownloadContentPlugin struct {
 	SourceType      string `json:"sourceType"`
 	SourceInfo      string `json:"sourceInfo"`
 	DestinationPath string `json:"destinationPath"`
+	// AdditionalSources are downloaded along with SourceInfo, concurrently unless they are downloaded after it
+	AdditionalSources []AdditionalSource `json:"additionalSources"`
 	// TODO: 08/25/2017 meloniam@ Change the type of SourceInfo and documentParameters to map[string]interface{}
 	// TODO: https://amazon.awsapps.com/workdocs/index.html#/document/7d56a42ea5b040a7c33548d77dc98040f0fb380bbbfb2fd580c861225e2ee1c7
 }
 
+// AdditionalSource is more content downloaded by the plugin, its destination is resolved like DestinationPath.
+// Only optional applies to its SourceInfo, skipIfUnchanged and postDownloadCommand are rejected.
+type AdditionalSource struct {
+	SourceType      string `json:"sourceType"`
+	SourceInfo      string `json:"sourceInfo"`
+	DestinationPath string `json:"destinationPath"`
+	// AfterSource downloads the source once the source of the plugin is downloaded, e.g. the scripts of a document it downloads.
+	// It isn't downloaded when the source of the plugin fails.
+	AfterSource bool `json:"afterSource"`
+}
+
+// auditedSource downloads a source of the plugin through the audit log when downloaded with remoteresource.DownloadAll
+type auditedSource struct {
+	remoteresource.RemoteResource
+	sink   remoteresource.AuditSink
+	record remoteresource.AuditRecord
+	// downloaded is set once the source is downloaded
+	downloaded bool
+}
+
+// Download downloads the source and records it in the audit log
+func (source *auditedSource) Download(log log.T, filesys filemanager.FileSystem, destinationDir string) error {
+	if err := remoteresource.DownloadAudited(log, source.sink, source.record, source.RemoteResource, filesys, destinationDir); err != nil {
+		return err
+	}
+	source.downloaded = true
+	return nil
+}
+
+// LocationKey lets identical sources share a download, it is empty for resources without a location key
+func (source *auditedSource) LocationKey() string {
+	if keyed, ok := source.RemoteResource.(remoteresource.KeyedResource); ok {
+		return keyed.LocationKey()
+	}
+	return ""
+}
+
+// sourceOptions are the settings of SourceInfo shared by all source types
+type sourceOptions struct {
+	// Optional turns a failed download into a warning, the document then proceeds without the content
+	Optional bool `json:"optional"`
+	// SkipIfUnchanged skips the post download command when the content is the same as on its last successful run
+	SkipIfUnchanged bool `json:"skipIfUnchanged"`
+	// PostDownloadHook runs after the content is downloaded, its failure fails the plugin
+	remoteresource.PostDownloadHook
+}
+
 // newRemoteResource switches between the source type and returns a struct of the source type that implements remoteresource
 func newRemoteResource(log log.T, SourceType string, SourceInfo string) (resource remoteresource.RemoteResource, err error) {
 	switch SourceType {
+	case Bitbucket:
+		// Bitbucket app passwords are stored like GitHub tokens
+		return bitbucketresource.NewBitbucketResource(log, SourceInfo, privategithub.NewTokenInfoImpl())
 	case GitHub:
 		// TODO: meloniam@ 08/24/2017 Replace string type to map[string]inteface{} type once Runcommand supports string maps
 		// TODO: https://amazon.awsapps.com/workdocs/index.html#/document/7d56a42ea5b040a7c33548d77dc98040f0fb380bbbfb2fd580c861225e2ee1c7
 		token := privategithub.NewTokenInfoImpl()
 		return gitresource.NewGitResource(log, SourceInfo, token)
+	case GitLab:
+		// GitLab tokens are stored like GitHub ones
+		return gitlabresource.NewGitLabResource(log, SourceInfo, privategithub.NewTokenInfoImpl())
 	case S3:
 		return s3resource.NewS3Resource(log, SourceInfo)
 	case SSMDocument:
@@ -109,56 +195,171 @@ func (p *Plugin) execute(context context.T, config contracts.Configuration, canc
 	} else if input, err := parseAndValidateInput(config.Properties); err != nil {
 		output.MarkAsFailed(err)
 	} else {
-		p.runCopyContent(log, input, config, output)
+		p.runCopyContent(log, input, config, cancelFlag, output)
 	}
 }
 
 // runCopyContent figures out the type of source, downloads the resource, saves it on disk and returns information required for it
-func (p *Plugin) runCopyContent(log log.T, input *DownloadContentPlugin, config contracts.Configuration, output iohandler.IOHandler) {
+func (p *Plugin) runCopyContent(log log.T, input *DownloadContentPlugin, config contracts.Configuration, cancelFlag task.CancelFlag, output iohandler.IOHandler) {
 
 	//Run aws:downloadContent plugin
 	log.Debug("Inside run downloadcontent function")
 
-	// remoteResourceCreator makes a call to a function that creates a new remote resource based on the source type
-	log.Debug("Creating resource of type - ", input.SourceType)
-	remoteResource, err := p.remoteResourceCreator(log, input.SourceType, input.SourceInfo)
+	remoteResource, destinationPath, err := p.prepareSource(log, input.SourceType, input.SourceInfo, input.DestinationPath, config)
 	if err != nil {
 		output.MarkAsFailed(err)
 		return
 	}
-	var destinationPath string
+	var options sourceOptions
+	jsonutil.Unmarshal(input.SourceInfo, &options)
+	log.Debug("Downloading resource")
+	// keep the content from being cleaned up as a stale artifact while it is downloaded
+	defer downloadcleanup.MarkInUse(destinationPath)()
+	auditRecord := remoteresource.AuditRecord{
+		MessageID:  config.MessageId,
+		PluginID:   config.PluginID,
+		SourceType: input.SourceType,
+	}
+	if len(input.AdditionalSources) > 0 {
+		downloaded, destinations, err := p.downloadWithAdditionalSources(log, input, config, auditRecord, remoteResource, destinationPath, options.Optional)
+		if err != nil {
+			output.MarkAsFailed(err)
+			return
+		}
+		for _, destination := range destinations {
+			if err := SetPermission(log, destination); err != nil {
+				output.MarkAsFailed(fmt.Errorf("Failed to set right permissions to the content. Error - %v", err))
+				return
+			}
+			output.AppendInfof("Content downloaded to %v", destination)
+		}
+		if !downloaded {
+			output.AppendInfof("Optional content could not be downloaded to %v, continuing without it", destinationPath)
+			output.MarkAsSucceeded()
+			return
+		}
+	} else if err = remoteresource.DownloadAudited(log, p.auditSink, auditRecord, remoteResource, p.filesys, destinationPath); err != nil {
+		if options.Optional {
+			log.Warnf("Optional content could not be downloaded, continuing without it - %v", err)
+			output.AppendInfof("Optional content could not be downloaded to %v, continuing without it - %v", destinationPath, err)
+			output.MarkAsSucceeded()
+			return
+		}
+		output.MarkAsFailed(err)
+		return
+	}
+
+	if err := SetPermission(log, destinationPath); err != nil {
+		output.MarkAsFailed(fmt.Errorf("Failed to set right permissions to the content. Error - %v", err))
+		return
+	}
+
+	var executionKey, contentHash string
+	if options.SkipIfUnchanged && p.executionStore != nil {
+		executionKey = remoteresource.ExecutionKey(input.SourceType, input.SourceInfo, destinationPath)
+		if contentHash, err = remoteresource.ContentHash(destinationPath); err != nil {
+			output.MarkAsFailed(err)
+			return
+		}
+		if !p.executionStore.HasChanged(log, executionKey, contentHash) {
+			output.AppendInfof("Content downloaded to %v is unchanged since its last successful run", destinationPath)
+			output.MarkAsSucceeded()
+			return
+		}
+	}
+
+	if options.PostDownloadHook.Command != "" {
+		if err := options.PostDownloadHook.Run(log, p.filesys, p.CommandExecuter, cancelFlag, destinationPath); err != nil {
+			output.MarkAsFailed(err)
+			return
+		}
+	}
+	if executionKey != "" {
+		p.executionStore.RecordExecution(log, executionKey, contentHash)
+	}
+
+	output.AppendInfof("Content downloaded to %v", destinationPath)
+	output.MarkAsSucceeded()
+	return
+}
+
+// prepareSource creates the remote resource of a source, validates its location and resolves where it is downloaded
+func (p *Plugin) prepareSource(log log.T, sourceType string, sourceInfo string, destination string, config contracts.Configuration) (remoteResource remoteresource.RemoteResource, destinationPath string, err error) {
+	// remoteResourceCreator makes a call to a function that creates a new remote resource based on the source type
+	log.Debug("Creating resource of type - ", sourceType)
+	if remoteResource, err = p.remoteResourceCreator(log, sourceType, sourceInfo); err != nil {
+		return nil, "", err
+	}
+	// the destination may be composed from the parameters of the command
+	if destinationPath, err = remoteresource.ResolveDestination(log, destination, config.Parameters); err != nil {
+		return nil, "", err
+	}
+	orchestrationDir := strings.TrimSuffix(config.OrchestrationDirectory, config.PluginID)
 
 	// If path is absolute, then download to the path,
 	// else download to orchestrationDir/<downloads dir>/relative path
-	if filepath.IsAbs(input.DestinationPath) {
-		destinationPath = input.DestinationPath
-	} else {
+	if !filepath.IsAbs(destinationPath) {
 		log.Debugf("PluginId, plugin name, orch dir  - %v, %v, %v ", config.PluginID, config.PluginName, config.OrchestrationDirectory)
-		orchestrationDir := strings.TrimSuffix(config.OrchestrationDirectory, config.PluginID)
 
 		// The reason for not using Join or Buildpath here is so that the trailing "\" in case of windows is not dropped.
-		destinationPath = filepath.Join(orchestrationDir, downloadsDir) + string(os.PathSeparator) + input.DestinationPath
+		destinationPath = filepath.Join(orchestrationDir, downloadsDir) + string(os.PathSeparator) + destinationPath
+	}
+
+	if p.allowedDestinationRoots != nil {
+		allowedRoots := append([]string{filepath.Join(orchestrationDir, downloadsDir)}, p.allowedDestinationRoots...)
+		if err = remoteresource.ValidateDestination(destinationPath, allowedRoots); err != nil {
+			return nil, "", err
+		}
 	}
 
 	log.Debug("About to validate source info")
 	if valid, err := remoteResource.ValidateLocationInfo(); !valid {
-		output.MarkAsFailed(err)
-		return
-	}
-	log.Debug("Downloading resource")
-	if err = remoteResource.Download(log, p.filesys, destinationPath); err != nil {
-		output.MarkAsFailed(err)
-		return
+		return nil, "", err
 	}
+	return remoteResource, destinationPath, nil
+}
 
-	if err := SetPermission(log, destinationPath); err != nil {
-		output.MarkAsFailed(fmt.Errorf("Failed to set right permissions to the content. Error - %v", err))
-		return
+// downloadWithAdditionalSources downloads the source of the plugin and its additional sources concurrently, the ones
+// downloaded after it wait for it. It returns whether the source of the plugin was downloaded, which only an optional one may not be,
+// and the destinations of the additional sources downloaded.
+func (p *Plugin) downloadWithAdditionalSources(log log.T, input *DownloadContentPlugin, config contracts.Configuration, record remoteresource.AuditRecord,
+	remoteResource remoteresource.RemoteResource, destinationPath string, optional bool) (downloaded bool, destinations []string, err error) {
+	main := &auditedSource{RemoteResource: remoteResource, sink: p.auditSink, record: record}
+	sources := []*auditedSource{main}
+	requests := []remoteresource.DownloadRequest{{Resource: main, DestinationDir: destinationPath, Optional: optional}}
+	for i, additional := range input.AdditionalSources {
+		resource, destination, err := p.prepareSource(log, additional.SourceType, additional.SourceInfo, additional.DestinationPath, config)
+		if err != nil {
+			return false, nil, fmt.Errorf("additional source %v: %v", i, err)
+		}
+		var options sourceOptions
+		jsonutil.Unmarshal(additional.SourceInfo, &options)
+		if options.SkipIfUnchanged || options.PostDownloadHook.Command != "" {
+			return false, nil, fmt.Errorf("additional source %v: skipIfUnchanged and postDownloadCommand only apply to the source of the plugin", i)
+		}
+		sourceRecord := record
+		sourceRecord.SourceType = additional.SourceType
+		source := &auditedSource{RemoteResource: resource, sink: p.auditSink, record: sourceRecord}
+		request := remoteresource.DownloadRequest{Resource: source, DestinationDir: destination, Optional: options.Optional}
+		if additional.AfterSource {
+			request.After = []int{0}
+		}
+		// keep the content from being cleaned up as a stale artifact while it is downloaded
+		defer downloadcleanup.MarkInUse(destination)()
+		sources = append(sources, source)
+		requests = append(requests, request)
 	}
 
-	output.AppendInfof("Content downloaded to %v", destinationPath)
-	output.MarkAsSucceeded()
-	return
+	log.Debugf("Downloading %v sources", len(requests))
+	if err = remoteresource.DownloadAll(log, p.filesys, requests, 0); err != nil {
+		return false, nil, err
+	}
+	for i, source := range sources[1:] {
+		if source.downloaded {
+			destinations = append(destinations, requests[i+1].DestinationDir)
+		}
+	}
+	return main.downloaded, destinations, nil
 }
 
 // Name returns the plugin name
@@ -183,19 +384,32 @@ func parseAndValidateInput(rawPluginInput interface{}) (*DownloadContentPlugin,
 
 // validateInput ensures the plugin input matches the defined schema
 func validateInput(input *DownloadContentPlugin) (valid bool, err error) {
+	if err = validateSource(input.SourceType, input.SourceInfo); err != nil {
+		return false, err
+	}
+	for i, additional := range input.AdditionalSources {
+		if err = validateSource(additional.SourceType, additional.SourceInfo); err != nil {
+			return false, fmt.Errorf("additional source %v: %v", i, err)
+		}
+	}
+	return true, nil
+}
+
+// validateSource ensures a source has a supported type and its info
+func validateSource(sourceType string, sourceInfo string) error {
 	// ensure non-empty source type
-	if input.SourceType == "" {
-		return false, errors.New("SourceType must be specified")
+	if sourceType == "" {
+		return errors.New("SourceType must be specified")
 	}
 	//ensure all entries are valid
-	if input.SourceType != GitHub && input.SourceType != S3 && input.SourceType != SSMDocument {
-		return false, errors.New("Unsupported source type")
+	if sourceType != Bitbucket && sourceType != GitHub && sourceType != GitLab && sourceType != S3 && sourceType != SSMDocument {
+		return errors.New("Unsupported source type")
 	}
 	// ensure non-empty source info
-	if input.SourceInfo == "" {
-		return false, errors.New("SourceInfo must be specified")
+	if sourceInfo == "" {
+		return errors.New("SourceInfo must be specified")
 	}
-	return true, nil
+	return nil
 }
 
 // SetFilePermissions applies execute permissions to the folder
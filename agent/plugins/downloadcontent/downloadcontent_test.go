@@ -20,12 +20,21 @@ import (
 
 	"time"
 
+	"encoding/json"
 	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
 
 	"github.com/aws/amazon-ssm-agent/agent/context"
 	"github.com/aws/amazon-ssm-agent/agent/contracts"
+	"github.com/aws/amazon-ssm-agent/agent/executers"
+	"github.com/aws/amazon-ssm-agent/agent/fileutil/filemanager"
 	filemock "github.com/aws/amazon-ssm-agent/agent/fileutil/filemanager/mock"
 	"github.com/aws/amazon-ssm-agent/agent/framework/processor/executer/iohandler/mock"
+	"github.com/aws/amazon-ssm-agent/agent/jsonutil"
 	"github.com/aws/amazon-ssm-agent/agent/log"
 	"github.com/aws/amazon-ssm-agent/agent/plugins/downloadcontent/remoteresource"
 	resourcemock "github.com/aws/amazon-ssm-agent/agent/plugins/downloadcontent/remoteresource/mock"
@@ -88,6 +97,37 @@ func TestNewRemoteResource_SSMDocument(t *testing.T) {
 
 }
 
+func TestCapabilities_BuiltInSourceTypes(t *testing.T) {
+	report := remoteresource.Capabilities()
+
+	assert.Equal(t, remoteresource.CapabilitiesSchemaVersion, report.SchemaVersion)
+	assert.Contains(t, report.CommonOptions, "optional")
+	assert.Contains(t, report.CommonOptions, "postDownloadCommand")
+	git, found := report.SourceType(GitHub)
+	assert.True(t, found)
+	assert.Contains(t, git.Options, "owner")
+	assert.Contains(t, git.Options, "verifySignature")
+	assert.Contains(t, git.Features, "detachedSignatureVerification")
+	s3, found := report.SourceType(S3)
+	assert.True(t, found)
+	assert.Equal(t, []string{"path"}, s3.Options)
+	_, found = report.SourceType(SSMDocument)
+	assert.True(t, found)
+	gitlab, found := report.SourceType(GitLab)
+	assert.True(t, found)
+	assert.Contains(t, gitlab.Options, "project")
+	assert.Contains(t, gitlab.Options, "ref")
+	bitbucket, found := report.SourceType(Bitbucket)
+	assert.True(t, found)
+	assert.Contains(t, bitbucket.Options, "workspace")
+	assert.Contains(t, bitbucket.Options, "username")
+
+	serialized, err := jsonutil.Marshal(report)
+	assert.NoError(t, err)
+	assert.Contains(t, serialized, `"sourceType":"GitHub"`)
+	assert.Contains(t, serialized, `"sourceType":"S3"`)
+}
+
 func TestNewPlugin_RunCopyContent(t *testing.T) {
 
 	fileMock := filemock.FileSystemMock{}
@@ -107,7 +147,7 @@ func TestNewPlugin_RunCopyContent(t *testing.T) {
 	mockIOHandler.On("MarkAsSucceeded").Return()
 
 	SetPermission = stubChmod
-	p.runCopyContent(logger, &input, config, mockIOHandler)
+	p.runCopyContent(logger, &input, config, createMockCancelFlag(), mockIOHandler)
 
 	copyContentResourceMock.AssertExpectations(t)
 	fileMock.AssertExpectations(t)
@@ -133,7 +173,7 @@ func TestNewPlugin_RunCopyContent_absPathDestinationDir(t *testing.T) {
 	mockIOHandler.On("MarkAsSucceeded").Return()
 
 	SetPermission = stubChmod
-	p.runCopyContent(logger, &input, config, mockIOHandler)
+	p.runCopyContent(logger, &input, config, createMockCancelFlag(), mockIOHandler)
 
 	copyContentResourceMock.AssertExpectations(t)
 	fileMock.AssertExpectations(t)
@@ -158,7 +198,7 @@ func TestNewPlugin_RunCopyContent_relativeDirDestinationPath(t *testing.T) {
 	mockIOHandler.On("MarkAsSucceeded").Return()
 
 	SetPermission = stubChmod
-	p.runCopyContent(logger, &input, config, mockIOHandler)
+	p.runCopyContent(logger, &input, config, createMockCancelFlag(), mockIOHandler)
 
 	copyContentResourceMock.AssertExpectations(t)
 	fileMock.AssertExpectations(t)
@@ -188,12 +228,358 @@ func Test_RunCopyContentBadLocationInfo(t *testing.T) {
 	}
 	mockIOHandler.On("MarkAsFailed", mock.Anything).Return()
 
-	p.runCopyContent(logger, &input, config, mockIOHandler)
+	p.runCopyContent(logger, &input, config, createMockCancelFlag(), mockIOHandler)
 
 	fileMock.AssertExpectations(t)
 	mockIOHandler.AssertExpectations(t)
 }
 
+func TestPlugin_RunCopyContentOptionalSource(t *testing.T) {
+	data := []struct {
+		name             string
+		sourceInfo       string
+		valid            bool
+		downloadErr      error
+		expectedSucceed  bool
+		expectedDownload bool
+	}{
+		{"fail closed by default", `{"owner": "owner"}`, true, errors.New("unreachable"), false, true},
+		{"fail closed when not optional", `{"owner": "owner", "optional": false}`, true, errors.New("unreachable"), false, true},
+		{"best effort when optional", `{"owner": "owner", "optional": true}`, true, errors.New("unreachable"), true, true},
+		{"optional download succeeds", `{"owner": "owner", "optional": true}`, true, nil, true, true},
+		{"optional source must still be valid", `{"optional": true}`, false, nil, false, false},
+	}
+	for _, testdata := range data {
+		t.Run(testdata.name, func(t *testing.T) {
+			fileMock := filemock.FileSystemMock{}
+			resourceMock := resourcemock.RemoteResourceMock{}
+			mockIOHandler := new(iohandlermocks.MockIOHandler)
+
+			var validationErr error
+			if !testdata.valid {
+				validationErr = errors.New("owner must be specified")
+			}
+			resourceMock.On("ValidateLocationInfo").Return(testdata.valid, validationErr).Once()
+			if testdata.expectedDownload {
+				resourceMock.On("Download", logger, fileMock, mock.Anything).Return(testdata.downloadErr).Once()
+			}
+			if testdata.expectedSucceed {
+				mockIOHandler.On("AppendInfof", mock.Anything, mock.Anything).Return()
+				mockIOHandler.On("MarkAsSucceeded").Return()
+			} else {
+				mockIOHandler.On("MarkAsFailed", mock.Anything).Return()
+			}
+
+			input := DownloadContentPlugin{
+				SourceType:      "GitHub",
+				SourceInfo:      testdata.sourceInfo,
+				DestinationPath: "/var/tmp/destination",
+			}
+			p := Plugin{
+				remoteResourceCreator: func(log log.T, sourceType string, sourceInfo string) (remoteresource.RemoteResource, error) {
+					return resourceMock, nil
+				},
+				filesys: fileMock,
+			}
+			SetPermission = stubChmod
+			p.runCopyContent(logger, &input, createStubConfiguration("orch", "bucket", "prefix", "1234-1234-1234", "directory"), createMockCancelFlag(), mockIOHandler)
+
+			resourceMock.AssertExpectations(t)
+			mockIOHandler.AssertExpectations(t)
+		})
+	}
+}
+
+type auditSinkStub struct {
+	records []remoteresource.AuditRecord
+	lock    sync.Mutex
+}
+
+func (sink *auditSinkStub) Record(record remoteresource.AuditRecord) error {
+	sink.lock.Lock()
+	defer sink.lock.Unlock()
+	sink.records = append(sink.records, record)
+	return nil
+}
+
+func TestPlugin_RunCopyContentAudit(t *testing.T) {
+	data := []struct {
+		name            string
+		downloadErr     error
+		expectedOutcome string
+	}{
+		{"success is audited", nil, remoteresource.AuditSucceeded},
+		{"failure is audited", errors.New("unreachable"), remoteresource.AuditFailed},
+	}
+	for _, testdata := range data {
+		t.Run(testdata.name, func(t *testing.T) {
+			fileMock := filemock.FileSystemMock{}
+			resourceMock := resourcemock.RemoteResourceMock{}
+			mockIOHandler := new(iohandlermocks.MockIOHandler)
+			sink := &auditSinkStub{}
+
+			resourceMock.On("ValidateLocationInfo").Return(true, nil).Once()
+			resourceMock.On("Download", logger, mock.Anything, mock.Anything).Return(testdata.downloadErr).Once()
+			if testdata.downloadErr == nil {
+				mockIOHandler.On("AppendInfof", mock.Anything, mock.Anything).Return()
+				mockIOHandler.On("MarkAsSucceeded").Return()
+			} else {
+				mockIOHandler.On("MarkAsFailed", mock.Anything).Return()
+			}
+
+			input := DownloadContentPlugin{
+				SourceType:      "GitHub",
+				SourceInfo:      `{"owner": "owner", "tokenInfo": "{{ssm-secure:github-token}}"}`,
+				DestinationPath: "/var/tmp/destination",
+			}
+			p := Plugin{
+				remoteResourceCreator: func(log log.T, sourceType string, sourceInfo string) (remoteresource.RemoteResource, error) {
+					return resourceMock, nil
+				},
+				filesys:   fileMock,
+				auditSink: sink,
+			}
+			SetPermission = stubChmod
+			p.runCopyContent(logger, &input, createStubConfiguration("orch", "bucket", "prefix", "1234-1234-1234", "directory"), createMockCancelFlag(), mockIOHandler)
+
+			resourceMock.AssertExpectations(t)
+			mockIOHandler.AssertExpectations(t)
+			assert.Len(t, sink.records, 1)
+			record := sink.records[0]
+			assert.Equal(t, "1234-1234-1234", record.MessageID)
+			assert.Equal(t, "aws-copyContent", record.PluginID)
+			assert.Equal(t, "GitHub", record.SourceType)
+			assert.Equal(t, "/var/tmp/destination", record.Destination)
+			assert.Equal(t, testdata.expectedOutcome, record.Outcome)
+			line, _ := json.Marshal(record)
+			assert.NotContains(t, string(line), "github-token")
+		})
+	}
+}
+
+func TestPlugin_RunCopyContentDestinationRoots(t *testing.T) {
+	data := []struct {
+		name             string
+		destinationPath  string
+		expectedDownload bool
+	}{
+		{"relative path within the command directory", "scripts", true},
+		{"absolute path within an allowed root", "/var/tmp/allowed/scripts", true},
+		{"absolute path out of the allowed roots", "/etc/cron.d", false},
+		{"relative path escaping the command directory", "../../../etc", false},
+	}
+	for _, testdata := range data {
+		t.Run(testdata.name, func(t *testing.T) {
+			fileMock := filemock.FileSystemMock{}
+			resourceMock := resourcemock.RemoteResourceMock{}
+			mockIOHandler := new(iohandlermocks.MockIOHandler)
+
+			if testdata.expectedDownload {
+				resourceMock.On("ValidateLocationInfo").Return(true, nil).Once()
+				resourceMock.On("Download", logger, fileMock, mock.Anything).Return(nil).Once()
+				mockIOHandler.On("AppendInfof", mock.Anything, mock.Anything).Return()
+				mockIOHandler.On("MarkAsSucceeded").Return()
+			} else {
+				mockIOHandler.On("MarkAsFailed", mock.Anything).Return()
+			}
+
+			input := DownloadContentPlugin{
+				SourceType:      "GitHub",
+				SourceInfo:      `{"owner": "owner"}`,
+				DestinationPath: testdata.destinationPath,
+			}
+			p := Plugin{
+				remoteResourceCreator: func(log log.T, sourceType string, sourceInfo string) (remoteresource.RemoteResource, error) {
+					return resourceMock, nil
+				},
+				filesys:                 fileMock,
+				allowedDestinationRoots: []string{"/var/tmp/allowed"},
+			}
+			SetPermission = stubChmod
+			p.runCopyContent(logger, &input, createStubConfiguration("orch", "bucket", "prefix", "1234-1234-1234", "directory"), createMockCancelFlag(), mockIOHandler)
+
+			resourceMock.AssertExpectations(t)
+			mockIOHandler.AssertExpectations(t)
+			if !testdata.expectedDownload {
+				resourceMock.AssertNotCalled(t, "Download", logger, fileMock, mock.Anything)
+			}
+		})
+	}
+}
+
+func TestPlugin_RunCopyContentParameterizedDestination(t *testing.T) {
+	data := []struct {
+		name                string
+		destinationPath     string
+		parameters          map[string]interface{}
+		expectedDestination string
+	}{
+		{"resolved within an allowed root", "{{ appDir }}/scripts", map[string]interface{}{"appDir": "/var/tmp/allowed/app"}, "/var/tmp/allowed/app/scripts"},
+		{"resolved out of the allowed roots", "{{ appDir }}/scripts", map[string]interface{}{"appDir": "/etc/cron.d"}, ""},
+		{"unresolved reference", "{{ appDir }}/scripts", map[string]interface{}{}, ""},
+	}
+	for _, testdata := range data {
+		t.Run(testdata.name, func(t *testing.T) {
+			fileMock := filemock.FileSystemMock{}
+			resourceMock := resourcemock.RemoteResourceMock{}
+			mockIOHandler := new(iohandlermocks.MockIOHandler)
+
+			if testdata.expectedDestination != "" {
+				resourceMock.On("ValidateLocationInfo").Return(true, nil).Once()
+				resourceMock.On("Download", logger, fileMock, testdata.expectedDestination).Return(nil).Once()
+				mockIOHandler.On("AppendInfof", mock.Anything, mock.Anything).Return()
+				mockIOHandler.On("MarkAsSucceeded").Return()
+			} else {
+				mockIOHandler.On("MarkAsFailed", mock.Anything).Return()
+			}
+
+			input := DownloadContentPlugin{
+				SourceType:      "GitHub",
+				SourceInfo:      `{"owner": "owner"}`,
+				DestinationPath: testdata.destinationPath,
+			}
+			p := Plugin{
+				remoteResourceCreator: func(log log.T, sourceType string, sourceInfo string) (remoteresource.RemoteResource, error) {
+					return resourceMock, nil
+				},
+				filesys:                 fileMock,
+				allowedDestinationRoots: []string{"/var/tmp/allowed"},
+			}
+			config := createStubConfiguration("orch", "bucket", "prefix", "1234-1234-1234", "directory")
+			config.Parameters = testdata.parameters
+			SetPermission = stubChmod
+			p.runCopyContent(logger, &input, config, createMockCancelFlag(), mockIOHandler)
+
+			resourceMock.AssertExpectations(t)
+			mockIOHandler.AssertExpectations(t)
+			if testdata.expectedDestination == "" {
+				resourceMock.AssertNotCalled(t, "Download", logger, fileMock, mock.Anything)
+			}
+		})
+	}
+}
+
+func TestPlugin_RunCopyContentPostDownloadCommand(t *testing.T) {
+	data := []struct {
+		name            string
+		sourceInfo      string
+		expectedHook    bool
+		exitCode        int
+		expectedSucceed bool
+	}{
+		{"no post download command", `{"owner": "owner"}`, false, 0, true},
+		{"post download command succeeds", `{"owner": "owner", "postDownloadCommand": "chmod +x *.sh"}`, true, 0, true},
+		{"post download command fails", `{"owner": "owner", "postDownloadCommand": "chmod +x *.sh"}`, true, 1, false},
+	}
+	for _, testdata := range data {
+		t.Run(testdata.name, func(t *testing.T) {
+			fileMock := filemock.FileSystemMock{}
+			resourceMock := resourcemock.RemoteResourceMock{}
+			executerMock := executers.MockCommandExecuter{}
+			mockIOHandler := new(iohandlermocks.MockIOHandler)
+
+			if testdata.expectedHook {
+				fileMock.On("IsDirectory", "/var/tmp/destination").Return(true)
+				executerMock.On("NewExecute", logger, "/var/tmp/destination", mock.Anything, mock.Anything, mock.Anything, remoteresource.DefaultPostDownloadTimeoutSeconds, mock.Anything, mock.Anything).Return(testdata.exitCode, nil).Once()
+			}
+			resourceMock.On("ValidateLocationInfo").Return(true, nil).Once()
+			resourceMock.On("Download", logger, fileMock, "/var/tmp/destination").Return(nil).Once()
+			if testdata.expectedSucceed {
+				mockIOHandler.On("AppendInfof", mock.Anything, mock.Anything).Return()
+				mockIOHandler.On("MarkAsSucceeded").Return()
+			} else {
+				mockIOHandler.On("MarkAsFailed", mock.Anything).Return()
+			}
+
+			input := DownloadContentPlugin{
+				SourceType:      "GitHub",
+				SourceInfo:      testdata.sourceInfo,
+				DestinationPath: "/var/tmp/destination",
+			}
+			p := Plugin{
+				remoteResourceCreator: func(log log.T, sourceType string, sourceInfo string) (remoteresource.RemoteResource, error) {
+					return resourceMock, nil
+				},
+				filesys:         fileMock,
+				CommandExecuter: &executerMock,
+			}
+			SetPermission = stubChmod
+			p.runCopyContent(logger, &input, createStubConfiguration("orch", "bucket", "prefix", "1234-1234-1234", "directory"), createMockCancelFlag(), mockIOHandler)
+
+			resourceMock.AssertExpectations(t)
+			executerMock.AssertExpectations(t)
+			mockIOHandler.AssertExpectations(t)
+		})
+	}
+}
+
+func TestPlugin_RunCopyContentSkipIfUnchanged(t *testing.T) {
+	root, err := ioutil.TempDir("", "downloadcontent")
+	assert.NoError(t, err)
+	defer os.RemoveAll(root)
+	destination := filepath.Join(root, "destination")
+	store := &remoteresource.ExecutionStore{Dir: filepath.Join(root, "executions")}
+	sourceInfo := `{"owner": "owner", "skipIfUnchanged": true, "postDownloadCommand": "sh install.sh"}`
+
+	// each run downloads content, the post download command runs only when it changed
+	data := []struct {
+		name         string
+		content      string
+		hookExitCode int
+		expectedHook bool
+	}{
+		{"first run", "echo v1", 0, true},
+		{"unchanged content", "echo v1", 0, false},
+		{"changed content", "echo v2", 1, true},
+		{"changed content after a failed run", "echo v2", 0, true},
+		{"unchanged after a successful run", "echo v2", 0, false},
+	}
+	for _, testdata := range data {
+		t.Run(testdata.name, func(t *testing.T) {
+			fileMock := filemock.FileSystemMock{}
+			resourceMock := resourcemock.RemoteResourceMock{}
+			executerMock := executers.MockCommandExecuter{}
+			mockIOHandler := new(iohandlermocks.MockIOHandler)
+
+			resourceMock.On("ValidateLocationInfo").Return(true, nil).Once()
+			resourceMock.On("Download", logger, mock.Anything, destination).Run(func(args mock.Arguments) {
+				assert.NoError(t, os.MkdirAll(destination, 0700))
+				assert.NoError(t, ioutil.WriteFile(filepath.Join(destination, "install.sh"), []byte(testdata.content), 0600))
+			}).Return(nil).Once()
+			if testdata.expectedHook {
+				fileMock.On("IsDirectory", destination).Return(true)
+				executerMock.On("NewExecute", logger, destination, mock.Anything, mock.Anything, mock.Anything, remoteresource.DefaultPostDownloadTimeoutSeconds, mock.Anything, mock.Anything).Return(testdata.hookExitCode, nil).Once()
+			}
+			if testdata.hookExitCode == 0 {
+				mockIOHandler.On("AppendInfof", mock.Anything, mock.Anything).Return()
+				mockIOHandler.On("MarkAsSucceeded").Return()
+			} else {
+				mockIOHandler.On("MarkAsFailed", mock.Anything).Return()
+			}
+
+			input := DownloadContentPlugin{
+				SourceType:      "GitHub",
+				SourceInfo:      sourceInfo,
+				DestinationPath: destination,
+			}
+			p := Plugin{
+				remoteResourceCreator: func(log log.T, sourceType string, sourceInfo string) (remoteresource.RemoteResource, error) {
+					return resourceMock, nil
+				},
+				filesys:         fileMock,
+				CommandExecuter: &executerMock,
+				executionStore:  store,
+			}
+			SetPermission = stubChmod
+			p.runCopyContent(logger, &input, createStubConfiguration("orch", "bucket", "prefix", "1234-1234-1234", "directory"), createMockCancelFlag(), mockIOHandler)
+
+			resourceMock.AssertExpectations(t)
+			executerMock.AssertExpectations(t)
+			mockIOHandler.AssertExpectations(t)
+		})
+	}
+}
+
 func TestPlugin_ExecuteGitHubFile(t *testing.T) {
 
 	mockplugin := MockDefaultPlugin{}
@@ -392,6 +778,30 @@ func TestValidateInput_NoLocationInfo(t *testing.T) {
 	assert.Contains(t, err.Error(), "SourceInfo must be specified")
 }
 
+func TestValidateInput_GitLab(t *testing.T) {
+
+	input := DownloadContentPlugin{}
+	input.SourceType = "GitLab"
+	input.SourceInfo = `{"project": "group/project"}`
+
+	result, err := validateInput(&input)
+
+	assert.True(t, result)
+	assert.NoError(t, err)
+}
+
+func TestValidateInput_Bitbucket(t *testing.T) {
+
+	input := DownloadContentPlugin{}
+	input.SourceType = "Bitbucket"
+	input.SourceInfo = `{"workspace": "team", "repository": "repo"}`
+
+	result, err := validateInput(&input)
+
+	assert.True(t, result)
+	assert.NoError(t, err)
+}
+
 func TestName(t *testing.T) {
 	assert.Equal(t, "aws:downloadContent", Name())
 }
@@ -475,3 +885,167 @@ func createMockCancelFlag() task.CancelFlag {
 func stubChmod(log log.T, workingDir string) error {
 	return nil
 }
+
+// orderedResource records the order resources are downloaded in
+type orderedResource struct {
+	name  string
+	err   error
+	order *[]string
+	lock  *sync.Mutex
+}
+
+func (resource orderedResource) Download(log log.T, filesys filemanager.FileSystem, destinationDir string) error {
+	resource.lock.Lock()
+	defer resource.lock.Unlock()
+	*resource.order = append(*resource.order, resource.name)
+	return resource.err
+}
+
+func (resource orderedResource) ValidateLocationInfo() (bool, error) {
+	return true, nil
+}
+
+func TestPlugin_RunCopyContentAdditionalSources(t *testing.T) {
+	data := []struct {
+		name                 string
+		documentErr          error
+		scriptErr            error
+		scriptInfo           string
+		afterSource          bool
+		expectedOrder        []string
+		expectedDestinations []string
+		expectedFailure      string
+		expectedInfo         string
+	}{
+		{
+			name:                 "scripts after the document",
+			scriptInfo:           `{"path": "run.sh"}`,
+			afterSource:          true,
+			expectedOrder:        []string{"document", "script"},
+			expectedDestinations: []string{"/var/tmp/document", "/var/tmp/scripts"},
+		},
+		{
+			name:            "required source fails",
+			scriptErr:       errors.New("unreachable"),
+			scriptInfo:      `{"path": "run.sh"}`,
+			expectedFailure: "Failed to download 1 resource(s) - resource 1: unreachable",
+		},
+		{
+			name:                 "optional source fails",
+			scriptErr:            errors.New("unreachable"),
+			scriptInfo:           `{"path": "run.sh", "optional": true}`,
+			expectedDestinations: []string{"/var/tmp/document"},
+		},
+		{
+			name:            "scripts skipped when the document fails",
+			documentErr:     errors.New("unreachable"),
+			scriptInfo:      `{"path": "run.sh"}`,
+			afterSource:     true,
+			expectedOrder:   []string{"document"},
+			expectedFailure: "Failed to download 2 resource(s) - resource 0: unreachable; resource 1: skipped because request 0 failed",
+		},
+	}
+	for _, testdata := range data {
+		t.Run(testdata.name, func(t *testing.T) {
+			var order []string
+			var lock sync.Mutex
+			mockIOHandler := new(iohandlermocks.MockIOHandler)
+			var permissions []string
+			SetPermission = func(log log.T, workingDir string) error {
+				permissions = append(permissions, workingDir)
+				return nil
+			}
+			defer func() { SetPermission = stubChmod }()
+			if testdata.expectedFailure != "" {
+				mockIOHandler.On("MarkAsFailed", mock.Anything).Return()
+			} else {
+				mockIOHandler.On("AppendInfof", mock.Anything, mock.Anything).Return()
+				mockIOHandler.On("MarkAsSucceeded").Return()
+			}
+
+			input := DownloadContentPlugin{
+				SourceType:      "SSMDocument",
+				SourceInfo:      `{"name": "document"}`,
+				DestinationPath: "/var/tmp/document",
+				AdditionalSources: []AdditionalSource{
+					{SourceType: "GitHub", SourceInfo: testdata.scriptInfo, DestinationPath: "/var/tmp/scripts", AfterSource: testdata.afterSource},
+				},
+			}
+			sink := &auditSinkStub{}
+			p := Plugin{
+				remoteResourceCreator: func(log log.T, sourceType string, sourceInfo string) (remoteresource.RemoteResource, error) {
+					if sourceType == "SSMDocument" {
+						return orderedResource{name: "document", err: testdata.documentErr, order: &order, lock: &lock}, nil
+					}
+					return orderedResource{name: "script", err: testdata.scriptErr, order: &order, lock: &lock}, nil
+				},
+				filesys:   filemock.FileSystemMock{},
+				auditSink: sink,
+			}
+			p.runCopyContent(logger, &input, createStubConfiguration("orch", "bucket", "prefix", "1234-1234-1234", "directory"), createMockCancelFlag(), mockIOHandler)
+
+			mockIOHandler.AssertExpectations(t)
+			if testdata.expectedOrder != nil {
+				assert.Equal(t, testdata.expectedOrder, order)
+			}
+			if testdata.expectedFailure != "" {
+				mockIOHandler.AssertCalled(t, "MarkAsFailed", errors.New(testdata.expectedFailure))
+			} else {
+				sort.Strings(permissions)
+				assert.Equal(t, testdata.expectedDestinations, permissions)
+			}
+			// every download attempted is audited with its own source type
+			for _, record := range sink.records {
+				assert.Equal(t, map[string]string{"/var/tmp/document": "SSMDocument", "/var/tmp/scripts": "GitHub"}[record.Destination], record.SourceType)
+			}
+		})
+	}
+}
+
+func TestPlugin_RunCopyContentAdditionalSourceOptions(t *testing.T) {
+	data := []struct {
+		name       string
+		sourceInfo string
+	}{
+		{"skip if unchanged", `{"owner": "owner", "skipIfUnchanged": true}`},
+		{"post download command", `{"owner": "owner", "postDownloadCommand": "./install.sh"}`},
+	}
+	for _, testdata := range data {
+		t.Run(testdata.name, func(t *testing.T) {
+			mockIOHandler := new(iohandlermocks.MockIOHandler)
+			mockIOHandler.On("MarkAsFailed", errors.New("additional source 0: skipIfUnchanged and postDownloadCommand only apply to the source of the plugin")).Return()
+			resourceMock := resourcemock.RemoteResourceMock{}
+			resourceMock.On("ValidateLocationInfo").Return(true, nil)
+
+			input := DownloadContentPlugin{
+				SourceType:        "GitHub",
+				SourceInfo:        `{"owner": "owner"}`,
+				DestinationPath:   "/var/tmp/document",
+				AdditionalSources: []AdditionalSource{{SourceType: "GitHub", SourceInfo: testdata.sourceInfo, DestinationPath: "/var/tmp/scripts"}},
+			}
+			p := Plugin{
+				remoteResourceCreator: func(log log.T, sourceType string, sourceInfo string) (remoteresource.RemoteResource, error) {
+					return resourceMock, nil
+				},
+				filesys: filemock.FileSystemMock{},
+			}
+			p.runCopyContent(logger, &input, createStubConfiguration("orch", "bucket", "prefix", "1234-1234-1234", "directory"), createMockCancelFlag(), mockIOHandler)
+
+			mockIOHandler.AssertExpectations(t)
+			resourceMock.AssertNotCalled(t, "Download", mock.Anything, mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestValidateInput_AdditionalSources(t *testing.T) {
+	input := DownloadContentPlugin{
+		SourceType:        "GitHub",
+		SourceInfo:        `{"owner": "owner"}`,
+		AdditionalSources: []AdditionalSource{{SourceType: "FTP", SourceInfo: "ftp://host/file"}},
+	}
+
+	valid, err := validateInput(&input)
+
+	assert.False(t, valid)
+	assert.EqualError(t, err, "additional source 0: Unsupported source type")
+}
@@ -0,0 +1,184 @@
+// Copyright 2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package gitlabresource
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/aws/amazon-ssm-agent/agent/network"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/downloadcontent/remoteresource"
+)
+
+const (
+	// treePageSize is the number of entries of a directory listed per request, the most GitLab allows
+	treePageSize = 100
+
+	treeEntryFile = "blob"
+	treeEntryDir  = "tree"
+)
+
+// gitlabFile is a file of a project as returned by the repository files API
+type gitlabFile struct {
+	FilePath string `json:"file_path"`
+	Size     int64  `json:"size"`
+	Encoding string `json:"encoding"`
+	Content  string `json:"content"`
+}
+
+// decodedContent returns the content of the file, which GitLab encodes in base64
+func (file *gitlabFile) decodedContent() (string, error) {
+	if file.Encoding != "base64" {
+		return file.Content, nil
+	}
+	content, err := base64.StdEncoding.DecodeString(file.Content)
+	if err != nil {
+		return "", fmt.Errorf("Content of %v could not be decoded - %v", file.FilePath, err)
+	}
+	return string(content), nil
+}
+
+// treeEntry is an entry of a directory, Type is "blob" for files and "tree" for directories
+type treeEntry struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+	Path string `json:"path"`
+}
+
+// gitlabClient reads the repository of a GitLab project
+type gitlabClient interface {
+	// GetFile returns the file at filePath, found is false when there is no file at filePath, e.g. it is a directory
+	GetFile(log log.T, project, filePath, ref string) (file *gitlabFile, found bool, err error)
+	// ListTree returns the entries of the directory at dirPath, the root of the repository when empty
+	ListTree(log log.T, project, dirPath, ref string) ([]treeEntry, error)
+	// GetDefaultBranch returns the default branch of the project
+	GetDefaultBranch(log log.T, project string) (string, error)
+}
+
+// gitlabAPI is a client of the GitLab REST API at baseURL, e.g. https://gitlab.com/api/v4/, sending token as the private token
+type gitlabAPI struct {
+	baseURL    string
+	token      string
+	httpClient *http.Client
+}
+
+// newGitlabAPI creates a client of the API of the GitLab instance at endpoint, whose requests time out after the request timeout
+func newGitlabAPI(endpoint string, token string) *gitlabAPI {
+	return &gitlabAPI{
+		baseURL:    strings.TrimSuffix(endpoint, "/") + "/api/v4/",
+		token:      token,
+		httpClient: &http.Client{Transport: network.DefaultTransport(), Timeout: requestTimeout},
+	}
+}
+
+// GetFile gets the file from the repository files API
+func (api *gitlabAPI) GetFile(log log.T, project, filePath, ref string) (file *gitlabFile, found bool, err error) {
+	resource := "projects/" + url.PathEscape(project) + "/repository/files/" + url.PathEscape(filePath) + "?ref=" + url.QueryEscape(ref)
+	resp, err := api.get(log, resource)
+	if err != nil {
+		return nil, false, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, false, nil
+	}
+	if err = remoteresource.CheckResponse(resp, errorMessage); err != nil {
+		return nil, false, err
+	}
+	file = &gitlabFile{}
+	if err = json.NewDecoder(resp.Body).Decode(file); err != nil {
+		return nil, false, fmt.Errorf("File %v of GitLab project %v could not be read - %v", filePath, project, err)
+	}
+	return file, true, nil
+}
+
+// ListTree lists the directory with the repository tree API, following its pages
+func (api *gitlabAPI) ListTree(log log.T, project, dirPath, ref string) (entries []treeEntry, err error) {
+	for page := "1"; page != ""; {
+		resource := fmt.Sprintf("projects/%v/repository/tree?path=%v&ref=%v&per_page=%v&page=%v",
+			url.PathEscape(project), url.QueryEscape(dirPath), url.QueryEscape(ref), treePageSize, page)
+		resp, err := api.get(log, resource)
+		if err != nil {
+			return nil, err
+		}
+		var pageEntries []treeEntry
+		if err = remoteresource.CheckResponse(resp, errorMessage); err == nil {
+			err = json.NewDecoder(resp.Body).Decode(&pageEntries)
+		}
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("Directory %v of GitLab project %v could not be listed - %v", dirPath, project, err)
+		}
+		entries = append(entries, pageEntries...)
+		page = resp.Header.Get("X-Next-Page")
+	}
+	return entries, nil
+}
+
+// GetDefaultBranch reads the default branch from the projects API
+func (api *gitlabAPI) GetDefaultBranch(log log.T, project string) (string, error) {
+	resp, err := api.get(log, "projects/"+url.PathEscape(project))
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if err = remoteresource.CheckResponse(resp, errorMessage); err != nil {
+		return "", fmt.Errorf("GitLab project %v could not be read - %v", project, err)
+	}
+	var projectInfo struct {
+		DefaultBranch string `json:"default_branch"`
+	}
+	if err = json.NewDecoder(resp.Body).Decode(&projectInfo); err != nil {
+		return "", fmt.Errorf("GitLab project %v could not be read - %v", project, err)
+	}
+	if projectInfo.DefaultBranch == "" {
+		return "", fmt.Errorf("GitLab project %v has no default branch, specify a ref", project)
+	}
+	return projectInfo.DefaultBranch, nil
+}
+
+// get sends a GET request for resource, relative to the base URL of the API
+func (api *gitlabAPI) get(log log.T, resource string) (*http.Response, error) {
+	req, err := http.NewRequest("GET", api.baseURL+resource, nil)
+	if err != nil {
+		return nil, err
+	}
+	if api.token != "" {
+		// NOTE: Do not log the token
+		req.Header.Set("PRIVATE-TOKEN", api.token)
+	}
+	log.Debugf("Requesting %v from GitLab", req.URL.Path)
+	resp, err := api.httpClient.Do(req)
+	if err != nil {
+		return nil, network.ClassifyError(err)
+	}
+	return resp, nil
+}
+
+// errorMessage returns the message GitLab answered with, a string or an object of messages by field
+func errorMessage(body []byte) string {
+	var answer struct {
+		Message interface{} `json:"message"`
+	}
+	if json.Unmarshal(body, &answer) != nil || answer.Message == nil {
+		return ""
+	}
+	return fmt.Sprint(answer.Message)
+}
@@ -0,0 +1,113 @@
+// Copyright 2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package gitlabresource
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestAPI returns a client of a local test server of the GitLab API
+func newTestAPI(handler http.HandlerFunc, token string) (*gitlabAPI, *httptest.Server) {
+	server := httptest.NewServer(handler)
+	api := newGitlabAPI(server.URL+"/", token)
+	api.httpClient = server.Client()
+	return api, server
+}
+
+func TestGitlabAPI_GetFile(t *testing.T) {
+	api, server := newTestAPI(func(w http.ResponseWriter, r *http.Request) {
+		// the project path and the file path are single path segments
+		assert.Equal(t, "/api/v4/projects/group%2Fproject/repository/files/scripts%2Frun.sh", r.URL.EscapedPath())
+		assert.Equal(t, "release/1.0", r.URL.Query().Get("ref"))
+		assert.Equal(t, "secret-token", r.Header.Get("PRIVATE-TOKEN"))
+		w.Write([]byte(`{"file_path": "scripts/run.sh", "size": 7, "encoding": "base64", "content": "Y29udGVudA=="}`))
+	}, "secret-token")
+	defer server.Close()
+
+	file, found, err := api.GetFile(logMock, "group/project", "scripts/run.sh", "release/1.0")
+
+	assert.NoError(t, err)
+	assert.True(t, found)
+	content, err := file.decodedContent()
+	assert.NoError(t, err)
+	assert.Equal(t, "content", content)
+}
+
+func TestGitlabAPI_GetFileNotFound(t *testing.T) {
+	api, server := newTestAPI(func(w http.ResponseWriter, r *http.Request) {
+		assert.Empty(t, r.Header.Get("PRIVATE-TOKEN"))
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"message": "404 File Not Found"}`))
+	}, "")
+	defer server.Close()
+
+	file, found, err := api.GetFile(logMock, "group/project", "scripts", "main")
+
+	assert.NoError(t, err)
+	assert.False(t, found)
+	assert.Nil(t, file)
+}
+
+func TestGitlabAPI_GetFileUnauthorized(t *testing.T) {
+	api, server := newTestAPI(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(`{"message": "401 Unauthorized"}`))
+	}, "expired-token")
+	defer server.Close()
+
+	_, found, err := api.GetFile(logMock, "group/project", "scripts/run.sh", "main")
+
+	assert.EqualError(t, err, "401 Unauthorized 401 Unauthorized")
+	assert.False(t, found)
+}
+
+func TestGitlabAPI_ListTreeFollowsPages(t *testing.T) {
+	api, server := newTestAPI(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/v4/projects/42/repository/tree", r.URL.Path)
+		assert.Equal(t, "scripts", r.URL.Query().Get("path"))
+		assert.Equal(t, "main", r.URL.Query().Get("ref"))
+		if r.URL.Query().Get("page") == "1" {
+			w.Header().Set("X-Next-Page", "2")
+			w.Write([]byte(`[{"name": "lib", "type": "tree", "path": "scripts/lib"}]`))
+			return
+		}
+		assert.Equal(t, "2", r.URL.Query().Get("page"))
+		w.Header().Set("X-Next-Page", "")
+		w.Write([]byte(`[{"name": "run.sh", "type": "blob", "path": "scripts/run.sh"}]`))
+	}, "")
+	defer server.Close()
+
+	entries, err := api.ListTree(logMock, "42", "scripts", "main")
+
+	assert.NoError(t, err)
+	assert.Equal(t, []treeEntry{{"lib", "tree", "scripts/lib"}, {"run.sh", "blob", "scripts/run.sh"}}, entries)
+}
+
+func TestGitlabAPI_GetDefaultBranch(t *testing.T) {
+	api, server := newTestAPI(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/v4/projects/group%2Fproject", r.URL.EscapedPath())
+		w.Write([]byte(`{"id": 42, "default_branch": "main"}`))
+	}, "")
+	defer server.Close()
+
+	branch, err := api.GetDefaultBranch(logMock, "group/project")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "main", branch)
+}
@@ -0,0 +1,215 @@
+// Copyright 2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package gitlabresource implements the methods to access resources from GitLab
+package gitlabresource
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/fileutil/artifact"
+	"github.com/aws/amazon-ssm-agent/agent/fileutil/filemanager"
+	"github.com/aws/amazon-ssm-agent/agent/jsonutil"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/downloadcontent/remoteresource"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/downloadcontent/system"
+)
+
+const (
+	// defaultEndpoint is the GitLab instance projects are downloaded from when GitLabInfo has no Endpoint
+	defaultEndpoint = "https://gitlab.com"
+
+	// requestTimeout bounds each request to GitLab, reading its response included
+	requestTimeout = 30 * time.Second
+)
+
+// GitLabResource is a struct for the remote resource of type GitLab
+type GitLabResource struct {
+	client gitlabClient
+	Info   GitLabInfo
+	// fileOwnership is given to downloaded files, as configured in appconfig
+	fileOwnership system.FileOwnership
+	// maxFileSize is the configured size in bytes a single downloaded file may not exceed, files are not limited when it is 0
+	maxFileSize int64
+}
+
+// GitLabInfo represents the sourceInfo type sent by runcommand
+type GitLabInfo struct {
+	// Project is the path of the project with its namespace, e.g. group/subgroup/project, or its numeric ID
+	Project string `json:"project"`
+	// Path is the file or directory of the repository to download, the whole repository when empty
+	Path string `json:"path"`
+	// Ref is the branch, tag or commit to download, the default branch of the project when empty
+	Ref string `json:"ref"`
+	// TokenInfo is the secure string parameter, {{ ssm-secure:parameter-name }}, or the Secrets Manager secret,
+	// secretsmanager:secret-name, holding the private token the project is accessed with
+	TokenInfo string `json:"tokenInfo"`
+	// Endpoint is the URL of the GitLab instance hosting the project, e.g. https://gitlab.example.com, gitlab.com when empty
+	Endpoint string `json:"endpoint"`
+}
+
+// NewGitLabResource is a constructor of type GitLabResource
+func NewGitLabResource(log log.T, info string, token remoteresource.TokenAccess) (gitlab *GitLabResource, err error) {
+	var gitlabInfo GitLabInfo
+	if gitlabInfo, err = parseSourceInfo(info); err != nil {
+		return nil, err
+	}
+	var privateToken string
+	if gitlabInfo.TokenInfo != "" {
+		// NOTE: Do not log the token
+		if privateToken, err = token.GetToken(log, gitlabInfo.TokenInfo); err != nil {
+			return nil, err
+		}
+	}
+	endpoint := gitlabInfo.Endpoint
+	if endpoint == "" {
+		endpoint = defaultEndpoint
+	}
+	return &GitLabResource{
+		client:        newGitlabAPI(endpoint, privateToken),
+		Info:          gitlabInfo,
+		fileOwnership: system.ConfiguredFileOwnership(),
+		maxFileSize:   artifact.MaxFileSize(),
+	}, nil
+}
+
+// parseSourceInfo unmarshals the information in sourceInfo of type GitLabInfo and returns it
+func parseSourceInfo(sourceInfo string) (gitlabInfo GitLabInfo, err error) {
+	if err = jsonutil.Unmarshal(sourceInfo, &gitlabInfo); err != nil {
+		return gitlabInfo, fmt.Errorf("Source Info could not be unmarshalled for source type GitLab. Please check JSON format of sourceInfo - %v", err.Error())
+	}
+	return gitlabInfo, nil
+}
+
+// Download pulls down the file or directory at Path of the project, like a GitHub download: a file is saved to destPath,
+// or in it when it is a directory or ends with a path separator, and the content of a directory is saved in destPath
+func (gitlab *GitLabResource) Download(log log.T, filesys filemanager.FileSystem, destPath string) (err error) {
+	// if destination directory is not specified, specify the directory
+	if destPath == "" {
+		destPath = appconfig.DownloadRoot
+	}
+	ref := gitlab.Info.Ref
+	if ref == "" {
+		if ref, err = gitlab.client.GetDefaultBranch(log, gitlab.Info.Project); err != nil {
+			return err
+		}
+		log.Debugf("ref not specified, using default branch %v of %v", ref, gitlab.Info.Project)
+	}
+	repositoryPath := strings.Trim(path.Clean("/"+gitlab.Info.Path), "/")
+	log.Infof("Downloading %v from GitLab project %v at ref %v", repositoryPath, gitlab.Info.Project, ref)
+	return gitlab.download(log, filesys, ref, repositoryPath, destPath, false)
+}
+
+// download pulls down either the file or directory at repositoryPath and stores it on disk
+func (gitlab *GitLabResource) download(log log.T, filesys filemanager.FileSystem, ref string, repositoryPath string, destination string, isDirTypeDownload bool) (err error) {
+	if repositoryPath != "" {
+		file, found, err := gitlab.client.GetFile(log, gitlab.Info.Project, repositoryPath, ref)
+		if err != nil {
+			log.Error("Error occurred when trying to get repository file - ", err)
+			return err
+		}
+		if found {
+			// all files and sub-directories will be placed under the specified destination when a directory was downloaded
+			if !isDirTypeDownload {
+				destination = remoteresource.FileDestination(filesys, destination, repositoryPath)
+			}
+			return gitlab.saveFile(log, filesys, file, destination)
+		}
+	}
+
+	// the path is not a file, so it must be a directory
+	entries, err := gitlab.client.ListTree(log, gitlab.Info.Project, repositoryPath, ref)
+	if err != nil {
+		return err
+	}
+	// git has no empty directories
+	if len(entries) == 0 {
+		return fmt.Errorf("Path %v was not found in GitLab project %v at ref %v", repositoryPath, gitlab.Info.Project, ref)
+	}
+	for _, entry := range entries {
+		if entry.Type != treeEntryFile && entry.Type != treeEntryDir {
+			log.Debugf("Skipping %v of type %v", entry.Path, entry.Type)
+			continue
+		}
+		// the name comes from the repository, it may not leave the destination
+		entryDestination, err := filemanager.SafeJoin(destination, entry.Name)
+		if err != nil {
+			return err
+		}
+		if err = gitlab.download(log, filesys, ref, entry.Path, entryDestination, true); err != nil {
+			log.Error("Error retrieving file from directory", destination)
+			return err
+		}
+	}
+	return nil
+}
+
+// saveFile saves the content of file to destination
+func (gitlab *GitLabResource) saveFile(log log.T, filesys filemanager.FileSystem, file *gitlabFile, destination string) error {
+	if err := artifact.CheckDeclaredSize(file.FilePath, file.Size, gitlab.maxFileSize); err != nil {
+		return err
+	}
+	content, err := file.decodedContent()
+	if err != nil {
+		log.Error("File content could not be retrieved - ", err)
+		return err
+	}
+	if err = artifact.CheckDeclaredSize(file.FilePath, int64(len(content)), gitlab.maxFileSize); err != nil {
+		return err
+	}
+	if err = system.SaveFileContentWithOwnership(log, filesys, destination, content, gitlab.fileOwnership); err != nil {
+		log.Errorf("Error saving file - %v", err)
+		return err
+	}
+	return nil
+}
+
+// AuditLocation describes the project, path and ref downloaded for the audit log, leaving out tokenInfo
+func (gitlab *GitLabResource) AuditLocation() remoteresource.AuditLocation {
+	return remoteresource.AuditLocation{
+		Source: gitlab.Info.Project,
+		Path:   gitlab.Info.Path,
+		Ref:    gitlab.Info.Ref,
+	}
+}
+
+// LocationKey describes the content this resource downloads, normalizing the parts of GitLabInfo that don't change it
+func (gitlab *GitLabResource) LocationKey() string {
+	info := gitlab.Info
+	info.Project = strings.ToLower(info.Project)
+	info.Path = strings.Trim(path.Clean("/"+info.Path), "/")
+	info.Endpoint = strings.TrimSuffix(info.Endpoint, "/")
+	key, _ := jsonutil.Marshal(info)
+	return "GitLab:" + key
+}
+
+// ValidateLocationInfo ensures that the required parameters of SourceInfo are specified
+func (gitlab *GitLabResource) ValidateLocationInfo() (valid bool, err error) {
+	if gitlab.Info.Project == "" {
+		return false, errors.New("Project for GitLab SourceType must be specified")
+	}
+	if gitlab.Info.Endpoint != "" {
+		endpoint, err := url.Parse(gitlab.Info.Endpoint)
+		if err != nil || (endpoint.Scheme != "https" && endpoint.Scheme != "http") || endpoint.Host == "" || endpoint.RawQuery != "" || endpoint.Fragment != "" {
+			return false, fmt.Errorf("GitLab endpoint %v is not valid", gitlab.Info.Endpoint)
+		}
+	}
+	return true, nil
+}
@@ -0,0 +1,236 @@
+// Copyright 2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package gitlabresource
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/aws/amazon-ssm-agent/agent/fileutil/filemanager"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	resourcemock "github.com/aws/amazon-ssm-agent/agent/plugins/downloadcontent/remoteresource/mock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+var logMock = log.NewMockLog()
+
+// clientMock mocks the GitLab API
+type clientMock struct {
+	mock.Mock
+}
+
+func (m *clientMock) GetFile(log log.T, project, filePath, ref string) (*gitlabFile, bool, error) {
+	args := m.Called(log, project, filePath, ref)
+	return args.Get(0).(*gitlabFile), args.Bool(1), args.Error(2)
+}
+
+func (m *clientMock) ListTree(log log.T, project, dirPath, ref string) ([]treeEntry, error) {
+	args := m.Called(log, project, dirPath, ref)
+	return args.Get(0).([]treeEntry), args.Error(1)
+}
+
+func (m *clientMock) GetDefaultBranch(log log.T, project string) (string, error) {
+	args := m.Called(log, project)
+	return args.String(0), args.Error(1)
+}
+
+// mockFile expects the fetch of the file at filePath, with content "content"
+func mockFile(client *clientMock, filePath string) {
+	file := &gitlabFile{FilePath: filePath, Size: 7, Encoding: "base64", Content: "Y29udGVudA=="}
+	client.On("GetFile", logMock, "group/project", filePath, "main").Return(file, true, nil).Once()
+}
+
+// mockDirectory expects the fetch of dirPath, which is not a file, and the listing of its entries
+func mockDirectory(client *clientMock, dirPath string, entries ...treeEntry) {
+	client.On("GetFile", logMock, "group/project", dirPath, "main").Return((*gitlabFile)(nil), false, nil).Once()
+	client.On("ListTree", logMock, "group/project", dirPath, "main").Return(entries, nil).Once()
+}
+
+// newResourceWithMockedClient returns a resource downloading Path of group/project at main with the mocked client
+func newResourceWithMockedClient(client *clientMock, path string) *GitLabResource {
+	return &GitLabResource{
+		client: client,
+		Info:   GitLabInfo{Project: "group/project", Path: path, Ref: "main"},
+	}
+}
+
+func TestNewGitLabResource(t *testing.T) {
+	token := &resourcemock.TokenAccessMock{}
+	token.On("GetToken", logMock, "{{ ssm-secure:gitlab-token }}").Return("secret-token", nil).Once()
+
+	gitlab, err := NewGitLabResource(logMock, `{"project": "group/project", "path": "scripts", "tokenInfo": "{{ ssm-secure:gitlab-token }}"}`, token)
+
+	assert.NoError(t, err)
+	assert.Equal(t, GitLabInfo{Project: "group/project", Path: "scripts", TokenInfo: "{{ ssm-secure:gitlab-token }}"}, gitlab.Info)
+	assert.Equal(t, "https://gitlab.com/api/v4/", gitlab.client.(*gitlabAPI).baseURL)
+	assert.Equal(t, "secret-token", gitlab.client.(*gitlabAPI).token)
+	token.AssertExpectations(t)
+}
+
+func TestNewGitLabResource_TokenFail(t *testing.T) {
+	token := &resourcemock.TokenAccessMock{}
+	token.On("GetToken", logMock, "{{ ssm-secure:gitlab-token }}").Return("", errors.New("Token not found")).Once()
+
+	_, err := NewGitLabResource(logMock, `{"project": "group/project", "tokenInfo": "{{ ssm-secure:gitlab-token }}"}`, token)
+
+	assert.EqualError(t, err, "Token not found")
+}
+
+func TestNewGitLabResource_Endpoint(t *testing.T) {
+	gitlab, err := NewGitLabResource(logMock, `{"project": "group/project", "endpoint": "https://gitlab.example.com/"}`, &resourcemock.TokenAccessMock{})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "https://gitlab.example.com/api/v4/", gitlab.client.(*gitlabAPI).baseURL)
+}
+
+func TestGitLabResource_DownloadFile(t *testing.T) {
+	data := []struct {
+		name                string
+		destination         string
+		existingDirectory   bool
+		expectedDestination string
+	}{
+		{"to a file", "run-me.sh", false, "run-me.sh"},
+		{"to a path ending with a separator", "destination" + string(filepath.Separator), false, filepath.Join("destination", "run.sh")},
+		{"to an existing directory", "destination", true, filepath.Join("destination", "run.sh")},
+	}
+	for _, testdata := range data {
+		t.Run(testdata.name, func(t *testing.T) {
+			client := &clientMock{}
+			mockFile(client, "scripts/run.sh")
+			filesys := filemanager.NewMemoryFileSystem()
+			if testdata.existingDirectory {
+				filesys.MakeDirs(testdata.destination)
+			}
+
+			err := newResourceWithMockedClient(client, "scripts/run.sh").Download(logMock, filesys, testdata.destination)
+
+			assert.NoError(t, err)
+			content, err := filesys.ReadFile(testdata.expectedDestination)
+			assert.NoError(t, err)
+			assert.Equal(t, "content", content)
+			client.AssertExpectations(t)
+		})
+	}
+}
+
+func TestGitLabResource_DownloadDirectory(t *testing.T) {
+	client := &clientMock{}
+	mockDirectory(client, "scripts",
+		treeEntry{Name: "lib", Type: "tree", Path: "scripts/lib"},
+		treeEntry{Name: "module", Type: "commit", Path: "scripts/module"},
+		treeEntry{Name: "run.sh", Type: "blob", Path: "scripts/run.sh"})
+	mockDirectory(client, "scripts/lib", treeEntry{Name: "common.sh", Type: "blob", Path: "scripts/lib/common.sh"})
+	mockFile(client, "scripts/lib/common.sh")
+	mockFile(client, "scripts/run.sh")
+	filesys := filemanager.NewMemoryFileSystem()
+
+	err := newResourceWithMockedClient(client, "/scripts/").Download(logMock, filesys, "destination")
+
+	assert.NoError(t, err)
+	// the submodule is skipped, the content of the directory lands in the destination itself
+	assert.Equal(t, []string{"lib/common.sh", "run.sh"}, filesys.FilesUnder("destination"))
+	client.AssertExpectations(t)
+}
+
+func TestGitLabResource_DownloadDirectoryEntryOutsideDestination(t *testing.T) {
+	client := &clientMock{}
+	mockDirectory(client, "scripts", treeEntry{Name: "..", Type: "tree", Path: "scripts/.."})
+	filesys := filemanager.NewMemoryFileSystem()
+
+	err := newResourceWithMockedClient(client, "scripts").Download(logMock, filesys, "destination")
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "outside")
+	assert.Empty(t, filesys.Files())
+}
+
+func TestGitLabResource_DownloadRepositoryAtDefaultBranch(t *testing.T) {
+	client := &clientMock{}
+	client.On("GetDefaultBranch", logMock, "group/project").Return("main", nil).Once()
+	client.On("ListTree", logMock, "group/project", "", "main").Return([]treeEntry{{Name: "README.md", Type: "blob", Path: "README.md"}}, nil).Once()
+	mockFile(client, "README.md")
+	gitlab := newResourceWithMockedClient(client, "")
+	gitlab.Info.Ref = ""
+	filesys := filemanager.NewMemoryFileSystem()
+
+	err := gitlab.Download(logMock, filesys, "destination")
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"README.md"}, filesys.FilesUnder("destination"))
+	client.AssertExpectations(t)
+}
+
+func TestGitLabResource_DownloadPathNotFound(t *testing.T) {
+	client := &clientMock{}
+	mockDirectory(client, "missing")
+	filesys := filemanager.NewMemoryFileSystem()
+
+	err := newResourceWithMockedClient(client, "missing").Download(logMock, filesys, "destination")
+
+	assert.EqualError(t, err, "Path missing was not found in GitLab project group/project at ref main")
+	assert.Empty(t, filesys.Files())
+}
+
+func TestGitLabResource_DownloadFileFails(t *testing.T) {
+	client := &clientMock{}
+	client.On("GetFile", logMock, "group/project", "scripts/run.sh", "main").Return((*gitlabFile)(nil), false, errors.New("401 Unauthorized")).Once()
+	filesys := filemanager.NewMemoryFileSystem()
+
+	err := newResourceWithMockedClient(client, "scripts/run.sh").Download(logMock, filesys, "run.sh")
+
+	assert.EqualError(t, err, "401 Unauthorized")
+	client.AssertNotCalled(t, "ListTree", logMock, "group/project", "scripts/run.sh", "main")
+}
+
+func TestGitLabResource_ValidateLocationInfo(t *testing.T) {
+	data := []struct {
+		name          string
+		info          GitLabInfo
+		expectedError string
+	}{
+		{"valid", GitLabInfo{Project: "group/project"}, ""},
+		{"valid endpoint", GitLabInfo{Project: "group/project", Endpoint: "https://gitlab.example.com"}, ""},
+		{"no project", GitLabInfo{Path: "scripts"}, "Project for GitLab SourceType must be specified"},
+		{"endpoint without scheme", GitLabInfo{Project: "group/project", Endpoint: "gitlab.example.com"}, "GitLab endpoint gitlab.example.com is not valid"},
+		{"endpoint with query", GitLabInfo{Project: "group/project", Endpoint: "https://gitlab.example.com/?a=b"}, "GitLab endpoint https://gitlab.example.com/?a=b is not valid"},
+	}
+	for _, testdata := range data {
+		t.Run(testdata.name, func(t *testing.T) {
+			gitlab := &GitLabResource{Info: testdata.info}
+
+			valid, err := gitlab.ValidateLocationInfo()
+
+			if testdata.expectedError == "" {
+				assert.True(t, valid)
+				assert.NoError(t, err)
+			} else {
+				assert.False(t, valid)
+				assert.EqualError(t, err, testdata.expectedError)
+			}
+		})
+	}
+}
+
+func TestGitLabResource_LocationKey(t *testing.T) {
+	first := &GitLabResource{Info: GitLabInfo{Project: "Group/Project", Path: "/scripts/", Ref: "main"}}
+	second := &GitLabResource{Info: GitLabInfo{Project: "group/project", Path: "scripts", Ref: "main"}}
+	other := &GitLabResource{Info: GitLabInfo{Project: "group/project", Path: "scripts", Ref: "release"}}
+
+	assert.Equal(t, first.LocationKey(), second.LocationKey())
+	assert.NotEqual(t, first.LocationKey(), other.LocationKey())
+}
@@ -0,0 +1,69 @@
+// Copyright 2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package gitresource
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/aws/amazon-ssm-agent/agent/fileutil/artifact"
+	"github.com/aws/amazon-ssm-agent/agent/fileutil/filemanager"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+)
+
+// blobShaPattern matches the SHA-1 or SHA-256 object names of git blobs
+var blobShaPattern = regexp.MustCompile(`^([0-9a-fA-F]{40}|[0-9a-fA-F]{64})$`)
+
+// validateBlobSha ensures a blob download names a valid blob and where to save it, and nothing that selects content by path or ref
+func validateBlobSha(info GitInfo) error {
+	if !blobShaPattern.MatchString(info.BlobSha) {
+		return fmt.Errorf("BlobSha %v for GitHub SourceType must be a full hexadecimal object name", info.BlobSha)
+	}
+	if info.DestinationFileName == "" {
+		return errors.New("DestinationFileName for GitHub SourceType must be specified to download a blob")
+	}
+	if info.Path != "" || info.GetOptions != "" || info.RefPattern != "" || info.Select != "" || info.Concatenate {
+		return errors.New("BlobSha for GitHub SourceType can't be combined with path, getOptions, refPattern, select or concatenate")
+	}
+	if info.TreeSha != "" || info.RequireSignature || info.VerifySignature {
+		return errors.New("BlobSha for GitHub SourceType can't be combined with treeSha, requireSignature or verifySignature")
+	}
+	return nil
+}
+
+// downloadBlob fetches the blob of info.BlobSha through the git blobs API and saves it as info.DestinationFileName
+func (git *downloadContext) downloadBlob(log log.T, filesys filemanager.FileSystem, info GitInfo, destinationDir string) error {
+	log.Infof("Downloading blob %v", info.BlobSha)
+	content, err := git.client.GetBlob(log, info.Owner, info.Repository, info.BlobSha)
+	if err != nil {
+		return fmt.Errorf("Could not download blob %v of %v/%v - %v", info.BlobSha, info.Owner, info.Repository, err)
+	}
+	if err = artifact.CheckDeclaredSize(info.BlobSha, int64(len(content)), git.maxFileSize); err != nil {
+		return err
+	}
+	// a truncated or corrupted response no longer hashes to the blob SHA it was requested by
+	if !strings.EqualFold(gitBlobSha(content, info.BlobSha), info.BlobSha) {
+		return fmt.Errorf("Content of blob %v does not match its SHA", info.BlobSha)
+	}
+
+	destination, err := filemanager.SafeJoin(destinationDir, info.DestinationFileName)
+	if err != nil {
+		return err
+	}
+	log.Debugf("Saving blob %v (%v bytes) to %v", info.BlobSha, len(content), destination)
+	return git.saveFile(log, filesys, destination, content, 0)
+}
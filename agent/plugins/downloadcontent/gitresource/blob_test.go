@@ -0,0 +1,137 @@
+// Copyright 2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package gitresource
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/aws/amazon-ssm-agent/agent/fileutil/filemanager"
+	githubclientmock "github.com/aws/amazon-ssm-agent/agent/githubclient/mock"
+	"github.com/stretchr/testify/assert"
+)
+
+const testBlobSha = "3b18e512dba79e4c8300dd08aeb37f8e728b8dad"
+
+func TestGitResource_DownloadBlob(t *testing.T) {
+	clientMock := githubclientmock.ClientMock{}
+	clientMock.On("GetBlob", logMock, "owner", "repo", testBlobSha).Return("hello world\n", nil).Once()
+
+	gitResource := NewResourceWithMockedClient(&clientMock)
+	gitResource.Info.Path = ""
+	gitResource.Info.BlobSha = testBlobSha
+	gitResource.Info.DestinationFileName = "hello.txt"
+	valid, err := gitResource.ValidateLocationInfo()
+	assert.True(t, valid)
+	assert.NoError(t, err)
+	filesys := filemanager.NewMemoryFileSystem()
+	destination := filepath.Join("/var", "tmp", "blobs")
+
+	err = gitResource.Download(logMock, filesys, destination)
+
+	assert.NoError(t, err)
+	clientMock.AssertExpectations(t)
+	// the blob is fetched by its SHA alone, without resolving a path or ref
+	clientMock.AssertNotCalled(t, "ParseGetOptions")
+	content, err := filesys.ReadFile(filepath.Join(destination, "hello.txt"))
+	assert.NoError(t, err)
+	assert.Equal(t, "hello world\n", content)
+}
+
+func TestGitResource_DownloadBlobCorrupted(t *testing.T) {
+	clientMock := githubclientmock.ClientMock{}
+	clientMock.On("GetBlob", logMock, "owner", "repo", testBlobSha).Return("hello wor", nil).Once()
+
+	gitResource := NewResourceWithMockedClient(&clientMock)
+	gitResource.Info.Path = ""
+	gitResource.Info.BlobSha = testBlobSha
+	gitResource.Info.DestinationFileName = "hello.txt"
+	filesys := filemanager.NewMemoryFileSystem()
+
+	err := gitResource.Download(logMock, filesys, "destination")
+
+	assert.EqualError(t, err, "Content of blob "+testBlobSha+" does not match its SHA")
+	assert.Empty(t, filesys.Files())
+}
+
+func TestGitResource_DownloadBlobNotFound(t *testing.T) {
+	clientMock := githubclientmock.ClientMock{}
+	clientMock.On("GetBlob", logMock, "owner", "repo", testBlobSha).Return("", errors.New("404 Not Found")).Once()
+
+	gitResource := NewResourceWithMockedClient(&clientMock)
+	gitResource.Info.Path = ""
+	gitResource.Info.BlobSha = testBlobSha
+	gitResource.Info.DestinationFileName = "hello.txt"
+	filesys := filemanager.NewMemoryFileSystem()
+
+	err := gitResource.Download(logMock, filesys, filepath.Join("/var", "tmp", "blobs"))
+
+	assert.EqualError(t, err, "Could not download blob "+testBlobSha+" of owner/repo - 404 Not Found")
+	clientMock.AssertExpectations(t)
+	assert.Empty(t, filesys.Files())
+}
+
+func TestGitResource_DownloadBlobOutsideDestination(t *testing.T) {
+	clientMock := githubclientmock.ClientMock{}
+	clientMock.On("GetBlob", logMock, "owner", "repo", testBlobSha).Return("hello world\n", nil).Once()
+
+	gitResource := NewResourceWithMockedClient(&clientMock)
+	gitResource.Info.Path = ""
+	gitResource.Info.BlobSha = testBlobSha
+	gitResource.Info.DestinationFileName = "../../etc/cron.d/hello"
+	filesys := filemanager.NewMemoryFileSystem()
+
+	err := gitResource.Download(logMock, filesys, filepath.Join("/var", "tmp", "blobs"))
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "outside")
+	assert.Empty(t, filesys.Files())
+}
+
+func TestGitResource_ValidateLocationInfoBlobSha(t *testing.T) {
+	data := []struct {
+		name        string
+		info        GitInfo
+		expectedErr string
+	}{
+		{"valid", GitInfo{BlobSha: testBlobSha, DestinationFileName: "hello.txt"}, ""},
+		{"sha256", GitInfo{BlobSha: testBlobSha + "0123456789abcdef01234567", DestinationFileName: "hello.txt"}, ""},
+		{"abbreviated", GitInfo{BlobSha: "3b18e51", DestinationFileName: "hello.txt"},
+			"BlobSha 3b18e51 for GitHub SourceType must be a full hexadecimal object name"},
+		{"no destination file name", GitInfo{BlobSha: testBlobSha},
+			"DestinationFileName for GitHub SourceType must be specified to download a blob"},
+		{"with path", GitInfo{BlobSha: testBlobSha, DestinationFileName: "hello.txt", Path: "hello.txt"},
+			"BlobSha for GitHub SourceType can't be combined with path, getOptions, refPattern, select or concatenate"},
+		{"with getOptions", GitInfo{BlobSha: testBlobSha, DestinationFileName: "hello.txt", GetOptions: "branch:master"},
+			"BlobSha for GitHub SourceType can't be combined with path, getOptions, refPattern, select or concatenate"},
+	}
+	for _, testdata := range data {
+		t.Run(testdata.name, func(t *testing.T) {
+			testdata.info.Owner, testdata.info.Repository = "owner", "repo"
+			gitResource := &GitResource{Info: testdata.info}
+
+			valid, err := gitResource.ValidateLocationInfo()
+
+			if testdata.expectedErr == "" {
+				assert.True(t, valid)
+				assert.NoError(t, err)
+			} else {
+				assert.False(t, valid)
+				assert.EqualError(t, err, testdata.expectedErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,164 @@
+// Copyright 2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package gitresource
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/aws/amazon-ssm-agent/agent/fileutil/artifact"
+	"github.com/aws/amazon-ssm-agent/agent/fileutil/filemanager"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/downloadcontent/remoteresource"
+)
+
+// githubSSHHost is the host repositories of github.com are cloned from
+const githubSSHHost = "github.com"
+
+// runGit runs git with args, env added to the environment of the agent. It is a seam for tests.
+var runGit = func(log log.T, env []string, args ...string) error {
+	cmd := exec.Command("git", args...)
+	cmd.Env = append(os.Environ(), env...)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("git %v failed - %v: %v", args[0], err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// cloneURL returns the SSH URL of the repository, on the Enterprise Server of Endpoint when one is given
+func (info GitInfo) cloneURL() (string, error) {
+	host := githubSSHHost
+	if info.Endpoint != "" {
+		endpoint, err := url.Parse(info.Endpoint)
+		if err != nil || endpoint.Hostname() == "" {
+			return "", fmt.Errorf("GitHub Enterprise endpoint %v is not valid", info.Endpoint)
+		}
+		host = endpoint.Hostname()
+	}
+	return fmt.Sprintf("git@%v:%v/%v.git", host, info.Owner, info.Repository), nil
+}
+
+// downloadClone clones the repository over SSH with its deploy key and saves Path of the clone to destPath,
+// like the contents API download saves it
+func (git *downloadContext) downloadClone(log log.T, filesys filemanager.FileSystem, info GitInfo, destPath string) (err error) {
+	opt, err := git.client.ParseGetOptions(log, info.GetOptions)
+	if err != nil {
+		return err
+	}
+	repositoryURL, err := info.cloneURL()
+	if err != nil {
+		return err
+	}
+	workDir, err := ioutil.TempDir("", "ssm-git-clone")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(workDir)
+
+	// NOTE: Do not log the deploy key
+	keyFile := filepath.Join(workDir, "deploy_key")
+	if err = ioutil.WriteFile(keyFile, []byte(strings.TrimSpace(git.deployKey)+"\n"), 0600); err != nil {
+		return err
+	}
+	env := []string{
+		fmt.Sprintf("GIT_SSH_COMMAND=ssh -i '%v' -o IdentitiesOnly=yes -o StrictHostKeyChecking=accept-new", keyFile),
+		"GIT_TERMINAL_PROMPT=0",
+	}
+	cloneDir := filepath.Join(workDir, "repository")
+	log.Infof("Cloning %v at ref %v with its deploy key", repositoryURL, opt.Ref)
+	if err = runGit(log, env, "clone", "--quiet", "--no-checkout", repositoryURL, cloneDir); err != nil {
+		return err
+	}
+	if err = runGit(log, env, "-C", cloneDir, "checkout", "--quiet", opt.Ref); err != nil {
+		return err
+	}
+
+	repositoryPath := strings.Trim(path.Clean("/"+info.Path), "/")
+	source, err := filemanager.SafeJoin(cloneDir, repositoryPath)
+	if err != nil {
+		return err
+	}
+	sourceInfo, err := os.Lstat(source)
+	if err != nil {
+		return fmt.Errorf("Path %v was not found in %v at ref %v", repositoryPath, repositoryURL, opt.Ref)
+	}
+	if sourceInfo.Mode()&os.ModeSymlink != 0 {
+		return fmt.Errorf("Path %v is a symbolic link, only files and directories are downloaded", repositoryPath)
+	}
+	if !sourceInfo.IsDir() {
+		return git.saveClonedFile(log, filesys, info, repositoryPath, source, remoteresource.FileDestination(filesys, destPath, repositoryPath))
+	}
+	return filepath.Walk(source, func(filePath string, fileInfo os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		relative, err := filepath.Rel(source, filePath)
+		if err != nil {
+			return err
+		}
+		if fileInfo.IsDir() {
+			if fileInfo.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !fileInfo.Mode().IsRegular() {
+			// symbolic links are not followed out of the clone
+			log.Debugf("Skipping %v, it is not a regular file", relative)
+			return nil
+		}
+		fileRepositoryPath := path.Join(repositoryPath, filepath.ToSlash(relative))
+		if !isExtensionIncluded(info, fileRepositoryPath) {
+			log.Debugf("Skipping %v, its extension is filtered out", fileRepositoryPath)
+			return nil
+		}
+		destination, err := filemanager.SafeJoin(destPath, relative)
+		if err != nil {
+			return err
+		}
+		return git.saveClonedFile(log, filesys, info, fileRepositoryPath, filePath, destination)
+	})
+}
+
+// saveClonedFile saves the file at source of the clone, repositoryPath in the repository, to destination
+func (git *downloadContext) saveClonedFile(log log.T, filesys filemanager.FileSystem, info GitInfo, repositoryPath string, source string, destination string) error {
+	sourceInfo, err := os.Stat(source)
+	if err != nil {
+		return err
+	}
+	if err = artifact.CheckDeclaredSize(repositoryPath, sourceInfo.Size(), git.maxFileSize); err != nil {
+		return err
+	}
+	content, err := ioutil.ReadFile(source)
+	if err != nil {
+		return err
+	}
+	rendered, err := git.renderTemplate(log, repositoryPath, string(content))
+	if err != nil {
+		return err
+	}
+	var mode os.FileMode
+	if info.PreserveFileMode && sourceInfo.Mode()&0111 != 0 {
+		mode = executableFileMode
+	}
+	log.Debugf("Saving %v (%v bytes) to %v", repositoryPath, len(rendered), destination)
+	return git.saveFile(log, filesys, destination, rendered, mode)
+}
@@ -0,0 +1,209 @@
+// Copyright 2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package gitresource
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/aws/amazon-ssm-agent/agent/fileutil/filemanager"
+	githubclientmock "github.com/aws/amazon-ssm-agent/agent/githubclient/mock"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/go-github/github"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// DeployKeyTokenMock resolves deploy keys on top of the tokens of TokenMock
+type DeployKeyTokenMock struct {
+	TokenMock
+}
+
+func (m DeployKeyTokenMock) GetDeployKey(log log.T, owner string, repository string) (string, error) {
+	args := m.Called(log, owner, repository)
+	return args.String(0), args.Error(1)
+}
+
+// clonedFiles are the files of the repository created by the clone of fakeGit
+var clonedFiles = map[string]string{
+	"README.md":             "readme",
+	"scripts/run.sh":        "run",
+	"scripts/lib/common.sh": "common",
+	".git/config":           "config",
+}
+
+// fakeGit replaces runGit with one cloning clonedFiles and recording the calls and the deploy key they were made with
+func fakeGit(t *testing.T, calls *[]string, keys *[]string) (restore func()) {
+	runGit = func(log log.T, env []string, args ...string) error {
+		*calls = append(*calls, strings.Join(args, " "))
+		for _, variable := range env {
+			if strings.HasPrefix(variable, "GIT_SSH_COMMAND=") {
+				keyFile := strings.SplitN(variable, "'", 3)[1]
+				key, err := ioutil.ReadFile(keyFile)
+				assert.NoError(t, err)
+				*keys = append(*keys, string(key))
+			}
+		}
+		if args[0] != "clone" {
+			return nil
+		}
+		cloneDir := args[len(args)-1]
+		for name, content := range clonedFiles {
+			file := filepath.Join(cloneDir, filepath.FromSlash(name))
+			assert.NoError(t, os.MkdirAll(filepath.Dir(file), 0700))
+			assert.NoError(t, ioutil.WriteFile(file, []byte(content), 0600))
+		}
+		return nil
+	}
+	return func() { runGit = defaultRunGit }
+}
+
+var defaultRunGit = runGit
+
+// newCloneResource returns a resource cloning Path of owner/repo at getOptions with the deploy key
+func newCloneResource(path string, getOptions string) *GitResource {
+	clientMock := &githubclientmock.ClientMock{}
+	clientMock.On("ParseGetOptions", mock.Anything, getOptions).Return(&github.RepositoryContentGetOptions{Ref: strings.TrimPrefix(getOptions, "branch:")}, nil)
+	return &GitResource{
+		client:    clientMock,
+		deployKey: "deploy-key",
+		Info:      GitInfo{Owner: "owner", Repository: "repo", Path: path, GetOptions: getOptions, UseDeployKey: true},
+	}
+}
+
+func TestNewGitResource_DeployKey(t *testing.T) {
+	token := DeployKeyTokenMock{}
+	token.On("GetDeployKey", logMock, "owner", "repo").Return("deploy-key", nil).Once()
+
+	git, err := NewGitResource(logMock, `{"owner": "owner", "repository": "repo", "useDeployKey": true}`, token)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "deploy-key", git.deployKey)
+	token.AssertExpectations(t)
+}
+
+func TestNewGitResource_DeployKeyFail(t *testing.T) {
+	token := DeployKeyTokenMock{}
+	token.On("GetDeployKey", logMock, "owner", "repo").Return("", errors.New("ThrottlingException")).Once()
+
+	_, err := NewGitResource(logMock, `{"owner": "owner", "repository": "repo", "useDeployKey": true}`, token)
+
+	assert.EqualError(t, err, "ThrottlingException")
+}
+
+func TestNewGitResource_DeployKeyNotAvailable(t *testing.T) {
+	_, err := NewGitResource(logMock, `{"owner": "owner", "repository": "repo", "useDeployKey": true}`, TokenMock{})
+
+	assert.EqualError(t, err, "Deploy keys are not available for source type GitHub")
+}
+
+func TestGitResource_DownloadClone_Directory(t *testing.T) {
+	var calls, keys []string
+	defer fakeGit(t, &calls, &keys)()
+	filesys := filemanager.NewMemoryFileSystem()
+
+	err := newCloneResource("scripts", "branch:release").Download(logMock, filesys, "destination")
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"lib/common.sh", "run.sh"}, filesys.FilesUnder("destination"))
+	assert.Len(t, calls, 2)
+	assert.True(t, strings.HasPrefix(calls[0], "clone --quiet --no-checkout git@github.com:owner/repo.git "))
+	assert.True(t, strings.HasSuffix(calls[1], "checkout --quiet release"))
+	assert.Equal(t, []string{"deploy-key\n", "deploy-key\n"}, keys)
+}
+
+func TestGitResource_DownloadClone_File(t *testing.T) {
+	var calls, keys []string
+	defer fakeGit(t, &calls, &keys)()
+	filesys := filemanager.NewMemoryFileSystem()
+	filesys.MakeDirs("destination")
+
+	err := newCloneResource("scripts/run.sh", "branch:main").Download(logMock, filesys, "destination")
+
+	assert.NoError(t, err)
+	content, err := filesys.ReadFile(filepath.Join("destination", "run.sh"))
+	assert.NoError(t, err)
+	assert.Equal(t, "run", content)
+}
+
+func TestGitResource_DownloadClone_Repository(t *testing.T) {
+	var calls, keys []string
+	defer fakeGit(t, &calls, &keys)()
+	filesys := filemanager.NewMemoryFileSystem()
+
+	err := newCloneResource("", "branch:main").Download(logMock, filesys, "destination")
+
+	assert.NoError(t, err)
+	// the git directory of the clone is not downloaded
+	assert.Equal(t, []string{"README.md", "scripts/lib/common.sh", "scripts/run.sh"}, filesys.FilesUnder("destination"))
+}
+
+func TestGitResource_DownloadClone_PathNotFound(t *testing.T) {
+	var calls, keys []string
+	defer fakeGit(t, &calls, &keys)()
+
+	err := newCloneResource("missing", "branch:main").Download(logMock, filemanager.NewMemoryFileSystem(), "destination")
+
+	assert.EqualError(t, err, "Path missing was not found in git@github.com:owner/repo.git at ref main")
+}
+
+func TestGitResource_DownloadClone_CloneFails(t *testing.T) {
+	runGit = func(log log.T, env []string, args ...string) error {
+		return errors.New("git clone failed - exit status 128: Permission denied (publickey)")
+	}
+	defer func() { runGit = defaultRunGit }()
+
+	err := newCloneResource("scripts", "branch:main").Download(logMock, filemanager.NewMemoryFileSystem(), "destination")
+
+	assert.EqualError(t, err, "git clone failed - exit status 128: Permission denied (publickey)")
+}
+
+func TestGitInfo_CloneURL(t *testing.T) {
+	url, err := GitInfo{Owner: "owner", Repository: "repo", Endpoint: "https://github.example.com"}.cloneURL()
+
+	assert.NoError(t, err)
+	assert.Equal(t, "git@github.example.com:owner/repo.git", url)
+}
+
+func TestGitResource_ValidateLocationInfo_DeployKey(t *testing.T) {
+	data := []struct {
+		name        string
+		info        GitInfo
+		expectedErr string
+	}{
+		{"path at a ref", GitInfo{Path: "scripts", GetOptions: "branch:main"}, ""},
+		{"with tokenInfo", GitInfo{TokenInfo: "{{ ssm-secure:token }}"}, "UseDeployKey for GitHub SourceType can't be combined with tokenInfo, mirrors or useRawHost"},
+		{"with concatenate", GitInfo{Concatenate: true, DestinationFileName: "all"}, "UseDeployKey for GitHub SourceType only supports downloading Path at getOptions"},
+	}
+	for _, testdata := range data {
+		t.Run(testdata.name, func(t *testing.T) {
+			info := testdata.info
+			info.Owner, info.Repository, info.UseDeployKey = "owner", "repo", true
+
+			_, err := (&GitResource{Info: info}).ValidateLocationInfo()
+
+			if testdata.expectedErr == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), testdata.expectedErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,103 @@
+// Copyright 2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package gitresource
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/aws/amazon-ssm-agent/agent/fileutil/filemanager"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/go-github/github"
+)
+
+// signatureSuffix is appended to the path of a file to get the path of its detached signature
+const signatureSuffix = ".sig"
+
+// isSignatureFile returns true if the directory entry is the detached signature of another file
+func isSignatureFile(content *github.RepositoryContent) bool {
+	return content.GetType() != "dir" && strings.HasSuffix(content.GetPath(), signatureSuffix)
+}
+
+// verifyFileSignature verifies the file downloaded from filePath to destination against its detached signature,
+// filePath.sig in the repository, made with the configured signature key. The file is deleted when it can't be verified.
+func (git *downloadContext) verifyFileSignature(log log.T, filesys filemanager.FileSystem, info GitInfo, opt *github.RepositoryContentGetOptions, filePath string, destination string) (err error) {
+	defer func() {
+		if err == nil {
+			return
+		}
+		if deleteErr := filesys.DeleteFile(destination); deleteErr != nil {
+			log.Warnf("Could not delete %v after its signature failed to verify - %v", destination, deleteErr)
+		}
+	}()
+
+	signaturePath := filePath + signatureSuffix
+	signature, err := git.fetchFile(log, info, opt, signaturePath)
+	if err != nil {
+		return fmt.Errorf("Could not fetch signature %v of %v - %v", signaturePath, filePath, err)
+	}
+	content, err := filesys.ReadFile(destination)
+	if err != nil {
+		return fmt.Errorf("Could not read %v to verify its signature - %v", destination, err)
+	}
+	if err = verifyDetachedSignature(git.signaturePublicKey, []byte(content), []byte(signature)); err != nil {
+		return fmt.Errorf("Signature %v does not verify %v - %v", signaturePath, filePath, err)
+	}
+	log.Debugf("Signature %v of %v verified", signaturePath, filePath)
+	return nil
+}
+
+// verifyDetachedSignature verifies the signature of content with a PEM encoded RSA, ECDSA or Ed25519 public key.
+// RSA (PKCS #1 v1.5) and ECDSA signatures are made over the SHA-256 digest of the content.
+// The signature is either raw or base64 encoded, as written by e.g. openssl dgst -sha256 -sign.
+func verifyDetachedSignature(publicKeyPEM string, content []byte, signature []byte) error {
+	block, _ := pem.Decode([]byte(publicKeyPEM))
+	if block == nil {
+		return errors.New("signature public key is not PEM encoded")
+	}
+	publicKey, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return fmt.Errorf("signature public key could not be parsed - %v", err)
+	}
+	if decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(signature))); err == nil {
+		signature = decoded
+	}
+
+	digest := sha256.Sum256(content)
+	switch key := publicKey.(type) {
+	case *rsa.PublicKey:
+		err = rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signature)
+	case *ecdsa.PublicKey:
+		if !ecdsa.VerifyASN1(key, digest[:], signature) {
+			err = errors.New("ecdsa: verification error")
+		}
+	case ed25519.PublicKey:
+		if !ed25519.Verify(key, content, signature) {
+			err = errors.New("ed25519: verification error")
+		}
+	default:
+		return fmt.Errorf("signature public key of type %T is not supported", publicKey)
+	}
+	return err
+}
@@ -0,0 +1,145 @@
+// Copyright 2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package gitresource
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	filemock "github.com/aws/amazon-ssm-agent/agent/fileutil/filemanager/mock"
+	githubclientmock "github.com/aws/amazon-ssm-agent/agent/githubclient/mock"
+	"github.com/go-github/github"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// publicKeyPEM encodes the public key of a test signing key
+func publicKeyPEM(publicKey interface{}) string {
+	der, _ := x509.MarshalPKIXPublicKey(publicKey)
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+}
+
+func TestGitResource_DownloadVerifySignature(t *testing.T) {
+	signingKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	content := "echo signed"
+	digest := sha256.Sum256([]byte(content))
+	validSignature, _ := ecdsa.SignASN1(rand.Reader, signingKey, digest[:])
+	otherDigest := sha256.Sum256([]byte("echo tampered"))
+	invalidSignature, _ := ecdsa.SignASN1(rand.Reader, signingKey, otherDigest[:])
+
+	data := []struct {
+		name        string
+		signature   []byte
+		expectedErr string
+	}{
+		{"valid signature", validSignature, ""},
+		{"invalid signature", invalidSignature, "Signature path/to/file.ext.sig does not verify path/to/file.ext"},
+		{"missing signature", nil, "Could not fetch signature path/to/file.ext.sig of path/to/file.ext"},
+	}
+
+	for _, testdata := range data {
+		t.Run(testdata.name, func(t *testing.T) {
+			opt := &github.RepositoryContentGetOptions{Ref: "master"}
+			fileType, filePath := "file", "path/to/file.ext"
+			fileMetadata := &github.RepositoryContent{Content: &content, Type: &fileType, Path: &filePath}
+			destination := filepath.Join(appconfig.DownloadRoot, "file.ext")
+
+			clientMock := githubclientmock.ClientMock{}
+			clientMock.On("ParseGetOptions", logMock, "").Return(opt, nil)
+			clientMock.On("GetRepositoryContents", logMock, "owner", "repo", filePath, opt).Return(fileMetadata, []*github.RepositoryContent(nil), nil).Once()
+			clientMock.On("IsFileContentType", mock.AnythingOfType("*github.RepositoryContent")).Return(true)
+			if testdata.signature != nil {
+				signature, signaturePath := base64.StdEncoding.EncodeToString(testdata.signature), filePath+signatureSuffix
+				signatureMetadata := &github.RepositoryContent{Content: &signature, Type: &fileType, Path: &signaturePath}
+				clientMock.On("GetRepositoryContents", logMock, "owner", "repo", signaturePath, opt).Return(signatureMetadata, []*github.RepositoryContent(nil), nil).Once()
+			} else {
+				clientMock.On("GetRepositoryContents", logMock, "owner", "repo", filePath+signatureSuffix, opt).Return((*github.RepositoryContent)(nil), []*github.RepositoryContent(nil), errors.New("Response is - 404 Not Found")).Once()
+			}
+
+			fileMock := filemock.FileSystemMock{}
+			fileMock.On("Exists", appconfig.DownloadRoot).Return(true)
+			fileMock.On("IsDirectory", appconfig.DownloadRoot).Return(true)
+			fileMock.On("MakeDirs", strings.TrimSuffix(appconfig.DownloadRoot, "/")).Return(nil)
+			fileMock.On("WriteFile", destination, content).Return(nil)
+			if testdata.signature != nil {
+				fileMock.On("ReadFile", destination).Return(content, nil)
+			}
+			if testdata.expectedErr != "" {
+				fileMock.On("DeleteFile", destination).Return(nil).Once()
+			}
+
+			gitResource := NewResourceWithMockedClient(&clientMock)
+			gitResource.Info.VerifySignature = true
+			gitResource.signaturePublicKey = publicKeyPEM(&signingKey.PublicKey)
+
+			err := gitResource.Download(logMock, fileMock, "")
+
+			if testdata.expectedErr != "" {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), testdata.expectedErr)
+			} else {
+				assert.NoError(t, err)
+			}
+			clientMock.AssertExpectations(t)
+			fileMock.AssertExpectations(t)
+		})
+	}
+}
+
+func TestVerifyDetachedSignature(t *testing.T) {
+	content := []byte("echo signed")
+	digest := sha256.Sum256(content)
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	rsaSignature, _ := rsa.SignPKCS1v15(rand.Reader, rsaKey, crypto.SHA256, digest[:])
+	ed25519PublicKey, ed25519Key, _ := ed25519.GenerateKey(rand.Reader)
+	ed25519Signature := ed25519.Sign(ed25519Key, content)
+
+	assert.NoError(t, verifyDetachedSignature(publicKeyPEM(&rsaKey.PublicKey), content, rsaSignature))
+	assert.NoError(t, verifyDetachedSignature(publicKeyPEM(ed25519PublicKey), content, []byte(base64.StdEncoding.EncodeToString(ed25519Signature))))
+	assert.Error(t, verifyDetachedSignature(publicKeyPEM(&rsaKey.PublicKey), []byte("echo tampered"), rsaSignature))
+	assert.Error(t, verifyDetachedSignature(publicKeyPEM(ed25519PublicKey), content, rsaSignature))
+	assert.EqualError(t, verifyDetachedSignature("not a key", content, rsaSignature), "signature public key is not PEM encoded")
+}
+
+func TestGitResource_ValidateLocationInfoVerifySignature(t *testing.T) {
+	gitResource := NewResourceWithMockedClient(&githubclientmock.ClientMock{})
+	gitResource.Info.VerifySignature = true
+
+	_, err := gitResource.ValidateLocationInfo()
+	assert.EqualError(t, err, "VerifySignature for GitHub SourceType requires a signature public key in the agent configuration")
+
+	gitResource.signaturePublicKey = "key"
+	valid, err := gitResource.ValidateLocationInfo()
+	assert.True(t, valid)
+	assert.NoError(t, err)
+
+	gitResource.Info.Concatenate = true
+	gitResource.Info.DestinationFileName = "all.sh"
+	_, err = gitResource.ValidateLocationInfo()
+	assert.EqualError(t, err, "VerifySignature for GitHub SourceType can't be combined with concatenate or substituteParameters")
+}
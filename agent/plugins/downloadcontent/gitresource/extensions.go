@@ -0,0 +1,46 @@
+// Copyright 2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package gitresource
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// hasExtension returns true if the extension of filePath is one of extensions, which may be given with or without the leading dot
+func hasExtension(filePath string, extensions []string) bool {
+	extension := filepath.Ext(filePath)
+	if extension == "" {
+		return false
+	}
+	for _, candidate := range extensions {
+		if !strings.HasPrefix(candidate, ".") {
+			candidate = "." + candidate
+		}
+		if strings.EqualFold(candidate, extension) {
+			return true
+		}
+	}
+	return false
+}
+
+// isExtensionIncluded returns true if a file at filePath of a directory download passes the IncludeExtensions and ExcludeExtensions of info.
+// Every file is included when IncludeExtensions is empty, and ExcludeExtensions wins over it.
+func isExtensionIncluded(info GitInfo, filePath string) bool {
+	if len(info.IncludeExtensions) > 0 && !hasExtension(filePath, info.IncludeExtensions) {
+		return false
+	}
+	return !hasExtension(filePath, info.ExcludeExtensions)
+}
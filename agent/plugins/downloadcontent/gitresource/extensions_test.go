@@ -0,0 +1,109 @@
+// Copyright 2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package gitresource
+
+import (
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/aws/amazon-ssm-agent/agent/fileutil/filemanager"
+	githubclientmock "github.com/aws/amazon-ssm-agent/agent/githubclient/mock"
+	"github.com/go-github/github"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestIsExtensionIncluded(t *testing.T) {
+	tests := []struct {
+		name     string
+		include  []string
+		exclude  []string
+		filePath string
+		expected bool
+	}{
+		{"no filters", nil, nil, "scripts/logo.png", true},
+		{"included", []string{"sh", ".ps1"}, nil, "scripts/run.sh", true},
+		{"included with dot", []string{"sh", ".ps1"}, nil, "scripts/run.ps1", true},
+		{"included ignoring case", []string{"sh"}, nil, "scripts/RUN.SH", true},
+		{"not included", []string{"sh"}, nil, "scripts/logo.png", false},
+		{"no extension not included", []string{"sh"}, nil, "scripts/Makefile", false},
+		{"excluded", nil, []string{"png"}, "scripts/logo.png", false},
+		{"not excluded", nil, []string{"png"}, "scripts/run.sh", true},
+		{"no extension not excluded", nil, []string{"png"}, "scripts/Makefile", true},
+		{"exclude wins", []string{"sh"}, []string{".sh"}, "scripts/run.sh", false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			info := GitInfo{IncludeExtensions: test.include, ExcludeExtensions: test.exclude}
+			assert.Equal(t, test.expected, isExtensionIncluded(info, test.filePath))
+		})
+	}
+}
+
+func TestGitResource_DownloadDirectoryFiltersExtensions(t *testing.T) {
+	tests := []struct {
+		name     string
+		include  []string
+		exclude  []string
+		expected []string
+	}{
+		{"include only", []string{"sh"}, nil, []string{"lib/common.sh", "run.sh"}},
+		{"exclude only", nil, []string{"png"}, []string{"README.md", "lib/common.sh", "run.sh"}},
+		{"include and exclude", []string{"sh", "png"}, []string{"png"}, []string{"lib/common.sh", "run.sh"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			clientMock := githubclientmock.ClientMock{}
+			opt := &github.RepositoryContentGetOptions{Ref: "master"}
+			clientMock.On("ParseGetOptions", logMock, "").Return(opt, nil)
+			clientMock.On("GetRepositoryContents", logMock, "owner", "repo", "scripts", opt).Return((*github.RepositoryContent)(nil), []*github.RepositoryContent{
+				repositoryContent("file", "scripts/README.md", 6, "blob1"),
+				repositoryContent("dir", "scripts/lib", 0, "tree1"),
+				repositoryContent("file", "scripts/logo.png", 6, "blob2"),
+				repositoryContent("file", "scripts/run.sh", 6, "blob3"),
+			}, nil).Once()
+			clientMock.On("GetRepositoryContents", logMock, "owner", "repo", "scripts/lib", opt).Return((*github.RepositoryContent)(nil), []*github.RepositoryContent{
+				repositoryContent("file", "scripts/lib/common.sh", 6, "blob4"),
+				repositoryContent("file", "scripts/lib/icon.png", 6, "blob5"),
+			}, nil).Once()
+			for _, expected := range test.expected {
+				content := "content"
+				file := repositoryContent("file", "scripts/"+expected, len(content), "blob")
+				file.Content = &content
+				clientMock.On("GetRepositoryContents", logMock, "owner", "repo", "scripts/"+expected, opt).Return(file, []*github.RepositoryContent(nil), nil).Once()
+			}
+			clientMock.On("IsFileContentType", mock.AnythingOfType("*github.RepositoryContent")).Return(true)
+
+			gitResource := NewResourceWithMockedClient(&clientMock)
+			gitResource.Info.Path = "scripts"
+			gitResource.Info.IncludeExtensions = test.include
+			gitResource.Info.ExcludeExtensions = test.exclude
+			filesys := filemanager.NewMemoryFileSystem()
+
+			err := gitResource.Download(logMock, filesys, "destination")
+
+			assert.NoError(t, err)
+			clientMock.AssertExpectations(t)
+			var saved []string
+			for _, file := range filesys.Files() {
+				relative, _ := filepath.Rel("destination", file)
+				saved = append(saved, filepath.ToSlash(relative))
+			}
+			sort.Strings(saved)
+			assert.Equal(t, test.expected, saved)
+		})
+	}
+}
@@ -0,0 +1,67 @@
+// Copyright 2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package gitresource
+
+import (
+	"os"
+	"path"
+
+	"github.com/aws/amazon-ssm-agent/agent/log"
+)
+
+const (
+	// executableGitMode is the git file mode of executable files
+	executableGitMode = "100755"
+	// executableFileMode is given to the executable files of a download preserving file modes
+	executableFileMode os.FileMode = 0755
+)
+
+// fileMode returns the permissions to save the file at repositoryPath with: executableFileMode when PreserveFileMode is set
+// and git has the file executable at ref, 0 to keep the default ones otherwise.
+// The modes of the files of a directory are asked of GitHub once per download.
+func (git *downloadContext) fileMode(log log.T, info GitInfo, ref string, repositoryPath string) (os.FileMode, error) {
+	if !info.PreserveFileMode {
+		return 0, nil
+	}
+	git.treeModesLock.Lock()
+	defer git.treeModesLock.Unlock()
+
+	var err error
+	if ref == "" {
+		if ref, err = git.resolveDefaultBranch(log); err != nil {
+			return 0, err
+		}
+	}
+	// the root tree of a ref is the one of the ref itself
+	tree := ref
+	if dir := path.Dir(repositoryPath); dir != "." && dir != "/" {
+		tree = ref + ":" + dir
+	}
+	modes, found := git.treeModes[tree]
+	if !found {
+		if modes, err = git.client.GetTreeModes(log, info.Owner, info.Repository, tree); err != nil {
+			return 0, err
+		}
+		if git.treeModes == nil {
+			git.treeModes = map[string]map[string]string{}
+		}
+		git.treeModes[tree] = modes
+	}
+	if modes[path.Base(repositoryPath)] == executableGitMode {
+		log.Debugf("%v is executable", repositoryPath)
+		return executableFileMode, nil
+	}
+	return 0, nil
+}
@@ -0,0 +1,126 @@
+// Copyright 2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package gitresource
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/aws/amazon-ssm-agent/agent/fileutil/filemanager"
+	githubclientmock "github.com/aws/amazon-ssm-agent/agent/githubclient/mock"
+	"github.com/go-github/github"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// expectedExecutableMode is the mode executable files are saved with, permissions are not changed on Windows
+func expectedExecutableMode() os.FileMode {
+	if runtime.GOOS == "windows" {
+		return 0
+	}
+	return 0755
+}
+
+func TestGitResource_DownloadDirectoryPreservesFileMode(t *testing.T) {
+	clientMock := githubclientmock.ClientMock{}
+	opt := &github.RepositoryContentGetOptions{Ref: "master"}
+	clientMock.On("ParseGetOptions", logMock, "").Return(opt, nil)
+	clientMock.On("GetRepositoryContents", logMock, "owner", "repo", "scripts", opt).Return((*github.RepositoryContent)(nil), []*github.RepositoryContent{
+		repositoryContent("file", "scripts/README.md", 7, "blob1"),
+		repositoryContent("file", "scripts/run.sh", 7, "blob2"),
+	}, nil).Once()
+	for _, filePath := range []string{"scripts/README.md", "scripts/run.sh"} {
+		content := "content"
+		file := repositoryContent("file", filePath, len(content), "blob")
+		file.Content = &content
+		clientMock.On("GetRepositoryContents", logMock, "owner", "repo", filePath, opt).Return(file, []*github.RepositoryContent(nil), nil).Once()
+	}
+	clientMock.On("IsFileContentType", mock.AnythingOfType("*github.RepositoryContent")).Return(true)
+	// the modes of a directory are only asked once
+	clientMock.On("GetTreeModes", logMock, "owner", "repo", "master:scripts").Return(map[string]string{"README.md": "100644", "run.sh": "100755"}, nil).Once()
+
+	gitResource := NewResourceWithMockedClient(&clientMock)
+	gitResource.Info.Path = "scripts"
+	gitResource.Info.PreserveFileMode = true
+	filesys := filemanager.NewMemoryFileSystem()
+
+	err := gitResource.Download(logMock, filesys, "destination")
+
+	assert.NoError(t, err)
+	clientMock.AssertExpectations(t)
+	assert.Equal(t, expectedExecutableMode(), filesys.Mode(filepath.Join("destination", "run.sh")))
+	assert.Equal(t, os.FileMode(0), filesys.Mode(filepath.Join("destination", "README.md")))
+}
+
+func TestGitResource_DownloadFilePreservesFileModeAtDefaultBranch(t *testing.T) {
+	clientMock := githubclientmock.ClientMock{}
+	opt := &github.RepositoryContentGetOptions{Ref: ""}
+	content := "#!/bin/sh"
+	file := repositoryContent("file", "run.sh", len(content), "blob")
+	file.Content = &content
+	clientMock.On("ParseGetOptions", logMock, "").Return(opt, nil)
+	clientMock.On("GetRepositoryContents", logMock, "owner", "repo", "run.sh", opt).Return(file, []*github.RepositoryContent(nil), nil).Once()
+	clientMock.On("IsFileContentType", mock.AnythingOfType("*github.RepositoryContent")).Return(true)
+	clientMock.On("GetDefaultBranch", logMock, "owner", "repo").Return("main", nil).Once()
+	// the root tree is the one of the ref itself
+	clientMock.On("GetTreeModes", logMock, "owner", "repo", "main").Return(map[string]string{"run.sh": "100755"}, nil).Once()
+
+	gitResource := NewResourceWithMockedClient(&clientMock)
+	gitResource.Info.Path = "run.sh"
+	gitResource.Info.PreserveFileMode = true
+	filesys := filemanager.NewMemoryFileSystem()
+
+	err := gitResource.Download(logMock, filesys, "run.sh")
+
+	assert.NoError(t, err)
+	clientMock.AssertExpectations(t)
+	assert.Equal(t, expectedExecutableMode(), filesys.Mode("run.sh"))
+}
+
+func TestGitResource_DownloadWithoutPreserveFileMode(t *testing.T) {
+	clientMock := githubclientmock.ClientMock{}
+	opt := &github.RepositoryContentGetOptions{Ref: "master"}
+	content := "#!/bin/sh"
+	file := repositoryContent("file", "scripts/run.sh", len(content), "blob")
+	file.Content = &content
+	clientMock.On("ParseGetOptions", logMock, "").Return(opt, nil)
+	clientMock.On("GetRepositoryContents", logMock, "owner", "repo", "scripts/run.sh", opt).Return(file, []*github.RepositoryContent(nil), nil).Once()
+	clientMock.On("IsFileContentType", mock.AnythingOfType("*github.RepositoryContent")).Return(true)
+
+	gitResource := NewResourceWithMockedClient(&clientMock)
+	gitResource.Info.Path = "scripts/run.sh"
+	filesys := filemanager.NewMemoryFileSystem()
+
+	err := gitResource.Download(logMock, filesys, "run.sh")
+
+	assert.NoError(t, err)
+	clientMock.AssertNotCalled(t, "GetTreeModes", logMock, "owner", "repo", "master:scripts")
+	assert.Equal(t, os.FileMode(0), filesys.Mode("run.sh"))
+}
+
+func TestGitResource_ValidateLocationInfoPreserveFileMode(t *testing.T) {
+	gitResource := &GitResource{Info: GitInfo{Owner: "owner", Repository: "repo", PreserveFileMode: true}}
+	valid, err := gitResource.ValidateLocationInfo()
+	assert.True(t, valid)
+	assert.NoError(t, err)
+
+	gitResource.Info.Concatenate = true
+	gitResource.Info.DestinationFileName = "all.sh"
+	valid, err = gitResource.ValidateLocationInfo()
+	assert.False(t, valid)
+	assert.EqualError(t, err, "PreserveFileMode for GitHub SourceType can't be combined with stream or concatenate")
+}
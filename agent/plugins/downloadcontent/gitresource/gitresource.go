@@ -17,33 +17,235 @@ package gitresource
 
 import (
 	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/fileutil/artifact"
 	"github.com/aws/amazon-ssm-agent/agent/fileutil/filemanager"
 	"github.com/aws/amazon-ssm-agent/agent/githubclient"
 	"github.com/aws/amazon-ssm-agent/agent/jsonutil"
 	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/aws/amazon-ssm-agent/agent/network"
 	"github.com/aws/amazon-ssm-agent/agent/plugins/downloadcontent/gitresource/privategithub"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/downloadcontent/remoteresource"
 	"github.com/aws/amazon-ssm-agent/agent/plugins/downloadcontent/system"
+	"github.com/go-github/github"
 
 	"errors"
 	"fmt"
 	"net/http"
 	"os"
+	"path"
 	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
 )
 
+const (
+	// selectLatest picks the newest file of the directory in Path
+	selectLatest = "latest"
+
+	sortByName       = "name"
+	sortByCommitDate = "commitDate"
+
+	// fileFetchRetryBackoff is the wait before the first retry of a fetch, it doubles with each attempt
+	fileFetchRetryBackoff = time.Second
+	// defaultRequestTimeout bounds each request to GitHub when GitInfo has no TimeoutSeconds
+	defaultRequestTimeout = 30 * time.Second
+)
+
+// sleep is a seam for waiting between fetch attempts
+var sleep = time.Sleep
+
+// Features are the optional behaviors of GitHub downloads reported by remoteresource.Capabilities
+var Features = []string{
+	"blobDownload",
+	"commitSignatureVerification",
+	"deployKeys",
+	"detachedSignatureVerification",
+	"enterpriseEndpoint",
+	"extensionFilters",
+	"fileModes",
+	"gitLfs",
+	"lineEndingNormalization",
+	"maxTotalBytes",
+	"mirrors",
+	"parameterSubstitution",
+	"prefetch",
+	"rawContentHost",
+	"requestTimeout",
+	"streaming",
+	"valuesOverlay",
+}
+
 // GitResource is a struct for the remote resource of type git
 type GitResource struct {
 	client githubclient.IGitClient
+	// apiHost is the host of the API client sends requests to, whose retry budget fetch retries spend
+	apiHost string
 	Info   GitInfo
+	// defaultRef is the configured branch used when getOptions is not specified
+	defaultRef string
+	// allowedRepositories are the configured owner/repository globs downloads are restricted to
+	allowedRepositories []string
+	// trustedSigningKeys are the configured key fingerprints or IDs commits must be signed with when a signature is required
+	trustedSigningKeys []string
+	// fileOwnership is given to downloaded files, from GitInfo or else appconfig
+	fileOwnership system.FileOwnership
+	// deployKey is the private key the repository is cloned with when UseDeployKey is set
+	deployKey string
+	// mirrors are tried in order by Download, which passes each to downloadOnce
+	mirrors []gitMirror
+	// resourceTypes are the configured extension to resource type mappings telling which files are documents
+	resourceTypes map[string]string
+	// signaturePublicKey is the configured PEM public key detached file signatures are verified with
+	signaturePublicKey string
+	// maxFileSize is the configured size in bytes a single downloaded file may not exceed, files are not limited when it is 0
+	maxFileSize int64
+	// fetchMaxAttempts is the configured number of times a fetch is attempted, appconfig.DefaultGitHubFetchMaxAttempts when 0
+	fetchMaxAttempts int
+	// downloadConcurrency is the configured number of files of a directory downloaded at once, one at a time when 0
+	downloadConcurrency int
+}
+
+// downloadContext is the state of a single Download, List or Prefetch of a GitResource, which is not changed after construction.
+// The configuration of the resource is reached through the embedded GitResource, requests are made with client.
+type downloadContext struct {
+	*GitResource
+	// client is the one of the mirror being downloaded from, or else the one of the resource
+	client githubclient.IGitClient
+	// apiHost is the host of the API client sends requests to
+	apiHost string
+	// fileSlots bound the files being downloaded at once
+	fileSlots chan struct{}
+	// downloaded records the files saved by a download writing a manifest, it is nil otherwise
+	downloaded []downloadedFile
+	// downloadedLock guards downloaded, which the concurrent file downloads of a directory record to
+	downloadedLock sync.Mutex
+	// flattened maps the lower case base names saved by a flattened download to the repository path of the file saved under it
+	flattened map[string]string
+	// treeModes caches the git file modes of the entries of the trees, <ref>:<path>, of a download preserving file modes
+	treeModes     map[string]map[string]string
+	treeModesLock sync.Mutex
+	// savedBytes and savedPaths are the bytes and files saved by the download, its bytes are limited by MaxTotalBytes
+	savedBytes int64
+	savedPaths []string
+	savedLock  sync.Mutex
+	// repositoryDefaultBranch caches the default branch of the repository once resolved from GitHub
+	repositoryDefaultBranch string
+}
+
+// newDownloadContext returns the state of a call of the resource making its requests with client to the API at apiHost
+func (git *GitResource) newDownloadContext(client githubclient.IGitClient, apiHost string) *downloadContext {
+	return &downloadContext{GitResource: git, client: client, apiHost: apiHost, fileSlots: git.newFileSlots()}
 }
 
 // GitInfo represents the sourceInfo type sent by runcommand
 type GitInfo struct {
 	Owner      string `json:"owner"`
 	Repository string `json:"repository"`
+	// Repo is the short "owner/repository" form of Owner and Repository, only one of the forms may be used
+	Repo       string `json:"repo"`
 	Path       string `json:"path"`
 	GetOptions string `json:"getOptions"`
-	TokenInfo  string `json:"tokenInfo"`
+	// TokenInfo is the secure string parameter, {{ ssm-secure:parameter-name }}, or the Secrets Manager secret,
+	// secretsmanager:secret-name, holding the token the repository is accessed with. A GitHub App installation,
+	// githubapp:app-id:installation-id:{{ ssm-secure:parameter-name }} with the private key of the app, gets a short lived token instead.
+	TokenInfo string `json:"tokenInfo"`
+	// Endpoint is the URL of the GitHub Enterprise Server hosting the repository, e.g. https://github.example.com, github.com when empty
+	Endpoint string `json:"endpoint"`
+	// AnonymousFallback retries a request refused with 401 anonymously, after refreshing the token didn't help,
+	// so a misconfigured or expired token doesn't fail the download of a public repository
+	AnonymousFallback bool `json:"anonymousFallback"`
+	// RootPath is a directory of the repository Path is relative to, e.g. the service directory of a monorepo
+	RootPath string `json:"rootPath"`
+	// RefPattern, instead of GetOptions, is resolved to a concrete ref before the download: HEAD for the default branch,
+	// or the newest tag or branch matching refs/tags/<glob> or refs/heads/<glob>, by "semver" (default) or "commitDate" RefOrder
+	RefPattern string `json:"refPattern"`
+	RefOrder   string `json:"refOrder"`
+	// Select, when set to "latest", downloads the newest file in the Path directory matching NamePattern
+	Select      string `json:"select"`
+	NamePattern string `json:"namePattern"`
+	// SortBy orders the matching files by "name" (default) or "commitDate"
+	SortBy string `json:"sortBy"`
+	// TreeSha is the expected tree of the commit in GetOptions, the download fails if the commit points to another tree
+	TreeSha string `json:"treeSha"`
+	// SkipUnchanged reuses files downloaded by a previous run when their blob SHA at the ref has not changed
+	SkipUnchanged bool `json:"skipUnchanged"`
+	// PrefetchCache takes the files of a directory download from the files saved by Prefetch when their blob SHA matches,
+	// instead of fetching each of them
+	PrefetchCache bool `json:"prefetchCache"`
+	// UseRawHost fetches a single file of a public repository from the raw content host instead of the contents API,
+	// the API is used when that fails
+	UseRawHost bool `json:"useRawHost"`
+	// RequireSignature aborts the download unless the commit of GetOptions has a verified GPG signature
+	RequireSignature bool `json:"requireSignature"`
+	// VerifySignature aborts the download unless each file is verified by its detached signature, <path>.sig in the repository,
+	// made with the configured signature public key. Files that fail verification are deleted.
+	VerifySignature bool `json:"verifySignature"`
+	// Verbose logs the details of this download at Info level
+	Verbose bool `json:"verbose"`
+	// BlobSha downloads the blob with that SHA through the git blobs API into DestinationFileName, instead of Path at a ref.
+	// The blob is the same however refs move.
+	BlobSha string `json:"blobSha"`
+	// Concatenate joins the files of the Path directory, in name order, into DestinationFileName
+	Concatenate         bool   `json:"concatenate"`
+	Separator           string `json:"separator"`
+	DestinationFileName string `json:"destinationFileName"`
+	// FileOwner and FileGroup, names or numeric IDs, own the downloaded files on Unix instead of the configured ones
+	FileOwner string `json:"fileOwner"`
+	FileGroup string `json:"fileGroup"`
+	// Flatten saves every file of a directory download, whatever its depth, right in the destination under its base name.
+	// The download fails when two files have the same base name.
+	Flatten bool `json:"flatten"`
+	// StripComponents drops that many leading directories from the path of each file of a directory download,
+	// relative to Path, like tar --strip-components. The download fails when a file is not deeper than that.
+	StripComponents int `json:"stripComponents"`
+	// AllowLfs downloads the Git LFS object a file is a pointer to, instead of saving the pointer
+	AllowLfs bool `json:"allowLfs"`
+	// IncludeExtensions, when not empty, limits the files of a directory download, at every depth, to the ones with these extensions.
+	// Files with ExcludeExtensions are left out, even when included. The leading dot of an extension is optional.
+	IncludeExtensions []string `json:"includeExtensions"`
+	ExcludeExtensions []string `json:"excludeExtensions"`
+	// PreserveFileMode saves the files git has as executable, mode 100755, with permissions 0755 instead of the default ones.
+	// The modes are read from the git tree of each directory. Permissions are not changed on Windows.
+	PreserveFileMode bool `json:"preserveFileMode"`
+	// MaxTotalBytes aborts the download once the files it saves add up to more bytes, deleting them. Downloads are not limited when it is 0.
+	MaxTotalBytes int64 `json:"maxTotalBytes"`
+	// TimeoutSeconds bounds each request to GitHub, reading its response included, e.g. the content of a file. 30 seconds when 0,
+	// very large files may need more.
+	TimeoutSeconds int `json:"timeoutSeconds"`
+	// Stream writes files to disk as they are downloaded instead of holding their whole content in memory, for very large files
+	Stream bool `json:"stream"`
+	// NormalizeLineEndings converts the line endings of text files to the ones of the platform, LF or CRLF on Windows,
+	// e.g. so scripts authored on Windows run on Linux. Files with null bytes are binary and saved as they are.
+	NormalizeLineEndings bool `json:"normalizeLineEndings"`
+	// WriteManifest writes .ssm-manifest.json, listing the path, size and SHA-256 of each downloaded file, to the destination
+	WriteManifest bool `json:"writeManifest"`
+	// ValuesOverlay copies the values file selected for the platform of the instance to a canonical name in the downloaded directory
+	ValuesOverlay *ValuesOverlay `json:"valuesOverlay"`
+	// SubstituteParameters renders downloaded documents as templates, substituting Parameters for their {{ parameter }} references
+	SubstituteParameters bool                   `json:"substituteParameters"`
+	Parameters           map[string]interface{} `json:"parameters"`
+	// Mirrors are GitHub or GitHub Enterprise APIs hosting the repository, tried in order until one serves the download.
+	// TokenInfo is ignored when mirrors are specified, each mirror has its own.
+	Mirrors []GitMirror `json:"mirrors"`
+	// UseDeployKey clones the repository over SSH with its read-only deploy key, stored in parameter store under
+	// <configured prefix>/<owner>/<repository> or else the configured default key, instead of using the contents API
+	UseDeployKey bool `json:"useDeployKey"`
+}
+
+// GitMirror is an API serving the repository of a GitInfo
+type GitMirror struct {
+	// BaseURL of the API, e.g. https://github.example.com/api/v3/, github.com when empty
+	BaseURL   string `json:"baseURL"`
+	TokenInfo string `json:"tokenInfo"`
+}
+
+// gitMirror is a client for a GitMirror, the name logged for it and the host of its API
+type gitMirror struct {
+	name   string
+	client githubclient.IGitClient
+	host   string
 }
 
 // NewGitResource is a constructor of type GitResource
@@ -61,12 +263,164 @@ func NewGitResource(log log.T, info string, token privategithub.PrivateGithubAcc
 			return nil, err
 		}
 	}
+	var defaultRef, mirrorURL, signaturePublicKey string
+	var fetchMaxAttempts, downloadConcurrency int
+	var allowedRepositories, trustedSigningKeys []string
+	var resourceTypes map[string]string
+	if appCfg, err := appconfig.Config(false); err == nil {
+		resourceTypes = appCfg.RemoteResource.ResourceTypes
+		defaultRef = appCfg.GitHub.DefaultRef
+		mirrorURL = appCfg.GitHub.MirrorURL
+		allowedRepositories = appCfg.GitHub.AllowedRepositories
+		trustedSigningKeys = appCfg.GitHub.TrustedSigningKeys
+		signaturePublicKey = appCfg.GitHub.SignaturePublicKey
+		fetchMaxAttempts = appCfg.GitHub.FetchMaxAttempts
+		downloadConcurrency = appCfg.GitHub.DownloadConcurrency
+	}
+
+	fileOwnership := system.ConfiguredFileOwnership()
+	if gitInfo.FileOwner != "" {
+		fileOwnership.Owner = gitInfo.FileOwner
+	}
+	if gitInfo.FileGroup != "" {
+		fileOwnership.Group = gitInfo.FileGroup
+	}
+
+	// the configured mirror falls back to github.com
+	apiHost := githubclient.APIHost("")
+	if gitInfo.Endpoint != "" {
+		// the configured mirror caches github.com, not the Enterprise Server
+		mirrorURL = ""
+		apiURL, err := githubclient.EnterpriseAPIURL(gitInfo.Endpoint)
+		if err != nil {
+			return nil, err
+		}
+		apiHost = githubclient.APIHost(apiURL)
+	}
+	if mirrorURL != "" {
+		if _, err := githubclient.NewMirroredClient(nil, mirrorURL); err != nil {
+			log.Warnf("Ignoring GitHub mirror configuration - %v", err)
+			mirrorURL = ""
+		}
+	}
+	timeout := gitInfo.requestTimeout()
+	newClient := func(httpClient *http.Client) githubclient.IGitClient {
+		if gitInfo.Endpoint != "" {
+			// the endpoint was validated above
+			enterpriseClient, _ := githubclient.NewEnterpriseClient(httpClient, gitInfo.Endpoint)
+			return githubclient.WithTimeout(enterpriseClient, timeout)
+		}
+		if mirrorURL != "" {
+			// the mirror URL was validated above
+			mirroredClient, _ := githubclient.NewMirroredClient(httpClient, mirrorURL)
+			return githubclient.WithTimeout(mirroredClient, timeout)
+		}
+		return githubclient.WithTimeout(githubclient.NewClient(httpClient), timeout)
+	}
+	client := newClient(httpClient)
+	if gitInfo.TokenInfo != "" {
+		refreshing := newRefreshingClient(client, refreshToken(token, gitInfo.TokenInfo, newClient))
+		if gitInfo.AnonymousFallback {
+			refreshing.anonymous = newClient(nil)
+		}
+		client = refreshing
+	}
+
+	var deployKey string
+	if gitInfo.UseDeployKey {
+		keys, ok := token.(privategithub.DeployKeyAccess)
+		if !ok {
+			return nil, errors.New("Deploy keys are not available for source type GitHub")
+		}
+		// NOTE: Do not log the deploy key
+		if deployKey, err = keys.GetDeployKey(log, gitInfo.Owner, gitInfo.Repository); err != nil {
+			return nil, err
+		}
+	}
+
+	var mirrors []gitMirror
+	if len(gitInfo.Mirrors) > 0 {
+		if mirrors, err = newMirrors(log, gitInfo.Mirrors, token, timeout); err != nil {
+			return nil, err
+		}
+		client, apiHost = mirrors[0].client, mirrors[0].host
+	}
+
 	return &GitResource{
-		client: githubclient.NewClient(httpClient),
-		Info:   gitInfo,
+		client:              client,
+		apiHost:             apiHost,
+		deployKey:           deployKey,
+		mirrors:             mirrors,
+		Info:                gitInfo,
+		defaultRef:          defaultRef,
+		allowedRepositories: allowedRepositories,
+		trustedSigningKeys:  trustedSigningKeys,
+		fileOwnership:       fileOwnership,
+		resourceTypes:       resourceTypes,
+		signaturePublicKey:  signaturePublicKey,
+		maxFileSize:         artifact.MaxFileSize(),
+		fetchMaxAttempts:    fetchMaxAttempts,
+		downloadConcurrency: downloadConcurrency,
 	}, nil
 }
 
+// newMirrors creates a client for each mirror, authorized with the token of the mirror, whose requests time out after timeout
+func newMirrors(log log.T, gitMirrors []GitMirror, token privategithub.PrivateGithubAccess, timeout time.Duration) (mirrors []gitMirror, err error) {
+	for _, mirrorInfo := range gitMirrors {
+		var httpClient *http.Client
+		if mirrorInfo.TokenInfo != "" {
+			if httpClient, err = token.GetOAuthClient(log, mirrorInfo.TokenInfo); err != nil {
+				return nil, err
+			}
+		}
+		mirror := gitMirror{name: "github.com", client: githubclient.WithTimeout(githubclient.NewClient(httpClient), timeout), host: githubclient.APIHost(mirrorInfo.BaseURL)}
+		newClient := func(httpClient *http.Client) githubclient.IGitClient {
+			return githubclient.WithTimeout(githubclient.NewClient(httpClient), timeout)
+		}
+		if mirrorInfo.BaseURL != "" {
+			baseURL := mirrorInfo.BaseURL
+			mirror.name = baseURL
+			if mirror.client, err = githubclient.NewClientWithBaseURL(httpClient, baseURL); err != nil {
+				return nil, err
+			}
+			mirror.client = githubclient.WithTimeout(mirror.client, timeout)
+			newClient = func(httpClient *http.Client) githubclient.IGitClient {
+				// the base URL was validated when the mirror client was created
+				client, _ := githubclient.NewClientWithBaseURL(httpClient, baseURL)
+				return githubclient.WithTimeout(client, timeout)
+			}
+		}
+		if mirrorInfo.TokenInfo != "" {
+			mirror.client = newRefreshingClient(mirror.client, refreshToken(token, mirrorInfo.TokenInfo, newClient))
+		}
+		mirrors = append(mirrors, mirror)
+	}
+	return mirrors, nil
+}
+
+// verboseLogger returns a logger writing the debug messages of a download at Info level when verbose is set
+func verboseLogger(logger log.T, verbose bool) log.T {
+	return log.Verbose(logger, verbose)
+}
+
+// LocationKey describes the content this resource downloads, normalizing the parts of GitInfo that don't change it
+func (git *GitResource) LocationKey() string {
+	info := git.Info
+	info.Owner = strings.ToLower(info.Owner)
+	info.Repository = strings.ToLower(info.Repository)
+	if repositoryPath, err := info.repositoryPath(); err == nil {
+		info.Path = repositoryPath
+	}
+	info.Path = strings.Trim(path.Clean("/"+info.Path), "/")
+	info.RootPath = ""
+	info.Verbose = false
+	if info.GetOptions == "" && git.defaultRef != "" {
+		info.GetOptions = "branch:" + git.defaultRef
+	}
+	key, _ := jsonutil.Marshal(info)
+	return "GitHub:" + key
+}
+
 // parseSourceInfo unmarshals the information in sourceInfo of type GitInfo and returns it
 func parseSourceInfo(sourceInfo string) (gitInfo GitInfo, err error) {
 
@@ -74,30 +428,179 @@ func parseSourceInfo(sourceInfo string) (gitInfo GitInfo, err error) {
 		return gitInfo, fmt.Errorf("Source Info could not be unmarshalled for source type GitHub. Please check JSON format of sourceInfo - %v", err.Error())
 	}
 
+	if gitInfo.Repo != "" {
+		if gitInfo.Owner != "" || gitInfo.Repository != "" {
+			return gitInfo, errors.New("Specify either repo or owner and repository for source type GitHub, not both")
+		}
+		if gitInfo.Owner, gitInfo.Repository, err = splitRepo(gitInfo.Repo); err != nil {
+			return gitInfo, err
+		}
+	}
+
 	return gitInfo, nil
 }
 
+// repositoryPath returns Path from the root of the repository, joining it to RootPath when one is given.
+// It fails when RootPath leaves the repository or Path leaves RootPath.
+func (info GitInfo) repositoryPath() (string, error) {
+	if info.RootPath == "" {
+		return info.Path, nil
+	}
+	rootPath := path.Clean(strings.TrimPrefix(info.RootPath, "/"))
+	if rootPath == ".." || strings.HasPrefix(rootPath, "../") {
+		return "", fmt.Errorf("RootPath %v for GitHub SourceType must be within the repository", info.RootPath)
+	}
+	repositoryPath := path.Join(rootPath, info.Path)
+	if rootPath != "." && repositoryPath != rootPath && !strings.HasPrefix(repositoryPath, rootPath+"/") {
+		return "", fmt.Errorf("Path %v for GitHub SourceType must be within RootPath %v", info.Path, info.RootPath)
+	}
+	return repositoryPath, nil
+}
+
+// requestTimeout returns the time each request to GitHub may take, TimeoutSeconds or defaultRequestTimeout when it is not set
+func (info GitInfo) requestTimeout() time.Duration {
+	if info.TimeoutSeconds > 0 {
+		return time.Duration(info.TimeoutSeconds) * time.Second
+	}
+	return defaultRequestTimeout
+}
+
+// splitRepo splits the short "owner/repository" form of a repository
+func splitRepo(repo string) (owner string, repository string, err error) {
+	parts := strings.Split(repo, "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", fmt.Errorf("Repo for GitHub SourceType must be of the form owner/repository, got %v", repo)
+	}
+	return parts[0], parts[1], nil
+}
+
 // Download calls download to pull down files or directory from github
 func (git *GitResource) Download(log log.T, filesys filemanager.FileSystem, destPath string) (err error) {
 	// if destination directory is not specified, specify the directory
 	if destPath == "" {
 		destPath = appconfig.DownloadRoot
 	}
+	if len(git.mirrors) == 0 {
+		return git.downloadOnce(log, gitMirror{client: git.client, host: git.apiHost}, filesys, destPath)
+	}
+
+	for _, mirror := range git.mirrors {
+		if err = git.downloadOnce(log, mirror, filesys, destPath); err == nil {
+			log.Infof("Downloaded %v/%v from %v", git.Info.Owner, git.Info.Repository, mirror.name)
+			return nil
+		}
+		log.Warnf("Could not download %v/%v from %v - %v", git.Info.Owner, git.Info.Repository, mirror.name, err)
+	}
+	return err
+}
+
+// downloadOnce downloads the resource with the client of source. When the resource has mirrors, the files saved by a download
+// that fails are deleted so the next mirror doesn't leave them mixed with its own.
+func (git *GitResource) downloadOnce(log log.T, source gitMirror, filesys filemanager.FileSystem, destPath string) (err error) {
+	download := git.newDownloadContext(source.client, source.host)
+	if git.Info.Flatten {
+		download.flattened = map[string]string{}
+	}
+	defer func() {
+		if _, exceeded := err.(*totalSizeExceededError); exceeded || (err != nil && len(git.mirrors) > 0) {
+			download.deleteSavedFiles(log, filesys)
+		}
+	}()
+	if !git.Info.WriteManifest {
+		return download.downloadContent(log, filesys, destPath)
+	}
+
+	download.downloaded = []downloadedFile{}
+	if err = download.downloadContent(log, filesys, destPath); err != nil {
+		return err
+	}
+	return writeManifest(log, filesys, destPath, download.downloaded)
+}
 
+// downloadContent pulls down the files of the resource to destPath according to its GitInfo
+func (git *downloadContext) downloadContent(log log.T, filesys filemanager.FileSystem, destPath string) (err error) {
+	info := git.Info
+	log = verboseLogger(log, info.Verbose)
 	log.Debug("Destination path from Download to download - ", destPath)
+	if info.BlobSha != "" {
+		return git.downloadBlob(log, filesys, info, destPath)
+	}
+
+	if info.Path, err = info.repositoryPath(); err != nil {
+		return err
+	}
+	// paths returned by GitHub are from the repository root, so directory entries keep the root path
+	info.RootPath = ""
+
+	if info.RefPattern != "" {
+		if info.GetOptions, err = git.resolveRefPattern(log, info); err != nil {
+			return err
+		}
+	} else if info.GetOptions == "" && git.defaultRef != "" {
+		log.Debugf("getOptions not specified, using configured default branch %v", git.defaultRef)
+		info.GetOptions = "branch:" + git.defaultRef
+	}
+	if info.UseDeployKey {
+		if err = git.downloadClone(log, filesys, info, destPath); err != nil || info.ValuesOverlay == nil {
+			return err
+		}
+		return git.applyValuesOverlay(log, filesys, info.ValuesOverlay, destPath)
+	}
+	if info.TreeSha != "" {
+		if err = git.verifyTreeSha(log, info); err != nil {
+			return err
+		}
+	}
+	if info.RequireSignature {
+		if err = git.verifySignature(log, info); err != nil {
+			return err
+		}
+	}
+	if info.Select != "" {
+		if info.Path, err = git.selectFile(log, info); err != nil {
+			return err
+		}
+	}
+	if info.Concatenate {
+		return git.downloadConcatenated(log, filesys, info, destPath)
+	}
+	if info.UseRawHost {
+		if info.TokenInfo != "" {
+			log.Debug("useRawHost only applies to public repositories, using the contents API")
+		} else if info.VerifySignature {
+			log.Debug("useRawHost does not verify signatures, using the contents API")
+		} else if info.AllowLfs {
+			log.Debug("useRawHost does not download Git LFS objects, using the contents API")
+		} else if info.PreserveFileMode {
+			log.Debug("useRawHost does not preserve file modes, using the contents API")
+		} else if info.ValuesOverlay != nil || info.StripComponents > 0 {
+			log.Debug("useRawHost does not download directories, using the contents API")
+		} else if err = git.downloadRaw(log, filesys, info, destPath); err == nil {
+			return nil
+		} else {
+			log.Warnf("Could not download %v from the raw content host, falling back to the contents API - %v", info.Path, err)
+		}
+	}
 	// call download that has object of type GitInfo that keeps changing recursively for directory download
 	// call is made with the assumption that the content is of file type
-	return git.download(log, filesys, git.Info, destPath, false)
+	if err = git.download(log, filesys, info, destPath, false); err != nil || info.ValuesOverlay == nil {
+		return err
+	}
+	return git.applyValuesOverlay(log, filesys, info.ValuesOverlay, destPath)
 }
 
 //download pulls down either the file or directory specified and stores it on disk
-func (git *GitResource) download(log log.T, filesys filemanager.FileSystem, info GitInfo, destinationDir string, isDirTypeDownload bool) (err error) {
+func (git *downloadContext) download(log log.T, filesys filemanager.FileSystem, info GitInfo, destinationDir string, isDirTypeDownload bool) (err error) {
 
 	opt, err := git.client.ParseGetOptions(log, info.GetOptions)
 	if err != nil {
 		return err
 	}
-	fileMetadata, directoryMetadata, err := git.client.GetRepositoryContents(log, info.Owner, info.Repository, info.Path, opt)
+	if !isDirTypeDownload {
+		log.Infof("Downloading %v from ref %v", info.Path, opt.Ref)
+	}
+	log.Debugf("Requesting contents of %v/%v/%v at ref %v", info.Owner, info.Repository, info.Path, opt.Ref)
+	fileMetadata, directoryMetadata, err := git.getRepositoryContents(log, info, opt)
 	if err != nil {
 		log.Error("Error occurred when trying to get repository contents - ", err)
 		return err
@@ -111,40 +614,131 @@ func (git *GitResource) download(log log.T, filesys filemanager.FileSystem, info
 	// If the resource is a directory, the content will be empty and the directoryMetadata is an array of all the files, directories.
 	// Each directory type needs to make a recursive call to Download to pull down the files within them.
 	if directoryMetadata != nil { // path received was of directory type
+		// files are downloaded concurrently while directories are walked in order, so flattened names are claimed in order
+		files := git.newDownloadGroup()
 		for _, dirContent := range directoryMetadata {
+			if info.VerifySignature && isSignatureFile(dirContent) {
+				// signatures are verified along with the files they sign
+				continue
+			}
+			if dirContent.GetType() == "file" && !isExtensionIncluded(info, dirContent.GetPath()) {
+				log.Debugf("Skipping %v, its extension is filtered out", dirContent.GetPath())
+				continue
+			}
 
 			dirInput := GitInfo{
-				Owner:      info.Owner,
-				Repository: info.Repository,
-				Path:       dirContent.GetPath(),
-				GetOptions: info.GetOptions,
-			}
-			destDir := filepath.Join(destinationDir, filepath.Base(dirContent.GetPath()))
-			if err = git.download(log, filesys, dirInput, destDir, true); err != nil {
-				log.Error("Error retrieving file from directory", destinationDir)
-				return err
+				Owner:           info.Owner,
+				Repository:      info.Repository,
+				Path:            dirContent.GetPath(),
+				GetOptions:      info.GetOptions,
+				SkipUnchanged:   info.SkipUnchanged,
+				PrefetchCache:   info.PrefetchCache,
+				Flatten:         info.Flatten,
+				Stream:          info.Stream,
+				VerifySignature: info.VerifySignature,
+
+				IncludeExtensions: info.IncludeExtensions,
+				ExcludeExtensions: info.ExcludeExtensions,
+				PreserveFileMode:  info.PreserveFileMode,
+			}
+			// the path comes from the repository, it may not leave the destination
+			var destDir string
+			if destDir, err = filemanager.SafeJoin(destinationDir, filepath.Base(dirContent.GetPath())); err != nil {
+				break
+			}
+			if info.StripComponents > 0 {
+				// the name of each entry at this level is stripped, so only directories can be
+				if dirContent.GetType() != "dir" {
+					err = fmt.Errorf("StripComponents for GitHub SourceType exceeds the depth of %v", dirContent.GetPath())
+					break
+				}
+				dirInput.StripComponents = info.StripComponents - 1
+				destDir = destinationDir
+			} else if info.Flatten {
+				if dirContent.GetType() == "dir" {
+					// the files of subdirectories all land in the destination itself
+					destDir = destinationDir
+				} else if err = git.claimFlattenedName(dirContent.GetPath(), destinationDir); err != nil {
+					break
+				}
+			}
+			if dirContent.GetType() != "file" {
+				if err = git.download(log, filesys, dirInput, destDir, true); err != nil {
+					log.Error("Error retrieving file from directory", destinationDir)
+					break
+				}
+				continue
+			}
+			dirContent := dirContent
+			started := files.Go(func() error {
+				if info.PrefetchCache {
+					mode, err := git.fileMode(log, info, opt.Ref, dirContent.GetPath())
+					if err != nil {
+						return err
+					}
+					if prefetched, err := git.downloadPrefetched(log, filesys, dirContent, destDir, mode); err != nil || prefetched {
+						return err
+					}
+				}
+				if err := git.download(log, filesys, dirInput, destDir, true); err != nil {
+					log.Error("Error retrieving file from directory", destinationDir)
+					return err
+				}
+				return nil
+			})
+			if !started {
+				break
 			}
 		}
-	} else if git.client.IsFileContentType(fileMetadata) { // If content returned is by GetRepositoryContents is a file, it needs to be saved on disk.
-		var content string
-		if content, err = fileMetadata.GetContent(); err != nil {
-			log.Error("File content could not be retrieved - ", err)
+		if filesErr := files.Wait(); err == nil {
+			err = filesErr
+		}
+		if err != nil {
 			return err
 		}
-
+	} else if git.client.IsFileContentType(fileMetadata) { // If content returned is by GetRepositoryContents is a file, it needs to be saved on disk.
+		if info.StripComponents > 0 { // a single file has no directories to strip
+			return fmt.Errorf("StripComponents for GitHub SourceType exceeds the depth of %v", fileMetadata.GetPath())
+		}
 		// all files and sub-directories will be placed under the specified destinationDir when a directory was downloaded
 		if !isDirTypeDownload { // If only a file was downloaded
-			// If the destinationDir has a path separator in the end, then the file should be appended to the directory
-			// also if the folder already exists
-			if (filesys.Exists(destinationDir) && filesys.IsDirectory(destinationDir)) || os.IsPathSeparator(destinationDir[len(destinationDir)-1]) {
-				destinationDir = filepath.Join(destinationDir, filepath.Base(fileMetadata.GetPath()))
+			destinationDir = remoteresource.FileDestination(filesys, destinationDir, fileMetadata.GetPath())
+		}
+
+		var recordPath string
+		if info.SkipUnchanged {
+			recordPath = downloadRecordPath(info.Owner, info.Repository, fileMetadata.GetPath(), opt.Ref)
+			if isUnchanged(log, filesys, recordPath, fileMetadata.GetSHA(), destinationDir) {
+				log.Infof("%v is unchanged since the last download, reusing %v", fileMetadata.GetPath(), destinationDir)
+				return git.recordExistingFile(filesys, destinationDir)
 			}
 		}
 
-		if err = system.SaveFileContent(log, filesys, destinationDir, content); err != nil {
-			log.Errorf("Error obtaining file content from GitHub file - %v, %v", fileMetadata.GetPath(), err)
+		if fileMetadata.Size != nil {
+			if err = artifact.CheckDeclaredSize(fileMetadata.GetPath(), int64(fileMetadata.GetSize()), git.maxFileSize); err != nil {
+				return err
+			}
+		}
+		if info.Stream {
+			err = git.streamFile(log, filesys, info, opt, fileMetadata.GetPath(), destinationDir)
+		} else {
+			var mode os.FileMode
+			if mode, err = git.fileMode(log, info, opt.Ref, fileMetadata.GetPath()); err != nil {
+				return err
+			}
+			err = git.saveContent(log, filesys, fileMetadata, destinationDir, mode)
+		}
+		if err != nil {
 			return err
 		}
+		if info.VerifySignature {
+			if err = git.verifyFileSignature(log, filesys, info, opt, fileMetadata.GetPath(), destinationDir); err != nil {
+				return err
+			}
+		}
+		if info.SkipUnchanged && fileMetadata.GetSHA() != "" {
+			recordDownload(log, filesys, recordPath, fileMetadata.GetSHA(), destinationDir)
+		}
 	} else {
 		return fmt.Errorf("Could not download from GitHub repository")
 	}
@@ -152,8 +746,308 @@ func (git *GitResource) download(log log.T, filesys filemanager.FileSystem, info
 	return err
 }
 
+// saveContent saves the content returned with the metadata of a file to destination with the permissions of mode, the default ones when 0
+func (git *downloadContext) saveContent(log log.T, filesys filemanager.FileSystem, fileMetadata *github.RepositoryContent, destination string, mode os.FileMode) (err error) {
+	var content string
+	if content, err = git.verifiedContent(log, fileMetadata); err != nil {
+		return err
+	}
+	if content, err = git.resolveLfs(log, fileMetadata.GetPath(), content); err != nil {
+		return err
+	}
+
+	if content, err = git.renderTemplate(log, fileMetadata.GetPath(), content); err != nil {
+		return err
+	}
+
+	log.Debugf("Saving %v (%v bytes) to %v", fileMetadata.GetPath(), len(content), destination)
+	if err = git.saveFile(log, filesys, destination, content, mode); err != nil {
+		log.Errorf("Error obtaining file content from GitHub file - %v, %v", fileMetadata.GetPath(), err)
+		return err
+	}
+	return nil
+}
+
+// fetchFile fetches the file at filePath, retrying transient failures, and returns its verified content
+func (git *downloadContext) fetchFile(log log.T, info GitInfo, opt *github.RepositoryContentGetOptions, filePath string) (string, error) {
+	fileInfo := info
+	fileInfo.Path = filePath
+	fileMetadata, _, err := git.getRepositoryContents(log, fileInfo, opt)
+	if err != nil {
+		log.Error("Error occurred when trying to get repository contents - ", err)
+		return "", err
+	}
+	return git.verifiedContent(log, fileMetadata)
+}
+
+// verifiedContent returns the decoded content of a file once it is checked against the size limit
+// and the blob SHA GitHub returned with it
+func (git *downloadContext) verifiedContent(log log.T, fileMetadata *github.RepositoryContent) (string, error) {
+	if fileMetadata != nil && fileMetadata.Size != nil {
+		if err := artifact.CheckDeclaredSize(fileMetadata.GetPath(), int64(fileMetadata.GetSize()), git.maxFileSize); err != nil {
+			return "", err
+		}
+	}
+	content, err := fileContent(fileMetadata)
+	if err != nil {
+		log.Error("File content could not be retrieved - ", err)
+		return "", err
+	}
+	if err = artifact.CheckDeclaredSize(fileMetadata.GetPath(), int64(len(content)), git.maxFileSize); err != nil {
+		return "", err
+	}
+	// a truncated or corrupted response no longer hashes to the blob SHA GitHub returned with it
+	if sha := fileMetadata.GetSHA(); blobShaPattern.MatchString(sha) && !strings.EqualFold(gitBlobSha(content, sha), sha) {
+		log.Errorf("Content of %v does not match its blob SHA %v", fileMetadata.GetPath(), sha)
+		return "", fmt.Errorf("Content of %v does not match its blob SHA %v", fileMetadata.GetPath(), sha)
+	}
+	return content, nil
+}
+
+// fileContent returns the decoded content of a file.
+// GitHub may leave the content out of the metadata of a zero-byte file, which is only empty when its declared size is 0.
+func fileContent(fileMetadata *github.RepositoryContent) (string, error) {
+	if fileMetadata == nil {
+		return "", errors.New("No file metadata, the path may be a directory")
+	}
+	if fileMetadata.Content == nil {
+		if fileMetadata.Size != nil && fileMetadata.GetSize() == 0 {
+			return "", nil
+		}
+		return "", fmt.Errorf("No content in the metadata of %v", fileMetadata.GetPath())
+	}
+	return fileMetadata.GetContent()
+}
+
+// renderTemplate substitutes the parameters of GitInfo into the content of the document at repositoryPath when substituteParameters is set,
+// other files are returned as they are
+func (git *downloadContext) renderTemplate(log log.T, repositoryPath string, content string) (string, error) {
+	if !git.Info.SubstituteParameters || !remoteresource.IsDocumentTemplate(log, repositoryPath, git.resourceTypes) {
+		return content, nil
+	}
+	log.Debugf("Substituting parameters into document %v", repositoryPath)
+	return remoteresource.RenderDocument(log, repositoryPath, content, git.Info.Parameters)
+}
+
+// resolveDefaultBranch returns the default branch of the repository, asking GitHub only the first time in a download
+func (git *downloadContext) resolveDefaultBranch(log log.T) (string, error) {
+	if git.repositoryDefaultBranch == "" {
+		branch, err := git.client.GetDefaultBranch(log, git.Info.Owner, git.Info.Repository)
+		if err != nil {
+			return "", err
+		}
+		log.Debugf("Default branch of %v/%v is %v", git.Info.Owner, git.Info.Repository, branch)
+		git.repositoryDefaultBranch = branch
+	}
+	return git.repositoryDefaultBranch, nil
+}
+
+// claimFlattenedName reserves the base name of filePath in a flattened download, failing if another file already has it.
+// Names are compared ignoring case since they would collide on case insensitive file systems.
+func (git *downloadContext) claimFlattenedName(filePath string, destinationDir string) error {
+	name := strings.ToLower(filepath.Base(filePath))
+	if previous, found := git.flattened[name]; found {
+		return fmt.Errorf("Cannot flatten %v into %v, %v has the same name", filePath, destinationDir, previous)
+	}
+	git.flattened[name] = filePath
+	return nil
+}
+
+// getRepositoryContents fetches the contents of info.Path, attempting it again with exponential backoff
+// while the fetch fails transiently, up to the configured number of attempts.
+// Each attempt is one call to the client, which waits out GitHub rate limits and retries secondary rate limits on its own.
+// The client fails with errors that aren't transient once it gives up on a rate limit, so those are not attempted again here,
+// and the retries of both are drawn from network.SharedRetryBudget: a fetch stops retrying once the budget of the host is spent.
+func (git *downloadContext) getRepositoryContents(log log.T, info GitInfo, opt *github.RepositoryContentGetOptions) (fileMetadata *github.RepositoryContent, directoryMetadata []*github.RepositoryContent, err error) {
+	maxAttempts := git.fetchMaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = appconfig.DefaultGitHubFetchMaxAttempts
+	}
+	for attempt := 1; ; attempt++ {
+		fileMetadata, directoryMetadata, err = git.client.GetRepositoryContents(log, info.Owner, info.Repository, info.Path, opt)
+		if err == nil || attempt >= maxAttempts || !githubclient.IsTransient(err) {
+			return fileMetadata, directoryMetadata, err
+		}
+		if !network.SharedRetryBudget().AllowRetry(git.apiHost) {
+			log.Warnf("Fetching %v failed and the retry budget of %v is spent", info.Path, git.apiHost)
+			return fileMetadata, directoryMetadata, err
+		}
+		wait := fileFetchRetryBackoff << uint(attempt-1)
+		log.Warnf("Fetching %v failed on attempt %v of %v, retrying in %v - %v", info.Path, attempt, maxAttempts, wait, err)
+		sleep(wait)
+	}
+}
+
+// isRepositoryAllowed returns true if owner/repository matches one of the allowed repository globs or none are configured
+func (git *GitResource) isRepositoryAllowed() bool {
+	if len(git.allowedRepositories) == 0 {
+		return true
+	}
+	// GitHub owner and repository names are case insensitive
+	repository := strings.ToLower(git.Info.Owner + "/" + git.Info.Repository)
+	for _, pattern := range git.allowedRepositories {
+		if matched, err := path.Match(strings.ToLower(pattern), repository); err == nil && matched {
+			return true
+		}
+	}
+	return false
+}
+
+// verifyTreeSha ensures the commit pinned in getOptions points to the expected tree
+func (git *downloadContext) verifyTreeSha(log log.T, info GitInfo) error {
+	opt, err := git.client.ParseGetOptions(log, info.GetOptions)
+	if err != nil {
+		return err
+	}
+	treeSha, err := git.client.GetCommitTreeSha(log, info.Owner, info.Repository, opt.Ref)
+	if err != nil {
+		return fmt.Errorf("Could not verify tree of commit %v - %v", opt.Ref, err)
+	}
+	if !strings.EqualFold(treeSha, info.TreeSha) {
+		return fmt.Errorf("Tree %v of commit %v does not match the expected tree %v", treeSha, opt.Ref, info.TreeSha)
+	}
+	log.Debugf("Tree %v of commit %v verified", treeSha, opt.Ref)
+	return nil
+}
+
+// downloadConcatenated joins the files in the info.Path directory in name order and saves them as a single file
+func (git *downloadContext) downloadConcatenated(log log.T, filesys filemanager.FileSystem, info GitInfo, destinationDir string) (err error) {
+	opt, err := git.client.ParseGetOptions(log, info.GetOptions)
+	if err != nil {
+		return err
+	}
+	_, directoryMetadata, err := git.getRepositoryContents(log, info, opt)
+	if err != nil {
+		log.Error("Error occurred when trying to get repository contents - ", err)
+		return err
+	}
+	if directoryMetadata == nil {
+		return fmt.Errorf("Path %v must be a directory to concatenate its files", info.Path)
+	}
+
+	var filePaths []string
+	for _, content := range directoryMetadata {
+		if !git.client.IsFileContentType(content) {
+			log.Debugf("Skipping %v, only files are concatenated", content.GetPath())
+			continue
+		}
+		if !isExtensionIncluded(info, content.GetPath()) {
+			log.Debugf("Skipping %v, its extension is filtered out", content.GetPath())
+			continue
+		}
+		if content.Size != nil {
+			if err = artifact.CheckDeclaredSize(content.GetPath(), int64(content.GetSize()), git.maxFileSize); err != nil {
+				return err
+			}
+		}
+		filePaths = append(filePaths, content.GetPath())
+	}
+	sort.Strings(filePaths)
+
+	var contents []string
+	for _, filePath := range filePaths {
+		content, err := git.fetchFile(log, info, opt, filePath)
+		if err != nil {
+			return err
+		}
+		contents = append(contents, content)
+	}
+
+	destination, err := filemanager.SafeJoin(destinationDir, info.DestinationFileName)
+	if err != nil {
+		return err
+	}
+	log.Infof("Concatenating %v files of %v into %v", len(contents), info.Path, destination)
+	if err = git.saveFile(log, filesys, destination, strings.Join(contents, info.Separator), 0); err != nil {
+		log.Errorf("Error saving concatenated files of %v - %v", info.Path, err)
+		return err
+	}
+	return nil
+}
+
+// selectFile lists the directory in info.Path and returns the path of the newest file matching info.NamePattern
+func (git *downloadContext) selectFile(log log.T, info GitInfo) (string, error) {
+	opt, err := git.client.ParseGetOptions(log, info.GetOptions)
+	if err != nil {
+		return "", err
+	}
+	_, directoryMetadata, err := git.getRepositoryContents(log, info, opt)
+	if err != nil {
+		log.Error("Error occurred when trying to get repository contents - ", err)
+		return "", err
+	}
+	if directoryMetadata == nil {
+		return "", fmt.Errorf("Path %v must be a directory to select the %v file from it", info.Path, info.Select)
+	}
+
+	pattern := info.NamePattern
+	if pattern == "" {
+		pattern = "*"
+	}
+	var candidates []string
+	for _, content := range directoryMetadata {
+		if !git.client.IsFileContentType(content) {
+			continue
+		}
+		matched, err := path.Match(pattern, path.Base(content.GetPath()))
+		if err != nil {
+			return "", fmt.Errorf("Name pattern %v is not valid - %v", pattern, err)
+		}
+		if matched {
+			candidates = append(candidates, content.GetPath())
+		}
+	}
+	if len(candidates) == 0 {
+		return "", fmt.Errorf("No file in %v matches the name pattern %v", info.Path, pattern)
+	}
+
+	// sort by name descending, which is also the tie-breaker order when sorting by commit date
+	sort.Sort(sort.Reverse(sort.StringSlice(candidates)))
+	if info.SortBy == sortByCommitDate {
+		dates := make(map[string]time.Time, len(candidates))
+		for _, candidate := range candidates {
+			if dates[candidate], err = git.client.GetLatestCommitDate(log, info.Owner, info.Repository, candidate, opt); err != nil {
+				return "", err
+			}
+		}
+		sort.SliceStable(candidates, func(i, j int) bool {
+			return dates[candidates[i]].After(dates[candidates[j]])
+		})
+		if len(candidates) > 1 && dates[candidates[0]].Equal(dates[candidates[1]]) {
+			return "", fmt.Errorf("Could not select the latest file in %v, %v and %v were committed at the same time", info.Path, candidates[0], candidates[1])
+		}
+	}
+
+	log.Infof("Selected %v as the %v file in %v", candidates[0], info.Select, info.Path)
+	return candidates[0], nil
+}
+
+// AuditLocation describes the repository, path and ref downloaded for the audit log, leaving out tokenInfo
+func (git *GitResource) AuditLocation() remoteresource.AuditLocation {
+	repositoryPath, err := git.Info.repositoryPath()
+	if err != nil {
+		repositoryPath = git.Info.Path
+	}
+	return remoteresource.AuditLocation{
+		Source: git.Info.Owner + "/" + git.Info.Repository,
+		Path:   repositoryPath,
+		Ref:    git.Info.GetOptions,
+	}
+}
+
 // ValidateLocationInfo ensures that the required parameters of SourceInfo are specified
 func (git *GitResource) ValidateLocationInfo() (valid bool, err error) {
+	if git.Info.Repo != "" {
+		owner, repository, err := splitRepo(git.Info.Repo)
+		if err != nil {
+			return false, err
+		}
+		// owner and repository are only set from repo, anything else means both forms were used
+		if git.Info.Owner != owner || git.Info.Repository != repository {
+			return false, errors.New("Specify either repo or owner and repository for source type GitHub, not both")
+		}
+	}
+
 	// source not yet supported
 	if git.Info.Owner == "" {
 		return false, errors.New("Owner for GitHub SourceType must be specified")
@@ -163,5 +1057,123 @@ func (git *GitResource) ValidateLocationInfo() (valid bool, err error) {
 		return false, errors.New("Repository for GitHub SourceType must be specified")
 	}
 
+	if !git.isRepositoryAllowed() {
+		return false, fmt.Errorf("Repository %v/%v is not allowed by the agent configuration", git.Info.Owner, git.Info.Repository)
+	}
+
+	if git.Info.RootPath != "" {
+		repositoryPath, err := git.Info.repositoryPath()
+		if err != nil {
+			return false, err
+		}
+		// Path is normalized to the path from the repository root for the checks and downloads that follow
+		git.Info.Path, git.Info.RootPath = repositoryPath, ""
+	}
+
+	if git.Info.RefPattern != "" {
+		if err := validateRefPattern(git.Info); err != nil {
+			return false, err
+		}
+	}
+
+	if err := githubclient.ValidateGetOptions(git.Info.GetOptions); err != nil {
+		return false, err
+	}
+
+	if git.Info.Endpoint != "" {
+		if _, err := githubclient.EnterpriseAPIURL(git.Info.Endpoint); err != nil {
+			return false, err
+		}
+		if len(git.Info.Mirrors) > 0 || git.Info.UseRawHost {
+			return false, errors.New("Endpoint for GitHub SourceType can't be combined with mirrors or useRawHost")
+		}
+	}
+
+	if git.Info.UseDeployKey {
+		if git.Info.TokenInfo != "" || len(git.Info.Mirrors) > 0 || git.Info.UseRawHost {
+			return false, errors.New("UseDeployKey for GitHub SourceType can't be combined with tokenInfo, mirrors or useRawHost")
+		}
+		if git.Info.BlobSha != "" || git.Info.RefPattern != "" || git.Info.TreeSha != "" || git.Info.RequireSignature || git.Info.VerifySignature ||
+			git.Info.Select != "" || git.Info.Concatenate || git.Info.Stream || git.Info.AllowLfs {
+			return false, errors.New("UseDeployKey for GitHub SourceType only supports downloading Path at getOptions, " +
+				"without blobSha, refPattern, treeSha, requireSignature, verifySignature, select, concatenate, stream or allowLfs")
+		}
+	}
+
+	if git.Info.Select != "" && git.Info.Select != selectLatest {
+		return false, fmt.Errorf("Select for GitHub SourceType must be %v", selectLatest)
+	}
+
+	if git.Info.TreeSha != "" && !strings.HasPrefix(git.Info.GetOptions, "commitID:") {
+		return false, errors.New("TreeSha for GitHub SourceType requires getOptions to pin a commitID")
+	}
+
+	if git.Info.BlobSha != "" {
+		if err := validateBlobSha(git.Info); err != nil {
+			return false, err
+		}
+	}
+
+	if git.Info.Concatenate && git.Info.DestinationFileName == "" {
+		return false, errors.New("DestinationFileName for GitHub SourceType must be specified to concatenate files")
+	}
+
+	if git.Info.SubstituteParameters && (git.Info.Stream || git.Info.SkipUnchanged) {
+		return false, errors.New("SubstituteParameters for GitHub SourceType can't be combined with stream or skipUnchanged")
+	}
+
+	if git.Info.PrefetchCache && (git.Info.Stream || git.Info.SkipUnchanged || git.Info.VerifySignature) {
+		return false, errors.New("PrefetchCache for GitHub SourceType can't be combined with stream, skipUnchanged or verifySignature")
+	}
+
+	if git.Info.AllowLfs && (git.Info.Stream || git.Info.Concatenate) {
+		return false, errors.New("AllowLfs for GitHub SourceType can't be combined with stream or concatenate")
+	}
+
+	if git.Info.PreserveFileMode && (git.Info.Stream || git.Info.Concatenate) {
+		return false, errors.New("PreserveFileMode for GitHub SourceType can't be combined with stream or concatenate")
+	}
+
+	if git.Info.NormalizeLineEndings && (git.Info.Stream || git.Info.VerifySignature) {
+		return false, errors.New("NormalizeLineEndings for GitHub SourceType can't be combined with stream or verifySignature")
+	}
+
+	if git.Info.VerifySignature {
+		if git.signaturePublicKey == "" {
+			return false, errors.New("VerifySignature for GitHub SourceType requires a signature public key in the agent configuration")
+		}
+		if git.Info.Concatenate || git.Info.SubstituteParameters {
+			return false, errors.New("VerifySignature for GitHub SourceType can't be combined with concatenate or substituteParameters")
+		}
+	}
+
+	if git.Info.MaxTotalBytes < 0 {
+		return false, errors.New("MaxTotalBytes for GitHub SourceType can't be negative")
+	}
+	if git.Info.TimeoutSeconds < 0 {
+		return false, errors.New("TimeoutSeconds for GitHub SourceType can't be negative")
+	}
+
+	if git.Info.StripComponents < 0 {
+		return false, errors.New("StripComponents for GitHub SourceType can't be negative")
+	}
+
+	if git.Info.StripComponents > 0 && (git.Info.Concatenate || git.Info.Flatten) {
+		return false, errors.New("StripComponents for GitHub SourceType can't be combined with concatenate or flatten")
+	}
+
+	if git.Info.ValuesOverlay != nil {
+		if git.Info.Concatenate || git.Info.Flatten {
+			return false, errors.New("ValuesOverlay for GitHub SourceType can't be combined with concatenate or flatten")
+		}
+		if err := validateValuesOverlay(git.Info.ValuesOverlay); err != nil {
+			return false, err
+		}
+	}
+
+	if git.Info.SortBy != "" && git.Info.SortBy != sortByName && git.Info.SortBy != sortByCommitDate {
+		return false, fmt.Errorf("SortBy for GitHub SourceType must be either %v or %v", sortByName, sortByCommitDate)
+	}
+
 	return true, nil
 }
@@ -16,24 +16,42 @@
 package gitresource
 
 import (
+	"github.com/aws/amazon-ssm-agent/agent/fileutil/filemanager"
 	filemock "github.com/aws/amazon-ssm-agent/agent/fileutil/filemanager/mock"
 	githubclientmock "github.com/aws/amazon-ssm-agent/agent/githubclient/mock"
 
 	"github.com/aws/amazon-ssm-agent/agent/appconfig"
 	"github.com/aws/amazon-ssm-agent/agent/fileutil"
+	"github.com/aws/amazon-ssm-agent/agent/githubclient"
 	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/aws/amazon-ssm-agent/agent/network"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/downloadcontent/remoteresource"
 	"github.com/go-github/github"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 
+	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"net/http/httptest"
+	"net/url"
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 )
 
-var logMock = log.NewMockLog()
+var logMock = mockLog{log.NewMockLog()}
+
+// mockLog prints as its name so mocks matching it as an argument do not format every call it has recorded
+type mockLog struct {
+	*log.Mock
+}
+
+func (mockLog) String() string {
+	return "logMock"
+}
 
 func NewResourceWithMockedClient(mockClient *githubclientmock.ClientMock) *GitResource {
 	gitInfo := GitInfo{
@@ -88,6 +106,45 @@ func TestGitResource_DownloadFile(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestGitResource_DownloadFileVerifiesBlobSha(t *testing.T) {
+	// the blob SHA of "hello\n"
+	sha := "ce013625030ba8dba906f756967f9e9ca394464a"
+	tests := []struct {
+		content string
+		err     string
+	}{
+		{"hello\n", ""},
+		{"hel", "Content of path/to/file.sh does not match its blob SHA " + sha},
+	}
+	for _, test := range tests {
+		t.Run(test.content, func(t *testing.T) {
+			clientMock := githubclientmock.ClientMock{}
+			opt := &github.RepositoryContentGetOptions{Ref: ""}
+			content := test.content
+			file := repositoryContent("file", "path/to/file.sh", 6, sha)
+			file.Content = &content
+			clientMock.On("ParseGetOptions", logMock, "").Return(opt, nil)
+			clientMock.On("GetRepositoryContents", logMock, "owner", "repo", "path/to/file.sh", opt).Return(file, []*github.RepositoryContent(nil), nil).Once()
+			clientMock.On("IsFileContentType", mock.AnythingOfType("*github.RepositoryContent")).Return(true)
+
+			gitResource := NewResourceWithMockedClient(&clientMock)
+			gitResource.Info.Path = "path/to/file.sh"
+			filesys := filemanager.NewMemoryFileSystem()
+
+			err := gitResource.Download(logMock, filesys, "destination")
+
+			clientMock.AssertExpectations(t)
+			if test.err == "" {
+				assert.NoError(t, err)
+				assert.True(t, filesys.Exists("destination"))
+			} else {
+				assert.EqualError(t, err, test.err)
+				assert.False(t, filesys.Exists("destination"))
+			}
+		})
+	}
+}
+
 func TestGitResource_DownloadDirectory(t *testing.T) {
 	clientMock := githubclientmock.ClientMock{}
 
@@ -132,6 +189,472 @@ func TestGitResource_DownloadDirectory(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestGitResource_DownloadRootPathFile(t *testing.T) {
+	clientMock := githubclientmock.ClientMock{}
+	opt := &github.RepositoryContentGetOptions{Ref: ""}
+
+	content := "content"
+	file := "file"
+	gitpath := "services/foo/scripts/run.sh"
+	fileMetadata := github.RepositoryContent{
+		Content: &content,
+		Type:    &file,
+		Path:    &gitpath,
+	}
+
+	gitResource := &GitResource{
+		client: &clientMock,
+		Info: GitInfo{
+			Owner:      "owner",
+			Repository: "repo",
+			RootPath:   "services/foo/",
+			Path:       "scripts/run.sh",
+		},
+	}
+	clientMock.On("ParseGetOptions", logMock, "").Return(opt, nil)
+	var nilDirMetadata []*github.RepositoryContent
+	clientMock.On("GetRepositoryContents", logMock, "owner", "repo", gitpath, opt).Return(&fileMetadata, nilDirMetadata, nil).Once()
+	clientMock.On("IsFileContentType", mock.AnythingOfType("*github.RepositoryContent")).Return(true)
+
+	fileMock := filemock.FileSystemMock{}
+	fileMock.On("IsDirectory", appconfig.DownloadRoot).Return(true)
+	fileMock.On("Exists", appconfig.DownloadRoot).Return(true)
+	fileMock.On("MakeDirs", strings.TrimSuffix(appconfig.DownloadRoot, "/")).Return(nil)
+	fileMock.On("WriteFile", filepath.Join(appconfig.DownloadRoot, "run.sh"), content).Return(nil)
+
+	err := gitResource.Download(logMock, fileMock, "")
+	clientMock.AssertExpectations(t)
+	fileMock.AssertExpectations(t)
+	assert.NoError(t, err)
+}
+
+func TestGitResource_DownloadRootPathDirectory(t *testing.T) {
+	clientMock := githubclientmock.ClientMock{}
+	opt := &github.RepositoryContentGetOptions{Ref: ""}
+
+	content := "content"
+	file, dir := "file", "dir"
+	dirPath := "services/foo/conf"
+	subDirPath := "services/foo/conf/app"
+	filePath := "services/foo/conf/app/app.json"
+	subDirMetadata := github.RepositoryContent{Type: &dir, Path: &subDirPath}
+	fileMetadata := github.RepositoryContent{Content: &content, Type: &file, Path: &filePath}
+	var nilFileMetadata github.RepositoryContent
+	var nilDirMetadata []*github.RepositoryContent
+
+	gitResource := &GitResource{
+		client: &clientMock,
+		Info: GitInfo{
+			Owner:      "owner",
+			Repository: "repo",
+			RootPath:   "services/foo",
+			Path:       "./conf/",
+		},
+	}
+	clientMock.On("ParseGetOptions", logMock, "").Return(opt, nil)
+	clientMock.On("GetRepositoryContents", logMock, "owner", "repo", dirPath, opt).Return(&nilFileMetadata, []*github.RepositoryContent{&subDirMetadata}, nil).Once()
+	clientMock.On("GetRepositoryContents", logMock, "owner", "repo", subDirPath, opt).Return(&nilFileMetadata, []*github.RepositoryContent{&fileMetadata}, nil).Once()
+	clientMock.On("GetRepositoryContents", logMock, "owner", "repo", filePath, opt).Return(&fileMetadata, nilDirMetadata, nil).Once()
+	clientMock.On("IsFileContentType", mock.AnythingOfType("*github.RepositoryContent")).Return(true)
+
+	fileMock := filemock.FileSystemMock{}
+	fileMock.On("MakeDirs", filepath.Join("destination", "app")).Return(nil)
+	fileMock.On("WriteFile", filepath.Join("destination", "app", "app.json"), content).Return(nil)
+
+	err := gitResource.Download(logMock, fileMock, "destination")
+	clientMock.AssertExpectations(t)
+	fileMock.AssertExpectations(t)
+	assert.NoError(t, err)
+}
+
+func TestGitResource_ValidateLocationInfoRootPath(t *testing.T) {
+	data := []struct {
+		name          string
+		rootPath      string
+		path          string
+		expectedPath  string
+		expectedError string
+	}{
+		{"relative to root path", "services/foo", "scripts/../run.sh", "services/foo/run.sh", ""},
+		{"sibling of root path", "services/foo", "../bar/run.sh", "", "must be within RootPath"},
+		{"root path outside repository", "../services", "run.sh", "", "must be within the repository"},
+		{"whole root path", "/services/foo/", "", "services/foo", ""},
+	}
+	for _, testdata := range data {
+		t.Run(testdata.name, func(t *testing.T) {
+			gitResource := &GitResource{
+				Info: GitInfo{Owner: "owner", Repository: "repo", RootPath: testdata.rootPath, Path: testdata.path},
+			}
+			valid, err := gitResource.ValidateLocationInfo()
+			if testdata.expectedError != "" {
+				assert.False(t, valid)
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), testdata.expectedError)
+				return
+			}
+			assert.True(t, valid)
+			assert.NoError(t, err)
+			assert.Equal(t, testdata.expectedPath, gitResource.Info.Path)
+		})
+	}
+}
+
+func TestGitResource_DownloadSubstituteParameters(t *testing.T) {
+	template := `{"schemaVersion": "2.2", "parameters": {"message": {"type": "String"}}, ` +
+		`"mainSteps": [{"action": "aws:runShellScript", "name": "echo", "inputs": {"runCommand": ["echo {{ message }}"]}}]}`
+	data := []struct {
+		name          string
+		parameters    map[string]interface{}
+		expectedError string
+	}{
+		{"substituted", map[string]interface{}{"message": "hello"}, ""},
+		{"required parameter missing", map[string]interface{}{}, "required parameters message were not provided"},
+	}
+	for _, testdata := range data {
+		t.Run(testdata.name, func(t *testing.T) {
+			clientMock := githubclientmock.ClientMock{}
+			opt := &github.RepositoryContentGetOptions{Ref: ""}
+			file := "file"
+			gitpath := "documents/echo.json"
+			fileMetadata := github.RepositoryContent{Content: &template, Type: &file, Path: &gitpath}
+			var nilDirMetadata []*github.RepositoryContent
+
+			gitResource := &GitResource{
+				client: &clientMock,
+				Info: GitInfo{
+					Owner:                "owner",
+					Repository:           "repo",
+					Path:                 gitpath,
+					SubstituteParameters: true,
+					Parameters:           testdata.parameters,
+				},
+			}
+			clientMock.On("ParseGetOptions", logMock, "").Return(opt, nil)
+			clientMock.On("GetRepositoryContents", logMock, "owner", "repo", gitpath, opt).Return(&fileMetadata, nilDirMetadata, nil).Once()
+			clientMock.On("IsFileContentType", mock.AnythingOfType("*github.RepositoryContent")).Return(true)
+
+			fileMock := filemock.FileSystemMock{}
+			fileMock.On("IsDirectory", "destination").Return(true)
+			fileMock.On("Exists", "destination").Return(true)
+			if testdata.expectedError == "" {
+				fileMock.On("MakeDirs", "destination").Return(nil)
+				fileMock.On("WriteFile", filepath.Join("destination", "echo.json"), mock.MatchedBy(func(content string) bool {
+					return strings.Contains(content, "echo hello") && !strings.Contains(content, "{{ message }}")
+				})).Return(nil).Once()
+			}
+
+			err := gitResource.Download(logMock, fileMock, "destination")
+			clientMock.AssertExpectations(t)
+			fileMock.AssertExpectations(t)
+			if testdata.expectedError == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), testdata.expectedError)
+			}
+		})
+	}
+}
+
+func TestGitResource_ValidateLocationInfoSubstituteParameters(t *testing.T) {
+	gitResource := &GitResource{
+		Info: GitInfo{Owner: "owner", Repository: "repo", Path: "doc.json", SubstituteParameters: true, Stream: true},
+	}
+	valid, err := gitResource.ValidateLocationInfo()
+	assert.False(t, valid)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "SubstituteParameters")
+}
+
+func TestGitResource_DownloadDirectoryRetriesFile(t *testing.T) {
+	defer func() { sleep = time.Sleep }()
+
+	data := []struct {
+		name        string
+		failures    int
+		expectedErr bool
+	}{
+		{"file succeeds after transient failures", 2, false},
+		{"file exhausts its attempts", appconfig.DefaultGitHubFetchMaxAttempts, true},
+	}
+	for _, testdata := range data {
+		t.Run(testdata.name, func(t *testing.T) {
+			var waits []time.Duration
+			sleep = func(d time.Duration) { waits = append(waits, d) }
+
+			clientMock := githubclientmock.ClientMock{}
+			opt := &github.RepositoryContentGetOptions{Ref: ""}
+			connectionReset := &url.Error{Op: "Get", URL: "https://api.github.com", Err: errors.New("connection reset by peer")}
+			content := "content"
+			flakyFile := repositoryContent("file", "path/to/dir/flaky.rb", 7, "blob1")
+			flakyFile.Content = &content
+			stableFile := repositoryContent("file", "path/to/dir/stable.rb", 7, "blob2")
+			stableFile.Content = &content
+
+			clientMock.On("ParseGetOptions", logMock, "").Return(opt, nil)
+			clientMock.On("GetRepositoryContents", logMock, "owner", "repo", "path/to/dir/", opt).Return((*github.RepositoryContent)(nil), []*github.RepositoryContent{flakyFile, stableFile}, nil).Once()
+			clientMock.On("GetRepositoryContents", logMock, "owner", "repo", "path/to/dir/flaky.rb", opt).Return((*github.RepositoryContent)(nil), []*github.RepositoryContent(nil), connectionReset).Times(testdata.failures)
+			fileMock := filemock.FileSystemMock{}
+			if !testdata.expectedErr {
+				clientMock.On("IsFileContentType", mock.AnythingOfType("*github.RepositoryContent")).Return(true)
+				clientMock.On("GetRepositoryContents", logMock, "owner", "repo", "path/to/dir/flaky.rb", opt).Return(flakyFile, []*github.RepositoryContent(nil), nil).Once()
+				clientMock.On("GetRepositoryContents", logMock, "owner", "repo", "path/to/dir/stable.rb", opt).Return(stableFile, []*github.RepositoryContent(nil), nil).Once()
+				fileMock.On("MakeDirs", mock.Anything).Return(nil)
+				fileMock.On("WriteFile", mock.Anything, "content").Return(nil)
+			}
+
+			gitResource := NewResourceWithMockedClient(&clientMock)
+			gitResource.Info.Path = "path/to/dir/"
+
+			err := gitResource.Download(logMock, fileMock, "destination")
+
+			clientMock.AssertExpectations(t)
+			if testdata.expectedErr {
+				assert.Equal(t, connectionReset, err)
+				assert.Equal(t, []time.Duration{fileFetchRetryBackoff, 2 * fileFetchRetryBackoff}, waits)
+				clientMock.AssertNotCalled(t, "GetRepositoryContents", logMock, "owner", "repo", "path/to/dir/stable.rb", opt)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, []time.Duration{fileFetchRetryBackoff, 2 * fileFetchRetryBackoff}, waits)
+			}
+		})
+	}
+}
+
+func TestGitResource_DownloadRetriesTransientFailures(t *testing.T) {
+	defer func() { sleep = time.Sleep }()
+
+	serverError := &github.ErrorResponse{Response: &http.Response{StatusCode: http.StatusServiceUnavailable}}
+	data := []struct {
+		name             string
+		err              error
+		fetchMaxAttempts int
+		expectedAttempts int
+	}{
+		{"server error", serverError, 0, appconfig.DefaultGitHubFetchMaxAttempts},
+		{"too many requests", &github.ErrorResponse{Response: &http.Response{StatusCode: http.StatusTooManyRequests}}, 0, appconfig.DefaultGitHubFetchMaxAttempts},
+		{"configured attempts", serverError, 5, 5},
+		{"not found", &github.ErrorResponse{Response: &http.Response{StatusCode: http.StatusNotFound}}, 0, 1},
+		{"unauthorized", &github.ErrorResponse{Response: &http.Response{StatusCode: http.StatusUnauthorized}}, 0, 1},
+		// the client already retried the secondary rate limit
+		{"secondary rate limit given up by the client", errors.New("GitHub secondary rate limit still exceeded after 3 retries. Error - abuse detection"), 0, 1},
+	}
+	for _, testdata := range data {
+		t.Run(testdata.name, func(t *testing.T) {
+			var waits []time.Duration
+			sleep = func(d time.Duration) { waits = append(waits, d) }
+
+			clientMock := githubclientmock.ClientMock{}
+			opt := &github.RepositoryContentGetOptions{Ref: "master"}
+			clientMock.On("ParseGetOptions", logMock, "").Return(opt, nil)
+			clientMock.On("GetRepositoryContents", logMock, "owner", "repo", "path/to/file.ext", opt).Return((*github.RepositoryContent)(nil), []*github.RepositoryContent(nil), testdata.err).Times(testdata.expectedAttempts)
+
+			gitResource := NewResourceWithMockedClient(&clientMock)
+			gitResource.fetchMaxAttempts = testdata.fetchMaxAttempts
+
+			err := gitResource.Download(logMock, filemanager.NewMemoryFileSystem(), "destination")
+
+			clientMock.AssertExpectations(t)
+			assert.Equal(t, testdata.err, err)
+			assert.Len(t, waits, testdata.expectedAttempts-1)
+			for i, wait := range waits {
+				assert.Equal(t, fileFetchRetryBackoff<<uint(i), wait)
+			}
+		})
+	}
+}
+
+// hostBudget records the hosts whose retry budget is spent
+type hostBudget struct {
+	hosts []string
+}
+
+func (b *hostBudget) AllowRetry(host string) bool {
+	b.hosts = append(b.hosts, host)
+	return true
+}
+
+func (b *hostBudget) RecordSuccess(host string) {}
+
+func TestGitResource_DownloadRetriesSpendTheBudgetOfTheMirror(t *testing.T) {
+	sleep = func(d time.Duration) {}
+	defer func() { sleep = time.Sleep }()
+	budget := &hostBudget{}
+	network.SetSharedRetryBudget(budget)
+	defer network.SetSharedRetryBudget(nil)
+
+	opt := &github.RepositoryContentGetOptions{Ref: "master"}
+	serverError := &github.ErrorResponse{Response: &http.Response{StatusCode: http.StatusServiceUnavailable}}
+	var mirrors []gitMirror
+	for _, host := range []string{"github.example.com", "api.github.com"} {
+		clientMock := &githubclientmock.ClientMock{}
+		clientMock.On("ParseGetOptions", logMock, "").Return(opt, nil)
+		clientMock.On("GetRepositoryContents", logMock, "owner", "repo", "path/to/file.ext", opt).Return((*github.RepositoryContent)(nil), []*github.RepositoryContent(nil), serverError).Twice()
+		mirrors = append(mirrors, gitMirror{name: host, client: clientMock, host: host})
+	}
+	gitResource := NewResourceWithMockedClient(&githubclientmock.ClientMock{})
+	gitResource.fetchMaxAttempts = 2
+	gitResource.mirrors = mirrors
+
+	err := gitResource.Download(logMock, filemanager.NewMemoryFileSystem(), "destination")
+
+	assert.Equal(t, serverError, err)
+	assert.Equal(t, []string{"github.example.com", "api.github.com"}, budget.hosts)
+}
+
+func TestGitResource_DownloadUnavailableForLegalReasonsIsNotRetried(t *testing.T) {
+	sleep = func(d time.Duration) { assert.Fail(t, "unexpected retry") }
+	defer func() { sleep = time.Sleep }()
+
+	clientMock := githubclientmock.ClientMock{}
+	opt := &github.RepositoryContentGetOptions{Ref: ""}
+	blockedFile := repositoryContent("file", "path/to/dir/blocked.rb", 7, "blob1")
+	legalErr := &githubclient.LegalUnavailableError{Owner: "owner", Repository: "repo", Err: errors.New("451 Repository access blocked")}
+	clientMock.On("ParseGetOptions", logMock, "").Return(opt, nil)
+	clientMock.On("GetRepositoryContents", logMock, "owner", "repo", "path/to/dir/", opt).Return((*github.RepositoryContent)(nil), []*github.RepositoryContent{blockedFile}, nil).Once()
+	clientMock.On("GetRepositoryContents", logMock, "owner", "repo", "path/to/dir/blocked.rb", opt).Return((*github.RepositoryContent)(nil), []*github.RepositoryContent(nil), legalErr).Once()
+
+	gitResource := NewResourceWithMockedClient(&clientMock)
+	gitResource.Info.Path = "path/to/dir/"
+
+	err := gitResource.Download(logMock, filemanager.NewMemoryFileSystem(), "destination")
+
+	clientMock.AssertExpectations(t)
+	assert.Equal(t, legalErr, err)
+}
+
+func TestGitResource_DownloadFlatten(t *testing.T) {
+	data := []struct {
+		name        string
+		nestedName  string
+		expectedErr string
+	}{
+		{"distinct names", "common.sh", ""},
+		{"collision", "RUN.sh", "Cannot flatten bundle/lib/RUN.sh into destination, bundle/run.sh has the same name"},
+	}
+	for _, testdata := range data {
+		t.Run(testdata.name, func(t *testing.T) {
+			clientMock := githubclientmock.ClientMock{}
+			opt := &github.RepositoryContentGetOptions{Ref: "master"}
+			content := "content"
+			topFile := repositoryContent("file", "bundle/run.sh", len(content), "blob1")
+			topFile.Content = &content
+			nestedFile := repositoryContent("file", "bundle/lib/"+testdata.nestedName, len(content), "blob2")
+			nestedFile.Content = &content
+
+			clientMock.On("ParseGetOptions", logMock, "").Return(opt, nil)
+			clientMock.On("GetRepositoryContents", logMock, "owner", "repo", "bundle", opt).Return((*github.RepositoryContent)(nil), []*github.RepositoryContent{
+				topFile,
+				repositoryContent("dir", "bundle/lib", 0, "tree1"),
+			}, nil).Once()
+			clientMock.On("GetRepositoryContents", logMock, "owner", "repo", "bundle/run.sh", opt).Return(topFile, []*github.RepositoryContent(nil), nil).Once()
+			clientMock.On("GetRepositoryContents", logMock, "owner", "repo", "bundle/lib", opt).Return((*github.RepositoryContent)(nil), []*github.RepositoryContent{nestedFile}, nil).Once()
+			clientMock.On("IsFileContentType", mock.AnythingOfType("*github.RepositoryContent")).Return(true)
+
+			fileMock := filemock.FileSystemMock{}
+			fileMock.On("MakeDirs", "destination").Return(nil)
+			fileMock.On("WriteFile", filepath.Join("destination", "run.sh"), content).Return(nil)
+			if testdata.expectedErr == "" {
+				clientMock.On("GetRepositoryContents", logMock, "owner", "repo", "bundle/lib/"+testdata.nestedName, opt).Return(nestedFile, []*github.RepositoryContent(nil), nil).Once()
+				fileMock.On("WriteFile", filepath.Join("destination", testdata.nestedName), content).Return(nil)
+			}
+
+			gitResource := NewResourceWithMockedClient(&clientMock)
+			gitResource.Info.Path = "bundle"
+			gitResource.Info.Flatten = true
+
+			err := gitResource.Download(logMock, fileMock, "destination")
+
+			clientMock.AssertExpectations(t)
+			fileMock.AssertExpectations(t)
+			if testdata.expectedErr == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, testdata.expectedErr)
+				clientMock.AssertNotCalled(t, "GetRepositoryContents", logMock, "owner", "repo", "bundle/lib/"+testdata.nestedName, opt)
+			}
+		})
+	}
+}
+
+func TestGitResource_DownloadStripComponents(t *testing.T) {
+	data := []struct {
+		name            string
+		stripComponents int
+		expectedPath    string
+		expectedErr     string
+	}{
+		{"none", 0, filepath.Join("nested", "path", "file.sh"), ""},
+		{"one", 1, filepath.Join("path", "file.sh"), ""},
+		{"all directories", 2, "file.sh", ""},
+		{"too many", 3, "", "StripComponents for GitHub SourceType exceeds the depth of deep/nested/path/file.sh"},
+	}
+	for _, testdata := range data {
+		t.Run(testdata.name, func(t *testing.T) {
+			clientMock := githubclientmock.ClientMock{}
+			opt := &github.RepositoryContentGetOptions{Ref: "master"}
+			content := "content"
+			file := repositoryContent("file", "deep/nested/path/file.sh", len(content), "blob1")
+			file.Content = &content
+
+			clientMock.On("ParseGetOptions", logMock, "").Return(opt, nil)
+			clientMock.On("GetRepositoryContents", logMock, "owner", "repo", "deep", opt).Return((*github.RepositoryContent)(nil), []*github.RepositoryContent{
+				repositoryContent("dir", "deep/nested", 0, "tree1"),
+			}, nil).Once()
+			clientMock.On("GetRepositoryContents", logMock, "owner", "repo", "deep/nested", opt).Return((*github.RepositoryContent)(nil), []*github.RepositoryContent{
+				repositoryContent("dir", "deep/nested/path", 0, "tree2"),
+			}, nil).Once()
+			clientMock.On("GetRepositoryContents", logMock, "owner", "repo", "deep/nested/path", opt).Return((*github.RepositoryContent)(nil), []*github.RepositoryContent{file}, nil).Once()
+			if testdata.expectedErr == "" {
+				clientMock.On("GetRepositoryContents", logMock, "owner", "repo", "deep/nested/path/file.sh", opt).Return(file, []*github.RepositoryContent(nil), nil).Once()
+				clientMock.On("IsFileContentType", mock.AnythingOfType("*github.RepositoryContent")).Return(true)
+			}
+
+			gitResource := NewResourceWithMockedClient(&clientMock)
+			gitResource.Info.Path = "deep"
+			gitResource.Info.StripComponents = testdata.stripComponents
+			valid, err := gitResource.ValidateLocationInfo()
+			assert.True(t, valid)
+			assert.NoError(t, err)
+			filesys := filemanager.NewMemoryFileSystem()
+
+			err = gitResource.Download(logMock, filesys, "destination")
+
+			clientMock.AssertExpectations(t)
+			if testdata.expectedErr == "" {
+				assert.NoError(t, err)
+				assert.Equal(t, []string{filepath.Join("destination", testdata.expectedPath)}, filesys.Files())
+			} else {
+				assert.EqualError(t, err, testdata.expectedErr)
+				assert.Empty(t, filesys.Files())
+			}
+		})
+	}
+}
+
+func TestGitResource_ValidateLocationInfoStripComponents(t *testing.T) {
+	data := []struct {
+		name        string
+		info        GitInfo
+		expectedErr string
+	}{
+		{"negative", GitInfo{StripComponents: -1}, "StripComponents for GitHub SourceType can't be negative"},
+		{"flatten", GitInfo{StripComponents: 1, Flatten: true}, "StripComponents for GitHub SourceType can't be combined with concatenate or flatten"},
+		{"concatenate", GitInfo{StripComponents: 1, Concatenate: true, DestinationFileName: "all.sh"}, "StripComponents for GitHub SourceType can't be combined with concatenate or flatten"},
+	}
+	for _, testdata := range data {
+		t.Run(testdata.name, func(t *testing.T) {
+			testdata.info.Owner, testdata.info.Repository, testdata.info.Path = "owner", "repo", "deep"
+			gitResource := &GitResource{Info: testdata.info}
+
+			valid, err := gitResource.ValidateLocationInfo()
+
+			assert.False(t, valid)
+			assert.EqualError(t, err, testdata.expectedErr)
+		})
+	}
+}
+
 func TestGitResource_DownloadFileMissing(t *testing.T) {
 	clientMock := githubclientmock.ClientMock{}
 
@@ -163,6 +686,95 @@ func TestGitResource_DownloadFileMissing(t *testing.T) {
 	assert.Contains(t, err.Error(), "Could not download from GitHub repository")
 }
 
+func TestGitResource_DownloadEmptyFile(t *testing.T) {
+	empty, base64Encoding := "", "base64"
+	size := 0
+	data := []struct {
+		name     string
+		metadata github.RepositoryContent
+	}{
+		{"empty content", github.RepositoryContent{Content: &empty, Encoding: &base64Encoding, Size: &size}},
+		{"no content", github.RepositoryContent{Size: &size}},
+	}
+	for _, testdata := range data {
+		t.Run(testdata.name+" as file", func(t *testing.T) {
+			clientMock := githubclientmock.ClientMock{}
+			opt := &github.RepositoryContentGetOptions{Ref: ""}
+			file, filePath := "file", "path/to/empty.txt"
+			fileMetadata := testdata.metadata
+			fileMetadata.Type, fileMetadata.Path = &file, &filePath
+			clientMock.On("ParseGetOptions", logMock, "").Return(opt, nil)
+			clientMock.On("GetRepositoryContents", logMock, "owner", "repo", filePath, opt).Return(&fileMetadata, []*github.RepositoryContent(nil), nil).Once()
+			clientMock.On("IsFileContentType", mock.AnythingOfType("*github.RepositoryContent")).Return(true)
+
+			gitResource := NewResourceWithMockedClient(&clientMock)
+			gitResource.Info.Path = filePath
+			filesys := filemanager.NewMemoryFileSystem()
+			destination := filepath.Join("/var", "tmp", "empty.txt")
+
+			err := gitResource.Download(logMock, filesys, destination)
+
+			assert.NoError(t, err)
+			clientMock.AssertExpectations(t)
+			assert.Equal(t, []string{destination}, filesys.Files())
+			saved, err := filesys.ReadFile(destination)
+			assert.NoError(t, err)
+			assert.Empty(t, saved)
+		})
+		t.Run(testdata.name+" in directory", func(t *testing.T) {
+			clientMock := githubclientmock.ClientMock{}
+			opt := &github.RepositoryContentGetOptions{Ref: ""}
+			content, file := "content", "file"
+			dirPath, filePath, emptyPath := "path/to/dir", "path/to/dir/app.json", "path/to/dir/.keep"
+			fileMetadata := github.RepositoryContent{Content: &content, Type: &file, Path: &filePath}
+			emptyMetadata := testdata.metadata
+			emptyMetadata.Type, emptyMetadata.Path = &file, &emptyPath
+			dirMetadata := []*github.RepositoryContent{
+				{Type: &file, Path: &filePath},
+				{Type: &file, Path: &emptyPath},
+			}
+			clientMock.On("ParseGetOptions", logMock, "").Return(opt, nil)
+			clientMock.On("GetRepositoryContents", logMock, "owner", "repo", dirPath, opt).Return((*github.RepositoryContent)(nil), dirMetadata, nil).Once()
+			clientMock.On("GetRepositoryContents", logMock, "owner", "repo", filePath, opt).Return(&fileMetadata, []*github.RepositoryContent(nil), nil).Once()
+			clientMock.On("GetRepositoryContents", logMock, "owner", "repo", emptyPath, opt).Return(&emptyMetadata, []*github.RepositoryContent(nil), nil).Once()
+			clientMock.On("IsFileContentType", mock.AnythingOfType("*github.RepositoryContent")).Return(true)
+
+			gitResource := NewResourceWithMockedClient(&clientMock)
+			gitResource.Info.Path = dirPath
+			filesys := filemanager.NewMemoryFileSystem()
+			destination := filepath.Join("/var", "tmp", "dir")
+
+			err := gitResource.Download(logMock, filesys, destination)
+
+			assert.NoError(t, err)
+			clientMock.AssertExpectations(t)
+			assert.Len(t, filesys.Files(), 2)
+			saved, err := filesys.ReadFile(filepath.Join(destination, ".keep"))
+			assert.NoError(t, err)
+			assert.Empty(t, saved)
+		})
+	}
+}
+
+func TestGitResource_DownloadFileWithoutContent(t *testing.T) {
+	clientMock := githubclientmock.ClientMock{}
+	opt := &github.RepositoryContentGetOptions{Ref: ""}
+	file, filePath := "file", "path/to/file.ext"
+	fileMetadata := github.RepositoryContent{Type: &file, Path: &filePath}
+	clientMock.On("ParseGetOptions", logMock, "").Return(opt, nil)
+	clientMock.On("GetRepositoryContents", logMock, "owner", "repo", filePath, opt).Return(&fileMetadata, []*github.RepositoryContent(nil), nil).Once()
+	clientMock.On("IsFileContentType", mock.AnythingOfType("*github.RepositoryContent")).Return(true)
+
+	gitResource := NewResourceWithMockedClient(&clientMock)
+	filesys := filemanager.NewMemoryFileSystem()
+
+	err := gitResource.Download(logMock, filesys, filepath.Join("/var", "tmp", "file.ext"))
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "No content in the metadata of path/to/file.ext")
+	assert.Empty(t, filesys.Files())
+}
+
 func TestGitResource_DownloadParseGetOptionFail(t *testing.T) {
 	clientMock := githubclientmock.ClientMock{}
 
@@ -218,6 +830,111 @@ func TestGitResource_DownloadGetRepositoryContentsFail(t *testing.T) {
 	assert.Contains(t, err.Error(), "Rate limit exceeded")
 }
 
+func TestGitResource_DownloadMirrorFailover(t *testing.T) {
+	opt := &github.RepositoryContentGetOptions{Ref: ""}
+	content := "content"
+	file := "file"
+	gitpath := "path/to/file.ext"
+	fileMetadata := github.RepositoryContent{
+		Content: &content,
+		Type:    &file,
+		Path:    &gitpath,
+	}
+
+	failingMirror := githubclientmock.ClientMock{}
+	failingMirror.On("ParseGetOptions", logMock, "").Return(opt, nil).Once()
+	failingMirror.On("GetRepositoryContents", logMock, "owner", "repo", gitpath, opt).Return((*github.RepositoryContent)(nil), []*github.RepositoryContent(nil), fmt.Errorf("Mirror unavailable")).Once()
+
+	servingMirror := githubclientmock.ClientMock{}
+	servingMirror.On("ParseGetOptions", logMock, "").Return(opt, nil).Once()
+	servingMirror.On("GetRepositoryContents", logMock, "owner", "repo", gitpath, opt).Return(&fileMetadata, []*github.RepositoryContent(nil), nil).Once()
+	servingMirror.On("IsFileContentType", mock.AnythingOfType("*github.RepositoryContent")).Return(true)
+
+	fileMock := filemock.FileSystemMock{}
+	fileMock.On("IsDirectory", appconfig.DownloadRoot).Return(true)
+	fileMock.On("Exists", appconfig.DownloadRoot).Return(true)
+	fileMock.On("MakeDirs", strings.TrimSuffix(appconfig.DownloadRoot, "/")).Return(nil)
+	fileMock.On("WriteFile", filepath.Join(appconfig.DownloadRoot, "file.ext"), mock.Anything).Return(nil)
+
+	gitResource := NewResourceWithMockedClient(&failingMirror)
+	gitResource.mirrors = []gitMirror{
+		{name: "https://primary.example.com/api/v3/", client: &failingMirror},
+		{name: "https://secondary.example.com/api/v3/", client: &servingMirror},
+	}
+
+	err := gitResource.Download(logMock, fileMock, "")
+
+	assert.NoError(t, err)
+	failingMirror.AssertExpectations(t)
+	servingMirror.AssertExpectations(t)
+	fileMock.AssertExpectations(t)
+}
+
+func TestGitResource_DownloadMirrorsAllFail(t *testing.T) {
+	opt := &github.RepositoryContentGetOptions{Ref: ""}
+
+	var mirrors []gitMirror
+	var clientMocks []*githubclientmock.ClientMock
+	for i := 0; i < 2; i++ {
+		clientMock := &githubclientmock.ClientMock{}
+		clientMock.On("ParseGetOptions", logMock, "").Return(opt, nil).Once()
+		clientMock.On("GetRepositoryContents", logMock, "owner", "repo", "path/to/file.ext", opt).Return((*github.RepositoryContent)(nil), []*github.RepositoryContent(nil), fmt.Errorf("Mirror %v unavailable", i)).Once()
+		clientMocks = append(clientMocks, clientMock)
+		mirrors = append(mirrors, gitMirror{name: fmt.Sprintf("mirror%v", i), client: clientMock})
+	}
+
+	gitResource := NewResourceWithMockedClient(clientMocks[0])
+	gitResource.mirrors = mirrors
+
+	err := gitResource.Download(logMock, filemock.FileSystemMock{}, "")
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "Mirror 1 unavailable")
+	for _, clientMock := range clientMocks {
+		clientMock.AssertExpectations(t)
+	}
+}
+
+func TestGitResource_DownloadMirrorFailoverDeletesPartialFiles(t *testing.T) {
+	opt := &github.RepositoryContentGetOptions{Ref: "master"}
+
+	failingMirror := githubclientmock.ClientMock{}
+	failingMirror.On("ParseGetOptions", logMock, "").Return(opt, nil)
+	failingMirror.On("GetRepositoryContents", logMock, "owner", "repo", "scripts", opt).Return((*github.RepositoryContent)(nil), []*github.RepositoryContent{
+		repositoryContent("file", "scripts/run.sh", 7, "blob1"),
+		repositoryContent("dir", "scripts/lib", 0, "tree1"),
+	}, nil).Once()
+	failingMirror.On("GetRepositoryContents", logMock, "owner", "repo", "scripts/lib", opt).Return((*github.RepositoryContent)(nil), []*github.RepositoryContent(nil), fmt.Errorf("Mirror unavailable")).Once()
+	mockFiles(&failingMirror, opt, "content", "scripts/run.sh")
+
+	servingMirror := githubclientmock.ClientMock{}
+	servingMirror.On("ParseGetOptions", logMock, "").Return(opt, nil)
+	servingMirror.On("GetRepositoryContents", logMock, "owner", "repo", "scripts", opt).Return((*github.RepositoryContent)(nil), []*github.RepositoryContent{
+		repositoryContent("file", "scripts/setup.sh", 7, "blob2"),
+	}, nil).Once()
+	mockFiles(&servingMirror, opt, "content", "scripts/setup.sh")
+
+	resourceClient := githubclientmock.ClientMock{}
+	gitResource := NewResourceWithMockedClient(&resourceClient)
+	gitResource.Info.Path = "scripts"
+	gitResource.fetchMaxAttempts = 1
+	gitResource.mirrors = []gitMirror{
+		{name: "https://primary.example.com/api/v3/", client: &failingMirror},
+		{name: "https://secondary.example.com/api/v3/", client: &servingMirror},
+	}
+	filesys := filemanager.NewMemoryFileSystem()
+
+	err := gitResource.Download(logMock, filesys, "destination")
+
+	assert.NoError(t, err)
+	failingMirror.AssertExpectations(t)
+	servingMirror.AssertExpectations(t)
+	// run.sh saved by the failing mirror is deleted before the next one is tried
+	assert.Equal(t, []string{"setup.sh"}, filesys.FilesUnder("destination"))
+	// the mirror clients are only used for the download
+	assert.Equal(t, &resourceClient, gitResource.client)
+}
+
 func TestGitResource_ValidateLocationInfoOwner(t *testing.T) {
 	locationInfo := `{
 		"repository": "repo",
@@ -262,6 +979,37 @@ func TestGitResource_ValidateLocationInfo(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestGitResource_ValidateLocationInfoCommitID(t *testing.T) {
+	data := []struct {
+		getOptions    string
+		expectedError string
+	}{
+		{"commitID:3b18e512dba79e4c8300dd08aeb37f8e728b8dad", ""},
+		{"commitID:3b18e51", ""},
+		{"commitID:master", "commitID master in getOptions must be a full or abbreviated hexadecimal commit SHA"},
+		{"branch:master,commitID:3b18e51", "Specify either a branch or a commitID in getOptions, not both"},
+	}
+	for _, testdata := range data {
+		t.Run(testdata.getOptions, func(t *testing.T) {
+			locationInfo := `{
+				"owner": "owner",
+				"repository": "repo",
+				"path":"path/to/file.rb",
+				"getOptions": "` + testdata.getOptions + `"
+			}`
+			token := TokenMock{}
+			gitresource, _ := NewGitResource(logMock, locationInfo, token)
+			_, err := gitresource.ValidateLocationInfo()
+
+			if testdata.expectedError == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, testdata.expectedError)
+			}
+		})
+	}
+}
+
 func TestNewGitResource_parseLocationInfoFail(t *testing.T) {
 
 	token := TokenMock{}
@@ -291,6 +1039,183 @@ func TestNewGitResource_GithubTokenInfo(t *testing.T) {
 	assert.Equal(t, "ssm:token", gitresource.Info.TokenInfo)
 }
 
+func TestNewGitResource_RequestTimeout(t *testing.T) {
+	data := []struct {
+		name            string
+		timeoutSeconds  string
+		expectedTimeout time.Duration
+	}{
+		{"default", "", 30 * time.Second},
+		{"configured", `"timeoutSeconds": 5,`, 5 * time.Second},
+	}
+	for _, testdata := range data {
+		t.Run(testdata.name, func(t *testing.T) {
+			locationInfo := `{
+				"owner": "owner",
+				"repository": "repository",
+				"path" : "path",` + testdata.timeoutSeconds + `
+				"tokenInfo" : "ssm:token",
+				"mirrors": [{"baseURL": "https://github.example.com/api/v3", "tokenInfo": "ssm:enterprise-token"}]
+			}`
+			tokenClient, mirrorClient := &http.Client{}, &http.Client{}
+			token := TokenMock{}
+			token.On("GetOAuthClient", logMock, "ssm:token").Return(tokenClient, nil).Once()
+			token.On("GetOAuthClient", logMock, "ssm:enterprise-token").Return(mirrorClient, nil).Once()
+
+			_, err := NewGitResource(logMock, locationInfo, token)
+
+			assert.NoError(t, err)
+			assert.Equal(t, testdata.expectedTimeout, tokenClient.Timeout)
+			assert.Equal(t, testdata.expectedTimeout, mirrorClient.Timeout)
+		})
+	}
+}
+
+func TestNewGitResource_RequestTimeoutCoversFileContent(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// the file is found right away, its content takes longer than the timeout
+		w.WriteHeader(http.StatusOK)
+		w.(http.Flusher).Flush()
+		time.Sleep(1500 * time.Millisecond)
+		w.Write([]byte(`{"type": "file", "encoding": "base64", "content": "Y29udGVudA=="}`))
+	}))
+	defer server.Close()
+	locationInfo := `{
+		"owner": "owner",
+		"repository": "repository",
+		"path" : "path",
+		"endpoint": "` + server.URL + `",
+		"timeoutSeconds": 1
+	}`
+
+	gitresource, err := NewGitResource(logMock, locationInfo, TokenMock{})
+	assert.NoError(t, err)
+	_, _, err = gitresource.client.GetRepositoryContents(logMock, "owner", "repository", "path", &github.RepositoryContentGetOptions{})
+
+	assert.Error(t, err)
+}
+
+func TestGitResource_ValidateLocationInfoTimeoutSeconds(t *testing.T) {
+	gitResource := &GitResource{Info: GitInfo{Owner: "owner", Repository: "repo", TimeoutSeconds: -1}}
+	valid, err := gitResource.ValidateLocationInfo()
+	assert.False(t, valid)
+	assert.EqualError(t, err, "TimeoutSeconds for GitHub SourceType can't be negative")
+}
+
+func TestNewGitResource_Mirrors(t *testing.T) {
+	locationInfo := `{
+		"owner": "owner",
+		"repository": "repository",
+		"path" : "path",
+		"mirrors": [
+			{"baseURL": "https://github.example.com/api/v3", "tokenInfo": "ssm:enterprise-token"},
+			{"tokenInfo": "ssm:token"}
+		]
+	}`
+
+	token := TokenMock{}
+	token.On("GetOAuthClient", logMock, "ssm:enterprise-token").Return(&http.Client{}, nil).Once()
+	token.On("GetOAuthClient", logMock, "ssm:token").Return(&http.Client{}, nil).Once()
+
+	gitresource, err := NewGitResource(logMock, locationInfo, token)
+	assert.NoError(t, err)
+	assert.Len(t, gitresource.mirrors, 2)
+	assert.Equal(t, "https://github.example.com/api/v3", gitresource.mirrors[0].name)
+	assert.Equal(t, "github.com", gitresource.mirrors[1].name)
+	assert.Equal(t, gitresource.mirrors[0].client, gitresource.client)
+	assert.Equal(t, "github.example.com", gitresource.mirrors[0].host)
+	assert.Equal(t, "api.github.com", gitresource.mirrors[1].host)
+	assert.Equal(t, "github.example.com", gitresource.apiHost)
+	token.AssertExpectations(t)
+}
+
+func TestNewGitResource_Endpoint(t *testing.T) {
+	locationInfo := `{
+		"owner": "owner",
+		"repository": "repository",
+		"path" : "path",
+		"endpoint": "https://github.example.com"
+	}`
+
+	gitresource, err := NewGitResource(logMock, locationInfo, TokenMock{})
+	assert.NoError(t, err)
+	assert.Equal(t, "https://github.example.com/api/v3/", gitresource.client.(*githubclient.GitClient).BaseURL.String())
+	assert.Equal(t, "github.example.com", gitresource.apiHost)
+}
+
+func TestNewGitResource_EndpointInvalid(t *testing.T) {
+	locationInfo := `{
+		"owner": "owner",
+		"repository": "repository",
+		"path" : "path",
+		"endpoint": "github.example.com"
+	}`
+
+	_, err := NewGitResource(logMock, locationInfo, TokenMock{})
+	assert.EqualError(t, err, "GitHub Enterprise endpoint github.example.com is not valid")
+}
+
+func TestGitResource_ValidateLocationInfoEndpoint(t *testing.T) {
+	data := []struct {
+		name          string
+		info          GitInfo
+		expectedError string
+	}{
+		{"valid", GitInfo{Endpoint: "https://github.example.com"}, ""},
+		{"malformed", GitInfo{Endpoint: "https://github.example.com/?a=b"}, "GitHub Enterprise endpoint https://github.example.com/?a=b is not valid"},
+		{"mirrors", GitInfo{Endpoint: "https://github.example.com", Mirrors: []GitMirror{{}}}, "Endpoint for GitHub SourceType can't be combined with mirrors or useRawHost"},
+		{"raw host", GitInfo{Endpoint: "https://github.example.com", UseRawHost: true}, "Endpoint for GitHub SourceType can't be combined with mirrors or useRawHost"},
+	}
+	for _, testdata := range data {
+		t.Run(testdata.name, func(t *testing.T) {
+			testdata.info.Owner, testdata.info.Repository, testdata.info.Path = "owner", "repo", "path"
+			gitResource := &GitResource{Info: testdata.info}
+
+			_, err := gitResource.ValidateLocationInfo()
+
+			if testdata.expectedError == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, testdata.expectedError)
+			}
+		})
+	}
+}
+
+func TestGitResource_AuditLocation(t *testing.T) {
+	locationInfo := `{
+		"repo": "owner/repository",
+		"path": "path/to/file.rb",
+		"getOptions": "branch:main",
+		"tokenInfo": "{{ssm-secure:github-token}}"
+	}`
+	token := TokenMock{}
+	token.On("GetOAuthClient", logMock, "{{ssm-secure:github-token}}").Return(&http.Client{}, nil).Once()
+
+	gitresource, err := NewGitResource(logMock, locationInfo, token)
+	assert.NoError(t, err)
+	location := gitresource.AuditLocation()
+	assert.Equal(t, remoteresource.AuditLocation{Source: "owner/repository", Path: "path/to/file.rb", Ref: "branch:main"}, location)
+	record, _ := json.Marshal(location)
+	assert.NotContains(t, string(record), "github-token")
+}
+
+func TestNewGitResource_MirrorTokenFail(t *testing.T) {
+	locationInfo := `{
+		"owner": "owner",
+		"repository": "repository",
+		"path" : "path",
+		"mirrors": [{"baseURL": "https://github.example.com/api/v3", "tokenInfo": "ssm:enterprise-token"}]
+	}`
+
+	token := TokenMock{}
+	token.On("GetOAuthClient", logMock, "ssm:enterprise-token").Return((*http.Client)(nil), fmt.Errorf("Token not found"))
+
+	_, err := NewGitResource(logMock, locationInfo, token)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "Token not found")
+}
+
 func TestGitResource_DownloadFileToDifferentName(t *testing.T) {
 	clientMock := githubclientmock.ClientMock{}
 
@@ -332,6 +1257,550 @@ func TestGitResource_DownloadFileToDifferentName(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestGitResource_DownloadUsesDefaultRef(t *testing.T) {
+	data := []struct {
+		name               string
+		getOptions         string
+		defaultRef         string
+		expectedGetOptions string
+	}{
+		{"configured default ref", "", "trunk", "branch:trunk"},
+		{"getOptions override the default ref", "branch:release", "trunk", "branch:release"},
+		{"no default ref configured", "", "", ""},
+	}
+	for _, testdata := range data {
+		t.Run(testdata.name, func(t *testing.T) {
+			clientMock := githubclientmock.ClientMock{}
+
+			content := "content"
+			file := "file"
+			gitpath := "path/to/file.ext"
+			fileMetadata := github.RepositoryContent{
+				Content: &content,
+				Type:    &file,
+				Path:    &gitpath,
+			}
+			var dirMetadata []*github.RepositoryContent
+			opt := &github.RepositoryContentGetOptions{Ref: "ref"}
+
+			gitResource := NewResourceWithMockedClient(&clientMock)
+			gitResource.Info.GetOptions = testdata.getOptions
+			gitResource.defaultRef = testdata.defaultRef
+			clientMock.On("ParseGetOptions", logMock, testdata.expectedGetOptions).Return(opt, nil).Once()
+			clientMock.On("GetRepositoryContents", logMock, "owner", "repo", gitpath, opt).Return(&fileMetadata, dirMetadata, nil).Once()
+			clientMock.On("IsFileContentType", mock.AnythingOfType("*github.RepositoryContent")).Return(true)
+
+			destPath := `/var/temp/my/filename`
+			fileMock := filemock.FileSystemMock{}
+			fileMock.On("IsDirectory", destPath).Return(false)
+			fileMock.On("Exists", destPath).Return(true)
+			fileMock.On("MakeDirs", filepath.Dir(destPath)).Return(nil)
+			fileMock.On("WriteFile", destPath, content).Return(nil)
+
+			err := gitResource.Download(logMock, fileMock, destPath)
+			clientMock.AssertExpectations(t)
+			assert.NoError(t, err)
+		})
+	}
+}
+
+// repositoryFiles returns the directory listing of the given file paths
+func repositoryFiles(paths ...string) []*github.RepositoryContent {
+	var contents []*github.RepositoryContent
+	file := "file"
+	for i := range paths {
+		contents = append(contents, &github.RepositoryContent{
+			Type: &file,
+			Path: &paths[i],
+		})
+	}
+	return contents
+}
+
+func TestGitResource_DownloadSelectLatest(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2024, time.January, d, 0, 0, 0, 0, time.UTC) }
+	data := []struct {
+		name         string
+		namePattern  string
+		sortBy       string
+		files        []string
+		commitDates  map[string]time.Time
+		expectedPath string
+		expectedErr  string
+	}{
+		{
+			"name sorted",
+			"build-*.tar.gz",
+			"",
+			[]string{"builds/build-2024-01-01.tar.gz", "builds/build-2024-03-01.tar.gz", "builds/build-2024-02-01.tar.gz", "builds/readme.md"},
+			nil,
+			"builds/build-2024-03-01.tar.gz",
+			"",
+		},
+		{
+			"commit date sorted",
+			"*.tar.gz",
+			"commitDate",
+			[]string{"builds/a.tar.gz", "builds/b.tar.gz", "builds/c.tar.gz"},
+			map[string]time.Time{"builds/a.tar.gz": day(3), "builds/b.tar.gz": day(1), "builds/c.tar.gz": day(2)},
+			"builds/a.tar.gz",
+			"",
+		},
+		{
+			"no match",
+			"*.zip",
+			"",
+			[]string{"builds/a.tar.gz"},
+			nil,
+			"",
+			"No file in builds matches the name pattern *.zip",
+		},
+		{
+			"ambiguous commit date",
+			"",
+			"commitDate",
+			[]string{"builds/a.tar.gz", "builds/b.tar.gz"},
+			map[string]time.Time{"builds/a.tar.gz": day(1), "builds/b.tar.gz": day(1)},
+			"",
+			"committed at the same time",
+		},
+	}
+	for _, testdata := range data {
+		t.Run(testdata.name, func(t *testing.T) {
+			clientMock := githubclientmock.ClientMock{}
+			opt := &github.RepositoryContentGetOptions{Ref: "ref"}
+
+			gitResource := NewResourceWithMockedClient(&clientMock)
+			gitResource.Info.Path = "builds"
+			gitResource.Info.GetOptions = "branch:ref"
+			gitResource.Info.Select = "latest"
+			gitResource.Info.NamePattern = testdata.namePattern
+			gitResource.Info.SortBy = testdata.sortBy
+
+			var nilFileMetadata *github.RepositoryContent
+			clientMock.On("ParseGetOptions", logMock, "branch:ref").Return(opt, nil)
+			clientMock.On("GetRepositoryContents", logMock, "owner", "repo", "builds", opt).Return(nilFileMetadata, repositoryFiles(testdata.files...), nil).Once()
+			clientMock.On("IsFileContentType", mock.AnythingOfType("*github.RepositoryContent")).Return(true)
+			for path, date := range testdata.commitDates {
+				clientMock.On("GetLatestCommitDate", logMock, "owner", "repo", path, opt).Return(date, nil).Once()
+			}
+
+			destPath := `/var/temp/my/filename`
+			fileMock := filemock.FileSystemMock{}
+			if testdata.expectedPath != "" {
+				content := "content"
+				fileMetadata := repositoryFiles(testdata.expectedPath)[0]
+				fileMetadata.Content = &content
+				var dirMetadata []*github.RepositoryContent
+				clientMock.On("GetRepositoryContents", logMock, "owner", "repo", testdata.expectedPath, opt).Return(fileMetadata, dirMetadata, nil).Once()
+				fileMock.On("IsDirectory", destPath).Return(false)
+				fileMock.On("Exists", destPath).Return(true)
+				fileMock.On("MakeDirs", filepath.Dir(destPath)).Return(nil)
+				fileMock.On("WriteFile", destPath, content).Return(nil)
+			}
+
+			err := gitResource.Download(logMock, fileMock, destPath)
+			clientMock.AssertExpectations(t)
+			if testdata.expectedErr != "" {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), testdata.expectedErr)
+			} else {
+				assert.NoError(t, err)
+				fileMock.AssertExpectations(t)
+			}
+		})
+	}
+}
+
+func TestGitResource_DownloadConcatenated(t *testing.T) {
+	data := []struct {
+		name            string
+		separator       string
+		expectedContent string
+	}{
+		{"no separator", "", "abc"},
+		{"newline separator", "\n", "a\nb\nc"},
+		{"multi character separator", "\n---\n", "a\n---\nb\n---\nc"},
+	}
+	for _, testdata := range data {
+		t.Run(testdata.name, func(t *testing.T) {
+			clientMock := githubclientmock.ClientMock{}
+			opt := &github.RepositoryContentGetOptions{Ref: "ref"}
+
+			gitResource := NewResourceWithMockedClient(&clientMock)
+			gitResource.Info.Path = "conf.d"
+			gitResource.Info.GetOptions = "branch:ref"
+			gitResource.Info.Concatenate = true
+			gitResource.Info.Separator = testdata.separator
+			gitResource.Info.DestinationFileName = "app.conf"
+
+			// listed out of order, with a sub directory that must be skipped
+			directory := "dir"
+			subDirPath := "conf.d/sub"
+			listing := repositoryFiles("conf.d/20-b.conf", "conf.d/30-c.conf", "conf.d/10-a.conf")
+			subDir := &github.RepositoryContent{Type: &directory, Path: &subDirPath}
+			listing = append(listing, subDir)
+
+			var nilFileMetadata *github.RepositoryContent
+			var nilDirMetadata []*github.RepositoryContent
+			clientMock.On("ParseGetOptions", logMock, "branch:ref").Return(opt, nil)
+			clientMock.On("GetRepositoryContents", logMock, "owner", "repo", "conf.d", opt).Return(nilFileMetadata, listing, nil).Once()
+			clientMock.On("IsFileContentType", subDir).Return(false)
+			clientMock.On("IsFileContentType", mock.AnythingOfType("*github.RepositoryContent")).Return(true)
+			for path, content := range map[string]string{"conf.d/10-a.conf": "a", "conf.d/20-b.conf": "b", "conf.d/30-c.conf": "c"} {
+				fileMetadata := repositoryFiles(path)[0]
+				fileContent := content
+				fileMetadata.Content = &fileContent
+				clientMock.On("GetRepositoryContents", logMock, "owner", "repo", path, opt).Return(fileMetadata, nilDirMetadata, nil).Once()
+			}
+
+			destPath := "/var/temp/my"
+			fileMock := filemock.FileSystemMock{}
+			fileMock.On("MakeDirs", destPath).Return(nil)
+			fileMock.On("WriteFile", filepath.Join(destPath, "app.conf"), testdata.expectedContent).Return(nil).Once()
+
+			err := gitResource.Download(logMock, fileMock, destPath)
+
+			assert.NoError(t, err)
+			clientMock.AssertExpectations(t)
+			fileMock.AssertExpectations(t)
+		})
+	}
+}
+
+func TestGitResource_DownloadConcatenatedVerifiesFiles(t *testing.T) {
+	defer func() { sleep = time.Sleep }()
+	// the blob SHA of "hello\n"
+	sha := "ce013625030ba8dba906f756967f9e9ca394464a"
+	serverError := &github.ErrorResponse{Response: &http.Response{StatusCode: http.StatusServiceUnavailable}}
+	data := []struct {
+		name          string
+		content       string
+		declaredSize  int
+		fetchErr      error
+		maxFileSize   int64
+		expectedWrite bool
+		expectedErr   string
+	}{
+		{"verified content", "hello\n", 6, nil, 0, true, ""},
+		{"transient failure retried", "hello\n", 6, serverError, 0, true, ""},
+		{"corrupted content", "hel", 6, nil, 0, false, "Content of conf.d/10-a.conf does not match its blob SHA " + sha},
+		{"declared size over the limit", "hello\n", 6, nil, 4, false, "conf.d/10-a.conf"},
+	}
+	for _, testdata := range data {
+		t.Run(testdata.name, func(t *testing.T) {
+			sleep = func(d time.Duration) {}
+			clientMock := githubclientmock.ClientMock{}
+			opt := &github.RepositoryContentGetOptions{Ref: "ref"}
+			gitResource := NewResourceWithMockedClient(&clientMock)
+			gitResource.maxFileSize = testdata.maxFileSize
+			gitResource.Info.Path = "conf.d"
+			gitResource.Info.GetOptions = "branch:ref"
+			gitResource.Info.Concatenate = true
+			gitResource.Info.DestinationFileName = "app.conf"
+			// only the files with an included extension are concatenated
+			gitResource.Info.IncludeExtensions = []string{"conf"}
+
+			listing := []*github.RepositoryContent{
+				repositoryContent("file", "conf.d/10-a.conf", testdata.declaredSize, sha),
+				repositoryContent("file", "conf.d/README.md", 6, sha),
+			}
+			file := repositoryContent("file", "conf.d/10-a.conf", testdata.declaredSize, sha)
+			content := testdata.content
+			file.Content = &content
+			clientMock.On("ParseGetOptions", logMock, "branch:ref").Return(opt, nil)
+			clientMock.On("GetRepositoryContents", logMock, "owner", "repo", "conf.d", opt).Return((*github.RepositoryContent)(nil), listing, nil).Once()
+			clientMock.On("IsFileContentType", mock.AnythingOfType("*github.RepositoryContent")).Return(true)
+			if testdata.maxFileSize == 0 {
+				if testdata.fetchErr != nil {
+					clientMock.On("GetRepositoryContents", logMock, "owner", "repo", "conf.d/10-a.conf", opt).Return((*github.RepositoryContent)(nil), []*github.RepositoryContent(nil), testdata.fetchErr).Once()
+				}
+				clientMock.On("GetRepositoryContents", logMock, "owner", "repo", "conf.d/10-a.conf", opt).Return(file, []*github.RepositoryContent(nil), nil).Once()
+			}
+			filesys := filemanager.NewMemoryFileSystem()
+
+			err := gitResource.Download(logMock, filesys, "destination")
+
+			clientMock.AssertExpectations(t)
+			clientMock.AssertNotCalled(t, "GetRepositoryContents", logMock, "owner", "repo", "conf.d/README.md", opt)
+			if testdata.expectedErr == "" {
+				assert.NoError(t, err)
+				saved, err := filesys.ReadFile(filepath.Join("destination", "app.conf"))
+				assert.NoError(t, err)
+				assert.Equal(t, "hello\n", saved)
+			} else {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), testdata.expectedErr)
+				assert.Empty(t, filesys.Files())
+			}
+		})
+	}
+}
+
+func TestGitResource_ValidateLocationInfoConcatenate(t *testing.T) {
+	locationInfo := `{
+		"owner": "owner",
+		"repository": "repo",
+		"path":"conf.d",
+		"concatenate": true
+	}`
+	token := TokenMock{}
+	gitresource, _ := NewGitResource(logMock, locationInfo, token)
+	_, err := gitresource.ValidateLocationInfo()
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "DestinationFileName")
+}
+
+func TestGitResource_DownloadVerbose(t *testing.T) {
+	for _, verbose := range []bool{false, true} {
+		clientMock := githubclientmock.ClientMock{}
+		content := "content"
+		fileMetadata := repositoryFiles("path/to/file.ext")[0]
+		fileMetadata.Content = &content
+		var dirMetadata []*github.RepositoryContent
+		opt := &github.RepositoryContentGetOptions{Ref: "ref"}
+
+		logger := log.NewMockLog()
+		gitResource := NewResourceWithMockedClient(&clientMock)
+		gitResource.Info.Verbose = verbose
+		clientMock.On("ParseGetOptions", mock.Anything, "").Return(opt, nil)
+		clientMock.On("GetRepositoryContents", mock.Anything, "owner", "repo", "path/to/file.ext", opt).Return(fileMetadata, dirMetadata, nil)
+		clientMock.On("IsFileContentType", mock.AnythingOfType("*github.RepositoryContent")).Return(true)
+
+		destPath := `/var/temp/my/filename`
+		fileMock := filemock.FileSystemMock{}
+		fileMock.On("IsDirectory", destPath).Return(false)
+		fileMock.On("Exists", destPath).Return(true)
+		fileMock.On("MakeDirs", filepath.Dir(destPath)).Return(nil)
+		fileMock.On("WriteFile", destPath, content).Return(nil)
+
+		err := gitResource.Download(logger, fileMock, destPath)
+
+		assert.NoError(t, err)
+		savingArgs := []interface{}{"path/to/file.ext", len(content), destPath}
+		if verbose {
+			logger.AssertCalled(t, "Infof", "Saving %v (%v bytes) to %v", savingArgs)
+		} else {
+			logger.AssertCalled(t, "Debugf", "Saving %v (%v bytes) to %v", savingArgs)
+			logger.AssertNotCalled(t, "Infof", "Saving %v (%v bytes) to %v", savingArgs)
+		}
+	}
+}
+
+func TestGitResource_DownloadVerifiesTreeSha(t *testing.T) {
+	data := []struct {
+		name        string
+		treeSha     string
+		expectedErr bool
+	}{
+		{"matching tree", "DEF456", false},
+		{"moved ref or compromised mirror", "other", true},
+	}
+	for _, testdata := range data {
+		t.Run(testdata.name, func(t *testing.T) {
+			clientMock := githubclientmock.ClientMock{}
+			content := "content"
+			fileMetadata := repositoryFiles("path/to/file.ext")[0]
+			fileMetadata.Content = &content
+			var dirMetadata []*github.RepositoryContent
+			opt := &github.RepositoryContentGetOptions{Ref: "abc123"}
+
+			gitResource := NewResourceWithMockedClient(&clientMock)
+			gitResource.Info.GetOptions = "commitID:abc123"
+			gitResource.Info.TreeSha = testdata.treeSha
+			clientMock.On("ParseGetOptions", logMock, "commitID:abc123").Return(opt, nil)
+			clientMock.On("GetCommitTreeSha", logMock, "owner", "repo", "abc123").Return("def456", nil).Once()
+
+			destPath := `/var/temp/my/filename`
+			fileMock := filemock.FileSystemMock{}
+			if !testdata.expectedErr {
+				clientMock.On("GetRepositoryContents", logMock, "owner", "repo", "path/to/file.ext", opt).Return(fileMetadata, dirMetadata, nil).Once()
+				clientMock.On("IsFileContentType", mock.AnythingOfType("*github.RepositoryContent")).Return(true)
+				fileMock.On("IsDirectory", destPath).Return(false)
+				fileMock.On("Exists", destPath).Return(true)
+				fileMock.On("MakeDirs", filepath.Dir(destPath)).Return(nil)
+				fileMock.On("WriteFile", destPath, content).Return(nil)
+			}
+
+			err := gitResource.Download(logMock, fileMock, destPath)
+
+			clientMock.AssertExpectations(t)
+			if testdata.expectedErr {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), "does not match the expected tree")
+			} else {
+				assert.NoError(t, err)
+				fileMock.AssertExpectations(t)
+			}
+		})
+	}
+}
+
+func TestGitResource_ValidateLocationInfoTreeSha(t *testing.T) {
+	locationInfo := `{
+		"owner": "owner",
+		"repository": "repo",
+		"path":"path/to/file.rb",
+		"getOptions": "branch:master",
+		"treeSha": "def456"
+	}`
+	token := TokenMock{}
+	gitresource, _ := NewGitResource(logMock, locationInfo, token)
+	_, err := gitresource.ValidateLocationInfo()
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "commitID")
+}
+
+func TestGitResource_ValidateLocationInfoAllowedRepositories(t *testing.T) {
+	data := []struct {
+		name                string
+		allowedRepositories []string
+		expectedValid       bool
+	}{
+		{"no allow-list", nil, true},
+		{"allowed repository", []string{"other/repo", "owner/repo"}, true},
+		{"allowed case insensitively", []string{"Owner/Repo"}, true},
+		{"wildcard repository", []string{"owner/*"}, true},
+		{"wildcard owner", []string{"*/repo"}, true},
+		{"denied repository", []string{"owner/other"}, false},
+		{"wildcard does not cross the owner", []string{"own*"}, false},
+	}
+	for _, testdata := range data {
+		t.Run(testdata.name, func(t *testing.T) {
+			gitResource := NewResourceWithMockedClient(&githubclientmock.ClientMock{})
+			gitResource.allowedRepositories = testdata.allowedRepositories
+
+			valid, err := gitResource.ValidateLocationInfo()
+
+			assert.Equal(t, testdata.expectedValid, valid)
+			if testdata.expectedValid {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), "owner/repo is not allowed")
+			}
+		})
+	}
+}
+
+func TestNewGitResource_Repo(t *testing.T) {
+	data := []struct {
+		name               string
+		locationInfo       string
+		expectedOwner      string
+		expectedRepository string
+		expectedErr        string
+	}{
+		{"combined form", `{"repo": "org/name", "path": "scripts"}`, "org", "name", ""},
+		{"discrete form", `{"owner": "org", "repository": "name"}`, "org", "name", ""},
+		{"both forms", `{"repo": "org/name", "owner": "org"}`, "", "", "Specify either repo or owner and repository for source type GitHub, not both"},
+		{"missing repository", `{"repo": "org"}`, "", "", "Repo for GitHub SourceType must be of the form owner/repository, got org"},
+		{"nested path", `{"repo": "org/name/scripts"}`, "", "", "Repo for GitHub SourceType must be of the form owner/repository, got org/name/scripts"},
+	}
+	for _, testdata := range data {
+		t.Run(testdata.name, func(t *testing.T) {
+			gitResource, err := NewGitResource(logMock, testdata.locationInfo, TokenMock{})
+
+			if testdata.expectedErr != "" {
+				assert.EqualError(t, err, testdata.expectedErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, testdata.expectedOwner, gitResource.Info.Owner)
+			assert.Equal(t, testdata.expectedRepository, gitResource.Info.Repository)
+			valid, err := gitResource.ValidateLocationInfo()
+			assert.True(t, valid)
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestGitResource_ValidateLocationInfoRepoConflict(t *testing.T) {
+	gitResource := &GitResource{Info: GitInfo{Repo: "org/name", Owner: "other", Repository: "name"}}
+
+	valid, err := gitResource.ValidateLocationInfo()
+
+	assert.False(t, valid)
+	assert.EqualError(t, err, "Specify either repo or owner and repository for source type GitHub, not both")
+}
+
+func TestGitResource_ValidateLocationInfoSelect(t *testing.T) {
+	locationInfo := `{
+		"owner": "owner",
+		"repository": "repo",
+		"path":"builds",
+		"select": "oldest"
+	}`
+	token := TokenMock{}
+	gitresource, _ := NewGitResource(logMock, locationInfo, token)
+	_, err := gitresource.ValidateLocationInfo()
+
+	assert.Error(t, err)
+	assert.Equal(t, "Select for GitHub SourceType must be latest", err.Error())
+}
+
+func TestGitResource_LocationKey(t *testing.T) {
+	base := &GitResource{Info: GitInfo{Owner: "owner", Repository: "repo", Path: "path/to/file.ext"}}
+	data := []struct {
+		name  string
+		other *GitResource
+		same  bool
+	}{
+		{"identical", &GitResource{Info: GitInfo{Owner: "owner", Repository: "repo", Path: "path/to/file.ext"}}, true},
+		{"owner and repository case", &GitResource{Info: GitInfo{Owner: "Owner", Repository: "Repo", Path: "path/to/file.ext"}}, true},
+		{"path slashes", &GitResource{Info: GitInfo{Owner: "owner", Repository: "repo", Path: "/path//to/file.ext/"}}, true},
+		{"verbose", &GitResource{Info: GitInfo{Owner: "owner", Repository: "repo", Path: "path/to/file.ext", Verbose: true}}, true},
+		{"path case", &GitResource{Info: GitInfo{Owner: "owner", Repository: "repo", Path: "path/to/File.ext"}}, false},
+		{"branch", &GitResource{Info: GitInfo{Owner: "owner", Repository: "repo", Path: "path/to/file.ext", GetOptions: "branch:dev"}}, false},
+		{"default branch", &GitResource{Info: GitInfo{Owner: "owner", Repository: "repo", Path: "path/to/file.ext"}, defaultRef: "dev"}, false},
+	}
+	for _, testdata := range data {
+		t.Run(testdata.name, func(t *testing.T) {
+			assert.Equal(t, testdata.same, base.LocationKey() == testdata.other.LocationKey())
+		})
+	}
+}
+
+func TestGitResource_DownloadAllDeduplicates(t *testing.T) {
+	clientMock := githubclientmock.ClientMock{}
+	opt := &github.RepositoryContentGetOptions{Ref: ""}
+	content := "content"
+	file := "file"
+	gitpath := "path/to/file.ext"
+	fileMetadata := github.RepositoryContent{
+		Content: &content,
+		Type:    &file,
+		Path:    &gitpath,
+	}
+	clientMock.On("ParseGetOptions", logMock, "").Return(opt, nil)
+	clientMock.On("GetRepositoryContents", logMock, "owner", "repo", "path/to/file.ext", opt).Return(&fileMetadata, []*github.RepositoryContent(nil), nil)
+	clientMock.On("IsFileContentType", mock.AnythingOfType("*github.RepositoryContent")).Return(true)
+
+	destination := filepath.Join("destination", "dir")
+	fileMock := filemock.FileSystemMock{}
+	fileMock.On("IsDirectory", destination).Return(true)
+	fileMock.On("Exists", destination).Return(true)
+	fileMock.On("MakeDirs", destination).Return(nil)
+	fileMock.On("WriteFile", filepath.Join(destination, "file.ext"), content).Return(nil)
+
+	helper := NewResourceWithMockedClient(&clientMock)
+	sameHelper := &GitResource{
+		client: &clientMock,
+		Info:   GitInfo{Owner: "Owner", Repository: "repo", Path: "/path/to/file.ext"},
+	}
+	requests := []remoteresource.DownloadRequest{
+		{Resource: helper, DestinationDir: destination},
+		{Resource: sameHelper, DestinationDir: destination + string(filepath.Separator)},
+	}
+
+	err := remoteresource.DownloadAll(logMock, fileMock, requests, 2)
+
+	assert.NoError(t, err)
+	clientMock.AssertNumberOfCalls(t, "GetRepositoryContents", 1)
+}
+
 type TokenMock struct {
 	mock.Mock
 }
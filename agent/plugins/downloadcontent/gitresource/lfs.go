@@ -0,0 +1,70 @@
+// Copyright 2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package gitresource
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/aws/amazon-ssm-agent/agent/fileutil/artifact"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+)
+
+const (
+	// lfsPointerVersion is the first line of a Git LFS pointer file
+	lfsPointerVersion = "version https://git-lfs.github.com/spec/v1"
+	// maxLfsPointerSize is the size pointer files are smaller than
+	maxLfsPointerSize = 1024
+)
+
+// lfsOidPattern matches the oid line of a Git LFS pointer, the SHA-256 of the object
+var lfsOidPattern = regexp.MustCompile(`^oid sha256:([0-9a-f]{64})$`)
+
+// parseLfsPointer returns the oid and size of the Git LFS object content points to, isPointer is false when content is not a pointer
+func parseLfsPointer(content string) (oid string, size int64, isPointer bool) {
+	if len(content) >= maxLfsPointerSize || !strings.HasPrefix(content, lfsPointerVersion+"\n") {
+		return "", 0, false
+	}
+	sizeFound := false
+	for _, line := range strings.Split(strings.TrimSuffix(content, "\n"), "\n")[1:] {
+		if match := lfsOidPattern.FindStringSubmatch(line); match != nil {
+			oid = match[1]
+		} else if strings.HasPrefix(line, "size ") {
+			var err error
+			if size, err = strconv.ParseInt(strings.TrimPrefix(line, "size "), 10, 64); err != nil || size < 0 {
+				return "", 0, false
+			}
+			sizeFound = true
+		}
+	}
+	return oid, size, oid != "" && sizeFound
+}
+
+// resolveLfs returns the Git LFS object content points to when AllowLfs is set, other content is returned as it is
+func (git *downloadContext) resolveLfs(log log.T, repositoryPath string, content string) (string, error) {
+	if !git.Info.AllowLfs {
+		return content, nil
+	}
+	oid, size, isPointer := parseLfsPointer(content)
+	if !isPointer {
+		return content, nil
+	}
+	if err := artifact.CheckDeclaredSize(repositoryPath, size, git.maxFileSize); err != nil {
+		return "", err
+	}
+	log.Debugf("%v is a Git LFS pointer, downloading object %v (%v bytes)", repositoryPath, oid, size)
+	return git.client.GetLFSObject(log, git.Info.Owner, git.Info.Repository, oid, size)
+}
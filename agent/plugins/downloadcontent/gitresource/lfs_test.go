@@ -0,0 +1,128 @@
+// Copyright 2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package gitresource
+
+import (
+	"testing"
+
+	"github.com/aws/amazon-ssm-agent/agent/fileutil/filemanager"
+	githubclientmock "github.com/aws/amazon-ssm-agent/agent/githubclient/mock"
+	"github.com/go-github/github"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+const testLfsOid = "9a6006e812cbdb4d8e7a5188a7c767a14f5bdac313753a844ecb4a6aa3ac8cf5"
+
+const testLfsPointer = "version https://git-lfs.github.com/spec/v1\noid sha256:" + testLfsOid + "\nsize 12\n"
+
+func TestParseLfsPointer(t *testing.T) {
+	tests := []struct {
+		name      string
+		content   string
+		oid       string
+		size      int64
+		isPointer bool
+	}{
+		{"pointer", testLfsPointer, testLfsOid, 12, true},
+		{"pointer with extension", "version https://git-lfs.github.com/spec/v1\next-0-foo sha256:" + testLfsOid + "\noid sha256:" + testLfsOid + "\nsize 12\n", testLfsOid, 12, true},
+		{"script", "#!/bin/bash\necho hello\n", "", 0, false},
+		{"no oid", "version https://git-lfs.github.com/spec/v1\nsize 12\n", "", 0, false},
+		{"no size", "version https://git-lfs.github.com/spec/v1\noid sha256:" + testLfsOid + "\n", "", 0, false},
+		{"invalid size", "version https://git-lfs.github.com/spec/v1\noid sha256:" + testLfsOid + "\nsize twelve\n", "", 0, false},
+		{"invalid oid", "version https://git-lfs.github.com/spec/v1\noid sha256:1234\nsize 12\n", "", 0, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			oid, size, isPointer := parseLfsPointer(test.content)
+			assert.Equal(t, test.isPointer, isPointer)
+			if test.isPointer {
+				assert.Equal(t, test.oid, oid)
+				assert.Equal(t, test.size, size)
+			}
+		})
+	}
+}
+
+func TestGitResource_DownloadLfsPointer(t *testing.T) {
+	tests := []struct {
+		name     string
+		allowLfs bool
+		expected string
+	}{
+		{"allowLfs", true, "binary asset"},
+		{"pointer saved as it is by default", false, testLfsPointer},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			clientMock := githubclientmock.ClientMock{}
+			opt := &github.RepositoryContentGetOptions{Ref: ""}
+			content := testLfsPointer
+			file := repositoryContent("file", "assets/logo.png", len(content), gitBlobSha(content, ""))
+			file.Content = &content
+			clientMock.On("ParseGetOptions", logMock, "").Return(opt, nil)
+			clientMock.On("GetRepositoryContents", logMock, "owner", "repo", "assets/logo.png", opt).Return(file, []*github.RepositoryContent(nil), nil).Once()
+			clientMock.On("IsFileContentType", mock.AnythingOfType("*github.RepositoryContent")).Return(true)
+			if test.allowLfs {
+				clientMock.On("GetLFSObject", logMock, "owner", "repo", testLfsOid, int64(12)).Return("binary asset", nil).Once()
+			}
+
+			gitResource := NewResourceWithMockedClient(&clientMock)
+			gitResource.Info.Path = "assets/logo.png"
+			gitResource.Info.AllowLfs = test.allowLfs
+			filesys := filemanager.NewMemoryFileSystem()
+
+			err := gitResource.Download(logMock, filesys, "logo.png")
+
+			assert.NoError(t, err)
+			clientMock.AssertExpectations(t)
+			saved, _ := filesys.ReadFile("logo.png")
+			assert.Equal(t, test.expected, string(saved))
+		})
+	}
+}
+
+func TestGitResource_DownloadLfsObjectTooLarge(t *testing.T) {
+	clientMock := githubclientmock.ClientMock{}
+	opt := &github.RepositoryContentGetOptions{Ref: ""}
+	content := testLfsPointer
+	file := repositoryContent("file", "assets/logo.png", len(content), "blob1")
+	file.Content = &content
+	clientMock.On("ParseGetOptions", logMock, "").Return(opt, nil)
+	clientMock.On("GetRepositoryContents", logMock, "owner", "repo", "assets/logo.png", opt).Return(file, []*github.RepositoryContent(nil), nil).Once()
+	clientMock.On("IsFileContentType", mock.AnythingOfType("*github.RepositoryContent")).Return(true)
+
+	gitResource := NewResourceWithMockedClient(&clientMock)
+	gitResource.Info.Path = "assets/logo.png"
+	gitResource.Info.AllowLfs = true
+	gitResource.maxFileSize = 10
+
+	err := gitResource.Download(logMock, filemanager.NewMemoryFileSystem(), "logo.png")
+
+	assert.Error(t, err)
+	clientMock.AssertNotCalled(t, "GetLFSObject", logMock, "owner", "repo", testLfsOid, int64(12))
+}
+
+func TestGitResource_ValidateLocationInfoAllowLfs(t *testing.T) {
+	gitResource := &GitResource{Info: GitInfo{Owner: "owner", Repository: "repo", AllowLfs: true}}
+	valid, err := gitResource.ValidateLocationInfo()
+	assert.True(t, valid)
+	assert.NoError(t, err)
+
+	gitResource.Info.Stream = true
+	valid, err = gitResource.ValidateLocationInfo()
+	assert.False(t, valid)
+	assert.EqualError(t, err, "AllowLfs for GitHub SourceType can't be combined with stream or concatenate")
+}
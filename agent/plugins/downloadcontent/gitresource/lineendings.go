@@ -0,0 +1,49 @@
+// Copyright 2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package gitresource
+
+import (
+	"runtime"
+	"strings"
+)
+
+// lineEnding is the line ending of text files on the platform of the agent
+var lineEnding = platformLineEnding(runtime.GOOS)
+
+// platformLineEnding returns the line ending text files use on the operating system
+func platformLineEnding(goos string) string {
+	if goos == "windows" {
+		return "\r\n"
+	}
+	return "\n"
+}
+
+// isBinaryContent returns true if the content has a null byte, which text files don't
+func isBinaryContent(content string) bool {
+	return strings.IndexByte(content, 0) >= 0
+}
+
+// normalizeLineEndings converts the CRLF and LF line endings of text content to the platform line ending.
+// Binary content is returned as is.
+func normalizeLineEndings(content string) string {
+	if isBinaryContent(content) {
+		return content
+	}
+	normalized := strings.Replace(content, "\r\n", "\n", -1)
+	if lineEnding != "\n" {
+		normalized = strings.Replace(normalized, "\n", lineEnding, -1)
+	}
+	return normalized
+}
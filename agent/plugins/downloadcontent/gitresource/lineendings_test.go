@@ -0,0 +1,120 @@
+// Copyright 2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package gitresource
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/aws/amazon-ssm-agent/agent/fileutil/filemanager"
+	githubclientmock "github.com/aws/amazon-ssm-agent/agent/githubclient/mock"
+	"github.com/go-github/github"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestNormalizeLineEndings(t *testing.T) {
+	data := []struct {
+		name       string
+		lineEnding string
+		content    string
+		expected   string
+	}{
+		{"crlf script to lf", "\n", "#!/bin/sh\r\necho hello\r\n", "#!/bin/sh\necho hello\n"},
+		{"lf script to lf", "\n", "#!/bin/sh\necho hello\n", "#!/bin/sh\necho hello\n"},
+		{"mixed script to lf", "\n", "#!/bin/sh\r\necho hello\n", "#!/bin/sh\necho hello\n"},
+		{"lf script to crlf", "\r\n", "echo hello\necho world\n", "echo hello\r\necho world\r\n"},
+		{"crlf script to crlf", "\r\n", "echo hello\r\necho world\r\n", "echo hello\r\necho world\r\n"},
+		{"binary to lf", "\n", "\x7fELF\x00\x01\r\n\x00", "\x7fELF\x00\x01\r\n\x00"},
+		{"binary to crlf", "\r\n", "PK\x03\x04\n\x00\n", "PK\x03\x04\n\x00\n"},
+	}
+	originalLineEnding := lineEnding
+	defer func() { lineEnding = originalLineEnding }()
+	for _, testdata := range data {
+		t.Run(testdata.name, func(t *testing.T) {
+			lineEnding = testdata.lineEnding
+			assert.Equal(t, testdata.expected, normalizeLineEndings(testdata.content))
+		})
+	}
+}
+
+func TestPlatformLineEnding(t *testing.T) {
+	assert.Equal(t, "\r\n", platformLineEnding("windows"))
+	assert.Equal(t, "\n", platformLineEnding("linux"))
+	assert.Equal(t, "\n", platformLineEnding("darwin"))
+}
+
+func TestGitResource_DownloadNormalizeLineEndings(t *testing.T) {
+	data := []struct {
+		name     string
+		content  string
+		expected string
+	}{
+		{"crlf script", "#!/bin/sh\r\necho hello\r\n", "#!/bin/sh\necho hello\n"},
+		{"lf script", "#!/bin/sh\necho hello\n", "#!/bin/sh\necho hello\n"},
+		{"binary file", "\x7fELF\x00\r\n\x00\r\n", "\x7fELF\x00\r\n\x00\r\n"},
+	}
+	originalLineEnding := lineEnding
+	lineEnding = "\n"
+	defer func() { lineEnding = originalLineEnding }()
+	for _, testdata := range data {
+		t.Run(testdata.name, func(t *testing.T) {
+			clientMock := githubclientmock.ClientMock{}
+			opt := &github.RepositoryContentGetOptions{Ref: ""}
+			content, file, filePath := testdata.content, "file", "path/to/script"
+			fileMetadata := &github.RepositoryContent{Content: &content, Type: &file, Path: &filePath}
+			clientMock.On("ParseGetOptions", logMock, "").Return(opt, nil)
+			clientMock.On("GetRepositoryContents", logMock, "owner", "repo", filePath, opt).Return(fileMetadata, []*github.RepositoryContent(nil), nil).Once()
+			clientMock.On("IsFileContentType", mock.AnythingOfType("*github.RepositoryContent")).Return(true)
+
+			gitResource := NewResourceWithMockedClient(&clientMock)
+			gitResource.Info.Path = filePath
+			gitResource.Info.NormalizeLineEndings = true
+			filesys := filemanager.NewMemoryFileSystem()
+			destination := filepath.Join("/var", "tmp", "script")
+
+			err := gitResource.Download(logMock, filesys, destination)
+
+			assert.NoError(t, err)
+			clientMock.AssertExpectations(t)
+			saved, err := filesys.ReadFile(destination)
+			assert.NoError(t, err)
+			assert.Equal(t, testdata.expected, saved)
+		})
+	}
+}
+
+func TestGitResource_ValidateLocationInfoNormalizeLineEndings(t *testing.T) {
+	data := []struct {
+		name  string
+		info  GitInfo
+		valid bool
+	}{
+		{"alone", GitInfo{Owner: "owner", Repository: "repo", NormalizeLineEndings: true}, true},
+		{"with stream", GitInfo{Owner: "owner", Repository: "repo", NormalizeLineEndings: true, Stream: true}, false},
+	}
+	for _, testdata := range data {
+		t.Run(testdata.name, func(t *testing.T) {
+			gitResource := &GitResource{Info: testdata.info}
+			valid, err := gitResource.ValidateLocationInfo()
+			assert.Equal(t, testdata.valid, valid)
+			if testdata.valid {
+				assert.NoError(t, err)
+			} else {
+				assert.Contains(t, err.Error(), "can't be combined with stream or verifySignature")
+			}
+		})
+	}
+}
@@ -0,0 +1,149 @@
+// Copyright 2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package gitresource
+
+import (
+	"github.com/aws/amazon-ssm-agent/agent/jsonutil"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/go-github/github"
+)
+
+// ListEntry describes a file or directory found under the location of a GitResource.
+// The JSON field names are a stable format parsed by automation, fields may be added but never renamed or removed.
+type ListEntry struct {
+	// Path of the entry from the root of the repository
+	Path string `json:"path"`
+	// Type as reported by GitHub, "file" or "dir", or "symlink" and "submodule"
+	Type string `json:"type"`
+	// Size in bytes, 0 for directories
+	Size int `json:"size"`
+	// Sha of the git blob or tree of the entry
+	Sha string `json:"sha"`
+
+	// sizeUnknown is set when GitHub did not report the size of the entry
+	sizeUnknown bool
+}
+
+// ListResult is the recursive listing of the location of a GitResource at a ref.
+// Entries of a directory are listed right after the entry of the directory itself.
+type ListResult struct {
+	Owner      string      `json:"owner"`
+	Repository string      `json:"repository"`
+	Ref        string      `json:"ref"`
+	Entries    []ListEntry `json:"entries"`
+}
+
+// List returns the files and directories that Download would fetch, without downloading any content
+func (git *GitResource) List(log log.T) (result ListResult, err error) {
+	return git.newDownloadContext(git.client, git.apiHost).listAll(log)
+}
+
+// listAll lists the entries of the location of the resource for List
+func (git *downloadContext) listAll(log log.T) (result ListResult, err error) {
+	getOptions := git.Info.GetOptions
+	if git.Info.RefPattern != "" {
+		if getOptions, err = git.resolveRefPattern(log, git.Info); err != nil {
+			return result, err
+		}
+	}
+	opt, err := git.client.ParseGetOptions(log, getOptions)
+	if err != nil {
+		return result, err
+	}
+	result = ListResult{
+		Owner:      git.Info.Owner,
+		Repository: git.Info.Repository,
+		Ref:        opt.Ref,
+		Entries:    []ListEntry{},
+	}
+
+	listPath, err := git.Info.repositoryPath()
+	if err != nil {
+		return result, err
+	}
+	result.Entries, err = git.list(log, listPath, opt, result.Entries)
+	return result, err
+}
+
+// list appends the entries found at listPath to entries, recursing into directories
+func (git *downloadContext) list(log log.T, listPath string, opt *github.RepositoryContentGetOptions, entries []ListEntry) ([]ListEntry, error) {
+	listInfo := git.Info
+	listInfo.Path = listPath
+	fileMetadata, directoryMetadata, err := git.getRepositoryContents(log, listInfo, opt)
+	if err != nil {
+		log.Error("Error occurred when trying to get repository contents - ", err)
+		return entries, err
+	}
+	if directoryMetadata == nil {
+		return append(entries, newListEntry(fileMetadata)), nil
+	}
+	for _, dirContent := range directoryMetadata {
+		entries = append(entries, newListEntry(dirContent))
+		if dirContent.GetType() == "dir" {
+			if entries, err = git.list(log, dirContent.GetPath(), opt, entries); err != nil {
+				return entries, err
+			}
+		}
+	}
+	return entries, nil
+}
+
+// newListEntry describes the content returned by GitHub
+func newListEntry(content *github.RepositoryContent) ListEntry {
+	return ListEntry{
+		Path: content.GetPath(),
+		Type: content.GetType(),
+		Size: content.GetSize(),
+		Sha:  content.GetSHA(),
+
+		sizeUnknown: content.Size == nil,
+	}
+}
+
+// DownloadSize is the amount of content Download would fetch, as reported by the metadata of the files
+type DownloadSize struct {
+	// Files is the number of files that would be downloaded
+	Files int `json:"files"`
+	// Bytes is the total size of the files whose size is known
+	Bytes int64 `json:"bytes"`
+	// UnknownSizeFiles is the number of files GitHub did not report a size for, Bytes leaves them out
+	UnknownSizeFiles int `json:"unknownSizeFiles"`
+}
+
+// TotalSize returns the size of the content Download would fetch, summed from the listing of the location
+// without downloading any content, so that a large pull can be confirmed before it starts
+func (git *GitResource) TotalSize(log log.T) (size DownloadSize, err error) {
+	result, err := git.List(log)
+	if err != nil {
+		return size, err
+	}
+	for _, entry := range result.Entries {
+		if entry.Type == "dir" || entry.Type == "submodule" {
+			continue
+		}
+		size.Files++
+		if entry.sizeUnknown {
+			size.UnknownSizeFiles++
+			continue
+		}
+		size.Bytes += int64(entry.Size)
+	}
+	return size, nil
+}
+
+// JSON serializes the listing for command output
+func (result ListResult) JSON() (string, error) {
+	return jsonutil.Marshal(result)
+}
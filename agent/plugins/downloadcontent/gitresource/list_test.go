@@ -0,0 +1,138 @@
+// Copyright 2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package gitresource
+
+import (
+	"errors"
+	"testing"
+
+	githubclientmock "github.com/aws/amazon-ssm-agent/agent/githubclient/mock"
+	"github.com/go-github/github"
+	"github.com/stretchr/testify/assert"
+)
+
+func repositoryContent(contentType, contentPath string, size int, sha string) *github.RepositoryContent {
+	return &github.RepositoryContent{Type: &contentType, Path: &contentPath, Size: &size, SHA: &sha}
+}
+
+func TestGitResource_ListJSON(t *testing.T) {
+	clientMock := githubclientmock.ClientMock{}
+	opt := &github.RepositoryContentGetOptions{Ref: "master"}
+	clientMock.On("ParseGetOptions", logMock, "").Return(opt, nil)
+	clientMock.On("GetRepositoryContents", logMock, "owner", "repo", "scripts", opt).Return((*github.RepositoryContent)(nil), []*github.RepositoryContent{
+		repositoryContent("dir", "scripts/lib", 0, "tree1"),
+		repositoryContent("file", "scripts/run.sh", 120, "blob1"),
+	}, nil).Once()
+	clientMock.On("GetRepositoryContents", logMock, "owner", "repo", "scripts/lib", opt).Return((*github.RepositoryContent)(nil), []*github.RepositoryContent{
+		repositoryContent("file", "scripts/lib/common.sh", 42, "blob2"),
+	}, nil).Once()
+
+	gitResource := NewResourceWithMockedClient(&clientMock)
+	gitResource.Info.Path = "scripts"
+
+	result, err := gitResource.List(logMock)
+	assert.NoError(t, err)
+	output, err := result.JSON()
+	assert.NoError(t, err)
+
+	clientMock.AssertExpectations(t)
+	assert.JSONEq(t, `{
+		"owner": "owner",
+		"repository": "repo",
+		"ref": "master",
+		"entries": [
+			{"path": "scripts/lib", "type": "dir", "size": 0, "sha": "tree1"},
+			{"path": "scripts/lib/common.sh", "type": "file", "size": 42, "sha": "blob2"},
+			{"path": "scripts/run.sh", "type": "file", "size": 120, "sha": "blob1"}
+		]
+	}`, output)
+}
+
+func TestGitResource_ListSingleFile(t *testing.T) {
+	clientMock := githubclientmock.ClientMock{}
+	opt := &github.RepositoryContentGetOptions{Ref: "master"}
+	clientMock.On("ParseGetOptions", logMock, "").Return(opt, nil)
+	clientMock.On("GetRepositoryContents", logMock, "owner", "repo", "path/to/file.ext", opt).Return(repositoryContent("file", "path/to/file.ext", 7, "blob"), []*github.RepositoryContent(nil), nil).Once()
+
+	result, err := NewResourceWithMockedClient(&clientMock).List(logMock)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []ListEntry{{Path: "path/to/file.ext", Type: "file", Size: 7, Sha: "blob"}}, result.Entries)
+}
+
+func TestGitResource_ListEmptyJSON(t *testing.T) {
+	clientMock := githubclientmock.ClientMock{}
+	opt := &github.RepositoryContentGetOptions{Ref: "master"}
+	clientMock.On("ParseGetOptions", logMock, "").Return(opt, nil)
+	clientMock.On("GetRepositoryContents", logMock, "owner", "repo", "path/to/file.ext", opt).Return((*github.RepositoryContent)(nil), []*github.RepositoryContent{}, nil).Once()
+
+	result, err := NewResourceWithMockedClient(&clientMock).List(logMock)
+	assert.NoError(t, err)
+	output, err := result.JSON()
+
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"owner": "owner", "repository": "repo", "ref": "master", "entries": []}`, output)
+}
+
+func TestGitResource_ListFails(t *testing.T) {
+	clientMock := githubclientmock.ClientMock{}
+	opt := &github.RepositoryContentGetOptions{Ref: "master"}
+	clientMock.On("ParseGetOptions", logMock, "").Return(opt, nil)
+	clientMock.On("GetRepositoryContents", logMock, "owner", "repo", "path/to/file.ext", opt).Return((*github.RepositoryContent)(nil), []*github.RepositoryContent(nil), errors.New("Rate limit exceeded")).Once()
+
+	_, err := NewResourceWithMockedClient(&clientMock).List(logMock)
+
+	assert.EqualError(t, err, "Rate limit exceeded")
+}
+
+func TestGitResource_TotalSize(t *testing.T) {
+	clientMock := githubclientmock.ClientMock{}
+	opt := &github.RepositoryContentGetOptions{Ref: "master"}
+	unknownType, unknownPath := "file", "scripts/lib/deep/unknown.bin"
+	clientMock.On("ParseGetOptions", logMock, "").Return(opt, nil)
+	clientMock.On("GetRepositoryContents", logMock, "owner", "repo", "scripts", opt).Return((*github.RepositoryContent)(nil), []*github.RepositoryContent{
+		repositoryContent("dir", "scripts/lib", 0, "tree1"),
+		repositoryContent("file", "scripts/run.sh", 120, "blob1"),
+		repositoryContent("submodule", "scripts/vendored", 0, "commit1"),
+	}, nil).Once()
+	clientMock.On("GetRepositoryContents", logMock, "owner", "repo", "scripts/lib", opt).Return((*github.RepositoryContent)(nil), []*github.RepositoryContent{
+		repositoryContent("file", "scripts/lib/common.sh", 42, "blob2"),
+		repositoryContent("dir", "scripts/lib/deep", 0, "tree2"),
+	}, nil).Once()
+	clientMock.On("GetRepositoryContents", logMock, "owner", "repo", "scripts/lib/deep", opt).Return((*github.RepositoryContent)(nil), []*github.RepositoryContent{
+		repositoryContent("file", "scripts/lib/deep/large.bin", 3<<30, "blob3"),
+		{Type: &unknownType, Path: &unknownPath},
+	}, nil).Once()
+
+	gitResource := NewResourceWithMockedClient(&clientMock)
+	gitResource.Info.Path = "scripts"
+
+	size, err := gitResource.TotalSize(logMock)
+
+	assert.NoError(t, err)
+	clientMock.AssertExpectations(t)
+	assert.Equal(t, DownloadSize{Files: 4, Bytes: 120 + 42 + 3<<30, UnknownSizeFiles: 1}, size)
+}
+
+func TestGitResource_TotalSizeFails(t *testing.T) {
+	clientMock := githubclientmock.ClientMock{}
+	opt := &github.RepositoryContentGetOptions{Ref: "master"}
+	clientMock.On("ParseGetOptions", logMock, "").Return(opt, nil)
+	clientMock.On("GetRepositoryContents", logMock, "owner", "repo", "path/to/file.ext", opt).Return((*github.RepositoryContent)(nil), []*github.RepositoryContent(nil), errors.New("Rate limit exceeded")).Once()
+
+	_, err := NewResourceWithMockedClient(&clientMock).TotalSize(logMock)
+
+	assert.EqualError(t, err, "Rate limit exceeded")
+}
@@ -0,0 +1,175 @@
+// Copyright 2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package gitresource
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/aws/amazon-ssm-agent/agent/fileutil/filemanager"
+	"github.com/aws/amazon-ssm-agent/agent/jsonutil"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/downloadcontent/system"
+)
+
+const (
+	// manifestFileName is the sidecar written to the destination of a download with writeManifest
+	manifestFileName = ".ssm-manifest.json"
+	// manifestCreator tells manifests written by the agent, which may be replaced, from user files of the same name
+	manifestCreator = "amazon-ssm-agent"
+)
+
+// Manifest lists the files of a download for downstream verification
+type Manifest struct {
+	CreatedBy string          `json:"createdBy"`
+	Files     []ManifestEntry `json:"files"`
+}
+
+// ManifestEntry describes a downloaded file, its path is relative to the manifest and uses forward slashes
+type ManifestEntry struct {
+	Path   string `json:"path"`
+	Size   int    `json:"size"`
+	Sha256 string `json:"sha256"`
+}
+
+// downloadedFile is a file saved by a download writing a manifest
+type downloadedFile struct {
+	localPath string
+	size      int
+	sha256    string
+}
+
+// saveFile saves the content of a downloaded file with the permissions of mode, the default ones when 0, and records it when a manifest is being written.
+// The line endings of text files are normalized first when NormalizeLineEndings is set.
+func (git *downloadContext) saveFile(log log.T, filesys filemanager.FileSystem, destination string, content string, mode os.FileMode) error {
+	if git.Info.NormalizeLineEndings {
+		content = normalizeLineEndings(content)
+	}
+	if err := git.reserveSavedBytes(destination, int64(len(content))); err != nil {
+		return err
+	}
+	if err := system.SaveFileContentWithMode(log, filesys, destination, content, git.fileOwnership, mode); err != nil {
+		return err
+	}
+	git.recordFile(destination, content)
+	return nil
+}
+
+// saveStream saves a downloaded file as it is read and records it when a manifest is being written
+func (git *downloadContext) saveStream(log log.T, filesys filemanager.FileSystem, destination string, content io.Reader) error {
+	hash := sha256.New()
+	if git.writingManifest() {
+		content = io.TeeReader(content, hash)
+	}
+	written, err := system.SaveFileStreamWithOwnership(log, filesys, destination, content, git.fileOwnership)
+	if err != nil {
+		return err
+	}
+	if err = git.countSavedBytes(destination, written); err != nil {
+		return err
+	}
+	git.recordDigest(destination, int(written), hex.EncodeToString(hash.Sum(nil)))
+	return nil
+}
+
+// recordExistingFile records a file reused from a previous download when a manifest is being written
+func (git *downloadContext) recordExistingFile(filesys filemanager.FileSystem, destination string) error {
+	if !git.writingManifest() {
+		return nil
+	}
+	content, err := filesys.ReadFile(destination)
+	if err != nil {
+		return fmt.Errorf("Could not read %v for the download manifest - %v", destination, err)
+	}
+	git.recordFile(destination, content)
+	return nil
+}
+
+// recordFile adds a saved file to the manifest being written, if any
+func (git *downloadContext) recordFile(destination string, content string) {
+	if !git.writingManifest() {
+		return
+	}
+	sum := sha256.Sum256([]byte(content))
+	git.recordDigest(destination, len(content), hex.EncodeToString(sum[:]))
+}
+
+// recordDigest adds a saved file of the given size and SHA-256 to the manifest being written, if any
+func (git *downloadContext) recordDigest(destination string, size int, sum string) {
+	git.downloadedLock.Lock()
+	defer git.downloadedLock.Unlock()
+	if git.downloaded == nil {
+		return
+	}
+	git.downloaded = append(git.downloaded, downloadedFile{
+		localPath: destination,
+		size:      size,
+		sha256:    sum,
+	})
+}
+
+// writingManifest returns true if the files being downloaded are recorded for a manifest
+func (git *downloadContext) writingManifest() bool {
+	git.downloadedLock.Lock()
+	defer git.downloadedLock.Unlock()
+	return git.downloaded != nil
+}
+
+// writeManifest writes the manifest of the downloaded files to the destPath directory,
+// or next to the file when destPath names the downloaded file.
+// A file of the same name that wasn't written by the agent is never overwritten.
+func writeManifest(log log.T, filesys filemanager.FileSystem, destPath string, files []downloadedFile) error {
+	manifestDir := destPath
+	if !filesys.IsDirectory(destPath) {
+		manifestDir = filepath.Dir(destPath)
+	}
+	manifestPath := filepath.Join(manifestDir, manifestFileName)
+	if filesys.Exists(manifestPath) {
+		var existing Manifest
+		content, err := filesys.ReadFile(manifestPath)
+		if err == nil {
+			err = jsonutil.Unmarshal(content, &existing)
+		}
+		if err != nil || existing.CreatedBy != manifestCreator {
+			return fmt.Errorf("%v already exists and is not a download manifest, it was not overwritten", manifestPath)
+		}
+	}
+
+	manifest := Manifest{CreatedBy: manifestCreator, Files: []ManifestEntry{}}
+	for _, file := range files {
+		relativePath, err := filepath.Rel(manifestDir, file.localPath)
+		if err != nil {
+			return fmt.Errorf("Could not add %v to the download manifest - %v", file.localPath, err)
+		}
+		manifest.Files = append(manifest.Files, ManifestEntry{
+			Path:   filepath.ToSlash(relativePath),
+			Size:   file.size,
+			Sha256: file.sha256,
+		})
+	}
+	// the files of a directory are downloaded concurrently, so they are recorded in no particular order
+	sort.Slice(manifest.Files, func(i, j int) bool { return manifest.Files[i].Path < manifest.Files[j].Path })
+	content, err := jsonutil.MarshalIndent(manifest)
+	if err != nil {
+		return err
+	}
+	log.Debugf("Writing download manifest %v", manifestPath)
+	return filesys.WriteFile(manifestPath, content)
+}
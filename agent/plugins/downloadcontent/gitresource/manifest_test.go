@@ -0,0 +1,117 @@
+// Copyright 2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package gitresource
+
+import (
+	"path/filepath"
+	"testing"
+
+	filemock "github.com/aws/amazon-ssm-agent/agent/fileutil/filemanager/mock"
+	githubclientmock "github.com/aws/amazon-ssm-agent/agent/githubclient/mock"
+	"github.com/go-github/github"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestGitResource_DownloadWritesManifest(t *testing.T) {
+	manifestPath := filepath.Join("destination", manifestFileName)
+	data := []struct {
+		name             string
+		existingManifest string
+		expectedErr      string
+	}{
+		{"no existing manifest", "", ""},
+		{"previous download manifest is replaced", `{"createdBy": "amazon-ssm-agent", "files": []}`, ""},
+		{"user file is not overwritten", "my notes", manifestPath + " already exists and is not a download manifest, it was not overwritten"},
+	}
+	for _, testdata := range data {
+		t.Run(testdata.name, func(t *testing.T) {
+			clientMock := githubclientmock.ClientMock{}
+			opt := &github.RepositoryContentGetOptions{Ref: "master"}
+			scriptContent, libContent := "echo hello", "lib"
+			script := repositoryContent("file", "scripts/run.sh", len(scriptContent), "blob1")
+			script.Content = &scriptContent
+			lib := repositoryContent("file", "scripts/lib/common.sh", len(libContent), "blob2")
+			lib.Content = &libContent
+
+			clientMock.On("ParseGetOptions", logMock, "").Return(opt, nil)
+			clientMock.On("GetRepositoryContents", logMock, "owner", "repo", "scripts", opt).Return((*github.RepositoryContent)(nil), []*github.RepositoryContent{
+				repositoryContent("dir", "scripts/lib", 0, "tree1"),
+				script,
+			}, nil).Once()
+			clientMock.On("GetRepositoryContents", logMock, "owner", "repo", "scripts/lib", opt).Return((*github.RepositoryContent)(nil), []*github.RepositoryContent{lib}, nil).Once()
+			clientMock.On("GetRepositoryContents", logMock, "owner", "repo", "scripts/lib/common.sh", opt).Return(lib, []*github.RepositoryContent(nil), nil).Once()
+			clientMock.On("GetRepositoryContents", logMock, "owner", "repo", "scripts/run.sh", opt).Return(script, []*github.RepositoryContent(nil), nil).Once()
+			clientMock.On("IsFileContentType", mock.AnythingOfType("*github.RepositoryContent")).Return(true)
+
+			var manifest string
+			fileMock := filemock.FileSystemMock{}
+			fileMock.On("MakeDirs", mock.Anything).Return(nil)
+			fileMock.On("WriteFile", filepath.Join("destination", "lib", "common.sh"), libContent).Return(nil)
+			fileMock.On("WriteFile", filepath.Join("destination", "run.sh"), scriptContent).Return(nil)
+			fileMock.On("IsDirectory", "destination").Return(true)
+			fileMock.On("Exists", manifestPath).Return(testdata.existingManifest != "")
+			if testdata.existingManifest != "" {
+				fileMock.On("ReadFile", manifestPath).Return(testdata.existingManifest, nil)
+			}
+			if testdata.expectedErr == "" {
+				fileMock.On("WriteFile", manifestPath, mock.Anything).Run(func(args mock.Arguments) {
+					manifest = args.String(1)
+				}).Return(nil)
+			}
+
+			gitResource := NewResourceWithMockedClient(&clientMock)
+			gitResource.Info.Path = "scripts"
+			gitResource.Info.WriteManifest = true
+
+			err := gitResource.Download(logMock, fileMock, "destination")
+
+			fileMock.AssertExpectations(t)
+			if testdata.expectedErr != "" {
+				assert.EqualError(t, err, testdata.expectedErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.JSONEq(t, `{
+				"createdBy": "amazon-ssm-agent",
+				"files": [
+					{"path": "lib/common.sh", "size": 3, "sha256": "76b5a357391276b282a516f54f48ef3c207f46d8192dc58c208d5183d38415f8"},
+					{"path": "run.sh", "size": 10, "sha256": "584a331fd6b02dcb1ecbe2eba731f609a2e1e3dac0bb73ae998dfad14c309a77"}
+				]
+			}`, manifest)
+		})
+	}
+}
+
+func TestGitResource_DownloadWithoutManifest(t *testing.T) {
+	clientMock := githubclientmock.ClientMock{}
+	opt := &github.RepositoryContentGetOptions{Ref: "master"}
+	content := "content"
+	file := repositoryContent("file", "path/to/file.ext", len(content), "blob")
+	file.Content = &content
+	clientMock.On("ParseGetOptions", logMock, "").Return(opt, nil)
+	clientMock.On("GetRepositoryContents", logMock, "owner", "repo", "path/to/file.ext", opt).Return(file, []*github.RepositoryContent(nil), nil).Once()
+	clientMock.On("IsFileContentType", mock.AnythingOfType("*github.RepositoryContent")).Return(true)
+
+	fileMock := filemock.FileSystemMock{}
+	fileMock.On("Exists", "destination").Return(false)
+	fileMock.On("MakeDirs", ".").Return(nil)
+	fileMock.On("WriteFile", "destination", content).Return(nil)
+
+	err := NewResourceWithMockedClient(&clientMock).Download(logMock, fileMock, "destination")
+
+	assert.NoError(t, err)
+	fileMock.AssertExpectations(t)
+}
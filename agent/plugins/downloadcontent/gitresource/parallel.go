@@ -0,0 +1,82 @@
+// Copyright 2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package gitresource
+
+import (
+	"sync"
+)
+
+// downloadGroup runs the file downloads of a directory concurrently and keeps the first error.
+// The groups of all the directories of a download share its slots, bounding the files downloaded at once.
+type downloadGroup struct {
+	slots   chan struct{}
+	running sync.WaitGroup
+	lock    sync.Mutex
+	err     error
+}
+
+// newFileSlots returns the slots of a download, one per file that may be downloaded at once
+func (git *GitResource) newFileSlots() chan struct{} {
+	concurrency := git.downloadConcurrency
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+	return make(chan struct{}, concurrency)
+}
+
+// newDownloadGroup returns a group taking the slots of the download in progress, files are downloaded one at a time without one
+func (git *downloadContext) newDownloadGroup() *downloadGroup {
+	slots := git.fileSlots
+	if slots == nil {
+		slots = make(chan struct{}, 1)
+	}
+	return &downloadGroup{slots: slots}
+}
+
+// Go runs download once a slot is free. It returns false, without running download, when a download of the group already failed.
+func (g *downloadGroup) Go(download func() error) bool {
+	g.slots <- struct{}{}
+	if g.Err() != nil {
+		<-g.slots
+		return false
+	}
+	g.running.Add(1)
+	go func() {
+		defer g.running.Done()
+		if err := download(); err != nil {
+			g.lock.Lock()
+			if g.err == nil {
+				g.err = err
+			}
+			g.lock.Unlock()
+		}
+		// released after the error is kept so the next download of a sequential group sees it
+		<-g.slots
+	}()
+	return true
+}
+
+// Err returns the first error of the downloads of the group
+func (g *downloadGroup) Err() error {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	return g.err
+}
+
+// Wait waits for the running downloads of the group and returns the first error
+func (g *downloadGroup) Wait() error {
+	g.running.Wait()
+	return g.Err()
+}
@@ -0,0 +1,156 @@
+// Copyright 2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package gitresource
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/fileutil/filemanager"
+	githubclientmock "github.com/aws/amazon-ssm-agent/agent/githubclient/mock"
+	"github.com/go-github/github"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// inFlight counts the fetches running at once and the most seen
+type inFlight struct {
+	lock    sync.Mutex
+	running int
+	most    int
+}
+
+func (f *inFlight) fetch(mock.Arguments) {
+	f.lock.Lock()
+	f.running++
+	if f.running > f.most {
+		f.most = f.running
+	}
+	f.lock.Unlock()
+	time.Sleep(20 * time.Millisecond)
+	f.lock.Lock()
+	f.running--
+	f.lock.Unlock()
+}
+
+// mockDirectory expects the listing of scripts, holding a lib directory and count files, and the fetch of each file
+func mockDirectory(clientMock *githubclientmock.ClientMock, opt *github.RepositoryContentGetOptions, count int, fetches *inFlight, failing string) {
+	content := "echo"
+	libFile := repositoryContent("file", "scripts/lib/common.sh", len(content), "lib")
+	libFile.Content = &content
+	entries := []*github.RepositoryContent{repositoryContent("dir", "scripts/lib", 0, "tree")}
+	for i := 0; i < count; i++ {
+		file := repositoryContent("file", fmt.Sprintf("scripts/%02d.sh", i), len(content), fmt.Sprintf("blob%v", i))
+		file.Content = &content
+		entries = append(entries, file)
+		if file.GetPath() == failing {
+			clientMock.On("GetRepositoryContents", logMock, "owner", "repo", file.GetPath(), opt).Return((*github.RepositoryContent)(nil), []*github.RepositoryContent(nil), errors.New("Response is - 404 Not Found")).Run(fetches.fetch)
+		} else {
+			clientMock.On("GetRepositoryContents", logMock, "owner", "repo", file.GetPath(), opt).Return(file, []*github.RepositoryContent(nil), nil).Run(fetches.fetch)
+		}
+	}
+	clientMock.On("ParseGetOptions", logMock, "").Return(opt, nil)
+	clientMock.On("GetRepositoryContents", logMock, "owner", "repo", "scripts", opt).Return((*github.RepositoryContent)(nil), entries, nil).Once()
+	clientMock.On("GetRepositoryContents", logMock, "owner", "repo", "scripts/lib", opt).Return((*github.RepositoryContent)(nil), []*github.RepositoryContent{libFile}, nil).Once()
+	clientMock.On("GetRepositoryContents", logMock, "owner", "repo", "scripts/lib/common.sh", opt).Return(libFile, []*github.RepositoryContent(nil), nil).Run(fetches.fetch).Once()
+	clientMock.On("IsFileContentType", mock.AnythingOfType("*github.RepositoryContent")).Return(true)
+}
+
+func TestGitResource_DownloadDirectoryConcurrently(t *testing.T) {
+	clientMock := githubclientmock.ClientMock{}
+	opt := &github.RepositoryContentGetOptions{Ref: "master"}
+	fetches := &inFlight{}
+	mockDirectory(&clientMock, opt, 12, fetches, "")
+
+	gitResource := NewResourceWithMockedClient(&clientMock)
+	gitResource.Info.Path = "scripts"
+	gitResource.downloadConcurrency = 3
+	filesys := filemanager.NewMemoryFileSystem()
+
+	err := gitResource.Download(logMock, filesys, "destination")
+
+	assert.NoError(t, err)
+	clientMock.AssertExpectations(t)
+	assert.Equal(t, 3, fetches.most)
+	assert.Len(t, filesys.Files(), 13)
+	assert.True(t, filesys.Exists(filepath.Join("destination", "lib", "common.sh")))
+}
+
+func TestGitResource_DownloadDirectoryConcurrentlyFails(t *testing.T) {
+	clientMock := githubclientmock.ClientMock{}
+	opt := &github.RepositoryContentGetOptions{Ref: "master"}
+	fetches := &inFlight{}
+	mockDirectory(&clientMock, opt, 12, fetches, "scripts/04.sh")
+
+	gitResource := NewResourceWithMockedClient(&clientMock)
+	gitResource.Info.Path = "scripts"
+	gitResource.downloadConcurrency = 3
+
+	err := gitResource.Download(logMock, filemanager.NewMemoryFileSystem(), "destination")
+
+	assert.EqualError(t, err, "Response is - 404 Not Found")
+	// files are no longer started once one failed
+	clientMock.AssertNotCalled(t, "GetRepositoryContents", logMock, "owner", "repo", "scripts/11.sh", opt)
+	assert.Equal(t, 0, fetches.running)
+}
+
+func TestGitResource_DownloadsShareNoState(t *testing.T) {
+	clientMock := githubclientmock.ClientMock{}
+	opt := &github.RepositoryContentGetOptions{Ref: "master"}
+	content := "echo"
+	var entries []*github.RepositoryContent
+	for i := 0; i < 4; i++ {
+		file := repositoryContent("file", fmt.Sprintf("scripts/%02d.sh", i), len(content), fmt.Sprintf("blob%v", i))
+		file.Content = &content
+		entries = append(entries, file)
+		clientMock.On("GetRepositoryContents", logMock, "owner", "repo", file.GetPath(), opt).Return(file, []*github.RepositoryContent(nil), nil).Run(func(mock.Arguments) { time.Sleep(5 * time.Millisecond) })
+	}
+	clientMock.On("ParseGetOptions", logMock, "").Return(opt, nil)
+	clientMock.On("GetRepositoryContents", logMock, "owner", "repo", "scripts", opt).Return((*github.RepositoryContent)(nil), entries, nil)
+	clientMock.On("IsFileContentType", mock.AnythingOfType("*github.RepositoryContent")).Return(true)
+
+	gitResource := NewResourceWithMockedClient(&clientMock)
+	gitResource.Info.Path = "scripts"
+	gitResource.Info.Flatten = true
+	gitResource.Info.WriteManifest = true
+	gitResource.downloadConcurrency = 2
+	filesys := filemanager.NewMemoryFileSystem()
+
+	// downloads of the same resource running at once each record and flatten their own files
+	destinations := []string{"first", "second", "third"}
+	errs := make([]error, len(destinations))
+	var running sync.WaitGroup
+	for i, destination := range destinations {
+		running.Add(1)
+		go func(i int, destination string) {
+			defer running.Done()
+			errs[i] = gitResource.Download(logMock, filesys, destination)
+		}(i, destination)
+	}
+	running.Wait()
+
+	for i, destination := range destinations {
+		assert.NoError(t, errs[i])
+		assert.Equal(t, []string{manifestFileName, "00.sh", "01.sh", "02.sh", "03.sh"}, filesys.FilesUnder(destination))
+		manifest, err := filesys.ReadFile(filepath.Join(destination, manifestFileName))
+		assert.NoError(t, err)
+		assert.Equal(t, 4, strings.Count(manifest, `"sha256"`))
+	}
+}
@@ -0,0 +1,161 @@
+// Copyright 2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package gitresource
+
+import (
+	"crypto/sha1"
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/fileutil/artifact"
+	"github.com/aws/amazon-ssm-agent/agent/fileutil/filemanager"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/go-github/github"
+)
+
+// prefetchCacheDir holds the files saved by Prefetch, named after their git blob SHA
+var prefetchCacheDir = filepath.Join(appconfig.DownloadRoot, "gitresource", "prefetch")
+
+// gitBlobSha returns the git object name of content, SHA-256 when sha is one and SHA-1 otherwise
+func gitBlobSha(content string, sha string) string {
+	object := fmt.Sprintf("blob %d\x00%s", len(content), content)
+	if len(sha) == 64 {
+		return fmt.Sprintf("%x", sha256.Sum256([]byte(object)))
+	}
+	return fmt.Sprintf("%x", sha1.Sum([]byte(object)))
+}
+
+// prefetchedPath returns where the file with the blob SHA is kept in the prefetch cache
+func prefetchedPath(sha string) string {
+	return filepath.Join(prefetchCacheDir, strings.ToLower(sha))
+}
+
+// readPrefetched returns the cached content of the blob SHA, false when it isn't cached or its content doesn't have that SHA
+func readPrefetched(log log.T, filesys filemanager.FileSystem, sha string) (string, bool) {
+	if !blobShaPattern.MatchString(sha) || !filesys.Exists(prefetchedPath(sha)) {
+		return "", false
+	}
+	content, err := filesys.ReadFile(prefetchedPath(sha))
+	if err != nil {
+		log.Debugf("Could not read prefetched blob %v - %v", sha, err)
+		return "", false
+	}
+	if !strings.EqualFold(gitBlobSha(content, sha), sha) {
+		log.Warnf("Ignoring prefetched blob %v, its content has changed", sha)
+		return "", false
+	}
+	return content, true
+}
+
+// Prefetch saves the files of paths, files or directories relative to RootPath at the ref of GetOptions, to the prefetch cache
+// under DownloadRoot. Later downloads with PrefetchCache set take the files of the directories they download from the cache
+// instead of fetching each of them, as long as their blob SHA is the same.
+func (git *GitResource) Prefetch(log log.T, filesys filemanager.FileSystem, paths []string) (err error) {
+	info := git.Info
+	log = verboseLogger(log, info.Verbose)
+	if info.GetOptions == "" && git.defaultRef != "" {
+		info.GetOptions = "branch:" + git.defaultRef
+	}
+	download := git.newDownloadContext(git.client, git.apiHost)
+	opt, err := download.client.ParseGetOptions(log, info.GetOptions)
+	if err != nil {
+		return err
+	}
+	if err = filesys.MakeDirs(prefetchCacheDir); err != nil {
+		return err
+	}
+	for _, prefetchPath := range paths {
+		info.Path = prefetchPath
+		if info.Path, err = info.repositoryPath(); err != nil {
+			return err
+		}
+		log.Infof("Prefetching %v from ref %v", info.Path, opt.Ref)
+		if err = download.prefetch(log, filesys, info, opt); err != nil {
+			return fmt.Errorf("Could not prefetch %v - %v", prefetchPath, err)
+		}
+	}
+	return nil
+}
+
+// prefetch saves the file or the files of the directory at info.Path to the prefetch cache, skipping the ones already there
+func (git *downloadContext) prefetch(log log.T, filesys filemanager.FileSystem, info GitInfo, opt *github.RepositoryContentGetOptions) error {
+	fileMetadata, directoryMetadata, err := git.getRepositoryContents(log, info, opt)
+	if err != nil {
+		return err
+	}
+	if directoryMetadata == nil {
+		return git.savePrefetched(log, filesys, fileMetadata)
+	}
+	for _, entry := range directoryMetadata {
+		if entry.GetType() == "file" {
+			if _, found := readPrefetched(log, filesys, entry.GetSHA()); found {
+				log.Debugf("%v is already prefetched", entry.GetPath())
+				continue
+			}
+		}
+		entryInfo := info
+		entryInfo.Path = entry.GetPath()
+		if err = git.prefetch(log, filesys, entryInfo, opt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// savePrefetched saves the content of a file to the prefetch cache once it is verified to have the blob SHA GitHub declared
+func (git *downloadContext) savePrefetched(log log.T, filesys filemanager.FileSystem, fileMetadata *github.RepositoryContent) error {
+	if !git.client.IsFileContentType(fileMetadata) {
+		log.Debugf("Skipping %v, only files are prefetched", fileMetadata.GetPath())
+		return nil
+	}
+	content, err := fileContent(fileMetadata)
+	if err != nil {
+		return err
+	}
+	if err = artifact.CheckDeclaredSize(fileMetadata.GetPath(), int64(len(content)), git.maxFileSize); err != nil {
+		return err
+	}
+	sha := fileMetadata.GetSHA()
+	if !blobShaPattern.MatchString(sha) || !strings.EqualFold(gitBlobSha(content, sha), sha) {
+		return fmt.Errorf("Content of %v does not match its blob SHA %v", fileMetadata.GetPath(), sha)
+	}
+	log.Debugf("Prefetched %v as blob %v", fileMetadata.GetPath(), sha)
+	return filesys.WriteFile(prefetchedPath(sha), content)
+}
+
+// downloadPrefetched saves a file entry of a directory download from the prefetch cache with the permissions of mode,
+// it returns false when the blob of the entry isn't cached and must be downloaded
+func (git *downloadContext) downloadPrefetched(log log.T, filesys filemanager.FileSystem, entry *github.RepositoryContent, destination string, mode os.FileMode) (bool, error) {
+	content, found := readPrefetched(log, filesys, entry.GetSHA())
+	if !found {
+		return false, nil
+	}
+	if err := artifact.CheckDeclaredSize(entry.GetPath(), int64(len(content)), git.maxFileSize); err != nil {
+		return true, err
+	}
+	content, err := git.resolveLfs(log, entry.GetPath(), content)
+	if err != nil {
+		return true, err
+	}
+	if content, err = git.renderTemplate(log, entry.GetPath(), content); err != nil {
+		return true, err
+	}
+	log.Debugf("Saving prefetched %v (%v bytes) to %v", entry.GetPath(), len(content), destination)
+	return true, git.saveFile(log, filesys, destination, content, mode)
+}
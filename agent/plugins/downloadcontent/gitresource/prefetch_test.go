@@ -0,0 +1,144 @@
+// Copyright 2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package gitresource
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/aws/amazon-ssm-agent/agent/fileutil/filemanager"
+	githubclientmock "github.com/aws/amazon-ssm-agent/agent/githubclient/mock"
+	"github.com/go-github/github"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// prefetchedFile returns the directory entry and the metadata with content of a file whose SHA is the blob SHA of content
+func prefetchedFile(filePath string, content string) (*github.RepositoryContent, *github.RepositoryContent) {
+	sha := gitBlobSha(content, "")
+	entry := repositoryContent("file", filePath, len(content), sha)
+	metadata := repositoryContent("file", filePath, len(content), sha)
+	metadata.Content = &content
+	return entry, metadata
+}
+
+func TestGitBlobSha(t *testing.T) {
+	// git hash-object of "hello\n"
+	assert.Equal(t, "ce013625030ba8dba906f756967f9e9ca394464a", gitBlobSha("hello\n", ""))
+	assert.Equal(t, "e69de29bb2d1d6434b8b29ae775ad8c2e48c5391", gitBlobSha("", ""))
+	assert.Len(t, gitBlobSha("hello\n", "ce013625030ba8dba906f756967f9e9ca394464ace013625030ba8dba906f756"), 64)
+}
+
+func TestGitResource_PrefetchThenDownload(t *testing.T) {
+	clientMock := githubclientmock.ClientMock{}
+	opt := &github.RepositoryContentGetOptions{Ref: "master"}
+	installEntry, installMetadata := prefetchedFile("bootstrap/install.sh", "echo install")
+	configEntry, configMetadata := prefetchedFile("bootstrap/conf/agent.json", `{"enabled": true}`)
+	clientMock.On("ParseGetOptions", logMock, "").Return(opt, nil)
+	clientMock.On("GetRepositoryContents", logMock, "owner", "repo", "bootstrap", opt).Return((*github.RepositoryContent)(nil), []*github.RepositoryContent{
+		installEntry,
+		repositoryContent("dir", "bootstrap/conf", 0, "tree1"),
+	}, nil).Twice()
+	clientMock.On("GetRepositoryContents", logMock, "owner", "repo", "bootstrap/conf", opt).Return((*github.RepositoryContent)(nil), []*github.RepositoryContent{configEntry}, nil).Twice()
+	// each file is only fetched by the prefetch
+	clientMock.On("GetRepositoryContents", logMock, "owner", "repo", "bootstrap/install.sh", opt).Return(installMetadata, []*github.RepositoryContent(nil), nil).Once()
+	clientMock.On("GetRepositoryContents", logMock, "owner", "repo", "bootstrap/conf/agent.json", opt).Return(configMetadata, []*github.RepositoryContent(nil), nil).Once()
+	clientMock.On("IsFileContentType", mock.AnythingOfType("*github.RepositoryContent")).Return(true)
+	filesys := filemanager.NewMemoryFileSystem()
+
+	gitResource := NewResourceWithMockedClient(&clientMock)
+	assert.NoError(t, gitResource.Prefetch(logMock, filesys, []string{"bootstrap"}))
+
+	gitResource.Info.Path = "bootstrap"
+	gitResource.Info.PrefetchCache = true
+	destination := filepath.Join("/var", "tmp", "bootstrap")
+	assert.NoError(t, gitResource.Download(logMock, filesys, destination))
+
+	clientMock.AssertExpectations(t)
+	install, err := filesys.ReadFile(filepath.Join(destination, "install.sh"))
+	assert.NoError(t, err)
+	assert.Equal(t, "echo install", install)
+	config, err := filesys.ReadFile(filepath.Join(destination, "conf", "agent.json"))
+	assert.NoError(t, err)
+	assert.Equal(t, `{"enabled": true}`, config)
+}
+
+func TestGitResource_DownloadPrefetchCacheMiss(t *testing.T) {
+	data := []struct {
+		name   string
+		cached func(filesys *filemanager.MemoryFileSystem, sha string)
+	}{
+		{"changed in the repository", func(filesys *filemanager.MemoryFileSystem, sha string) {
+			filesys.WriteFile(prefetchedPath(gitBlobSha("echo old", "")), "echo old")
+		}},
+		{"tampered cache", func(filesys *filemanager.MemoryFileSystem, sha string) {
+			filesys.WriteFile(prefetchedPath(sha), "echo tampered")
+		}},
+	}
+	for _, testdata := range data {
+		t.Run(testdata.name, func(t *testing.T) {
+			clientMock := githubclientmock.ClientMock{}
+			opt := &github.RepositoryContentGetOptions{Ref: "master"}
+			entry, metadata := prefetchedFile("bootstrap/install.sh", "echo new")
+			clientMock.On("ParseGetOptions", logMock, "").Return(opt, nil)
+			clientMock.On("GetRepositoryContents", logMock, "owner", "repo", "bootstrap", opt).Return((*github.RepositoryContent)(nil), []*github.RepositoryContent{entry}, nil).Once()
+			clientMock.On("GetRepositoryContents", logMock, "owner", "repo", "bootstrap/install.sh", opt).Return(metadata, []*github.RepositoryContent(nil), nil).Once()
+			clientMock.On("IsFileContentType", mock.AnythingOfType("*github.RepositoryContent")).Return(true)
+			filesys := filemanager.NewMemoryFileSystem()
+			testdata.cached(filesys, entry.GetSHA())
+
+			gitResource := NewResourceWithMockedClient(&clientMock)
+			gitResource.Info.Path = "bootstrap"
+			gitResource.Info.PrefetchCache = true
+			destination := filepath.Join("/var", "tmp", "bootstrap")
+			assert.NoError(t, gitResource.Download(logMock, filesys, destination))
+
+			clientMock.AssertExpectations(t)
+			install, err := filesys.ReadFile(filepath.Join(destination, "install.sh"))
+			assert.NoError(t, err)
+			assert.Equal(t, "echo new", install)
+		})
+	}
+}
+
+func TestGitResource_PrefetchRejectsContentNotMatchingSha(t *testing.T) {
+	clientMock := githubclientmock.ClientMock{}
+	opt := &github.RepositoryContentGetOptions{Ref: "master"}
+	_, metadata := prefetchedFile("bootstrap/install.sh", "echo install")
+	tampered := "echo tampered"
+	metadata.Content = &tampered
+	clientMock.On("ParseGetOptions", logMock, "").Return(opt, nil)
+	clientMock.On("GetRepositoryContents", logMock, "owner", "repo", "bootstrap/install.sh", opt).Return(metadata, []*github.RepositoryContent(nil), nil).Once()
+	clientMock.On("IsFileContentType", mock.AnythingOfType("*github.RepositoryContent")).Return(true)
+	filesys := filemanager.NewMemoryFileSystem()
+
+	err := NewResourceWithMockedClient(&clientMock).Prefetch(logMock, filesys, []string{"bootstrap/install.sh"})
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "does not match its blob SHA")
+	assert.False(t, filesys.Exists(prefetchedPath(metadata.GetSHA())))
+}
+
+func TestGitResource_ValidateLocationInfoPrefetchCache(t *testing.T) {
+	gitResource := &GitResource{Info: GitInfo{Owner: "owner", Repository: "repo", PrefetchCache: true}}
+	valid, err := gitResource.ValidateLocationInfo()
+	assert.True(t, valid)
+	assert.NoError(t, err)
+
+	gitResource.Info.SkipUnchanged = true
+	valid, err = gitResource.ValidateLocationInfo()
+	assert.False(t, valid)
+	assert.Contains(t, err.Error(), "PrefetchCache for GitHub SourceType can't be combined")
+}
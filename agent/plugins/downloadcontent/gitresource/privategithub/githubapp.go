@@ -0,0 +1,201 @@
+// Copyright 2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package privategithub
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/aws/amazon-ssm-agent/agent/network"
+)
+
+const (
+	githubAppPrefix = "githubapp:"
+
+	// defaultAppAPIURL is the API installation tokens are created with
+	defaultAppAPIURL = "https://api.github.com/"
+	// mediaTypeApps is the preview media type of the GitHub Apps API
+	mediaTypeApps = "application/vnd.github.machine-man-preview+json"
+
+	// appJWTLifetime is how long the JWT authenticating as the app is valid, GitHub accepts at most 10 minutes
+	appJWTLifetime = 9 * time.Minute
+	// appJWTClockSkew backdates the JWT so a clock running ahead of GitHub's doesn't make it invalid yet
+	appJWTClockSkew = time.Minute
+	// installationTokenRenewal is how long before it expires a cached installation token is replaced by a new one
+	installationTokenRenewal = 5 * time.Minute
+	// appRequestTimeout bounds the request creating an installation token
+	appRequestTimeout = 30 * time.Second
+)
+
+// githubAppTokenPattern matches tokenInfo of a GitHub App installation,
+// githubapp:<app-id>:<installation-id>:{{ ssm-secure:parameter-name }} with the private key of the app in the parameter
+var githubAppTokenPattern = regexp.MustCompile(`^\s*` + githubAppPrefix + `(\d+):(\d+):\{\{\s*(` + ssmSecurePrefix + `[\w-/]+)\s*\}\}\s*$`)
+
+// timeNow is a seam for the time tokens are minted and expire at
+var timeNow = time.Now
+
+// installationTokens cache the installation tokens created by the agent, by <app-id>:<installation-id>, until near their expiry
+var (
+	installationTokens     = map[string]installationToken{}
+	installationTokensLock sync.Mutex
+)
+
+// installationToken is an installation access token and the time it expires at
+type installationToken struct {
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// githubAppInstallation is an installation of a GitHub App, the app authenticates with the private key in keyParameter
+type githubAppInstallation struct {
+	appID          string
+	installationID string
+	keyParameter   string
+}
+
+// parseGithubAppToken returns the installation named by tokenInfo of the form githubapp:<app-id>:<installation-id>:{{ ssm-secure:parameter-name }}
+func parseGithubAppToken(tokenInfo string) (installation githubAppInstallation, ok bool) {
+	match := githubAppTokenPattern.FindStringSubmatch(tokenInfo)
+	if match == nil {
+		return installation, false
+	}
+	return githubAppInstallation{appID: match[1], installationID: match[2], keyParameter: match[3]}, true
+}
+
+// getInstallationToken returns an installation access token of the app, cached until it is about to expire
+func (t TokenInfoImpl) getInstallationToken(log log.T, installation githubAppInstallation) (string, error) {
+	cacheKey := installation.appID + ":" + installation.installationID
+	installationTokensLock.Lock()
+	defer installationTokensLock.Unlock()
+	if cached, found := installationTokens[cacheKey]; found && timeNow().Add(installationTokenRenewal).Before(cached.ExpiresAt) {
+		log.Debugf("Using the cached installation token of GitHub App %v, installation %v", installation.appID, installation.installationID)
+		return cached.Token, nil
+	}
+
+	// NOTE: Do not log the private key
+	privateKey, err := t.getSecureParameter(log, installation.keyParameter)
+	if err != nil {
+		return "", err
+	}
+	sign := t.signAppJWT
+	if sign == nil {
+		sign = signAppJWT
+	}
+	jwt, err := sign(privateKey.Value, installation.appID, timeNow())
+	if err != nil {
+		return "", fmt.Errorf("Could not sign the JWT of GitHub App %v. Error - %v", installation.appID, err)
+	}
+	token, err := t.createInstallationToken(jwt, installation.installationID)
+	if err != nil {
+		return "", fmt.Errorf("Could not create an installation token of GitHub App %v, installation %v. Error - %v", installation.appID, installation.installationID, err)
+	}
+	log.Debugf("Created an installation token of GitHub App %v, installation %v, expiring at %v", installation.appID, installation.installationID, token.ExpiresAt)
+	installationTokens[cacheKey] = token
+	return token.Token, nil
+}
+
+// createInstallationToken asks GitHub for an installation access token, authenticating as the app with jwt
+func (t TokenInfoImpl) createInstallationToken(jwt string, installationID string) (token installationToken, err error) {
+	apiURL := t.appAPIURL
+	if apiURL == "" {
+		apiURL = defaultAppAPIURL
+	}
+	req, err := http.NewRequest("POST", apiURL+"app/installations/"+installationID+"/access_tokens", nil)
+	if err != nil {
+		return token, err
+	}
+	req.Header.Set("Authorization", "Bearer "+jwt)
+	req.Header.Set("Accept", mediaTypeApps)
+
+	httpClient := t.appHTTPClient
+	if httpClient == nil {
+		httpClient = &http.Client{Transport: network.DefaultTransport(), Timeout: appRequestTimeout}
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return token, err
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return token, err
+	}
+	if resp.StatusCode != http.StatusCreated {
+		return token, fmt.Errorf("%v %v", resp.Status, strings.TrimSpace(string(body)))
+	}
+	if err = json.Unmarshal(body, &token); err != nil {
+		return token, err
+	}
+	if token.Token == "" {
+		return token, errors.New("GitHub answered without a token")
+	}
+	return token, nil
+}
+
+// signAppJWT returns the RS256 JWT authenticating as the app appID, signed with its PEM private key
+func signAppJWT(privateKey string, appID string, issuedAt time.Time) (string, error) {
+	key, err := parseRSAPrivateKey(privateKey)
+	if err != nil {
+		return "", err
+	}
+	header, _ := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT"})
+	claims, _ := json.Marshal(map[string]interface{}{
+		"iat": issuedAt.Add(-appJWTClockSkew).Unix(),
+		"exp": issuedAt.Add(appJWTLifetime).Unix(),
+		"iss": appID,
+	})
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(claims)
+	digest := sha256.Sum256([]byte(signed))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", err
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// parseRSAPrivateKey parses a PEM RSA private key, in the PKCS #1 form GitHub generates or PKCS #8
+func parseRSAPrivateKey(privateKey string) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode([]byte(strings.TrimSpace(privateKey)))
+	if block == nil {
+		return nil, errors.New("the private key is not PEM encoded")
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, errors.New("the private key is not an RSA private key")
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("the private key is not an RSA private key")
+	}
+	return key, nil
+}
@@ -0,0 +1,183 @@
+// Copyright 2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package privategithub
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	gitmock "github.com/aws/amazon-ssm-agent/agent/githubclient/mock"
+	"github.com/stretchr/testify/assert"
+)
+
+// appTokenServer answers the creation of installation tokens with the token, counting the requests
+func appTokenServer(t *testing.T, status int, body string, requests *int) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*requests++
+		assert.Equal(t, "POST", r.Method)
+		assert.Equal(t, "/app/installations/67890/access_tokens", r.URL.Path)
+		assert.Equal(t, "Bearer signed-jwt", r.Header.Get("Authorization"))
+		w.WriteHeader(status)
+		w.Write([]byte(body))
+	}))
+}
+
+// mockedAppSigner returns a JWT signer expecting the private key of app 12345
+func mockedAppSigner(t *testing.T) func(privateKey string, appID string, issuedAt time.Time) (string, error) {
+	return func(privateKey string, appID string, issuedAt time.Time) (string, error) {
+		assert.Equal(t, "private-key", privateKey)
+		assert.Equal(t, "12345", appID)
+		return "signed-jwt", nil
+	}
+}
+
+func TestParseGithubAppToken(t *testing.T) {
+	data := []struct {
+		tokenInfo string
+		expected  githubAppInstallation
+		ok        bool
+	}{
+		{"githubapp:12345:67890:{{ ssm-secure:github-app-key }}", githubAppInstallation{"12345", "67890", "ssm-secure:github-app-key"}, true},
+		{" githubapp:12345:67890:{{ssm-secure:/github/app-key}} ", githubAppInstallation{"12345", "67890", "ssm-secure:/github/app-key"}, true},
+		{"githubapp:my-app:67890:{{ ssm-secure:github-app-key }}", githubAppInstallation{}, false},
+		{"githubapp:12345:{{ ssm-secure:github-app-key }}", githubAppInstallation{}, false},
+		{"githubapp:12345:67890:github-app-key", githubAppInstallation{}, false},
+		{"{{ ssm-secure:github-token }}", githubAppInstallation{}, false},
+	}
+	for _, testdata := range data {
+		t.Run(testdata.tokenInfo, func(t *testing.T) {
+			installation, ok := parseGithubAppToken(testdata.tokenInfo)
+
+			assert.Equal(t, testdata.ok, ok)
+			assert.Equal(t, testdata.expected, installation)
+		})
+	}
+}
+
+func TestTokenInfoImpl_GetOAuthClient_GithubAppCachesToken(t *testing.T) {
+	installationTokens = map[string]installationToken{}
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	timeNow = func() time.Time { return now }
+	defer func() { timeNow = time.Now }()
+	requests := 0
+	server := appTokenServer(t, http.StatusCreated, `{"token": "installation-token", "expires_at": "2026-01-01T13:00:00Z"}`, &requests)
+	defer server.Close()
+
+	oauthclientmock := gitmock.OAuthClientMock{}
+	clientVal := &http.Client{}
+	oauthclientmock.On("GetGithubOauthClient", "installation-token").Return(clientVal)
+	tokenInfo := TokenInfoImpl{
+		SsmParameter:   mockedParameterStore(map[string]string{"github-app-key": "private-key"}),
+		gitoauthclient: oauthclientmock,
+		signAppJWT:     mockedAppSigner(t),
+		appAPIURL:      server.URL + "/",
+	}
+
+	httpout, err := tokenInfo.GetOAuthClient(logMock, "githubapp:12345:67890:{{ ssm-secure:github-app-key }}")
+	assert.NoError(t, err)
+	assert.Equal(t, clientVal, httpout)
+	assert.Equal(t, 1, requests)
+
+	// the token is reused until shortly before it expires
+	now = now.Add(50 * time.Minute)
+	_, err = tokenInfo.GetOAuthClient(logMock, "githubapp:12345:67890:{{ ssm-secure:github-app-key }}")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, requests)
+
+	now = now.Add(6 * time.Minute)
+	_, err = tokenInfo.GetOAuthClient(logMock, "githubapp:12345:67890:{{ ssm-secure:github-app-key }}")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, requests)
+	oauthclientmock.AssertExpectations(t)
+}
+
+func TestTokenInfoImpl_GetOAuthClient_GithubAppFailure(t *testing.T) {
+	data := []struct {
+		name        string
+		parameters  map[string]string
+		status      int
+		body        string
+		expectedErr string
+	}{
+		{"missing key", map[string]string{}, http.StatusCreated, `{"token": "installation-token"}`,
+			"The following parameter(s) cannot be resolved: github-app-key"},
+		{"refused", map[string]string{"github-app-key": "private-key"}, http.StatusUnauthorized, `{"message": "Bad credentials"}`,
+			`Could not create an installation token of GitHub App 12345, installation 67890. Error - 401 Unauthorized {"message": "Bad credentials"}`},
+		{"no token", map[string]string{"github-app-key": "private-key"}, http.StatusCreated, `{}`,
+			"Could not create an installation token of GitHub App 12345, installation 67890. Error - GitHub answered without a token"},
+	}
+	for _, testdata := range data {
+		t.Run(testdata.name, func(t *testing.T) {
+			installationTokens = map[string]installationToken{}
+			requests := 0
+			server := appTokenServer(t, testdata.status, testdata.body, &requests)
+			defer server.Close()
+			oauthclientmock := gitmock.OAuthClientMock{}
+			tokenInfo := TokenInfoImpl{
+				SsmParameter:   mockedParameterStore(testdata.parameters),
+				gitoauthclient: oauthclientmock,
+				signAppJWT:     mockedAppSigner(t),
+				appAPIURL:      server.URL + "/",
+			}
+
+			httpout, err := tokenInfo.GetOAuthClient(logMock, "githubapp:12345:67890:{{ ssm-secure:github-app-key }}")
+
+			assert.Error(t, err)
+			assert.Contains(t, err.Error(), testdata.expectedErr)
+			assert.Nil(t, httpout)
+			assert.Empty(t, installationTokens)
+			oauthclientmock.AssertExpectations(t)
+		})
+	}
+}
+
+func TestSignAppJWT(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 1024)
+	assert.NoError(t, err)
+	privateKey := string(pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}))
+	issuedAt := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	jwt, err := signAppJWT(privateKey, "12345", issuedAt)
+
+	assert.NoError(t, err)
+	parts := strings.Split(jwt, ".")
+	assert.Len(t, parts, 3)
+	signature, _ := base64.RawURLEncoding.DecodeString(parts[2])
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	assert.NoError(t, rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA256, digest[:], signature))
+	claimsJSON, _ := base64.RawURLEncoding.DecodeString(parts[1])
+	var claims map[string]interface{}
+	assert.NoError(t, json.Unmarshal(claimsJSON, &claims))
+	assert.Equal(t, "12345", claims["iss"])
+	assert.Equal(t, float64(issuedAt.Add(-time.Minute).Unix()), claims["iat"])
+	assert.Equal(t, float64(issuedAt.Add(9*time.Minute).Unix()), claims["exp"])
+}
+
+func TestSignAppJWT_InvalidKey(t *testing.T) {
+	_, err := signAppJWT("not a key", "12345", time.Now())
+
+	assert.EqualError(t, err, "the private key is not PEM encoded")
+}
@@ -16,30 +16,64 @@
 package privategithub
 
 import (
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
 	"github.com/aws/amazon-ssm-agent/agent/githubclient"
 	"github.com/aws/amazon-ssm-agent/agent/log"
 	"github.com/aws/amazon-ssm-agent/agent/parameterstore"
+	"github.com/aws/amazon-ssm-agent/agent/ssm"
 	"github.com/aws/amazon-ssm-agent/agent/ssmparameterresolver"
 
 	"errors"
 	"fmt"
 	"net/http"
+	"path"
 	"regexp"
+	"strings"
+	"time"
 )
 
 const (
 	ssmSecurePrefix = "ssm-secure:"
+
+	// defaultDeployKeyParameterPrefix is the parameter path under which deploy keys are stored as <prefix>/<owner>/<repository>
+	defaultDeployKeyParameterPrefix = "/ssm/github/deploykeys"
 )
 
 type PrivateGithubAccess interface {
 	GetOAuthClient(log log.T, token string) (*http.Client, error)
 }
 
+// DeployKeyAccess resolves the deploy key a repository is cloned with
+type DeployKeyAccess interface {
+	GetDeployKey(log log.T, owner string, repository string) (string, error)
+}
+
+// parameterNotFoundError is returned when a parameter does not exist in parameter store,
+// as opposed to the ones that exist but could not be read
+type parameterNotFoundError struct {
+	error
+}
+
 type TokenInfoImpl struct {
 	SsmParameter func(log log.T, paramService ssmparameterresolver.ISsmParameterService, parameterReferences []string,
 		resolverOptions ssmparameterresolver.ResolveOptions) (info map[string]ssmparameterresolver.SsmParameterInfo, err error)
 	paramAccess    ssmparameterresolver.SsmParameterService
 	gitoauthclient githubclient.IOAuthClient
+	// signAppJWT signs the JWT a GitHub App authenticates with to create installation tokens, signAppJWT when nil
+	signAppJWT func(privateKey string, appID string, issuedAt time.Time) (string, error)
+	// appAPIURL and appHTTPClient create the installation tokens of GitHub Apps, github.com and a client of the default transport when not set
+	appAPIURL     string
+	appHTTPClient *http.Client
+	// secretsManager reads the tokens of tokenInfo of the form secretsmanager:<secret name or ARN>,
+	// a client of the agent's AWS session is created when it is nil
+	secretsManager secretsManagerAPI
+	// ParameterKeyID returns the KMS key a secure string parameter is encrypted with
+	ParameterKeyID func(log log.T, parameterName string) (string, error)
+	// deployKeyPrefix and defaultDeployKey locate the per repository deploy keys in parameter store
+	deployKeyPrefix  string
+	defaultDeployKey string
+	// expectedKeyID is the KMS key secure parameters must be encrypted with, any key is accepted when it is empty
+	expectedKeyID string
 }
 
 // GetOAuthClient is the only method from privategithub package that is accessible to gitresource
@@ -48,49 +82,176 @@ func (t TokenInfoImpl) GetOAuthClient(log log.T, tokenInfo string) (client *http
 	// Make a call to secure string (disable logging) and obtain the token
 	// Create StaticTokenSource and create oauth client and return it
 
-	// Validate the format of token information
-	if valid, err := validateTokenParameter(tokenInfo); !valid {
+	// A GitHub App gets a short lived installation token
+	if installation, ok := parseGithubAppToken(tokenInfo); ok {
+		// NOTE: Do not log the token
+		token, err := t.getInstallationToken(log, installation)
+		if err != nil {
+			return nil, err
+		}
+		return t.gitoauthclient.GetGithubOauthClient(token), nil
+	}
+
+	// NOTE: Do not log the token
+	token, err := t.GetToken(log, tokenInfo)
+	if err != nil {
 		return nil, err
 	}
+	return t.gitoauthclient.GetGithubOauthClient(token), nil
+}
 
-	var tokenVal ssmparameterresolver.SsmParameterInfo
-	var tokenMap map[string]ssmparameterresolver.SsmParameterInfo
-	var parameterReferences []string
+// GetToken returns the token tokenInfo refers to, a secure string parameter or a Secrets Manager secret,
+// for sources that send the token themselves instead of through an OAuth client
+func (t TokenInfoImpl) GetToken(log log.T, tokenInfo string) (token string, err error) {
+	// A token stored in Secrets Manager is read from there instead
+	if secretID, ok := parseSecretsManagerToken(tokenInfo); ok {
+		// NOTE: Do not log the secret value
+		return t.getSecretString(log, secretID)
+	}
+
+	// Validate the format of token information
+	if valid, err := validateTokenParameter(tokenInfo); !valid {
+		return "", err
+	}
 
 	// Regex to extract the contents of the parameter from within {{ }} to get parameter value
 	// for. e.g. {{ ssm-secure:parameter-name }} will extract ssm-secure:parameter-name
 	subParam := regexp.MustCompile(`\{\{(.*?)\}\}`).FindStringSubmatch(tokenInfo)
-	if len(subParam) > 1 {
-		parameterReferences = []string{subParam[1]}
-	} else {
-		return client, errors.New("Something went wrong when trying to extract ssm-secure parameter")
+	if len(subParam) <= 1 {
+		return "", errors.New("Something went wrong when trying to extract ssm-secure parameter")
+	}
+
+	// NOTE: Do not log the parameter value
+	tokenVal, err := t.getSecureParameter(log, subParam[1])
+	if err != nil {
+		return "", err
+	}
+	return tokenVal.Value, nil
+}
+
+// GetDeployKey returns the private deploy key of a repository, stored as a secure string parameter
+// named <prefix>/<owner>/<repository>. The configured default key is used only when the repository has no key of its own,
+// any other error reading the key of the repository is returned so the broader default key isn't used in its place.
+func (t TokenInfoImpl) GetDeployKey(log log.T, owner string, repository string) (key string, err error) {
+	prefix := t.deployKeyPrefix
+	if prefix == "" {
+		prefix = defaultDeployKeyParameterPrefix
+	}
+
+	parameterNames := []string{path.Join(prefix, owner, repository)}
+	if t.defaultDeployKey != "" {
+		parameterNames = append(parameterNames, t.defaultDeployKey)
 	}
 
+	for _, parameterName := range parameterNames {
+		var keyVal ssmparameterresolver.SsmParameterInfo
+		// NOTE: Do not log the parameter value
+		if keyVal, err = t.getSecureParameter(log, ssmSecurePrefix+parameterName); err == nil {
+			log.Debugf("Using deploy key %v for repository %v/%v", parameterName, owner, repository)
+			return keyVal.Value, nil
+		}
+		if _, notFound := err.(parameterNotFoundError); !notFound {
+			return "", fmt.Errorf("Deploy key %v for repository %v/%v could not be read. Error - %v", parameterName, owner, repository, err)
+		}
+		log.Debugf("Deploy key %v does not exist", parameterName)
+	}
+
+	return "", fmt.Errorf("No deploy key found for repository %v/%v. Error - %v", owner, repository, err)
+}
+
+// getSecureParameter resolves a single secure string parameter reference of the form ssm-secure:parameter-name
+func (t TokenInfoImpl) getSecureParameter(log log.T, parameterReference string) (paramVal ssmparameterresolver.SsmParameterInfo, err error) {
+	var paramMap map[string]ssmparameterresolver.SsmParameterInfo
+	parameterReferences := []string{parameterReference}
+
 	resolverOptions := ssmparameterresolver.ResolveOptions{
 		IgnoreSecureParameters: false,
 	}
 
 	// Get the parameter value from parameter store.
-	// NOTE: Do not log the parameter value
-	if tokenMap, err = t.SsmParameter(log, &t.paramAccess, parameterReferences, resolverOptions); err != nil {
-		return nil, fmt.Errorf("Could not resolve ssm parameter - %v. Error - %v", parameterReferences, err)
+	if paramMap, err = t.SsmParameter(log, &t.paramAccess, parameterReferences, resolverOptions); err != nil {
+		if isDecryptionDenied(err) {
+			parameterName := strings.TrimPrefix(strings.TrimSpace(parameterReference), ssmSecurePrefix)
+			return paramVal, fmt.Errorf("Parameter %v could not be decrypted, the instance role must be allowed kms:Decrypt on the key it is encrypted with. Error - %v", parameterName, err)
+		}
+		if isParameterNotFound(err) {
+			return paramVal, parameterNotFoundError{fmt.Errorf("Could not resolve ssm parameter - %v. Error - %v", parameterReferences, err)}
+		}
+		return paramVal, fmt.Errorf("Could not resolve ssm parameter - %v. Error - %v", parameterReferences, err)
 	}
 
 	// Parameter output must be of size 1. Any other number of tokens returned can lead to undesired behavior
-	if len(tokenMap) != 1 {
-		return nil, fmt.Errorf("Invalid number of tokens returned - %v", len(tokenMap))
+	if len(paramMap) != 1 {
+		return paramVal, fmt.Errorf("Invalid number of tokens returned - %v", len(paramMap))
 	}
 
-	//Extracting single value of token contained within tokenMap
-	for _, token := range tokenMap {
-		tokenVal = token
+	//Extracting single value of token contained within paramMap
+	for _, param := range paramMap {
+		paramVal = param
 	}
 
 	// Validating to check if the parameter obtained is a secure string
-	if tokenVal.Type != parameterstore.ParamTypeSecureString {
-		return nil, fmt.Errorf("token-parameter-name %v must be of secure string type, Current type - %v", tokenVal.Name, tokenVal.Type)
+	if paramVal.Type != parameterstore.ParamTypeSecureString {
+		return paramVal, fmt.Errorf("token-parameter-name %v must be of secure string type, Current type - %v", paramVal.Name, paramVal.Type)
 	}
-	return t.gitoauthclient.GetGithubOauthClient(tokenVal.Value), nil
+
+	if t.expectedKeyID != "" {
+		keyID, err := t.ParameterKeyID(log, paramVal.Name)
+		if err != nil {
+			return ssmparameterresolver.SsmParameterInfo{}, fmt.Errorf("Could not find the KMS key of parameter %v. Error - %v", paramVal.Name, err)
+		}
+		if !sameKMSKey(keyID, t.expectedKeyID) {
+			return ssmparameterresolver.SsmParameterInfo{}, fmt.Errorf("Parameter %v is encrypted with KMS key %v, expected %v", paramVal.Name, keyID, t.expectedKeyID)
+		}
+	}
+	return paramVal, nil
+}
+
+// isDecryptionDenied returns true if the parameter store error is KMS refusing to decrypt the parameter
+func isDecryptionDenied(err error) bool {
+	message := err.Error()
+	return strings.Contains(message, "kms:Decrypt") || strings.Contains(message, "KMSAccessDenied") ||
+		(strings.Contains(message, "AccessDenied") && strings.Contains(message, "KMS"))
+}
+
+// isParameterNotFound returns true if the parameter store error is the parameter not existing,
+// GetParameters reports missing parameters as invalid ones which the resolver says cannot be resolved
+func isParameterNotFound(err error) bool {
+	message := err.Error()
+	return strings.Contains(message, "ParameterNotFound") || strings.Contains(message, "cannot be resolved")
+}
+
+// sameKMSKey returns true if both references, a key ID, alias or ARN, name the same key
+func sameKMSKey(keyID string, expected string) bool {
+	return normalizeKMSKey(keyID) == normalizeKMSKey(expected)
+}
+
+// normalizeKMSKey reduces a KMS key reference to key/<key-id> or alias/<alias-name>
+func normalizeKMSKey(keyID string) string {
+	if strings.HasPrefix(keyID, "arn:") {
+		// arn:<partition>:kms:<region>:<account>:key/<key-id> or :alias/<alias-name>
+		if parts := strings.SplitN(keyID, ":", 6); len(parts) == 6 {
+			keyID = parts[5]
+		}
+	}
+	if !strings.HasPrefix(keyID, "alias/") && !strings.HasPrefix(keyID, "key/") {
+		keyID = "key/" + keyID
+	}
+	return keyID
+}
+
+// describeParameterKeyID looks up the KMS key of a secure string parameter
+func describeParameterKeyID(log log.T, parameterName string) (string, error) {
+	output, err := ssm.NewService().DescribeParameters(log, []string{parameterName})
+	if err != nil {
+		return "", err
+	}
+	for _, parameter := range output.Parameters {
+		if parameter.Name != nil && *parameter.Name == parameterName && parameter.KeyId != nil {
+			return *parameter.KeyId, nil
+		}
+	}
+	return "", fmt.Errorf("Parameter %v has no KMS key", parameterName)
 }
 
 func getSSMParameter(log log.T, paramService ssmparameterresolver.ISsmParameterService, parameterReferences []string,
@@ -109,15 +270,23 @@ func validateTokenParameter(tokenInfo string) (valid bool, err error) {
 		return true, nil
 	}
 	return false, errors.New("Format of specifying ssm parameter used for token-parameter-name is incorrect. " +
-		"Please specify parameter as '{{ ssm-secure:parameter-name }}'")
+		"Please specify parameter as '{{ ssm-secure:parameter-name }}', or a secret as 'secretsmanager:secret-name', " +
+		"or a GitHub App as 'githubapp:app-id:installation-id:{{ ssm-secure:parameter-name }}'")
 }
 
 // NewTokenInfoImpl returns an object of type TokenInfoImpl
 func NewTokenInfoImpl() TokenInfoImpl {
 	parameterService := ssmparameterresolver.NewService()
-	return TokenInfoImpl{
+	tokenInfo := TokenInfoImpl{
 		SsmParameter:   getSSMParameter,
+		ParameterKeyID: describeParameterKeyID,
 		paramAccess:    parameterService,
 		gitoauthclient: githubclient.OAuthClient{},
 	}
+	if appCfg, err := appconfig.Config(false); err == nil {
+		tokenInfo.deployKeyPrefix = appCfg.GitHub.DeployKeyParameterPrefix
+		tokenInfo.defaultDeployKey = appCfg.GitHub.DefaultDeployKeyParameter
+		tokenInfo.expectedKeyID = appCfg.GitHub.TokenKMSKeyID
+	}
+	return tokenInfo
 }
@@ -23,7 +23,9 @@ import (
 	"github.com/aws/amazon-ssm-agent/agent/ssmparameterresolver"
 	"github.com/stretchr/testify/assert"
 
+	"errors"
 	"net/http"
+	"strings"
 	"testing"
 )
 
@@ -52,6 +54,17 @@ func TestTokenInfoImpl_GetOAuthClient_Success(t *testing.T) {
 	oauthclientmock.AssertExpectations(t)
 }
 
+func TestTokenInfoImpl_GetToken(t *testing.T) {
+	tokenInfo := TokenInfoImpl{
+		SsmParameter: getMockedSecureParam,
+	}
+
+	token, err := tokenInfo.GetToken(logMock, `{{ ssm-secure:dummysecureparam }}`)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "lskjksjgshfg1234jdskjhgvs", token)
+}
+
 func TestTokenInfoImpl_ValidateTokenParameter_Failure(t *testing.T) {
 
 	// tokenInfoInput has a format that is unsupported for token information.
@@ -68,7 +81,8 @@ func TestTokenInfoImpl_ValidateTokenParameter_Failure(t *testing.T) {
 	assert.Nil(t, httpout)
 	oauthclientmock.AssertExpectations(t)
 	assert.Equal(t, err.Error(), "Format of specifying ssm parameter used for token-parameter-name is incorrect. "+
-		"Please specify parameter as '{{ ssm-secure:parameter-name }}'")
+		"Please specify parameter as '{{ ssm-secure:parameter-name }}', or a secret as 'secretsmanager:secret-name', "+
+		"or a GitHub App as 'githubapp:app-id:installation-id:{{ ssm-secure:parameter-name }}'")
 }
 
 func TestTokenInfoImpl_ValidateSecureParameter(t *testing.T) {
@@ -122,3 +136,171 @@ func getMockedSecureParam(log log.T, paramService ssmparameterresolver.ISsmParam
 
 	return info, nil
 }
+
+// mockedParameterStore returns an SsmParameter function resolving references from the given secure parameters
+func mockedParameterStore(parameters map[string]string) func(log log.T, paramService ssmparameterresolver.ISsmParameterService, parameterReferences []string,
+	resolverOptions ssmparameterresolver.ResolveOptions) (map[string]ssmparameterresolver.SsmParameterInfo, error) {
+	return func(log log.T, paramService ssmparameterresolver.ISsmParameterService, parameterReferences []string,
+		resolverOptions ssmparameterresolver.ResolveOptions) (map[string]ssmparameterresolver.SsmParameterInfo, error) {
+		info := make(map[string]ssmparameterresolver.SsmParameterInfo)
+		for _, reference := range parameterReferences {
+			name := strings.TrimPrefix(reference, ssmSecurePrefix)
+			value, ok := parameters[name]
+			if !ok {
+				return nil, errors.New("The following parameter(s) cannot be resolved: " + name)
+			}
+			info[reference] = ssmparameterresolver.SsmParameterInfo{
+				Name:  name,
+				Type:  parameterstore.ParamTypeSecureString,
+				Value: value,
+			}
+		}
+		return info, nil
+	}
+}
+
+func TestTokenInfoImpl_GetDeployKey_PerRepository(t *testing.T) {
+	tokenInfo := TokenInfoImpl{
+		SsmParameter: mockedParameterStore(map[string]string{
+			"/ssm/github/deploykeys/owner/repo": "repo-key",
+			"/default/key":                      "default-key",
+		}),
+		defaultDeployKey: "/default/key",
+	}
+
+	key, err := tokenInfo.GetDeployKey(logMock, "owner", "repo")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "repo-key", key)
+}
+
+func TestTokenInfoImpl_GetDeployKey_CustomPrefix(t *testing.T) {
+	tokenInfo := TokenInfoImpl{
+		SsmParameter: mockedParameterStore(map[string]string{
+			"/keys/owner/repo": "repo-key",
+		}),
+		deployKeyPrefix: "/keys",
+	}
+
+	key, err := tokenInfo.GetDeployKey(logMock, "owner", "repo")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "repo-key", key)
+}
+
+func TestTokenInfoImpl_GetDeployKey_DefaultKey(t *testing.T) {
+	tokenInfo := TokenInfoImpl{
+		SsmParameter: mockedParameterStore(map[string]string{
+			"/default/key": "default-key",
+		}),
+		defaultDeployKey: "/default/key",
+	}
+
+	key, err := tokenInfo.GetDeployKey(logMock, "owner", "repo")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "default-key", key)
+}
+
+func TestTokenInfoImpl_GetDeployKey_ReadErrorDoesNotFallBack(t *testing.T) {
+	data := []struct {
+		name        string
+		err         error
+		expectedErr string
+	}{
+		{"throttled", errors.New("ThrottlingException: Rate exceeded"), "ThrottlingException: Rate exceeded"},
+		{"decryption denied", errors.New("AccessDeniedException: not authorized to perform: kms:Decrypt"), "could not be decrypted"},
+		{"network failure", errors.New("RequestError: send request failed"), "RequestError: send request failed"},
+	}
+	for _, testdata := range data {
+		t.Run(testdata.name, func(t *testing.T) {
+			var requested []string
+			tokenInfo := TokenInfoImpl{
+				SsmParameter: func(log log.T, paramService ssmparameterresolver.ISsmParameterService, parameterReferences []string,
+					resolverOptions ssmparameterresolver.ResolveOptions) (map[string]ssmparameterresolver.SsmParameterInfo, error) {
+					requested = append(requested, parameterReferences...)
+					return nil, testdata.err
+				},
+				defaultDeployKey: "/default/key",
+			}
+
+			_, err := tokenInfo.GetDeployKey(logMock, "owner", "repo")
+
+			assert.Error(t, err)
+			assert.Contains(t, err.Error(), "Deploy key /ssm/github/deploykeys/owner/repo for repository owner/repo could not be read")
+			assert.Contains(t, err.Error(), testdata.expectedErr)
+			// the default key is never requested
+			assert.Equal(t, []string{ssmSecurePrefix + "/ssm/github/deploykeys/owner/repo"}, requested)
+		})
+	}
+}
+
+func TestTokenInfoImpl_GetDeployKey_Missing(t *testing.T) {
+	tokenInfo := TokenInfoImpl{
+		SsmParameter: mockedParameterStore(map[string]string{}),
+	}
+
+	_, err := tokenInfo.GetDeployKey(logMock, "owner", "repo")
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "No deploy key found for repository owner/repo")
+}
+
+// deniedParameterStore is a parameter store whose KMS key doesn't allow the instance to decrypt parameters
+func deniedParameterStore(log log.T, paramService ssmparameterresolver.ISsmParameterService, parameterReferences []string,
+	resolverOptions ssmparameterresolver.ResolveOptions) (map[string]ssmparameterresolver.SsmParameterInfo, error) {
+	return nil, errors.New("Encountered error while calling GetParameters API. Error: AccessDeniedException: " +
+		"User: arn:aws:sts::123456789012:assumed-role/instance is not authorized to perform: kms:Decrypt")
+}
+
+func TestTokenInfoImpl_GetOAuthClient_KMSKey(t *testing.T) {
+	data := []struct {
+		name          string
+		ssmParameter  func(log.T, ssmparameterresolver.ISsmParameterService, []string, ssmparameterresolver.ResolveOptions) (map[string]ssmparameterresolver.SsmParameterInfo, error)
+		expectedKeyID string
+		keyID         string
+		keyErr        error
+		expectedErr   string
+	}{
+		{"any key accepted", getMockedSecureParam, "", "", nil, ""},
+		{"expected key ID", getMockedSecureParam, "1234abcd-12ab-34cd-56ef-1234567890ab", "1234abcd-12ab-34cd-56ef-1234567890ab", nil, ""},
+		{"expected key ARN", getMockedSecureParam, "arn:aws:kms:us-east-1:123456789012:key/1234abcd-12ab-34cd-56ef-1234567890ab", "1234abcd-12ab-34cd-56ef-1234567890ab", nil, ""},
+		{"expected alias", getMockedSecureParam, "alias/github", "arn:aws:kms:us-east-1:123456789012:alias/github", nil, ""},
+		{"other key", getMockedSecureParam, "alias/github", "alias/aws/ssm", nil,
+			"Parameter dummysecureparam is encrypted with KMS key alias/aws/ssm, expected alias/github"},
+		{"key lookup fails", getMockedSecureParam, "alias/github", "", errors.New("AccessDeniedException: ssm:DescribeParameters"),
+			"Could not find the KMS key of parameter dummysecureparam. Error - AccessDeniedException: ssm:DescribeParameters"},
+		{"decryption denied", deniedParameterStore, "", "", nil,
+			"Parameter dummysecureparam could not be decrypted, the instance role must be allowed kms:Decrypt on the key it is encrypted with."},
+	}
+	for _, testdata := range data {
+		t.Run(testdata.name, func(t *testing.T) {
+			oauthclientmock := gitmock.OAuthClientMock{}
+			clientVal := &http.Client{}
+			if testdata.expectedErr == "" {
+				oauthclientmock.On("GetGithubOauthClient", "lskjksjgshfg1234jdskjhgvs").Return(clientVal)
+			}
+			tokenInfo := TokenInfoImpl{
+				SsmParameter: testdata.ssmParameter,
+				ParameterKeyID: func(log log.T, parameterName string) (string, error) {
+					assert.Equal(t, "dummysecureparam", parameterName)
+					return testdata.keyID, testdata.keyErr
+				},
+				gitoauthclient: oauthclientmock,
+				expectedKeyID:  testdata.expectedKeyID,
+			}
+
+			httpout, err := tokenInfo.GetOAuthClient(logMock, `{{ ssm-secure:dummysecureparam }}`)
+
+			if testdata.expectedErr == "" {
+				assert.NoError(t, err)
+				assert.Equal(t, clientVal, httpout)
+			} else {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), testdata.expectedErr)
+				assert.Nil(t, httpout)
+			}
+			oauthclientmock.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,134 @@
+// Copyright 2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package privategithub
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/aws/amazon-ssm-agent/agent/sdkutil"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/client"
+	"github.com/aws/aws-sdk-go/aws/client/metadata"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/aws/signer/v4"
+	"github.com/aws/aws-sdk-go/private/protocol/jsonrpc"
+)
+
+const (
+	secretsManagerPrefix = "secretsmanager:"
+
+	// secretsManagerServiceName is the endpoint prefix and signing name of Secrets Manager
+	secretsManagerServiceName = "secretsmanager"
+)
+
+// secretsManagerTokenPattern matches tokenInfo naming a secret, secretsmanager:<secret name or ARN>
+var secretsManagerTokenPattern = regexp.MustCompile(`^\s*` + secretsManagerPrefix + `([\w/+=.@:-]+)\s*$`)
+
+// secretsManagerAPI reads secrets from AWS Secrets Manager
+type secretsManagerAPI interface {
+	GetSecretValue(input *getSecretValueInput) (*getSecretValueOutput, error)
+}
+
+// getSecretValueInput names the secret to read the current version of
+type getSecretValueInput struct {
+	_        struct{} `type:"structure"`
+	SecretId *string  `type:"string" required:"true"`
+}
+
+// getSecretValueOutput is the value of a secret, SecretString unless the secret is binary
+type getSecretValueOutput struct {
+	_            struct{} `type:"structure"`
+	ARN          *string  `type:"string"`
+	Name         *string  `type:"string"`
+	SecretString *string  `type:"string"`
+}
+
+// secretsManagerClient calls Secrets Manager with the JSON protocol of the AWS SDK, whose vendored version has no client for it
+type secretsManagerClient struct {
+	*client.Client
+}
+
+// newSecretsManagerClient creates a Secrets Manager client from the session p, like the clients of the AWS SDK
+func newSecretsManagerClient(p client.ConfigProvider, cfgs ...*aws.Config) *secretsManagerClient {
+	c := p.ClientConfig(secretsManagerServiceName, cfgs...)
+	svc := &secretsManagerClient{
+		Client: client.New(
+			*c.Config,
+			metadata.ClientInfo{
+				ServiceName:   secretsManagerServiceName,
+				SigningName:   c.SigningName,
+				SigningRegion: c.SigningRegion,
+				Endpoint:      c.Endpoint,
+				APIVersion:    "2017-10-17",
+				JSONVersion:   "1.1",
+				TargetPrefix:  "secretsmanager",
+			},
+			c.Handlers,
+		),
+	}
+	svc.Handlers.Sign.PushBackNamed(v4.SignRequestHandler)
+	svc.Handlers.Build.PushBackNamed(jsonrpc.BuildHandler)
+	svc.Handlers.Unmarshal.PushBackNamed(jsonrpc.UnmarshalHandler)
+	svc.Handlers.UnmarshalMeta.PushBackNamed(jsonrpc.UnmarshalMetaHandler)
+	svc.Handlers.UnmarshalError.PushBackNamed(jsonrpc.UnmarshalErrorHandler)
+	return svc
+}
+
+// GetSecretValue calls the GetSecretValue API
+func (c *secretsManagerClient) GetSecretValue(input *getSecretValueInput) (*getSecretValueOutput, error) {
+	op := &request.Operation{
+		Name:       "GetSecretValue",
+		HTTPMethod: "POST",
+		HTTPPath:   "/",
+	}
+	output := &getSecretValueOutput{}
+	req := c.NewRequest(op, input, output)
+	return output, req.Send()
+}
+
+// parseSecretsManagerToken returns the secret named by tokenInfo of the form secretsmanager:<secret name or ARN>
+func parseSecretsManagerToken(tokenInfo string) (secretID string, ok bool) {
+	match := secretsManagerTokenPattern.FindStringSubmatch(tokenInfo)
+	if match == nil {
+		return "", false
+	}
+	return match[1], true
+}
+
+// getSecretString reads the string value of a secret, a binary secret can't be a token
+func (t TokenInfoImpl) getSecretString(log log.T, secretID string) (string, error) {
+	secretsManager := t.secretsManager
+	if secretsManager == nil {
+		// the session is only created once a token is read from Secrets Manager
+		secretsManager = newSecretsManagerClient(session.New(sdkutil.AwsConfig()))
+	}
+	output, err := secretsManager.GetSecretValue(&getSecretValueInput{SecretId: aws.String(secretID)})
+	if err != nil {
+		if strings.Contains(err.Error(), "AccessDenied") {
+			return "", fmt.Errorf("Secret %v could not be read, the instance role must be allowed secretsmanager:GetSecretValue on it "+
+				"and kms:Decrypt on the key it is encrypted with. Error - %v", secretID, err)
+		}
+		return "", fmt.Errorf("Could not read secret %v. Error - %v", secretID, err)
+	}
+	if output.SecretString == nil || *output.SecretString == "" {
+		return "", fmt.Errorf("Secret %v has no string value", secretID)
+	}
+	log.Debugf("Using the token of secret %v", secretID)
+	return strings.TrimSpace(*output.SecretString), nil
+}
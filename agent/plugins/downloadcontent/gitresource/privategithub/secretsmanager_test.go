@@ -0,0 +1,148 @@
+// Copyright 2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package privategithub
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	gitmock "github.com/aws/amazon-ssm-agent/agent/githubclient/mock"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// secretsManagerMock mocks the Secrets Manager API
+type secretsManagerMock struct {
+	mock.Mock
+}
+
+func (m *secretsManagerMock) GetSecretValue(input *getSecretValueInput) (*getSecretValueOutput, error) {
+	args := m.Called(input)
+	return args.Get(0).(*getSecretValueOutput), args.Error(1)
+}
+
+func TestParseSecretsManagerToken(t *testing.T) {
+	data := []struct {
+		tokenInfo        string
+		expectedSecretID string
+	}{
+		{"secretsmanager:my/secret", "my/secret"},
+		{" secretsmanager:github-token ", "github-token"},
+		{"secretsmanager:arn:aws:secretsmanager:us-east-1:123456789012:secret:my/secret-AbCdEf", "arn:aws:secretsmanager:us-east-1:123456789012:secret:my/secret-AbCdEf"},
+		{"secretsmanager:", ""},
+		{"secretsmanager:my secret", ""},
+		{"{{ ssm-secure:my-parameter }}", ""},
+	}
+	for _, testdata := range data {
+		t.Run(testdata.tokenInfo, func(t *testing.T) {
+			secretID, ok := parseSecretsManagerToken(testdata.tokenInfo)
+
+			assert.Equal(t, testdata.expectedSecretID != "", ok)
+			assert.Equal(t, testdata.expectedSecretID, secretID)
+		})
+	}
+}
+
+func TestTokenInfoImpl_GetOAuthClient_SecretsManager(t *testing.T) {
+	data := []struct {
+		name        string
+		output      *getSecretValueOutput
+		err         error
+		expectedErr string
+	}{
+		{"token", &getSecretValueOutput{SecretString: aws.String("lskjksjgshfg1234jdskjhgvs\n")}, nil, ""},
+		{"binary secret", &getSecretValueOutput{}, nil, "Secret my/secret has no string value"},
+		{"not found", (*getSecretValueOutput)(nil), errors.New("ResourceNotFoundException: Secrets Manager can't find the specified secret."),
+			"Could not read secret my/secret. Error - ResourceNotFoundException: Secrets Manager can't find the specified secret."},
+		{"access denied", (*getSecretValueOutput)(nil), errors.New("AccessDeniedException: not authorized to perform: secretsmanager:GetSecretValue"),
+			"Secret my/secret could not be read, the instance role must be allowed secretsmanager:GetSecretValue on it and kms:Decrypt on the key it is encrypted with."},
+	}
+	for _, testdata := range data {
+		t.Run(testdata.name, func(t *testing.T) {
+			secretsManager := &secretsManagerMock{}
+			secretsManager.On("GetSecretValue", &getSecretValueInput{SecretId: aws.String("my/secret")}).Return(testdata.output, testdata.err).Once()
+			oauthclientmock := gitmock.OAuthClientMock{}
+			clientVal := &http.Client{}
+			if testdata.expectedErr == "" {
+				oauthclientmock.On("GetGithubOauthClient", "lskjksjgshfg1234jdskjhgvs").Return(clientVal)
+			}
+			tokenInfo := TokenInfoImpl{
+				SsmParameter:   getMockedSecureParam,
+				gitoauthclient: oauthclientmock,
+				secretsManager: secretsManager,
+			}
+
+			httpout, err := tokenInfo.GetOAuthClient(logMock, "secretsmanager:my/secret")
+
+			if testdata.expectedErr == "" {
+				assert.NoError(t, err)
+				assert.Equal(t, clientVal, httpout)
+			} else {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), testdata.expectedErr)
+				assert.Nil(t, httpout)
+			}
+			secretsManager.AssertExpectations(t)
+			oauthclientmock.AssertExpectations(t)
+		})
+	}
+}
+
+func TestTokenInfoImpl_GetOAuthClient_SecureParameterWithSecretsManager(t *testing.T) {
+	secretsManager := &secretsManagerMock{}
+	oauthclientmock := gitmock.OAuthClientMock{}
+	clientVal := &http.Client{}
+	oauthclientmock.On("GetGithubOauthClient", "lskjksjgshfg1234jdskjhgvs").Return(clientVal)
+	tokenInfo := TokenInfoImpl{
+		SsmParameter:   getMockedSecureParam,
+		gitoauthclient: oauthclientmock,
+		secretsManager: secretsManager,
+	}
+
+	httpout, err := tokenInfo.GetOAuthClient(logMock, `{{ ssm-secure:dummysecureparam }}`)
+
+	assert.NoError(t, err)
+	assert.Equal(t, clientVal, httpout)
+	secretsManager.AssertNotCalled(t, "GetSecretValue", mock.Anything)
+}
+
+func TestSecretsManagerClient_GetSecretValue(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "secretsmanager.GetSecretValue", r.Header.Get("X-Amz-Target"))
+		assert.Contains(t, r.Header.Get("Authorization"), "/us-east-1/secretsmanager/aws4_request")
+		var input map[string]string
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&input))
+		assert.Equal(t, map[string]string{"SecretId": "my/secret"}, input)
+		w.Header().Set("Content-Type", "application/x-amz-json-1.1")
+		w.Write([]byte(`{"ARN": "arn:aws:secretsmanager:us-east-1:123456789012:secret:my/secret-AbCdEf", "Name": "my/secret", "SecretString": "token"}`))
+	}))
+	defer server.Close()
+	sess := session.New(aws.NewConfig().
+		WithRegion("us-east-1").
+		WithEndpoint(server.URL).
+		WithCredentials(credentials.NewStaticCredentials("id", "secret", "")))
+
+	output, err := newSecretsManagerClient(sess).GetSecretValue(&getSecretValueInput{SecretId: aws.String("my/secret")})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "token", aws.StringValue(output.SecretString))
+	assert.Equal(t, "my/secret", aws.StringValue(output.Name))
+}
@@ -0,0 +1,105 @@
+// Copyright 2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package gitresource
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+
+	"github.com/aws/amazon-ssm-agent/agent/fileutil/artifact"
+	"github.com/aws/amazon-ssm-agent/agent/fileutil/filemanager"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/aws/amazon-ssm-agent/agent/network"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/downloadcontent/remoteresource"
+)
+
+// rawContentURL is the host serving the files of public repositories without the rate limits of the API
+var rawContentURL = "https://raw.githubusercontent.com"
+
+// rawFileURL builds the raw content URL of a file at the given ref
+func rawFileURL(owner, repository, ref, filePath string) (string, error) {
+	u, err := url.Parse(rawContentURL)
+	if err != nil {
+		return "", err
+	}
+	u.Path = path.Join("/", u.Path, owner, repository, ref, filePath)
+	return u.String(), nil
+}
+
+// downloadRaw fetches the single public file of info.Path from the raw content host and saves it on disk
+func (git *downloadContext) downloadRaw(log log.T, filesys filemanager.FileSystem, info GitInfo, destinationDir string) error {
+	opt, err := git.client.ParseGetOptions(log, info.GetOptions)
+	if err != nil {
+		return err
+	}
+	ref := opt.Ref
+	if info.GetOptions == "" {
+		// the raw host has no notion of a default branch, the URL needs its actual name
+		if ref, err = git.resolveDefaultBranch(log); err != nil {
+			return err
+		}
+	}
+	fileURL, err := rawFileURL(info.Owner, info.Repository, ref, info.Path)
+	if err != nil {
+		return err
+	}
+
+	log.Infof("Downloading %v from ref %v", info.Path, ref)
+	log.Debugf("Requesting %v", fileURL)
+	content, err := fetchRaw(fileURL, info.Path, git.maxFileSize)
+	if err != nil {
+		return err
+	}
+
+	rendered, err := git.renderTemplate(log, info.Path, string(content))
+	if err != nil {
+		return err
+	}
+	destination := remoteresource.FileDestination(filesys, destinationDir, info.Path)
+	log.Debugf("Saving %v (%v bytes) to %v", info.Path, len(rendered), destination)
+	return git.saveFile(log, filesys, destination, rendered, 0)
+}
+
+// fetchRaw reads a file from the raw content host, counting as a download for the shared download limit.
+// Files larger than maxSize are refused.
+func fetchRaw(fileURL string, filePath string, maxSize int64) ([]byte, error) {
+	limiter := network.SharedDownloadLimiter()
+	limiter.Acquire()
+	defer limiter.Release()
+
+	client := &http.Client{Transport: network.DefaultTransport()}
+	resp, err := client.Get(fileURL)
+	if err != nil {
+		return nil, network.ClassifyError(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Request for %v returned %v", fileURL, resp.Status)
+	}
+	if err = artifact.CheckDeclaredSize(filePath, resp.ContentLength, maxSize); err != nil {
+		return nil, err
+	}
+	var content bytes.Buffer
+	if resp.ContentLength > 0 {
+		content.Grow(int(resp.ContentLength))
+	}
+	if _, err = artifact.CopyBuffered(&content, artifact.LimitReader(resp.Body, filePath, maxSize)); err != nil {
+		return nil, err
+	}
+	return content.Bytes(), nil
+}
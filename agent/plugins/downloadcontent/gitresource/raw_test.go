@@ -0,0 +1,147 @@
+// Copyright 2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package gitresource
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	filemock "github.com/aws/amazon-ssm-agent/agent/fileutil/filemanager/mock"
+	githubclientmock "github.com/aws/amazon-ssm-agent/agent/githubclient/mock"
+	"github.com/go-github/github"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestRawFileURL(t *testing.T) {
+	data := []struct {
+		name     string
+		ref      string
+		path     string
+		expected string
+	}{
+		{"file", "master", "path/to/file.ext", "https://raw.githubusercontent.com/owner/repo/master/path/to/file.ext"},
+		{"leading slash", "master", "/file.ext", "https://raw.githubusercontent.com/owner/repo/master/file.ext"},
+		{"branch with slash", "feature/x", "file.ext", "https://raw.githubusercontent.com/owner/repo/feature/x/file.ext"},
+		{"commit", "abc123", "file.ext", "https://raw.githubusercontent.com/owner/repo/abc123/file.ext"},
+		{"escaped", "master", "my scripts/file #1.sh", "https://raw.githubusercontent.com/owner/repo/master/my%20scripts/file%20%231.sh"},
+	}
+	for _, testdata := range data {
+		t.Run(testdata.name, func(t *testing.T) {
+			fileURL, err := rawFileURL("owner", "repo", testdata.ref, testdata.path)
+
+			assert.NoError(t, err)
+			assert.Equal(t, testdata.expected, fileURL)
+		})
+	}
+}
+
+func TestGitResource_DownloadRawHost(t *testing.T) {
+	data := []struct {
+		name        string
+		tokenInfo   string
+		rawStatus   int
+		expectedRaw bool
+	}{
+		{"raw host", "", http.StatusOK, true},
+		{"fallback to API", "", http.StatusNotFound, false},
+		{"private repository uses API", "ssm-parameter-store:token", http.StatusOK, false},
+	}
+	defer func(original string) { rawContentURL = original }(rawContentURL)
+	for _, testdata := range data {
+		t.Run(testdata.name, func(t *testing.T) {
+			rawRequests := 0
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				rawRequests++
+				assert.Equal(t, "/owner/repo/master/path/to/file.ext", r.URL.Path)
+				w.WriteHeader(testdata.rawStatus)
+				w.Write([]byte("raw content"))
+			}))
+			defer server.Close()
+			rawContentURL = server.URL
+
+			clientMock := githubclientmock.ClientMock{}
+			opt := &github.RepositoryContentGetOptions{Ref: "master"}
+			clientMock.On("ParseGetOptions", logMock, "").Return(opt, nil)
+			clientMock.On("GetDefaultBranch", logMock, "owner", "repo").Return("master", nil)
+			content := "api content"
+			file := "file"
+			gitpath := "path/to/file.ext"
+			fileMetadata := github.RepositoryContent{Content: &content, Type: &file, Path: &gitpath}
+			clientMock.On("GetRepositoryContents", logMock, "owner", "repo", "path/to/file.ext", opt).Return(&fileMetadata, []*github.RepositoryContent(nil), nil)
+			clientMock.On("IsFileContentType", mock.AnythingOfType("*github.RepositoryContent")).Return(true)
+
+			destination := filepath.Join("destination", "file.ext")
+			expectedContent := content
+			if testdata.expectedRaw {
+				expectedContent = "raw content"
+			}
+			fileMock := filemock.FileSystemMock{}
+			fileMock.On("Exists", destination).Return(false)
+			fileMock.On("MakeDirs", "destination").Return(nil)
+			fileMock.On("WriteFile", destination, expectedContent).Return(nil)
+
+			gitResource := NewResourceWithMockedClient(&clientMock)
+			gitResource.Info.UseRawHost = true
+			gitResource.Info.TokenInfo = testdata.tokenInfo
+
+			err := gitResource.Download(logMock, fileMock, destination)
+
+			assert.NoError(t, err)
+			fileMock.AssertExpectations(t)
+			if testdata.expectedRaw {
+				clientMock.AssertNotCalled(t, "GetRepositoryContents", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+			} else {
+				clientMock.AssertNumberOfCalls(t, "GetRepositoryContents", 1)
+			}
+			if testdata.tokenInfo != "" {
+				assert.Equal(t, 0, rawRequests)
+			}
+		})
+	}
+}
+
+func TestGitResource_DownloadRawUsesDefaultBranch(t *testing.T) {
+	defer func(original string) { rawContentURL = original }(rawContentURL)
+	var requested []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested = append(requested, r.URL.Path)
+		w.Write([]byte("raw content"))
+	}))
+	defer server.Close()
+	rawContentURL = server.URL
+
+	clientMock := githubclientmock.ClientMock{}
+	clientMock.On("ParseGetOptions", logMock, "").Return(&github.RepositoryContentGetOptions{Ref: "master"}, nil)
+	clientMock.On("GetDefaultBranch", logMock, "owner", "repo").Return("main", nil).Twice()
+
+	destination := filepath.Join("destination", "file.ext")
+	fileMock := filemock.FileSystemMock{}
+	fileMock.On("Exists", destination).Return(false)
+	fileMock.On("MakeDirs", "destination").Return(nil)
+	fileMock.On("WriteFile", destination, "raw content").Return(nil)
+
+	gitResource := NewResourceWithMockedClient(&clientMock)
+	gitResource.Info.UseRawHost = true
+
+	assert.NoError(t, gitResource.Download(logMock, fileMock, destination))
+	assert.NoError(t, gitResource.Download(logMock, fileMock, destination))
+
+	assert.Equal(t, []string{"/owner/repo/main/path/to/file.ext", "/owner/repo/main/path/to/file.ext"}, requested)
+	// the resource isn't changed by a download, each one resolves the default branch
+	clientMock.AssertNumberOfCalls(t, "GetDefaultBranch", 2)
+}
@@ -0,0 +1,159 @@
+// Copyright 2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package gitresource
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/aws/amazon-ssm-agent/agent/versionutil"
+	"github.com/go-github/github"
+)
+
+const (
+	// refHead resolves to the default branch of the repository
+	refHead = "HEAD"
+	// refTagsPrefix and refHeadsPrefix start the glob of tags or branches a ref pattern is resolved against
+	refTagsPrefix  = "refs/tags/"
+	refHeadsPrefix = "refs/heads/"
+
+	// refOrderSemver picks the highest version among the matching refs, refOrderCommitDate the most recently committed one
+	refOrderSemver     = "semver"
+	refOrderCommitDate = "commitDate"
+)
+
+// validateRefPattern ensures RefPattern is HEAD or a valid tag or branch glob, and RefOrder a known order
+func validateRefPattern(info GitInfo) error {
+	if info.GetOptions != "" {
+		return errors.New("Specify either getOptions or refPattern for GitHub SourceType, not both")
+	}
+	if info.RefOrder != "" && info.RefOrder != refOrderSemver && info.RefOrder != refOrderCommitDate {
+		return fmt.Errorf("RefOrder for GitHub SourceType must be either %v or %v", refOrderSemver, refOrderCommitDate)
+	}
+	if info.RefPattern == refHead {
+		return nil
+	}
+	glob, _, err := splitRefPattern(info.RefPattern)
+	if err != nil {
+		return err
+	}
+	if _, err := path.Match(glob, ""); err != nil {
+		return fmt.Errorf("RefPattern %v for GitHub SourceType is not a valid glob - %v", info.RefPattern, err)
+	}
+	return nil
+}
+
+// splitRefPattern returns the glob of a refs/tags/ or refs/heads/ pattern and whether it matches tags
+func splitRefPattern(refPattern string) (glob string, isTag bool, err error) {
+	switch {
+	case strings.HasPrefix(refPattern, refTagsPrefix):
+		return strings.TrimPrefix(refPattern, refTagsPrefix), true, nil
+	case strings.HasPrefix(refPattern, refHeadsPrefix):
+		return strings.TrimPrefix(refPattern, refHeadsPrefix), false, nil
+	default:
+		return "", false, fmt.Errorf("RefPattern %v for GitHub SourceType must be %v or start with %v or %v", refPattern, refHead, refTagsPrefix, refHeadsPrefix)
+	}
+}
+
+// resolveRefPattern resolves info.RefPattern to the getOptions of a concrete ref: the default branch for HEAD,
+// or else the newest tag or branch matching the glob, by version or commit date as RefOrder tells.
+// It fails when nothing matches or when the newest matches can't be told apart.
+func (git *downloadContext) resolveRefPattern(log log.T, info GitInfo) (string, error) {
+	if info.RefPattern == refHead {
+		branch, err := git.resolveDefaultBranch(log)
+		if err != nil {
+			return "", err
+		}
+		log.Infof("Resolved %v to branch %v", refHead, branch)
+		return "branch:" + branch, nil
+	}
+
+	glob, isTag, err := splitRefPattern(info.RefPattern)
+	if err != nil {
+		return "", err
+	}
+	var refs []string
+	if isTag {
+		refs, err = git.client.ListTags(log, info.Owner, info.Repository)
+	} else {
+		refs, err = git.client.ListBranches(log, info.Owner, info.Repository)
+	}
+	if err != nil {
+		return "", fmt.Errorf("Could not list the refs of %v/%v to resolve %v - %v", info.Owner, info.Repository, info.RefPattern, err)
+	}
+
+	var candidates []string
+	for _, ref := range refs {
+		if matched, _ := path.Match(glob, ref); matched {
+			candidates = append(candidates, ref)
+		}
+	}
+	if len(candidates) == 0 {
+		return "", fmt.Errorf("No ref of %v/%v matches %v", info.Owner, info.Repository, info.RefPattern)
+	}
+
+	if info.RefOrder == refOrderCommitDate {
+		err = git.sortRefsByCommitDate(log, info, candidates)
+	} else {
+		err = sortRefsByVersion(candidates)
+	}
+	if err != nil {
+		return "", fmt.Errorf("Could not resolve %v - %v", info.RefPattern, err)
+	}
+
+	log.Infof("Resolved %v to %v", info.RefPattern, candidates[0])
+	// the contents API resolves tag names given as the ref just like branch names
+	return "branch:" + candidates[0], nil
+}
+
+// sortRefsByVersion orders refs from the highest version down, ignoring a leading v.
+// It fails when the two highest refs are the same version, e.g. v1.2 and 1.2.0.
+func sortRefsByVersion(refs []string) error {
+	version := func(ref string) string {
+		return strings.TrimPrefix(strings.TrimPrefix(ref, "v"), "V")
+	}
+	sort.SliceStable(refs, func(i, j int) bool {
+		return versionutil.Compare(version(refs[i]), version(refs[j]), true) > 0
+	})
+	if len(refs) > 1 && versionutil.Compare(version(refs[0]), version(refs[1]), false) == 0 {
+		return fmt.Errorf("%v and %v are the same version", refs[0], refs[1])
+	}
+	return nil
+}
+
+// sortRefsByCommitDate orders refs from the most recently committed down.
+// It fails when the two most recent refs were committed at the same time.
+func (git *downloadContext) sortRefsByCommitDate(log log.T, info GitInfo, refs []string) error {
+	dates := make(map[string]time.Time, len(refs))
+	for _, ref := range refs {
+		date, err := git.client.GetLatestCommitDate(log, info.Owner, info.Repository, "", &github.RepositoryContentGetOptions{Ref: ref})
+		if err != nil {
+			return err
+		}
+		dates[ref] = date
+	}
+	sort.SliceStable(refs, func(i, j int) bool {
+		return dates[refs[i]].After(dates[refs[j]])
+	})
+	if len(refs) > 1 && dates[refs[0]].Equal(dates[refs[1]]) {
+		return fmt.Errorf("%v and %v were committed at the same time", refs[0], refs[1])
+	}
+	return nil
+}
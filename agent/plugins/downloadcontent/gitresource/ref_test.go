@@ -0,0 +1,134 @@
+// Copyright 2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package gitresource
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	githubclientmock "github.com/aws/amazon-ssm-agent/agent/githubclient/mock"
+	"github.com/go-github/github"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGitResource_ResolveRefPatternTags(t *testing.T) {
+	data := []struct {
+		name        string
+		refPattern  string
+		tags        []string
+		expected    string
+		expectedErr string
+	}{
+		{"highest version among matching tags", "refs/tags/v1.*", []string{"v2.0.0", "v1.2.0", "v1.10.0", "v1.9.3", "nightly"}, "branch:v1.10.0", ""},
+		{"prerelease ranks below its release", "refs/tags/v1.*", []string{"v1.1.0-rc.1", "v1.1.0", "v1.0.0"}, "branch:v1.1.0", ""},
+		{"no matching tag", "refs/tags/v3.*", []string{"v2.0.0", "v1.2.0"}, "", "No ref of owner/repo matches refs/tags/v3.*"},
+		{"ambiguous versions", "refs/tags/*", []string{"v1.2", "1.2.0", "v1.1"}, "", "Could not resolve refs/tags/* - "},
+	}
+
+	for _, testdata := range data {
+		t.Run(testdata.name, func(t *testing.T) {
+			clientMock := githubclientmock.ClientMock{}
+			clientMock.On("ListTags", logMock, "owner", "repo").Return(testdata.tags, nil).Once()
+			gitResource := NewResourceWithMockedClient(&clientMock)
+			gitResource.Info.RefPattern = testdata.refPattern
+
+			getOptions, err := gitResource.newDownloadContext(gitResource.client, gitResource.apiHost).resolveRefPattern(logMock, gitResource.Info)
+
+			if testdata.expectedErr != "" {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), testdata.expectedErr)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, testdata.expected, getOptions)
+			}
+			clientMock.AssertExpectations(t)
+		})
+	}
+}
+
+func TestGitResource_ResolveRefPatternBranchesByCommitDate(t *testing.T) {
+	now := time.Now()
+	clientMock := githubclientmock.ClientMock{}
+	clientMock.On("ListBranches", logMock, "owner", "repo").Return([]string{"main", "release-1", "release-2"}, nil).Once()
+	clientMock.On("GetLatestCommitDate", logMock, "owner", "repo", "", &github.RepositoryContentGetOptions{Ref: "release-1"}).Return(now, nil).Once()
+	clientMock.On("GetLatestCommitDate", logMock, "owner", "repo", "", &github.RepositoryContentGetOptions{Ref: "release-2"}).Return(now.Add(-time.Hour), nil).Once()
+	gitResource := NewResourceWithMockedClient(&clientMock)
+	gitResource.Info.RefPattern = "refs/heads/release-*"
+	gitResource.Info.RefOrder = refOrderCommitDate
+
+	getOptions, err := gitResource.newDownloadContext(gitResource.client, gitResource.apiHost).resolveRefPattern(logMock, gitResource.Info)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "branch:release-1", getOptions)
+	clientMock.AssertExpectations(t)
+}
+
+func TestGitResource_ResolveRefPatternHead(t *testing.T) {
+	clientMock := githubclientmock.ClientMock{}
+	clientMock.On("GetDefaultBranch", logMock, "owner", "repo").Return("main", nil).Once()
+	gitResource := NewResourceWithMockedClient(&clientMock)
+	gitResource.Info.RefPattern = refHead
+
+	getOptions, err := gitResource.newDownloadContext(gitResource.client, gitResource.apiHost).resolveRefPattern(logMock, gitResource.Info)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "branch:main", getOptions)
+}
+
+func TestGitResource_ResolveRefPatternListFails(t *testing.T) {
+	clientMock := githubclientmock.ClientMock{}
+	clientMock.On("ListTags", logMock, "owner", "repo").Return(nil, errors.New("Rate limit exceeded")).Once()
+	gitResource := NewResourceWithMockedClient(&clientMock)
+	gitResource.Info.RefPattern = "refs/tags/*"
+
+	_, err := gitResource.newDownloadContext(gitResource.client, gitResource.apiHost).resolveRefPattern(logMock, gitResource.Info)
+
+	assert.EqualError(t, err, "Could not list the refs of owner/repo to resolve refs/tags/* - Rate limit exceeded")
+}
+
+func TestGitResource_ValidateLocationInfoRefPattern(t *testing.T) {
+	data := []struct {
+		name        string
+		info        GitInfo
+		expectedErr string
+	}{
+		{"tag glob", GitInfo{RefPattern: "refs/tags/v1.*"}, ""},
+		{"head", GitInfo{RefPattern: "HEAD", RefOrder: refOrderCommitDate}, ""},
+		{"with getOptions", GitInfo{RefPattern: "HEAD", GetOptions: "branch:main"}, "Specify either getOptions or refPattern for GitHub SourceType, not both"},
+		{"unknown prefix", GitInfo{RefPattern: "tags/v1.*"}, "RefPattern tags/v1.* for GitHub SourceType must be HEAD or start with refs/tags/ or refs/heads/"},
+		{"invalid glob", GitInfo{RefPattern: "refs/heads/[release"}, "RefPattern refs/heads/[release for GitHub SourceType is not a valid glob"},
+		{"unknown order", GitInfo{RefPattern: "HEAD", RefOrder: "name"}, "RefOrder for GitHub SourceType must be either semver or commitDate"},
+	}
+
+	for _, testdata := range data {
+		t.Run(testdata.name, func(t *testing.T) {
+			gitResource := NewResourceWithMockedClient(&githubclientmock.ClientMock{})
+			testdata.info.Owner, testdata.info.Repository = "owner", "repo"
+			gitResource.Info = testdata.info
+
+			valid, err := gitResource.ValidateLocationInfo()
+
+			if testdata.expectedErr != "" {
+				assert.False(t, valid)
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), testdata.expectedErr)
+			} else {
+				assert.True(t, valid)
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,183 @@
+// Copyright 2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package gitresource
+
+import (
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/githubclient"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/downloadcontent/gitresource/privategithub"
+	"github.com/go-github/github"
+)
+
+// refreshingClient retries a request that GitHub answered with 401 once, with a client authorized by a refreshed token.
+// Short-lived tokens read when the download started may expire before a long directory download ends.
+type refreshingClient struct {
+	githubclient.IGitClient
+	// refresh reads the token again and returns a client authorized with it
+	refresh func(log log.T) (githubclient.IGitClient, error)
+	// anonymous, when set, retries requests still refused after the refresh without a token, in case the repository is public
+	anonymous githubclient.IGitClient
+}
+
+// newRefreshingClient wraps client so that its requests are retried with a refreshed token when it is refused
+func newRefreshingClient(client githubclient.IGitClient, refresh func(log log.T) (githubclient.IGitClient, error)) *refreshingClient {
+	return &refreshingClient{IGitClient: client, refresh: refresh}
+}
+
+// retryUnauthorized calls request with the current client and, if GitHub refuses its token, once more with a refreshed one.
+// When the anonymous fallback is set and the token is still refused, the request is made a last time without a token,
+// which only succeeds for a public repository. The authorization error is returned otherwise.
+func (client *refreshingClient) retryUnauthorized(log log.T, request func(githubclient.IGitClient) error) error {
+	err := request(client.IGitClient)
+	if !githubclient.IsUnauthorized(err) {
+		return err
+	}
+	log.Infof("GitHub refused the token, refreshing it and retrying the request. Error - %v", err)
+	if refreshed, refreshErr := client.refresh(log); refreshErr != nil {
+		log.Warnf("Could not refresh the GitHub token - %v", refreshErr)
+	} else {
+		client.IGitClient = refreshed
+		if err = request(refreshed); !githubclient.IsUnauthorized(err) {
+			return err
+		}
+	}
+
+	if client.anonymous == nil {
+		return err
+	}
+	log.Warnf("GitHub refused the token, retrying the request anonymously in case the repository is public. Error - %v", err)
+	if anonymousErr := request(client.anonymous); anonymousErr != nil {
+		log.Debugf("Anonymous request failed, the repository is not public - %v", anonymousErr)
+		return err
+	}
+	// the repository is public, the token isn't needed for the rest of the download
+	client.IGitClient = client.anonymous
+	client.anonymous = nil
+	return nil
+}
+
+// GetRepositoryContents retries GetRepositoryContents with a refreshed token when the token is refused
+func (client *refreshingClient) GetRepositoryContents(log log.T, owner, repo, path string, opt *github.RepositoryContentGetOptions) (fileContent *github.RepositoryContent, directoryContent []*github.RepositoryContent, err error) {
+	err = client.retryUnauthorized(log, func(current githubclient.IGitClient) (err error) {
+		fileContent, directoryContent, err = current.GetRepositoryContents(log, owner, repo, path, opt)
+		return err
+	})
+	return fileContent, directoryContent, err
+}
+
+// GetLatestCommitDate retries GetLatestCommitDate with a refreshed token when the token is refused
+func (client *refreshingClient) GetLatestCommitDate(log log.T, owner, repo, path string, opt *github.RepositoryContentGetOptions) (date time.Time, err error) {
+	err = client.retryUnauthorized(log, func(current githubclient.IGitClient) (err error) {
+		date, err = current.GetLatestCommitDate(log, owner, repo, path, opt)
+		return err
+	})
+	return date, err
+}
+
+// GetCommitTreeSha retries GetCommitTreeSha with a refreshed token when the token is refused
+func (client *refreshingClient) GetCommitTreeSha(log log.T, owner, repo, commitID string) (sha string, err error) {
+	err = client.retryUnauthorized(log, func(current githubclient.IGitClient) (err error) {
+		sha, err = current.GetCommitTreeSha(log, owner, repo, commitID)
+		return err
+	})
+	return sha, err
+}
+
+// GetCommitSignature retries GetCommitSignature with a refreshed token when the token is refused
+func (client *refreshingClient) GetCommitSignature(log log.T, owner, repo, ref string) (signature *github.SignatureVerification, err error) {
+	err = client.retryUnauthorized(log, func(current githubclient.IGitClient) (err error) {
+		signature, err = current.GetCommitSignature(log, owner, repo, ref)
+		return err
+	})
+	return signature, err
+}
+
+// GetDefaultBranch retries GetDefaultBranch with a refreshed token when the token is refused
+func (client *refreshingClient) GetDefaultBranch(log log.T, owner, repo string) (branch string, err error) {
+	err = client.retryUnauthorized(log, func(current githubclient.IGitClient) (err error) {
+		branch, err = current.GetDefaultBranch(log, owner, repo)
+		return err
+	})
+	return branch, err
+}
+
+// GetRawContent retries GetRawContent with a refreshed token when the token is refused
+func (client *refreshingClient) GetRawContent(log log.T, owner, repo, path string, opt *github.RepositoryContentGetOptions) (content io.ReadCloser, err error) {
+	err = client.retryUnauthorized(log, func(current githubclient.IGitClient) (err error) {
+		content, err = current.GetRawContent(log, owner, repo, path, opt)
+		return err
+	})
+	return content, err
+}
+
+// GetBlob retries GetBlob with a refreshed token when the token is refused
+func (client *refreshingClient) GetBlob(log log.T, owner, repo, sha string) (content string, err error) {
+	err = client.retryUnauthorized(log, func(current githubclient.IGitClient) (err error) {
+		content, err = current.GetBlob(log, owner, repo, sha)
+		return err
+	})
+	return content, err
+}
+
+// GetLFSObject retries GetLFSObject with a refreshed token when the token is refused
+func (client *refreshingClient) GetLFSObject(log log.T, owner, repo, oid string, size int64) (content string, err error) {
+	err = client.retryUnauthorized(log, func(current githubclient.IGitClient) (err error) {
+		content, err = current.GetLFSObject(log, owner, repo, oid, size)
+		return err
+	})
+	return content, err
+}
+
+// GetTreeModes retries GetTreeModes with a refreshed token when the token is refused
+func (client *refreshingClient) GetTreeModes(log log.T, owner, repo, tree string) (modes map[string]string, err error) {
+	err = client.retryUnauthorized(log, func(current githubclient.IGitClient) (err error) {
+		modes, err = current.GetTreeModes(log, owner, repo, tree)
+		return err
+	})
+	return modes, err
+}
+
+// ListTags retries ListTags with a refreshed token when the token is refused
+func (client *refreshingClient) ListTags(log log.T, owner, repo string) (tags []string, err error) {
+	err = client.retryUnauthorized(log, func(current githubclient.IGitClient) (err error) {
+		tags, err = current.ListTags(log, owner, repo)
+		return err
+	})
+	return tags, err
+}
+
+// ListBranches retries ListBranches with a refreshed token when the token is refused
+func (client *refreshingClient) ListBranches(log log.T, owner, repo string) (branches []string, err error) {
+	err = client.retryUnauthorized(log, func(current githubclient.IGitClient) (err error) {
+		branches, err = current.ListBranches(log, owner, repo)
+		return err
+	})
+	return branches, err
+}
+
+// refreshToken returns a refresh reading tokenInfo again, e.g. from Parameter Store, and creating a client authorized with it
+func refreshToken(token privategithub.PrivateGithubAccess, tokenInfo string, newClient func(*http.Client) githubclient.IGitClient) func(log log.T) (githubclient.IGitClient, error) {
+	return func(log log.T) (githubclient.IGitClient, error) {
+		httpClient, err := token.GetOAuthClient(log, tokenInfo)
+		if err != nil {
+			return nil, err
+		}
+		return newClient(httpClient), nil
+	}
+}
@@ -0,0 +1,199 @@
+// Copyright 2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package gitresource
+
+import (
+	"errors"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	filemock "github.com/aws/amazon-ssm-agent/agent/fileutil/filemanager/mock"
+	"github.com/aws/amazon-ssm-agent/agent/githubclient"
+	githubclientmock "github.com/aws/amazon-ssm-agent/agent/githubclient/mock"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/go-github/github"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+var unauthorizedErr = &github.ErrorResponse{Response: &http.Response{StatusCode: http.StatusUnauthorized}, Message: "Bad credentials"}
+
+func TestGitResource_DownloadRefreshesExpiredToken(t *testing.T) {
+	opt := &github.RepositoryContentGetOptions{Ref: "master"}
+	content, fileType, gitpath := "content", "file", "path/to/file.ext"
+	fileMetadata := &github.RepositoryContent{Content: &content, Type: &fileType, Path: &gitpath}
+
+	expiredClient := githubclientmock.ClientMock{}
+	expiredClient.On("ParseGetOptions", logMock, "").Return(opt, nil)
+	expiredClient.On("GetRepositoryContents", logMock, "owner", "repo", gitpath, opt).Return((*github.RepositoryContent)(nil), []*github.RepositoryContent(nil), unauthorizedErr).Once()
+	refreshedClient := githubclientmock.ClientMock{}
+	refreshedClient.On("GetRepositoryContents", logMock, "owner", "repo", gitpath, opt).Return(fileMetadata, []*github.RepositoryContent(nil), nil).Once()
+	refreshedClient.On("IsFileContentType", mock.AnythingOfType("*github.RepositoryContent")).Return(true)
+
+	refreshes := 0
+	gitResource := NewResourceWithMockedClient(&expiredClient)
+	gitResource.client = newRefreshingClient(&expiredClient, func(log log.T) (githubclient.IGitClient, error) {
+		refreshes++
+		return &refreshedClient, nil
+	})
+
+	fileMock := filemock.FileSystemMock{}
+	fileMock.On("Exists", appconfig.DownloadRoot).Return(true)
+	fileMock.On("IsDirectory", appconfig.DownloadRoot).Return(true)
+	fileMock.On("MakeDirs", strings.TrimSuffix(appconfig.DownloadRoot, "/")).Return(nil)
+	fileMock.On("WriteFile", filepath.Join(appconfig.DownloadRoot, "file.ext"), mock.Anything).Return(nil)
+
+	err := gitResource.Download(logMock, fileMock, "")
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, refreshes)
+	expiredClient.AssertExpectations(t)
+	refreshedClient.AssertExpectations(t)
+}
+
+func TestRefreshingClient_RefreshesOnce(t *testing.T) {
+	opt := &github.RepositoryContentGetOptions{Ref: "master"}
+	expiredClient := githubclientmock.ClientMock{}
+	expiredClient.On("GetDefaultBranch", logMock, "owner", "repo").Return("", unauthorizedErr).Once()
+	refreshedClient := githubclientmock.ClientMock{}
+	refreshedClient.On("GetDefaultBranch", logMock, "owner", "repo").Return("", unauthorizedErr).Once()
+	refreshedClient.On("GetRepositoryContents", logMock, "owner", "repo", "path", opt).Return((*github.RepositoryContent)(nil), []*github.RepositoryContent(nil), errors.New("Rate limit exceeded")).Once()
+
+	refreshes := 0
+	client := newRefreshingClient(&expiredClient, func(log log.T) (githubclient.IGitClient, error) {
+		refreshes++
+		return &refreshedClient, nil
+	})
+
+	_, err := client.GetDefaultBranch(logMock, "owner", "repo")
+	assert.Equal(t, unauthorizedErr, err)
+	assert.Equal(t, 1, refreshes)
+
+	// errors other than 401 are returned without refreshing the token
+	_, _, err = client.GetRepositoryContents(logMock, "owner", "repo", "path", opt)
+	assert.EqualError(t, err, "Rate limit exceeded")
+	assert.Equal(t, 1, refreshes)
+	expiredClient.AssertExpectations(t)
+	refreshedClient.AssertExpectations(t)
+}
+
+func TestRefreshingClient_RefreshFails(t *testing.T) {
+	expiredClient := githubclientmock.ClientMock{}
+	expiredClient.On("GetCommitTreeSha", logMock, "owner", "repo", "abc").Return("", unauthorizedErr).Once()
+
+	client := newRefreshingClient(&expiredClient, func(log log.T) (githubclient.IGitClient, error) {
+		return nil, errors.New("parameter not found")
+	})
+
+	_, err := client.GetCommitTreeSha(logMock, "owner", "repo", "abc")
+
+	assert.Equal(t, unauthorizedErr, err)
+	expiredClient.AssertExpectations(t)
+}
+
+func TestGitResource_DownloadFallsBackToAnonymous(t *testing.T) {
+	opt := &github.RepositoryContentGetOptions{Ref: "master"}
+	content, fileType, gitpath := "content", "file", "path/to/file.ext"
+	fileMetadata := &github.RepositoryContent{Content: &content, Type: &fileType, Path: &gitpath}
+
+	expiredClient := githubclientmock.ClientMock{}
+	expiredClient.On("ParseGetOptions", logMock, "").Return(opt, nil)
+	expiredClient.On("GetRepositoryContents", logMock, "owner", "repo", gitpath, opt).Return((*github.RepositoryContent)(nil), []*github.RepositoryContent(nil), unauthorizedErr).Twice()
+	anonymousClient := githubclientmock.ClientMock{}
+	anonymousClient.On("GetRepositoryContents", logMock, "owner", "repo", gitpath, opt).Return(fileMetadata, []*github.RepositoryContent(nil), nil).Once()
+	anonymousClient.On("IsFileContentType", mock.AnythingOfType("*github.RepositoryContent")).Return(true)
+
+	gitResource := NewResourceWithMockedClient(&expiredClient)
+	client := newRefreshingClient(&expiredClient, func(log log.T) (githubclient.IGitClient, error) {
+		// the refreshed token is just as wrong
+		return &expiredClient, nil
+	})
+	client.anonymous = &anonymousClient
+	gitResource.client = client
+
+	fileMock := filemock.FileSystemMock{}
+	fileMock.On("Exists", appconfig.DownloadRoot).Return(true)
+	fileMock.On("IsDirectory", appconfig.DownloadRoot).Return(true)
+	fileMock.On("MakeDirs", strings.TrimSuffix(appconfig.DownloadRoot, "/")).Return(nil)
+	fileMock.On("WriteFile", filepath.Join(appconfig.DownloadRoot, "file.ext"), mock.Anything).Return(nil)
+
+	err := gitResource.Download(logMock, fileMock, "")
+
+	assert.NoError(t, err)
+	expiredClient.AssertExpectations(t)
+	anonymousClient.AssertExpectations(t)
+}
+
+func TestRefreshingClient_AnonymousFallback(t *testing.T) {
+	notFoundErr := &github.ErrorResponse{Response: &http.Response{StatusCode: http.StatusNotFound}, Message: "Not Found"}
+	data := []struct {
+		name             string
+		fallback         bool
+		anonymousErr     error
+		expectedErr      error
+		expectedFallback bool
+	}{
+		{"public repository", true, nil, nil, true},
+		{"private repository", true, notFoundErr, unauthorizedErr, true},
+		{"fallback not enabled", false, nil, unauthorizedErr, false},
+	}
+	for _, testdata := range data {
+		t.Run(testdata.name, func(t *testing.T) {
+			expiredClient := githubclientmock.ClientMock{}
+			expiredClient.On("GetDefaultBranch", logMock, "owner", "repo").Return("", unauthorizedErr)
+			anonymousClient := githubclientmock.ClientMock{}
+			if testdata.expectedFallback {
+				anonymousClient.On("GetDefaultBranch", logMock, "owner", "repo").Return("master", testdata.anonymousErr).Once()
+			}
+
+			client := newRefreshingClient(&expiredClient, func(log log.T) (githubclient.IGitClient, error) {
+				return nil, errors.New("parameter not found")
+			})
+			if testdata.fallback {
+				client.anonymous = &anonymousClient
+			}
+
+			_, err := client.GetDefaultBranch(logMock, "owner", "repo")
+
+			assert.Equal(t, testdata.expectedErr, err)
+			anonymousClient.AssertExpectations(t)
+			if !testdata.expectedFallback {
+				anonymousClient.AssertNotCalled(t, "GetDefaultBranch", logMock, "owner", "repo")
+			}
+		})
+	}
+}
+
+func TestRefreshToken(t *testing.T) {
+	token := TokenMock{}
+	httpClient := &http.Client{}
+	token.On("GetOAuthClient", logMock, "{{ssm-secure:github-token}}").Return(httpClient, nil).Once()
+
+	var usedClient *http.Client
+	refresh := refreshToken(token, "{{ssm-secure:github-token}}", func(client *http.Client) githubclient.IGitClient {
+		usedClient = client
+		return githubclient.NewClient(client)
+	})
+
+	client, err := refresh(logMock)
+
+	assert.NoError(t, err)
+	assert.NotNil(t, client)
+	assert.Equal(t, httpClient, usedClient)
+	token.AssertExpectations(t)
+}
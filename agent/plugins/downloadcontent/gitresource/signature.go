@@ -0,0 +1,260 @@
+// Copyright 2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package gitresource
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/aws/amazon-ssm-agent/agent/log"
+)
+
+const (
+	// OpenPGP packet tag of signatures
+	signaturePacketTag = 2
+
+	// OpenPGP signature subpackets identifying the signing key
+	issuerKeyIDSubpacket       = 16
+	issuerFingerprintSubpacket = 33
+
+	// longKeyIDLength is the number of hex digits of a long key ID
+	longKeyIDLength = 16
+)
+
+// verifySignature ensures the commit of getOptions has a signature verified by GitHub
+// and, when trusted keys are configured, that it was made with one of them
+func (git *downloadContext) verifySignature(log log.T, info GitInfo) error {
+	opt, err := git.client.ParseGetOptions(log, info.GetOptions)
+	if err != nil {
+		return err
+	}
+	verification, err := git.client.GetCommitSignature(log, info.Owner, info.Repository, opt.Ref)
+	if err != nil {
+		return fmt.Errorf("Could not verify signature of %v - %v", opt.Ref, err)
+	}
+	if verification.GetSignature() == "" {
+		return fmt.Errorf("Commit %v is not signed", opt.Ref)
+	}
+	if !verification.GetVerified() {
+		return fmt.Errorf("Signature of commit %v could not be verified - %v", opt.Ref, verification.GetReason())
+	}
+	if len(git.trustedSigningKeys) == 0 {
+		log.Debugf("Signature of commit %v verified", opt.Ref)
+		return nil
+	}
+
+	issuers, err := signatureIssuers(verification.GetSignature())
+	if err != nil {
+		return fmt.Errorf("Could not read signature of commit %v - %v", opt.Ref, err)
+	}
+	for _, issuer := range issuers {
+		if isTrustedKey(issuer, git.trustedSigningKeys) {
+			log.Debugf("Signature of commit %v verified with trusted key %v", opt.Ref, issuer)
+			return nil
+		}
+	}
+	return fmt.Errorf("Commit %v is not signed with a trusted key, signed by %v", opt.Ref, strings.Join(issuers, ", "))
+}
+
+// isTrustedKey matches an issuer fingerprint or key ID against the trusted fingerprints or long key IDs.
+// Long key IDs are the last 16 hex digits of a fingerprint, shorter IDs are never trusted.
+func isTrustedKey(issuer string, trustedKeys []string) bool {
+	if len(issuer) < longKeyIDLength {
+		return false
+	}
+	for _, trusted := range trustedKeys {
+		trusted = strings.ToUpper(strings.Replace(trusted, " ", "", -1))
+		if len(trusted) < longKeyIDLength {
+			continue
+		}
+		if strings.HasSuffix(trusted, issuer) || strings.HasSuffix(issuer, trusted) {
+			return true
+		}
+	}
+	return false
+}
+
+// signatureIssuers returns the upper case hex fingerprints and key IDs of the key that made an armored OpenPGP signature
+func signatureIssuers(armored string) ([]string, error) {
+	packet, err := dearmor(armored)
+	if err != nil {
+		return nil, err
+	}
+	body, err := signaturePacketBody(packet)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(body) == 0 {
+		return nil, errors.New("empty signature packet")
+	}
+	switch body[0] {
+	case 3:
+		// version, hashed material length, signature type, creation time, key ID
+		if len(body) < 15 {
+			return nil, errors.New("truncated signature packet")
+		}
+		return []string{strings.ToUpper(hex.EncodeToString(body[7:15]))}, nil
+	case 4:
+		// version, signature type, public key algorithm, hash algorithm, hashed and unhashed subpackets
+		var issuers []string
+		rest := body[4:]
+		for i := 0; i < 2; i++ {
+			if len(rest) < 2 {
+				return nil, errors.New("truncated signature packet")
+			}
+			length := int(binary.BigEndian.Uint16(rest))
+			if len(rest) < 2+length {
+				return nil, errors.New("truncated signature subpackets")
+			}
+			found, err := subpacketIssuers(rest[2 : 2+length])
+			if err != nil {
+				return nil, err
+			}
+			issuers = append(issuers, found...)
+			rest = rest[2+length:]
+		}
+		if len(issuers) == 0 {
+			return nil, errors.New("signature does not identify its key")
+		}
+		return issuers, nil
+	default:
+		return nil, fmt.Errorf("unsupported signature version %v", body[0])
+	}
+}
+
+// dearmor decodes the base64 data of an ASCII armored OpenPGP block, ignoring its headers and checksum
+func dearmor(armored string) ([]byte, error) {
+	lines := strings.Split(strings.Replace(armored, "\r\n", "\n", -1), "\n")
+	var data []string
+	inBlock, inHeaders := false, false
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(line, "-----BEGIN PGP"):
+			inBlock, inHeaders = true, true
+		case !inBlock:
+		case strings.HasPrefix(line, "-----END PGP"):
+			inBlock = false
+		case inHeaders:
+			if line == "" {
+				inHeaders = false
+			} else if !strings.Contains(line, ":") {
+				// blocks without headers start with the data right away
+				inHeaders = false
+				data = append(data, line)
+			}
+		case strings.HasPrefix(line, "="):
+		default:
+			data = append(data, line)
+		}
+	}
+	if len(data) == 0 {
+		return nil, errors.New("no armored data found")
+	}
+	return base64.StdEncoding.DecodeString(strings.Join(data, ""))
+}
+
+// signaturePacketBody returns the body of the first OpenPGP packet, which must be a signature
+func signaturePacketBody(packet []byte) ([]byte, error) {
+	if len(packet) < 2 || packet[0]&0x80 == 0 {
+		return nil, errors.New("invalid packet header")
+	}
+	var tag, length int
+	rest := packet[1:]
+	if packet[0]&0x40 != 0 {
+		tag = int(packet[0] & 0x3f)
+		switch first := int(rest[0]); {
+		case first < 192:
+			length, rest = first, rest[1:]
+		case first < 224:
+			if len(rest) < 2 {
+				return nil, errors.New("truncated packet header")
+			}
+			length, rest = (first-192)<<8+int(rest[1])+192, rest[2:]
+		case first == 255:
+			if len(rest) < 5 {
+				return nil, errors.New("truncated packet header")
+			}
+			length, rest = int(binary.BigEndian.Uint32(rest[1:])), rest[5:]
+		default:
+			return nil, errors.New("partial packet lengths are not supported")
+		}
+	} else {
+		tag = int(packet[0]>>2) & 0x0f
+		switch packet[0] & 0x03 {
+		case 0:
+			length, rest = int(rest[0]), rest[1:]
+		case 1:
+			if len(rest) < 2 {
+				return nil, errors.New("truncated packet header")
+			}
+			length, rest = int(binary.BigEndian.Uint16(rest)), rest[2:]
+		case 2:
+			if len(rest) < 4 {
+				return nil, errors.New("truncated packet header")
+			}
+			length, rest = int(binary.BigEndian.Uint32(rest)), rest[4:]
+		default:
+			length = len(rest)
+		}
+	}
+	if tag != signaturePacketTag {
+		return nil, fmt.Errorf("expected a signature packet, found packet type %v", tag)
+	}
+	if length > len(rest) {
+		return nil, errors.New("truncated packet")
+	}
+	return rest[:length], nil
+}
+
+// subpacketIssuers returns the issuer key IDs and fingerprints found in a signature subpacket area
+func subpacketIssuers(area []byte) ([]string, error) {
+	var issuers []string
+	for len(area) > 0 {
+		var length int
+		switch first := int(area[0]); {
+		case first < 192:
+			length, area = first, area[1:]
+		case first < 255:
+			if len(area) < 2 {
+				return nil, errors.New("truncated subpacket")
+			}
+			length, area = (first-192)<<8+int(area[1])+192, area[2:]
+		default:
+			if len(area) < 5 {
+				return nil, errors.New("truncated subpacket")
+			}
+			length, area = int(binary.BigEndian.Uint32(area[1:])), area[5:]
+		}
+		if length == 0 || length > len(area) {
+			return nil, errors.New("truncated subpacket")
+		}
+		subpacketType, data := area[0]&0x7f, area[1:length]
+		switch {
+		case subpacketType == issuerKeyIDSubpacket && len(data) == 8:
+			issuers = append(issuers, strings.ToUpper(hex.EncodeToString(data)))
+		case subpacketType == issuerFingerprintSubpacket && len(data) > 1:
+			// the first octet is the key version
+			issuers = append(issuers, strings.ToUpper(hex.EncodeToString(data[1:])))
+		}
+		area = area[length:]
+	}
+	return issuers, nil
+}
@@ -0,0 +1,165 @@
+// Copyright 2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package gitresource
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"testing"
+
+	githubclientmock "github.com/aws/amazon-ssm-agent/agent/githubclient/mock"
+	"github.com/go-github/github"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	testFingerprint = "0123456789ABCDEF0123456789ABCDEF01234567"
+	testKeyID       = "89ABCDEF01234567"
+)
+
+// armoredSignature builds an armored v4 signature identifying its key by fingerprint (hashed) and key ID (unhashed)
+func armoredSignature(fingerprint string) string {
+	fingerprintBytes, _ := hex.DecodeString(fingerprint)
+	hashed := append([]byte{byte(len(fingerprintBytes) + 2), issuerFingerprintSubpacket, 4}, fingerprintBytes...)
+	unhashed := append([]byte{9, issuerKeyIDSubpacket}, fingerprintBytes[len(fingerprintBytes)-8:]...)
+
+	body := []byte{4, 0x00, 1, 8}
+	body = append(body, 0, byte(len(hashed)))
+	body = append(body, hashed...)
+	body = append(body, 0, byte(len(unhashed)))
+	body = append(body, unhashed...)
+	// hash prefix and a dummy signature value
+	body = append(body, 0xab, 0xcd, 0x00, 0x08, 0xff)
+
+	packet := append([]byte{0xc2, byte(len(body))}, body...)
+	return "-----BEGIN PGP SIGNATURE-----\nComment: test\n\n" +
+		base64.StdEncoding.EncodeToString(packet) +
+		"\n=abcd\n-----END PGP SIGNATURE-----\n"
+}
+
+func TestSignatureIssuers(t *testing.T) {
+	issuers, err := signatureIssuers(armoredSignature(testFingerprint))
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{testFingerprint, testKeyID}, issuers)
+}
+
+func TestSignatureIssuersInvalid(t *testing.T) {
+	_, err := signatureIssuers("not a signature")
+	assert.Error(t, err)
+
+	_, err = signatureIssuers("-----BEGIN PGP SIGNATURE-----\n\nxsBNBFk=\n-----END PGP SIGNATURE-----")
+	assert.Error(t, err)
+}
+
+func TestGitResource_DownloadRequireSignature(t *testing.T) {
+	signature := armoredSignature(testFingerprint)
+	verified := true
+	unverified := false
+	reason := "unknown_key"
+	empty := ""
+
+	data := []struct {
+		name         string
+		verification *github.SignatureVerification
+		getErr       error
+		trustedKeys  []string
+		expectedErr  string
+	}{
+		{
+			"unsigned",
+			&github.SignatureVerification{Verified: &unverified, Signature: &empty},
+			nil,
+			nil,
+			"Commit v1.0 is not signed",
+		},
+		{
+			"unverified",
+			&github.SignatureVerification{Verified: &unverified, Reason: &reason, Signature: &signature},
+			nil,
+			nil,
+			"Signature of commit v1.0 could not be verified - unknown_key",
+		},
+		{
+			"verification unavailable",
+			nil,
+			errors.New("not found"),
+			nil,
+			"Could not verify signature of v1.0 - not found",
+		},
+		{
+			"untrusted key",
+			&github.SignatureVerification{Verified: &verified, Signature: &signature},
+			nil,
+			[]string{"FEDCBA9876543210FEDCBA9876543210FEDCBA98"},
+			"Commit v1.0 is not signed with a trusted key, signed by " + testFingerprint + ", " + testKeyID,
+		},
+		{
+			"short key ID is not trusted",
+			&github.SignatureVerification{Verified: &verified, Signature: &signature},
+			nil,
+			[]string{"01234567"},
+			"Commit v1.0 is not signed with a trusted key, signed by " + testFingerprint + ", " + testKeyID,
+		},
+	}
+	for _, testdata := range data {
+		t.Run(testdata.name, func(t *testing.T) {
+			clientMock := githubclientmock.ClientMock{}
+			opt := &github.RepositoryContentGetOptions{Ref: "v1.0"}
+			clientMock.On("ParseGetOptions", logMock, "branch:v1.0").Return(opt, nil)
+			clientMock.On("GetCommitSignature", logMock, "owner", "repo", "v1.0").Return(testdata.verification, testdata.getErr)
+
+			gitResource := NewResourceWithMockedClient(&clientMock)
+			gitResource.Info.GetOptions = "branch:v1.0"
+			gitResource.Info.RequireSignature = true
+			gitResource.trustedSigningKeys = testdata.trustedKeys
+
+			err := gitResource.Download(logMock, nil, "destination")
+
+			assert.EqualError(t, err, testdata.expectedErr)
+			clientMock.AssertNotCalled(t, "GetRepositoryContents")
+		})
+	}
+}
+
+func TestGitResource_VerifySignature(t *testing.T) {
+	signature := armoredSignature(testFingerprint)
+	verified := true
+
+	data := []struct {
+		name        string
+		trustedKeys []string
+	}{
+		{"no trusted keys configured", nil},
+		{"trusted fingerprint", []string{"0123 4567 89ab cdef 0123  4567 89ab cdef 0123 4567"}},
+		{"trusted long key ID", []string{testKeyID}},
+	}
+	for _, testdata := range data {
+		t.Run(testdata.name, func(t *testing.T) {
+			clientMock := githubclientmock.ClientMock{}
+			opt := &github.RepositoryContentGetOptions{Ref: "abc123"}
+			clientMock.On("ParseGetOptions", logMock, "commitID:abc123").Return(opt, nil)
+			clientMock.On("GetCommitSignature", logMock, "owner", "repo", "abc123").Return(&github.SignatureVerification{Verified: &verified, Signature: &signature}, nil)
+
+			gitResource := NewResourceWithMockedClient(&clientMock)
+			gitResource.trustedSigningKeys = testdata.trustedKeys
+			info := gitResource.Info
+			info.GetOptions = "commitID:abc123"
+
+			assert.NoError(t, gitResource.newDownloadContext(gitResource.client, gitResource.apiHost).verifySignature(logMock, info))
+		})
+	}
+}
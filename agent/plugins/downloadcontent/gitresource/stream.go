@@ -0,0 +1,44 @@
+// Copyright 2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package gitresource
+
+import (
+	"github.com/aws/amazon-ssm-agent/agent/fileutil/artifact"
+	"github.com/aws/amazon-ssm-agent/agent/fileutil/filemanager"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/go-github/github"
+)
+
+// streamFile saves the file at filePath to destination as it is read from GitHub, its content is never held in memory as a whole
+func (git *downloadContext) streamFile(log log.T, filesys filemanager.FileSystem, info GitInfo, opt *github.RepositoryContentGetOptions, filePath string, destination string) error {
+	body, err := git.client.GetRawContent(log, info.Owner, info.Repository, filePath, opt)
+	if err != nil {
+		log.Errorf("Error streaming file content from GitHub file - %v, %v", filePath, err)
+		return err
+	}
+	defer body.Close()
+
+	log.Debugf("Streaming %v to %v", filePath, destination)
+	if err = git.saveStream(log, filesys, destination, artifact.LimitReader(body, filePath, git.maxFileSize)); err != nil {
+		log.Errorf("Error streaming file content from GitHub file - %v, %v", filePath, err)
+		if _, tooLarge := err.(*artifact.FileTooLargeError); tooLarge {
+			if deleteErr := filesys.DeleteFile(destination); deleteErr != nil {
+				log.Warnf("Could not delete the partial download %v - %v", destination, deleteErr)
+			}
+		}
+		return err
+	}
+	return nil
+}
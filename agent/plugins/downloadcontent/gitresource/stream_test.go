@@ -0,0 +1,153 @@
+// Copyright 2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package gitresource
+
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/aws/amazon-ssm-agent/agent/fileutil/filemanager"
+	filemock "github.com/aws/amazon-ssm-agent/agent/fileutil/filemanager/mock"
+	githubclientmock "github.com/aws/amazon-ssm-agent/agent/githubclient/mock"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/go-github/github"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// filler is an endless stream of the same byte
+type filler struct{}
+
+func (filler) Read(p []byte) (int, error) {
+	for i := range p {
+		p[i] = 'x'
+	}
+	return len(p), nil
+}
+
+// streamedFileMetadata is the metadata the contents API returns for a file too large to embed its content
+func streamedFileMetadata(path string, size int) *github.RepositoryContent {
+	file := "file"
+	return &github.RepositoryContent{Type: &file, Path: &path, Size: &size}
+}
+
+func TestGitResource_DownloadStreamMemory(t *testing.T) {
+	const size = 64 << 20
+	destination, err := ioutil.TempDir("", "stream")
+	assert.NoError(t, err)
+	defer os.RemoveAll(destination)
+
+	// a log of its own, the shared mock log allocates for every call it has recorded
+	logger := log.NewMockLog()
+	opt := &github.RepositoryContentGetOptions{Ref: ""}
+	clientMock := githubclientmock.ClientMock{}
+	clientMock.On("ParseGetOptions", logger, "").Return(opt, nil)
+	clientMock.On("GetRepositoryContents", logger, "owner", "repo", "path/to/large.bin", opt).Return(streamedFileMetadata("path/to/large.bin", size), []*github.RepositoryContent(nil), nil).Once()
+	clientMock.On("IsFileContentType", mock.AnythingOfType("*github.RepositoryContent")).Return(true)
+	clientMock.On("GetRawContent", logger, "owner", "repo", "path/to/large.bin", opt).Return(ioutil.NopCloser(io.LimitReader(filler{}, size)), nil).Once()
+
+	gitResource := NewResourceWithMockedClient(&clientMock)
+	gitResource.Info.Path = "path/to/large.bin"
+	gitResource.Info.Stream = true
+
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+	err = gitResource.Download(logger, filemanager.FileSystemImpl{}, destination)
+	runtime.ReadMemStats(&after)
+
+	assert.NoError(t, err)
+	clientMock.AssertExpectations(t)
+	info, err := os.Stat(filepath.Join(destination, "large.bin"))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(size), info.Size())
+	// the whole file would be at least size bytes of allocations if it were buffered
+	assert.True(t, after.TotalAlloc-before.TotalAlloc < size/8, "allocated %v bytes to stream %v bytes", after.TotalAlloc-before.TotalAlloc, size)
+}
+
+func TestGitResource_DownloadStreamFail(t *testing.T) {
+	opt := &github.RepositoryContentGetOptions{Ref: ""}
+	clientMock := githubclientmock.ClientMock{}
+	clientMock.On("ParseGetOptions", logMock, "").Return(opt, nil)
+	clientMock.On("GetRepositoryContents", logMock, "owner", "repo", "path/to/file.ext", opt).Return(streamedFileMetadata("path/to/file.ext", 10), []*github.RepositoryContent(nil), nil).Once()
+	clientMock.On("IsFileContentType", mock.AnythingOfType("*github.RepositoryContent")).Return(true)
+	clientMock.On("GetRawContent", logMock, "owner", "repo", "path/to/file.ext", opt).Return(nil, errors.New("Response is - 404 Not Found")).Once()
+
+	fileMock := filemock.FileSystemMock{}
+	fileMock.On("Exists", "/var/tmp/destination").Return(true)
+	fileMock.On("IsDirectory", "/var/tmp/destination").Return(true)
+
+	gitResource := NewResourceWithMockedClient(&clientMock)
+	gitResource.Info.Stream = true
+	err := gitResource.Download(logMock, fileMock, "/var/tmp/destination")
+
+	assert.EqualError(t, err, "Response is - 404 Not Found")
+	clientMock.AssertExpectations(t)
+}
+
+func TestGitResource_DownloadDeclaredSizeOverLimit(t *testing.T) {
+	opt := &github.RepositoryContentGetOptions{Ref: ""}
+	clientMock := githubclientmock.ClientMock{}
+	clientMock.On("ParseGetOptions", logMock, "").Return(opt, nil)
+	clientMock.On("GetRepositoryContents", logMock, "owner", "repo", "path/to/large.bin", opt).Return(streamedFileMetadata("path/to/large.bin", 2048), []*github.RepositoryContent(nil), nil).Once()
+	clientMock.On("IsFileContentType", mock.AnythingOfType("*github.RepositoryContent")).Return(true)
+
+	gitResource := NewResourceWithMockedClient(&clientMock)
+	gitResource.Info.Path = "path/to/large.bin"
+	gitResource.Info.Stream = true
+	gitResource.maxFileSize = 1024
+
+	// nothing is fetched or written for a file declared larger than the limit
+	fileMock := filemock.FileSystemMock{}
+	fileMock.On("Exists", mock.Anything).Return(true)
+	fileMock.On("IsDirectory", mock.Anything).Return(true)
+	err := gitResource.Download(logMock, fileMock, "destination")
+
+	assert.EqualError(t, err, "path/to/large.bin is 2048 bytes, larger than the download limit of 1024 bytes")
+	clientMock.AssertExpectations(t)
+	fileMock.AssertNotCalled(t, "WriteStream", mock.Anything, mock.Anything)
+}
+
+func TestGitResource_DownloadStreamOverLimit(t *testing.T) {
+	destination, err := ioutil.TempDir("", "stream")
+	assert.NoError(t, err)
+	defer os.RemoveAll(destination)
+
+	// the size of the file is unknown, the limit is enforced as it is streamed
+	file, filePath := "file", "path/to/large.bin"
+	opt := &github.RepositoryContentGetOptions{Ref: ""}
+	clientMock := githubclientmock.ClientMock{}
+	clientMock.On("ParseGetOptions", logMock, "").Return(opt, nil)
+	clientMock.On("GetRepositoryContents", logMock, "owner", "repo", filePath, opt).Return(&github.RepositoryContent{Type: &file, Path: &filePath}, []*github.RepositoryContent(nil), nil).Once()
+	clientMock.On("IsFileContentType", mock.AnythingOfType("*github.RepositoryContent")).Return(true)
+	clientMock.On("GetRawContent", logMock, "owner", "repo", filePath, opt).Return(ioutil.NopCloser(io.LimitReader(filler{}, 1<<20)), nil).Once()
+
+	gitResource := NewResourceWithMockedClient(&clientMock)
+	gitResource.Info.Path = filePath
+	gitResource.Info.Stream = true
+	gitResource.maxFileSize = 64 << 10
+
+	err = gitResource.Download(logMock, filemanager.FileSystemImpl{}, destination)
+
+	assert.EqualError(t, err, "path/to/large.bin exceeded the download limit of 65536 bytes")
+	clientMock.AssertExpectations(t)
+	_, err = os.Stat(filepath.Join(destination, "large.bin"))
+	assert.True(t, os.IsNotExist(err), "the partial download is deleted")
+}
@@ -0,0 +1,73 @@
+// Copyright 2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package gitresource
+
+import (
+	"fmt"
+
+	"github.com/aws/amazon-ssm-agent/agent/fileutil/filemanager"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+)
+
+// totalSizeExceededError aborts a download that would save more bytes than its MaxTotalBytes
+type totalSizeExceededError struct {
+	destination string
+	limit       int64
+}
+
+func (e *totalSizeExceededError) Error() string {
+	return fmt.Sprintf("Saving %v exceeds the maxTotalBytes of %v bytes of the download, the files it saved are deleted", e.destination, e.limit)
+}
+
+// countSavedBytes adds size bytes saved to destination to the total of the download in progress,
+// failing once the total exceeds MaxTotalBytes. Downloads are not limited when it is 0.
+// A destination is remembered so it can be deleted even when it exceeds the limit, since a stream is counted once written.
+func (git *downloadContext) countSavedBytes(destination string, size int64) error {
+	git.savedLock.Lock()
+	defer git.savedLock.Unlock()
+	git.savedPaths = append(git.savedPaths, destination)
+	if git.savedBytes += size; git.Info.MaxTotalBytes > 0 && git.savedBytes > git.Info.MaxTotalBytes {
+		return &totalSizeExceededError{destination: destination, limit: git.Info.MaxTotalBytes}
+	}
+	return nil
+}
+
+// reserveSavedBytes counts size bytes about to be saved to destination like countSavedBytes, before anything is written.
+// Nothing is counted when the file would exceed MaxTotalBytes, it is not saved.
+func (git *downloadContext) reserveSavedBytes(destination string, size int64) error {
+	git.savedLock.Lock()
+	defer git.savedLock.Unlock()
+	if git.Info.MaxTotalBytes > 0 && git.savedBytes+size > git.Info.MaxTotalBytes {
+		return &totalSizeExceededError{destination: destination, limit: git.Info.MaxTotalBytes}
+	}
+	git.savedBytes += size
+	git.savedPaths = append(git.savedPaths, destination)
+	return nil
+}
+
+// deleteSavedFiles deletes the files saved by the download in progress, a file that can't be deleted is only logged
+func (git *downloadContext) deleteSavedFiles(log log.T, filesys filemanager.FileSystem) {
+	git.savedLock.Lock()
+	defer git.savedLock.Unlock()
+	for _, destination := range git.savedPaths {
+		if !filesys.Exists(destination) {
+			continue
+		}
+		if err := filesys.DeleteFile(destination); err != nil {
+			log.Warnf("Could not delete %v saved by the download - %v", destination, err)
+		}
+	}
+	log.Infof("Deleted the %v files saved by the failed download", len(git.savedPaths))
+}
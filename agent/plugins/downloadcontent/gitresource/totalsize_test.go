@@ -0,0 +1,108 @@
+// Copyright 2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package gitresource
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/aws/amazon-ssm-agent/agent/fileutil/filemanager"
+	githubclientmock "github.com/aws/amazon-ssm-agent/agent/githubclient/mock"
+	"github.com/go-github/github"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// mockFiles expects the fetch of each of the files, all with content
+func mockFiles(clientMock *githubclientmock.ClientMock, opt *github.RepositoryContentGetOptions, content string, filePaths ...string) {
+	for _, filePath := range filePaths {
+		file := repositoryContent("file", filePath, len(content), "blob")
+		file.Content = &content
+		clientMock.On("GetRepositoryContents", logMock, "owner", "repo", filePath, opt).Return(file, []*github.RepositoryContent(nil), nil).Once()
+	}
+	clientMock.On("IsFileContentType", mock.AnythingOfType("*github.RepositoryContent")).Return(true)
+}
+
+func TestGitResource_DownloadFileLargerThanMaxTotalBytes(t *testing.T) {
+	clientMock := githubclientmock.ClientMock{}
+	opt := &github.RepositoryContentGetOptions{Ref: "master"}
+	clientMock.On("ParseGetOptions", logMock, "").Return(opt, nil)
+	mockFiles(&clientMock, opt, "content", "scripts/run.sh")
+
+	gitResource := NewResourceWithMockedClient(&clientMock)
+	gitResource.Info.Path = "scripts/run.sh"
+	gitResource.Info.MaxTotalBytes = 3
+	filesys := filemanager.NewMemoryFileSystem()
+
+	err := gitResource.Download(logMock, filesys, "run.sh")
+
+	assert.EqualError(t, err, "Saving run.sh exceeds the maxTotalBytes of 3 bytes of the download, the files it saved are deleted")
+	assert.Empty(t, filesys.Files())
+}
+
+func TestGitResource_DownloadDirectoryExceedsMaxTotalBytes(t *testing.T) {
+	clientMock := githubclientmock.ClientMock{}
+	opt := &github.RepositoryContentGetOptions{Ref: "master"}
+	clientMock.On("ParseGetOptions", logMock, "").Return(opt, nil)
+	clientMock.On("GetRepositoryContents", logMock, "owner", "repo", "scripts", opt).Return((*github.RepositoryContent)(nil), []*github.RepositoryContent{
+		repositoryContent("dir", "scripts/lib", 0, "tree1"),
+		repositoryContent("file", "scripts/run.sh", 7, "blob1"),
+		repositoryContent("file", "scripts/setup.sh", 7, "blob2"),
+	}, nil).Once()
+	clientMock.On("GetRepositoryContents", logMock, "owner", "repo", "scripts/lib", opt).Return((*github.RepositoryContent)(nil), []*github.RepositoryContent{
+		repositoryContent("file", "scripts/lib/common.sh", 7, "blob3"),
+	}, nil).Once()
+	mockFiles(&clientMock, opt, "content", "scripts/lib/common.sh", "scripts/run.sh", "scripts/setup.sh")
+
+	gitResource := NewResourceWithMockedClient(&clientMock)
+	gitResource.Info.Path = "scripts"
+	// the first two files fit, the third one crosses the limit
+	gitResource.Info.MaxTotalBytes = 15
+	filesys := filemanager.NewMemoryFileSystem()
+
+	err := gitResource.Download(logMock, filesys, "destination")
+
+	assert.EqualError(t, err, "Saving "+filepath.Join("destination", "setup.sh")+" exceeds the maxTotalBytes of 15 bytes of the download, the files it saved are deleted")
+	clientMock.AssertExpectations(t)
+	assert.Empty(t, filesys.Files())
+}
+
+func TestGitResource_DownloadDirectoryWithinMaxTotalBytes(t *testing.T) {
+	clientMock := githubclientmock.ClientMock{}
+	opt := &github.RepositoryContentGetOptions{Ref: "master"}
+	clientMock.On("ParseGetOptions", logMock, "").Return(opt, nil)
+	clientMock.On("GetRepositoryContents", logMock, "owner", "repo", "scripts", opt).Return((*github.RepositoryContent)(nil), []*github.RepositoryContent{
+		repositoryContent("file", "scripts/run.sh", 7, "blob1"),
+		repositoryContent("file", "scripts/setup.sh", 7, "blob2"),
+	}, nil).Once()
+	mockFiles(&clientMock, opt, "content", "scripts/run.sh", "scripts/setup.sh")
+
+	gitResource := NewResourceWithMockedClient(&clientMock)
+	gitResource.Info.Path = "scripts"
+	gitResource.Info.MaxTotalBytes = 14
+	filesys := filemanager.NewMemoryFileSystem()
+
+	err := gitResource.Download(logMock, filesys, "destination")
+
+	assert.NoError(t, err)
+	assert.Len(t, filesys.Files(), 2)
+}
+
+func TestGitResource_ValidateLocationInfoMaxTotalBytes(t *testing.T) {
+	gitResource := &GitResource{Info: GitInfo{Owner: "owner", Repository: "repo", MaxTotalBytes: -1}}
+	valid, err := gitResource.ValidateLocationInfo()
+	assert.False(t, valid)
+	assert.EqualError(t, err, "MaxTotalBytes for GitHub SourceType can't be negative")
+}
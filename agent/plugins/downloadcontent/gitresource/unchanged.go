@@ -0,0 +1,72 @@
+// Copyright 2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package gitresource
+
+import (
+	"crypto/sha1"
+	"fmt"
+	"path/filepath"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/fileutil/filemanager"
+	"github.com/aws/amazon-ssm-agent/agent/jsonutil"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+)
+
+// downloadRecordDir holds the records of the files downloaded with skipUnchanged
+var downloadRecordDir = filepath.Join(appconfig.DownloadRoot, "gitresource")
+
+// downloadRecord is the git blob SHA of the last download of a file at a ref and where it was saved
+type downloadRecord struct {
+	Sha       string `json:"sha"`
+	LocalPath string `json:"localPath"`
+}
+
+// downloadRecordPath returns the path of the record of owner/repository/filePath at ref
+func downloadRecordPath(owner, repository, filePath, ref string) string {
+	key := sha1.Sum([]byte(fmt.Sprintf("%v/%v/%v@%v", owner, repository, filePath, ref)))
+	return filepath.Join(downloadRecordDir, fmt.Sprintf("%x.json", key))
+}
+
+// isUnchanged returns true if the file was last downloaded to destination with the given blob SHA and is still there
+func isUnchanged(log log.T, filesys filemanager.FileSystem, recordPath, sha, destination string) bool {
+	if sha == "" || !filesys.Exists(recordPath) {
+		return false
+	}
+	content, err := filesys.ReadFile(recordPath)
+	if err != nil {
+		log.Debugf("Could not read download record %v - %v", recordPath, err)
+		return false
+	}
+	var record downloadRecord
+	if err = jsonutil.Unmarshal(content, &record); err != nil {
+		log.Debugf("Ignoring invalid download record %v - %v", recordPath, err)
+		return false
+	}
+	return record.Sha == sha && record.LocalPath == destination && filesys.Exists(destination)
+}
+
+// recordDownload stores the blob SHA of a downloaded file, a failure only means the next run downloads it again
+func recordDownload(log log.T, filesys filemanager.FileSystem, recordPath, sha, destination string) {
+	content, err := jsonutil.Marshal(downloadRecord{Sha: sha, LocalPath: destination})
+	if err == nil {
+		if err = filesys.MakeDirs(filepath.Dir(recordPath)); err == nil {
+			err = filesys.WriteFile(recordPath, content)
+		}
+	}
+	if err != nil {
+		log.Warnf("Could not record download of %v - %v", destination, err)
+	}
+}
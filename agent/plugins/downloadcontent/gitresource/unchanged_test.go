@@ -0,0 +1,84 @@
+// Copyright 2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package gitresource
+
+import (
+	"path/filepath"
+	"testing"
+
+	filemock "github.com/aws/amazon-ssm-agent/agent/fileutil/filemanager/mock"
+	githubclientmock "github.com/aws/amazon-ssm-agent/agent/githubclient/mock"
+	"github.com/go-github/github"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestGitResource_DownloadSkipUnchanged(t *testing.T) {
+	destination := filepath.Join("destination", "file.ext")
+	recordPath := downloadRecordPath("owner", "repo", "path/to/file.ext", "master")
+
+	data := []struct {
+		name          string
+		record        string
+		destExists    bool
+		expectedWrite bool
+	}{
+		{"unchanged", `{"sha": "blob1", "localPath": "` + destination + `"}`, true, false},
+		{"changed", `{"sha": "blob0", "localPath": "` + destination + `"}`, true, true},
+		{"local file removed", `{"sha": "blob1", "localPath": "` + destination + `"}`, false, true},
+		{"never downloaded", "", false, true},
+	}
+	for _, testdata := range data {
+		t.Run(testdata.name, func(t *testing.T) {
+			clientMock := githubclientmock.ClientMock{}
+			opt := &github.RepositoryContentGetOptions{Ref: "master"}
+			content := "content"
+			file := "file"
+			gitpath := "path/to/file.ext"
+			sha := "blob1"
+			fileMetadata := github.RepositoryContent{Content: &content, Type: &file, Path: &gitpath, SHA: &sha}
+			clientMock.On("ParseGetOptions", logMock, "").Return(opt, nil)
+			clientMock.On("GetRepositoryContents", logMock, "owner", "repo", "path/to/file.ext", opt).Return(&fileMetadata, []*github.RepositoryContent(nil), nil)
+			clientMock.On("IsFileContentType", mock.AnythingOfType("*github.RepositoryContent")).Return(true)
+
+			fileMock := filemock.FileSystemMock{}
+			fileMock.On("Exists", destination).Return(testdata.destExists)
+			if testdata.destExists {
+				fileMock.On("IsDirectory", destination).Return(false)
+			}
+			fileMock.On("Exists", recordPath).Return(testdata.record != "")
+			if testdata.record != "" {
+				fileMock.On("ReadFile", recordPath).Return(testdata.record, nil)
+			}
+			if testdata.expectedWrite {
+				fileMock.On("MakeDirs", "destination").Return(nil)
+				fileMock.On("WriteFile", destination, content).Return(nil).Once()
+				fileMock.On("MakeDirs", downloadRecordDir).Return(nil)
+				fileMock.On("WriteFile", recordPath, `{"sha":"blob1","localPath":"`+destination+`"}`).Return(nil).Once()
+			}
+
+			gitResource := NewResourceWithMockedClient(&clientMock)
+			gitResource.Info.SkipUnchanged = true
+
+			err := gitResource.Download(logMock, fileMock, destination)
+
+			assert.NoError(t, err)
+			fileMock.AssertExpectations(t)
+			if !testdata.expectedWrite {
+				fileMock.AssertNotCalled(t, "WriteFile", mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
@@ -0,0 +1,125 @@
+// Copyright 2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package gitresource
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/aws/amazon-ssm-agent/agent/fileutil/filemanager"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/aws/amazon-ssm-agent/agent/platform"
+)
+
+const (
+	// defaultValuesTarget is the name the selected values file is saved as when no target is given
+	defaultValuesTarget = "values.yaml"
+	// defaultValuesPlatform is the key of the values file used when none matches the platform
+	defaultValuesPlatform = "default"
+)
+
+// ValuesOverlay selects one of several values files of a downloaded directory, e.g. a Helm chart, for the platform of the instance
+type ValuesOverlay struct {
+	// Files maps a platform to its values file, relative to the downloaded directory.
+	// The platform name (e.g. "ubuntu" or "amazon-linux-ami"), the operating system ("linux", "windows" or "darwin")
+	// and then "default" are looked up in that order.
+	Files map[string]string `json:"files"`
+	// Target is the name the selected values file is copied to in the destination, values.yaml by default
+	Target string `json:"target"`
+}
+
+// platformKeys returns the keys values files are looked up with, from the most to the least specific platform
+var platformKeys = func(log log.T) []string {
+	var keys []string
+	if name, err := platform.PlatformName(log); err != nil {
+		log.Debugf("Could not detect the platform name, selecting values files by operating system - %v", err)
+	} else if name != "" {
+		keys = append(keys, normalizePlatformKey(name))
+	}
+	return append(keys, runtime.GOOS, defaultValuesPlatform)
+}
+
+// normalizePlatformKey lowercases a platform name and replaces its spaces with dashes
+func normalizePlatformKey(name string) string {
+	return strings.Join(strings.Fields(strings.ToLower(name)), "-")
+}
+
+// validateValuesOverlay ensures the values files and target stay within the downloaded directory
+func validateValuesOverlay(overlay *ValuesOverlay) error {
+	if len(overlay.Files) == 0 {
+		return errors.New("ValuesOverlay for GitHub SourceType must specify files")
+	}
+	for key, file := range overlay.Files {
+		cleaned := path.Clean(filepath.ToSlash(file))
+		if file == "" || path.IsAbs(cleaned) || cleaned == ".." || strings.HasPrefix(cleaned, "../") {
+			return fmt.Errorf("ValuesOverlay file %v of %v for GitHub SourceType must be relative to the downloaded directory", file, key)
+		}
+	}
+	if overlay.Target != "" && (strings.ContainsAny(overlay.Target, `/\`) || overlay.Target == "." || overlay.Target == "..") {
+		return fmt.Errorf("ValuesOverlay target %v for GitHub SourceType must be a file name", overlay.Target)
+	}
+	return nil
+}
+
+// selectValuesFile returns the platform key and values file of the first of keys the overlay has a file for
+func selectValuesFile(overlay *ValuesOverlay, keys []string) (string, string, error) {
+	files := make(map[string]string, len(overlay.Files))
+	for key, file := range overlay.Files {
+		files[normalizePlatformKey(key)] = file
+	}
+	for _, key := range keys {
+		if file, found := files[key]; found {
+			return key, file, nil
+		}
+	}
+	return "", "", fmt.Errorf("No values file matches the platform, looked up %v", strings.Join(keys, ", "))
+}
+
+// applyValuesOverlay copies the values file selected for the platform to the target name in the downloaded directory
+func (git *downloadContext) applyValuesOverlay(log log.T, filesys filemanager.FileSystem, overlay *ValuesOverlay, destinationDir string) error {
+	if !filesys.IsDirectory(destinationDir) {
+		return errors.New("ValuesOverlay requires path to be a directory")
+	}
+	key, file, err := selectValuesFile(overlay, platformKeys(log))
+	if err != nil {
+		return err
+	}
+	target := overlay.Target
+	if target == "" {
+		target = defaultValuesTarget
+	}
+
+	source, err := filemanager.SafeJoin(destinationDir, file)
+	if err != nil {
+		return err
+	}
+	destination, err := filemanager.SafeJoin(destinationDir, target)
+	if err != nil {
+		return err
+	}
+	content, err := filesys.ReadFile(source)
+	if err != nil {
+		return fmt.Errorf("Values file %v selected for %v was not downloaded - %v", file, key, err)
+	}
+	if err = filesys.WriteFile(destination, content); err != nil {
+		return err
+	}
+	log.Infof("Using values file %v selected for %v as %v", file, key, target)
+	return nil
+}
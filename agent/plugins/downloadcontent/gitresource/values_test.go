@@ -0,0 +1,186 @@
+// Copyright 2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package gitresource
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/aws/amazon-ssm-agent/agent/fileutil/filemanager"
+	githubclientmock "github.com/aws/amazon-ssm-agent/agent/githubclient/mock"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/go-github/github"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+var chartValuesFiles = map[string]string{
+	"Ubuntu":  "values/ubuntu.yaml",
+	"linux":   "values/linux.yaml",
+	"windows": "values/windows.yaml",
+	"default": "values/default.yaml",
+}
+
+func TestSelectValuesFile(t *testing.T) {
+	data := []struct {
+		name         string
+		keys         []string
+		expectedKey  string
+		expectedFile string
+	}{
+		{"platform name", []string{"ubuntu", "linux", "default"}, "ubuntu", "values/ubuntu.yaml"},
+		{"operating system", []string{"amazon-linux-ami", "linux", "default"}, "linux", "values/linux.yaml"},
+		{"windows", []string{"microsoft-windows-server-2016-datacenter", "windows", "default"}, "windows", "values/windows.yaml"},
+		{"default", []string{"mac-os-x", "darwin", "default"}, "default", "values/default.yaml"},
+	}
+	for _, testdata := range data {
+		t.Run(testdata.name, func(t *testing.T) {
+			key, file, err := selectValuesFile(&ValuesOverlay{Files: chartValuesFiles}, testdata.keys)
+			assert.NoError(t, err)
+			assert.Equal(t, testdata.expectedKey, key)
+			assert.Equal(t, testdata.expectedFile, file)
+		})
+	}
+
+	_, _, err := selectValuesFile(&ValuesOverlay{Files: map[string]string{"windows": "values/windows.yaml"}}, []string{"ubuntu", "linux", "default"})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "ubuntu, linux, default")
+}
+
+func TestNormalizePlatformKey(t *testing.T) {
+	assert.Equal(t, "amazon-linux-ami", normalizePlatformKey("Amazon Linux AMI"))
+	assert.Equal(t, "ubuntu", normalizePlatformKey(" Ubuntu "))
+}
+
+func TestGitResource_DownloadValuesOverlay(t *testing.T) {
+	data := []struct {
+		name           string
+		platformKeys   []string
+		target         string
+		expectedTarget string
+		expectedValues string
+	}{
+		{"ubuntu", []string{"ubuntu", "linux", "default"}, "", "values.yaml", "ubuntu values"},
+		{"other linux", []string{"centos", "linux", "default"}, "", "values.yaml", "linux values"},
+		{"windows with target name", []string{"microsoft-windows-server-2016", "windows", "default"}, "platform-values.yaml", "platform-values.yaml", "windows values"},
+	}
+	for _, testdata := range data {
+		t.Run(testdata.name, func(t *testing.T) {
+			clientMock := githubclientmock.ClientMock{}
+			opt := &github.RepositoryContentGetOptions{Ref: ""}
+			file, dir := "file", "dir"
+			chartPath, valuesPath := "charts/app", "charts/app/values"
+			repositoryFiles := map[string]string{
+				"charts/app/Chart.yaml":          "name: app",
+				"charts/app/values.yaml":         "chart values",
+				"charts/app/values/ubuntu.yaml":  "ubuntu values",
+				"charts/app/values/linux.yaml":   "linux values",
+				"charts/app/values/windows.yaml": "windows values",
+				"charts/app/values/default.yaml": "default values",
+			}
+			var chartEntries, valuesEntries []*github.RepositoryContent
+			for path, content := range repositoryFiles {
+				path, content := path, content
+				metadata := &github.RepositoryContent{Content: &content, Type: &file, Path: &path}
+				if filepath.Dir(path) == chartPath {
+					chartEntries = append(chartEntries, metadata)
+				} else {
+					valuesEntries = append(valuesEntries, metadata)
+				}
+				clientMock.On("GetRepositoryContents", logMock, "owner", "repo", path, opt).Return(metadata, []*github.RepositoryContent(nil), nil).Once()
+			}
+			chartEntries = append(chartEntries, &github.RepositoryContent{Type: &dir, Path: &valuesPath})
+			clientMock.On("ParseGetOptions", logMock, "").Return(opt, nil)
+			clientMock.On("GetRepositoryContents", logMock, "owner", "repo", chartPath, opt).Return((*github.RepositoryContent)(nil), chartEntries, nil).Once()
+			clientMock.On("GetRepositoryContents", logMock, "owner", "repo", valuesPath, opt).Return((*github.RepositoryContent)(nil), valuesEntries, nil).Once()
+			clientMock.On("IsFileContentType", mock.AnythingOfType("*github.RepositoryContent")).Return(true)
+
+			originalPlatformKeys := platformKeys
+			platformKeys = func(log log.T) []string { return testdata.platformKeys }
+			defer func() { platformKeys = originalPlatformKeys }()
+
+			gitResource := NewResourceWithMockedClient(&clientMock)
+			gitResource.Info.Path = chartPath
+			gitResource.Info.ValuesOverlay = &ValuesOverlay{Files: chartValuesFiles, Target: testdata.target}
+			valid, err := gitResource.ValidateLocationInfo()
+			assert.True(t, valid)
+			assert.NoError(t, err)
+
+			filesys := filemanager.NewMemoryFileSystem()
+			destination := filepath.Join("/var", "tmp", "chart")
+			err = gitResource.Download(logMock, filesys, destination)
+
+			assert.NoError(t, err)
+			clientMock.AssertExpectations(t)
+			values, err := filesys.ReadFile(filepath.Join(destination, testdata.expectedTarget))
+			assert.NoError(t, err)
+			assert.Equal(t, testdata.expectedValues, values)
+			chart, err := filesys.ReadFile(filepath.Join(destination, "Chart.yaml"))
+			assert.NoError(t, err)
+			assert.Equal(t, "name: app", chart)
+		})
+	}
+}
+
+func TestGitResource_DownloadValuesOverlayRequiresDirectory(t *testing.T) {
+	clientMock := githubclientmock.ClientMock{}
+	opt := &github.RepositoryContentGetOptions{Ref: ""}
+	content, file, path := "name: app", "file", "path/to/file.ext"
+	metadata := &github.RepositoryContent{Content: &content, Type: &file, Path: &path}
+	clientMock.On("ParseGetOptions", logMock, "").Return(opt, nil)
+	clientMock.On("GetRepositoryContents", logMock, "owner", "repo", path, opt).Return(metadata, []*github.RepositoryContent(nil), nil).Once()
+	clientMock.On("IsFileContentType", mock.AnythingOfType("*github.RepositoryContent")).Return(true)
+
+	gitResource := NewResourceWithMockedClient(&clientMock)
+	gitResource.Info.ValuesOverlay = &ValuesOverlay{Files: chartValuesFiles}
+
+	err := gitResource.Download(logMock, filemanager.NewMemoryFileSystem(), "/var/tmp/file.ext")
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "requires path to be a directory")
+}
+
+func TestGitResource_ValidateLocationInfoValuesOverlay(t *testing.T) {
+	data := []struct {
+		name          string
+		overlay       ValuesOverlay
+		flatten       bool
+		expectedError string
+	}{
+		{"valid", ValuesOverlay{Files: chartValuesFiles, Target: "values.yaml"}, false, ""},
+		{"no files", ValuesOverlay{}, false, "must specify files"},
+		{"file out of the directory", ValuesOverlay{Files: map[string]string{"linux": "../values.yaml"}}, false, "must be relative to the downloaded directory"},
+		{"absolute file", ValuesOverlay{Files: map[string]string{"linux": "/etc/values.yaml"}}, false, "must be relative to the downloaded directory"},
+		{"target in a directory", ValuesOverlay{Files: chartValuesFiles, Target: "../values.yaml"}, false, "must be a file name"},
+		{"flattened", ValuesOverlay{Files: chartValuesFiles}, true, "can't be combined with concatenate or flatten"},
+	}
+	for _, testdata := range data {
+		t.Run(testdata.name, func(t *testing.T) {
+			overlay := testdata.overlay
+			gitResource := &GitResource{
+				Info: GitInfo{Owner: "owner", Repository: "repo", Path: "charts/app", Flatten: testdata.flatten, ValuesOverlay: &overlay},
+			}
+			valid, err := gitResource.ValidateLocationInfo()
+			if testdata.expectedError == "" {
+				assert.True(t, valid)
+				assert.NoError(t, err)
+			} else {
+				assert.False(t, valid)
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), testdata.expectedError)
+			}
+		})
+	}
+}
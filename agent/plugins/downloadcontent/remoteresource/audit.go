@@ -0,0 +1,192 @@
+// Copyright 2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package remoteresource
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/fileutil/filemanager"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+)
+
+const (
+	// AuditSucceeded and AuditFailed are the outcomes of an audited download
+	AuditSucceeded = "Succeeded"
+	AuditFailed    = "Failed"
+
+	// auditLogFileName is the file, in the agent log directory, the shared audit sink appends to
+	auditLogFileName = "download-audit.log"
+)
+
+// AuditRecord is the record of one remote resource download.
+// It describes the resource by its location only, credentials such as tokenInfo are never part of it.
+type AuditRecord struct {
+	// MessageID identifies the command or association that requested the download
+	MessageID   string    `json:"messageId"`
+	PluginID    string    `json:"pluginId"`
+	SourceType  string    `json:"sourceType"`
+	Source      string    `json:"source"`
+	Path        string    `json:"path"`
+	Ref         string    `json:"ref,omitempty"`
+	Destination string    `json:"destination"`
+	StartTime   time.Time `json:"startTime"`
+	EndTime     time.Time `json:"endTime"`
+	Outcome     string    `json:"outcome"`
+	Error       string    `json:"error,omitempty"`
+	// Bytes is the size of the content written to the destination
+	Bytes int64 `json:"bytes"`
+}
+
+// AuditLocation is where an audited resource is downloaded from
+type AuditLocation struct {
+	Source string
+	Path   string
+	Ref    string
+}
+
+// AuditedResource is implemented by remote resources that can describe their location for audit records.
+// The location must not hold credentials.
+type AuditedResource interface {
+	AuditLocation() AuditLocation
+}
+
+// AuditSink receives the audit record of every remote resource download
+type AuditSink interface {
+	Record(record AuditRecord) error
+}
+
+// fileAuditSink appends audit records to a file, one JSON object per line
+type fileAuditSink struct {
+	path string
+	lock sync.Mutex
+}
+
+// NewFileAuditSink returns an AuditSink appending records to the file at path
+func NewFileAuditSink(path string) AuditSink {
+	return &fileAuditSink{path: path}
+}
+
+// Record appends record to the audit file, records already written are never modified
+func (sink *fileAuditSink) Record(record AuditRecord) error {
+	line, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	sink.lock.Lock()
+	defer sink.lock.Unlock()
+	if err = os.MkdirAll(filepath.Dir(sink.path), 0700); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(sink.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	_, err = file.Write(append(line, '\n'))
+	return err
+}
+
+var (
+	sharedAuditSink     AuditSink
+	sharedAuditSinkLock sync.Mutex
+)
+
+// SharedAuditSink returns the audit sink of the agent, which appends to a file in the agent log directory
+func SharedAuditSink() AuditSink {
+	sharedAuditSinkLock.Lock()
+	defer sharedAuditSinkLock.Unlock()
+	if sharedAuditSink == nil {
+		sharedAuditSink = NewFileAuditSink(filepath.Join(log.DefaultLogDir, auditLogFileName))
+	}
+	return sharedAuditSink
+}
+
+// SetSharedAuditSink replaces the audit sink returned by SharedAuditSink
+func SetSharedAuditSink(sink AuditSink) {
+	sharedAuditSinkLock.Lock()
+	defer sharedAuditSinkLock.Unlock()
+	sharedAuditSink = sink
+}
+
+// DownloadAudited downloads resource to destinationDir and records the download in sink.
+// record holds who requested the download, the location, outcome and size of the download are filled in.
+// The download isn't audited when sink is nil, failing to record it is logged and doesn't fail the download.
+func DownloadAudited(log log.T, sink AuditSink, record AuditRecord, resource RemoteResource, filesys filemanager.FileSystem, destinationDir string) error {
+	if sink == nil {
+		return resource.Download(log, filesys, destinationDir)
+	}
+	if audited, ok := resource.(AuditedResource); ok {
+		location := audited.AuditLocation()
+		record.Source, record.Path, record.Ref = location.Source, location.Path, location.Ref
+	}
+	record.Destination = destinationDir
+	counter := &countingFileSystem{FileSystem: filesys}
+	record.StartTime = time.Now().UTC()
+	err := resource.Download(log, counter, destinationDir)
+	record.EndTime = time.Now().UTC()
+	record.Bytes = counter.written
+	record.Outcome = AuditSucceeded
+	if err != nil {
+		record.Outcome = AuditFailed
+		record.Error = err.Error()
+	}
+	if auditErr := sink.Record(record); auditErr != nil {
+		log.Warnf("Could not record the download of %v in the audit log - %v", record.Source, auditErr)
+	}
+	return err
+}
+
+// countingFileSystem counts the bytes written through it
+type countingFileSystem struct {
+	filemanager.FileSystem
+	lock    sync.Mutex
+	written int64
+}
+
+func (filesys *countingFileSystem) WriteFile(filename string, content string) error {
+	err := filesys.FileSystem.WriteFile(filename, content)
+	if err == nil {
+		filesys.add(int64(len(content)))
+	}
+	return err
+}
+
+func (filesys *countingFileSystem) WriteStream(filename string, content io.Reader) (int64, error) {
+	written, err := filesys.FileSystem.WriteStream(filename, content)
+	filesys.add(written)
+	return written, err
+}
+
+// MoveAndRenameFile counts the files downloaded elsewhere, such as S3 artifacts, when they are moved to the destination
+func (filesys *countingFileSystem) MoveAndRenameFile(sourcePath, sourceName, destPath, destName string) (bool, error) {
+	result, err := filesys.FileSystem.MoveAndRenameFile(sourcePath, sourceName, destPath, destName)
+	if err == nil {
+		if info, statErr := os.Stat(filepath.Join(destPath, destName)); statErr == nil && !info.IsDir() {
+			filesys.add(info.Size())
+		}
+	}
+	return result, err
+}
+
+func (filesys *countingFileSystem) add(written int64) {
+	filesys.lock.Lock()
+	defer filesys.lock.Unlock()
+	filesys.written += written
+}
@@ -0,0 +1,141 @@
+// Copyright 2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package remoteresource
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/aws/amazon-ssm-agent/agent/fileutil/filemanager"
+	filemock "github.com/aws/amazon-ssm-agent/agent/fileutil/filemanager/mock"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/stretchr/testify/assert"
+)
+
+const auditSecret = "{{ssm-secure:github-token}}"
+
+// auditedResourceStub writes content to the destination and holds a credential that must not be audited
+type auditedResourceStub struct {
+	tokenInfo   string
+	content     string
+	downloadErr error
+}
+
+func (resource auditedResourceStub) Download(log log.T, filesys filemanager.FileSystem, destinationDir string) error {
+	if err := filesys.WriteFile(filepath.Join(destinationDir, "file.sh"), resource.content); err != nil {
+		return err
+	}
+	return resource.downloadErr
+}
+
+func (resource auditedResourceStub) ValidateLocationInfo() (bool, error) {
+	return true, nil
+}
+
+func (resource auditedResourceStub) AuditLocation() AuditLocation {
+	return AuditLocation{Source: "owner/repository", Path: "scripts/file.sh", Ref: "branch:main"}
+}
+
+type auditSinkStub struct {
+	records []AuditRecord
+	err     error
+}
+
+func (sink *auditSinkStub) Record(record AuditRecord) error {
+	sink.records = append(sink.records, record)
+	return sink.err
+}
+
+func TestDownloadAudited(t *testing.T) {
+	data := []struct {
+		name            string
+		downloadErr     error
+		sinkErr         error
+		expectedOutcome string
+		expectedError   string
+	}{
+		{"success", nil, nil, AuditSucceeded, ""},
+		{"failure", errors.New("Repository is unreachable"), nil, AuditFailed, "Repository is unreachable"},
+		{"sink failure does not fail download", nil, errors.New("disk full"), AuditSucceeded, ""},
+	}
+	for _, testdata := range data {
+		t.Run(testdata.name, func(t *testing.T) {
+			fileMock := filemock.FileSystemMock{}
+			fileMock.On("WriteFile", filepath.Join("destination", "file.sh"), "echo hello").Return(nil).Once()
+			resource := auditedResourceStub{tokenInfo: auditSecret, content: "echo hello", downloadErr: testdata.downloadErr}
+			sink := &auditSinkStub{err: testdata.sinkErr}
+			record := AuditRecord{MessageID: "aws.ssm.command-id.instance-id", PluginID: "downloadContent", SourceType: "GitHub"}
+
+			err := DownloadAudited(log.NewMockLog(), sink, record, resource, fileMock, "destination")
+
+			assert.Equal(t, testdata.downloadErr, err)
+			fileMock.AssertExpectations(t)
+			assert.Len(t, sink.records, 1)
+			audited := sink.records[0]
+			assert.Equal(t, "aws.ssm.command-id.instance-id", audited.MessageID)
+			assert.Equal(t, "downloadContent", audited.PluginID)
+			assert.Equal(t, "GitHub", audited.SourceType)
+			assert.Equal(t, "owner/repository", audited.Source)
+			assert.Equal(t, "scripts/file.sh", audited.Path)
+			assert.Equal(t, "branch:main", audited.Ref)
+			assert.Equal(t, "destination", audited.Destination)
+			assert.Equal(t, testdata.expectedOutcome, audited.Outcome)
+			assert.Equal(t, testdata.expectedError, audited.Error)
+			assert.Equal(t, int64(len("echo hello")), audited.Bytes)
+			assert.False(t, audited.StartTime.IsZero())
+			assert.False(t, audited.EndTime.Before(audited.StartTime))
+
+			line, _ := json.Marshal(audited)
+			assert.NotContains(t, string(line), auditSecret)
+		})
+	}
+}
+
+func TestDownloadAuditedWithoutSink(t *testing.T) {
+	fileMock := filemock.FileSystemMock{}
+	fileMock.On("WriteFile", filepath.Join("destination", "file.sh"), "echo hello").Return(nil).Once()
+
+	err := DownloadAudited(log.NewMockLog(), nil, AuditRecord{}, auditedResourceStub{content: "echo hello"}, fileMock, "destination")
+
+	assert.NoError(t, err)
+	fileMock.AssertExpectations(t)
+}
+
+func TestFileAuditSink_Record(t *testing.T) {
+	dir, err := ioutil.TempDir("", "audit")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	sink := NewFileAuditSink(filepath.Join(dir, "audit", auditLogFileName))
+
+	assert.NoError(t, sink.Record(AuditRecord{Source: "owner/first", Outcome: AuditSucceeded, Bytes: 10}))
+	assert.NoError(t, sink.Record(AuditRecord{Source: "owner/second", Outcome: AuditFailed, Error: "not found"}))
+
+	content, err := ioutil.ReadFile(filepath.Join(dir, "audit", auditLogFileName))
+	assert.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+	assert.Len(t, lines, 2)
+	var first, second AuditRecord
+	assert.NoError(t, json.Unmarshal([]byte(lines[0]), &first))
+	assert.NoError(t, json.Unmarshal([]byte(lines[1]), &second))
+	assert.Equal(t, "owner/first", first.Source)
+	assert.Equal(t, int64(10), first.Bytes)
+	assert.Equal(t, AuditFailed, second.Outcome)
+	assert.Equal(t, "not found", second.Error)
+}
@@ -0,0 +1,136 @@
+// Copyright 2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package remoteresource
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/aws/amazon-ssm-agent/agent/version"
+)
+
+// CapabilitiesSchemaVersion is the version of the structure of the capability report, raised when it changes incompatibly
+const CapabilitiesSchemaVersion = "1.0"
+
+// CapabilityReport describes the source types the running agent downloads from and the options each supports,
+// so documents can be authored for what the agent supports
+type CapabilityReport struct {
+	SchemaVersion string `json:"schemaVersion"`
+	AgentVersion  string `json:"agentVersion"`
+	// CommonOptions are the sourceInfo options every source type supports
+	CommonOptions []string           `json:"commonOptions"`
+	SourceTypes   []SourceCapability `json:"sourceTypes"`
+}
+
+// SourceCapability describes one source type
+type SourceCapability struct {
+	SourceType string `json:"sourceType"`
+	// Options are the sourceInfo options of the source type
+	Options []string `json:"options"`
+	// Features are the optional behaviors of the source type, e.g. signatureVerification
+	Features []string `json:"features"`
+}
+
+var capabilities = struct {
+	lock          sync.RWMutex
+	commonOptions []string
+	sourceTypes   map[string]SourceCapability
+}{sourceTypes: make(map[string]SourceCapability)}
+
+// RegisterSourceType adds a source type to the capability report, its options are the JSON names of the fields of sourceInfo
+func RegisterSourceType(sourceType string, sourceInfo interface{}, features ...string) {
+	capability := SourceCapability{SourceType: sourceType, Options: jsonOptions(sourceInfo), Features: append([]string{}, features...)}
+	sort.Strings(capability.Features)
+
+	capabilities.lock.Lock()
+	defer capabilities.lock.Unlock()
+	capabilities.sourceTypes[sourceType] = capability
+}
+
+// RegisterCommonOptions sets the options every source type supports, the JSON names of the fields of options
+func RegisterCommonOptions(options interface{}) {
+	common := jsonOptions(options)
+
+	capabilities.lock.Lock()
+	defer capabilities.lock.Unlock()
+	capabilities.commonOptions = common
+}
+
+// Capabilities returns the report of the registered source types, ordered by name
+func Capabilities() CapabilityReport {
+	capabilities.lock.RLock()
+	defer capabilities.lock.RUnlock()
+	report := CapabilityReport{
+		SchemaVersion: CapabilitiesSchemaVersion,
+		AgentVersion:  version.Version,
+		CommonOptions: append([]string{}, capabilities.commonOptions...),
+		SourceTypes:   make([]SourceCapability, 0, len(capabilities.sourceTypes)),
+	}
+	for _, capability := range capabilities.sourceTypes {
+		capability.Options = append([]string{}, capability.Options...)
+		capability.Features = append([]string{}, capability.Features...)
+		report.SourceTypes = append(report.SourceTypes, capability)
+	}
+	sort.Slice(report.SourceTypes, func(i, j int) bool { return report.SourceTypes[i].SourceType < report.SourceTypes[j].SourceType })
+	return report
+}
+
+// SourceType returns the capability of a registered source type
+func (report CapabilityReport) SourceType(sourceType string) (SourceCapability, bool) {
+	for _, capability := range report.SourceTypes {
+		if capability.SourceType == sourceType {
+			return capability, true
+		}
+	}
+	return SourceCapability{}, false
+}
+
+// jsonOptions returns the sorted JSON names of the fields of a struct, including those of its embedded structs
+func jsonOptions(value interface{}) []string {
+	options := []string{}
+	collectJSONOptions(reflect.TypeOf(value), &options)
+	sort.Strings(options)
+	return options
+}
+
+func collectJSONOptions(structType reflect.Type, options *[]string) {
+	for structType != nil && structType.Kind() == reflect.Ptr {
+		structType = structType.Elem()
+	}
+	if structType == nil || structType.Kind() != reflect.Struct {
+		return
+	}
+	for i := 0; i < structType.NumField(); i++ {
+		field := structType.Field(i)
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if name == "-" {
+			continue
+		}
+		if field.Anonymous && name == "" {
+			collectJSONOptions(field.Type, options)
+			continue
+		}
+		if field.PkgPath != "" {
+			// unexported fields aren't sourceInfo options
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		*options = append(*options, name)
+	}
+}
@@ -0,0 +1,59 @@
+// Copyright 2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package remoteresource
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testSourceInfo struct {
+	Path     string `json:"path"`
+	Ref      string `json:"ref,omitempty"`
+	Region   string
+	Ignored  string `json:"-"`
+	internal string
+	PostDownloadHook
+}
+
+func TestCapabilities(t *testing.T) {
+	originalSourceTypes, originalCommonOptions := capabilities.sourceTypes, capabilities.commonOptions
+	defer func() { capabilities.sourceTypes, capabilities.commonOptions = originalSourceTypes, originalCommonOptions }()
+	capabilities.sourceTypes = make(map[string]SourceCapability)
+
+	RegisterCommonOptions(struct {
+		Optional bool `json:"optional"`
+	}{})
+	RegisterSourceType("Test", testSourceInfo{}, "streaming", "archiveExtraction")
+	RegisterSourceType("Other", &testSourceInfo{})
+
+	report := Capabilities()
+
+	assert.Equal(t, CapabilitiesSchemaVersion, report.SchemaVersion)
+	assert.Equal(t, []string{"optional"}, report.CommonOptions)
+	assert.Equal(t, []SourceCapability{
+		{SourceType: "Other", Options: []string{"Region", "path", "postDownloadCommand", "postDownloadTimeoutSeconds", "ref"}, Features: []string{}},
+		{SourceType: "Test", Options: []string{"Region", "path", "postDownloadCommand", "postDownloadTimeoutSeconds", "ref"}, Features: []string{"archiveExtraction", "streaming"}},
+	}, report.SourceTypes)
+
+	// the report is a copy of the registry
+	report.SourceTypes[1].Features[0] = "changed"
+	test, found := Capabilities().SourceType("Test")
+	assert.True(t, found)
+	assert.Equal(t, "archiveExtraction", test.Features[0])
+	_, found = report.SourceType("Missing")
+	assert.False(t, found)
+}
@@ -0,0 +1,116 @@
+// Copyright 2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package remoteresource
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+)
+
+// executionRecordDir is where the shared execution store keeps its records.
+// It is with the agent data so the records survive agent restarts and download cleanups.
+var executionRecordDir = filepath.Join(appconfig.DefaultDataStorePath, "downloadcontent", "executions")
+
+// executionRecord is the hash of the content a download last ran successfully with
+type executionRecord struct {
+	Hash string    `json:"hash"`
+	Time time.Time `json:"time"`
+}
+
+// ExecutionKey identifies the download of a source to a destination across runs
+func ExecutionKey(sourceType, sourceInfo, destination string) string {
+	key := sha256.Sum256([]byte(fmt.Sprintf("%v\n%v\n%v", sourceType, sourceInfo, filepath.Clean(destination))))
+	return hex.EncodeToString(key[:])
+}
+
+// ContentHash returns the SHA-256 of the content downloaded to localPath.
+// The hash of a directory covers the relative path and content of every file in it.
+func ContentHash(localPath string) (string, error) {
+	hash := sha256.New()
+	err := filepath.Walk(localPath, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		relativePath, err := filepath.Rel(localPath, path)
+		if err != nil {
+			return err
+		}
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		fmt.Fprintf(hash, "%v\x00%v\x00", filepath.ToSlash(relativePath), info.Size())
+		_, err = io.Copy(hash, file)
+		return err
+	})
+	if err != nil {
+		return "", fmt.Errorf("Could not hash the content of %v - %v", localPath, err)
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// ExecutionStore records the content hash of the last successful run of downloads, one file per download in Dir
+type ExecutionStore struct {
+	Dir string
+}
+
+// SharedExecutionStore returns the execution store of the agent
+func SharedExecutionStore() *ExecutionStore {
+	return &ExecutionStore{Dir: executionRecordDir}
+}
+
+// HasChanged returns true unless the content hash is the one recorded by RecordExecution for the key.
+// A missing or unreadable record counts as changed, so the content runs again.
+func (store *ExecutionStore) HasChanged(log log.T, key, hash string) bool {
+	recordPath := filepath.Join(store.Dir, key+".json")
+	content, err := ioutil.ReadFile(recordPath)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Debugf("Could not read execution record %v - %v", recordPath, err)
+		}
+		return true
+	}
+	var record executionRecord
+	if err = json.Unmarshal(content, &record); err != nil {
+		log.Debugf("Ignoring invalid execution record %v - %v", recordPath, err)
+		return true
+	}
+	return record.Hash != hash
+}
+
+// RecordExecution stores the content hash a download ran successfully with, a failure only means the content runs again next time
+func (store *ExecutionStore) RecordExecution(log log.T, key, hash string) {
+	recordPath := filepath.Join(store.Dir, key+".json")
+	content, err := json.Marshal(executionRecord{Hash: hash, Time: time.Now().UTC()})
+	if err == nil {
+		if err = os.MkdirAll(store.Dir, appconfig.ReadWriteExecuteAccess); err == nil {
+			err = ioutil.WriteFile(recordPath, content, appconfig.ReadWriteAccess)
+		}
+	}
+	if err != nil {
+		log.Warnf("Could not record the execution of %v - %v", key, err)
+	}
+}
@@ -0,0 +1,76 @@
+// Copyright 2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package remoteresource
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContentHash(t *testing.T) {
+	root, err := ioutil.TempDir("", "contenthash")
+	assert.NoError(t, err)
+	defer os.RemoveAll(root)
+	script := filepath.Join(root, "scripts", "install.sh")
+	assert.NoError(t, os.MkdirAll(filepath.Dir(script), 0700))
+	assert.NoError(t, ioutil.WriteFile(script, []byte("echo install"), 0600))
+
+	fileHash, err := ContentHash(script)
+	assert.NoError(t, err)
+	dirHash, err := ContentHash(root)
+	assert.NoError(t, err)
+	assert.NotEqual(t, fileHash, dirHash)
+
+	// the same content hashes the same
+	again, err := ContentHash(root)
+	assert.NoError(t, err)
+	assert.Equal(t, dirHash, again)
+
+	// renaming a file changes the hash of its directory
+	assert.NoError(t, os.Rename(script, filepath.Join(root, "scripts", "setup.sh")))
+	renamed, err := ContentHash(root)
+	assert.NoError(t, err)
+	assert.NotEqual(t, dirHash, renamed)
+
+	_, err = ContentHash(filepath.Join(root, "missing"))
+	assert.Error(t, err)
+}
+
+func TestExecutionStore_HasChanged(t *testing.T) {
+	dir, err := ioutil.TempDir("", "executions")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	store := &ExecutionStore{Dir: filepath.Join(dir, "records")}
+	logger := log.NewMockLog()
+	key := ExecutionKey("GitHub", `{"owner": "owner"}`, "/var/tmp/destination")
+
+	assert.True(t, store.HasChanged(logger, key, "hash1"), "content never run has changed")
+
+	store.RecordExecution(logger, key, "hash1")
+	assert.False(t, store.HasChanged(logger, key, "hash1"), "unchanged content")
+	assert.True(t, store.HasChanged(logger, key, "hash2"), "changed content")
+	assert.True(t, store.HasChanged(logger, ExecutionKey("GitHub", `{"owner": "other"}`, "/var/tmp/destination"), "hash1"), "other source")
+
+	// records persist, a new store over the same directory sees them
+	assert.False(t, (&ExecutionStore{Dir: store.Dir}).HasChanged(logger, key, "hash1"))
+
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(store.Dir, key+".json"), []byte("{not json"), 0600))
+	assert.True(t, store.HasChanged(logger, key, "hash1"), "invalid record")
+}
@@ -0,0 +1,115 @@
+// Copyright 2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package remoteresource
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/aws/amazon-ssm-agent/agent/fileutil/filemanager"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/aws/amazon-ssm-agent/agent/parameters"
+)
+
+// parameterReference matches a {{ name }} parameter reference
+var parameterReference = regexp.MustCompile(`{{\s*([^{}]*?)\s*}}`)
+
+// ResolveDestination substitutes the parameters of the command for the {{ name }} references in destination,
+// so the destination may be composed from other parameters. References left unresolved are an error.
+// The resolved destination must still be validated against the allowed roots.
+func ResolveDestination(log log.T, destination string, params map[string]interface{}) (string, error) {
+	if !parameterReference.MatchString(destination) {
+		return destination, nil
+	}
+	resolved, ok := parameters.ReplaceParameters(destination, parameters.ValidParameters(log, params), log).(string)
+	if !ok {
+		return "", fmt.Errorf("Destination %v does not resolve to a path", destination)
+	}
+	if unresolved := parameterReference.FindAllStringSubmatch(resolved, -1); len(unresolved) > 0 {
+		names := make([]string, 0, len(unresolved))
+		for _, match := range unresolved {
+			names = append(names, match[1])
+		}
+		return "", fmt.Errorf("Destination %v has unresolved parameter references %v", destination, strings.Join(names, ", "))
+	}
+	log.Debugf("Destination %v resolved to %v", destination, resolved)
+	return resolved, nil
+}
+
+// FileDestination returns where a single file downloaded from filePath of a repository is saved.
+// If the destination has a path separator in the end, or the folder already exists, then the file is appended to the directory.
+func FileDestination(filesys filemanager.FileSystem, destination string, filePath string) string {
+	if (filesys.Exists(destination) && filesys.IsDirectory(destination)) || os.IsPathSeparator(destination[len(destination)-1]) {
+		return filepath.Join(destination, path.Base(filepath.ToSlash(filePath)))
+	}
+	return destination
+}
+
+// ValidateDestination ensures destinationDir resolves to a directory within one of allowedRoots.
+// Symbolic links in the existing part of the paths are followed, so links cannot be used to escape the roots.
+func ValidateDestination(destinationDir string, allowedRoots []string) error {
+	destination, err := resolvePath(destinationDir)
+	if err != nil {
+		return fmt.Errorf("Could not resolve destination %v - %v", destinationDir, err)
+	}
+	for _, root := range allowedRoots {
+		if root == "" {
+			continue
+		}
+		resolvedRoot, err := resolvePath(root)
+		if err != nil {
+			continue
+		}
+		if isWithin(resolvedRoot, destination) {
+			return nil
+		}
+	}
+	return fmt.Errorf("Destination %v is not within the allowed download roots %v", destinationDir, allowedRoots)
+}
+
+// resolvePath returns the absolute path with symbolic links of its longest existing prefix evaluated
+func resolvePath(path string) (string, error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	existing, rest := absPath, ""
+	for {
+		if resolved, err := filepath.EvalSymlinks(existing); err == nil {
+			return filepath.Join(resolved, rest), nil
+		} else if !os.IsNotExist(err) {
+			return "", err
+		}
+		parent := filepath.Dir(existing)
+		if parent == existing {
+			return absPath, nil
+		}
+		rest = filepath.Join(filepath.Base(existing), rest)
+		existing = parent
+	}
+}
+
+// isWithin returns true if path is root or one of its descendants
+func isWithin(root, path string) bool {
+	rel, err := filepath.Rel(root, path)
+	if err != nil {
+		return false
+	}
+	return rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
@@ -0,0 +1,143 @@
+// Copyright 2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package remoteresource
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/aws/amazon-ssm-agent/agent/fileutil/filemanager"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFileDestination(t *testing.T) {
+	filesys := filemanager.NewMemoryFileSystem()
+	filesys.MakeDirs(filepath.Join("downloads", "existing"))
+	data := []struct {
+		name        string
+		destination string
+		expected    string
+	}{
+		{"file path", filepath.Join("downloads", "renamed.sh"), filepath.Join("downloads", "renamed.sh")},
+		{"existing directory", filepath.Join("downloads", "existing"), filepath.Join("downloads", "existing", "run.sh")},
+		{"trailing separator", filepath.Join("downloads", "new") + string(filepath.Separator), filepath.Join("downloads", "new", "run.sh")},
+	}
+	for _, testdata := range data {
+		t.Run(testdata.name, func(t *testing.T) {
+			assert.Equal(t, testdata.expected, FileDestination(filesys, testdata.destination, "scripts/run.sh"))
+		})
+	}
+}
+
+func TestValidateDestination(t *testing.T) {
+	root, err := ioutil.TempDir("", "allowedroot")
+	assert.NoError(t, err)
+	defer os.RemoveAll(root)
+	outside, err := ioutil.TempDir("", "outside")
+	assert.NoError(t, err)
+	defer os.RemoveAll(outside)
+	assert.NoError(t, os.Mkdir(filepath.Join(root, "existing"), 0700))
+
+	data := []struct {
+		name        string
+		destination string
+		valid       bool
+	}{
+		{"root itself", root, true},
+		{"existing directory in root", filepath.Join(root, "existing"), true},
+		{"new directory in root", filepath.Join(root, "new", "dir"), true},
+		{"directory out of root", outside, false},
+		{"new directory out of root", filepath.Join(outside, "new"), false},
+		{"parent references escaping root", filepath.Join(root, "existing") + string(filepath.Separator) + filepath.Join("..", "..", filepath.Base(outside)), false},
+		{"root name prefix", root + "sibling", false},
+	}
+	for _, testdata := range data {
+		t.Run(testdata.name, func(t *testing.T) {
+			err := ValidateDestination(testdata.destination, []string{outside + "-unused", root})
+			if testdata.valid {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+			}
+		})
+	}
+}
+
+func TestValidateDestinationSymlinkEscape(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("creating symbolic links requires elevated privileges on windows")
+	}
+	root, err := ioutil.TempDir("", "allowedroot")
+	assert.NoError(t, err)
+	defer os.RemoveAll(root)
+	outside, err := ioutil.TempDir("", "outside")
+	assert.NoError(t, err)
+	defer os.RemoveAll(outside)
+	assert.NoError(t, os.Symlink(outside, filepath.Join(root, "link")))
+
+	assert.Error(t, ValidateDestination(filepath.Join(root, "link"), []string{root}))
+	assert.Error(t, ValidateDestination(filepath.Join(root, "link", "new"), []string{root}))
+}
+
+func TestValidateDestinationSymlinkedRoot(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("creating symbolic links requires elevated privileges on windows")
+	}
+	target, err := ioutil.TempDir("", "roottarget")
+	assert.NoError(t, err)
+	defer os.RemoveAll(target)
+	link := target + "-link"
+	assert.NoError(t, os.Symlink(target, link))
+	defer os.Remove(link)
+
+	assert.NoError(t, ValidateDestination(filepath.Join(target, "dir"), []string{link}))
+	assert.NoError(t, ValidateDestination(filepath.Join(link, "dir"), []string{target}))
+}
+
+func TestResolveDestination(t *testing.T) {
+	params := map[string]interface{}{
+		"workDir":  "/var/lib/app",
+		"release":  "1.2",
+		"commands": []interface{}{"a", "b"},
+	}
+	data := []struct {
+		name        string
+		destination string
+		expected    string
+		expectedErr string
+	}{
+		{"no references", "/var/tmp/destination", "/var/tmp/destination", ""},
+		{"single reference", "{{ workDir }}", "/var/lib/app", ""},
+		{"composed destination", "{{workDir}}/releases/{{ release }}", "/var/lib/app/releases/1.2", ""},
+		{"unresolved reference", "{{ workDir }}/{{ missing }}", "", "unresolved parameter references missing"},
+		{"not a path", "{{ commands }}", "", "does not resolve to a path"},
+	}
+	for _, testdata := range data {
+		t.Run(testdata.name, func(t *testing.T) {
+			resolved, err := ResolveDestination(log.NewMockLog(), testdata.destination, params)
+			if testdata.expectedErr != "" {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), testdata.expectedErr)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, testdata.expected, resolved)
+			}
+		})
+	}
+}
@@ -0,0 +1,137 @@
+// Copyright 2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package remoteresource
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/aws/amazon-ssm-agent/agent/fileutil/filemanager"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+)
+
+// DefaultDownloadConcurrency is the number of resources downloaded at the same time when no limit is given
+const DefaultDownloadConcurrency = 4
+
+// DownloadRequest is a remote resource to be downloaded to a destination directory
+type DownloadRequest struct {
+	Resource       RemoteResource
+	DestinationDir string
+	// Optional requests don't fail the batch when their download fails
+	Optional bool
+	// After holds the indexes of earlier requests that must be downloaded before this one starts,
+	// e.g. the scripts of a document are downloaded after the document itself
+	After []int
+}
+
+// KeyedResource is implemented by remote resources that can describe their location in a normalized form.
+// Resources with the same location key download the same content, resources with an empty key are never deduplicated.
+type KeyedResource interface {
+	LocationKey() string
+}
+
+// duplicateOf maps the index of every request to the index of the first earlier request
+// downloading the same resource to the same destination, or -1 when there is none
+func duplicateOf(requests []DownloadRequest) []int {
+	duplicates := make([]int, len(requests))
+	firstByKey := make(map[string]int)
+	for i, request := range requests {
+		duplicates[i] = -1
+		keyed, ok := request.Resource.(KeyedResource)
+		if !ok || keyed.LocationKey() == "" {
+			continue
+		}
+		key := keyed.LocationKey() + "|" + filepath.Clean(request.DestinationDir)
+		if first, found := firstByKey[key]; found {
+			duplicates[i] = first
+		} else {
+			firstByKey[key] = i
+		}
+	}
+	return duplicates
+}
+
+// DownloadAll downloads independent resources concurrently, using at most limit downloads at a time.
+// All requests are attempted and the failures of required resources are aggregated in the returned error.
+// Requests repeating a keyed resource and destination of an earlier request reuse its download instead of fetching it again.
+func DownloadAll(log log.T, filesys filemanager.FileSystem, requests []DownloadRequest, limit int) error {
+	for i, request := range requests {
+		for _, dep := range request.After {
+			if dep < 0 || dep >= i {
+				return fmt.Errorf("download request %v can only depend on earlier requests, found %v", i, dep)
+			}
+		}
+	}
+	if limit <= 0 {
+		limit = DefaultDownloadConcurrency
+	}
+
+	errs := make([]error, len(requests))
+	done := make([]chan struct{}, len(requests))
+	for i := range done {
+		done[i] = make(chan struct{})
+	}
+	semaphore := make(chan struct{}, limit)
+	duplicates := duplicateOf(requests)
+
+	var wg sync.WaitGroup
+	for i := range requests {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			defer close(done[i])
+
+			request := requests[i]
+			for _, dep := range request.After {
+				<-done[dep]
+				if errs[dep] != nil {
+					errs[i] = fmt.Errorf("skipped because request %v failed", dep)
+					return
+				}
+			}
+
+			if first := duplicates[i]; first >= 0 {
+				log.Debugf("Resource %v is identical to resource %v, reusing its download", i, first)
+				<-done[first]
+				errs[i] = errs[first]
+				return
+			}
+
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+			log.Debugf("Downloading resource %v to %v", i, request.DestinationDir)
+			errs[i] = request.Resource.Download(log, filesys, request.DestinationDir)
+		}(i)
+	}
+	wg.Wait()
+
+	var failures []string
+	for i, err := range errs {
+		if err == nil {
+			continue
+		}
+		if requests[i].Optional {
+			log.Warnf("Optional resource %v could not be downloaded - %v", i, err)
+			continue
+		}
+		failures = append(failures, fmt.Sprintf("resource %v: %v", i, err))
+	}
+	if len(failures) > 0 {
+		return fmt.Errorf("Failed to download %v resource(s) - %v", len(failures), strings.Join(failures, "; "))
+	}
+	return nil
+}
@@ -0,0 +1,190 @@
+// Copyright 2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package remoteresource
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/fileutil/filemanager"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/stretchr/testify/assert"
+)
+
+var logMock = log.NewMockLog()
+
+// fakeResource records the downloads made to it
+type fakeResource struct {
+	err       error
+	delay     time.Duration
+	inFlight  *int32
+	maxFlight *int32
+	order     *[]string
+	orderLock *sync.Mutex
+	name      string
+}
+
+func (r fakeResource) Download(log log.T, filesys filemanager.FileSystem, destinationDir string) error {
+	if r.inFlight != nil {
+		current := atomic.AddInt32(r.inFlight, 1)
+		for {
+			max := atomic.LoadInt32(r.maxFlight)
+			if current <= max || atomic.CompareAndSwapInt32(r.maxFlight, max, current) {
+				break
+			}
+		}
+		defer atomic.AddInt32(r.inFlight, -1)
+	}
+	time.Sleep(r.delay)
+	if r.order != nil {
+		r.orderLock.Lock()
+		*r.order = append(*r.order, r.name)
+		r.orderLock.Unlock()
+	}
+	return r.err
+}
+
+func (r fakeResource) ValidateLocationInfo() (bool, error) {
+	return true, nil
+}
+
+func TestDownloadAll_BoundedConcurrency(t *testing.T) {
+	var inFlight, maxFlight int32
+	var requests []DownloadRequest
+	for i := 0; i < 10; i++ {
+		requests = append(requests, DownloadRequest{
+			Resource: fakeResource{delay: 10 * time.Millisecond, inFlight: &inFlight, maxFlight: &maxFlight},
+		})
+	}
+
+	err := DownloadAll(logMock, filemanager.FileSystemImpl{}, requests, 3)
+
+	assert.NoError(t, err)
+	assert.True(t, maxFlight <= 3)
+	assert.True(t, maxFlight > 1)
+}
+
+func TestDownloadAll_RequiredFailure(t *testing.T) {
+	requests := []DownloadRequest{
+		{Resource: fakeResource{}},
+		{Resource: fakeResource{err: errors.New("not found")}},
+		{Resource: fakeResource{err: errors.New("forbidden")}, Optional: true},
+	}
+
+	err := DownloadAll(logMock, filemanager.FileSystemImpl{}, requests, 0)
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "resource 1: not found")
+	assert.NotContains(t, err.Error(), "forbidden")
+}
+
+func TestDownloadAll_OptionalFailure(t *testing.T) {
+	requests := []DownloadRequest{
+		{Resource: fakeResource{}},
+		{Resource: fakeResource{err: errors.New("not found")}, Optional: true},
+	}
+
+	err := DownloadAll(logMock, filemanager.FileSystemImpl{}, requests, 0)
+
+	assert.NoError(t, err)
+}
+
+func TestDownloadAll_DependentOrdering(t *testing.T) {
+	var order []string
+	var orderLock sync.Mutex
+	requests := []DownloadRequest{
+		{Resource: fakeResource{name: "document", delay: 20 * time.Millisecond, order: &order, orderLock: &orderLock}},
+		{Resource: fakeResource{name: "script", order: &order, orderLock: &orderLock}, After: []int{0}},
+	}
+
+	err := DownloadAll(logMock, filemanager.FileSystemImpl{}, requests, 2)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"document", "script"}, order)
+}
+
+func TestDownloadAll_DependencyFailed(t *testing.T) {
+	requests := []DownloadRequest{
+		{Resource: fakeResource{err: errors.New("not found")}},
+		{Resource: fakeResource{}, After: []int{0}},
+	}
+
+	err := DownloadAll(logMock, filemanager.FileSystemImpl{}, requests, 2)
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "resource 1: skipped because request 0 failed")
+}
+
+func TestDownloadAll_InvalidDependency(t *testing.T) {
+	requests := []DownloadRequest{
+		{Resource: fakeResource{}, After: []int{1}},
+		{Resource: fakeResource{}},
+	}
+
+	err := DownloadAll(logMock, filemanager.FileSystemImpl{}, requests, 2)
+
+	assert.Error(t, err)
+}
+
+type keyedResource struct {
+	key       string
+	err       error
+	downloads *int32
+}
+
+func (r keyedResource) Download(log log.T, filesys filemanager.FileSystem, destinationDir string) error {
+	atomic.AddInt32(r.downloads, 1)
+	return r.err
+}
+
+func (r keyedResource) ValidateLocationInfo() (bool, error) {
+	return true, nil
+}
+
+func (r keyedResource) LocationKey() string {
+	return r.key
+}
+
+func TestDownloadAll_Deduplicates(t *testing.T) {
+	var downloads int32
+	requests := []DownloadRequest{
+		{Resource: keyedResource{key: "helper", downloads: &downloads}, DestinationDir: "dir"},
+		{Resource: keyedResource{key: "helper", downloads: &downloads}, DestinationDir: "dir/"},
+		{Resource: keyedResource{key: "helper", downloads: &downloads}, DestinationDir: "other"},
+		{Resource: keyedResource{key: "script", downloads: &downloads}, DestinationDir: "dir"},
+	}
+
+	err := DownloadAll(logMock, filemanager.FileSystemImpl{}, requests, 2)
+
+	assert.NoError(t, err)
+	assert.Equal(t, int32(3), downloads)
+}
+
+func TestDownloadAll_DuplicateSharesFailure(t *testing.T) {
+	var downloads int32
+	requests := []DownloadRequest{
+		{Resource: keyedResource{key: "helper", err: errors.New("not found"), downloads: &downloads}, Optional: true},
+		{Resource: keyedResource{key: "helper", downloads: &downloads}},
+	}
+
+	err := DownloadAll(logMock, filemanager.FileSystemImpl{}, requests, 2)
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "resource 1: not found")
+	assert.Equal(t, int32(1), downloads)
+}
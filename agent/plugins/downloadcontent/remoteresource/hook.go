@@ -0,0 +1,65 @@
+// Copyright 2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package remoteresource
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/aws/amazon-ssm-agent/agent/executers"
+	"github.com/aws/amazon-ssm-agent/agent/fileutil/filemanager"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/aws/amazon-ssm-agent/agent/task"
+)
+
+// DefaultPostDownloadTimeoutSeconds is the time a post download command may run when no timeout is given
+const DefaultPostDownloadTimeoutSeconds = 600
+
+// PostDownloadHook is a command run once a remote resource is downloaded, before it is used,
+// e.g. to make the downloaded scripts executable or scan them.
+// It is read from the LocationInfo of any remote resource, the default timeout applies when TimeoutSeconds isn't positive.
+type PostDownloadHook struct {
+	Command        string `json:"postDownloadCommand"`
+	TimeoutSeconds int    `json:"postDownloadTimeoutSeconds"`
+}
+
+// Run executes the command with the platform shell from destinationDir, or from its parent directory when the resource was saved as a file.
+// The command failing or exiting with a non-zero code is returned as an error.
+func (hook PostDownloadHook) Run(log log.T, filesys filemanager.FileSystem, executer executers.T, cancelFlag task.CancelFlag, destinationDir string) error {
+	workingDir := destinationDir
+	if !filesys.IsDirectory(workingDir) {
+		workingDir = filepath.Dir(workingDir)
+	}
+
+	timeout := hook.TimeoutSeconds
+	if timeout <= 0 {
+		timeout = DefaultPostDownloadTimeoutSeconds
+	}
+
+	log.Infof("Running post download command in %v", workingDir)
+	var stdout, stderr bytes.Buffer
+	commandArguments := append(append([]string{}, hookShellArguments...), hook.Command)
+	exitCode, err := executer.NewExecute(log, workingDir, &stdout, &stderr, cancelFlag, timeout, hookShell, commandArguments)
+	log.Debugf("Post download command output - %v", stdout.String())
+	if err != nil {
+		return fmt.Errorf("Post download command failed - %v", err)
+	}
+	if exitCode != 0 {
+		return fmt.Errorf("Post download command exited with code %v - %v", exitCode, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+// Copyright 2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package remoteresource
+
+import (
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/aws/amazon-ssm-agent/agent/executers"
+	filemock "github.com/aws/amazon-ssm-agent/agent/fileutil/filemanager/mock"
+	"github.com/aws/amazon-ssm-agent/agent/task"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestPostDownloadHook_Run(t *testing.T) {
+	data := []struct {
+		name               string
+		destination        string
+		isDirectory        bool
+		timeoutSeconds     int
+		expectedTimeout    int
+		expectedWorkingDir string
+		exitCode           int
+		stderr             string
+		executeErr         error
+		expectedErr        string
+	}{
+		{"succeeds in directory", "/var/tmp/destination", true, 30, 30, "/var/tmp/destination", 0, "", nil, ""},
+		{"succeeds next to file", "/var/tmp/destination/script.sh", false, 30, 30, "/var/tmp/destination", 0, "", nil, ""},
+		{"fails on non-zero exit code", "/var/tmp/destination", true, 30, 30, "/var/tmp/destination", 2, "infected file found\n", nil, "Post download command exited with code 2 - infected file found"},
+		{"fails when command can't run", "/var/tmp/destination", true, 30, 30, "/var/tmp/destination", 1, "", errors.New("timed out"), "Post download command failed - timed out"},
+		{"default timeout", "/var/tmp/destination", true, 0, DefaultPostDownloadTimeoutSeconds, "/var/tmp/destination", 0, "", nil, ""},
+	}
+	for _, testdata := range data {
+		t.Run(testdata.name, func(t *testing.T) {
+			fileMock := filemock.FileSystemMock{}
+			fileMock.On("IsDirectory", testdata.destination).Return(testdata.isDirectory)
+			cancelFlag := task.NewChanneledCancelFlag()
+			executerMock := executers.MockCommandExecuter{}
+			executerMock.On("NewExecute", mock.Anything, testdata.expectedWorkingDir, mock.Anything, mock.Anything, cancelFlag, testdata.expectedTimeout, hookShell, append(append([]string{}, hookShellArguments...), "scan .")).
+				Run(func(args mock.Arguments) {
+					io.WriteString(args.Get(3).(io.Writer), testdata.stderr)
+				}).
+				Return(testdata.exitCode, testdata.executeErr).Once()
+
+			hook := PostDownloadHook{Command: "scan .", TimeoutSeconds: testdata.timeoutSeconds}
+			err := hook.Run(logMock, fileMock, &executerMock, cancelFlag, testdata.destination)
+
+			executerMock.AssertExpectations(t)
+			if testdata.expectedErr == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, testdata.expectedErr)
+			}
+		})
+	}
+}
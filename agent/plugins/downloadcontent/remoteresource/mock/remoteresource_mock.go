@@ -34,3 +34,13 @@ func (resourceMock RemoteResourceMock) ValidateLocationInfo() (bool, error) {
 	args := resourceMock.Called()
 	return args.Bool(0), args.Error(1)
 }
+
+// TokenAccessMock mocks the resolution of the tokenInfo of a source
+type TokenAccessMock struct {
+	mock.Mock
+}
+
+func (tokenMock *TokenAccessMock) GetToken(log log.T, tokenInfo string) (string, error) {
+	args := tokenMock.Called(log, tokenInfo)
+	return args.String(0), args.Error(1)
+}
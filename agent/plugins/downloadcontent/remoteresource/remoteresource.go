@@ -16,17 +16,104 @@
 package remoteresource
 
 import (
+	"encoding/json"
+	"path/filepath"
+	"strings"
+
 	"github.com/aws/amazon-ssm-agent/agent/fileutil/filemanager"
 	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/go-yaml/yaml"
 )
 
 const (
 	JSONExtension = ".json"
 	YAMLExtension = ".yaml"
+	YMLExtension  = ".yml"
+)
+
+// ResourceType describes how a downloaded file is meant to be run
+type ResourceType string
+
+const (
+	Script   ResourceType = "Script"
+	Document ResourceType = "Document"
+	// Data is a JSON or YAML file that isn't an SSM document, it is neither run nor executed as a document
+	Data ResourceType = "Data"
 )
 
+// ResourceInfo holds the local path and the type of a downloaded resource
+type ResourceInfo struct {
+	LocalDestinationPath string
+	TypeOfResource       ResourceType
+}
+
 // RemoteResource is an interface for accessing remote resources. Every type of remote resource is expected to implement RemoteResource interface
 type RemoteResource interface {
 	Download(log log.T, filesys filemanager.FileSystem, destinationDir string) error
 	ValidateLocationInfo() (bool, error)
 }
+
+// TokenAccess resolves the tokenInfo of a source to the token itself
+type TokenAccess interface {
+	GetToken(log log.T, tokenInfo string) (string, error)
+}
+
+// PopulateResourceInfo classifies the file at localPath by its extension.
+// resourceTypes maps extensions to resource types and takes precedence over the built-in rules,
+// which treat JSON and YAML files, .yaml or .yml, as documents and anything else as a script.
+// Files classified as documents that don't have the structure of one are data files.
+func PopulateResourceInfo(log log.T, filesys filemanager.FileSystem, localPath string, resourceTypes map[string]string) ResourceInfo {
+	resourceType := resourceTypeOf(log, localPath, resourceTypes)
+	if resourceType == Document && !isDocument(log, filesys, localPath) {
+		log.Warnf("%v does not have the schemaVersion and mainSteps or runtimeConfig of an SSM document, treating it as a data file", localPath)
+		resourceType = Data
+	}
+	return ResourceInfo{
+		LocalDestinationPath: localPath,
+		TypeOfResource:       resourceType,
+	}
+}
+
+// isDocument returns true if the file is a JSON or YAML object with a schemaVersion and either mainSteps or runtimeConfig
+func isDocument(log log.T, filesys filemanager.FileSystem, localPath string) bool {
+	content, err := filesys.ReadFile(localPath)
+	if err != nil {
+		log.Debugf("Could not read %v to check it is a document - %v", localPath, err)
+		return false
+	}
+	var fields map[string]interface{}
+	// JSON may be indented with tabs, which YAML doesn't allow
+	if json.Unmarshal([]byte(content), &fields) != nil && yaml.Unmarshal([]byte(content), &fields) != nil {
+		return false
+	}
+	_, hasSchemaVersion := fields["schemaVersion"]
+	_, hasMainSteps := fields["mainSteps"]
+	_, hasRuntimeConfig := fields["runtimeConfig"]
+	return hasSchemaVersion && (hasMainSteps || hasRuntimeConfig)
+}
+
+// resourceTypeOf looks up the extension of localPath in resourceTypes before falling back to the built-in rules
+func resourceTypeOf(log log.T, localPath string, resourceTypes map[string]string) ResourceType {
+	extension := strings.ToLower(filepath.Ext(localPath))
+	for configured, resourceType := range resourceTypes {
+		if !strings.HasPrefix(configured, ".") {
+			configured = "." + configured
+		}
+		if extension == "" || !strings.EqualFold(configured, extension) {
+			continue
+		}
+		switch {
+		case strings.EqualFold(resourceType, string(Script)):
+			return Script
+		case strings.EqualFold(resourceType, string(Document)):
+			return Document
+		default:
+			log.Warnf("Ignoring unknown resource type %v configured for extension %v", resourceType, configured)
+		}
+	}
+
+	if extension == JSONExtension || extension == YAMLExtension || extension == YMLExtension {
+		return Document
+	}
+	return Script
+}
@@ -0,0 +1,102 @@
+// Copyright 2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package remoteresource
+
+import (
+	"errors"
+	"testing"
+
+	filemock "github.com/aws/amazon-ssm-agent/agent/fileutil/filemanager/mock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+const jsonDocument = `{
+	"schemaVersion": "2.2",
+	"mainSteps": [{"action": "aws:runShellScript", "name": "run", "inputs": {"runCommand": ["echo hello"]}}]
+}`
+
+func TestPopulateResourceInfo(t *testing.T) {
+	overrides := map[string]string{
+		".template": "Document",
+		"ps1":       "script",
+		".yaml":     "Script",
+		".conf":     "Unknown",
+	}
+
+	data := []struct {
+		name          string
+		path          string
+		resourceTypes map[string]string
+		expected      ResourceType
+	}{
+		{"json document", "dir/doc.json", nil, Document},
+		{"yaml document", "dir/doc.yaml", nil, Document},
+		{"yml document", "dir/doc.yml", nil, Document},
+		{"yml document ignoring case", "dir/DOC.YML", nil, Document},
+		{"shell script", "dir/script.sh", nil, Script},
+		{"no extension", "dir/script", nil, Script},
+		{"template without override", "dir/doc.template", nil, Script},
+		{"template with override", "dir/doc.template", overrides, Document},
+		{"override is case insensitive", "dir/DOC.TEMPLATE", overrides, Document},
+		{"override without leading dot", "dir/script.ps1", overrides, Script},
+		{"override replaces built-in rule", "dir/doc.yaml", overrides, Script},
+		{"built-in rule kept with overrides", "dir/doc.json", overrides, Document},
+		{"unknown override type ignored", "dir/file.conf", overrides, Script},
+	}
+	for _, testdata := range data {
+		t.Run(testdata.name, func(t *testing.T) {
+			fileMock := filemock.FileSystemMock{}
+			fileMock.On("ReadFile", mock.Anything).Return(jsonDocument, nil)
+
+			info := PopulateResourceInfo(logMock, fileMock, testdata.path, testdata.resourceTypes)
+
+			assert.Equal(t, testdata.path, info.LocalDestinationPath)
+			assert.Equal(t, testdata.expected, info.TypeOfResource)
+		})
+	}
+}
+
+func TestPopulateResourceInfoChecksDocumentStructure(t *testing.T) {
+	data := []struct {
+		name     string
+		path     string
+		content  string
+		readErr  error
+		expected ResourceType
+	}{
+		{"json document", "doc.json", jsonDocument, nil, Document},
+		{"tab indented json document", "doc.json", "{\n\t\"schemaVersion\": \"2.2\",\n\t\"mainSteps\": []\n}", nil, Document},
+		{"yaml document", "doc.yaml", "schemaVersion: '2.2'\nmainSteps:\n  - action: aws:runShellScript\n    name: run\n", nil, Document},
+		{"runtimeConfig document", "doc.json", `{"schemaVersion": "1.2", "runtimeConfig": {}}`, nil, Document},
+		{"json data file", "settings.json", `{"endpoint": "https://example.com", "retries": 3}`, nil, Data},
+		{"schemaVersion without steps", "settings.json", `{"schemaVersion": "2.2"}`, nil, Data},
+		{"json array", "list.json", `["a", "b"]`, nil, Data},
+		{"yaml data file", "values.yaml", "replicas: 3\n", nil, Data},
+		{"yml document", "doc.yml", "schemaVersion: '2.2'\nmainSteps:\n  - action: aws:runShellScript\n    name: run\n", nil, Document},
+		{"yml data file", "values.yml", "replicas: 3\n", nil, Data},
+		{"unreadable file", "doc.json", "", errors.New("permission denied"), Data},
+	}
+	for _, testdata := range data {
+		t.Run(testdata.name, func(t *testing.T) {
+			fileMock := filemock.FileSystemMock{}
+			fileMock.On("ReadFile", testdata.path).Return(testdata.content, testdata.readErr)
+
+			info := PopulateResourceInfo(logMock, fileMock, testdata.path, nil)
+
+			assert.Equal(t, testdata.expected, info.TypeOfResource)
+		})
+	}
+}
@@ -0,0 +1,35 @@
+// Copyright 2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package remoteresource
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+)
+
+// CheckResponse returns an error with the status of the response when a repository API did not answer with success.
+// errorMessage extracts the message the API answered with from the body of the response, in the error shape of the API,
+// and returns an empty message when there is none.
+func CheckResponse(resp *http.Response, errorMessage func(body []byte) string) error {
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+	body, _ := ioutil.ReadAll(resp.Body)
+	if message := errorMessage(body); message != "" {
+		return fmt.Errorf("%v %v", resp.Status, message)
+	}
+	return fmt.Errorf("%v", resp.Status)
+}
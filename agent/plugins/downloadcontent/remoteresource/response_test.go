@@ -0,0 +1,58 @@
+// Copyright 2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package remoteresource
+
+import (
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckResponse(t *testing.T) {
+	message := func(body []byte) string {
+		return strings.TrimPrefix(string(body), "error: ")
+	}
+	data := []struct {
+		name        string
+		statusCode  int
+		status      string
+		body        string
+		expectedErr string
+	}{
+		{"success", 200, "200 OK", "", ""},
+		{"error with message", 404, "404 Not Found", "error: no such file", "404 Not Found no such file"},
+		{"error without message", 500, "500 Internal Server Error", "", "500 Internal Server Error"},
+	}
+	for _, testdata := range data {
+		t.Run(testdata.name, func(t *testing.T) {
+			resp := &http.Response{
+				StatusCode: testdata.statusCode,
+				Status:     testdata.status,
+				Body:       ioutil.NopCloser(strings.NewReader(testdata.body)),
+			}
+
+			err := CheckResponse(resp, message)
+
+			if testdata.expectedErr == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, testdata.expectedErr)
+			}
+		})
+	}
+}
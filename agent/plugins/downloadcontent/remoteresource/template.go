@@ -0,0 +1,134 @@
+// Copyright 2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package remoteresource
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/aws/amazon-ssm-agent/agent/parameters"
+	"github.com/go-yaml/yaml"
+)
+
+// documentParametersKey is the section of a document declaring its parameters
+const documentParametersKey = "parameters"
+
+// IsDocumentTemplate returns true if the file at localPath is classified as a document and may be rendered with RenderDocument
+func IsDocumentTemplate(log log.T, localPath string, resourceTypes map[string]string) bool {
+	return resourceTypeOf(log, localPath, resourceTypes) == Document
+}
+
+// RenderDocument substitutes values for the {{ parameter }} references of the document content downloaded to localPath,
+// in the same way parameters are substituted into documents sent to the agent.
+// Parameters declared by the document without a default must have a value, their defaults are used otherwise.
+// The declarations are kept as they are and the document is returned in its original JSON or YAML format.
+func RenderDocument(log log.T, localPath string, content string, values map[string]interface{}) (string, error) {
+	isJSON := strings.ToLower(filepath.Ext(localPath)) == JSONExtension
+	var document map[string]interface{}
+	var err error
+	if isJSON {
+		err = json.Unmarshal([]byte(content), &document)
+	} else {
+		err = yaml.Unmarshal([]byte(content), &document)
+	}
+	if err != nil {
+		return "", fmt.Errorf("Document template %v could not be parsed - %v", localPath, err)
+	}
+
+	resolved, err := resolveDocumentParameters(document[documentParametersKey], values)
+	if err != nil {
+		return "", fmt.Errorf("Document template %v could not be rendered - %v", localPath, err)
+	}
+	resolved = parameters.ValidParameters(log, resolved)
+
+	declarations, declared := document[documentParametersKey]
+	delete(document, documentParametersKey)
+	rendered := parameters.ReplaceParameters(document, resolved, log).(map[string]interface{})
+	if declared {
+		rendered[documentParametersKey] = declarations
+	}
+
+	var output []byte
+	if isJSON {
+		output, err = json.MarshalIndent(rendered, "", "  ")
+	} else {
+		output, err = yaml.Marshal(rendered)
+	}
+	if err != nil {
+		return "", err
+	}
+	return string(output), nil
+}
+
+// resolveDocumentParameters returns the value of every declared parameter, from values or else its default,
+// along with the undeclared values. It fails when a parameter declared without a default has no value.
+func resolveDocumentParameters(declarations interface{}, values map[string]interface{}) (map[string]interface{}, error) {
+	resolved := make(map[string]interface{}, len(values))
+	for name, value := range values {
+		resolved[name] = value
+	}
+
+	var missing []string
+	for name, declaration := range declarationsByName(declarations) {
+		if _, found := resolved[name]; found {
+			continue
+		}
+		if defaultValue, hasDefault := declarationDefault(declaration); hasDefault {
+			resolved[name] = defaultValue
+		} else {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return nil, fmt.Errorf("required parameters %v were not provided", strings.Join(missing, ", "))
+	}
+	return resolved, nil
+}
+
+// declarationsByName returns the parameter declarations of a document, parsed from JSON or YAML
+func declarationsByName(declarations interface{}) map[string]interface{} {
+	byName := make(map[string]interface{})
+	switch declarations := declarations.(type) {
+	case map[string]interface{}:
+		for name, declaration := range declarations {
+			byName[name] = declaration
+		}
+	case map[interface{}]interface{}:
+		for name, declaration := range declarations {
+			if name, ok := name.(string); ok {
+				byName[name] = declaration
+			}
+		}
+	}
+	return byName
+}
+
+// declarationDefault returns the default value of a parameter declaration, if it has one
+func declarationDefault(declaration interface{}) (interface{}, bool) {
+	switch declaration := declaration.(type) {
+	case map[string]interface{}:
+		defaultValue, found := declaration["default"]
+		return defaultValue, found
+	case map[interface{}]interface{}:
+		defaultValue, found := declaration["default"]
+		return defaultValue, found
+	}
+	return nil, false
+}
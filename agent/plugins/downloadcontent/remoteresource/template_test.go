@@ -0,0 +1,84 @@
+// Copyright 2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package remoteresource
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/go-yaml/yaml"
+	"github.com/stretchr/testify/assert"
+)
+
+const jsonTemplate = `{
+	"schemaVersion": "2.2",
+	"parameters": {
+		"message": {"type": "String"},
+		"count": {"type": "String", "default": "3"}
+	},
+	"mainSteps": [{
+		"action": "aws:runShellScript",
+		"name": "echo",
+		"inputs": {"runCommand": ["echo {{ message }} {{ count }} times"]}
+	}]
+}`
+
+const yamlTemplate = `schemaVersion: "2.2"
+parameters:
+  message:
+    type: String
+mainSteps:
+- action: aws:runShellScript
+  name: echo
+  inputs:
+    runCommand:
+    - echo {{ message }}
+`
+
+func TestRenderDocument_JSON(t *testing.T) {
+	rendered, err := RenderDocument(log.NewMockLog(), "docs/echo.json", jsonTemplate, map[string]interface{}{"message": "hello"})
+	assert.NoError(t, err)
+
+	var document map[string]interface{}
+	assert.NoError(t, json.Unmarshal([]byte(rendered), &document))
+	step := document["mainSteps"].([]interface{})[0].(map[string]interface{})
+	commands := step["inputs"].(map[string]interface{})["runCommand"].([]interface{})
+	assert.Equal(t, "echo hello 3 times", commands[0])
+	// the declarations are kept as written
+	assert.Contains(t, document["parameters"], "message")
+}
+
+func TestRenderDocument_YAML(t *testing.T) {
+	rendered, err := RenderDocument(log.NewMockLog(), "docs/echo.yaml", yamlTemplate, map[string]interface{}{"message": "hello"})
+	assert.NoError(t, err)
+
+	var document map[string]interface{}
+	assert.NoError(t, yaml.Unmarshal([]byte(rendered), &document))
+	assert.Contains(t, rendered, "echo hello")
+	assert.Equal(t, "2.2", document["schemaVersion"])
+}
+
+func TestRenderDocument_MissingRequiredParameter(t *testing.T) {
+	_, err := RenderDocument(log.NewMockLog(), "docs/echo.json", jsonTemplate, map[string]interface{}{"count": "5"})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "required parameters message were not provided")
+}
+
+func TestRenderDocument_InvalidDocument(t *testing.T) {
+	_, err := RenderDocument(log.NewMockLog(), "docs/echo.json", "{not json", nil)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "could not be parsed")
+}
@@ -22,6 +22,7 @@ import (
 	"github.com/aws/amazon-ssm-agent/agent/fileutil/filemanager"
 	"github.com/aws/amazon-ssm-agent/agent/jsonutil"
 	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/downloadcontent/remoteresource"
 	"github.com/aws/amazon-ssm-agent/agent/plugins/downloadcontent/system"
 	"github.com/aws/amazon-ssm-agent/agent/s3util"
 
@@ -172,6 +173,16 @@ func (s3 *S3Resource) Download(log log.T, filesys filemanager.FileSystem, destPa
 	return nil
 }
 
+// AuditLocation describes the S3 URL downloaded for the audit log, without its query which may hold a presigned signature
+func (s3 *S3Resource) AuditLocation() remoteresource.AuditLocation {
+	location := remoteresource.AuditLocation{Path: strings.SplitN(s3.Info.Path, "?", 2)[0]}
+	if fileURL, err := url.Parse(s3.Info.Path); err == nil {
+		location.Source = fileURL.Scheme + "://" + fileURL.Host
+		location.Path = fileURL.Path
+	}
+	return location
+}
+
 // ValidateLocationInfo ensures that the required parameters of SourceInfo are specified
 func (s3 *S3Resource) ValidateLocationInfo() (valid bool, err error) {
 	// Path is a mandatory input
@@ -98,6 +98,11 @@ func (ssmdoc *SSMDocResource) Download(log log.T, filesys filemanager.FileSystem
 	return
 }
 
+// AuditLocation describes the SSM document downloaded for the audit log
+func (s3 *SSMDocResource) AuditLocation() remoteresource.AuditLocation {
+	return remoteresource.AuditLocation{Source: "ssm", Path: s3.Info.DocName}
+}
+
 // ValidateLocationInfo ensures that the required parameters of SourceInfo are specified
 func (s3 *SSMDocResource) ValidateLocationInfo() (valid bool, err error) {
 	if s3.Info.DocName == "" {
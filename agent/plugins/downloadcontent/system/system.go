@@ -16,31 +16,199 @@
 package system
 
 import (
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
 	"github.com/aws/amazon-ssm-agent/agent/fileutil/filemanager"
 	"github.com/aws/amazon-ssm-agent/agent/log"
 
+	"io"
+	"os"
 	"path/filepath"
+	"sync"
+	"syscall"
+	"time"
 )
 
+// writeRetryBackoff is the wait before the first retry of a write, it grows linearly with each attempt
+const writeRetryBackoff = 100 * time.Millisecond
+
+// sleep is a seam for waiting between write attempts
+var sleep = time.Sleep
+
+// FileOwnership is the user and group, as names or numeric IDs, given to saved files and the directories created for them.
+// Empty values keep the owner or group of the agent. Ownership is not changed on Windows.
+type FileOwnership struct {
+	Owner string
+	Group string
+}
+
+// IsSet returns true if the ownership changes the owner or the group of files
+func (ownership FileOwnership) IsSet() bool {
+	return ownership.Owner != "" || ownership.Group != ""
+}
+
+// ConfiguredFileOwnership returns the ownership of downloaded files configured in appconfig
+func ConfiguredFileOwnership() FileOwnership {
+	if appCfg, err := appconfig.Config(false); err == nil {
+		return FileOwnership{Owner: appCfg.RemoteResource.FileOwner, Group: appCfg.RemoteResource.FileGroup}
+	}
+	return FileOwnership{}
+}
+
+// destinationLocks serializes the writes of concurrent downloads of this process to the same path
+var destinationLocks = struct {
+	sync.Mutex
+	paths map[string]*destinationLock
+}{paths: make(map[string]*destinationLock)}
+
+// destinationLock is held while a file is written, it is dropped once no download waits for it
+type destinationLock struct {
+	sync.Mutex
+	waiters int
+}
+
+// LockDestination blocks until no other download of the process writes to destination and returns the function releasing it
+func LockDestination(destination string) (unlock func()) {
+	path := filepath.Clean(destination)
+	if absolutePath, err := filepath.Abs(path); err == nil {
+		path = absolutePath
+	}
+
+	destinationLocks.Lock()
+	lock, ok := destinationLocks.paths[path]
+	if !ok {
+		lock = &destinationLock{}
+		destinationLocks.paths[path] = lock
+	}
+	lock.waiters++
+	destinationLocks.Unlock()
+
+	lock.Lock()
+	return func() {
+		lock.Unlock()
+		destinationLocks.Lock()
+		if lock.waiters--; lock.waiters == 0 {
+			delete(destinationLocks.paths, path)
+		}
+		destinationLocks.Unlock()
+	}
+}
+
 // SaveFileContent is a method that returns the content in a file and saves it on disk
 func SaveFileContent(log log.T, filesysdep filemanager.FileSystem, destination string, contents string) (err error) {
+	return SaveFileContentWithOwnership(log, filesysdep, destination, contents, ConfiguredFileOwnership())
+}
+
+// SaveFileContentWithOwnership saves the content on disk, giving the file and the directories created for it the ownership
+func SaveFileContentWithOwnership(log log.T, filesysdep filemanager.FileSystem, destination string, contents string, ownership FileOwnership) (err error) {
+	return SaveFileContentWithMode(log, filesysdep, destination, contents, ownership, 0)
+}
+
+// SaveFileContentWithMode saves the content on disk like SaveFileContentWithOwnership and gives the file the permissions of mode,
+// e.g. 0755 for an executable. A mode of 0 keeps the permissions files are created with. Permissions are not changed on Windows.
+func SaveFileContentWithMode(log log.T, filesysdep filemanager.FileSystem, destination string, contents string, ownership FileOwnership, mode os.FileMode) (err error) {
+	defer LockDestination(destination)()
 
 	log.Debugf("Destination is %v ", destination)
+	var createdDirs []string
+	if ownership.IsSet() {
+		createdDirs = missingDirs(filesysdep, filepath.Dir(destination))
+	}
 	// create directory to download github resources
 	if err = filesysdep.MakeDirs(filepath.Dir(destination)); err != nil {
 		log.Error("failed to create directory for github - ", err)
 		return err
 	}
-	log.Debug("Content obtained - ", contents)
 
-	if err = filesysdep.WriteFile(destination, contents); err != nil {
+	retryLimit := appconfig.DefaultFileWriteRetryLimit
+	if appCfg, cfgErr := appconfig.Config(false); cfgErr == nil {
+		retryLimit = appCfg.Agent.FileWriteRetryLimit
+	}
+	if err = writeFileWithRetry(log, filesysdep, destination, contents, retryLimit); err != nil {
 		log.Errorf("Error writing to file %v - %v", destination, err)
 		return err
 	}
+	if mode != 0 {
+		if err = changeMode(filesysdep, destination, mode); err != nil {
+			log.Errorf("Error changing the permissions of %v to %v - %v", destination, mode, err)
+			return err
+		}
+	}
 
+	if ownership.IsSet() {
+		if err = changeOwnership(append(createdDirs, destination), ownership); err != nil {
+			log.Error(err)
+			return err
+		}
+	}
 	return nil
 }
 
+// SaveFileStreamWithOwnership saves the content read from the reader on disk as it is read, giving the file and the directories created for it the ownership.
+// Unlike SaveFileContentWithOwnership the write isn't retried, the content can only be read once.
+func SaveFileStreamWithOwnership(log log.T, filesysdep filemanager.FileSystem, destination string, content io.Reader, ownership FileOwnership) (written int64, err error) {
+	defer LockDestination(destination)()
+
+	log.Debugf("Destination is %v ", destination)
+	var createdDirs []string
+	if ownership.IsSet() {
+		createdDirs = missingDirs(filesysdep, filepath.Dir(destination))
+	}
+	if err = filesysdep.MakeDirs(filepath.Dir(destination)); err != nil {
+		log.Error("failed to create directory for github - ", err)
+		return 0, err
+	}
+
+	if written, err = filesysdep.WriteStream(destination, content); err != nil {
+		log.Errorf("Error writing to file %v - %v", destination, err)
+		return written, err
+	}
+
+	if ownership.IsSet() {
+		if err = changeOwnership(append(createdDirs, destination), ownership); err != nil {
+			log.Error(err)
+			return written, err
+		}
+	}
+	return written, nil
+}
+
+// missingDirs returns the directories, outermost first, that creating dir will create
+func missingDirs(filesysdep filemanager.FileSystem, dir string) []string {
+	var missing []string
+	for dir = filepath.Clean(dir); !filesysdep.Exists(dir); dir = filepath.Dir(dir) {
+		missing = append([]string{dir}, missing...)
+		if parent := filepath.Dir(dir); parent == dir {
+			break
+		}
+	}
+	return missing
+}
+
+// writeFileWithRetry writes the file, retrying up to retryLimit times when the write fails with a transient error
+func writeFileWithRetry(log log.T, filesysdep filemanager.FileSystem, destination string, contents string, retryLimit int) (err error) {
+	for attempt := 0; ; attempt++ {
+		if err = filesysdep.WriteFile(destination, contents); err == nil || attempt >= retryLimit || !isTransientFileError(err) {
+			return err
+		}
+		log.Warnf("Transient error writing to file %v, retrying - %v", destination, err)
+		sleep(time.Duration(attempt+1) * writeRetryBackoff)
+	}
+}
+
+// isTransientFileError returns true if the file system error is expected to go away on retry
+func isTransientFileError(err error) bool {
+	switch e := err.(type) {
+	case *os.PathError:
+		err = e.Err
+	case *os.LinkError:
+		err = e.Err
+	case *os.SyscallError:
+		err = e.Err
+	}
+	errno, ok := err.(syscall.Errno)
+	return ok && isTransientErrno(errno)
+}
+
 // RenameFile is a method that renames a file and deletes the original copy
 func RenameFile(log log.T, filesys filemanager.FileSystem, fullSourceName, destName string) error {
 
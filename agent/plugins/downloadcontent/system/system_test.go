@@ -16,13 +16,20 @@
 package system
 
 import (
+	"github.com/aws/amazon-ssm-agent/agent/fileutil/filemanager"
 	filemock "github.com/aws/amazon-ssm-agent/agent/fileutil/filemanager/mock"
 	"github.com/aws/amazon-ssm-agent/agent/log"
 	"github.com/stretchr/testify/assert"
 
 	"errors"
 	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 )
 
 var logMock = log.NewMockLog()
@@ -69,6 +76,137 @@ func TestSaveFileContent_Pass(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestSaveFileContent_DoesNotLogContent(t *testing.T) {
+	logger := log.NewMockLog()
+	filesys := filemanager.NewMemoryFileSystem()
+	contents := "export PASSWORD=secret"
+
+	err := SaveFileContent(logger, filesys, filepath.Join("destinationDir", "secret.sh"), contents)
+
+	assert.NoError(t, err)
+	for _, call := range logger.Calls {
+		assert.NotContains(t, fmt.Sprint(call.Arguments...), contents, "%v logged the content", call.Method)
+	}
+}
+
+func TestSaveFileStream(t *testing.T) {
+	data := []struct {
+		name     string
+		writeErr error
+	}{
+		{"pass", nil},
+		{"write fail", errors.New("disk full")},
+	}
+	for _, testdata := range data {
+		t.Run(testdata.name, func(t *testing.T) {
+			fileMock := filemock.FileSystemMock{}
+			destination := "destinationDir/filename.py"
+			content := strings.NewReader("contents")
+
+			fileMock.On("MakeDirs", "destinationDir").Return(nil).Once()
+			fileMock.On("WriteStream", destination, content).Return(int64(8), testdata.writeErr).Once()
+
+			written, err := SaveFileStreamWithOwnership(logMock, fileMock, destination, content, FileOwnership{})
+
+			assert.Equal(t, testdata.writeErr, err)
+			assert.Equal(t, int64(8), written)
+			fileMock.AssertExpectations(t)
+		})
+	}
+}
+
+// overlapDetectingFileSystem fails writes that overlap another write to the same file
+type overlapDetectingFileSystem struct {
+	filemanager.FileSystem
+	lock     sync.Mutex
+	writing  map[string]bool
+	overlaps int
+	written  map[string]string
+	failures int
+}
+
+func (fs *overlapDetectingFileSystem) MakeDirs(destinationDir string) error {
+	return nil
+}
+
+func (fs *overlapDetectingFileSystem) WriteFile(filename string, content string) error {
+	fs.lock.Lock()
+	if fs.writing[filename] {
+		fs.overlaps++
+	}
+	fs.writing[filename] = true
+	fail := fs.failures > 0
+	fs.failures--
+	fs.lock.Unlock()
+
+	time.Sleep(time.Millisecond)
+
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+	fs.writing[filename] = false
+	if fail {
+		return errors.New("disk full")
+	}
+	fs.written[filename] = content
+	return nil
+}
+
+func TestSaveFileContent_ConcurrentWritesToSameDestination(t *testing.T) {
+	filesys := &overlapDetectingFileSystem{writing: map[string]bool{}, written: map[string]string{}, failures: 1}
+	destination := filepath.Join("destinationDir", "file.sh")
+	contents := []string{strings.Repeat("a", 1024), strings.Repeat("b", 1024)}
+
+	var wg sync.WaitGroup
+	errs := make([]error, 10)
+	for i := range errs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			// the same path spelled differently is the same destination
+			path := destination
+			if i%2 == 1 {
+				path = filepath.Join("destinationDir", ".", "file.sh")
+			}
+			errs[i] = SaveFileContentWithOwnership(logMock, filesys, path, contents[i%2], FileOwnership{})
+		}(i)
+	}
+	wg.Wait()
+
+	assert.Equal(t, 0, filesys.overlaps)
+	failed := 0
+	for _, err := range errs {
+		if err != nil {
+			failed++
+		}
+	}
+	// the failed write released the destination for the others
+	assert.Equal(t, 1, failed)
+	assert.Contains(t, contents, filesys.written[destination])
+	assert.Empty(t, destinationLocks.paths)
+}
+
+func TestSaveFileContent_ConcurrentWritesDoNotCorruptFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "system")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	destination := filepath.Join(dir, "nested", "file.sh")
+	contents := []string{strings.Repeat("first\n", 100000), strings.Repeat("second\n", 100000)}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(content string) {
+			defer wg.Done()
+			assert.NoError(t, SaveFileContentWithOwnership(logMock, filemanager.FileSystemImpl{}, destination, content, FileOwnership{}))
+		}(contents[i%2])
+	}
+	wg.Wait()
+
+	saved, err := ioutil.ReadFile(destination)
+	assert.NoError(t, err)
+	assert.Contains(t, contents, string(saved))
+}
+
 func TestRenameFile(t *testing.T) {
 	fileMock := filemock.FileSystemMock{}
 	sourceName := "destination/oldFileName.ext"
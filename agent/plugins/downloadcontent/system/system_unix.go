@@ -0,0 +1,87 @@
+// Copyright 2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+//
+// +build darwin freebsd linux netbsd openbsd
+
+// Package system have all the files related dependencies used by the copy package
+package system
+
+import (
+	"fmt"
+	"os"
+	"os/user"
+	"strconv"
+	"syscall"
+
+	"github.com/aws/amazon-ssm-agent/agent/fileutil/filemanager"
+)
+
+// seams for changing and resolving file ownership
+var (
+	chown       = os.Chown
+	lookupUser  = user.Lookup
+	lookupGroup = user.LookupGroup
+)
+
+// isTransientErrno returns true for errors caused by a busy file or resource, e.g. on network file systems
+func isTransientErrno(errno syscall.Errno) bool {
+	return errno == syscall.EAGAIN || errno == syscall.EBUSY || errno == syscall.ETXTBSY || errno == syscall.EINTR
+}
+
+// changeOwnership gives the paths the user and group of ownership
+func changeOwnership(paths []string, ownership FileOwnership) error {
+	uid, gid, err := resolveOwnership(ownership)
+	if err != nil {
+		return err
+	}
+	for _, path := range paths {
+		if err = chown(path, uid, gid); err != nil {
+			return fmt.Errorf("Failed to change ownership of %v to %v:%v, this usually requires the agent to run as root - %v", path, ownership.Owner, ownership.Group, err)
+		}
+	}
+	return nil
+}
+
+// changeMode gives the file the permissions of mode
+func changeMode(filesysdep filemanager.FileSystem, path string, mode os.FileMode) error {
+	return filesysdep.Chmod(path, mode)
+}
+
+// resolveOwnership converts the user and group of ownership to IDs, -1 keeps the current owner or group
+func resolveOwnership(ownership FileOwnership) (uid int, gid int, err error) {
+	uid, gid = -1, -1
+	if ownership.Owner != "" {
+		if uid, err = strconv.Atoi(ownership.Owner); err != nil {
+			u, lookupErr := lookupUser(ownership.Owner)
+			if lookupErr != nil {
+				return -1, -1, fmt.Errorf("Unknown file owner %v - %v", ownership.Owner, lookupErr)
+			}
+			if uid, err = strconv.Atoi(u.Uid); err != nil {
+				return -1, -1, fmt.Errorf("Invalid user ID %v of file owner %v", u.Uid, ownership.Owner)
+			}
+		}
+	}
+	if ownership.Group != "" {
+		if gid, err = strconv.Atoi(ownership.Group); err != nil {
+			g, lookupErr := lookupGroup(ownership.Group)
+			if lookupErr != nil {
+				return -1, -1, fmt.Errorf("Unknown file group %v - %v", ownership.Group, lookupErr)
+			}
+			if gid, err = strconv.Atoi(g.Gid); err != nil {
+				return -1, -1, fmt.Errorf("Invalid group ID %v of file group %v", g.Gid, ownership.Group)
+			}
+		}
+	}
+	return uid, gid, nil
+}
@@ -0,0 +1,201 @@
+// Copyright 2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+//
+// +build darwin freebsd linux netbsd openbsd
+
+// Package system have all the file related dependencies used by the copy package
+package system
+
+import (
+	"github.com/aws/amazon-ssm-agent/agent/fileutil/filemanager"
+	filemock "github.com/aws/amazon-ssm-agent/agent/fileutil/filemanager/mock"
+	"github.com/stretchr/testify/assert"
+
+	"errors"
+	"io/ioutil"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strconv"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestWriteFileWithRetry(t *testing.T) {
+	data := []struct {
+		name          string
+		writeErr      error
+		failures      int
+		retryLimit    int
+		expectedCalls int
+		expectedErr   bool
+	}{
+		{"transient error then success", &os.PathError{Op: "open", Path: "file", Err: syscall.EAGAIN}, 2, 2, 3, false},
+		{"transient error exceeds retry limit", &os.PathError{Op: "open", Path: "file", Err: syscall.EBUSY}, 3, 2, 3, true},
+		{"no space is not retried", &os.PathError{Op: "write", Path: "file", Err: syscall.ENOSPC}, 1, 2, 1, true},
+		{"permission denied is not retried", &os.PathError{Op: "open", Path: "file", Err: syscall.EACCES}, 1, 2, 1, true},
+		{"retries disabled", &os.PathError{Op: "open", Path: "file", Err: syscall.EAGAIN}, 1, 0, 1, true},
+	}
+
+	defer func() { sleep = time.Sleep }()
+	for _, testdata := range data {
+		t.Run(testdata.name, func(t *testing.T) {
+			var waits []time.Duration
+			sleep = func(d time.Duration) { waits = append(waits, d) }
+
+			fileMock := filemock.FileSystemMock{}
+			fileMock.On("WriteFile", "destinationDir/file", "contents").Return(testdata.writeErr).Times(testdata.failures)
+			fileMock.On("WriteFile", "destinationDir/file", "contents").Return(nil)
+
+			err := writeFileWithRetry(logMock, fileMock, "destinationDir/file", "contents", testdata.retryLimit)
+
+			if testdata.expectedErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			// every write but the last one is followed by a wait
+			assert.Len(t, waits, testdata.expectedCalls-1)
+		})
+	}
+}
+
+func TestResolveOwnership(t *testing.T) {
+	defer func() { lookupUser, lookupGroup = user.Lookup, user.LookupGroup }()
+	// a temporary mapping of names to IDs
+	lookupUser = func(name string) (*user.User, error) {
+		if name == "service" {
+			return &user.User{Uid: "1500"}, nil
+		}
+		return nil, user.UnknownUserError(name)
+	}
+	lookupGroup = func(name string) (*user.Group, error) {
+		if name == "services" {
+			return &user.Group{Gid: "1600"}, nil
+		}
+		return nil, user.UnknownGroupError(name)
+	}
+
+	data := []struct {
+		name        string
+		ownership   FileOwnership
+		expectedUID int
+		expectedGID int
+		expectedErr bool
+	}{
+		{"numeric IDs", FileOwnership{Owner: "1001", Group: "1002"}, 1001, 1002, false},
+		{"names", FileOwnership{Owner: "service", Group: "services"}, 1500, 1600, false},
+		{"owner only", FileOwnership{Owner: "service"}, 1500, -1, false},
+		{"group only", FileOwnership{Group: "1002"}, -1, 1002, false},
+		{"unknown owner", FileOwnership{Owner: "nobody-here"}, -1, -1, true},
+		{"unknown group", FileOwnership{Group: "nobody-here"}, -1, -1, true},
+	}
+	for _, testdata := range data {
+		t.Run(testdata.name, func(t *testing.T) {
+			uid, gid, err := resolveOwnership(testdata.ownership)
+
+			assert.Equal(t, testdata.expectedErr, err != nil)
+			assert.Equal(t, testdata.expectedUID, uid)
+			assert.Equal(t, testdata.expectedGID, gid)
+		})
+	}
+}
+
+func TestSaveFileContentWithOwnership(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "system")
+	defer os.RemoveAll(dir)
+	destination := filepath.Join(dir, "created", "nested", "file.sh")
+
+	type chownCall struct {
+		path     string
+		uid, gid int
+	}
+	var calls []chownCall
+	defer func() { chown = os.Chown }()
+	chown = func(path string, uid, gid int) error {
+		calls = append(calls, chownCall{path, uid, gid})
+		return nil
+	}
+
+	err := SaveFileContentWithOwnership(logMock, filemanager.FileSystemImpl{}, destination, "content", FileOwnership{Owner: "1001", Group: "1002"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []chownCall{
+		{filepath.Join(dir, "created"), 1001, 1002},
+		{filepath.Join(dir, "created", "nested"), 1001, 1002},
+		{destination, 1001, 1002},
+	}, calls)
+}
+
+func TestSaveFileContentWithOwnershipChownFails(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "system")
+	defer os.RemoveAll(dir)
+
+	defer func() { chown = os.Chown }()
+	chown = func(path string, uid, gid int) error {
+		return &os.PathError{Op: "chown", Path: path, Err: syscall.EPERM}
+	}
+
+	err := SaveFileContentWithOwnership(logMock, filemanager.FileSystemImpl{}, filepath.Join(dir, "file.sh"), "content", FileOwnership{Owner: "1001"})
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "requires the agent to run as root")
+}
+
+func TestSaveFileContentWithOwnershipOfAgent(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "system")
+	defer os.RemoveAll(dir)
+	destination := filepath.Join(dir, "file.sh")
+	// changing the group to the current one is allowed without privileges
+	ownership := FileOwnership{Owner: strconv.Itoa(os.Getuid()), Group: strconv.Itoa(os.Getgid())}
+
+	err := SaveFileContentWithOwnership(logMock, filemanager.FileSystemImpl{}, destination, "content", ownership)
+
+	assert.NoError(t, err)
+	info, statErr := os.Stat(destination)
+	assert.NoError(t, statErr)
+	stat := info.Sys().(*syscall.Stat_t)
+	assert.Equal(t, uint32(os.Getuid()), stat.Uid)
+	assert.Equal(t, uint32(os.Getgid()), stat.Gid)
+}
+
+func TestSaveFileContentWithoutOwnership(t *testing.T) {
+	defer func() { chown = os.Chown }()
+	chown = func(path string, uid, gid int) error {
+		return errors.New("unexpected chown")
+	}
+
+	fileMock := filemock.FileSystemMock{}
+	fileMock.On("MakeDirs", "destinationDir").Return(nil)
+	fileMock.On("WriteFile", filepath.Join("destinationDir", "file"), "contents").Return(nil)
+
+	err := SaveFileContentWithOwnership(logMock, fileMock, filepath.Join("destinationDir", "file"), "contents", FileOwnership{})
+
+	assert.NoError(t, err)
+	fileMock.AssertExpectations(t)
+}
+
+func TestSaveFileContentWithMode(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "system")
+	defer os.RemoveAll(dir)
+	destination := filepath.Join(dir, "run.sh")
+
+	err := SaveFileContentWithMode(logMock, filemanager.FileSystemImpl{}, destination, "#!/bin/sh", FileOwnership{}, 0755)
+
+	assert.NoError(t, err)
+	info, statErr := os.Stat(destination)
+	assert.NoError(t, statErr)
+	assert.Equal(t, os.FileMode(0755), info.Mode().Perm())
+}
@@ -0,0 +1,45 @@
+// Copyright 2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+//
+// +build windows
+
+// Package system have all the files related dependencies used by the copy package
+package system
+
+import (
+	"os"
+	"syscall"
+
+	"github.com/aws/amazon-ssm-agent/agent/fileutil/filemanager"
+)
+
+const (
+	errorSharingViolation syscall.Errno = 32
+	errorLockViolation    syscall.Errno = 33
+)
+
+// isTransientErrno returns true for errors caused by another process holding the file, e.g. anti-virus scanners
+func isTransientErrno(errno syscall.Errno) bool {
+	return errno == errorSharingViolation || errno == errorLockViolation
+}
+
+// changeOwnership is a no-op, file ownership is not changed on Windows
+func changeOwnership(paths []string, ownership FileOwnership) error {
+	return nil
+}
+
+// changeMode is a no-op, file permissions are not changed on Windows
+func changeMode(filesysdep filemanager.FileSystem, path string, mode os.FileMode) error {
+	return nil
+}
@@ -24,6 +24,7 @@ import (
 	"unsafe"
 
 	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/aws/amazon-ssm-agent/agent/network"
 )
 
 // WinHttpIEProxyConfig represents the Internet Explorer proxy configuration information
@@ -115,7 +116,7 @@ func SetProxySettings(log log.T) {
 	var v = []string{}
 
 	for _, value := range ProxyEnvVariables {
-		v = append(v, value+":"+os.Getenv(value))
+		v = append(v, value+":"+network.RedactProxyURL(os.Getenv(value)))
 	}
 	log.Debugf("Current proxy environment variables: %v", strings.Join(v, ";"))
 	v = nil
@@ -184,7 +185,7 @@ func SetProxySettings(log log.T) {
 	}
 
 	for _, value := range ProxyEnvVariables {
-		v = append(v, value+":"+os.Getenv(value))
+		v = append(v, value+":"+network.RedactProxyURL(os.Getenv(value)))
 	}
 	log.Debugf("New proxy environment variables: %v", strings.Join(v, ";"))
 
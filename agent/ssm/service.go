@@ -69,6 +69,7 @@ type Service interface {
 	UpdateInstanceInformation(log log.T, agentVersion, agentStatus, agentName string) (response *ssm.UpdateInstanceInformationOutput, err error)
 	GetParameters(log log.T, paramNames []string) (response *ssm.GetParametersOutput, err error)
 	GetDecryptedParameters(log log.T, paramNames []string) (response *ssm.GetParametersOutput, err error)
+	DescribeParameters(log log.T, paramNames []string) (response *ssm.DescribeParametersOutput, err error)
 }
 
 var ssmStopPolicy *sdkutil.StopPolicy
@@ -482,3 +483,26 @@ func (svc *sdkService) GetDecryptedParameters(log log.T, paramNames []string) (r
 	}
 	return
 }
+
+// DescribeParameters returns the metadata, including the KMS key of secure strings, of the named parameters
+func (svc *sdkService) DescribeParameters(log log.T, paramNames []string) (response *ssm.DescribeParametersOutput, err error) {
+	serviceParams := ssm.DescribeParametersInput{
+		ParameterFilters: []*ssm.ParameterStringFilter{
+			{
+				Key:    aws.String("Name"),
+				Option: aws.String("Equals"),
+				Values: aws.StringSlice(paramNames),
+			},
+		},
+	}
+
+	log.Debugf("Calling DescribeParameters API with params - %v", serviceParams)
+
+	if response, err = svc.sdk.DescribeParameters(&serviceParams); err != nil {
+		errorString := fmt.Errorf("Encountered error while calling DescribeParameters API. Error: %v", err)
+		log.Debug(err)
+		sdkutil.HandleAwsError(log, err, ssmStopPolicy)
+		return nil, errorString
+	}
+	return
+}
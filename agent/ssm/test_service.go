@@ -139,6 +139,12 @@ func (m *Mock) GetDecryptedParameters(log log.T, paramNames []string) (response
 	return args.Get(0).(*ssm.GetParametersOutput), args.Error(1)
 }
 
+// DescribeParameters mocks the DescribeParameters function.
+func (m *Mock) DescribeParameters(log log.T, paramNames []string) (response *ssm.DescribeParametersOutput, err error) {
+	args := m.Called(log, paramNames)
+	return args.Get(0).(*ssm.DescribeParametersOutput), args.Error(1)
+}
+
 // PutComplianceItem mocks the PutComplianceItem function
 func (m *Mock) PutComplianceItems(
 	log log.T,